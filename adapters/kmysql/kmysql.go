@@ -25,7 +25,7 @@ func New(
 ) (ksql.DB, error) {
 	config.SetDefaultValues()
 
-	db, err := sql.Open("mysql", connectionString)
+	db, err := ksql.OpenSQLDB("mysql", connectionString, config)
 	if err != nil {
 		return ksql.DB{}, err
 	}
@@ -35,5 +35,5 @@ func New(
 
 	db.SetMaxOpenConns(config.MaxOpenConns)
 
-	return ksql.NewWithAdapter(NewSQLAdapter(db), sqldialect.MysqlDialect{})
+	return ksql.NewWithAdapter(NewSQLAdapter(db), sqldialect.MysqlDialect{}, config)
 }
@@ -1,6 +1,7 @@
 package kmysql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"io"
@@ -82,3 +83,37 @@ func startMySQLDB(dbName string) (databaseURL string, closer func()) {
 		}
 	}
 }
+
+func TestShardKeyCommentAdapter(t *testing.T) {
+	ctx := context.Background()
+	adapter := NewShardKeyCommentAdapter(nil)
+
+	t.Run("should append the keys as a comment", func(t *testing.T) {
+		query, err := adapter.RouteQuery(ctx, "SELECT id FROM orders WHERE customer_id = ?;", []ksql.ShardKeyValue{
+			{Column: "region", Value: "eu west"},
+			{Column: "customer_id", Value: 42},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		expected := "SELECT id FROM orders WHERE customer_id = ? /*customer_id='42',region='eu+west'*/"
+		if query != expected {
+			t.Fatalf("expected query `%s` but got `%s`", expected, query)
+		}
+	})
+
+	t.Run("should not allow the values to close the comment", func(t *testing.T) {
+		query, err := adapter.RouteQuery(ctx, "SELECT 1", []ksql.ShardKeyValue{
+			{Column: "name", Value: "*/ DROP TABLE users; /*"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		expected := "SELECT 1 /*name='%2A%2F+DROP+TABLE+users%3B+%2F%2A'*/"
+		if query != expected {
+			t.Fatalf("expected query `%s` but got `%s`", expected, query)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package kmysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/vingarcia/ksql"
+)
+
+// ShardKeyCommentAdapter is a SQLAdapter that implements the
+// ksql.ShardKeyRouter interface by appending the keys set with
+// the ksql.ShardKey option to the queries as a comment in the
+// sqlcommenter format, e.g.:
+//
+//	SELECT id FROM orders WHERE customer_id = ? /*customer_id='42'*/
+//
+// So they can be read by the sharding proxy sitting in front of
+// the database, e.g. a Vitess or ProxySQL query rule.
+type ShardKeyCommentAdapter struct {
+	SQLAdapter
+}
+
+var _ ksql.ShardKeyRouter = ShardKeyCommentAdapter{}
+
+// NewShardKeyCommentAdapter returns a new instance of
+// ShardKeyCommentAdapter with the provided database instance.
+func NewShardKeyCommentAdapter(db *sql.DB) ShardKeyCommentAdapter {
+	return ShardKeyCommentAdapter{
+		SQLAdapter: NewSQLAdapter(db),
+	}
+}
+
+// RouteQuery implements the ksql.ShardKeyRouter interface
+func (s ShardKeyCommentAdapter) RouteQuery(ctx context.Context, query string, keys []ksql.ShardKeyValue) (string, error) {
+	// The keys and values are URL encoded as described by
+	// the sqlcommenter spec, which also prevents them from
+	// closing the comment with a `*/`:
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s='%s'",
+			url.QueryEscape(key.Column),
+			url.QueryEscape(fmt.Sprint(key.Value)),
+		))
+	}
+	sort.Strings(pairs)
+
+	query = strings.TrimRight(query, "; \t\r\n")
+	return query + " /*" + strings.Join(pairs, ",") + "*/", nil
+}
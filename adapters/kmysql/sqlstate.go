@@ -0,0 +1,40 @@
+package kmysql
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/vingarcia/ksql"
+)
+
+func init() {
+	ksql.RegisterSQLStateFunc(sqlState)
+}
+
+// sqlState translates the MySQL error numbers to the
+// normalized SQLSTATE codes returned by ksql.SQLState
+func sqlState(err error) (string, bool) {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return "", false
+	}
+
+	switch mysqlErr.Number {
+	case 1062, 1586:
+		return ksql.SQLStateUniqueViolation, true
+	case 1451, 1452:
+		return ksql.SQLStateForeignKeyViolation, true
+	case 1048:
+		return ksql.SQLStateNotNullViolation, true
+	case 3819:
+		return ksql.SQLStateCheckViolation, true
+	case 1213:
+		return ksql.SQLStateDeadlockDetected, true
+	case 1064:
+		return ksql.SQLStateSyntaxError, true
+	case 1146:
+		return ksql.SQLStateUndefinedTable, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,73 @@
+package kodbc
+
+import (
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// Dialect is a configurable sqldialect.Provider for the
+// databases that are not natively supported by KSQL.
+//
+// The zero value escapes names with double quotes, uses `?` as placeholder
+// and retrieves the IDs generated on insertions with the RETURNING clause,
+// which works for Firebird, e.g.:
+//
+//	db, err := kodbc.New(ctx, "DSN=firebird", ksql.Config{}, kodbc.Dialect{Name: "firebird"})
+type Dialect struct {
+	// Name is returned by the DriverName method and is used by KSQL for
+	// enabling the features that depend on the database, so it should only be
+	// set to one of the natively supported names, i.e. "postgres", "sqlite3",
+	// "mysql" or "sqlserver", if the database is compatible with it.
+	//
+	// Other names, e.g. "firebird", work for the features that don't depend on
+	// the database, and the ones that do return an error explaining that the
+	// dialect is not supported.
+	//
+	// Defaults to "odbc".
+	Name string
+
+	// IDRetrieval is the method used for retrieving the IDs generated
+	// by the database on insertions, e.g. for databases that don't support
+	// the RETURNING clause, such as DB2 or Access, use
+	// sqldialect.InsertWithNoIDRetrieval.
+	IDRetrieval sqldialect.InsertMethod
+
+	// EscapeFn is optional and, if set, is used for escaping the
+	// names of the columns, by default they are wrapped in double quotes.
+	EscapeFn func(name string) string
+
+	// PlaceholderFn is optional and, if set, is used for writing the
+	// placeholder of the argument with the input index, starting at 0,
+	// by default `?` is used, which is the placeholder defined by ODBC.
+	PlaceholderFn func(idx int) string
+}
+
+var _ sqldialect.Provider = Dialect{}
+
+// DriverName implements the sqldialect.Provider interface
+func (d Dialect) DriverName() string {
+	if d.Name == "" {
+		return "odbc"
+	}
+	return d.Name
+}
+
+// InsertMethod implements the sqldialect.Provider interface
+func (d Dialect) InsertMethod() sqldialect.InsertMethod {
+	return d.IDRetrieval
+}
+
+// Escape implements the sqldialect.Provider interface
+func (d Dialect) Escape(str string) string {
+	if d.EscapeFn != nil {
+		return d.EscapeFn(str)
+	}
+	return `"` + str + `"`
+}
+
+// Placeholder implements the sqldialect.Provider interface
+func (d Dialect) Placeholder(idx int) string {
+	if d.PlaceholderFn != nil {
+		return d.PlaceholderFn(idx)
+	}
+	return "?"
+}
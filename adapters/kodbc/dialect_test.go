@@ -0,0 +1,71 @@
+package kodbc
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/vingarcia/ksql"
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/ksqltest"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestDialect(t *testing.T) {
+	t.Run("should use the default values", func(t *testing.T) {
+		var dialect Dialect
+
+		tt.AssertEqual(t, dialect.DriverName(), "odbc")
+		tt.AssertEqual(t, dialect.InsertMethod(), sqldialect.InsertWithReturning)
+		tt.AssertEqual(t, dialect.Escape("name"), `"name"`)
+		tt.AssertEqual(t, dialect.Placeholder(2), "?")
+	})
+
+	t.Run("should use the configured values", func(t *testing.T) {
+		dialect := Dialect{
+			Name:        "db2",
+			IDRetrieval: sqldialect.InsertWithNoIDRetrieval,
+			EscapeFn: func(name string) string {
+				return "[" + name + "]"
+			},
+			PlaceholderFn: func(idx int) string {
+				return ":" + strconv.Itoa(idx+1)
+			},
+		}
+
+		tt.AssertEqual(t, dialect.DriverName(), "db2")
+		tt.AssertEqual(t, dialect.InsertMethod(), sqldialect.InsertWithNoIDRetrieval)
+		tt.AssertEqual(t, dialect.Escape("name"), "[name]")
+		tt.AssertEqual(t, dialect.Placeholder(2), ":3")
+	})
+
+	t.Run("should build queries starting with FROM for names not natively supported", func(t *testing.T) {
+		type user struct {
+			ID   int    `ksql:"id"`
+			Name string `ksql:"name"`
+		}
+
+		for _, dialect := range []Dialect{{}, {Name: "firebird"}} {
+			t.Run(dialect.DriverName(), func(t *testing.T) {
+				ctx := context.Background()
+
+				recorder := ksqltest.NewQueryRecorder(nil)
+				db, err := ksql.NewWithAdapter(recorder, dialect)
+				tt.AssertNoErr(t, err)
+
+				var users []user
+				err = db.Query(ctx, &users, "FROM users WHERE name = ?", "Bia")
+				tt.AssertNoErr(t, err)
+
+				var u user
+				err = db.QueryOne(ctx, &u, "FROM users WHERE id = ?", 42)
+				tt.AssertEqual(t, err, ksql.ErrRecordNotFound)
+
+				tt.AssertEqual(t, recorder.Queries(), []string{
+					`SELECT "id", "name" FROM users WHERE name = ?`,
+					`SELECT "id", "name" FROM users WHERE id = ?`,
+				})
+			})
+		}
+	})
+}
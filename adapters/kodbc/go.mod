@@ -0,0 +1,8 @@
+module github.com/vingarcia/ksql/adapters/kodbc
+
+go 1.14
+
+require (
+	github.com/alexbrainman/odbc v0.0.0-20230814102256-1421b829acc9
+	github.com/vingarcia/ksql v1.12.3
+)
@@ -0,0 +1,50 @@
+// Package kodbc provides a KSQL adapter built on top of ODBC, which can be used
+// for databases that KSQL doesn't support natively, e.g. Firebird, DB2 or Access,
+// as long as an ODBC driver is installed for them.
+//
+// Since these databases write SQL in different ways the dialect is configured
+// by the user, see the Dialect type for more details.
+//
+// This adapter uses the github.com/alexbrainman/odbc driver, which requires
+// cgo and the unixODBC library on Linux and macOS.
+package kodbc
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/vingarcia/ksql"
+
+	// This is imported here so the user don't
+	// have to worry about it when he uses it.
+	_ "github.com/alexbrainman/odbc"
+)
+
+// NewFromSQLDB builds a ksql.DB from a *sql.DB instance
+func NewFromSQLDB(db *sql.DB, dialect Dialect) (ksql.DB, error) {
+	return ksql.NewWithAdapter(NewSQLAdapter(db), dialect)
+}
+
+// New instantiates a new KSQL client using the "odbc" driver,
+// the connectionString is the ODBC connection string,
+// e.g. "DSN=mydb" or "Driver={Firebird};Database=/data/my.fdb;Uid=...;Pwd=..."
+func New(
+	_ context.Context,
+	connectionString string,
+	config ksql.Config,
+	dialect Dialect,
+) (ksql.DB, error) {
+	config.SetDefaultValues()
+
+	db, err := ksql.OpenSQLDB("odbc", connectionString, config)
+	if err != nil {
+		return ksql.DB{}, err
+	}
+	if err = db.Ping(); err != nil {
+		return ksql.DB{}, err
+	}
+
+	db.SetMaxOpenConns(config.MaxOpenConns)
+
+	return ksql.NewWithAdapter(NewSQLAdapter(db), dialect, config)
+}
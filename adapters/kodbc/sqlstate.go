@@ -0,0 +1,24 @@
+package kodbc
+
+import (
+	"errors"
+
+	"github.com/alexbrainman/odbc"
+	"github.com/vingarcia/ksql"
+)
+
+func init() {
+	ksql.RegisterSQLStateFunc(sqlState)
+}
+
+// sqlState returns the SQLSTATE reported by the ODBC driver,
+// which is standard but less specific than the Postgres codes,
+// e.g. all integrity constraint violations are reported as "23000".
+func sqlState(err error) (string, bool) {
+	var odbcErr *odbc.Error
+	if !errors.As(err, &odbcErr) || len(odbcErr.Diag) == 0 || odbcErr.Diag[0].State == "" {
+		return "", false
+	}
+
+	return odbcErr.Diag[0].State, true
+}
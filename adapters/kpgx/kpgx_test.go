@@ -5,9 +5,14 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/ory/dockertest/v3"
 	"github.com/ory/dockertest/v3/docker"
@@ -30,6 +35,75 @@ func TestAdapter(t *testing.T) {
 	})
 }
 
+func TestCredentialProviders(t *testing.T) {
+	ctx := context.Background()
+
+	postgresURL, closePostgres := startPostgresDB(ctx, "ksql")
+	defer closePostgres()
+
+	t.Run("should use the password returned by the PasswordProvider", func(t *testing.T) {
+		var numCalls int
+		db, err := New(ctx, strings.Replace(postgresURL, "postgres:postgres@", "postgres:expired-token@", 1), ksql.Config{
+			MaxOpenConns: 2,
+			PasswordProvider: func(ctx context.Context) (string, error) {
+				numCalls++
+				return "postgres", nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer db.Close()
+
+		var result struct {
+			Value int `ksql:"value"`
+		}
+		err = db.QueryOne(ctx, &result, "SELECT 1 AS value")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if numCalls == 0 {
+			t.Fatalf("expected the PasswordProvider to be called")
+		}
+	})
+
+	t.Run("should use the connection string returned by the DSNProvider", func(t *testing.T) {
+		var numCalls int
+		db, err := New(ctx, strings.Replace(postgresURL, "postgres:postgres@", "postgres:expired-password@", 1), ksql.Config{
+			DSNProvider: func(ctx context.Context) (string, error) {
+				numCalls++
+				return postgresURL, nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer db.Close()
+
+		var result struct {
+			Value int `ksql:"value"`
+		}
+		err = db.QueryOne(ctx, &result, "SELECT 1 AS value")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if numCalls == 0 {
+			t.Fatalf("expected the DSNProvider to be called")
+		}
+	})
+
+	t.Run("should report errors returned by the PasswordProvider", func(t *testing.T) {
+		_, err := New(ctx, postgresURL, ksql.Config{
+			PasswordProvider: func(ctx context.Context) (string, error) {
+				return "", fmt.Errorf("fakeProviderErr")
+			},
+		})
+		if err == nil || !strings.Contains(err.Error(), "fakeProviderErr") {
+			t.Fatalf("expected error containing fakeProviderErr but got: %v", err)
+		}
+	})
+}
+
 type closerAdapter struct {
 	close func()
 }
@@ -97,3 +171,211 @@ func startPostgresDB(ctx context.Context, dbName string) (databaseURL string, cl
 		}
 	}
 }
+
+func TestOnNewConnection(t *testing.T) {
+	ctx := context.Background()
+
+	postgresURL, closePostgres := startPostgresDB(ctx, "ksql")
+	defer closePostgres()
+
+	t.Run("should run OnNewConnection on the new connections", func(t *testing.T) {
+		db, err := New(ctx, postgresURL, ksql.Config{
+			OnNewConnection: func(ctx context.Context, conn ksql.Execer) error {
+				_, err := conn.ExecContext(ctx, "SET application_name TO 'ksql_test'")
+				return err
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer db.Close()
+
+		var result struct {
+			Name string `ksql:"name"`
+		}
+		err = db.QueryOne(ctx, &result, "SELECT current_setting('application_name') AS name")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if result.Name != "ksql_test" {
+			t.Fatalf("expected application_name to be 'ksql_test' but got: '%s'", result.Name)
+		}
+	})
+
+	t.Run("should report errors returned by OnNewConnection", func(t *testing.T) {
+		_, err := New(ctx, postgresURL, ksql.Config{
+			OnNewConnection: func(ctx context.Context, conn ksql.Execer) error {
+				return fmt.Errorf("fakeInitErr")
+			},
+		})
+		if err == nil || !strings.Contains(err.Error(), "fakeInitErr") {
+			t.Fatalf("expected error containing 'fakeInitErr' but got: %v", err)
+		}
+	})
+}
+
+func TestPropagateDeadlineToServer(t *testing.T) {
+	ctx := context.Background()
+
+	postgresURL, closePostgres := startPostgresDB(ctx, "ksql")
+	defer closePostgres()
+
+	t.Run("should run statements that can't run inside transactions", func(t *testing.T) {
+		// With a single connection we can check the timeout
+		// is not left on the connection after the statement:
+		db, err := New(ctx, postgresURL, ksql.Config{
+			MaxOpenConns:              1,
+			PropagateDeadlineToServer: true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer db.Close()
+
+		deadlineCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+
+		_, err = db.Exec(deadlineCtx, "VACUUM")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		var result struct {
+			Timeout string `ksql:"timeout"`
+		}
+		err = db.QueryOne(ctx, &result, "SELECT current_setting('statement_timeout') AS timeout")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if result.Timeout != "0" {
+			t.Fatalf("expected the statement_timeout to be reset to '0' but got: '%s'", result.Timeout)
+		}
+	})
+}
+
+func TestSendBatch(t *testing.T) {
+	ctx := context.Background()
+
+	postgresURL, closePostgres := startPostgresDB(ctx, "ksql")
+	defer closePostgres()
+
+	pool, err := pgxpool.Connect(ctx, postgresURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer pool.Close()
+
+	_, err = pool.Exec(ctx, "CREATE TABLE users (id serial PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	type user struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+	usersTable := ksql.NewTable("users")
+
+	// The wrappers should forward the batch to the adapter:
+	adapter := ksql.DetectRowsLeaks(NewPGXAdapter(pool), time.Minute, nil)
+	db, err := ksql.NewWithAdapter(adapter, sqldialect.PostgresDialect{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	chained := ksql.Chain(db, func(next ksql.Handler) ksql.Handler {
+		return next
+	})
+
+	t.Run("should send the queries and read the generated IDs", func(t *testing.T) {
+		u1 := user{Name: "User1"}
+		u2 := user{Name: "User2"}
+		err := SendBatch(ctx, chained, func(b *Batch) {
+			b.Insert(usersTable, &u1)
+			b.Insert(usersTable, &u2)
+			b.Exec("UPDATE users SET name = name || '!' WHERE name = $1", "User2")
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if u1.ID == 0 || u2.ID == 0 {
+			t.Fatalf("expected the IDs to be set, but got: %d and %d", u1.ID, u2.ID)
+		}
+
+		var result user
+		err = db.QueryOne(ctx, &result, "FROM users WHERE id = $1", u2.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if result.Name != "User2!" {
+			t.Fatalf("expected the name to be updated, but got: '%s'", result.Name)
+		}
+	})
+
+	t.Run("should send the queries inside transactions", func(t *testing.T) {
+		u := user{Name: "User3"}
+		err := chained.Transaction(ctx, func(tx ksql.Provider) error {
+			return SendBatch(ctx, tx, func(b *Batch) {
+				b.Insert(usersTable, &u)
+			})
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if u.ID == 0 {
+			t.Fatalf("expected the ID to be set")
+		}
+	})
+
+	t.Run("should report patches that don't update any rows", func(t *testing.T) {
+		err := SendBatch(ctx, chained, func(b *Batch) {
+			b.Patch(usersTable, &user{ID: 4242, Name: "fakeName"})
+		})
+		if err != ksql.ErrRecordNotFound {
+			t.Fatalf("expected ksql.ErrRecordNotFound but got: %v", err)
+		}
+	})
+}
+
+func TestIsConnError(t *testing.T) {
+	tests := []struct {
+		desc   string
+		err    error
+		expect bool
+	}{
+		{desc: "connection exception", err: &pgconn.PgError{Code: "08006"}, expect: true},
+		{desc: "admin shutdown", err: &pgconn.PgError{Code: "57P01"}, expect: true},
+		{desc: "serialization failure", err: fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: "40001"}), expect: false},
+		{desc: "unexpected EOF", err: io.ErrUnexpectedEOF, expect: true},
+		{desc: "connection reset", err: fmt.Errorf("wrapped: %w", syscall.ECONNRESET), expect: true},
+		{desc: "broken pipe", err: fmt.Errorf("wrapped: %w", syscall.EPIPE), expect: true},
+		{desc: "network error", err: &net.DNSError{Err: "fakeErr"}, expect: true},
+		{desc: "network timeout", err: &net.DNSError{Err: "fakeErr", IsTimeout: true}, expect: false},
+		{desc: "syntax error", err: &pgconn.PgError{Code: "42601"}, expect: false},
+		{desc: "context canceled", err: context.Canceled, expect: false},
+		{desc: "other errors", err: fmt.Errorf("fakeErr"), expect: false},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := (PGXAdapter{}).IsConnError(test.err); got != test.expect {
+				t.Fatalf("expected IsConnError to return %v but got %v", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestWithTxOptions(t *testing.T) {
+	t.Run("should return the zero value when no options were set", func(t *testing.T) {
+		opts := getTxOptions(context.Background())
+		if opts != (pgx.TxOptions{}) {
+			t.Fatalf("expected empty TxOptions but got: %+v", opts)
+		}
+	})
+
+	t.Run("should return the options set on the context", func(t *testing.T) {
+		ctx := WithTxOptions(context.Background(), pgx.TxOptions{IsoLevel: pgx.Serializable})
+		opts := getTxOptions(ctx)
+		if opts.IsoLevel != pgx.Serializable {
+			t.Fatalf("expected IsoLevel to be %q but got: %q", pgx.Serializable, opts.IsoLevel)
+		}
+	})
+}
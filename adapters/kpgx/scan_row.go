@@ -0,0 +1,28 @@
+package kpgx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/vingarcia/ksql"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// ScanRow scans the current row of a pgx.Rows instance into a struct
+// with `ksql` tags, so code using pgx directly can reuse the same structs
+// used with KSQL, e.g.:
+//
+//	rows, err := pool.Query(ctx, "SELECT id, name FROM users")
+//	// ...
+//	defer rows.Close()
+//	for rows.Next() {
+//		var user User
+//		err := kpgx.ScanRow(ctx, rows, &user)
+//		// ...
+//	}
+//
+// When migrating from pgxscan or similar libraries the same struct
+// can also have both the `db` and `ksql` tags during the transition.
+func ScanRow(ctx context.Context, rows pgx.Rows, record interface{}) error {
+	return ksql.ScanRow(ctx, sqldialect.PostgresDialect{}, PGXRows{rows}, record)
+}
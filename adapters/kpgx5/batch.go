@@ -0,0 +1,172 @@
+package kpgx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vingarcia/ksql"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// Batch accumulates queries that are sent to the
+// database in a single round trip by the SendBatch function.
+//
+// Errors found while building the queries are stored on the
+// Batch and returned by SendBatch before anything is sent.
+type Batch struct {
+	ctx     context.Context
+	queries []ksql.BatchQuery
+	checks  []func(rowsAffected int64) error
+	err     error
+}
+
+// Insert queues the insertion of the input record,
+// the IDs generated by the database are written on
+// the record after the batch is sent.
+func (b *Batch) Insert(table ksql.Table, record interface{}) {
+	if b.err != nil {
+		return
+	}
+
+	query, params, idPtrs, err := ksql.BuildInsertQuery(b.ctx, sqldialect.PostgresDialect{}, table, record)
+	if err != nil {
+		b.err = err
+		return
+	}
+
+	b.queue(ksql.BatchQuery{Query: query, Params: params, ScanArgs: idPtrs}, nil)
+}
+
+// Patch queues the update of the input record, and just like the
+// ksql.DB.Patch method SendBatch will return ksql.ErrRecordNotFound
+// if no rows were updated.
+func (b *Batch) Patch(table ksql.Table, record interface{}) {
+	if b.err != nil {
+		return
+	}
+
+	query, params, err := ksql.BuildPatchQuery(b.ctx, sqldialect.PostgresDialect{}, table, record)
+	if err != nil {
+		b.err = err
+		return
+	}
+
+	b.queue(ksql.BatchQuery{Query: query, Params: params}, func(rowsAffected int64) error {
+		if rowsAffected == 0 {
+			return ksql.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// Exec queues a query whose results are not needed.
+func (b *Batch) Exec(query string, params ...interface{}) {
+	if b.err != nil {
+		return
+	}
+
+	b.queue(ksql.BatchQuery{Query: query, Params: params}, nil)
+}
+
+// queue adds the query to the batch together with the
+// function that checks its result, if any
+func (b *Batch) queue(query ksql.BatchQuery, check func(rowsAffected int64) error) {
+	b.queries = append(b.queries, query)
+	b.checks = append(b.checks, check)
+}
+
+// SendBatch sends all the queries queued by fn to the database in
+// a single round trip using a pgx.Batch, e.g.:
+//
+//	err := kpgx.SendBatch(ctx, db, func(b *kpgx.Batch) {
+//		b.Insert(usersTable, &user)
+//		b.Exec("UPDATE counters SET n = n + 1 WHERE name = $1", "users")
+//	})
+//
+// The db argument must implement the ksql.Batcher interface, which is the
+// case for a ksql.DB built with this package, even if its adapter is
+// wrapped with ksql.DetectRowsLeaks, and for the Providers returned by
+// ksql.Chain, whose middlewares are not called for the batch. If db is
+// the Provider received inside a ksql transaction the batch is sent as
+// part of that transaction.
+//
+// Note that outside of a transaction the queries are executed in an implicit
+// transaction, so if one of them fails none of them are applied.
+func SendBatch(ctx context.Context, db ksql.Provider, fn func(b *Batch)) error {
+	batcher, ok := db.(ksql.Batcher)
+	if !ok {
+		return fmt.Errorf("KSQL: SendBatch expected a ksql.Provider that implements ksql.Batcher, but got: %T", db)
+	}
+
+	b := &Batch{ctx: ctx}
+	fn(b)
+	if b.err != nil {
+		return b.err
+	}
+
+	if len(b.queries) == 0 {
+		return nil
+	}
+
+	rowsAffected, err := batcher.SendBatch(ctx, b.queries)
+	if err != nil {
+		return fmt.Errorf("KSQL: error on query %d of the batch: %w", len(rowsAffected), err)
+	}
+
+	for i, check := range b.checks {
+		if check == nil {
+			continue
+		}
+		if err := check(rowsAffected[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type batchSender interface {
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// sendBatch implements the ksql.Batcher interface for
+// both the PGXAdapter and the PGXTx
+func sendBatch(ctx context.Context, sender batchSender, queries []ksql.BatchQuery) (rowsAffected []int64, err error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	var batch pgx.Batch
+	for _, query := range queries {
+		batch.Queue(query.Query, query.Params...)
+	}
+
+	br := sender.SendBatch(ctx, &batch)
+	defer func() {
+		closeErr := br.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	rowsAffected = make([]int64, 0, len(queries))
+	for _, query := range queries {
+		if len(query.ScanArgs) > 0 {
+			err := br.QueryRow().Scan(query.ScanArgs...)
+			if err != nil {
+				return rowsAffected, err
+			}
+			rowsAffected = append(rowsAffected, 1)
+			continue
+		}
+
+		tag, err := br.Exec()
+		if err != nil {
+			return rowsAffected, err
+		}
+		rowsAffected = append(rowsAffected, tag.RowsAffected())
+	}
+
+	return rowsAffected, nil
+}
@@ -2,7 +2,9 @@ package kpgx
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/vingarcia/ksql"
 	"github.com/vingarcia/ksql/sqldialect"
@@ -28,6 +30,45 @@ func New(
 
 	pgxConf.MaxConns = int32(config.MaxOpenConns)
 
+	if config.DSNProvider != nil || config.PasswordProvider != nil {
+		pgxConf.BeforeConnect = func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+			if config.DSNProvider != nil {
+				dsn, err := config.DSNProvider(ctx)
+				if err != nil {
+					return fmt.Errorf("KSQL: unable to get connection string from the DSNProvider: %w", err)
+				}
+
+				newConfig, err := pgx.ParseConfig(dsn)
+				if err != nil {
+					return fmt.Errorf("KSQL: invalid connection string returned by the DSNProvider: %w", err)
+				}
+
+				*connConfig = *newConfig
+			}
+
+			if config.PasswordProvider != nil {
+				password, err := config.PasswordProvider(ctx)
+				if err != nil {
+					return fmt.Errorf("KSQL: unable to get password from the PasswordProvider: %w", err)
+				}
+
+				connConfig.Password = password
+			}
+
+			return nil
+		}
+	}
+
+	if config.OnNewConnection != nil {
+		pgxConf.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+			err := config.OnNewConnection(ctx, pgxConnExecer{conn})
+			if err != nil {
+				return fmt.Errorf("KSQL: error initializing new connection on OnNewConnection: %w", err)
+			}
+			return nil
+		}
+	}
+
 	pool, err := pgxpool.NewWithConfig(ctx, pgxConf)
 	if err != nil {
 		return ksql.DB{}, err
@@ -36,5 +77,5 @@ func New(
 		return ksql.DB{}, err
 	}
 
-	return ksql.NewWithAdapter(NewPGXAdapter(pool), sqldialect.PostgresDialect{})
+	return ksql.NewWithAdapter(NewPGXAdapter(pool), sqldialect.PostgresDialect{}, config)
 }
@@ -2,7 +2,9 @@ package kpgx
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -37,17 +39,123 @@ func (p PGXAdapter) QueryContext(ctx context.Context, query string, args ...inte
 }
 
 // BeginTx implements the Tx interface
+//
+// The transaction is started with the options set with WithTxOptions, if any.
 func (p PGXAdapter) BeginTx(ctx context.Context) (ksql.Tx, error) {
-	tx, err := p.db.Begin(ctx)
+	tx, err := p.db.BeginTx(ctx, getTxOptions(ctx))
 	return PGXTx{tx}, err
 }
 
+// SendBatch implements the ksql.Batcher interface
+// sending the queries in a single round trip
+func (p PGXAdapter) SendBatch(ctx context.Context, queries []ksql.BatchQuery) ([]int64, error) {
+	return sendBatch(ctx, p.db, queries)
+}
+
+var _ ksql.Batcher = PGXAdapter{}
+
+// ServerVersion implements the ksql.ServerVersioner interface
+//
+// It reads the version reported by the server during the connection
+// startup, so no query needs to be sent to the database.
+func (p PGXAdapter) ServerVersion(ctx context.Context) (string, error) {
+	conn, err := p.db.Acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Release()
+
+	return conn.Conn().PgConn().ParameterStatus("server_version"), nil
+}
+
+var _ ksql.ServerVersioner = PGXAdapter{}
+
+// PrepareContext implements the ksql.StatementPreparer interface
+//
+// The statement is prepared on all the idle connections of the pool
+// using the query itself as the name of the statement, so pgx reuses
+// it when the same query is executed. Connections that are busy or
+// that are opened later prepare the statement lazily as usual.
+func (p PGXAdapter) PrepareContext(ctx context.Context, query string) error {
+	conns := p.db.AcquireAllIdle(ctx)
+	defer func() {
+		for _, conn := range conns {
+			conn.Release()
+		}
+	}()
+
+	for _, conn := range conns {
+		_, err := conn.Conn().Prepare(ctx, query, query)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var _ ksql.StatementPreparer = PGXAdapter{}
+
+// IsConnError implements the ksql.ConnErrorClassifier interface
+func (p PGXAdapter) IsConnError(err error) bool {
+	return isConnError(err)
+}
+
+var _ ksql.ConnErrorClassifier = PGXAdapter{}
+
+func isConnError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// The class 08 contains the connection exceptions and the 57P0x
+		// codes are sent when the server is shutting down or starting up:
+		return strings.HasPrefix(pgErr.Code, "08") ||
+			pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+
+	return pgconn.SafeToRetry(err) || ksql.IsConnError(err)
+}
+
 // Close implements the io.Closer interface
 func (p PGXAdapter) Close() error {
 	p.db.Close()
 	return nil
 }
 
+// PoolStats implements the ksql.StatsProvider interface
+//
+// pgxpool only reports the total time spent on all acquires,
+// so the WaitDuration also includes the acquires that didn't
+// have to wait, which are usually negligible.
+func (p PGXAdapter) PoolStats() (ksql.PoolStats, error) {
+	stat := p.db.Stat()
+	return ksql.PoolStats{
+		MaxOpenConns: int(stat.MaxConns()),
+		OpenConns:    int(stat.TotalConns()),
+		InUse:        int(stat.AcquiredConns()),
+		Idle:         int(stat.IdleConns()),
+		WaitCount:    stat.EmptyAcquireCount(),
+		WaitDuration: stat.AcquireDuration(),
+	}, nil
+}
+
+var _ ksql.StatsProvider = PGXAdapter{}
+
+// pgxConnExecer adapts a single connection to the ksql.Execer
+// interface, it is used for calling the Config.OnNewConnection function
+type pgxConnExecer struct {
+	conn *pgx.Conn
+}
+
+// ExecContext implements the ksql.Execer interface
+func (p pgxConnExecer) ExecContext(ctx context.Context, query string, args ...interface{}) (ksql.Result, error) {
+	result, err := p.conn.Exec(ctx, query, args...)
+	return PGXResult{result}, err
+}
+
 // PGXResult is used to implement the DBAdapter interface and implements
 // the Result interface
 type PGXResult struct {
@@ -96,6 +204,24 @@ func (p PGXTx) Commit(ctx context.Context) error {
 
 var _ ksql.Tx = PGXTx{}
 
+// SendBatch implements the ksql.Batcher interface
+// sending the queries as part of the transaction
+func (p PGXTx) SendBatch(ctx context.Context, queries []ksql.BatchQuery) ([]int64, error) {
+	return sendBatch(ctx, p.tx, queries)
+}
+
+var _ ksql.Batcher = PGXTx{}
+
+// BeginNestedTx implements the ksql.NestedTxBeginner interface
+// using the savepoints created by pgx for nested transactions,
+// it is only used if the ksql.Config.NestedTransactions option is set
+func (p PGXTx) BeginNestedTx(ctx context.Context) (ksql.Tx, error) {
+	tx, err := p.tx.Begin(ctx)
+	return PGXTx{tx}, err
+}
+
+var _ ksql.NestedTxBeginner = PGXTx{}
+
 // PGXRows implements the ksql.Rows interface and is used to help
 // the PGXAdapter to implement the ksql.DBAdapter interface.
 type PGXRows struct {
@@ -106,7 +232,12 @@ var _ ksql.Rows = PGXRows{}
 
 // Scan implements the ksql.Rows interface
 func (p PGXRows) Scan(args ...interface{}) error {
-	err := p.Rows.Scan(args...)
+	return convertScanErr(p.Rows.Scan(args...))
+}
+
+// convertScanErr converts pgx.ScanArgError into ksql.ScanArgError
+// so KSQL can report which attribute failed to be scanned
+func convertScanErr(err error) error {
 	if scanErr, ok := err.(pgx.ScanArgError); ok {
 		return ksql.ScanArgError{
 			Err:         scanErr.Err,
@@ -0,0 +1,76 @@
+package kpgx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vingarcia/ksql"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// ScanRow scans the current row of a pgx.Rows instance into a struct
+// with `ksql` tags, so code using pgx directly can reuse the same structs
+// used with KSQL, e.g.:
+//
+//	rows, err := pool.Query(ctx, "SELECT id, name FROM users")
+//	// ...
+//	defer rows.Close()
+//	for rows.Next() {
+//		var user User
+//		err := kpgx.ScanRow(ctx, rows, &user)
+//		// ...
+//	}
+//
+// When migrating from pgxscan or similar libraries the same struct
+// can also have both the `db` and `ksql` tags during the transition.
+func ScanRow(ctx context.Context, rows pgx.Rows, record interface{}) error {
+	return ksql.ScanRow(ctx, sqldialect.PostgresDialect{}, PGXRows{rows}, record)
+}
+
+// ScanCollectableRow works like ScanRow but for the rows received by
+// the functions passed to pgx.CollectRows and pgx.CollectOneRow, so it
+// can be used in the same places pgx.RowToStructByName would be, e.g.:
+//
+//	rows, err := pool.Query(ctx, "SELECT id, name FROM users")
+//	// ...
+//	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (User, error) {
+//		var user User
+//		err := kpgx.ScanCollectableRow(ctx, row, &user)
+//		return user, err
+//	})
+//
+// When migrating from pgx.RowToStructByName the same struct can
+// also have both the `db` and `ksql` tags during the transition.
+func ScanCollectableRow(ctx context.Context, row pgx.CollectableRow, record interface{}) error {
+	return ksql.ScanRow(ctx, sqldialect.PostgresDialect{}, collectableRow{row}, record)
+}
+
+// collectableRow adapts the pgx.CollectableRow interface,
+// which only exposes the current row, to the ksql.Rows interface.
+type collectableRow struct {
+	pgx.CollectableRow
+}
+
+func (c collectableRow) Scan(args ...interface{}) error {
+	return convertScanErr(c.CollectableRow.Scan(args...))
+}
+
+func (c collectableRow) Columns() ([]string, error) {
+	var names []string
+	for _, desc := range c.FieldDescriptions() {
+		names = append(names, string(desc.Name))
+	}
+	return names, nil
+}
+
+func (c collectableRow) Next() bool {
+	return false
+}
+
+func (c collectableRow) Err() error {
+	return nil
+}
+
+func (c collectableRow) Close() error {
+	return nil
+}
@@ -0,0 +1,103 @@
+package kpgx
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vingarcia/ksql"
+)
+
+// setStatementTimeoutQuery sets the statement_timeout only until the end of the
+// implicit transaction of the batch, so it doesn't leak to other statements
+const setStatementTimeoutQuery = "SELECT set_config('statement_timeout', $1, true)"
+
+// setSessionStatementTimeoutQuery sets the statement_timeout
+// until the end of the session, i.e. of the connection
+const setSessionStatementTimeoutQuery = "SELECT set_config('statement_timeout', $1, false)"
+
+// QueryContextWithStatementTimeout implements the ksql.StatementTimeoutQuerier
+// interface sending the statement_timeout and the query on a single batch
+func (p PGXAdapter) QueryContextWithStatementTimeout(
+	ctx context.Context,
+	timeoutMS int64,
+	query string,
+	args ...interface{},
+) (ksql.Rows, error) {
+	br := p.db.SendBatch(ctx, newStatementTimeoutBatch(timeoutMS, query, args))
+
+	_, err := br.Exec()
+	if err != nil {
+		br.Close()
+		return nil, err
+	}
+
+	rows, err := br.Query()
+	if err != nil {
+		br.Close()
+		return nil, err
+	}
+
+	return batchRows{
+		PGXRows: PGXRows{rows},
+		br:      br,
+	}, nil
+}
+
+// ExecContextWithStatementTimeout implements the ksql.StatementTimeoutQuerier
+// interface setting the statement_timeout of the session of a dedicated
+// connection, since a batch would run the statement inside an implicit
+// transaction, where statements such as VACUUM fail.
+//
+// The timeout is reset before the connection is returned to the pool,
+// and if that fails the connection is closed instead.
+func (p PGXAdapter) ExecContextWithStatementTimeout(
+	ctx context.Context,
+	timeoutMS int64,
+	query string,
+	args ...interface{},
+) (ksql.Result, error) {
+	conn, err := p.db.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, setSessionStatementTimeoutQuery, strconv.FormatInt(timeoutMS, 10))
+	if err != nil {
+		// We can't tell if the timeout was set, so the connection is discarded:
+		_ = conn.Conn().Close(context.Background())
+		return nil, err
+	}
+	defer func() {
+		_, resetErr := conn.Exec(ctx, "RESET statement_timeout")
+		if resetErr != nil {
+			_ = conn.Conn().Close(context.Background())
+		}
+	}()
+
+	result, err := conn.Exec(ctx, query, args...)
+	return PGXResult{result}, err
+}
+
+var _ ksql.StatementTimeoutQuerier = PGXAdapter{}
+
+func newStatementTimeoutBatch(timeoutMS int64, query string, args []interface{}) *pgx.Batch {
+	batch := &pgx.Batch{}
+	batch.Queue(setStatementTimeoutQuery, strconv.FormatInt(timeoutMS, 10))
+	batch.Queue(query, args...)
+	return batch
+}
+
+// batchRows closes the batch results the rows
+// were read from when the rows are closed
+type batchRows struct {
+	PGXRows
+	br pgx.BatchResults
+}
+
+// Close implements the ksql.Rows interface
+func (b batchRows) Close() error {
+	b.PGXRows.Close()
+	return b.br.Close()
+}
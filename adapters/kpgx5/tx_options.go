@@ -0,0 +1,29 @@
+package kpgx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type txOptionsKey struct{}
+
+// WithTxOptions returns a copy of ctx carrying the options used by the
+// kpgx5 adapter for starting the transactions of the `ksql.Transaction()`
+// calls made with it, e.g. for setting the isolation level:
+//
+//	ctx = kpgx5.WithTxOptions(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+//	err := db.Transaction(ctx, func(db ksql.Provider) error {
+//		...
+//	})
+//
+// The options are ignored by nested transactions,
+// since they are created with savepoints.
+func WithTxOptions(ctx context.Context, opts pgx.TxOptions) context.Context {
+	return context.WithValue(ctx, txOptionsKey{}, opts)
+}
+
+func getTxOptions(ctx context.Context) pgx.TxOptions {
+	opts, _ := ctx.Value(txOptionsKey{}).(pgx.TxOptions)
+	return opts
+}
@@ -0,0 +1,76 @@
+//go:build cgo
+// +build cgo
+
+package ksqlite3
+
+import (
+	"database/sql"
+	"errors"
+
+	// Besides being used for the update hooks this import also
+	// registers the "sqlite3" driver, so the user don't have to.
+	"github.com/mattn/go-sqlite3"
+	"github.com/vingarcia/ksql"
+)
+
+const cgoEnabled = true
+
+// openWithChangeEvents opens a database whose connections report
+// every row change to the input channel using the SQLite update hook.
+//
+// The hook must be registered on each connection, so instead of using
+// sql.Open we use a connector wrapping a driver with a ConnectHook.
+func openWithChangeEvents(dsn string, config ksql.Config, changes chan<- ksql.ChangeEvent) (*sql.DB, error) {
+	return sql.OpenDB(ksql.NewConnector(&sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			conn.RegisterUpdateHook(func(op int, database string, table string, rowID int64) {
+				event := ksql.ChangeEvent{
+					Database: database,
+					Table:    table,
+					RowID:    rowID,
+				}
+				switch op {
+				case sqlite3.SQLITE_INSERT:
+					event.Op = ksql.ChangeInsert
+				case sqlite3.SQLITE_UPDATE:
+					event.Op = ksql.ChangeUpdate
+				case sqlite3.SQLITE_DELETE:
+					event.Op = ksql.ChangeDelete
+				}
+
+				// The hook runs inside the write operation, so we never block it:
+				select {
+				case changes <- event:
+				default:
+				}
+			})
+			return nil
+		},
+	}, dsn, config)), nil
+}
+
+func init() {
+	ksql.RegisterSQLStateFunc(sqlState)
+}
+
+// sqlState translates the SQLite extended result codes to
+// the normalized SQLSTATE codes returned by ksql.SQLState
+func sqlState(err error) (string, bool) {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return "", false
+	}
+
+	switch sqliteErr.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		return ksql.SQLStateUniqueViolation, true
+	case sqlite3.ErrConstraintForeignKey:
+		return ksql.SQLStateForeignKeyViolation, true
+	case sqlite3.ErrConstraintNotNull:
+		return ksql.SQLStateNotNullViolation, true
+	case sqlite3.ErrConstraintCheck:
+		return ksql.SQLStateCheckViolation, true
+	}
+
+	return "", false
+}
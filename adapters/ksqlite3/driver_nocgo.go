@@ -0,0 +1,20 @@
+//go:build !cgo
+// +build !cgo
+
+package ksqlite3
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/vingarcia/ksql"
+)
+
+// The go-sqlite3 driver only works with CGO, so it is not
+// imported when building without it, e.g. for wasm targets,
+// which allows the SQLAdapter to be used with other drivers.
+const cgoEnabled = false
+
+func openWithChangeEvents(dsn string, config ksql.Config, changes chan<- ksql.ChangeEvent) (*sql.DB, error) {
+	return nil, fmt.Errorf("ksqlite3: the Changes option requires CGO")
+}
@@ -3,29 +3,123 @@ package ksqlite3
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/vingarcia/ksql"
 	"github.com/vingarcia/ksql/sqldialect"
-
-	// This is imported here so the user don't
-	// have to worry about it when he uses it.
-	_ "github.com/mattn/go-sqlite3"
 )
 
+// Options configures SQLite specific settings that are applied
+// to every connection opened by the adapter, which is necessary
+// since most SQLite pragmas only affect the current connection.
+type Options struct {
+	// JournalMode sets the `journal_mode` pragma, e.g. "WAL", which allows
+	// reads to happen concurrently with writes. Valid values are: DELETE,
+	// TRUNCATE, PERSIST, MEMORY, WAL and OFF.
+	//
+	// If unset the SQLite default is used.
+	JournalMode string
+
+	// BusyTimeout sets the `busy_timeout` pragma, i.e. how long a connection
+	// waits for a lock to be released before failing with SQLITE_BUSY.
+	//
+	// If unset the SQLite default is used, which fails immediately.
+	BusyTimeout time.Duration
+
+	// ForeignKeys enables the `foreign_keys` pragma,
+	// since SQLite doesn't enforce foreign keys by default.
+	ForeignKeys bool
+
+	// Changes is optional and, if set, receives a ksql.ChangeEvent for
+	// every row inserted, updated or deleted by this DB, which is useful
+	// for reactive UIs on local-first apps, e.g.:
+	//
+	//	changes := make(chan ksql.ChangeEvent, 100)
+	//	db, err := ksqlite3.New(ctx, "/tmp/app.db", ksql.Config{}, ksqlite3.Options{
+	//		Changes: changes,
+	//	})
+	//
+	// The events are sent by the SQLite update hook while the change is being
+	// written, so they are also sent for changes that are later rolled back.
+	//
+	// Since blocking the hook would block the write the events are
+	// dropped when the channel is full, so use a buffered channel.
+	//
+	// Changes made by other processes or by other *sql.DB
+	// instances using the same file are not reported.
+	Changes chan<- ksql.ChangeEvent
+}
+
+var validJournalModes = map[string]bool{
+	"DELETE":   true,
+	"TRUNCATE": true,
+	"PERSIST":  true,
+	"MEMORY":   true,
+	"WAL":      true,
+	"OFF":      true,
+}
+
 // NewFromSQLDB builds a ksql.DB from a *sql.DB instance
 func NewFromSQLDB(db *sql.DB) (ksql.DB, error) {
 	return ksql.NewWithAdapter(NewSQLAdapter(db), sqldialect.Sqlite3Dialect{})
 }
 
 // New instantiates a new KSQL client using the "sqlite3" driver
+//
+// The optional sqliteOptions argument is used for configuring
+// SQLite specific settings, only the first one is used, e.g.:
+//
+//	db, err := ksqlite3.New(ctx, "/tmp/app.db", ksql.Config{}, ksqlite3.Options{
+//		JournalMode: "WAL",
+//		BusyTimeout: 5 * time.Second,
+//		ForeignKeys: true,
+//	})
 func New(
 	_ context.Context,
 	connectionString string,
 	config ksql.Config,
+	sqliteOptions ...Options,
 ) (ksql.DB, error) {
+	if !cgoEnabled {
+		return ksql.DB{}, fmt.Errorf(
+			"ksqlite3 requires CGO, if you can't use CGO try the modernc-ksqlite adapter instead",
+		)
+	}
+
 	config.SetDefaultValues()
 
-	db, err := sql.Open("sqlite3", connectionString)
+	var options Options
+	if len(sqliteOptions) > 0 {
+		options = sqliteOptions[0]
+
+		var err error
+		connectionString, err = addOptionsToDSN(connectionString, options)
+		if err != nil {
+			return ksql.DB{}, err
+		}
+
+		// The connection strings returned by the DSNProvider
+		// replace the one above, so they need the options too:
+		if dsnProvider := config.DSNProvider; dsnProvider != nil {
+			config.DSNProvider = func(ctx context.Context) (string, error) {
+				dsn, err := dsnProvider(ctx)
+				if err != nil {
+					return "", err
+				}
+				return addOptionsToDSN(dsn, options)
+			}
+		}
+	}
+
+	var db *sql.DB
+	var err error
+	if options.Changes != nil {
+		db, err = openWithChangeEvents(connectionString, config, options.Changes)
+	} else {
+		db, err = ksql.OpenSQLDB("sqlite3", connectionString, config)
+	}
 	if err != nil {
 		return ksql.DB{}, err
 	}
@@ -35,5 +129,41 @@ func New(
 
 	db.SetMaxOpenConns(config.MaxOpenConns)
 
-	return ksql.NewWithAdapter(NewSQLAdapter(db), sqldialect.Sqlite3Dialect{})
+	return ksql.NewWithAdapter(NewSQLAdapter(db), sqldialect.Sqlite3Dialect{}, config)
+}
+
+// addOptionsToDSN adds the options to the connection string using the
+// parameters supported by the mattn/go-sqlite3 driver, which applies
+// them every time a new connection is opened.
+func addOptionsToDSN(dsn string, options Options) (string, error) {
+	var params []string
+	if options.JournalMode != "" {
+		journalMode := strings.ToUpper(options.JournalMode)
+		if !validJournalModes[journalMode] {
+			return "", fmt.Errorf("ksqlite3: invalid JournalMode: '%s'", options.JournalMode)
+		}
+		params = append(params, "_journal_mode="+journalMode)
+	}
+
+	if options.BusyTimeout < 0 {
+		return "", fmt.Errorf("ksqlite3: BusyTimeout must not be negative, but got: %s", options.BusyTimeout)
+	}
+	if options.BusyTimeout > 0 {
+		params = append(params, fmt.Sprintf("_busy_timeout=%d", options.BusyTimeout.Milliseconds()))
+	}
+
+	if options.ForeignKeys {
+		params = append(params, "_foreign_keys=1")
+	}
+
+	if len(params) == 0 {
+		return dsn, nil
+	}
+
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+
+	return dsn + separator + strings.Join(params, "&"), nil
 }
@@ -1,9 +1,12 @@
 package ksqlite3
 
 import (
+	"context"
 	"database/sql"
 	"io"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/vingarcia/ksql"
 	"github.com/vingarcia/ksql/sqldialect"
@@ -18,3 +21,179 @@ func TestAdapter(t *testing.T) {
 		return SQLAdapter{db}, db
 	})
 }
+
+func TestOptions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should apply the options to the connections", func(t *testing.T) {
+		db, err := New(ctx, "/tmp/ksql_options.db", ksql.Config{MaxOpenConns: 2}, Options{
+			JournalMode: "wal",
+			BusyTimeout: 5 * time.Second,
+			ForeignKeys: true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer db.Close()
+
+		var journal struct {
+			Mode string `ksql:"journal_mode"`
+		}
+		err = db.QueryOne(ctx, &journal, "PRAGMA journal_mode")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if journal.Mode != "wal" {
+			t.Fatalf("expected journal_mode to be 'wal' but got: '%s'", journal.Mode)
+		}
+
+		var busy struct {
+			Timeout int `ksql:"timeout"`
+		}
+		err = db.QueryOne(ctx, &busy, "PRAGMA busy_timeout")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if busy.Timeout != 5000 {
+			t.Fatalf("expected busy_timeout to be 5000 but got: %d", busy.Timeout)
+		}
+
+		var fk struct {
+			Enabled int `ksql:"foreign_keys"`
+		}
+		err = db.QueryOne(ctx, &fk, "PRAGMA foreign_keys")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if fk.Enabled != 1 {
+			t.Fatalf("expected foreign_keys to be enabled but got: %d", fk.Enabled)
+		}
+	})
+
+	t.Run("should apply the options to the connection strings returned by the DSNProvider", func(t *testing.T) {
+		db, err := New(ctx, "/tmp/ksql_ignored.db", ksql.Config{
+			DSNProvider: func(ctx context.Context) (string, error) {
+				return "/tmp/ksql_dsn_provider_options.db", nil
+			},
+		}, Options{
+			BusyTimeout: 5 * time.Second,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer db.Close()
+
+		var busy struct {
+			Timeout int `ksql:"timeout"`
+		}
+		err = db.QueryOne(ctx, &busy, "PRAGMA busy_timeout")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if busy.Timeout != 5000 {
+			t.Fatalf("expected busy_timeout to be 5000 but got: %d", busy.Timeout)
+		}
+	})
+
+	t.Run("should report row changes on the Changes channel", func(t *testing.T) {
+		changes := make(chan ksql.ChangeEvent, 10)
+		db, err := New(ctx, "/tmp/ksql_changes.db", ksql.Config{}, Options{
+			Changes: changes,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer db.Close()
+
+		_, err = db.Exec(ctx, `DROP TABLE IF EXISTS items`)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		_, err = db.Exec(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)`)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		type item struct {
+			ID   int    `ksql:"id"`
+			Name string `ksql:"name"`
+		}
+		itemsTable := ksql.NewTable("items")
+
+		i := item{Name: "fakeName"}
+		err = db.Insert(ctx, itemsTable, &i)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		err = db.Patch(ctx, itemsTable, item{ID: i.ID, Name: "otherName"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		err = db.Delete(ctx, itemsTable, i.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		for _, op := range []ksql.ChangeOp{ksql.ChangeInsert, ksql.ChangeUpdate, ksql.ChangeDelete} {
+			select {
+			case event := <-changes:
+				expected := ksql.ChangeEvent{Op: op, Database: "main", Table: "items", RowID: int64(i.ID)}
+				if event != expected {
+					t.Fatalf("expected event %+v but got %+v", expected, event)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("timed out waiting for the %s event", op)
+			}
+		}
+	})
+
+	t.Run("should build the connection string correctly", func(t *testing.T) {
+		tests := []struct {
+			desc        string
+			dsn         string
+			options     Options
+			expectedDSN string
+		}{
+			{
+				desc:        "no options",
+				dsn:         "/tmp/ksql.db",
+				expectedDSN: "/tmp/ksql.db",
+			},
+			{
+				desc:        "all options",
+				dsn:         "/tmp/ksql.db",
+				options:     Options{JournalMode: "WAL", BusyTimeout: time.Second, ForeignKeys: true},
+				expectedDSN: "/tmp/ksql.db?_journal_mode=WAL&_busy_timeout=1000&_foreign_keys=1",
+			},
+			{
+				desc:        "connection string with other params",
+				dsn:         "file:ksql.db?cache=shared",
+				options:     Options{ForeignKeys: true},
+				expectedDSN: "file:ksql.db?cache=shared&_foreign_keys=1",
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				dsn, err := addOptionsToDSN(test.dsn, test.options)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if dsn != test.expectedDSN {
+					t.Fatalf("expected '%s' but got '%s'", test.expectedDSN, dsn)
+				}
+			})
+		}
+	})
+
+	t.Run("should report invalid options", func(t *testing.T) {
+		_, err := addOptionsToDSN("/tmp/ksql.db", Options{JournalMode: "fake"})
+		if err == nil || !strings.Contains(err.Error(), "JournalMode") {
+			t.Fatalf("expected JournalMode error but got: %v", err)
+		}
+
+		_, err = addOptionsToDSN("/tmp/ksql.db", Options{BusyTimeout: -time.Second})
+		if err == nil || !strings.Contains(err.Error(), "BusyTimeout") {
+			t.Fatalf("expected BusyTimeout error but got: %v", err)
+		}
+	})
+}
@@ -47,6 +47,13 @@ func (s SQLAdapter) Close() error {
 	return s.DB.Close()
 }
 
+// PoolStats implements the ksql.StatsProvider interface
+func (s SQLAdapter) PoolStats() (ksql.PoolStats, error) {
+	return ksql.NewPoolStatsFromSQL(s.DB.Stats()), nil
+}
+
+var _ ksql.StatsProvider = SQLAdapter{}
+
 // SQLTx is used to implement the DBAdapter interface and implements
 // the Tx interface
 type SQLTx struct {
@@ -0,0 +1,43 @@
+package ksqlserver
+
+import (
+	"errors"
+	"strings"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/vingarcia/ksql"
+)
+
+func init() {
+	ksql.RegisterSQLStateFunc(sqlState)
+}
+
+// sqlState translates the SQLServer error numbers to the
+// normalized SQLSTATE codes returned by ksql.SQLState
+func sqlState(err error) (string, bool) {
+	var mssqlErr mssql.Error
+	if !errors.As(err, &mssqlErr) {
+		return "", false
+	}
+
+	switch mssqlErr.Number {
+	case 2601, 2627:
+		return ksql.SQLStateUniqueViolation, true
+	case 547:
+		// The same number is used for both foreign key and check constraints:
+		if strings.Contains(mssqlErr.Message, "FOREIGN KEY") || strings.Contains(mssqlErr.Message, "REFERENCE") {
+			return ksql.SQLStateForeignKeyViolation, true
+		}
+		return ksql.SQLStateCheckViolation, true
+	case 515:
+		return ksql.SQLStateNotNullViolation, true
+	case 1205:
+		return ksql.SQLStateDeadlockDetected, true
+	case 102:
+		return ksql.SQLStateSyntaxError, true
+	case 208:
+		return ksql.SQLStateUndefinedTable, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,35 @@
+package ksqlite
+
+import (
+	"errors"
+
+	"github.com/vingarcia/ksql"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+func init() {
+	ksql.RegisterSQLStateFunc(sqlState)
+}
+
+// sqlState translates the SQLite extended result codes to
+// the normalized SQLSTATE codes returned by ksql.SQLState
+func sqlState(err error) (string, bool) {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return "", false
+	}
+
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return ksql.SQLStateUniqueViolation, true
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return ksql.SQLStateForeignKeyViolation, true
+	case sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+		return ksql.SQLStateNotNullViolation, true
+	case sqlite3.SQLITE_CONSTRAINT_CHECK:
+		return ksql.SQLStateCheckViolation, true
+	}
+
+	return "", false
+}
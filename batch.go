@@ -0,0 +1,75 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+)
+
+// BatchQuery is one of the queries sent to the database by a Batcher.
+type BatchQuery struct {
+	Query  string
+	Params []interface{}
+
+	// ScanArgs is optional, when it is set the first row returned
+	// by the query is scanned into it, e.g. for reading the IDs
+	// generated by an INSERT with a RETURNING clause.
+	ScanArgs []interface{}
+}
+
+// Batcher can optionally be implemented by the DBAdapter, and by the
+// Tx returned by it, in order to send several queries to the database
+// in a single round trip, e.g. using a pgx.Batch on kpgx.
+//
+// SendBatch returns the number of rows affected by each query. If one
+// of them fails it returns the rows affected by the queries before it
+// together with the error, so the failed query is the one at the index
+// `len(rowsAffected)`.
+//
+// It is also implemented by DB and by the Providers returned by Chain,
+// so adapters can expose batching functions that work with any of them.
+type Batcher interface {
+	SendBatch(ctx context.Context, queries []BatchQuery) (rowsAffected []int64, err error)
+}
+
+// errNoBatcher is returned when the adapter, or the
+// adapter wrapped by KSQL, doesn't implement Batcher
+var errNoBatcher = errors.New("KSQL: the DBAdapter doesn't implement the Batcher interface")
+
+// SendBatch implements the Batcher interface by sending the queries
+// with the DBAdapter, which inside transactions is the adapter of the
+// transaction, after checking and routing them with the ShardKeyRouter
+// just like the Exec method does.
+//
+// It returns an error if the DBAdapter doesn't implement Batcher.
+func (c DB) SendBatch(ctx context.Context, queries []BatchQuery) (rowsAffected []int64, err error) {
+	batcher, ok := c.db.(Batcher)
+	if !ok {
+		return nil, errNoBatcher
+	}
+
+	routedQueries := make([]BatchQuery, len(queries))
+	for i, query := range queries {
+		if err := c.checkQuerySize(query.Query, query.Params); err != nil {
+			return nil, err
+		}
+
+		query.Query, err = c.routeQuery(ctx, query.Query)
+		if err != nil {
+			return nil, err
+		}
+		routedQueries[i] = query
+	}
+
+	if err := c.checkRLSSettings(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, done, err := c.trackOperation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	rowsAffected, err = batcher.SendBatch(ctx, routedQueries)
+	return rowsAffected, wrapTimeoutError(ctx, err, 0)
+}
@@ -0,0 +1,158 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+type mockBatcher struct {
+	mockTxBeginner
+	SendBatchFn func(ctx context.Context, queries []BatchQuery) ([]int64, error)
+}
+
+func (m mockBatcher) SendBatch(ctx context.Context, queries []BatchQuery) ([]int64, error) {
+	return m.SendBatchFn(ctx, queries)
+}
+
+type mockBatcherTx struct {
+	mockTx
+	SendBatchFn func(ctx context.Context, queries []BatchQuery) ([]int64, error)
+}
+
+func (m mockBatcherTx) SendBatch(ctx context.Context, queries []BatchQuery) ([]int64, error) {
+	return m.SendBatchFn(ctx, queries)
+}
+
+func TestSendBatch(t *testing.T) {
+	ctx := context.Background()
+	dialect := sqldialect.SupportedDialects["postgres"]
+
+	queries := []BatchQuery{
+		{Query: "INSERT INTO users (name) VALUES ($1) RETURNING id", Params: []interface{}{"fakeName"}},
+		{Query: "UPDATE counters SET n = n + 1"},
+	}
+
+	newAdapter := func(sentBy *[]string) mockBatcher {
+		adapter := mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				return mockResult{}, nil
+			},
+		}
+		return mockBatcher{
+			mockTxBeginner: mockTxBeginner{
+				DBAdapter: adapter,
+				BeginTxFn: func(ctx context.Context) (Tx, error) {
+					return mockBatcherTx{
+						mockTx: mockTx{
+							DBAdapter: adapter,
+							CommitFn: func(ctx context.Context) error {
+								return nil
+							},
+							RollbackFn: func(ctx context.Context) error {
+								return nil
+							},
+						},
+						SendBatchFn: func(ctx context.Context, queries []BatchQuery) ([]int64, error) {
+							*sentBy = append(*sentBy, "tx")
+							return []int64{1, 2}, nil
+						},
+					}, nil
+				},
+			},
+			SendBatchFn: func(ctx context.Context, queries []BatchQuery) ([]int64, error) {
+				*sentBy = append(*sentBy, "adapter")
+				return []int64{1, 2}, nil
+			},
+		}
+	}
+
+	t.Run("should send the batch with the adapter", func(t *testing.T) {
+		var sentBy []string
+		db, err := NewWithAdapter(newAdapter(&sentBy), dialect)
+		tt.AssertNoErr(t, err)
+
+		rowsAffected, err := db.SendBatch(ctx, queries)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, rowsAffected, []int64{1, 2})
+		tt.AssertEqual(t, sentBy, []string{"adapter"})
+	})
+
+	t.Run("should send the batch with the transaction", func(t *testing.T) {
+		var sentBy []string
+		db, err := NewWithAdapter(newAdapter(&sentBy), dialect)
+		tt.AssertNoErr(t, err)
+
+		err = db.Transaction(ctx, func(db Provider) error {
+			_, err := db.(Batcher).SendBatch(ctx, queries)
+			return err
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, sentBy, []string{"tx"})
+	})
+
+	t.Run("should work with adapters wrapped by DetectRowsLeaks", func(t *testing.T) {
+		var sentBy []string
+		db, err := NewWithAdapter(DetectRowsLeaks(newAdapter(&sentBy), time.Minute, nil), dialect)
+		tt.AssertNoErr(t, err)
+
+		_, err = db.SendBatch(ctx, queries)
+		tt.AssertNoErr(t, err)
+
+		err = db.Transaction(ctx, func(db Provider) error {
+			_, err := db.(Batcher).SendBatch(ctx, queries)
+			return err
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, sentBy, []string{"adapter", "tx"})
+	})
+
+	t.Run("should work with providers wrapped by Chain", func(t *testing.T) {
+		var sentBy []string
+		db, err := NewWithAdapter(newAdapter(&sentBy), dialect)
+		tt.AssertNoErr(t, err)
+
+		chained := Chain(db, func(next Handler) Handler {
+			return next
+		})
+
+		_, err = chained.(Batcher).SendBatch(ctx, queries)
+		tt.AssertNoErr(t, err)
+
+		err = chained.Transaction(ctx, func(db Provider) error {
+			_, err := db.(Batcher).SendBatch(ctx, queries)
+			return err
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, sentBy, []string{"adapter", "tx"})
+	})
+
+	t.Run("should check the size of the queries", func(t *testing.T) {
+		var sentBy []string
+		db, err := NewWithAdapter(newAdapter(&sentBy), dialect, Config{MaxQueryBytes: 10})
+		tt.AssertNoErr(t, err)
+
+		_, err = db.SendBatch(ctx, queries)
+		tt.AssertEqual(t, errors.Is(err, ErrQueryTooLarge), true)
+		tt.AssertEqual(t, len(sentBy), 0)
+	})
+
+	t.Run("should report adapters that don't implement Batcher", func(t *testing.T) {
+		var sentBy []string
+		db, err := NewWithAdapter(newAdapter(&sentBy).mockTxBeginner, dialect)
+		tt.AssertNoErr(t, err)
+
+		_, err = db.SendBatch(ctx, queries)
+		tt.AssertErrContains(t, err, "KSQL", "Batcher")
+
+		db, err = NewWithAdapter(DetectRowsLeaks(newAdapter(&sentBy).mockTxBeginner, time.Minute, nil), dialect)
+		tt.AssertNoErr(t, err)
+
+		_, err = db.SendBatch(ctx, queries)
+		tt.AssertErrContains(t, err, "KSQL", "Batcher")
+	})
+}
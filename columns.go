@@ -0,0 +1,94 @@
+package ksql
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// Column returns the name of the column mapped by the `ksql` tag of the
+// attribute referenced by `attrPtr`, which must be a pointer to one of the
+// attributes of the struct referenced by `recordPtr`, e.g.:
+//
+//	var u User
+//	var userNameCol = ksql.Column(&u, &u.Name)
+//
+//	err := db.Query(ctx, &users, "FROM users WHERE "+userNameCol+" = $1", name)
+//
+// Since the attribute is referenced by the compiler instead of a string,
+// renaming the attribute or changing its tag never leaves the queries
+// using the column name out of sync with the struct.
+//
+// Column panics if the arguments are invalid, since this can only happen
+// because of a programming error, so it is meant to be called when the
+// package is initialized, like `regexp.MustCompile`.
+func Column(recordPtr interface{}, attrPtr interface{}) string {
+	v := reflect.ValueOf(recordPtr)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Errorf("ksql.Column: expected the first argument to be a non-nil pointer to struct but got: %T", recordPtr))
+	}
+	v = v.Elem()
+
+	attr := reflect.ValueOf(attrPtr)
+	if attr.Kind() != reflect.Ptr || attr.IsNil() {
+		panic(fmt.Errorf("ksql.Column: expected the second argument to be a non-nil pointer to an attribute but got: %T", attrPtr))
+	}
+
+	info, err := structs.GetTagInfo(v.Type())
+	if err != nil {
+		panic(fmt.Errorf("ksql.Column: %w", err))
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+
+		// The type is also compared because the first attribute
+		// of a struct has the same address as the struct itself:
+		if field.Addr().Pointer() != attr.Pointer() || field.Type() != attr.Type().Elem() {
+			continue
+		}
+
+		fieldInfo := info.ByIndex(i)
+		if !fieldInfo.Valid {
+			panic(fmt.Errorf("ksql.Column: the attribute %s of %T has no ksql tag", v.Type().Field(i).Name, recordPtr))
+		}
+
+		return fieldInfo.ColumnName
+	}
+
+	panic(fmt.Errorf("ksql.Column: the second argument is not a pointer to an attribute of %T", recordPtr))
+}
+
+// Columns returns a map from the names of the attributes of the input
+// struct to the names of the columns mapped by their `ksql` tags.
+//
+// Attributes without a `ksql` tag are not included on the map.
+//
+// The input can be either a struct or a pointer to struct.
+func Columns(record interface{}) (map[string]string, error) {
+	t := reflect.TypeOf(record)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("ksql.Columns: expected input to be a struct or a pointer to struct but got: %T", record)
+	}
+
+	info, err := structs.GetTagInfo(t)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]string, info.NumFields())
+	for i := 0; i < t.NumField(); i++ {
+		fieldInfo := info.ByIndex(i)
+		if !fieldInfo.Valid {
+			continue
+		}
+
+		columns[fieldInfo.AttrName] = fieldInfo.ColumnName
+	}
+
+	return columns, nil
+}
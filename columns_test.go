@@ -0,0 +1,118 @@
+package ksql
+
+import (
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestColumn(t *testing.T) {
+	type User struct {
+		ID      int    `ksql:"id"`
+		Name    string `ksql:"name"`
+		Age     int    `ksql:"age"`
+		Ignored string
+	}
+
+	t.Run("should return the column names of the attributes", func(t *testing.T) {
+		var u User
+		tt.AssertEqual(t, Column(&u, &u.ID), "id")
+		tt.AssertEqual(t, Column(&u, &u.Name), "name")
+		tt.AssertEqual(t, Column(&u, &u.Age), "age")
+	})
+
+	t.Run("should not mistake the struct itself for its first attribute", func(t *testing.T) {
+		type Wrapper struct {
+			User User `tablename:"u"`
+		}
+
+		var w Wrapper
+		tt.AssertEqual(t, Column(&w, &w.User), "u")
+		tt.AssertEqual(t, Column(&w.User, &w.User.ID), "id")
+	})
+
+	t.Run("should panic for invalid arguments", func(t *testing.T) {
+		var u User
+		var other User
+		tests := []struct {
+			desc           string
+			recordPtr      interface{}
+			attrPtr        interface{}
+			expectErrToHas []string
+		}{
+			{
+				desc:           "record is not a pointer",
+				recordPtr:      u,
+				attrPtr:        &u.Name,
+				expectErrToHas: []string{"ksql.Column", "pointer to struct"},
+			},
+			{
+				desc:           "record is a nil pointer",
+				recordPtr:      (*User)(nil),
+				attrPtr:        &u.Name,
+				expectErrToHas: []string{"ksql.Column", "non-nil pointer to struct"},
+			},
+			{
+				desc:           "attribute is not a pointer",
+				recordPtr:      &u,
+				attrPtr:        u.Name,
+				expectErrToHas: []string{"ksql.Column", "pointer to an attribute"},
+			},
+			{
+				desc:           "attribute belongs to another struct",
+				recordPtr:      &u,
+				attrPtr:        &other.Name,
+				expectErrToHas: []string{"ksql.Column", "not a pointer to an attribute"},
+			},
+			{
+				desc:           "attribute has no ksql tag",
+				recordPtr:      &u,
+				attrPtr:        &u.Ignored,
+				expectErrToHas: []string{"ksql.Column", "Ignored", "no ksql tag"},
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				panicPayload := tt.PanicHandler(func() {
+					Column(test.recordPtr, test.attrPtr)
+				})
+
+				err, ok := panicPayload.(error)
+				tt.AssertEqual(t, ok, true)
+				tt.AssertErrContains(t, err, test.expectErrToHas...)
+			})
+		}
+	})
+}
+
+func TestColumns(t *testing.T) {
+	type User struct {
+		ID      int    `ksql:"id"`
+		Name    string `ksql:"name,json"`
+		Ignored string
+	}
+
+	t.Run("should map the attribute names to the column names", func(t *testing.T) {
+		columns, err := Columns(User{})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, columns, map[string]string{
+			"ID":   "id",
+			"Name": "name",
+		})
+
+		columns, err = Columns(&User{})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, columns, map[string]string{
+			"ID":   "id",
+			"Name": "name",
+		})
+	})
+
+	t.Run("should report error for invalid inputs", func(t *testing.T) {
+		_, err := Columns(42)
+		tt.AssertErrContains(t, err, "ksql.Columns", "struct", "int")
+
+		_, err = Columns(nil)
+		tt.AssertErrContains(t, err, "ksql.Columns", "struct")
+	})
+}
@@ -0,0 +1,38 @@
+package ksql
+
+import "context"
+
+type providerKey struct{}
+
+// WithProvider returns a copy of `ctx` carrying the given Provider,
+// so it can later be retrieved with `FromContext`.
+//
+// This is useful for passing the Provider created inside `db.Transaction()`
+// down to the application layers without adding an extra argument
+// to every function signature:
+//
+//	err := db.Transaction(ctx, func(tx ksql.Provider) error {
+//	    ctx := ksql.WithProvider(ctx, tx)
+//
+//	    // Any function called with this ctx can now use
+//	    // `ksql.FromContext(ctx)` to run its queries inside the transaction:
+//	    return usersRepo.UpdateBalance(ctx, userID, amount)
+//	})
+func WithProvider(ctx context.Context, provider Provider) context.Context {
+	return context.WithValue(ctx, providerKey{}, provider)
+}
+
+// FromContext returns the Provider injected into `ctx` by `WithProvider`,
+// or nil if no Provider was injected.
+//
+// A common pattern is to fallback to the default database instance
+// when no Provider is available on the context:
+//
+//	db := ksql.FromContext(ctx)
+//	if db == nil {
+//	    db = r.db
+//	}
+func FromContext(ctx context.Context) Provider {
+	provider, _ := ctx.Value(providerKey{}).(Provider)
+	return provider
+}
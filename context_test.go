@@ -0,0 +1,59 @@
+package ksql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vingarcia/ksql"
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Run("should return nil if no provider was injected", func(t *testing.T) {
+		ctx := context.Background()
+
+		tt.AssertEqual(t, ksql.FromContext(ctx), nil)
+	})
+
+	t.Run("should return the injected provider", func(t *testing.T) {
+		var called bool
+		mock := ksql.Mock{
+			ExecFn: func(ctx context.Context, query string, params ...interface{}) (ksql.Result, error) {
+				called = true
+				return nil, nil
+			},
+		}
+
+		ctx := ksql.WithProvider(context.Background(), mock)
+
+		provider := ksql.FromContext(ctx)
+		tt.AssertNotEqual(t, provider, nil)
+
+		_, err := provider.Exec(ctx, "fake-query")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, called, true)
+	})
+
+	t.Run("should return the provider injected last", func(t *testing.T) {
+		var calledProvider string
+		first := ksql.Mock{
+			ExecFn: func(ctx context.Context, query string, params ...interface{}) (ksql.Result, error) {
+				calledProvider = "first"
+				return nil, nil
+			},
+		}
+		second := ksql.Mock{
+			ExecFn: func(ctx context.Context, query string, params ...interface{}) (ksql.Result, error) {
+				calledProvider = "second"
+				return nil, nil
+			},
+		}
+
+		ctx := ksql.WithProvider(context.Background(), first)
+		ctx = ksql.WithProvider(ctx, second)
+
+		_, err := ksql.FromContext(ctx).Exec(ctx, "fake-query")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calledProvider, "second")
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"regexp"
+	"time"
 
 	"github.com/vingarcia/ksql/sqldialect"
 )
@@ -22,9 +24,14 @@ var ErrRecordNotFound error = fmt.Errorf("ksql: the query returned no results: %
 // The reasons that can cause an attribute to be ignored in the Patch() function are:
 // (1) If it is a nil pointer, Patch() will just ignore it.
 // (2) If the attribute is using a modifier that contains the SkipUpdates flag.
+//
+// When attributes are ignored because of their modifiers the returned error
+// wraps this one and lists those attributes, so it should be checked with
+// `errors.Is(err, ksql.ErrNoValuesToUpdate)`, or the DB.PatchOrNoop method
+// can be used for treating this case as a successful no-op.
 var ErrNoValuesToUpdate error = fmt.Errorf("ksql: the input struct contains no values to update")
 
-// ErrRecordMissingIDs is returned by the Update or Delete functions if an input record does
+// ErrRecordMissingIDs is returned by the Patch or Delete functions if an input record does
 // not have all of the IDs described on the input table.
 var ErrRecordMissingIDs error = fmt.Errorf("ksql: missing required ID fields")
 
@@ -32,9 +39,246 @@ var ErrRecordMissingIDs error = fmt.Errorf("ksql: missing required ID fields")
 // close the connection and return with no errors.
 var ErrAbortIteration error = fmt.Errorf("ksql: abort iteration, should only be used inside QueryChunks function")
 
+// ErrDBClosing is returned by the operations started after a call to DB.CloseWithContext.
+var ErrDBClosing error = fmt.Errorf("ksql: the database is closing and no longer accepts new operations")
+
+// ErrTooManyTransactions is returned by Transaction when the number of
+// running transactions reached the limit set with Config.MaxConcurrentTransactions.
+var ErrTooManyTransactions error = fmt.Errorf("ksql: too many concurrent transactions")
+
+// ErrQueryTooLarge is returned, wrapped in a QueryTooLargeError, when a query
+// exceeds the limits set with Config.MaxQueryBytes or Config.MaxParams.
+var ErrQueryTooLarge error = fmt.Errorf("ksql: query rejected for exceeding the size limits")
+
+// QueryTooLargeError is returned when a query is larger than
+// Config.MaxQueryBytes or has more params than Config.MaxParams,
+// in which case it is not sent to the database.
+//
+// It can be checked with `errors.Is(err, ksql.ErrQueryTooLarge)`.
+type QueryTooLargeError struct {
+	// NumBytes and NumParams describe the rejected query
+	NumBytes  int
+	NumParams int
+
+	// MaxBytes and MaxParams are the configured limits, 0 means no limit
+	MaxBytes  int
+	MaxParams int
+}
+
+// Error implements the error interface
+func (e QueryTooLargeError) Error() string {
+	if e.MaxParams > 0 && e.NumParams > e.MaxParams {
+		return fmt.Sprintf("%s: the query has %d params but the limit is %d", ErrQueryTooLarge, e.NumParams, e.MaxParams)
+	}
+	return fmt.Sprintf("%s: the query has %d bytes but the limit is %d", ErrQueryTooLarge, e.NumBytes, e.MaxBytes)
+}
+
+// Unwrap returns ErrQueryTooLarge
+func (e QueryTooLargeError) Unwrap() error {
+	return ErrQueryTooLarge
+}
+
+// ErrDeadlineApproaching is returned by QueryChunks, wrapped in a DeadlineApproachingError,
+// when the ChunkParser.DeadlineHeadroom option is used and there is not enough time
+// left before the context deadline to process the next chunk.
+var ErrDeadlineApproaching error = fmt.Errorf("ksql: stopped QueryChunks because the context deadline is approaching")
+
+// DeadlineApproachingError is returned by QueryChunks when it stops
+// early because the context deadline is approaching, and contains the
+// information necessary for resuming the iteration on a later execution.
+//
+// It can be checked with `errors.Is(err, ksql.ErrDeadlineApproaching)`.
+type DeadlineApproachingError struct {
+	// ProcessedChunks is the number of chunks that were
+	// successfully processed by the ForEachChunk callback
+	ProcessedChunks int
+
+	// ProcessedRows is the total number of rows contained in
+	// the chunks that were successfully processed
+	ProcessedRows int
+
+	// TimeLeft is the time that was left until
+	// the context deadline when QueryChunks stopped
+	TimeLeft time.Duration
+}
+
+// Error implements the error interface
+func (d DeadlineApproachingError) Error() string {
+	return fmt.Sprintf(
+		"%s: processed %d chunks (%d rows) with %s left",
+		ErrDeadlineApproaching, d.ProcessedChunks, d.ProcessedRows, d.TimeLeft,
+	)
+}
+
+// Unwrap returns ErrDeadlineApproaching
+func (d DeadlineApproachingError) Unwrap() error {
+	return ErrDeadlineApproaching
+}
+
+// ErrQueryTimeout is returned, wrapped in a TimeoutError, when the context
+// deadline expires while waiting for the database to run the query, i.e.
+// before the first row is received, which usually calls for tuning the
+// query or its indexes.
+var ErrQueryTimeout error = fmt.Errorf("ksql: context deadline exceeded while waiting for the query results")
+
+// ErrScanTimeout is returned, wrapped in a TimeoutError, when the context
+// deadline expires while reading the rows returned by the query, which
+// usually calls for reading fewer rows per call, e.g. using QueryChunks.
+var ErrScanTimeout error = fmt.Errorf("ksql: context deadline exceeded while reading the query results")
+
+// TimeoutError is returned when the context deadline expires during
+// an operation, it can be checked with `errors.Is(err, ksql.ErrQueryTimeout)`
+// or `errors.Is(err, ksql.ErrScanTimeout)` depending on when it happened.
+//
+// It also matches `context.DeadlineExceeded`, even if the
+// error returned by the driver doesn't wrap it.
+type TimeoutError struct {
+	// RowsRead is the number of rows read before the deadline expired,
+	// if it is greater than 0 the timeout happened while reading the rows
+	RowsRead int
+
+	// Err is the error returned by the database adapter
+	Err error
+}
+
+// Error implements the error interface
+func (t TimeoutError) Error() string {
+	if t.RowsRead > 0 {
+		return fmt.Sprintf("%s after %d rows: %s", ErrScanTimeout, t.RowsRead, t.Err)
+	}
+	return fmt.Sprintf("%s: %s", ErrQueryTimeout, t.Err)
+}
+
+// Unwrap returns the error returned by the database adapter
+func (t TimeoutError) Unwrap() error {
+	return t.Err
+}
+
+// Is matches ErrScanTimeout or ErrQueryTimeout, depending
+// on when the timeout happened, and context.DeadlineExceeded
+func (t TimeoutError) Is(target error) bool {
+	if t.RowsRead > 0 && target == ErrScanTimeout {
+		return true
+	}
+	return (t.RowsRead == 0 && target == ErrQueryTimeout) || target == context.DeadlineExceeded
+}
+
+// ErrTooManyRows is returned by Query, wrapped in a TooManyRowsError, when
+// the query returns more rows than the limit set with Config.MaxQueryRows
+// or with the MaxRows option.
+var ErrTooManyRows error = fmt.Errorf("ksql: the query returned too many rows, consider using QueryChunks instead")
+
+// TooManyRowsError is returned by Query when the number of rows
+// returned by the query exceeds the configured limit.
+//
+// It can be checked with `errors.Is(err, ksql.ErrTooManyRows)`.
+type TooManyRowsError struct {
+	// MaxRows is the limit that was exceeded
+	MaxRows int
+}
+
+// Error implements the error interface
+func (e TooManyRowsError) Error() string {
+	return fmt.Sprintf("%s: the limit is %d rows", ErrTooManyRows, e.MaxRows)
+}
+
+// Unwrap returns ErrTooManyRows
+func (e TooManyRowsError) Unwrap() error {
+	return ErrTooManyRows
+}
+
+// ErrTooManyChunks is returned by QueryChunks, wrapped in a TooManyChunksError,
+// when the query returns more chunks than the limit set with ChunkParser.MaxChunks.
+var ErrTooManyChunks error = fmt.Errorf("ksql: stopped QueryChunks because the query returned too many chunks")
+
+// TooManyChunksError is returned by QueryChunks when the number
+// of chunks returned by the query exceeds ChunkParser.MaxChunks.
+//
+// It can be checked with `errors.Is(err, ksql.ErrTooManyChunks)`.
+type TooManyChunksError struct {
+	// MaxChunks is the limit that was exceeded
+	MaxChunks int
+
+	// ProcessedRows is the total number of rows contained in
+	// the chunks that were successfully processed
+	ProcessedRows int
+}
+
+// Error implements the error interface
+func (e TooManyChunksError) Error() string {
+	return fmt.Sprintf(
+		"%s: the limit is %d chunks, processed %d rows",
+		ErrTooManyChunks, e.MaxChunks, e.ProcessedRows,
+	)
+}
+
+// Unwrap returns ErrTooManyChunks
+func (e TooManyChunksError) Unwrap() error {
+	return ErrTooManyChunks
+}
+
+// OpError is returned when the database adapter fails while
+// executing one of the operations of the Provider interface.
+//
+// It records which operation and table were involved, and the
+// original error can still be checked with errors.Is and errors.As.
+type OpError struct {
+	// Method is the name of the ksql method that failed, e.g. "Insert"
+	Method string
+
+	// Table is the name of the table involved in the operation,
+	// it is empty for the Query, QueryOne and QueryChunks methods
+	Table string
+
+	// Query is the query that was sent to the database
+	Query string
+
+	Err error
+}
+
+// Error implements the error interface
+func (o OpError) Error() string {
+	if o.Table == "" {
+		return fmt.Sprintf("KSQL: %s failed: %s", o.Method, o.Err)
+	}
+	return fmt.Sprintf("KSQL: %s on table '%s' failed: %s", o.Method, o.Table, o.Err)
+}
+
+// Unwrap returns the error returned by the database adapter
+func (o OpError) Unwrap() error {
+	return o.Err
+}
+
+// ChangeOp describes the kind of operation that caused a ChangeEvent
+type ChangeOp string
+
+// The operations reported by the ChangeEvent type
+const (
+	ChangeInsert ChangeOp = "INSERT"
+	ChangeUpdate ChangeOp = "UPDATE"
+	ChangeDelete ChangeOp = "DELETE"
+)
+
+// ChangeEvent describes a single row that was changed on the database.
+//
+// It is emitted by the adapters that support listening to row changes,
+// e.g. the `Options.Changes` channel of the ksqlite3 adapter.
+type ChangeEvent struct {
+	Op ChangeOp
+
+	// Database is the name of the database containing the table,
+	// e.g. "main" for the main database on SQLite
+	Database string
+	Table    string
+
+	// RowID is the identifier of the changed row
+	// as assigned by the database, e.g. the SQLite `rowid`
+	RowID int64
+}
+
 // Provider describes the ksql public behavior.
 //
-// The Insert, Update, Delete and QueryOne functions return ksql.ErrRecordNotFound
+// The Insert, Patch, Delete and QueryOne functions return ksql.ErrRecordNotFound
 // if no record was found or no rows were changed during the operation.
 type Provider interface {
 	Insert(ctx context.Context, table Table, record interface{}) error
@@ -60,8 +304,45 @@ type Table struct {
 
 	// IDColumns defaults to []string{"id"} if unset
 	idColumns []string
+
+	// reselectColumns are unique columns used for retrieving the
+	// IDs generated by the database on dialects without RETURNING
+	reselectColumns []string
+
+	keyGeneration KeyGeneration
+
+	// alias is optional and, if set, is used for referencing
+	// the table on the queries built by Patch and Delete
+	alias string
+
+	// scopeIdentity and sequence change how the IDs are
+	// generated and retrieved on SQL Server insertions
+	scopeIdentity bool
+	sequence      string
 }
 
+// KeyGeneration describes how the values of the
+// ID columns of a Table are generated on insertions.
+//
+// It is set with the Table.WithKeyGeneration() method.
+type KeyGeneration int
+
+const (
+	// DetectKeyGeneration is the default behavior: ID columns set to their
+	// zero value are omitted from the insert query so they can be generated
+	// by the database, and IDs with non-zero values are inserted as they are.
+	DetectKeyGeneration KeyGeneration = iota
+
+	// AutoIncrementKeys means the IDs are always generated by the database,
+	// so the ID columns are never included on the insert query.
+	AutoIncrementKeys
+
+	// ClientGeneratedKeys means the IDs are always generated by the application,
+	// so the ID columns are always included on the insert query, even when
+	// set to their zero value, and they are not retrieved after the insertion.
+	ClientGeneratedKeys
+)
+
 // NewTable returns a Table instance that stores
 // the tablename and the names of columns used as ID,
 // if no column name is passed it defaults to using
@@ -90,6 +371,151 @@ func NewTable(tableName string, ids ...string) Table {
 	}
 }
 
+// Name returns the name of the table
+func (t Table) Name() string {
+	return t.name
+}
+
+// IDColumns returns the names of the ID columns of the table
+func (t Table) IDColumns() []string {
+	return append([]string(nil), t.idColumns...)
+}
+
+// WithName returns a copy of the Table using a different
+// table name but keeping the same ID columns.
+//
+// This is useful when implementing a `Config.TableResolver`
+// for routing records to partitioned tables, e.g.:
+//
+//	return table.WithName(table.Name() + "_2024_06"), nil
+func (t Table) WithName(name string) Table {
+	t.name = name
+	return t
+}
+
+// WithReselectBy returns a copy of the Table that retrieves the IDs
+// generated by the database after each insertion by selecting the
+// inserted record using the input unique columns.
+//
+// This is only used by the dialects that can't return the generated IDs
+// from the insert query itself, i.e. MySQL and SQLite, and is necessary
+// when the ID is not an auto increment integer, e.g. for IDs generated
+// by the database with `DEFAULT (UUID())`:
+//
+//	var UsersTable = ksql.NewTable("users").WithReselectBy("email")
+//
+// The unique columns must be set on the records being inserted.
+func (t Table) WithReselectBy(uniqueColumns ...string) Table {
+	t.reselectColumns = uniqueColumns
+	return t
+}
+
+// WithKeyGeneration returns a copy of the Table that generates
+// its IDs as described by the input KeyGeneration, e.g.:
+//
+//	var UsersTable = ksql.NewTable("users").WithKeyGeneration(ksql.ClientGeneratedKeys)
+//
+// This is necessary for inserting IDs that are legitimately
+// set to their zero value, e.g. an int ID of 0.
+func (t Table) WithKeyGeneration(keyGeneration KeyGeneration) Table {
+	t.keyGeneration = keyGeneration
+	return t
+}
+
+// WithScopeIdentity returns a copy of the Table that retrieves the ID
+// generated by SQL Server for IDENTITY columns with `SELECT SCOPE_IDENTITY()`
+// instead of the OUTPUT clause, which is useful when the OUTPUT clause
+// can't be used, e.g. because of DBA policies or triggers on the table:
+//
+//	var UsersTable = ksql.NewTable("users").WithScopeIdentity()
+//
+// It only works for tables with a single ID column
+// and it is ignored by the other dialects.
+func (t Table) WithScopeIdentity() Table {
+	t.scopeIdentity = true
+	return t
+}
+
+// WithSequence returns a copy of the Table that fetches the ID of each
+// record inserted on SQL Server from the input sequence, using
+// `SELECT NEXT VALUE FOR`, and then inserts the record with this ID,
+// so no OUTPUT clause is necessary for retrieving it:
+//
+//	var UsersTable = ksql.NewTable("users").WithSequence("dbo.users_seq")
+//
+// IDs that are already set on the record are inserted unchanged.
+//
+// It only works for tables with a single ID column
+// and it is ignored by the other dialects.
+func (t Table) WithSequence(sequence string) Table {
+	t.sequence = sequence
+	return t
+}
+
+// WithAlias returns a copy of the Table that is referenced by the
+// input alias on the queries built by the Patch, PatchDiff, Delete
+// and DeleteReturning methods, e.g.:
+//
+//	var UsersTable = ksql.NewTable("public.users").WithAlias("u")
+//
+// Will generate queries such as:
+//
+//	UPDATE public.users AS u SET "name" = $1 WHERE u."id" = $2
+//
+// The ID columns on the WHERE clauses are qualified by the alias,
+// which avoids ambiguities when the table name is qualified by its schema.
+//
+// The alias is also used by the QualifiedSelect option, including
+// for the nested structs whose `tablename` tag matches the table name.
+//
+// The Insert and Upsert methods ignore the alias since MySQL and
+// SQL Server don't accept aliases on INSERT queries, and none of
+// the clauses generated for them reference the table.
+func (t Table) WithAlias(alias string) Table {
+	t.alias = alias
+	return t
+}
+
+// Alias returns the alias of the table, or
+// an empty string if no alias was set
+func (t Table) Alias() string {
+	return t.alias
+}
+
+// nameWithAlias returns the name of the table followed by
+// its alias, to be used on FROM clauses and similar ones
+func (t Table) nameWithAlias() string {
+	if t.alias == "" {
+		return t.name
+	}
+
+	return t.name + " AS " + t.alias
+}
+
+// qualifier returns the alias of the table, or its name
+// if it has no alias, for qualifying its columns
+func (t Table) qualifier() string {
+	if t.alias == "" {
+		return t.name
+	}
+
+	return t.alias
+}
+
+// qualifiedColumn returns the escaped column name
+// qualified by the alias of the table if it is set
+func (t Table) qualifiedColumn(dialect sqldialect.Provider, column string) string {
+	if t.alias == "" {
+		return dialect.Escape(column)
+	}
+
+	return t.alias + "." + dialect.Escape(column)
+}
+
+var tableAliasRegex = regexp.MustCompile(`^[a-zA-Z_]\w*$`)
+
+var sequenceNameRegex = regexp.MustCompile(`^(?:\[[^\]]+\]|[a-zA-Z_][\w$#@]*)(?:\.(?:\[[^\]]+\]|[a-zA-Z_][\w$#@]*))*$`)
+
 func (t Table) validate() error {
 	if t.name == "" {
 		return fmt.Errorf("table name cannot be an empty string")
@@ -101,10 +527,59 @@ func (t Table) validate() error {
 		}
 	}
 
+	for _, col := range t.reselectColumns {
+		if col == "" {
+			return fmt.Errorf("reselect columns cannot be empty strings")
+		}
+	}
+
+	if t.alias != "" && !tableAliasRegex.MatchString(t.alias) {
+		return fmt.Errorf("invalid table alias: '%s'", t.alias)
+	}
+
+	if t.scopeIdentity || t.sequence != "" {
+		if len(t.idColumns) != 1 {
+			return fmt.Errorf("SCOPE_IDENTITY() and sequences can only be used on tables with a single ID column")
+		}
+
+		if t.scopeIdentity && t.sequence != "" {
+			return fmt.Errorf("SCOPE_IDENTITY() and sequences can't be used together")
+		}
+
+		if t.keyGeneration == ClientGeneratedKeys {
+			return fmt.Errorf("SCOPE_IDENTITY() and sequences can't be used with client generated keys")
+		}
+	}
+
+	if t.sequence != "" {
+		if !sequenceNameRegex.MatchString(t.sequence) {
+			return fmt.Errorf("invalid sequence name: '%s'", t.sequence)
+		}
+
+		if t.keyGeneration == AutoIncrementKeys {
+			return fmt.Errorf("sequences can't be used with auto increment keys, since the IDs would never be sent")
+		}
+	}
+
+	switch t.keyGeneration {
+	case DetectKeyGeneration, AutoIncrementKeys:
+	case ClientGeneratedKeys:
+		if len(t.reselectColumns) > 0 {
+			return fmt.Errorf("reselect columns can't be used with client generated keys")
+		}
+	default:
+		return fmt.Errorf("invalid key generation: %d", t.keyGeneration)
+	}
+
 	return nil
 }
 
 func (t Table) insertMethodFor(dialect sqldialect.Provider) sqldialect.InsertMethod {
+	if t.keyGeneration == ClientGeneratedKeys {
+		// The IDs are already set on the record
+		return sqldialect.InsertWithNoIDRetrieval
+	}
+
 	if len(t.idColumns) == 1 {
 		return dialect.InsertMethod()
 	}
@@ -132,5 +607,46 @@ type ChunkParser struct {
 	//
 	// Where the actual Record type should be of a struct
 	// representing the rows you are expecting to receive.
+	//
+	// If the query returns a single column the Record type
+	// can also be a scalar type such as int64 or string.
+	//
+	// The function can also receive a ksql.Provider as second argument:
+	//
+	// `func(chunk []<Record>, tx ksql.Provider) error`
+	//
+	// In which case each chunk is processed in its own transaction,
+	// as explained on the documentation of the QueryChunks method.
 	ForEachChunk interface{}
+
+	// DeadlineHeadroom is optional, when it is set and the context has
+	// a deadline QueryChunks will only start loading a new chunk if the
+	// time left until the deadline is at least DeadlineHeadroom.
+	//
+	// Otherwise it will stop cleanly, closing the rows and returning
+	// a DeadlineApproachingError describing how many rows were processed.
+	DeadlineHeadroom time.Duration
+
+	// Timeout is optional, when it is set the whole QueryChunks call,
+	// including the ForEachChunk callbacks, must finish within this
+	// duration, otherwise it stops with a context.DeadlineExceeded error.
+	//
+	// If the context already has an earlier deadline it is kept.
+	Timeout time.Duration
+
+	// MaxChunks is optional, when it is set QueryChunks stops
+	// with a TooManyChunksError if the query returns more rows
+	// than what fits in MaxChunks chunks.
+	MaxChunks int
+
+	// FetchSize is optional, when it is set the rows are loaded from the
+	// database at most FetchSize rows at a time, so neither the server nor
+	// the client need to buffer the whole result of large queries.
+	//
+	// On Postgres this is done with a server-side cursor, which runs inside
+	// a transaction started by KSQL if the query is not already running
+	// inside one. On other databases it is only used if the adapter
+	// implements the FetchSizeQuerier interface, otherwise it is ignored,
+	// which is the case for all the adapters maintained by KSQL.
+	FetchSize int
 }
@@ -0,0 +1,365 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatementTimeoutQuerier can optionally be implemented by the DBAdapter in
+// order to support the Config.PropagateDeadlineToServer option on Postgres
+// outside of transactions.
+//
+// Queries can be sent on a single round trip, e.g. by pipelining them with
+// a call to `set_config('statement_timeout', $1, true)` on the same implicit
+// transaction. Statements sent with ExecContextWithStatementTimeout must not
+// run inside a transaction, since some of them, e.g. `VACUUM` or
+// `CREATE INDEX CONCURRENTLY`, fail inside transaction blocks, so the
+// timeout should be set on the session of a dedicated connection and
+// reset before the connection is reused.
+//
+// If it is not implemented the queries made outside of transactions are
+// wrapped in a short transaction that uses `SET LOCAL statement_timeout`,
+// and the deadline of the statements sent with Exec is not propagated.
+type StatementTimeoutQuerier interface {
+	QueryContextWithStatementTimeout(ctx context.Context, timeoutMS int64, query string, args ...interface{}) (Rows, error)
+	ExecContextWithStatementTimeout(ctx context.Context, timeoutMS int64, query string, args ...interface{}) (Result, error)
+}
+
+// queryContext works like c.db.QueryContext except that it routes the query using
+// the shard keys, checks the size limits and the RLS settings, tracks the
+// operation for CloseWithContext, wraps timeouts in a TimeoutError and
+// propagates the context deadline to the server if
+// Config.PropagateDeadlineToServer is set
+func (c DB) queryContext(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+	query, err := c.routeQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.checkQuerySize(query, params); err != nil {
+		return nil, err
+	}
+
+	ctx, done, err := c.trackOperation(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.untrackedQueryContext(ctx, query, params...)
+	if err != nil {
+		done()
+		return nil, wrapTimeoutError(ctx, err, 0)
+	}
+
+	return trackedRows{Rows: &timeoutRows{Rows: rows, ctx: ctx}, done: done}, nil
+}
+
+func (c DB) untrackedQueryContext(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+	if err := c.checkRLSSettings(ctx); err != nil {
+		return nil, err
+	}
+
+	timeoutMS, ok := c.serverTimeoutMS(ctx)
+	if !ok {
+		return c.db.QueryContext(ctx, query, params...)
+	}
+
+	switch c.dialect.DriverName() {
+	case "mysql":
+		return c.db.QueryContext(ctx, addMaxExecutionTimeHint(query, timeoutMS), params...)
+	case "postgres":
+		switch db := c.db.(type) {
+		case Tx:
+			restore, err := setStatementTimeoutInTx(ctx, db, timeoutMS)
+			if err != nil {
+				return nil, err
+			}
+
+			rows, err := db.QueryContext(ctx, query, params...)
+			if err != nil {
+				_ = restore()
+				return nil, err
+			}
+
+			return &restoreTimeoutRows{Rows: rows, restore: restore}, nil
+		case StatementTimeoutQuerier:
+			return db.QueryContextWithStatementTimeout(ctx, timeoutMS, query, params...)
+		case TxBeginner:
+			tx, err := beginTxWithStatementTimeout(ctx, db, timeoutMS)
+			if err != nil {
+				return nil, err
+			}
+
+			rows, err := tx.QueryContext(ctx, query, params...)
+			if err != nil {
+				_ = tx.Rollback(ctx)
+				return nil, err
+			}
+
+			return &txRows{Rows: rows, ctx: ctx, tx: tx}, nil
+		}
+	}
+
+	return c.db.QueryContext(ctx, query, params...)
+}
+
+// execContext works like c.db.ExecContext except that it routes the query using
+// the shard keys, checks the size limits and the RLS settings, tracks the
+// operation for CloseWithContext, wraps timeouts in a TimeoutError and
+// propagates the context deadline to the server if
+// Config.PropagateDeadlineToServer is set
+func (c DB) execContext(ctx context.Context, query string, params ...interface{}) (_ Result, err error) {
+	defer func() {
+		err = wrapTimeoutError(ctx, err, 0)
+	}()
+
+	query, err = c.routeQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.checkQuerySize(query, params); err != nil {
+		return nil, err
+	}
+
+	ctx, done, err := c.trackOperation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	if err := c.checkRLSSettings(ctx); err != nil {
+		return nil, err
+	}
+
+	timeoutMS, ok := c.serverTimeoutMS(ctx)
+	if !ok || c.dialect.DriverName() != "postgres" {
+		// The MAX_EXECUTION_TIME hint of MySQL only works for SELECT queries
+		// which are not expected to be sent with ExecContext.
+		return c.db.ExecContext(ctx, query, params...)
+	}
+
+	// Statements sent outside of transactions are never wrapped in a new
+	// transaction, since some of them, e.g. VACUUM, can't run inside one:
+
+	switch db := c.db.(type) {
+	case Tx:
+		restore, err := setStatementTimeoutInTx(ctx, db, timeoutMS)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := db.ExecContext(ctx, query, params...)
+		if err != nil {
+			_ = restore()
+			return nil, err
+		}
+
+		return result, restore()
+	case StatementTimeoutQuerier:
+		return db.ExecContextWithStatementTimeout(ctx, timeoutMS, query, params...)
+	}
+
+	return c.db.ExecContext(ctx, query, params...)
+}
+
+// serverTimeoutMS returns the number of milliseconds left until the context
+// deadline rounded up, and false if the deadline should not be propagated
+func (c DB) serverTimeoutMS(ctx context.Context) (int64, bool) {
+	if !c.config.PropagateDeadlineToServer {
+		return 0, false
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+
+	timeLeft := time.Until(deadline)
+	timeoutMS := int64((timeLeft + time.Millisecond - 1) / time.Millisecond)
+	if timeoutMS < 1 {
+		// Zero disables the timeout on both Postgres and MySQL,
+		// so we use the smallest timeout possible instead:
+		timeoutMS = 1
+	}
+
+	return timeoutMS, true
+}
+
+func beginTxWithStatementTimeout(ctx context.Context, db TxBeginner, timeoutMS int64) (Tx, error) {
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("KSQL: error starting transaction for propagating the context deadline: %w", err)
+	}
+
+	err = setLocalStatementTimeout(ctx, tx, timeoutMS)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// setStatementTimeoutInTx sets the statement_timeout of the transaction and
+// returns a function that restores its previous value, so the timeout is
+// only applied to the next statement instead of leaking to the following ones
+//
+// The previous value is read on the same round trip that sets the new one,
+// since the columns of a SELECT are evaluated from left to right.
+func setStatementTimeoutInTx(ctx context.Context, tx DBAdapter, timeoutMS int64) (restore func() error, _ error) {
+	rows, err := tx.QueryContext(ctx, setStatementTimeoutInTxQuery, strconv.FormatInt(timeoutMS, 10))
+	if err != nil {
+		return nil, fmt.Errorf("KSQL: error propagating the context deadline to the server: %w", err)
+	}
+	defer rows.Close()
+
+	var previous, current string
+	if rows.Next() {
+		err = rows.Scan(&previous, &current)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	if err == nil {
+		err = rows.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("KSQL: error propagating the context deadline to the server: %w", err)
+	}
+
+	return func() error {
+		_, err := tx.ExecContext(ctx, "SELECT set_config('statement_timeout', $1, true)", previous)
+		if err != nil {
+			return fmt.Errorf("KSQL: error restoring the statement_timeout after propagating the context deadline: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+const setStatementTimeoutInTxQuery = "SELECT current_setting('statement_timeout'), set_config('statement_timeout', $1, true)"
+
+func setLocalStatementTimeout(ctx context.Context, db DBAdapter, timeoutMS int64) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeoutMS))
+	if err != nil {
+		return fmt.Errorf("KSQL: error propagating the context deadline to the server: %w", err)
+	}
+	return nil
+}
+
+// addMaxExecutionTimeHint adds the MySQL optimizer hint for limiting
+// the execution time of SELECT queries, other queries are left unchanged.
+func addMaxExecutionTimeHint(query string, timeoutMS int64) string {
+	trimmedQuery := strings.TrimLeft(query, " \t\r\n")
+	if len(trimmedQuery) < len("SELECT ") || !strings.EqualFold(trimmedQuery[:len("SELECT")], "SELECT") {
+		return query
+	}
+
+	return fmt.Sprintf(
+		"%s /*+ MAX_EXECUTION_TIME(%d) */%s",
+		trimmedQuery[:len("SELECT")], timeoutMS, trimmedQuery[len("SELECT"):],
+	)
+}
+
+// txRows commits the transaction it was queried
+// from when it is closed, or rolls it back on errors
+type txRows struct {
+	Rows
+	ctx    context.Context
+	tx     Tx
+	closed bool
+}
+
+func (r *txRows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	err := r.Rows.Close()
+	if err == nil {
+		err = r.Rows.Err()
+	}
+	if err != nil {
+		_ = r.tx.Rollback(r.ctx)
+		return err
+	}
+
+	return r.tx.Commit(r.ctx)
+}
+
+// restoreTimeoutRows restores the statement_timeout of
+// the transaction it was queried from when it is closed
+type restoreTimeoutRows struct {
+	Rows
+	restore func() error
+	closed  bool
+}
+
+func (r *restoreTimeoutRows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	err := r.Rows.Close()
+	if err == nil {
+		err = r.Rows.Err()
+	}
+	if err != nil {
+		// The transaction is probably aborted, so we
+		// just report the error of the statement:
+		_ = r.restore()
+		return err
+	}
+
+	return r.restore()
+}
+
+// wrapTimeoutError wraps the error in a TimeoutError if
+// it was caused by the expiration of the context deadline
+func wrapTimeoutError(ctx context.Context, err error, rowsRead int) error {
+	if err == nil || ctx.Err() != context.DeadlineExceeded {
+		return err
+	}
+
+	var timeoutErr TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return err
+	}
+
+	return TimeoutError{
+		RowsRead: rowsRead,
+		Err:      err,
+	}
+}
+
+// timeoutRows counts the rows read so the timeouts that happen while
+// reading them can be told apart from the ones that happen while
+// waiting for the query, which for some drivers are only reported
+// on the first call to Next.
+type timeoutRows struct {
+	Rows
+	ctx      context.Context
+	rowsRead int
+}
+
+func (t *timeoutRows) Next() bool {
+	if !t.Rows.Next() {
+		return false
+	}
+	t.rowsRead++
+	return true
+}
+
+func (t *timeoutRows) Scan(args ...interface{}) error {
+	return wrapTimeoutError(t.ctx, t.Rows.Scan(args...), t.rowsRead)
+}
+
+func (t *timeoutRows) Err() error {
+	return wrapTimeoutError(t.ctx, t.Rows.Err(), t.rowsRead)
+}
@@ -0,0 +1,447 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestPropagateDeadlineToServer(t *testing.T) {
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	newFakeRows := func() Rows {
+		numRows := 1
+		return mockRows{
+			NextFn: func() bool {
+				numRows--
+				return numRows >= 0
+			},
+			ColumnsFn: func() ([]string, error) {
+				return []string{"id", "name"}, nil
+			},
+			ScanFn: func(args ...interface{}) error {
+				*args[0].(*int) = 42
+				*args[1].(*string) = "fakeName"
+				return nil
+			},
+		}
+	}
+
+	// newTimeoutRows returns the statement_timeout set before
+	// the deadline was propagated and the new one:
+	newTimeoutRows := func(timeoutMS string) Rows {
+		numRows := 1
+		return mockRows{
+			NextFn: func() bool {
+				numRows--
+				return numRows >= 0
+			},
+			ScanFn: func(args ...interface{}) error {
+				*args[0].(*string) = "5s"
+				*args[1].(*string) = timeoutMS + "ms"
+				return nil
+			},
+		}
+	}
+
+	// newFakeDB returns a DB that records all queries and transaction
+	// events, so we can check they were sent in the right order:
+	newFakeDB := func(dialect string, config Config) (DB, *[]string) {
+		var events []string
+		adapter := mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				if query == setStatementTimeoutInTxQuery {
+					events = append(events, fmt.Sprint("SET LOCAL statement_timeout = ", args[0]))
+					return newTimeoutRows(args[0].(string)), nil
+				}
+				events = append(events, query)
+				return newFakeRows(), nil
+			},
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				if len(args) > 0 {
+					query = fmt.Sprint(query, args)
+				}
+				events = append(events, query)
+				return mockResult{}, nil
+			},
+		}
+
+		db, err := NewWithAdapter(mockTxBeginner{
+			DBAdapter: adapter,
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				events = append(events, "BEGIN")
+				return mockTx{
+					DBAdapter: adapter,
+					CommitFn: func(ctx context.Context) error {
+						events = append(events, "COMMIT")
+						return nil
+					},
+					RollbackFn: func(ctx context.Context) error {
+						events = append(events, "ROLLBACK")
+						return nil
+					},
+				}, nil
+			},
+		}, sqldialect.SupportedDialects[dialect], config)
+		tt.AssertNoErr(t, err)
+
+		return db, &events
+	}
+
+	// The statement timeout is rounded up so
+	// it is always 10000ms or a little less:
+	assertTimeout := func(t *testing.T, query string) {
+		tt.AssertContains(t, query, "SET LOCAL statement_timeout = ")
+		tt.AssertEqual(t, strings.HasPrefix(query, "SET LOCAL statement_timeout = 99") ||
+			query == "SET LOCAL statement_timeout = 10000", true)
+	}
+
+	t.Run("should set statement_timeout on postgres queries outside transactions", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		db, events := newFakeDB("postgres", Config{PropagateDeadlineToServer: true})
+
+		var user User
+		err := db.QueryOne(ctx, &user, "FROM users WHERE id = $1", 42)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, user, User{ID: 42, Name: "fakeName"})
+
+		tt.AssertEqual(t, len(*events), 4)
+		tt.AssertEqual(t, (*events)[0], "BEGIN")
+		assertTimeout(t, (*events)[1])
+		tt.AssertEqual(t, (*events)[2], `SELECT "id", "name" FROM users WHERE id = $1`)
+		tt.AssertEqual(t, (*events)[3], "COMMIT")
+
+	})
+
+	t.Run("should not wrap statements outside transactions in a new transaction", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		db, events := newFakeDB("postgres", Config{PropagateDeadlineToServer: true})
+
+		// VACUUM fails if it runs inside a transaction block:
+		_, err := db.Exec(ctx, "VACUUM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, *events, []string{"VACUUM users"})
+	})
+
+	t.Run("should restore the statement_timeout after each statement of the current transaction", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		db, events := newFakeDB("postgres", Config{PropagateDeadlineToServer: true})
+
+		err := db.Transaction(ctx, func(db Provider) error {
+			var user User
+			err := db.QueryOne(ctx, &user, "FROM users WHERE id = $1", 42)
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Exec(ctx, "UPDATE users SET name = 'foo'")
+			return err
+		})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, len(*events), 8)
+		tt.AssertEqual(t, (*events)[0], "BEGIN")
+		assertTimeout(t, (*events)[1])
+		tt.AssertEqual(t, (*events)[2], `SELECT "id", "name" FROM users WHERE id = $1`)
+		tt.AssertEqual(t, (*events)[3], "SELECT set_config('statement_timeout', $1, true)[5s]")
+		assertTimeout(t, (*events)[4])
+		tt.AssertEqual(t, (*events)[5], "UPDATE users SET name = 'foo'")
+		tt.AssertEqual(t, (*events)[6], "SELECT set_config('statement_timeout', $1, true)[5s]")
+		tt.AssertEqual(t, (*events)[7], "COMMIT")
+	})
+
+	t.Run("should use the StatementTimeoutQuerier outside transactions if available", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var timeouts []int64
+		var queries []string
+		db, err := NewWithAdapter(mockStatementTimeoutQuerier{
+			QueryFn: func(ctx context.Context, timeoutMS int64, query string, args ...interface{}) (Rows, error) {
+				timeouts = append(timeouts, timeoutMS)
+				queries = append(queries, query)
+				return newFakeRows(), nil
+			},
+			ExecFn: func(ctx context.Context, timeoutMS int64, query string, args ...interface{}) (Result, error) {
+				timeouts = append(timeouts, timeoutMS)
+				queries = append(queries, query)
+				return mockResult{}, nil
+			},
+		}, sqldialect.SupportedDialects["postgres"], Config{PropagateDeadlineToServer: true})
+		tt.AssertNoErr(t, err)
+
+		var user User
+		err = db.QueryOne(ctx, &user, "FROM users WHERE id = $1", 42)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, user, User{ID: 42, Name: "fakeName"})
+
+		_, err = db.Exec(ctx, "UPDATE users SET name = 'foo'")
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, queries, []string{
+			`SELECT "id", "name" FROM users WHERE id = $1`,
+			"UPDATE users SET name = 'foo'",
+		})
+		for _, timeoutMS := range timeouts {
+			tt.AssertEqual(t, timeoutMS > 9900 && timeoutMS <= 10000, true)
+		}
+	})
+
+	t.Run("should add the MAX_EXECUTION_TIME hint on mysql", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		db, events := newFakeDB("mysql", Config{PropagateDeadlineToServer: true})
+
+		var user User
+		err := db.QueryOne(ctx, &user, "FROM users WHERE id = ?", 42)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, len(*events), 1)
+		tt.AssertContains(t, (*events)[0], "SELECT /*+ MAX_EXECUTION_TIME(", ") */ `id`, `name` FROM users WHERE id = ?")
+
+		*events = nil
+		_, err = db.Exec(ctx, "UPDATE users SET name = 'foo'")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, *events, []string{"UPDATE users SET name = 'foo'"})
+	})
+
+	t.Run("should not change the queries when there is no deadline or the option is disabled", func(t *testing.T) {
+		ctxWithDeadline, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		tests := []struct {
+			desc   string
+			ctx    context.Context
+			config Config
+		}{
+			{
+				desc:   "no deadline",
+				ctx:    context.Background(),
+				config: Config{PropagateDeadlineToServer: true},
+			},
+			{
+				desc:   "option disabled",
+				ctx:    ctxWithDeadline,
+				config: Config{},
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				db, events := newFakeDB("postgres", test.config)
+
+				var user User
+				err := db.QueryOne(test.ctx, &user, "FROM users WHERE id = $1", 42)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, *events, []string{`SELECT "id", "name" FROM users WHERE id = $1`})
+			})
+		}
+	})
+}
+
+type mockStatementTimeoutQuerier struct {
+	mockDBAdapter
+	QueryFn func(ctx context.Context, timeoutMS int64, query string, args ...interface{}) (Rows, error)
+	ExecFn  func(ctx context.Context, timeoutMS int64, query string, args ...interface{}) (Result, error)
+}
+
+func (m mockStatementTimeoutQuerier) QueryContextWithStatementTimeout(
+	ctx context.Context,
+	timeoutMS int64,
+	query string,
+	args ...interface{},
+) (Rows, error) {
+	return m.QueryFn(ctx, timeoutMS, query, args...)
+}
+
+func (m mockStatementTimeoutQuerier) ExecContextWithStatementTimeout(
+	ctx context.Context,
+	timeoutMS int64,
+	query string,
+	args ...interface{},
+) (Result, error) {
+	return m.ExecFn(ctx, timeoutMS, query, args...)
+}
+
+func TestAddMaxExecutionTimeHint(t *testing.T) {
+	tests := []struct {
+		query       string
+		expectQuery string
+	}{
+		{
+			query:       "SELECT * FROM users",
+			expectQuery: "SELECT /*+ MAX_EXECUTION_TIME(100) */ * FROM users",
+		},
+		{
+			query:       "\n\tselect id FROM users",
+			expectQuery: "select /*+ MAX_EXECUTION_TIME(100) */ id FROM users",
+		},
+		{
+			query:       "UPDATE users SET name = ?",
+			expectQuery: "UPDATE users SET name = ?",
+		},
+		{
+			query:       "WITH a AS (SELECT 1) SELECT * FROM a",
+			expectQuery: "WITH a AS (SELECT 1) SELECT * FROM a",
+		},
+	}
+	for _, test := range tests {
+		tt.AssertEqual(t, addMaxExecutionTimeHint(test.query, 100), test.expectQuery)
+	}
+}
+
+func TestTimeoutErrors(t *testing.T) {
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	// newFakeRows returns rows that block until the context
+	// expires after returning `numRows` rows:
+	newFakeRows := func(ctx context.Context, numRows int) Rows {
+		var driverErr error
+		return &mockRows{
+			NextFn: func() bool {
+				if numRows > 0 {
+					numRows--
+					return true
+				}
+				<-ctx.Done()
+				driverErr = errors.New("fakeCancelingStatementErr")
+				return false
+			},
+			ColumnsFn: func() ([]string, error) {
+				return []string{"id", "name"}, nil
+			},
+			ScanFn: func(args ...interface{}) error {
+				*args[0].(*int) = 42
+				*args[1].(*string) = "fakeName"
+				return nil
+			},
+			ErrFn: func() error {
+				return driverErr
+			},
+			CloseFn: func() error {
+				return nil
+			},
+		}
+	}
+
+	t.Run("should report timeouts while waiting for the query", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		db := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+					<-ctx.Done()
+					return nil, errors.New("fakeCancelingStatementErr")
+				},
+			},
+		}
+
+		var users []User
+		err := db.Query(ctx, &users, "FROM users")
+		tt.AssertEqual(t, errors.Is(err, ErrQueryTimeout), true)
+		tt.AssertEqual(t, errors.Is(err, ErrScanTimeout), false)
+		tt.AssertEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+		tt.AssertErrContains(t, err, "fakeCancelingStatementErr")
+	})
+
+	t.Run("should report timeouts before the first row as query timeouts", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		db := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+					return newFakeRows(ctx, 0), nil
+				},
+			},
+		}
+
+		var users []User
+		err := db.Query(ctx, &users, "FROM users")
+		tt.AssertEqual(t, errors.Is(err, ErrQueryTimeout), true)
+		tt.AssertEqual(t, errors.Is(err, ErrScanTimeout), false)
+	})
+
+	t.Run("should report timeouts while reading the rows", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		db := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+					return newFakeRows(ctx, 2), nil
+				},
+			},
+		}
+
+		var users []User
+		err := db.Query(ctx, &users, "FROM users")
+		tt.AssertEqual(t, errors.Is(err, ErrScanTimeout), true)
+		tt.AssertEqual(t, errors.Is(err, ErrQueryTimeout), false)
+		tt.AssertEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+
+		var timeoutErr TimeoutError
+		tt.AssertEqual(t, errors.As(err, &timeoutErr), true)
+		tt.AssertEqual(t, timeoutErr.RowsRead, 2)
+	})
+
+	t.Run("should report timeouts on Exec", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		db := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				},
+			},
+		}
+
+		_, err := db.Exec(ctx, "DELETE FROM users")
+		tt.AssertEqual(t, errors.Is(err, ErrQueryTimeout), true)
+	})
+
+	t.Run("should not wrap other errors", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		db := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+					return nil, errors.New("fakeErrMsg")
+				},
+			},
+		}
+
+		var users []User
+		err := db.Query(ctx, &users, "FROM users")
+		tt.AssertErrContains(t, err, "fakeErrMsg")
+		tt.AssertEqual(t, errors.Is(err, ErrQueryTimeout), false)
+		tt.AssertEqual(t, errors.Is(err, ErrScanTimeout), false)
+	})
+}
@@ -0,0 +1,164 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/vingarcia/ksql/internal/sqlparse"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+type defaultParamsKey struct{}
+
+// InjectDefaultParams adds params to the context that are bound
+// automatically to the named queries executed with DB.QueryNamed or DB.ExecNamed
+// that reference them with the `:name` syntax, e.g.:
+//
+//	queries := ksql.Queries{}.Add("ListOrders", "FROM orders WHERE tenant_id = :tenant_id AND status = $1")
+//
+//	ctx = ksql.InjectDefaultParams(ctx, map[string]interface{}{
+//		"tenant_id": 42,
+//	})
+//
+//	// Runs: FROM orders WHERE tenant_id = $2 AND status = $1
+//	err := db.QueryNamed(ctx, &orders, "ListOrders", "open")
+//
+// References to params that were not injected are sent unchanged, unless
+// the Config.RequireDefaultParams option is set, in which case KSQL returns
+// an error instead of running the query, so a forgotten tenant_id can't
+// cause the query to return the data of other tenants.
+//
+// Calling it on a context that already has default params
+// adds the new params to the existing ones.
+func InjectDefaultParams(ctx context.Context, params map[string]interface{}) context.Context {
+	previous, _ := ctx.Value(defaultParamsKey{}).(map[string]interface{})
+
+	// Copying so changes to the input map don't affect the context:
+	merged := make(map[string]interface{}, len(previous)+len(params))
+	for k, v := range previous {
+		merged[k] = v
+	}
+	for k, v := range params {
+		merged[k] = v
+	}
+
+	return context.WithValue(ctx, defaultParamsKey{}, merged)
+}
+
+// bindDefaultParams replaces the `:name` references on the query with
+// placeholders for the default params injected on the context.
+//
+// For dialects with numbered placeholders the values are appended after
+// the input params, for positional ones they are inserted in the position
+// where they are referenced.
+//
+// References to params that were not injected are kept unchanged
+// unless `required` is true, in which case an error is returned.
+func bindDefaultParams(
+	ctx context.Context,
+	dialect sqldialect.Provider,
+	query string,
+	params []interface{},
+	required bool,
+) (string, []interface{}, error) {
+	defaults, _ := ctx.Value(defaultParamsKey{}).(map[string]interface{})
+
+	prefix, numbered := placeholderPrefix(dialect)
+	syntax := querySyntax(dialect)
+
+	var b strings.Builder
+	var boundParams []interface{}
+	var nextPositional int
+	var referencedDefaults bool
+	indexByName := map[string]int{}
+	for i := 0; i < len(query); {
+		rest := query[i:]
+
+		skipLen := sqlparse.SkipLen(rest, syntax)
+		if skipLen == 0 && strings.HasPrefix(rest, "::") {
+			// Postgres casts, e.g. `created_at::date`
+			skipLen = 2
+		}
+		if skipLen > 0 {
+			b.WriteString(rest[:skipLen])
+			i += skipLen
+			continue
+		}
+
+		if !numbered && strings.HasPrefix(rest, prefix) {
+			if nextPositional < len(params) {
+				boundParams = append(boundParams, params[nextPositional])
+			}
+			nextPositional++
+			b.WriteString(prefix)
+			i += len(prefix)
+			continue
+		}
+
+		nameLen := 0
+		if rest[0] == ':' && (i == 0 || !isIdentifierByte(query[i-1])) && len(rest) > 1 && !isDigit(rest[1]) {
+			for nameLen+1 < len(rest) && isNameByte(rest[nameLen+1]) {
+				nameLen++
+			}
+		}
+		name := rest[1 : nameLen+1]
+		if nameLen == 0 || name == "ksql_columns" {
+			b.WriteByte(rest[0])
+			i++
+			continue
+		}
+
+		value, found := defaults[name]
+		if !found && required {
+			return "", nil, fmt.Errorf(
+				"the query references the param :%s, but it was not injected with ksql.InjectDefaultParams()",
+				name,
+			)
+		}
+		if !found {
+			b.WriteString(rest[:nameLen+1])
+			i += nameLen + 1
+			continue
+		}
+		referencedDefaults = true
+
+		if !numbered {
+			boundParams = append(boundParams, value)
+			b.WriteString(prefix)
+		} else {
+			idx, found := indexByName[name]
+			if !found {
+				idx = len(params) + len(boundParams)
+				indexByName[name] = idx
+				boundParams = append(boundParams, value)
+			}
+			b.WriteString(dialect.Placeholder(idx))
+		}
+		i += nameLen + 1
+	}
+
+	if !referencedDefaults {
+		return query, params, nil
+	}
+
+	if numbered {
+		return b.String(), append(append([]interface{}{}, params...), boundParams...), nil
+	}
+
+	// Params not referenced by any placeholder are kept at the end,
+	// so the driver can report the mismatch as usual:
+	if nextPositional < len(params) {
+		boundParams = append(boundParams, params[nextPositional:]...)
+	}
+
+	return b.String(), boundParams, nil
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
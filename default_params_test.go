@@ -0,0 +1,114 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestBindDefaultParams(t *testing.T) {
+	ctx := InjectDefaultParams(context.Background(), map[string]interface{}{
+		"tenant_id": 42,
+		"region":    "fakeRegion",
+	})
+
+	t.Run("should bind the default params", func(t *testing.T) {
+		tests := []struct {
+			desc           string
+			dialect        string
+			query          string
+			params         []interface{}
+			expectedQuery  string
+			expectedParams []interface{}
+		}{
+			{
+				desc:           "after the input params on numbered dialects",
+				dialect:        "postgres",
+				query:          "FROM orders WHERE tenant_id = :tenant_id AND status = $1",
+				params:         []interface{}{"open"},
+				expectedQuery:  "FROM orders WHERE tenant_id = $2 AND status = $1",
+				expectedParams: []interface{}{"open", 42},
+			},
+			{
+				desc:           "reusing the placeholder of repeated names",
+				dialect:        "sqlserver",
+				query:          "SELECT :tenant_id, :region WHERE tenant_id = :tenant_id",
+				expectedQuery:  "SELECT @p1, @p2 WHERE tenant_id = @p1",
+				expectedParams: []interface{}{42, "fakeRegion"},
+			},
+			{
+				desc:           "in the referenced position on positional dialects",
+				dialect:        "mysql",
+				query:          "FROM orders WHERE status = ? AND tenant_id = :tenant_id AND id > ? AND region = :region",
+				params:         []interface{}{"open", 10},
+				expectedQuery:  "FROM orders WHERE status = ? AND tenant_id = ? AND id > ? AND region = ?",
+				expectedParams: []interface{}{"open", 42, 10, "fakeRegion"},
+			},
+			{
+				desc:           "ignoring literals, comments, casts and the ksql_columns marker",
+				dialect:        "postgres",
+				query:          "SELECT :ksql_columns, ':region', created_at::date /* :region */ FROM orders WHERE arr[1:2] = $1 AND tenant_id = :tenant_id -- :region",
+				params:         []interface{}{"fakeArr"},
+				expectedQuery:  "SELECT :ksql_columns, ':region', created_at::date /* :region */ FROM orders WHERE arr[1:2] = $1 AND tenant_id = $2 -- :region",
+				expectedParams: []interface{}{"fakeArr", 42},
+			},
+			{
+				desc:           "ignoring literals with backslash escapes on mysql",
+				dialect:        "mysql",
+				query:          `FROM orders WHERE note <> 'it\'s :region' AND tenant_id = :tenant_id`,
+				expectedQuery:  `FROM orders WHERE note <> 'it\'s :region' AND tenant_id = ?`,
+				expectedParams: []interface{}{42},
+			},
+			{
+				desc:           "without changing queries with no references",
+				dialect:        "sqlite3",
+				query:          "FROM orders WHERE id = ?",
+				params:         []interface{}{1},
+				expectedQuery:  "FROM orders WHERE id = ?",
+				expectedParams: []interface{}{1},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				query, params, err := bindDefaultParams(ctx, sqldialect.SupportedDialects[test.dialect], test.query, test.params, true)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, query, test.expectedQuery)
+				tt.AssertEqual(t, params, test.expectedParams)
+			})
+		}
+	})
+
+	t.Run("should report params that were not injected if they are required", func(t *testing.T) {
+		_, _, err := bindDefaultParams(context.Background(), sqldialect.SupportedDialects["postgres"], "FROM orders WHERE tenant_id = :tenant_id", nil, true)
+		tt.AssertErrContains(t, err, ":tenant_id", "InjectDefaultParams")
+
+		_, _, err = bindDefaultParams(ctx, sqldialect.SupportedDialects["postgres"], "FROM orders WHERE user_id = :user_id", nil, true)
+		tt.AssertErrContains(t, err, ":user_id", "InjectDefaultParams")
+	})
+
+	t.Run("should keep the params that were not injected if they are not required", func(t *testing.T) {
+		query, params, err := bindDefaultParams(ctx, sqldialect.SupportedDialects["sqlite3"], "FROM orders WHERE user_id = :user_id AND status = ? AND tenant_id = :tenant_id", []interface{}{"open"}, false)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, "FROM orders WHERE user_id = :user_id AND status = ? AND tenant_id = ?")
+		tt.AssertEqual(t, params, []interface{}{"open", 42})
+
+		query, params, err = bindDefaultParams(context.Background(), sqldialect.SupportedDialects["postgres"], "FROM orders WHERE user_id = :user_id", nil, false)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, "FROM orders WHERE user_id = :user_id")
+		tt.AssertEqual(t, params, []interface{}(nil))
+	})
+
+	t.Run("should merge params injected multiple times", func(t *testing.T) {
+		ctx := InjectDefaultParams(ctx, map[string]interface{}{
+			"region": "otherRegion",
+			"userID": 7,
+		})
+
+		_, params, err := bindDefaultParams(ctx, sqldialect.SupportedDialects["postgres"], ":tenant_id :region :userID", nil, true)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, params, []interface{}{42, "otherRegion", 7})
+	})
+}
@@ -0,0 +1,256 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DeleteBlocker describes a foreign key whose rows would
+// prevent a record from being deleted, it is returned by
+// the DeleteCascadeCheck method.
+type DeleteBlocker struct {
+	// Table is the name of the table containing the referencing rows
+	Table string
+
+	// Constraint is the name of the foreign key constraint
+	Constraint string
+
+	// Columns are the columns of the foreign key on Table
+	Columns []string
+
+	// NumRows is the number of rows referencing the record
+	NumRows int
+}
+
+// foreignKeyColumn is a single column of a foreign key
+// as read from the metadata of the database
+type foreignKeyColumn struct {
+	ChildTable   string  `ksql:"child_table"`
+	Constraint   string  `ksql:"constraint_name"`
+	ChildColumn  string  `ksql:"child_column"`
+	ParentColumn *string `ksql:"parent_column"`
+	DeleteRule   string  `ksql:"delete_rule"`
+}
+
+type foreignKey struct {
+	childTable    string
+	constraint    string
+	childColumns  []string
+	parentColumns []string
+	deleteRule    string
+}
+
+// DeleteCascadeCheck checks if deleting the record would fail because of
+// foreign keys referencing it, and returns which tables would block it,
+// which allows APIs to return actionable errors instead of constraint
+// violations, e.g.:
+//
+//	blockers, err := db.DeleteCascadeCheck(ctx, UsersTable, userID)
+//	if err != nil {
+//		return err
+//	}
+//	if len(blockers) > 0 {
+//		return ConflictError{Message: "the user still has " + blockers[0].Table}
+//	}
+//
+//	err = db.Delete(ctx, UsersTable, userID)
+//
+// The idOrRecord argument works exactly as on the Delete method.
+//
+// The foreign keys are read from the metadata of the database and only the
+// ones without ON DELETE CASCADE, SET NULL or SET DEFAULT actions are checked.
+// Rows that would be deleted in cascade are not checked recursively.
+//
+// Since the check and the delete are separate statements, the result
+// might be outdated by the time the record is deleted, unless both
+// are executed inside a transaction that locks the referencing rows.
+func (c DB) DeleteCascadeCheck(
+	ctx context.Context,
+	table Table,
+	idOrRecord interface{},
+) ([]DeleteBlocker, error) {
+	table, err := c.resolveTable(ctx, table, idOrRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := table.validate(); err != nil {
+		return nil, fmt.Errorf("can't check deletes from ksql.Table: %w", err)
+	}
+
+	idMap, err := normalizeIDsAsMap(table.idColumns, idOrRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	foreignKeys, err := c.loadReferencingForeignKeys(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	var blockers []DeleteBlocker
+	for _, fk := range foreignKeys {
+		switch fk.deleteRule {
+		case "CASCADE", "SET NULL", "SET DEFAULT":
+			continue
+		}
+
+		// The conditions are built with EXISTS since the foreign key
+		// might reference unique columns other than the primary key:
+		var params []interface{}
+		var conditions []string
+		for _, id := range table.idColumns {
+			conditions = append(conditions,
+				"ksql_parent."+c.dialect.Escape(id)+" = "+AppendPlaceholder(c.dialect, &params, idMap[id]),
+			)
+		}
+		for i, col := range fk.childColumns {
+			conditions = append(conditions,
+				"ksql_child."+c.dialect.Escape(col)+" = ksql_parent."+c.dialect.Escape(fk.parentColumns[i]),
+			)
+		}
+
+		query := fmt.Sprintf(
+			"SELECT COUNT(*) AS count FROM %s ksql_child WHERE EXISTS (SELECT 1 FROM %s ksql_parent WHERE %s)",
+			c.dialect.Escape(fk.childTable),
+			table.name,
+			strings.Join(conditions, " AND "),
+		)
+
+		var result struct {
+			Count int `ksql:"count"`
+		}
+		err := c.QueryOne(withoutCallOptions(ctx), &result, query, params...)
+		if err != nil {
+			return nil, fmt.Errorf("KSQL: error counting the rows of '%s' referencing the record: %w", fk.childTable, err)
+		}
+
+		if result.Count > 0 {
+			blockers = append(blockers, DeleteBlocker{
+				Table:      fk.childTable,
+				Constraint: fk.constraint,
+				Columns:    fk.childColumns,
+				NumRows:    result.Count,
+			})
+		}
+	}
+
+	return blockers, nil
+}
+
+// loadReferencingForeignKeys reads the foreign keys that
+// reference the input table from the metadata of the database.
+func (c DB) loadReferencingForeignKeys(ctx context.Context, table Table) ([]foreignKey, error) {
+	// The schema is removed since the queries
+	// only check the tables of the current schema:
+	tableName := table.name[strings.LastIndex(table.name, ".")+1:]
+	tableName = strings.Trim(tableName, "\"`[]")
+
+	// All columns are aliased because MySQL 8 returns
+	// the information_schema columns in upper case:
+	var query string
+	switch c.dialect.DriverName() {
+	case "postgres":
+		query = `
+			SELECT
+				kcu.table_name AS child_table,
+				kcu.constraint_name AS constraint_name,
+				kcu.column_name AS child_column,
+				pk.column_name AS parent_column,
+				rc.delete_rule AS delete_rule
+			FROM information_schema.referential_constraints rc
+			JOIN information_schema.key_column_usage kcu
+				ON kcu.constraint_schema = rc.constraint_schema
+				AND kcu.constraint_name = rc.constraint_name
+			JOIN information_schema.key_column_usage pk
+				ON pk.constraint_schema = rc.unique_constraint_schema
+				AND pk.constraint_name = rc.unique_constraint_name
+				AND pk.ordinal_position = kcu.position_in_unique_constraint
+			WHERE pk.table_schema = current_schema() AND pk.table_name = $1
+			ORDER BY kcu.table_name, kcu.constraint_name, kcu.ordinal_position`
+	case "mysql":
+		query = `
+			SELECT
+				kcu.table_name AS child_table,
+				kcu.constraint_name AS constraint_name,
+				kcu.column_name AS child_column,
+				kcu.referenced_column_name AS parent_column,
+				rc.delete_rule AS delete_rule
+			FROM information_schema.key_column_usage kcu
+			JOIN information_schema.referential_constraints rc
+				ON rc.constraint_schema = kcu.constraint_schema
+				AND rc.constraint_name = kcu.constraint_name
+			WHERE kcu.referenced_table_schema = DATABASE() AND kcu.referenced_table_name = ?
+			ORDER BY kcu.table_name, kcu.constraint_name, kcu.ordinal_position`
+	case "sqlserver":
+		query = `
+			SELECT
+				OBJECT_NAME(fk.parent_object_id) AS child_table,
+				fk.name AS constraint_name,
+				COL_NAME(fkc.parent_object_id, fkc.parent_column_id) AS child_column,
+				COL_NAME(fkc.referenced_object_id, fkc.referenced_column_id) AS parent_column,
+				REPLACE(fk.delete_referential_action_desc, '_', ' ') AS delete_rule
+			FROM sys.foreign_keys fk
+			JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id
+			WHERE fk.referenced_object_id = OBJECT_ID(@p1)
+			ORDER BY child_table, constraint_name, fkc.constraint_column_id`
+	case "sqlite3":
+		// SQLite foreign keys have no names, so the
+		// id of the foreign key is used instead:
+		query = `
+			SELECT
+				m.name AS child_table,
+				CAST(fk.id AS TEXT) AS constraint_name,
+				fk."from" AS child_column,
+				fk."to" AS parent_column,
+				fk.on_delete AS delete_rule
+			FROM sqlite_master m
+			JOIN pragma_foreign_key_list(m.name) fk
+			WHERE m.type = 'table' AND fk."table" = ?
+			ORDER BY m.name, fk.id, fk.seq`
+	default:
+		return nil, fmt.Errorf("KSQL: DeleteCascadeCheck is not supported for the `%s` dialect", c.dialect.DriverName())
+	}
+
+	var columns []foreignKeyColumn
+	err := c.Query(withoutCallOptions(ctx), &columns, query, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("KSQL: error reading the foreign keys referencing the table '%s': %w", tableName, err)
+	}
+
+	var foreignKeys []foreignKey
+	for _, col := range columns {
+		last := len(foreignKeys) - 1
+		if last < 0 || foreignKeys[last].childTable != col.ChildTable || foreignKeys[last].constraint != col.Constraint {
+			foreignKeys = append(foreignKeys, foreignKey{
+				childTable: col.ChildTable,
+				constraint: col.Constraint,
+				deleteRule: strings.ToUpper(col.DeleteRule),
+			})
+			last++
+		}
+
+		// SQLite omits the parent column when the
+		// foreign key references the primary key:
+		parentColumn := ""
+		if col.ParentColumn != nil {
+			parentColumn = *col.ParentColumn
+		}
+		if parentColumn == "" {
+			idx := len(foreignKeys[last].childColumns)
+			if idx >= len(table.idColumns) {
+				return nil, fmt.Errorf(
+					"KSQL: the foreign key %s of table '%s' has more columns than the ID columns of '%s'",
+					col.Constraint, col.ChildTable, tableName,
+				)
+			}
+			parentColumn = table.idColumns[idx]
+		}
+
+		foreignKeys[last].childColumns = append(foreignKeys[last].childColumns, col.ChildColumn)
+		foreignKeys[last].parentColumns = append(foreignKeys[last].parentColumns, parentColumn)
+	}
+
+	return foreignKeys, nil
+}
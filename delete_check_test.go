@@ -0,0 +1,136 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestDeleteCascadeCheck(t *testing.T) {
+	ctx := context.Background()
+
+	// newDB returns a DB whose metadata query returns the input
+	// foreign key columns and whose count queries return numRows
+	newDB := func(driver string, fkColumns [][]interface{}, numRows int, queries *[]string, params *[][]interface{}) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects[driver],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, queryParams ...interface{}) (Rows, error) {
+					*queries = append(*queries, query)
+					*params = append(*params, queryParams)
+
+					rows := [][]interface{}{{numRows}}
+					if !strings.Contains(query, "COUNT(*)") {
+						rows = fkColumns
+					}
+
+					i := -1
+					return mockRows{
+						NextFn: func() bool {
+							i++
+							return i < len(rows)
+						},
+						ColumnsFn: func() ([]string, error) {
+							if len(rows[0]) == 1 {
+								return []string{"count"}, nil
+							}
+							return []string{"child_table", "constraint_name", "child_column", "parent_column", "delete_rule"}, nil
+						},
+						ScanFn: func(args ...interface{}) error {
+							for j, arg := range args {
+								reflect.ValueOf(arg).Elem().Set(reflect.ValueOf(rows[i][j]))
+							}
+							return nil
+						},
+					}, nil
+				},
+			},
+		}
+	}
+
+	strPtr := func(s string) *string { return &s }
+
+	t.Run("should count the rows of each foreign key that would block the delete", func(t *testing.T) {
+		var queries []string
+		var params [][]interface{}
+		c := newDB("postgres", [][]interface{}{
+			{"books", "books_author_fk", "author_id", strPtr("id"), "NO ACTION"},
+			{"reviews", "reviews_author_fk", "author_id", strPtr("id"), "CASCADE"},
+			{"prizes", "prizes_author_fk", "author_id", strPtr("id"), "SET NULL"},
+		}, 3, &queries, &params)
+
+		blockers, err := c.DeleteCascadeCheck(ctx, NewTable("authors"), 42)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, blockers, []DeleteBlocker{
+			{
+				Table:      "books",
+				Constraint: "books_author_fk",
+				Columns:    []string{"author_id"},
+				NumRows:    3,
+			},
+		})
+
+		tt.AssertEqual(t, len(queries), 2)
+		tt.AssertEqual(t, params[0], []interface{}{"authors"})
+		tt.AssertEqual(t, queries[1],
+			`SELECT COUNT(*) AS count FROM "books" ksql_child WHERE EXISTS (SELECT 1 FROM authors ksql_parent`+
+				` WHERE ksql_parent."id" = $1 AND ksql_child."author_id" = ksql_parent."id")`,
+		)
+		tt.AssertEqual(t, params[1], []interface{}{42})
+	})
+
+	t.Run("should group the columns of composite foreign keys", func(t *testing.T) {
+		var queries []string
+		var params [][]interface{}
+		c := newDB("sqlite3", [][]interface{}{
+			{"grants", "0", "user_id", (*string)(nil), "RESTRICT"},
+			{"grants", "0", "perm_id", (*string)(nil), "RESTRICT"},
+		}, 1, &queries, &params)
+
+		blockers, err := c.DeleteCascadeCheck(ctx, NewTable("user_permissions", "user_id", "perm_id"), map[string]interface{}{
+			"user_id": 1,
+			"perm_id": 2,
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(blockers), 1)
+		tt.AssertEqual(t, blockers[0].Columns, []string{"user_id", "perm_id"})
+
+		tt.AssertEqual(t, queries[1],
+			"SELECT COUNT(*) AS count FROM `grants` ksql_child WHERE EXISTS (SELECT 1 FROM user_permissions ksql_parent"+
+				" WHERE ksql_parent.`user_id` = ? AND ksql_parent.`perm_id` = ?"+
+				" AND ksql_child.`user_id` = ksql_parent.`user_id` AND ksql_child.`perm_id` = ksql_parent.`perm_id`)",
+		)
+		tt.AssertEqual(t, params[1], []interface{}{1, 2})
+	})
+
+	t.Run("should return no blockers when no rows reference the record", func(t *testing.T) {
+		var queries []string
+		var params [][]interface{}
+		c := newDB("mysql", [][]interface{}{
+			{"books", "books_author_fk", "author_id", strPtr("id"), "RESTRICT"},
+		}, 0, &queries, &params)
+
+		blockers, err := c.DeleteCascadeCheck(ctx, NewTable("authors"), 42)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(blockers), 0)
+	})
+
+	t.Run("should report errors", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					return nil, errors.New("fakeErrMsg")
+				},
+			},
+		}
+
+		_, err := c.DeleteCascadeCheck(ctx, NewTable("authors"), 42)
+		tt.AssertErrContains(t, err, "KSQL", "foreign keys", "authors", "fakeErrMsg")
+	})
+}
@@ -0,0 +1,187 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/vingarcia/ksql/internal/structs"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// DeleteReturning deletes a single record from the database and
+// fills the input struct with the values the record had before being deleted,
+// which is useful for audit logs and for implementing undo operations.
+//
+// The idOrRecord argument works exactly as on the Delete method and
+// the deletedRecord argument must be a pointer to a struct,
+// nested structs (i.e. the ones using the `tablename` tag) are not supported.
+//
+// Postgres uses the `DELETE ... RETURNING` clause and SQL Server uses
+// the `OUTPUT DELETED.*` clause, on the other dialects the record is
+// selected and then deleted inside a transaction.
+//
+// If no record is found ErrRecordNotFound is returned.
+func (c DB) DeleteReturning(
+	ctx context.Context,
+	table Table,
+	idOrRecord interface{},
+	deletedRecord interface{},
+) (err error) {
+	v := reflect.ValueOf(deletedRecord)
+	t := v.Type()
+	if err := assertStructPtr(t); err != nil {
+		return fmt.Errorf(
+			"KSQL: expected deletedRecord to be a pointer to struct, but got: %T",
+			deletedRecord,
+		)
+	}
+
+	if v.IsNil() {
+		return fmt.Errorf("KSQL: expected a valid pointer to struct as argument but received a nil pointer: %v", deletedRecord)
+	}
+
+	info, err := structs.GetTagInfo(t.Elem())
+	if err != nil {
+		return err
+	}
+
+	if info.IsNestedStruct {
+		return fmt.Errorf("KSQL: DeleteReturning doesn't support nested structs, but got: %T", deletedRecord)
+	}
+
+	table, err = c.resolveTable(ctx, table, idOrRecord)
+	if err != nil {
+		return err
+	}
+
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("can't delete from ksql.Table: %w", err)
+	}
+
+	idMap, err := normalizeIDsAsMap(table.idColumns, idOrRecord)
+	if err != nil {
+		return err
+	}
+
+	var columns []string
+	for i := 0; i < t.Elem().NumField(); i++ {
+		fieldInfo := info.ByIndex(i)
+		if !fieldInfo.Valid || fieldInfo.Expr != "" {
+			continue
+		}
+		columns = append(columns, c.dialect.Escape(fieldInfo.ColumnName))
+	}
+
+	if c.dialect.InsertMethod() != sqldialect.InsertWithReturning && c.dialect.InsertMethod() != sqldialect.InsertWithOutput {
+		return c.selectAndDelete(ctx, table, idMap, columns, deletedRecord)
+	}
+
+	query, params := buildDeleteQuery(c.dialect, table, idMap)
+
+	switch c.dialect.InsertMethod() {
+	case sqldialect.InsertWithReturning:
+		query += " RETURNING " + strings.Join(columns, ", ")
+	case sqldialect.InsertWithOutput:
+		for i := range columns {
+			columns[i] = "DELETED." + columns[i]
+		}
+		// The OUTPUT clause goes right before the FROM clause
+		// when the table has an alias, e.g. `DELETE u OUTPUT ... FROM users AS u`
+		outputPosition := " WHERE "
+		if table.alias != "" {
+			outputPosition = " FROM "
+		}
+		query = strings.Replace(
+			query, outputPosition, " OUTPUT "+strings.Join(columns, ", ")+outputPosition, 1,
+		)
+	}
+
+	var numRows int
+	defer ctxLog(ctx, query, params, time.Now(), &numRows, &err)
+	defer c.recordStats(query, &numRows, &err)
+
+	rows, err := c.queryContext(ctx, query, params...)
+	if err != nil {
+		return OpError{
+			Method: "DeleteReturning",
+			Table:  table.name,
+			Query:  query,
+			Err:    err,
+		}
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return ErrRecordNotFound
+	}
+
+	err = scanRowsWithConfig(ctx, c.dialect, c.config, rows, deletedRecord)
+	if err != nil {
+		return err
+	}
+	numRows = 1
+
+	return rows.Close()
+}
+
+// selectAndDelete is used by DeleteReturning on the dialects that don't
+// support returning the deleted rows, it selects the record with the same
+// WHERE clause of the delete query and then deletes it in a single transaction.
+//
+// On MySQL the record is selected with `FOR UPDATE`, since a plain SELECT
+// is a non-locking read and the record could be updated by a concurrent
+// transaction before being deleted. SQLite doesn't need it, since its
+// transactions can't write after another connection changed the database
+// since their first read, so the DELETE would fail instead.
+func (c DB) selectAndDelete(
+	ctx context.Context,
+	table Table,
+	idMap map[string]interface{},
+	columns []string,
+	deletedRecord interface{},
+) error {
+	whereClause, params := buildIDsWhereClause(c.dialect, table, idMap)
+
+	selectQuery := "SELECT " + strings.Join(columns, ", ") + " FROM " + table.nameWithAlias() + " WHERE " + whereClause
+	if c.dialect.DriverName() == "mysql" {
+		selectQuery += " FOR UPDATE"
+	}
+
+	deleteQuery := buildDeleteQueryWithWhere(c.dialect, table, whereClause)
+
+	return c.Transaction(ctx, func(db Provider) error {
+		// The select is built by KSQL, so the options
+		// injected by the user must not change it:
+		err := db.QueryOne(withoutCallOptions(ctx), deletedRecord, selectQuery, params...)
+		if err != nil {
+			return err
+		}
+
+		result, err := db.Exec(ctx, deleteQuery, params...)
+		if err != nil {
+			return OpError{
+				Method: "DeleteReturning",
+				Table:  table.name,
+				Query:  deleteQuery,
+				Err:    err,
+			}
+		}
+
+		n, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("unable to check if the record was succesfully deleted: %w", err)
+		}
+
+		if n == 0 {
+			return ErrRecordNotFound
+		}
+
+		return nil
+	})
+}
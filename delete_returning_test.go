@@ -0,0 +1,218 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestDeleteReturning(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+	usersTable := NewTable("users")
+
+	newFakeRows := func(numRows int) Rows {
+		return &mockRows{
+			NextFn: func() bool {
+				numRows--
+				return numRows >= 0
+			},
+			ColumnsFn: func() ([]string, error) {
+				return []string{"id", "name"}, nil
+			},
+			ScanFn: func(args ...interface{}) error {
+				*args[0].(*int) = 42
+				*args[1].(*string) = "fakeName"
+				return nil
+			},
+		}
+	}
+
+	t.Run("should use the RETURNING or OUTPUT clauses when available", func(t *testing.T) {
+		tests := []struct {
+			dialect     string
+			table       Table
+			expectQuery string
+		}{
+			{
+				dialect:     "postgres",
+				table:       usersTable,
+				expectQuery: `DELETE FROM users WHERE "id" = $1 RETURNING "id", "name"`,
+			},
+			{
+				dialect:     "sqlserver",
+				table:       usersTable,
+				expectQuery: `DELETE FROM users OUTPUT DELETED.[id], DELETED.[name] WHERE [id] = @p1`,
+			},
+			{
+				dialect:     "postgres",
+				table:       usersTable.WithAlias("u"),
+				expectQuery: `DELETE FROM users AS u WHERE u."id" = $1 RETURNING "id", "name"`,
+			},
+			{
+				dialect:     "sqlserver",
+				table:       usersTable.WithAlias("u"),
+				expectQuery: `DELETE u OUTPUT DELETED.[id], DELETED.[name] FROM users AS u WHERE u.[id] = @p1`,
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.dialect+" "+test.table.Alias(), func(t *testing.T) {
+				var queries []string
+				var params []interface{}
+				c := DB{
+					dialect: sqldialect.SupportedDialects[test.dialect],
+					db: mockDBAdapter{
+						QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+							queries = append(queries, query)
+							params = args
+							return newFakeRows(1), nil
+						},
+					},
+				}
+
+				var deletedUser User
+				err := c.DeleteReturning(ctx, test.table, 42, &deletedUser)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, deletedUser, User{ID: 42, Name: "fakeName"})
+				tt.AssertEqual(t, queries, []string{test.expectQuery})
+				tt.AssertEqual(t, params, []interface{}{42})
+			})
+		}
+	})
+
+	t.Run("should select and then delete in a transaction on mysql", func(t *testing.T) {
+		var queries []string
+		var committed bool
+		adapter := mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				queries = append(queries, query)
+				return newFakeRows(1), nil
+			},
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				queries = append(queries, query)
+				return mockResult{
+					RowsAffectedFn: func() (int64, error) {
+						return 1, nil
+					},
+				}, nil
+			},
+		}
+		c := DB{
+			dialect: sqldialect.SupportedDialects["mysql"],
+			db: mockTxBeginner{
+				DBAdapter: adapter,
+				BeginTxFn: func(ctx context.Context) (Tx, error) {
+					return mockTx{
+						DBAdapter: adapter,
+						CommitFn: func(ctx context.Context) error {
+							committed = true
+							return nil
+						},
+						RollbackFn: func(ctx context.Context) error {
+							return nil
+						},
+					}, nil
+				},
+			},
+		}
+
+		var deletedUser User
+		err := c.DeleteReturning(ctx, usersTable, 42, &deletedUser)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, deletedUser, User{ID: 42, Name: "fakeName"})
+		tt.AssertEqual(t, committed, true)
+		tt.AssertEqual(t, queries, []string{
+			"SELECT `id`, `name` FROM users WHERE `id` = ? FOR UPDATE",
+			"DELETE FROM users WHERE `id` = ?",
+		})
+	})
+
+	t.Run("should ignore the pagination options injected on the context on mysql", func(t *testing.T) {
+		var queries []string
+		adapter := mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				queries = append(queries, query)
+				return newFakeRows(1), nil
+			},
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				queries = append(queries, query)
+				return mockResult{
+					RowsAffectedFn: func() (int64, error) {
+						return 1, nil
+					},
+				}, nil
+			},
+		}
+		c := DB{
+			dialect: sqldialect.SupportedDialects["mysql"],
+			db: mockTxBeginner{
+				DBAdapter: adapter,
+				BeginTxFn: func(ctx context.Context) (Tx, error) {
+					return mockTx{
+						DBAdapter: adapter,
+						CommitFn: func(ctx context.Context) error {
+							return nil
+						},
+						RollbackFn: func(ctx context.Context) error {
+							return nil
+						},
+					}, nil
+				},
+			},
+		}
+
+		ctx := InjectOptions(ctx, OrderBy("name"), Limit(10))
+
+		var deletedUser User
+		err := c.DeleteReturning(ctx, usersTable, 42, &deletedUser)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, deletedUser, User{ID: 42, Name: "fakeName"})
+		tt.AssertEqual(t, queries, []string{
+			"SELECT `id`, `name` FROM users WHERE `id` = ? FOR UPDATE",
+			"DELETE FROM users WHERE `id` = ?",
+		})
+	})
+
+	t.Run("should return ErrRecordNotFound if nothing was deleted", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+					return newFakeRows(0), nil
+				},
+			},
+		}
+
+		var deletedUser User
+		err := c.DeleteReturning(ctx, usersTable, 42, &deletedUser)
+		tt.AssertEqual(t, err, ErrRecordNotFound)
+	})
+
+	t.Run("should report invalid arguments", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+		}
+
+		err := c.DeleteReturning(ctx, usersTable, 42, User{})
+		tt.AssertErrContains(t, err, "KSQL", "pointer to struct")
+
+		var nilUser *User
+		err = c.DeleteReturning(ctx, usersTable, 42, nilUser)
+		tt.AssertErrContains(t, err, "KSQL", "nil pointer")
+
+		type NestedUser struct {
+			User User `tablename:"u"`
+		}
+		err = c.DeleteReturning(ctx, usersTable, 42, &NestedUser{})
+		tt.AssertErrContains(t, err, "KSQL", "nested structs")
+
+		err = c.DeleteReturning(ctx, usersTable, map[string]interface{}{"name": "fakeName"}, &User{})
+		tt.AssertErrContains(t, err, "id")
+	})
+}
@@ -0,0 +1,184 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// Diff compares two instances of the same struct and returns a map
+// with the column names and the new values of all the attributes that
+// changed between `oldRecord` and `newRecord`.
+//
+// Both arguments must be of the same struct type or pointers to it,
+// and only attributes with a `ksql` tag are compared.
+//
+// Unlike Patch, pointer attributes that changed to nil are also
+// reported and their value on the returned map will be nil.
+func Diff(oldRecord interface{}, newRecord interface{}) (map[string]interface{}, error) {
+	oldValue, newValue, info, err := decodeDiffArgs(oldRecord, newRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffValues(oldValue, newValue, info), nil
+}
+
+func decodeDiffArgs(
+	oldRecord interface{},
+	newRecord interface{},
+) (oldValue reflect.Value, newValue reflect.Value, info structs.StructInfo, err error) {
+	oldValue, err = derefStructValue(oldRecord)
+	if err != nil {
+		return oldValue, newValue, info, fmt.Errorf("KSQL: invalid old record for Diff: %w", err)
+	}
+
+	newValue, err = derefStructValue(newRecord)
+	if err != nil {
+		return oldValue, newValue, info, fmt.Errorf("KSQL: invalid new record for Diff: %w", err)
+	}
+
+	if oldValue.Type() != newValue.Type() {
+		return oldValue, newValue, info, fmt.Errorf(
+			"KSQL: expected both records to have the same type but got: %v and %v",
+			oldValue.Type(), newValue.Type(),
+		)
+	}
+
+	info, err = structs.GetTagInfo(oldValue.Type())
+	if err != nil {
+		return oldValue, newValue, info, err
+	}
+
+	if info.IsNestedStruct {
+		return oldValue, newValue, info, fmt.Errorf(
+			"KSQL: can't compute the Diff of nested structs, got: %v",
+			oldValue.Type(),
+		)
+	}
+
+	return oldValue, newValue, info, nil
+}
+
+func derefStructValue(record interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(record)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, fmt.Errorf("expected a valid pointer to struct but received a nil pointer: %T", record)
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return v, fmt.Errorf("expected a struct or a pointer to struct, but got: %T", record)
+	}
+
+	return v, nil
+}
+
+func diffValues(oldValue reflect.Value, newValue reflect.Value, info structs.StructInfo) map[string]interface{} {
+	changes := map[string]interface{}{}
+	for i := 0; i < newValue.NumField(); i++ {
+		fieldInfo := info.ByIndex(i)
+		if !fieldInfo.Valid || fieldInfo.Expr != "" {
+			continue
+		}
+
+		oldField := oldValue.Field(i).Interface()
+		newField := newValue.Field(i)
+		if reflect.DeepEqual(oldField, newField.Interface()) {
+			continue
+		}
+
+		if newField.Kind() == reflect.Ptr {
+			if newField.IsNil() {
+				changes[fieldInfo.ColumnName] = nil
+				continue
+			}
+			newField = newField.Elem()
+		}
+
+		changes[fieldInfo.ColumnName] = newField.Interface()
+	}
+
+	return changes
+}
+
+// PatchDiff updates on the database only the attributes that changed
+// between `oldRecord` and `newRecord`, as reported by the `Diff` function.
+//
+// The ID attributes are read from `newRecord` and must be equal on both
+// records. If no attributes changed ErrNoValuesToUpdate is returned
+// and no query is sent to the database.
+//
+// Example Usage:
+//
+//	newUser := oldUser
+//	newUser.Name = "NewName"
+//
+//	// Will only update the `name` column:
+//	err := db.PatchDiff(ctx, UsersTable, oldUser, newUser)
+func (c DB) PatchDiff(
+	ctx context.Context,
+	table Table,
+	oldRecord interface{},
+	newRecord interface{},
+) error {
+	oldValue, newValue, info, err := decodeDiffArgs(oldRecord, newRecord)
+	if err != nil {
+		return err
+	}
+
+	err = runBeforeUpdate(ctx, newRecord)
+	if err != nil {
+		return err
+	}
+
+	table, err = c.resolveTable(ctx, table, newRecord)
+	if err != nil {
+		return err
+	}
+
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("can't update ksql.Table: %w", err)
+	}
+
+	recordMap := diffValues(oldValue, newValue, info)
+	for _, idName := range table.idColumns {
+		if _, changed := recordMap[idName]; changed {
+			return fmt.Errorf(
+				"KSQL: can't use PatchDiff to change the ID column `%s`, the IDs of both records must be equal",
+				idName,
+			)
+		}
+
+		// Missing IDs are reported by buildUpdateQuery:
+		idInfo := info.ByName(idName)
+		if !idInfo.Valid {
+			continue
+		}
+
+		idValue := newValue.Field(idInfo.Index)
+		if idValue.Kind() == reflect.Ptr {
+			if idValue.IsNil() {
+				continue
+			}
+			idValue = idValue.Elem()
+		}
+		recordMap[idName] = idValue.Interface()
+	}
+
+	query, params, err := buildUpdateQuery(ctx, c.dialect, table, info, recordMap, nil)
+	if err != nil {
+		return err
+	}
+
+	err = c.execUpdateQuery(ctx, "PatchDiff", table, query, params)
+	if err != nil {
+		return err
+	}
+
+	return runAfterUpdate(ctx, newRecord)
+}
@@ -0,0 +1,202 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestDiff(t *testing.T) {
+	type User struct {
+		ID      int               `ksql:"id"`
+		Name    string            `ksql:"name"`
+		Age     *int              `ksql:"age"`
+		Address map[string]string `ksql:"address,json"`
+
+		NotTagged string
+	}
+
+	age := 42
+	otherAge := 42
+	newAge := 43
+
+	tests := []struct {
+		desc           string
+		oldRecord      interface{}
+		newRecord      interface{}
+		expectedResult map[string]interface{}
+	}{
+		{
+			desc:           "should return an empty map if nothing changed",
+			oldRecord:      User{ID: 1, Name: "fakeName", Age: &age},
+			newRecord:      User{ID: 1, Name: "fakeName", Age: &otherAge},
+			expectedResult: map[string]interface{}{},
+		},
+		{
+			desc:      "should return only the attributes that changed",
+			oldRecord: User{ID: 1, Name: "fakeName", Age: &age},
+			newRecord: &User{ID: 1, Name: "newName", Age: &newAge},
+			expectedResult: map[string]interface{}{
+				"name": "newName",
+				"age":  43,
+			},
+		},
+		{
+			desc:      "should report pointers that changed to nil",
+			oldRecord: &User{ID: 1, Name: "fakeName", Age: &age},
+			newRecord: &User{ID: 1, Name: "fakeName"},
+			expectedResult: map[string]interface{}{
+				"age": nil,
+			},
+		},
+		{
+			desc:      "should compare complex attributes by value",
+			oldRecord: User{ID: 1, Address: map[string]string{"country": "BR"}},
+			newRecord: User{ID: 1, Address: map[string]string{"country": "US"}},
+			expectedResult: map[string]interface{}{
+				"address": map[string]string{"country": "US"},
+			},
+		},
+		{
+			desc:           "should ignore attributes without the ksql tag",
+			oldRecord:      User{ID: 1, NotTagged: "foo"},
+			newRecord:      User{ID: 1, NotTagged: "bar"},
+			expectedResult: map[string]interface{}{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			result, err := Diff(test.oldRecord, test.newRecord)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result, test.expectedResult)
+		})
+	}
+
+	t.Run("should report errors correctly", func(t *testing.T) {
+		type OtherUser struct {
+			ID int `ksql:"id"`
+		}
+
+		var nilUser *User
+		tests := []struct {
+			desc               string
+			oldRecord          interface{}
+			newRecord          interface{}
+			expectErrToContain []string
+		}{
+			{
+				desc:               "records of different types",
+				oldRecord:          User{},
+				newRecord:          OtherUser{},
+				expectErrToContain: []string{"same type", "User", "OtherUser"},
+			},
+			{
+				desc:               "nil pointer",
+				oldRecord:          nilUser,
+				newRecord:          User{},
+				expectErrToContain: []string{"old record", "nil pointer"},
+			},
+			{
+				desc:               "not a struct",
+				oldRecord:          User{},
+				newRecord:          42,
+				expectErrToContain: []string{"new record", "int"},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				_, err := Diff(test.oldRecord, test.newRecord)
+				tt.AssertErrContains(t, err, test.expectErrToContain...)
+			})
+		}
+	})
+}
+
+func TestPatchDiff(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+		Age  int    `ksql:"age"`
+	}
+	usersTable := NewTable("users")
+
+	newMockDB := func(query *string, params *[]interface{}, rowsAffected int64) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, q string, p ...interface{}) (Result, error) {
+					*query = q
+					*params = p
+					return mockResult{
+						RowsAffectedFn: func() (int64, error) {
+							return rowsAffected, nil
+						},
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should update only the attributes that changed", func(t *testing.T) {
+		var query string
+		var params []interface{}
+		c := newMockDB(&query, &params, 1)
+
+		oldUser := User{ID: 42, Name: "fakeName", Age: 20}
+		newUser := oldUser
+		newUser.Name = "newName"
+
+		err := c.PatchDiff(ctx, usersTable, oldUser, &newUser)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `UPDATE users SET "name" = $1 WHERE "id" = $2`)
+		tt.AssertEqual(t, params, []interface{}{"newName", 42})
+	})
+
+	t.Run("should return ErrNoValuesToUpdate if nothing changed", func(t *testing.T) {
+		var query string
+		var params []interface{}
+		c := newMockDB(&query, &params, 1)
+
+		user := User{ID: 42, Name: "fakeName", Age: 20}
+
+		err := c.PatchDiff(ctx, usersTable, user, user)
+		tt.AssertEqual(t, errors.Is(err, ErrNoValuesToUpdate), true)
+		tt.AssertEqual(t, query, "")
+	})
+
+	t.Run("should return ErrRecordNotFound if no rows were updated", func(t *testing.T) {
+		var query string
+		var params []interface{}
+		c := newMockDB(&query, &params, 0)
+
+		err := c.PatchDiff(ctx, usersTable, User{ID: 42}, User{ID: 42, Name: "newName"})
+		tt.AssertEqual(t, err, ErrRecordNotFound)
+	})
+
+	t.Run("should report error if the IDs are different", func(t *testing.T) {
+		var query string
+		var params []interface{}
+		c := newMockDB(&query, &params, 1)
+
+		err := c.PatchDiff(ctx, usersTable, User{ID: 42}, User{ID: 43, Name: "newName"})
+		tt.AssertErrContains(t, err, "PatchDiff", "id")
+		tt.AssertEqual(t, query, "")
+	})
+
+	t.Run("should report error if the IDs are missing", func(t *testing.T) {
+		var query string
+		var params []interface{}
+		c := newMockDB(&query, &params, 1)
+
+		err := c.PatchDiff(ctx, usersTable, User{}, User{Name: "newName"})
+		tt.AssertEqual(t, errors.Is(err, ErrRecordMissingIDs), true)
+		tt.AssertEqual(t, query, "")
+	})
+}
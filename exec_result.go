@@ -0,0 +1,98 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// ErrLastInsertIdUnsupported is returned by ExecResult.LastInsertId
+// for the databases that don't report the last inserted ID, i.e.
+// Postgres and SQL Server, where a `RETURNING` or `OUTPUT` clause
+// should be used instead.
+var ErrLastInsertIdUnsupported error = fmt.Errorf("ksql: LastInsertId is not supported by this database, use RETURNING or OUTPUT instead")
+
+// ExecResult wraps the Result returned by the adapter
+// with helpers for the most common ways of using it.
+//
+// It is returned by the DB.ExecWithResult method.
+type ExecResult struct {
+	Result
+
+	dialect sqldialect.Provider
+}
+
+// NewExecResult wraps the input result, which is useful
+// when calling the Exec method through the Provider interface:
+//
+//	result, err := db.Exec(ctx, "DELETE FROM sessions WHERE expires_at < $1", now)
+//	if err != nil {
+//		return err
+//	}
+//	numDeleted := ksql.NewExecResult(dialect, result).MustRowsAffected()
+func NewExecResult(dialect sqldialect.Provider, result Result) ExecResult {
+	return ExecResult{
+		Result:  result,
+		dialect: dialect,
+	}
+}
+
+// SupportsLastInsertId reports whether the database reports the
+// ID generated for the last inserted row with LastInsertId.
+func (e ExecResult) SupportsLastInsertId() bool {
+	switch e.dialect.DriverName() {
+	case "postgres", "sqlserver":
+		return false
+	}
+	return true
+}
+
+// LastInsertId works as Result.LastInsertId except that it returns
+// ErrLastInsertIdUnsupported for databases that don't support it,
+// instead of the adapter specific error.
+func (e ExecResult) LastInsertId() (int64, error) {
+	if !e.SupportsLastInsertId() {
+		return 0, ErrLastInsertIdUnsupported
+	}
+	return e.Result.LastInsertId()
+}
+
+// MustRowsAffected returns the number of rows affected by the query
+// and panics if the adapter is unable to report it, which only
+// happens for drivers that don't support this feature.
+func (e ExecResult) MustRowsAffected() int64 {
+	n, err := e.Result.RowsAffected()
+	if err != nil {
+		panic(fmt.Errorf("KSQL: unable to get the number of rows affected: %w", err))
+	}
+	return n
+}
+
+// HasRows reports whether the query affected at least one row,
+// which is useful for detecting updates and deletes of missing records.
+func (e ExecResult) HasRows() (bool, error) {
+	n, err := e.Result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("KSQL: unable to get the number of rows affected: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ExecWithResult works as the Exec method but returns
+// an ExecResult with helpers for using the result, e.g.:
+//
+//	result, err := db.ExecWithResult(ctx, "UPDATE users SET active = false WHERE id = $1", userID)
+//	if err != nil {
+//		return err
+//	}
+//
+//	found, err := result.HasRows()
+func (c DB) ExecWithResult(ctx context.Context, query string, params ...interface{}) (ExecResult, error) {
+	result, err := c.Exec(ctx, query, params...)
+	if err != nil {
+		return ExecResult{}, err
+	}
+
+	return NewExecResult(c.dialect, result), nil
+}
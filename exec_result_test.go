@@ -0,0 +1,103 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestExecResult(t *testing.T) {
+	ctx := context.Background()
+
+	newDB := func(driver string, result Result) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects[driver],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					return result, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should report the rows affected", func(t *testing.T) {
+		db := newDB("postgres", mockResult{
+			RowsAffectedFn: func() (int64, error) { return 3, nil },
+		})
+
+		result, err := db.ExecWithResult(ctx, "DELETE FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, result.MustRowsAffected(), int64(3))
+
+		hasRows, err := result.HasRows()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, hasRows, true)
+	})
+
+	t.Run("should report when no rows were affected", func(t *testing.T) {
+		result := NewExecResult(sqldialect.SupportedDialects["mysql"], mockResult{
+			RowsAffectedFn: func() (int64, error) { return 0, nil },
+		})
+
+		hasRows, err := result.HasRows()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, hasRows, false)
+	})
+
+	t.Run("should report errors from RowsAffected", func(t *testing.T) {
+		result := NewExecResult(sqldialect.SupportedDialects["mysql"], mockResult{
+			RowsAffectedFn: func() (int64, error) { return 0, fmt.Errorf("fakeErrMsg") },
+		})
+
+		_, err := result.HasRows()
+		tt.AssertErrContains(t, err, "KSQL", "fakeErrMsg")
+
+		panicPayload := tt.PanicHandler(func() {
+			result.MustRowsAffected()
+		})
+		err, ok := panicPayload.(error)
+		tt.AssertEqual(t, ok, true)
+		tt.AssertErrContains(t, err, "KSQL", "fakeErrMsg")
+	})
+
+	t.Run("should report if LastInsertId is supported", func(t *testing.T) {
+		fakeResult := mockResult{
+			LastInsertIdFn: func() (int64, error) { return 42, nil },
+		}
+
+		for _, driver := range []string{"postgres", "sqlserver"} {
+			result := NewExecResult(sqldialect.SupportedDialects[driver], fakeResult)
+			tt.AssertEqual(t, result.SupportsLastInsertId(), false)
+
+			_, err := result.LastInsertId()
+			tt.AssertEqual(t, errors.Is(err, ErrLastInsertIdUnsupported), true)
+		}
+
+		for _, driver := range []string{"mysql", "sqlite3"} {
+			result := NewExecResult(sqldialect.SupportedDialects[driver], fakeResult)
+			tt.AssertEqual(t, result.SupportsLastInsertId(), true)
+
+			id, err := result.LastInsertId()
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, id, int64(42))
+		}
+	})
+
+	t.Run("should forward errors from Exec", func(t *testing.T) {
+		db := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					return nil, fmt.Errorf("fakeExecErrMsg")
+				},
+			},
+		}
+
+		_, err := db.ExecWithResult(ctx, "DELETE FROM users")
+		tt.AssertErrContains(t, err, "fakeExecErrMsg")
+	})
+}
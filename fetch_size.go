@@ -0,0 +1,268 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// FetchSizeQuerier can optionally be implemented by the DBAdapter in order
+// to support the ChunkParser.FetchSize option using the driver-specific
+// settings for limiting how many rows are buffered at a time.
+//
+// If it is not implemented KSQL uses a server-side cursor on Postgres
+// and ignores the option on the other databases.
+type FetchSizeQuerier interface {
+	QueryContextWithFetchSize(ctx context.Context, fetchSize int, query string, args ...interface{}) (Rows, error)
+}
+
+var cursorCounter uint64
+
+// queryWithFetchSize works like c.queryContext except that it
+// limits the number of rows loaded at a time to `fetchSize`
+// when this is supported by the adapter or the database.
+func (c DB) queryWithFetchSize(ctx context.Context, fetchSize int, query string, params ...interface{}) (Rows, error) {
+	if fetchSize <= 0 {
+		return c.queryContext(ctx, query, params...)
+	}
+
+	if querier, ok := c.db.(FetchSizeQuerier); ok {
+		return c.queryWithFetchSizeQuerier(ctx, querier, fetchSize, query, params...)
+	}
+
+	if c.dialect.DriverName() != "postgres" {
+		return c.queryContext(ctx, query, params...)
+	}
+
+	// Postgres cursors only exist inside transactions:
+	txDB := c
+	var ownedTx Tx
+	done := func() {}
+	if _, isTx := c.db.(Tx); !isTx {
+		txBeginner, ok := c.db.(TxBeginner)
+		if !ok {
+			return c.queryContext(ctx, query, params...)
+		}
+
+		var err error
+		ctx, done, err = c.trackOperation(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		ownedTx, err = c.beginCursorTx(ctx, txBeginner)
+		if err != nil {
+			done()
+			return nil, err
+		}
+		txDB.db = ownedTx
+		txDB.outerAdapter = c.db
+	}
+
+	cursorName := fmt.Sprintf("ksql_cursor_%d", atomic.AddUint64(&cursorCounter, 1))
+	_, err := txDB.execContext(ctx, "DECLARE "+cursorName+" NO SCROLL CURSOR FOR "+query, params...)
+	if err != nil {
+		if ownedTx != nil {
+			_ = ownedTx.Rollback(ctx)
+		}
+		done()
+		return nil, err
+	}
+
+	return &timeoutRows{
+		Rows: &cursorRows{
+			ctx:        ctx,
+			db:         txDB,
+			ownedTx:    ownedTx,
+			done:       done,
+			cursorName: cursorName,
+			fetchSize:  fetchSize,
+		},
+		ctx: ctx,
+	}, nil
+}
+
+func (c DB) queryWithFetchSizeQuerier(
+	ctx context.Context,
+	querier FetchSizeQuerier,
+	fetchSize int,
+	query string,
+	params ...interface{},
+) (Rows, error) {
+	query, err := c.routeQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.checkQuerySize(query, params); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkRLSSettings(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, done, err := c.trackOperation(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := querier.QueryContextWithFetchSize(ctx, fetchSize, query, params...)
+	if err != nil {
+		done()
+		return nil, wrapTimeoutError(ctx, err, 0)
+	}
+
+	return trackedRows{Rows: &timeoutRows{Rows: rows, ctx: ctx}, done: done}, nil
+}
+
+// beginCursorTx starts the transaction the cursor is declared on,
+// applying the RLS settings of the context just like Transaction does.
+func (c DB) beginCursorTx(ctx context.Context, txBeginner TxBeginner) (Tx, error) {
+	tx, err := txBeginner.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("KSQL: error starting transaction for the FetchSize cursor: %w", err)
+	}
+
+	err = applyRLSSettings(ctx, c.dialect.DriverName(), tx)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// cursorRows reads the rows of a Postgres cursor
+// fetching `fetchSize` rows at a time.
+//
+// The db runs on the transaction the cursor was declared on. If this
+// transaction was started by KSQL it is the ownedTx, which is committed
+// and marked as done on Close.
+type cursorRows struct {
+	ctx        context.Context
+	db         DB
+	ownedTx    Tx
+	done       func()
+	cursorName string
+	fetchSize  int
+
+	current     Rows
+	columns     []string
+	fetchedRows int
+	finished    bool
+	closed      bool
+	err         error
+}
+
+// fetch loads the next batch of rows from the cursor.
+//
+// It skips the routing and the size checks of queryContext since the
+// FETCH statements don't contain the query of the user, and the timeouts
+// are reported by the timeoutRows wrapping the cursorRows.
+func (c *cursorRows) fetch() (Rows, error) {
+	return c.db.untrackedQueryContext(c.ctx, fmt.Sprintf("FETCH FORWARD %d FROM %s", c.fetchSize, c.cursorName))
+}
+
+func (c *cursorRows) Next() bool {
+	for {
+		if c.current != nil {
+			if c.current.Next() {
+				c.fetchedRows++
+				return true
+			}
+
+			c.err = c.current.Err()
+			if c.err == nil {
+				c.err = c.current.Close()
+			}
+			c.current = nil
+
+			// A partial batch means the cursor has no more rows:
+			if c.err != nil || c.fetchedRows < c.fetchSize {
+				c.finished = true
+			}
+		}
+
+		if c.finished {
+			return false
+		}
+
+		c.current, c.err = c.fetch()
+		if c.err != nil {
+			c.current = nil
+			c.finished = true
+			return false
+		}
+		c.fetchedRows = 0
+
+		if c.columns == nil {
+			c.columns, c.err = c.current.Columns()
+			if c.err != nil {
+				c.finished = true
+				return false
+			}
+		}
+	}
+}
+
+func (c *cursorRows) Scan(args ...interface{}) error {
+	if c.current == nil {
+		return fmt.Errorf("KSQL: Scan called without a preceding call to Next")
+	}
+	return c.current.Scan(args...)
+}
+
+func (c *cursorRows) Columns() ([]string, error) {
+	if c.columns == nil && !c.finished {
+		// The columns are only known after fetching the first batch,
+		// so we fetch it here without advancing to the first row:
+		c.current, c.err = c.fetch()
+		if c.err != nil {
+			c.current = nil
+			c.finished = true
+			return nil, c.err
+		}
+		c.fetchedRows = 0
+
+		c.columns, c.err = c.current.Columns()
+	}
+
+	return c.columns, c.err
+}
+
+func (c *cursorRows) Err() error {
+	return c.err
+}
+
+func (c *cursorRows) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	c.finished = true
+
+	err := c.err
+	if c.current != nil {
+		if closeErr := c.current.Close(); err == nil {
+			err = closeErr
+		}
+		c.current = nil
+	}
+
+	if err == nil {
+		_, err = c.db.execContext(c.ctx, "CLOSE "+c.cursorName)
+	}
+
+	if c.ownedTx == nil {
+		return err
+	}
+	defer c.done()
+
+	if err != nil {
+		_ = c.ownedTx.Rollback(c.ctx)
+		return err
+	}
+
+	return c.ownedTx.Commit(c.ctx)
+}
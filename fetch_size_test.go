@@ -0,0 +1,180 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestQueryChunksFetchSize(t *testing.T) {
+	type User struct {
+		ID int `ksql:"id"`
+	}
+
+	// newCursorAdapter mocks a Postgres connection with a
+	// cursor containing the input ids:
+	newCursorAdapter := func(ids []int, queries *[]string) mockDBAdapter {
+		return mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+				*queries = append(*queries, query)
+				return mockResult{}, nil
+			},
+			QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				*queries = append(*queries, query)
+
+				var fetchSize int
+				var cursorName string
+				_, err := fmt.Sscanf(query, "FETCH FORWARD %d FROM %s", &fetchSize, &cursorName)
+				if err != nil {
+					return nil, fmt.Errorf("unexpected query: %s", query)
+				}
+
+				batch := ids
+				if len(batch) > fetchSize {
+					batch = batch[:fetchSize]
+				}
+				ids = ids[len(batch):]
+
+				idx := -1
+				return mockRows{
+					ScanFn: func(args ...interface{}) error {
+						*(args[0].(*int)) = batch[idx]
+						return nil
+					},
+					NextFn: func() bool {
+						idx++
+						return idx < len(batch)
+					},
+					ColumnsFn: func() ([]string, error) { return []string{"id"}, nil },
+				}, nil
+			},
+		}
+	}
+
+	t.Run("should fetch the rows from a cursor inside a new transaction", func(t *testing.T) {
+		var queries []string
+		var committed bool
+		db := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockTxBeginner{
+				BeginTxFn: func(ctx context.Context) (Tx, error) {
+					return mockTx{
+						DBAdapter:  newCursorAdapter([]int{1, 2, 3, 4, 5}, &queries),
+						CommitFn:   func(ctx context.Context) error { committed = true; return nil },
+						RollbackFn: func(ctx context.Context) error { return nil },
+					}, nil
+				},
+			},
+		}
+
+		var chunks [][]User
+		err := db.QueryChunks(context.Background(), ChunkParser{
+			Query:     "SELECT id FROM users WHERE age > $1",
+			Params:    []interface{}{18},
+			ChunkSize: 3,
+			FetchSize: 2,
+			ForEachChunk: func(users []User) error {
+				chunks = append(chunks, append([]User(nil), users...))
+				return nil
+			},
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, chunks, [][]User{{{1}, {2}, {3}}, {{4}, {5}}})
+		tt.AssertEqual(t, committed, true)
+
+		tt.AssertEqual(t, len(queries), 5)
+		tt.AssertEqual(t, strings.HasPrefix(queries[0], "DECLARE ksql_cursor_"), true)
+		tt.AssertEqual(t, strings.HasSuffix(queries[0], " NO SCROLL CURSOR FOR SELECT id FROM users WHERE age > $1"), true)
+		cursorName := strings.Fields(queries[0])[1]
+		tt.AssertEqual(t, queries[1:], []string{
+			"FETCH FORWARD 2 FROM " + cursorName,
+			"FETCH FORWARD 2 FROM " + cursorName,
+			"FETCH FORWARD 2 FROM " + cursorName,
+			"CLOSE " + cursorName,
+		})
+	})
+
+	t.Run("should reuse the current transaction", func(t *testing.T) {
+		var queries []string
+		db := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockTx{
+				DBAdapter: newCursorAdapter([]int{1, 2}, &queries),
+				CommitFn: func(ctx context.Context) error {
+					return fmt.Errorf("the transaction should not be committed")
+				},
+			},
+		}
+
+		var users []User
+		err := db.QueryChunks(context.Background(), ChunkParser{
+			Query:     "SELECT id FROM users",
+			ChunkSize: 10,
+			FetchSize: 2,
+			ForEachChunk: func(chunk []User) error {
+				users = append(users, chunk...)
+				return nil
+			},
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, users, []User{{1}, {2}})
+		tt.AssertEqual(t, len(queries), 4)
+	})
+
+	t.Run("should use the FetchSizeQuerier when implemented by the adapter", func(t *testing.T) {
+		var receivedFetchSize int
+		db := DB{
+			dialect: sqldialect.SupportedDialects["mysql"],
+			db: mockFetchSizeQuerier{
+				QueryContextWithFetchSizeFn: func(ctx context.Context, fetchSize int, query string, params ...interface{}) (Rows, error) {
+					receivedFetchSize = fetchSize
+					return nil, fmt.Errorf("fakeErrMsg")
+				},
+			},
+		}
+
+		err := db.QueryChunks(context.Background(), ChunkParser{
+			Query:        "SELECT id FROM users",
+			ChunkSize:    10,
+			FetchSize:    100,
+			ForEachChunk: func(chunk []User) error { return nil },
+		})
+		tt.AssertErrContains(t, err, "fakeErrMsg")
+		tt.AssertEqual(t, receivedFetchSize, 100)
+	})
+
+	t.Run("should ignore the option on other databases", func(t *testing.T) {
+		var queries []string
+		db := DB{
+			dialect: sqldialect.SupportedDialects["mysql"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					queries = append(queries, query)
+					return nil, fmt.Errorf("fakeErrMsg")
+				},
+			},
+		}
+
+		err := db.QueryChunks(context.Background(), ChunkParser{
+			Query:        "SELECT id FROM users",
+			ChunkSize:    10,
+			FetchSize:    100,
+			ForEachChunk: func(chunk []User) error { return nil },
+		})
+		tt.AssertErrContains(t, err, "fakeErrMsg")
+		tt.AssertEqual(t, queries, []string{"SELECT id FROM users"})
+	})
+}
+
+type mockFetchSizeQuerier struct {
+	mockDBAdapter
+	QueryContextWithFetchSizeFn func(ctx context.Context, fetchSize int, query string, params ...interface{}) (Rows, error)
+}
+
+func (m mockFetchSizeQuerier) QueryContextWithFetchSize(ctx context.Context, fetchSize int, query string, params ...interface{}) (Rows, error) {
+	return m.QueryContextWithFetchSizeFn(ctx, fetchSize, query, params...)
+}
@@ -0,0 +1,118 @@
+package ksql
+
+import (
+	"context"
+	"sync"
+)
+
+// inFlightTracker counts the operations running on a DB instance
+// so CloseWithContext can wait for them before closing the adapter.
+type inFlightTracker struct {
+	mu       sync.Mutex
+	closing  bool
+	inFlight int
+	drained  chan struct{}
+}
+
+type inFlightKey struct{}
+
+// trackOperation registers a new operation on the tracker and returns a
+// context marking it, so the operations started inside it, e.g. the
+// transactions of each chunk on QueryChunks, are still accepted while
+// the DB is closing. The returned function must be called once the
+// operation finishes.
+//
+// Operations running inside transactions are not tracked individually
+// since the transaction itself is tracked.
+func (c DB) trackOperation(ctx context.Context) (context.Context, func(), error) {
+	if c.tracker == nil || ctx.Value(inFlightKey{}) != nil {
+		return ctx, func() {}, nil
+	}
+	if _, isTx := c.db.(Tx); isTx {
+		return ctx, func() {}, nil
+	}
+
+	t := c.tracker
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closing {
+		return ctx, nil, ErrDBClosing
+	}
+	t.inFlight++
+
+	var once sync.Once
+	return context.WithValue(ctx, inFlightKey{}, true), func() {
+		once.Do(t.done)
+	}, nil
+}
+
+func (t *inFlightTracker) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.inFlight--
+	if t.closing && t.inFlight == 0 {
+		close(t.drained)
+	}
+}
+
+// CloseWithContext stops accepting new operations, which fail with
+// ErrDBClosing, waits for the operations that are already running to
+// finish and then closes the adapter, which is useful for shutting
+// down servers gracefully:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	err := db.CloseWithContext(ctx)
+//
+// Operations are considered finished once their Rows are closed, and
+// transactions once they are committed or rolled back, so the operations
+// started inside a running transaction or QueryChunks call are still accepted.
+//
+// If the context is done before the operations finish the adapter
+// is closed anyway and the error of the context is returned.
+//
+// Only the DB instances created with New or NewWithAdapter
+// track their operations, for other instances it works as Close.
+func (c DB) CloseWithContext(ctx context.Context) error {
+	if c.tracker == nil {
+		return c.Close()
+	}
+
+	t := c.tracker
+	t.mu.Lock()
+	if !t.closing {
+		t.closing = true
+		if t.inFlight == 0 {
+			close(t.drained)
+		}
+	}
+	t.mu.Unlock()
+
+	var ctxErr error
+	select {
+	case <-t.drained:
+	case <-ctx.Done():
+		ctxErr = ctx.Err()
+	}
+
+	err := c.Close()
+	if err != nil {
+		return err
+	}
+
+	return ctxErr
+}
+
+// trackedRows finishes the operation that opened
+// it on the inFlightTracker when it is closed.
+type trackedRows struct {
+	Rows
+	done func()
+}
+
+func (t trackedRows) Close() error {
+	defer t.done()
+	return t.Rows.Close()
+}
@@ -0,0 +1,140 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+type mockClosableAdapter struct {
+	mockDBAdapter
+	CloseFn func() error
+}
+
+func (m mockClosableAdapter) Close() error {
+	return m.CloseFn()
+}
+
+func TestCloseWithContext(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID int `ksql:"id"`
+	}
+
+	newDB := func(t *testing.T, closed *bool) DB {
+		adapter := mockClosableAdapter{
+			mockDBAdapter: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					return mockRows{
+						NextFn:    func() bool { return false },
+						ColumnsFn: func() ([]string, error) { return []string{"id"}, nil },
+					}, nil
+				},
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					return mockResult{}, nil
+				},
+			},
+			CloseFn: func() error {
+				*closed = true
+				return nil
+			},
+		}
+
+		db, err := NewWithAdapter(adapter, sqldialect.SupportedDialects["postgres"])
+		tt.AssertNoErr(t, err)
+		return db
+	}
+
+	t.Run("should close immediately if there are no operations running", func(t *testing.T) {
+		var closed bool
+		db := newDB(t, &closed)
+
+		err := db.CloseWithContext(ctx)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, closed, true)
+	})
+
+	t.Run("should reject new operations after closing", func(t *testing.T) {
+		var closed bool
+		db := newDB(t, &closed)
+
+		err := db.CloseWithContext(ctx)
+		tt.AssertNoErr(t, err)
+
+		var users []User
+		err = db.Query(ctx, &users, "FROM users")
+		tt.AssertEqual(t, errors.Is(err, ErrDBClosing), true)
+
+		_, err = db.Exec(ctx, "DELETE FROM users")
+		tt.AssertEqual(t, errors.Is(err, ErrDBClosing), true)
+	})
+
+	t.Run("should wait for the running operations", func(t *testing.T) {
+		var closed bool
+		db := newDB(t, &closed)
+
+		rows, err := db.queryContext(ctx, "SELECT 1")
+		tt.AssertNoErr(t, err)
+
+		closeErrCh := make(chan error)
+		go func() {
+			closeErrCh <- db.CloseWithContext(ctx)
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		select {
+		case <-closeErrCh:
+			t.Fatal("CloseWithContext should wait for the rows to be closed")
+		default:
+		}
+
+		// Operations started inside the running
+		// operation should still be accepted:
+		markedCtx := context.WithValue(ctx, inFlightKey{}, true)
+		_, err = db.Exec(markedCtx, "UPDATE users SET name = 'foo'")
+		tt.AssertNoErr(t, err)
+
+		_, err = db.Exec(ctx, "UPDATE users SET name = 'foo'")
+		tt.AssertEqual(t, errors.Is(err, ErrDBClosing), true)
+
+		tt.AssertNoErr(t, rows.Close())
+		tt.AssertNoErr(t, <-closeErrCh)
+		tt.AssertEqual(t, closed, true)
+	})
+
+	t.Run("should close anyway when the context is done", func(t *testing.T) {
+		var closed bool
+		db := newDB(t, &closed)
+
+		_, err := db.queryContext(ctx, "SELECT 1")
+		tt.AssertNoErr(t, err)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		err = db.CloseWithContext(timeoutCtx)
+		tt.AssertEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+		tt.AssertEqual(t, closed, true)
+	})
+
+	t.Run("should not track operations inside transactions", func(t *testing.T) {
+		var closed bool
+		db := newDB(t, &closed)
+		db.db = mockTx{
+			DBAdapter:  db.db,
+			CommitFn:   func(ctx context.Context) error { return nil },
+			RollbackFn: func(ctx context.Context) error { return nil },
+		}
+
+		_, err := db.queryContext(ctx, "SELECT 1")
+		tt.AssertNoErr(t, err)
+
+		err = db.CloseWithContext(ctx)
+		tt.AssertNoErr(t, err)
+	})
+}
@@ -0,0 +1,128 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+)
+
+// The hooks below are only called by the methods listed on their docs,
+// Upsert and UpsertMany don't call any hooks since they can't tell
+// in advance if each record will be inserted or updated.
+
+// BeforeInserter can optionally be implemented by the records passed
+// to the Insert, InsertIgnore and QueryOneOrInsert methods, which call
+// BeforeInsert before building the query, so the changes it makes on
+// the record are inserted, e.g.:
+//
+//	func (u *User) BeforeInsert(ctx context.Context) error {
+//		u.CreatedAt = time.Now()
+//		return nil
+//	}
+//
+// InsertIgnore and QueryOneOrInsert call it even if the
+// insertion ends up being skipped due to a conflict.
+//
+// If it returns an error the record is not inserted.
+type BeforeInserter interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+// AfterInserter can optionally be implemented by the records passed
+// to the Insert, InsertIgnore and QueryOneOrInsert methods, which call
+// AfterInsert after the record is inserted and its IDs are set.
+//
+// It is not called if the insertion is skipped due to a conflict.
+//
+// The error it returns is returned by the method, but the
+// record is still inserted unless the caller rolls back
+// the transaction it was inserted on.
+type AfterInserter interface {
+	AfterInsert(ctx context.Context) error
+}
+
+// BeforeUpdater can optionally be implemented by the records passed to
+// the Patch, PatchOrNoop, PatchWithNewKeys and PatchDiff methods, which call
+// BeforeUpdate before building the query, so the changes it makes on the
+// record are saved.
+//
+// PatchDiff calls it on the new record before comparing it
+// with the old one, so the changes it makes are also saved.
+//
+// Note that the record must be passed by reference if the
+// method is implemented with a pointer receiver.
+//
+// If it returns an error the record is not updated.
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterUpdater can optionally be implemented by the records passed to
+// the Patch, PatchOrNoop, PatchWithNewKeys and PatchDiff methods, which call
+// AfterUpdate after the record is updated.
+//
+// The error it returns is returned by the method, but the
+// record is still updated unless the caller rolls back
+// the transaction it was updated on.
+type AfterUpdater interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+// runBeforeInsert calls the BeforeInsert hook of the
+// record if it implements the BeforeInserter interface
+func runBeforeInsert(ctx context.Context, record interface{}) error {
+	hook, ok := record.(BeforeInserter)
+	if !ok {
+		return nil
+	}
+
+	err := hook.BeforeInsert(ctx)
+	if err != nil {
+		return fmt.Errorf("KSQL: the BeforeInsert hook of %T returned an error: %w", record, err)
+	}
+	return nil
+}
+
+// runAfterInsert calls the AfterInsert hook of the
+// record if it implements the AfterInserter interface
+func runAfterInsert(ctx context.Context, record interface{}) error {
+	hook, ok := record.(AfterInserter)
+	if !ok {
+		return nil
+	}
+
+	err := hook.AfterInsert(ctx)
+	if err != nil {
+		return fmt.Errorf("KSQL: the AfterInsert hook of %T returned an error: %w", record, err)
+	}
+	return nil
+}
+
+// runBeforeUpdate calls the BeforeUpdate hook of the
+// record if it implements the BeforeUpdater interface
+func runBeforeUpdate(ctx context.Context, record interface{}) error {
+	hook, ok := record.(BeforeUpdater)
+	if !ok {
+		return nil
+	}
+
+	err := hook.BeforeUpdate(ctx)
+	if err != nil {
+		return fmt.Errorf("KSQL: the BeforeUpdate hook of %T returned an error: %w", record, err)
+	}
+	return nil
+}
+
+// runAfterUpdate calls the AfterUpdate hook of the
+// record if it implements the AfterUpdater interface
+func runAfterUpdate(ctx context.Context, record interface{}) error {
+	hook, ok := record.(AfterUpdater)
+	if !ok {
+		return nil
+	}
+
+	err := hook.AfterUpdate(ctx)
+	if err != nil {
+		return fmt.Errorf("KSQL: the AfterUpdate hook of %T returned an error: %w", record, err)
+	}
+	return nil
+}
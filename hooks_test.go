@@ -0,0 +1,210 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+type hookedUser struct {
+	ID   int    `ksql:"id"`
+	Name string `ksql:"name"`
+
+	calls     *[]string
+	hookErr   error
+	failingOn string
+}
+
+func (u *hookedUser) runHook(name string) error {
+	*u.calls = append(*u.calls, name)
+	if u.failingOn == name {
+		return u.hookErr
+	}
+	return nil
+}
+
+func (u *hookedUser) BeforeInsert(ctx context.Context) error {
+	u.Name = "before insert " + u.Name
+	return u.runHook("BeforeInsert")
+}
+
+func (u *hookedUser) AfterInsert(ctx context.Context) error {
+	return u.runHook("AfterInsert")
+}
+
+func (u *hookedUser) BeforeUpdate(ctx context.Context) error {
+	u.Name = "before update " + u.Name
+	return u.runHook("BeforeUpdate")
+}
+
+func (u *hookedUser) AfterUpdate(ctx context.Context) error {
+	return u.runHook("AfterUpdate")
+}
+
+func TestRecordHooks(t *testing.T) {
+	ctx := context.Background()
+
+	newMockDB := func(calls *[]string, params *[]interface{}) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects["sqlite3"],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, p ...interface{}) (Result, error) {
+					*calls = append(*calls, "query")
+					*params = p
+					return mockResult{
+						LastInsertIdFn: func() (int64, error) {
+							return 42, nil
+						},
+						RowsAffectedFn: func() (int64, error) {
+							return 1, nil
+						},
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should call the insert hooks around the query", func(t *testing.T) {
+		var calls []string
+		var params []interface{}
+		c := newMockDB(&calls, &params)
+
+		u := hookedUser{Name: "Ana", calls: &calls}
+		err := c.Insert(ctx, usersTable, &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{"BeforeInsert", "query", "AfterInsert"})
+		tt.AssertEqual(t, params, []interface{}{"before insert Ana"})
+		tt.AssertEqual(t, u.ID, 42)
+	})
+
+	t.Run("should call the update hooks around the query", func(t *testing.T) {
+		var calls []string
+		var params []interface{}
+		c := newMockDB(&calls, &params)
+
+		err := c.Patch(ctx, usersTable, &hookedUser{ID: 42, Name: "Ana", calls: &calls})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{"BeforeUpdate", "query", "AfterUpdate"})
+		tt.AssertEqual(t, params, []interface{}{"before update Ana", 42})
+
+		calls = nil
+		err = c.PatchWithNewKeys(ctx, usersTable, &hookedUser{ID: 42, Name: "Ana", calls: &calls}, NewKeys{"id": 43})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{"BeforeUpdate", "query", "AfterUpdate"})
+	})
+
+	t.Run("should call the insert hooks on InsertIgnore", func(t *testing.T) {
+		var calls []string
+		var params []interface{}
+		c := newMockDB(&calls, &params)
+
+		u := hookedUser{Name: "Ana", calls: &calls}
+		created, err := c.InsertIgnore(ctx, usersTable, &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, created, true)
+		tt.AssertEqual(t, calls, []string{"BeforeInsert", "query", "AfterInsert"})
+		tt.AssertEqual(t, params, []interface{}{"before insert Ana"})
+		tt.AssertEqual(t, u.ID, 42)
+	})
+
+	t.Run("should not call AfterInsert if InsertIgnore skips the record", func(t *testing.T) {
+		var calls []string
+		c := DB{
+			dialect: sqldialect.SupportedDialects["sqlite3"],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, p ...interface{}) (Result, error) {
+					calls = append(calls, "query")
+					return mockResult{
+						RowsAffectedFn: func() (int64, error) {
+							return 0, nil
+						},
+					}, nil
+				},
+			},
+		}
+
+		created, err := c.InsertIgnore(ctx, usersTable, &hookedUser{Name: "Ana", calls: &calls})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, created, false)
+		tt.AssertEqual(t, calls, []string{"BeforeInsert", "query"})
+	})
+
+	t.Run("should call the update hooks on PatchDiff", func(t *testing.T) {
+		var calls []string
+		var params []interface{}
+		c := newMockDB(&calls, &params)
+
+		oldUser := hookedUser{ID: 42, Name: "Ana"}
+		newUser := hookedUser{ID: 42, Name: "Bia", calls: &calls}
+		err := c.PatchDiff(ctx, usersTable, oldUser, &newUser)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{"BeforeUpdate", "query", "AfterUpdate"})
+		tt.AssertEqual(t, params, []interface{}{"before update Bia", 42})
+	})
+
+	t.Run("should not call hooks with pointer receivers on records passed by value", func(t *testing.T) {
+		var calls []string
+		var params []interface{}
+		c := newMockDB(&calls, &params)
+
+		err := c.Patch(ctx, usersTable, hookedUser{ID: 42, Name: "Ana", calls: &calls})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{"query"})
+	})
+
+	t.Run("should not run the query if a before hook fails", func(t *testing.T) {
+		var calls []string
+		var params []interface{}
+		c := newMockDB(&calls, &params)
+
+		err := c.Insert(ctx, usersTable, &hookedUser{
+			Name:      "Ana",
+			calls:     &calls,
+			failingOn: "BeforeInsert",
+			hookErr:   errors.New("fakeErrMsg"),
+		})
+		tt.AssertErrContains(t, err, "KSQL", "BeforeInsert", "hookedUser", "fakeErrMsg")
+		tt.AssertEqual(t, calls, []string{"BeforeInsert"})
+
+		calls = nil
+		err = c.Patch(ctx, usersTable, &hookedUser{
+			ID:        42,
+			Name:      "Ana",
+			calls:     &calls,
+			failingOn: "BeforeUpdate",
+			hookErr:   errors.New("fakeErrMsg"),
+		})
+		tt.AssertErrContains(t, err, "KSQL", "BeforeUpdate", "fakeErrMsg")
+		tt.AssertEqual(t, calls, []string{"BeforeUpdate"})
+	})
+
+	t.Run("should return the errors of the after hooks", func(t *testing.T) {
+		var calls []string
+		var params []interface{}
+		c := newMockDB(&calls, &params)
+
+		hookErr := errors.New("fakeErrMsg")
+		err := c.Insert(ctx, usersTable, &hookedUser{
+			Name:      "Ana",
+			calls:     &calls,
+			failingOn: "AfterInsert",
+			hookErr:   hookErr,
+		})
+		tt.AssertEqual(t, errors.Is(err, hookErr), true)
+		tt.AssertEqual(t, calls, []string{"BeforeInsert", "query", "AfterInsert"})
+
+		calls = nil
+		err = c.Patch(ctx, usersTable, &hookedUser{
+			ID:        42,
+			Name:      "Ana",
+			calls:     &calls,
+			failingOn: "AfterUpdate",
+			hookErr:   hookErr,
+		})
+		tt.AssertEqual(t, errors.Is(err, hookErr), true)
+		tt.AssertEqual(t, calls, []string{"BeforeUpdate", "query", "AfterUpdate"})
+	})
+}
@@ -0,0 +1,181 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/vingarcia/ksql/internal/structs"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// InsertIgnore works like Insert except that if the record conflicts
+// with an existing one on any unique constraint or primary key
+// the insertion is silently skipped and created is returned as false,
+// which is useful for idempotent ingestion of events.
+//
+// The IDs of the record are only updated if it was created.
+//
+// The SQL used depends on the dialect: Postgres and SQLite use
+// `INSERT ... ON CONFLICT DO NOTHING`, or `INSERT OR IGNORE` on SQLite for
+// records with no columns to insert, and MySQL uses `INSERT IGNORE`,
+// whose number of affected rows is 0 for skipped records even when the
+// `clientFoundRows` option of the driver is set. Note that on MySQL
+// `INSERT IGNORE` also turns some other errors into warnings, e.g.
+// values that don't fit on their columns are truncated instead of
+// causing an error.
+//
+// SQL Server has no equivalent syntax so it is not supported,
+// for conflicts on the primary key use Upsert with the
+// UpsertConfig.DoNothingOnMatch option instead.
+func (c DB) InsertIgnore(
+	ctx context.Context,
+	table Table,
+	record interface{},
+) (created bool, err error) {
+	v := reflect.ValueOf(record)
+	t := v.Type()
+	if err = assertStructPtr(t); err != nil {
+		return false, fmt.Errorf(
+			"KSQL: expected record to be a pointer to struct, but got: %T",
+			record,
+		)
+	}
+
+	if v.IsNil() {
+		return false, fmt.Errorf("KSQL: expected a valid pointer to struct as argument but received a nil pointer: %v", record)
+	}
+
+	err = runBeforeInsert(ctx, record)
+	if err != nil {
+		return false, err
+	}
+
+	table, err = c.resolveTable(ctx, table, record)
+	if err != nil {
+		return false, err
+	}
+
+	if err := table.validate(); err != nil {
+		return false, fmt.Errorf("can't insert in ksql.Table: %w", err)
+	}
+
+	var onConflictQuery string
+	switch c.dialect.DriverName() {
+	case "postgres", "sqlite3":
+		onConflictQuery = " ON CONFLICT DO NOTHING"
+	case "mysql":
+		// The IGNORE keyword is added after the query is built below
+	default:
+		return false, fmt.Errorf("KSQL: InsertIgnore is not supported for the `%s` dialect", c.dialect.DriverName())
+	}
+
+	info, err := structs.GetTagInfo(t.Elem())
+	if err != nil {
+		return false, err
+	}
+
+	query, params, scanValues, err := buildInsertQuery(ctx, c.dialect, table, t, v, info, record, onConflictQuery)
+	if err != nil {
+		return false, err
+	}
+
+	if c.dialect.DriverName() == "mysql" {
+		query = "INSERT IGNORE" + strings.TrimPrefix(query, "INSERT")
+	}
+
+	numRows := 0
+	defer ctxLog(ctx, query, params, time.Now(), &numRows, &err)
+	defer c.recordStats(query, &numRows, &err)
+
+	insertMethod := insertMethodForRecord(c.dialect, table, info, v)
+	if insertMethod == sqldialect.InsertWithReturning {
+		created, err = c.insertIgnoreReturningIDs(ctx, query, params, scanValues)
+	} else {
+		created, err = c.insertIgnoreWithExec(ctx, table, v, info, insertMethod, query, params)
+	}
+	if err != nil {
+		return false, OpError{
+			Method: "InsertIgnore",
+			Table:  table.name,
+			Query:  query,
+			Err:    err,
+		}
+	}
+
+	if !created {
+		return false, nil
+	}
+	numRows = 1
+
+	return true, runAfterInsert(ctx, record)
+}
+
+func (c DB) insertIgnoreReturningIDs(
+	ctx context.Context,
+	query string,
+	params []interface{},
+	scanValues []interface{},
+) (created bool, err error) {
+	rows, err := c.queryContext(ctx, query, params...)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		// No rows are returned when the insertion is skipped:
+		return false, rows.Err()
+	}
+
+	err = rows.Scan(scanValues...)
+	if err != nil {
+		return false, err
+	}
+
+	return true, rows.Close()
+}
+
+func (c DB) insertIgnoreWithExec(
+	ctx context.Context,
+	table Table,
+	v reflect.Value,
+	info structs.StructInfo,
+	insertMethod sqldialect.InsertMethod,
+	query string,
+	params []interface{},
+) (created bool, err error) {
+	reselectIDs := len(table.reselectColumns) > 0
+	var selectQuery string
+	var selectParams, scanValues []interface{}
+	if reselectIDs {
+		selectQuery, selectParams, scanValues, err = c.buildReselectIDsQuery(table, v, info)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	result, err := c.execContext(ctx, query, params...)
+	if err != nil {
+		return false, fmt.Errorf("error running insert query: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("unable to check if the record was created: %w", err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	switch {
+	case reselectIDs:
+		err = c.reselectIDs(ctx, selectQuery, selectParams, scanValues)
+	case insertMethod == sqldialect.InsertWithLastInsertID:
+		err = setLastInsertID(v, info, result, table.idColumns[0])
+	}
+
+	return true, err
+}
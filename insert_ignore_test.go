@@ -0,0 +1,194 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestInsertIgnore(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+	usersTable := NewTable("users")
+
+	t.Run("should use RETURNING on postgres", func(t *testing.T) {
+		tests := []struct {
+			desc          string
+			numRows       int
+			expectCreated bool
+			expectID      int
+		}{
+			{
+				desc:          "when the record is created",
+				numRows:       1,
+				expectCreated: true,
+				expectID:      42,
+			},
+			{
+				desc:          "when the record is ignored",
+				numRows:       0,
+				expectCreated: false,
+				expectID:      0,
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				numRows := test.numRows
+				var queries []string
+				c := DB{
+					dialect: sqldialect.SupportedDialects["postgres"],
+					db: mockDBAdapter{
+						QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+							queries = append(queries, query)
+							return &mockRows{
+								NextFn: func() bool {
+									numRows--
+									return numRows >= 0
+								},
+								ScanFn: func(args ...interface{}) error {
+									*args[0].(*int) = 42
+									return nil
+								},
+							}, nil
+						},
+					},
+				}
+
+				user := User{Name: "fakeName"}
+				created, err := c.InsertIgnore(ctx, usersTable, &user)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, created, test.expectCreated)
+				tt.AssertEqual(t, user.ID, test.expectID)
+				tt.AssertEqual(t, queries, []string{
+					`INSERT INTO users ("name") VALUES ($1) ON CONFLICT DO NOTHING RETURNING "id"`,
+				})
+			})
+		}
+	})
+
+	t.Run("should use the number of affected rows on mysql and sqlite", func(t *testing.T) {
+		tests := []struct {
+			dialect       string
+			rowsAffected  int64
+			expectQuery   string
+			expectCreated bool
+			expectID      int
+		}{
+			{
+				dialect:       "mysql",
+				rowsAffected:  1,
+				expectQuery:   "INSERT IGNORE INTO users (`name`) VALUES (?)",
+				expectCreated: true,
+				expectID:      42,
+			},
+			{
+				dialect:       "mysql",
+				rowsAffected:  0,
+				expectQuery:   "INSERT IGNORE INTO users (`name`) VALUES (?)",
+				expectCreated: false,
+				expectID:      0,
+			},
+			{
+				dialect:       "sqlite3",
+				rowsAffected:  1,
+				expectQuery:   "INSERT INTO users (`name`) VALUES (?) ON CONFLICT DO NOTHING",
+				expectCreated: true,
+				expectID:      42,
+			},
+			{
+				dialect:       "sqlite3",
+				rowsAffected:  0,
+				expectQuery:   "INSERT INTO users (`name`) VALUES (?) ON CONFLICT DO NOTHING",
+				expectCreated: false,
+				expectID:      0,
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.dialect, func(t *testing.T) {
+				var queries []string
+				c := DB{
+					dialect: sqldialect.SupportedDialects[test.dialect],
+					db: mockDBAdapter{
+						ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+							queries = append(queries, query)
+							return mockResult{
+								RowsAffectedFn: func() (int64, error) {
+									return test.rowsAffected, nil
+								},
+								LastInsertIdFn: func() (int64, error) {
+									return 42, nil
+								},
+							}, nil
+						},
+					},
+				}
+
+				user := User{Name: "fakeName"}
+				created, err := c.InsertIgnore(ctx, usersTable, &user)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, created, test.expectCreated)
+				tt.AssertEqual(t, user.ID, test.expectID)
+				tt.AssertEqual(t, queries, []string{test.expectQuery})
+			})
+		}
+	})
+
+	t.Run("should use INSERT OR IGNORE for records without columns on sqlite", func(t *testing.T) {
+		type Event struct {
+			ID int `ksql:"id"`
+		}
+
+		var queries []string
+		c := DB{
+			dialect: sqldialect.SupportedDialects["sqlite3"],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+					queries = append(queries, query)
+					return mockResult{
+						RowsAffectedFn: func() (int64, error) {
+							return 1, nil
+						},
+						LastInsertIdFn: func() (int64, error) {
+							return 42, nil
+						},
+					}, nil
+				},
+			},
+		}
+
+		var event Event
+		created, err := c.InsertIgnore(ctx, NewTable("events"), &event)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, created, true)
+		tt.AssertEqual(t, event.ID, 42)
+		tt.AssertEqual(t, queries, []string{"INSERT OR IGNORE INTO events DEFAULT VALUES"})
+	})
+
+	t.Run("should report unsupported dialects", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["sqlserver"],
+		}
+
+		_, err := c.InsertIgnore(ctx, usersTable, &User{Name: "fakeName"})
+		tt.AssertErrContains(t, err, "KSQL", "InsertIgnore", "sqlserver")
+	})
+
+	t.Run("should report invalid arguments", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+		}
+
+		_, err := c.InsertIgnore(ctx, usersTable, User{})
+		tt.AssertErrContains(t, err, "KSQL", "pointer to struct")
+
+		var nilUser *User
+		_, err = c.InsertIgnore(ctx, usersTable, nilUser)
+		tt.AssertErrContains(t, err, "KSQL", "nil pointer")
+	})
+}
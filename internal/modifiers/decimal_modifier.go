@@ -0,0 +1,70 @@
+package modifiers
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"strconv"
+
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+)
+
+// This modifier reads and writes NUMERIC/DECIMAL columns using
+// their text representation so no precision is lost on the way,
+// which would happen if the value was converted to a float64.
+//
+// It works with any type that implements encoding.TextMarshaler and
+// encoding.TextUnmarshaler, e.g. `decimal.Decimal` from the
+// github.com/shopspring/decimal package, and also with string attributes.
+var decimalModifier = ksqlmodifiers.AttrModifier{
+	Scan: func(ctx context.Context, opInfo ksqlmodifiers.OpInfo, attrPtr interface{}, dbValue interface{}) error {
+		if dbValue == nil {
+			return nil
+		}
+
+		var text string
+		switch v := dbValue.(type) {
+		case []byte:
+			text = string(v)
+		case string:
+			text = v
+		case int64:
+			text = strconv.FormatInt(v, 10)
+		case float64:
+			// Some drivers, e.g. sqlite3, only have floats for
+			// storing decimals so the precision is already lost,
+			// but we can at least avoid adding rounding errors:
+			text = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return fmt.Errorf("unexpected type received by the decimal modifier: %T", dbValue)
+		}
+
+		switch ptr := attrPtr.(type) {
+		case encoding.TextUnmarshaler:
+			return ptr.UnmarshalText([]byte(text))
+		case *string:
+			*ptr = text
+			return nil
+		}
+
+		return fmt.Errorf(
+			"the decimal modifier only works with attributes implementing encoding.TextUnmarshaler or strings, but got: %T",
+			attrPtr,
+		)
+	},
+
+	Value: func(ctx context.Context, opInfo ksqlmodifiers.OpInfo, inputValue interface{}) (outputValue interface{}, _ error) {
+		switch v := inputValue.(type) {
+		case encoding.TextMarshaler:
+			b, err := v.MarshalText()
+			return string(b), err
+		case string:
+			return v, nil
+		}
+
+		return nil, fmt.Errorf(
+			"the decimal modifier only works with attributes implementing encoding.TextMarshaler or strings, but got: %T",
+			inputValue,
+		)
+	},
+}
@@ -0,0 +1,110 @@
+package modifiers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+)
+
+// fakeDecimal mimics types like shopspring's decimal.Decimal
+// which implement the encoding.Text(Un)Marshaler interfaces.
+type fakeDecimal struct {
+	text string
+}
+
+func (f fakeDecimal) MarshalText() ([]byte, error) {
+	if f.text == "" {
+		return []byte("0"), nil
+	}
+	return []byte(f.text), nil
+}
+
+func (f *fakeDecimal) UnmarshalText(b []byte) error {
+	if strings.Contains(string(b), "invalid") {
+		return fmt.Errorf("fakeUnmarshalErrMsg")
+	}
+	f.text = string(b)
+	return nil
+}
+
+func TestDecimalModifier(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Scan", func(t *testing.T) {
+		tests := []struct {
+			desc         string
+			dbValue      interface{}
+			expectedText string
+		}{
+			{
+				desc:         "should scan bytes without losing precision",
+				dbValue:      []byte("12345678901234567890.123456789"),
+				expectedText: "12345678901234567890.123456789",
+			},
+			{
+				desc:         "should scan strings",
+				dbValue:      "-0.10",
+				expectedText: "-0.10",
+			},
+			{
+				desc:         "should scan integers",
+				dbValue:      int64(42),
+				expectedText: "42",
+			},
+			{
+				desc:         "should scan floats without exponents",
+				dbValue:      float64(0.000001),
+				expectedText: "0.000001",
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				var d fakeDecimal
+				err := decimalModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &d, test.dbValue)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, d.text, test.expectedText)
+
+				var s string
+				err = decimalModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &s, test.dbValue)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, s, test.expectedText)
+			})
+		}
+
+		t.Run("should ignore NULL values", func(t *testing.T) {
+			d := fakeDecimal{text: "1.5"}
+			err := decimalModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &d, nil)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, d.text, "1.5")
+		})
+
+		t.Run("should report errors from UnmarshalText", func(t *testing.T) {
+			var d fakeDecimal
+			err := decimalModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &d, "invalid")
+			tt.AssertErrContains(t, err, "fakeUnmarshalErrMsg")
+		})
+
+		t.Run("should report error for unsupported attributes", func(t *testing.T) {
+			var f float64
+			err := decimalModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &f, "1.5")
+			tt.AssertErrContains(t, err, "encoding.TextUnmarshaler", "*float64")
+		})
+	})
+
+	t.Run("Value", func(t *testing.T) {
+		value, err := decimalModifier.Value(ctx, ksqlmodifiers.OpInfo{}, fakeDecimal{text: "0.30"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, "0.30")
+
+		value, err = decimalModifier.Value(ctx, ksqlmodifiers.OpInfo{}, "12.5")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, "12.5")
+
+		_, err = decimalModifier.Value(ctx, ksqlmodifiers.OpInfo{}, 0.3)
+		tt.AssertErrContains(t, err, "encoding.TextMarshaler", "float64")
+	})
+}
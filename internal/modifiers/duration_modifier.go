@@ -0,0 +1,157 @@
+package modifiers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+)
+
+// This modifier maps time.Duration attributes to Postgres INTERVAL
+// and MySQL/SQLServer TIME columns, on sqlite3 which has no
+// equivalent type the duration is saved as an integer of nanoseconds.
+var durationModifier = ksqlmodifiers.AttrModifier{
+	Scan: func(ctx context.Context, opInfo ksqlmodifiers.OpInfo, attrPtr interface{}, dbValue interface{}) error {
+		durationPtr, ok := attrPtr.(*time.Duration)
+		if !ok {
+			return fmt.Errorf("the duration modifier only works with time.Duration attributes, but got: %T", attrPtr)
+		}
+
+		if dbValue == nil {
+			return nil
+		}
+
+		var d time.Duration
+		var err error
+		switch v := dbValue.(type) {
+		case int64:
+			d = time.Duration(v)
+		case time.Time:
+			// SQLServer returns TIME columns as a time.Time on 0001-01-01:
+			d = v.Sub(time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, v.Location()))
+		case []byte:
+			d, err = parseDBDuration(string(v))
+		case string:
+			d, err = parseDBDuration(v)
+		default:
+			return fmt.Errorf("unexpected type received by the duration modifier: %T", dbValue)
+		}
+		if err != nil {
+			return err
+		}
+
+		*durationPtr = d
+		return nil
+	},
+
+	Value: func(ctx context.Context, opInfo ksqlmodifiers.OpInfo, inputValue interface{}) (outputValue interface{}, _ error) {
+		d, ok := inputValue.(time.Duration)
+		if !ok {
+			return nil, fmt.Errorf("the duration modifier only works with time.Duration attributes, but got: %T", inputValue)
+		}
+
+		if opInfo.DriverName == "sqlite3" {
+			return int64(d), nil
+		}
+
+		return formatClockDuration(d), nil
+	},
+}
+
+// formatClockDuration formats the duration as `[-]HH:MM:SS.ffffff`
+// which is accepted by both the INTERVAL and TIME types, the number
+// of hours might be greater than 24.
+func formatClockDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+	seconds := d / time.Second
+	d -= seconds * time.Second
+
+	return fmt.Sprintf("%s%02d:%02d:%02d.%06d", sign, hours, minutes, seconds, d/time.Microsecond)
+}
+
+// parseDBDuration parses the text representation of a Postgres
+// INTERVAL, e.g. `1 day -02:03:04.5`, or a MySQL TIME, e.g. `-838:59:59`.
+//
+// Intervals containing months or years are rejected since they
+// don't have a fixed duration.
+func parseDBDuration(s string) (time.Duration, error) {
+	fields := strings.Fields(s)
+
+	var total time.Duration
+	for i := 0; i < len(fields); i++ {
+		if strings.Contains(fields[i], ":") {
+			d, err := parseClockDuration(fields[i])
+			if err != nil {
+				return 0, fmt.Errorf("unable to parse duration '%s': %w", s, err)
+			}
+			total += d
+			continue
+		}
+
+		if i+1 >= len(fields) {
+			return 0, fmt.Errorf("unable to parse duration '%s': missing unit after '%s'", s, fields[i])
+		}
+
+		n, err := strconv.ParseInt(fields[i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unable to parse duration '%s': %w", s, err)
+		}
+
+		i++
+		switch strings.TrimSuffix(fields[i], "s") {
+		case "day":
+			total += time.Duration(n) * 24 * time.Hour
+		default:
+			return 0, fmt.Errorf(
+				"unable to parse duration '%s': unit '%s' has no fixed duration and is not supported",
+				s, fields[i],
+			)
+		}
+	}
+
+	return total, nil
+}
+
+func parseClockDuration(s string) (time.Duration, error) {
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("expected format HH:MM:SS but got '%s'", s)
+	}
+
+	hours, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	d := time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second)+0.5)
+	if negative {
+		d = -d
+	}
+
+	return d, nil
+}
@@ -0,0 +1,102 @@
+package modifiers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+)
+
+func TestDurationModifier(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Scan", func(t *testing.T) {
+		tests := []struct {
+			desc             string
+			dbValue          interface{}
+			expectedDuration time.Duration
+		}{
+			{
+				desc:             "should parse postgres intervals",
+				dbValue:          []byte("01:02:03.5"),
+				expectedDuration: time.Hour + 2*time.Minute + 3500*time.Millisecond,
+			},
+			{
+				desc:             "should parse postgres intervals with days",
+				dbValue:          "2 days -01:00:00",
+				expectedDuration: 47 * time.Hour,
+			},
+			{
+				desc:             "should parse mysql negative times",
+				dbValue:          []byte("-838:59:59.000001"),
+				expectedDuration: -(838*time.Hour + 59*time.Minute + 59*time.Second + time.Microsecond),
+			},
+			{
+				desc:             "should parse sqlite nanoseconds",
+				dbValue:          int64(42),
+				expectedDuration: 42,
+			},
+			{
+				desc:             "should parse sqlserver times",
+				dbValue:          time.Date(1, 1, 1, 10, 30, 0, 0, time.UTC),
+				expectedDuration: 10*time.Hour + 30*time.Minute,
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				var d time.Duration
+				err := durationModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &d, test.dbValue)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, d, test.expectedDuration)
+			})
+		}
+
+		t.Run("should ignore NULL values", func(t *testing.T) {
+			d := time.Second
+			err := durationModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &d, nil)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, d, time.Second)
+		})
+
+		t.Run("should report error for units without a fixed duration", func(t *testing.T) {
+			var d time.Duration
+			err := durationModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &d, "1 mon 2 days")
+			tt.AssertErrContains(t, err, "mon", "not supported")
+		})
+
+		t.Run("should report error for attributes that are not durations", func(t *testing.T) {
+			var i int64
+			err := durationModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &i, int64(10))
+			tt.AssertErrContains(t, err, "time.Duration", "*int64")
+		})
+	})
+
+	t.Run("Value", func(t *testing.T) {
+		d := -(26*time.Hour + 3*time.Minute + 4*time.Second + 5*time.Microsecond)
+
+		value, err := durationModifier.Value(ctx, ksqlmodifiers.OpInfo{DriverName: "postgres"}, d)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, "-26:03:04.000005")
+
+		value, err = durationModifier.Value(ctx, ksqlmodifiers.OpInfo{DriverName: "sqlite3"}, d)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, int64(d))
+
+		_, err = durationModifier.Value(ctx, ksqlmodifiers.OpInfo{}, "1h")
+		tt.AssertErrContains(t, err, "time.Duration", "string")
+	})
+
+	t.Run("should round trip through its text representation", func(t *testing.T) {
+		original := 100*time.Hour + 123456*time.Microsecond
+
+		value, err := durationModifier.Value(ctx, ksqlmodifiers.OpInfo{DriverName: "mysql"}, original)
+		tt.AssertNoErr(t, err)
+
+		var d time.Duration
+		err = durationModifier.Scan(ctx, ksqlmodifiers.OpInfo{DriverName: "mysql"}, &d, value)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, d, original)
+	})
+}
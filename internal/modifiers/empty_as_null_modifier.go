@@ -0,0 +1,47 @@
+package modifiers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+)
+
+// This modifier saves empty strings as NULL on insertions
+// and updates, and reads NULL values back as empty strings,
+// which is useful for optional fields of submitted forms.
+var emptyAsNullModifier = ksqlmodifiers.AttrModifier{
+	Scan: func(ctx context.Context, opInfo ksqlmodifiers.OpInfo, attrPtr interface{}, dbValue interface{}) error {
+		var str string
+		switch v := dbValue.(type) {
+		case nil:
+		case string:
+			str = v
+		case []byte:
+			str = string(v)
+		default:
+			return fmt.Errorf("unexpected type received by the emptyAsNull modifier: %T", dbValue)
+		}
+
+		switch ptr := attrPtr.(type) {
+		case *string:
+			*ptr = str
+		case **string:
+			*ptr = nil
+			if dbValue != nil {
+				*ptr = &str
+			}
+		default:
+			return fmt.Errorf("the emptyAsNull modifier only works with string attributes, but got: %T", attrPtr)
+		}
+
+		return nil
+	},
+
+	Value: func(ctx context.Context, opInfo ksqlmodifiers.OpInfo, inputValue interface{}) (outputValue interface{}, _ error) {
+		if str, ok := inputValue.(string); ok && str == "" {
+			return nil, nil
+		}
+		return inputValue, nil
+	},
+}
@@ -0,0 +1,64 @@
+package modifiers
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+)
+
+func TestEmptyAsNullModifier(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Value", func(t *testing.T) {
+		value, err := emptyAsNullModifier.Value(ctx, ksqlmodifiers.OpInfo{}, "")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, nil)
+
+		value, err = emptyAsNullModifier.Value(ctx, ksqlmodifiers.OpInfo{}, "fakeValue")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, "fakeValue")
+	})
+
+	t.Run("Scan", func(t *testing.T) {
+		t.Run("should scan NULL as empty strings", func(t *testing.T) {
+			str := "notEmpty"
+			err := emptyAsNullModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &str, nil)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, str, "")
+		})
+
+		t.Run("should scan strings and bytes", func(t *testing.T) {
+			var str string
+			err := emptyAsNullModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &str, "fakeString")
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, str, "fakeString")
+
+			err = emptyAsNullModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &str, []byte("fakeBytes"))
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, str, "fakeBytes")
+		})
+
+		t.Run("should work with string pointers", func(t *testing.T) {
+			var strPtr *string
+			err := emptyAsNullModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &strPtr, "fakeString")
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, *strPtr, "fakeString")
+
+			err = emptyAsNullModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &strPtr, nil)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, strPtr == nil, true)
+		})
+
+		t.Run("should report unsupported types", func(t *testing.T) {
+			var i int
+			err := emptyAsNullModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &i, "fakeString")
+			tt.AssertErrContains(t, err, "emptyAsNull", "*int")
+
+			var str string
+			err = emptyAsNullModifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &str, 42)
+			tt.AssertErrContains(t, err, "emptyAsNull", "int")
+		})
+	})
+}
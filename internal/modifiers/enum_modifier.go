@@ -0,0 +1,86 @@
+package modifiers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+)
+
+// newEnumModifier builds the modifier for tags like `enum(active|disabled)`
+// which only allows saving the listed values, and if validateScan is true
+// it also validates the values read from the database.
+//
+// It works with string attributes, including custom string types.
+func newEnumModifier(args string, validateScan bool) (ksqlmodifiers.AttrModifier, error) {
+	allowedValues := strings.Split(args, "|")
+	isAllowed := map[string]bool{}
+	for _, value := range allowedValues {
+		if value == "" {
+			return ksqlmodifiers.AttrModifier{}, fmt.Errorf("the enum modifier expects a list of non empty values separated by '|', but got: '%s'", args)
+		}
+		isAllowed[value] = true
+	}
+
+	validate := func(value string) error {
+		if !isAllowed[value] {
+			return ksqlmodifiers.InvalidEnumValueError{
+				Value:         value,
+				AllowedValues: allowedValues,
+			}
+		}
+		return nil
+	}
+
+	modifier := ksqlmodifiers.AttrModifier{
+		Value: func(ctx context.Context, opInfo ksqlmodifiers.OpInfo, inputValue interface{}) (outputValue interface{}, _ error) {
+			if inputValue == nil {
+				return nil, nil
+			}
+
+			v := reflect.ValueOf(inputValue)
+			if v.Kind() != reflect.String {
+				return nil, fmt.Errorf("the enum modifier only works with string attributes, but got: %T", inputValue)
+			}
+
+			return v.String(), validate(v.String())
+		},
+	}
+
+	if validateScan {
+		modifier.Scan = func(ctx context.Context, opInfo ksqlmodifiers.OpInfo, attrPtr interface{}, dbValue interface{}) error {
+			var value string
+			switch v := dbValue.(type) {
+			case nil:
+				return nil
+			case string:
+				value = v
+			case []byte:
+				value = string(v)
+			default:
+				return fmt.Errorf("unexpected type received by the enum modifier: %T", dbValue)
+			}
+
+			err := validate(value)
+			if err != nil {
+				return err
+			}
+
+			attr := reflect.ValueOf(attrPtr).Elem()
+			if attr.Kind() == reflect.Ptr {
+				attr.Set(reflect.New(attr.Type().Elem()))
+				attr = attr.Elem()
+			}
+			if attr.Kind() != reflect.String {
+				return fmt.Errorf("the enum modifier only works with string attributes, but got: %T", attrPtr)
+			}
+
+			attr.SetString(value)
+			return nil
+		}
+	}
+
+	return modifier, nil
+}
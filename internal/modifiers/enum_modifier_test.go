@@ -0,0 +1,83 @@
+package modifiers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+)
+
+type fakeEnum string
+
+func TestEnumModifier(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Value", func(t *testing.T) {
+		modifier, err := LoadGlobalModifier("enum(active|disabled)")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, modifier.Scan == nil, true)
+
+		value, err := modifier.Value(ctx, ksqlmodifiers.OpInfo{}, "active")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, "active")
+
+		value, err = modifier.Value(ctx, ksqlmodifiers.OpInfo{}, fakeEnum("disabled"))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, "disabled")
+
+		value, err = modifier.Value(ctx, ksqlmodifiers.OpInfo{}, nil)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, nil)
+
+		_, err = modifier.Value(ctx, ksqlmodifiers.OpInfo{}, "deleted")
+		tt.AssertEqual(t, errors.Is(err, ksqlmodifiers.ErrInvalidEnumValue), true)
+
+		var enumErr ksqlmodifiers.InvalidEnumValueError
+		tt.AssertEqual(t, errors.As(err, &enumErr), true)
+		tt.AssertEqual(t, enumErr.Value, "deleted")
+		tt.AssertEqual(t, enumErr.AllowedValues, []string{"active", "disabled"})
+		tt.AssertErrContains(t, err, "deleted", "active, disabled")
+
+		_, err = modifier.Value(ctx, ksqlmodifiers.OpInfo{}, 42)
+		tt.AssertErrContains(t, err, "enum", "string attributes", "int")
+	})
+
+	t.Run("Scan", func(t *testing.T) {
+		modifier, err := LoadGlobalModifier("enum/strict(active|disabled)")
+		tt.AssertNoErr(t, err)
+
+		var value fakeEnum
+		err = modifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &value, []byte("active"))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, fakeEnum("active"))
+
+		var ptr *string
+		err = modifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &ptr, "disabled")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, *ptr, "disabled")
+
+		err = modifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &value, nil)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, fakeEnum("active"))
+
+		err = modifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &value, "deleted")
+		tt.AssertEqual(t, errors.Is(err, ksqlmodifiers.ErrInvalidEnumValue), true)
+
+		var i int
+		err = modifier.Scan(ctx, ksqlmodifiers.OpInfo{}, &i, "active")
+		tt.AssertErrContains(t, err, "enum", "string attributes", "*int")
+	})
+
+	t.Run("should report invalid arguments", func(t *testing.T) {
+		_, err := LoadGlobalModifier("enum()")
+		tt.AssertErrContains(t, err, "enum", "non empty values")
+
+		_, err = LoadGlobalModifier("enum(active||disabled)")
+		tt.AssertErrContains(t, err, "enum", "non empty values")
+
+		_, err = LoadGlobalModifier("notAModifier(foo)")
+		tt.AssertErrContains(t, err, "notAModifier")
+	})
+}
@@ -2,6 +2,7 @@ package modifiers
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/vingarcia/ksql/ksqlmodifiers"
@@ -14,6 +15,7 @@ func init() {
 	// Here we expose the registration function in a public package,
 	// so users can use it:
 	ksqlmodifiers.RegisterAttrModifier = RegisterAttrModifier
+	ksqlmodifiers.RegisterScanConverter = RegisterScanConverter
 
 	// These are the builtin modifiers:
 
@@ -26,6 +28,24 @@ func init() {
 	modifiers.Store("timeNowUTC", timeNowUTCModifier)
 	modifiers.Store("timeNowUTC/skipUpdates", timeNowUTCSkipUpdatesModifier)
 
+	// These two are useful for the CreatedBy and UpdatedBy fields respectively:
+	// They will set the attribute to the ID injected with ksqlmodifiers.InjectOperator().
+	modifiers.Store("userIDOnInsert", userIDOnInsertModifier)
+	modifiers.Store("userIDOnUpdate", userIDOnUpdateModifier)
+
+	// This one is useful for columns with sensible default values on the database,
+	// it omits the attribute from insertions if it is set to its zero value:
+	modifiers.Store("omitempty", omitEmptyModifier)
+
+	// This one is useful for optional text columns, it saves empty strings as NULL:
+	modifiers.Store("emptyAsNull", emptyAsNullModifier)
+
+	// This one maps time.Duration attributes to INTERVAL and TIME columns:
+	modifiers.Store("duration", durationModifier)
+
+	// This one is useful for NUMERIC columns, e.g. money, where float64 would lose precision:
+	modifiers.Store("decimal", decimalModifier)
+
 	// These are mostly example modifiers and they are also used
 	// to test the feature of skipping updates, inserts and queries.
 	modifiers.Store("skipUpdates", skipUpdatesModifier)
@@ -47,6 +67,14 @@ func RegisterAttrModifier(key string, modifier ksqlmodifiers.AttrModifier) {
 // LoadGlobalModifier is used internally by KSQL to load
 // modifiers during runtime.
 func LoadGlobalModifier(key string) (ksqlmodifiers.AttrModifier, error) {
+	if name, args, ok := parseModifierArgs(key); ok {
+		newModifier, found := modifierBuilders[name]
+		if !found {
+			return ksqlmodifiers.AttrModifier{}, fmt.Errorf("no modifier with arguments found with name '%s'", name)
+		}
+		return newModifier(args)
+	}
+
 	rawModifier, _ := modifiers.Load(key)
 	modifier, ok := rawModifier.(ksqlmodifiers.AttrModifier)
 	if !ok {
@@ -55,3 +83,26 @@ func LoadGlobalModifier(key string) (ksqlmodifiers.AttrModifier, error) {
 
 	return modifier, nil
 }
+
+// modifierBuilders contains the builtin modifiers that receive
+// arguments on the tag, e.g. `ksql:"status,enum(active|disabled)"`
+var modifierBuilders = map[string]func(args string) (ksqlmodifiers.AttrModifier, error){
+	// These validate that only the listed values are saved,
+	// and the strict version also validates the values read:
+	"enum": func(args string) (ksqlmodifiers.AttrModifier, error) {
+		return newEnumModifier(args, false)
+	},
+	"enum/strict": func(args string) (ksqlmodifiers.AttrModifier, error) {
+		return newEnumModifier(args, true)
+	},
+}
+
+// parseModifierArgs splits keys with the format `name(args)`
+func parseModifierArgs(key string) (name string, args string, ok bool) {
+	i := strings.Index(key, "(")
+	if i == -1 || !strings.HasSuffix(key, ")") {
+		return "", "", false
+	}
+
+	return key[:i], key[i+1 : len(key)-1], true
+}
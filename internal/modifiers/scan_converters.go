@@ -0,0 +1,116 @@
+package modifiers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+)
+
+// scanConverters maps the registered types to their AttrScanner
+var scanConverters sync.Map
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// RegisterScanConverter registers a function for converting the values
+// returned by the database into a custom type, it must have the format
+// `func(dbValue S) (T, error)` and it is used for scanning all the
+// attributes of type T or *T that don't have a modifier with a Scan function.
+//
+// It panics if the converter is invalid or if a converter
+// was already registered for the same type.
+func RegisterScanConverter(converter interface{}) {
+	fn := reflect.ValueOf(converter)
+	fnType := fn.Type()
+	if fnType.Kind() != reflect.Func ||
+		fnType.NumIn() != 1 || fnType.NumOut() != 2 ||
+		fnType.IsVariadic() || fnType.Out(1) != errorType {
+		panic(fmt.Errorf("KSQL: scan converters must have the format `func(dbValue S) (T, error)`, but got: %T", converter))
+	}
+
+	targetType := fnType.Out(0)
+	if _, found := scanConverters.Load(targetType); found {
+		panic(fmt.Errorf("KSQL: cannot register scan converter for type %v, a converter for this type is already registered", targetType))
+	}
+
+	inputType := fnType.In(0)
+	scanConverters.Store(targetType, ksqlmodifiers.AttrScanner(
+		func(ctx context.Context, opInfo ksqlmodifiers.OpInfo, attrPtr interface{}, dbValue interface{}) error {
+			attr := reflect.ValueOf(attrPtr).Elem()
+
+			isPtr := attr.Type() != targetType
+			if dbValue == nil {
+				attr.Set(reflect.Zero(attr.Type()))
+				return nil
+			}
+
+			input, err := convertScanInput(dbValue, inputType)
+			if err != nil {
+				return fmt.Errorf("scan converter for type %v: %w", targetType, err)
+			}
+
+			out := fn.Call([]reflect.Value{input})
+			if err, _ := out[1].Interface().(error); err != nil {
+				return err
+			}
+
+			if isPtr {
+				ptr := reflect.New(targetType)
+				ptr.Elem().Set(out[0])
+				attr.Set(ptr)
+			} else {
+				attr.Set(out[0])
+			}
+
+			return nil
+		},
+	))
+}
+
+// LoadScanConverter returns the AttrScanner registered for
+// the input type or for the type it points to, if any.
+func LoadScanConverter(t reflect.Type) (ksqlmodifiers.AttrScanner, bool) {
+	scanner, found := scanConverters.Load(t)
+	if !found && t.Kind() == reflect.Ptr {
+		scanner, found = scanConverters.Load(t.Elem())
+	}
+	if !found {
+		return nil, false
+	}
+
+	return scanner.(ksqlmodifiers.AttrScanner), true
+}
+
+// convertScanInput converts the value received from the driver to the
+// input type of the converter, e.g. from []byte to string or from
+// int64 to int, since each driver returns slightly different types.
+func convertScanInput(dbValue interface{}, inputType reflect.Type) (reflect.Value, error) {
+	v := reflect.ValueOf(dbValue)
+	if v.Type().AssignableTo(inputType) {
+		// This also covers converters receiving an interface{}:
+		result := reflect.New(inputType).Elem()
+		result.Set(v)
+		return result, nil
+	}
+
+	isText := func(t reflect.Type) bool {
+		return t.Kind() == reflect.String || (t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8)
+	}
+	isNumber := func(t reflect.Type) bool {
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return true
+		}
+		return false
+	}
+
+	if (isText(v.Type()) && isText(inputType)) || (isNumber(v.Type()) && isNumber(inputType)) {
+		return v.Convert(inputType), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("unable to convert value of type %T received from the database to %v", dbValue, inputType)
+}
@@ -0,0 +1,118 @@
+package modifiers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+)
+
+type fakeStatus string
+
+type fakeLevel int
+
+func TestRegisterScanConverter(t *testing.T) {
+	ctx := context.Background()
+
+	RegisterScanConverter(func(dbValue string) (fakeStatus, error) {
+		if dbValue == "invalid" {
+			return "", errors.New("fakeErrMsg")
+		}
+		return fakeStatus(strings.ToUpper(dbValue)), nil
+	})
+
+	RegisterScanConverter(func(dbValue int) (fakeLevel, error) {
+		return fakeLevel(dbValue * 10), nil
+	})
+
+	t.Run("should convert the values from the database", func(t *testing.T) {
+		scan, found := LoadScanConverter(reflect.TypeOf(fakeStatus("")))
+		tt.AssertEqual(t, found, true)
+
+		var status fakeStatus
+		err := scan(ctx, ksqlmodifiers.OpInfo{}, &status, []byte("active"))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, status, fakeStatus("ACTIVE"))
+
+		scan, found = LoadScanConverter(reflect.TypeOf(fakeLevel(0)))
+		tt.AssertEqual(t, found, true)
+
+		var level fakeLevel
+		err = scan(ctx, ksqlmodifiers.OpInfo{}, &level, int64(4))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, level, fakeLevel(40))
+	})
+
+	t.Run("should work with pointers", func(t *testing.T) {
+		var status *fakeStatus
+		scan, found := LoadScanConverter(reflect.TypeOf(status))
+		tt.AssertEqual(t, found, true)
+
+		err := scan(ctx, ksqlmodifiers.OpInfo{}, &status, "active")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, *status, fakeStatus("ACTIVE"))
+
+		err = scan(ctx, ksqlmodifiers.OpInfo{}, &status, nil)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, status == nil, true)
+	})
+
+	t.Run("should scan NULL as the zero value", func(t *testing.T) {
+		scan, _ := LoadScanConverter(reflect.TypeOf(fakeStatus("")))
+
+		status := fakeStatus("notEmpty")
+		err := scan(ctx, ksqlmodifiers.OpInfo{}, &status, nil)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, status, fakeStatus(""))
+	})
+
+	t.Run("should report errors", func(t *testing.T) {
+		scan, _ := LoadScanConverter(reflect.TypeOf(fakeStatus("")))
+
+		var status fakeStatus
+		err := scan(ctx, ksqlmodifiers.OpInfo{}, &status, "invalid")
+		tt.AssertErrContains(t, err, "fakeErrMsg")
+
+		err = scan(ctx, ksqlmodifiers.OpInfo{}, &status, 42.0)
+		tt.AssertErrContains(t, err, "fakeStatus", "float64")
+	})
+
+	t.Run("should return false for types without converters", func(t *testing.T) {
+		_, found := LoadScanConverter(reflect.TypeOf(""))
+		tt.AssertEqual(t, found, false)
+	})
+
+	t.Run("should panic for invalid converters", func(t *testing.T) {
+		invalidConverters := []interface{}{
+			"notAFunction",
+			func(a, b string) (fakeStatus, error) { return "", nil },
+			func(a string) fakeStatus { return "" },
+			func(a string) (fakeStatus, string) { return "", "" },
+		}
+		for _, converter := range invalidConverters {
+			panicPayload := tt.PanicHandler(func() {
+				RegisterScanConverter(converter)
+			})
+
+			err, ok := panicPayload.(error)
+			tt.AssertEqual(t, ok, true)
+			tt.AssertErrContains(t, err, "KSQL", "func(dbValue S) (T, error)")
+		}
+	})
+
+	t.Run("should panic when registering the same type twice", func(t *testing.T) {
+		panicPayload := tt.PanicHandler(func() {
+			RegisterScanConverter(func(dbValue string) (fakeStatus, error) {
+				return fakeStatus(dbValue), nil
+			})
+		})
+
+		err, ok := panicPayload.(error)
+		tt.AssertEqual(t, ok, true)
+		tt.AssertErrContains(t, err, "KSQL", "fakeStatus", "already registered")
+	})
+}
@@ -6,6 +6,10 @@ var skipInsertsModifier = ksqlmodifiers.AttrModifier{
 	SkipOnInsert: true,
 }
 
+var omitEmptyModifier = ksqlmodifiers.AttrModifier{
+	SkipOnInsertIfZero: true,
+}
+
 var skipUpdatesModifier = ksqlmodifiers.AttrModifier{
 	SkipOnUpdate: true,
 }
@@ -0,0 +1,42 @@
+package modifiers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+)
+
+// This one is useful for createdBy columns
+var userIDOnInsertModifier = ksqlmodifiers.AttrModifier{
+	SkipOnUpdate: true,
+
+	Value: operatorValuer("userIDOnInsert"),
+}
+
+// This one is useful for updatedBy columns
+var userIDOnUpdateModifier = ksqlmodifiers.AttrModifier{
+	Value: operatorValuer("userIDOnUpdate"),
+}
+
+// operatorValuer returns the ID injected on the context by
+// ksqlmodifiers.InjectOperator, if it is missing the value of
+// the attribute is kept if it was set explicitly, otherwise it
+// returns an error so audit columns are never left empty by mistake.
+func operatorValuer(modifierName string) ksqlmodifiers.AttrValuer {
+	return func(ctx context.Context, opInfo ksqlmodifiers.OpInfo, inputValue interface{}) (outputValue interface{}, _ error) {
+		if id, found := ksqlmodifiers.GetOperator(ctx); found {
+			return id, nil
+		}
+
+		if inputValue != nil && !reflect.ValueOf(inputValue).IsZero() {
+			return inputValue, nil
+		}
+
+		return nil, fmt.Errorf(
+			"the %s modifier requires an operator ID injected on the context with ksqlmodifiers.InjectOperator()",
+			modifierName,
+		)
+	}
+}
@@ -0,0 +1,53 @@
+package modifiers
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+)
+
+func TestUserIDModifiers(t *testing.T) {
+	ctx := context.Background()
+
+	modifiers := map[string]ksqlmodifiers.AttrModifier{
+		"userIDOnInsert": userIDOnInsertModifier,
+		"userIDOnUpdate": userIDOnUpdateModifier,
+	}
+
+	for name, modifier := range modifiers {
+		t.Run(name, func(t *testing.T) {
+			t.Run("should return the injected operator", func(t *testing.T) {
+				ctx := ksqlmodifiers.InjectOperator(ctx, "fakeOperatorID")
+
+				value, err := modifier.Value(ctx, ksqlmodifiers.OpInfo{}, "")
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, value, "fakeOperatorID")
+
+				value, err = modifier.Value(ctx, ksqlmodifiers.OpInfo{}, "explicitID")
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, value, "fakeOperatorID")
+			})
+
+			t.Run("should keep explicit values if no operator was injected", func(t *testing.T) {
+				value, err := modifier.Value(ctx, ksqlmodifiers.OpInfo{}, 42)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, value, 42)
+			})
+
+			t.Run("should report error if no operator was injected", func(t *testing.T) {
+				_, err := modifier.Value(ctx, ksqlmodifiers.OpInfo{}, 0)
+				tt.AssertErrContains(t, err, name, "InjectOperator")
+
+				_, err = modifier.Value(ctx, ksqlmodifiers.OpInfo{}, nil)
+				tt.AssertErrContains(t, err, name, "InjectOperator")
+			})
+		})
+	}
+
+	t.Run("only userIDOnInsert should skip updates", func(t *testing.T) {
+		tt.AssertEqual(t, userIDOnInsertModifier.SkipOnUpdate, true)
+		tt.AssertEqual(t, userIDOnUpdateModifier.SkipOnUpdate, false)
+	})
+}
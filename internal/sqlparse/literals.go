@@ -0,0 +1,62 @@
+package sqlparse
+
+import "strings"
+
+// StringLiterals returns the single-quoted string literals of the query,
+// including the quotes, in the order they appear.
+//
+// Quoted identifiers and comments are skipped, so apostrophes
+// inside of them are not mistaken for literals.
+func StringLiterals(query string) []string {
+	var literals []string
+	for i := 0; i < len(query); i++ {
+		switch {
+		case query[i] == '\'':
+			end := i + 1
+			for end < len(query) {
+				if query[end] == '\'' {
+					if end+1 < len(query) && query[end+1] == '\'' {
+						// Escaped quote, i.e. `'it''s'`
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end >= len(query) {
+				// Unterminated literals are reported until the end of the query
+				end = len(query) - 1
+			}
+			literals = append(literals, query[i:end+1])
+			i = end
+
+		case query[i] == '"' || query[i] == '`' || query[i] == '[':
+			closing := query[i]
+			if closing == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(query[i+1:], closing)
+			if end == -1 {
+				return literals
+			}
+			i += end + 1
+
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end == -1 {
+				return literals
+			}
+			i += end
+
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end == -1 {
+				return literals
+			}
+			i += end + 3
+		}
+	}
+
+	return literals
+}
@@ -0,0 +1,47 @@
+package sqlparse
+
+import (
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestStringLiterals(t *testing.T) {
+	tests := []struct {
+		desc             string
+		query            string
+		expectedLiterals []string
+	}{
+		{
+			desc:             "should return nothing for parameterized queries",
+			query:            "SELECT id, name FROM users WHERE name = $1 AND age > ?",
+			expectedLiterals: nil,
+		},
+		{
+			desc:             "should find string literals",
+			query:            "SELECT id FROM users WHERE name = 'John' AND address = 'Baker''s Street'",
+			expectedLiterals: []string{"'John'", "'Baker''s Street'"},
+		},
+		{
+			desc:             "should ignore quoted identifiers",
+			query:            "SELECT \"user's\", `user's`, [user's] FROM users",
+			expectedLiterals: nil,
+		},
+		{
+			desc:             "should ignore comments",
+			query:            "SELECT id -- don't worry\nFROM users /* it's fine */ WHERE kind = 'admin'",
+			expectedLiterals: []string{"'admin'"},
+		},
+		{
+			desc:             "should report unterminated literals",
+			query:            "SELECT id FROM users WHERE name = 'John",
+			expectedLiterals: []string{"'John"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			tt.AssertEqual(t, StringLiterals(test.query), test.expectedLiterals)
+		})
+	}
+}
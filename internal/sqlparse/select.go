@@ -0,0 +1,297 @@
+// Package sqlparse contains a minimal SQL parser used for
+// checking and adjusting the queries written for KSQL.
+//
+// It doesn't try to understand the whole SQL grammar, only enough
+// for handling the list of columns returned by a SELECT query.
+package sqlparse
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Column describes one of the expressions of the SELECT clause.
+type Column struct {
+	// Expr is the expression as written on the query, including the alias
+	Expr string
+
+	// Name is the name of the column returned by the database,
+	// which is empty if it can't be known statically, e.g. for
+	// expressions like `count(*)` written without an alias.
+	Name string
+}
+
+var identifierRegex = regexp.MustCompile(
+	"^(?:\"[^\"]+\"|`[^`]+`|\\[[^\\]]+\\]|[A-Za-z_][A-Za-z0-9_$]*)$",
+)
+
+var qualifiedIdentifierRegex = regexp.MustCompile(
+	"^(?:(?:\"[^\"]+\"|`[^`]+`|\\[[^\\]]+\\]|[A-Za-z_][A-Za-z0-9_$]*)\\.)*" +
+		"(?:\"[^\"]+\"|`[^`]+`|\\[[^\\]]+\\]|[A-Za-z_][A-Za-z0-9_$]*)$",
+)
+
+// keywords that can end an expression but are never aliases
+var nonAliasKeywords = map[string]bool{
+	"END":   true,
+	"NULL":  true,
+	"TRUE":  true,
+	"FALSE": true,
+}
+
+// SelectColumns returns the columns of the outermost SELECT clause of the query.
+//
+// The second return value is false if the query has no SELECT clause
+// or if the columns can't be known statically, e.g. when using `*`.
+func SelectColumns(query string) ([]Column, bool) {
+	_, exprs, ok := selectClause(query)
+	if !ok {
+		return nil, false
+	}
+
+	var columns []Column
+	for _, expr := range exprs {
+		column, ok := parseColumn(strings.TrimSpace(expr.text))
+		if !ok {
+			return nil, false
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, true
+}
+
+// AliasQualifiedColumns adds an alias to all the table-qualified columns
+// of the outermost SELECT clause that don't have one, using the qualified
+// name as the alias, e.g. `u.id` becomes `u.id AS "u.id"`.
+//
+// The escape function is used for quoting the aliases,
+// and if there is no SELECT clause the query is returned unchanged.
+func AliasQualifiedColumns(query string, escape func(string) string) string {
+	clauseStart, exprs, ok := selectClause(query)
+	if !ok {
+		return query
+	}
+
+	var b strings.Builder
+	lastPos := 0
+	for _, expr := range exprs {
+		text := strings.TrimSpace(expr.text)
+		if !qualifiedIdentifierRegex.MatchString(text) || identifierRegex.MatchString(text) {
+			continue
+		}
+
+		var names []string
+		for _, part := range splitTopLevel(text, func(r rune) bool { return r == '.' }) {
+			names = append(names, unquote(part.text))
+		}
+
+		exprEnd := clauseStart + expr.pos + len(strings.TrimRightFunc(expr.text, unicode.IsSpace))
+		b.WriteString(query[lastPos:exprEnd])
+		b.WriteString(" AS " + escape(strings.Join(names, ".")))
+		lastPos = exprEnd
+	}
+	b.WriteString(query[lastPos:])
+
+	return b.String()
+}
+
+// UnionBranches splits the query on its top-level UNION operators,
+// returning each of the SELECT queries that compose it.
+//
+// Queries without a top-level UNION are returned as a single branch.
+func UnionBranches(query string) []string {
+	var branches []string
+	branchStart := 0
+	tokens := splitTopLevel(query, isSpace)
+	for i := 0; i < len(tokens); i++ {
+		if strings.ToUpper(tokens[i].text) != "UNION" {
+			continue
+		}
+
+		branches = append(branches, strings.TrimSpace(query[branchStart:tokens[i].pos]))
+
+		next := i + 1
+		if next < len(tokens) {
+			keyword := strings.ToUpper(tokens[next].text)
+			if keyword == "ALL" || keyword == "DISTINCT" {
+				i = next
+			}
+		}
+		branchStart = tokens[i].pos + len(tokens[i].text)
+	}
+
+	return append(branches, strings.TrimSpace(query[branchStart:]))
+}
+
+// HasOrderBy reports whether the query has an ORDER BY clause
+// outside of parentheses, e.g. one that applies to its whole result
+// instead of a subquery or a window function.
+func HasOrderBy(query string) bool {
+	tokens := splitTopLevel(query, isSpace)
+	for i := 0; i+1 < len(tokens); i++ {
+		if strings.ToUpper(tokens[i].text) == "ORDER" && strings.ToUpper(tokens[i+1].text) == "BY" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selectClause returns the position where the list of columns of the
+// outermost SELECT clause starts and the expressions of this list.
+func selectClause(query string) (clauseStart int, exprs []token, ok bool) {
+	tokens := splitTopLevel(query, isSpace)
+
+	start := -1
+	end := len(tokens)
+	for i, token := range tokens {
+		keyword := strings.ToUpper(token.text)
+		if start == -1 && keyword == "SELECT" {
+			start = i + 1
+			continue
+		}
+		if start != -1 && keyword == "FROM" {
+			end = i
+			break
+		}
+	}
+	if start == -1 || start >= end {
+		return 0, nil, false
+	}
+
+	// Skip the modifiers that can come before the list of columns:
+	for start < end {
+		keyword := strings.ToUpper(tokens[start].text)
+		switch {
+		case keyword == "ALL" || keyword == "DISTINCT":
+			start++
+			if start < end && strings.HasPrefix(strings.ToUpper(tokens[start].text), "ON") {
+				// Skip the postgres `DISTINCT ON (...)` clause:
+				start++
+				if start < end && strings.ToUpper(tokens[start-1].text) == "ON" {
+					start++
+				}
+			}
+			continue
+		case keyword == "TOP" || strings.HasPrefix(keyword, "TOP("):
+			// Skip the SQL Server `TOP n` or `TOP (n)` clause:
+			if keyword == "TOP" {
+				start++
+			}
+			start++
+			continue
+		}
+		break
+	}
+	if start >= end {
+		return 0, nil, false
+	}
+
+	clauseStart = tokens[start].pos
+	clauseEnd := tokens[end-1].pos + len(tokens[end-1].text)
+
+	return clauseStart, splitTopLevel(query[clauseStart:clauseEnd], isComma), true
+}
+
+func parseColumn(expr string) (Column, bool) {
+	if expr == "" || strings.HasSuffix(expr, "*") {
+		return Column{}, false
+	}
+
+	tokens := splitTopLevel(expr, isSpace)
+	lastToken := tokens[len(tokens)-1].text
+
+	if len(tokens) == 1 {
+		if !qualifiedIdentifierRegex.MatchString(lastToken) {
+			return Column{Expr: expr}, true
+		}
+
+		parts := splitTopLevel(lastToken, func(r rune) bool { return r == '.' })
+		return Column{
+			Expr: expr,
+			Name: unquote(parts[len(parts)-1].text),
+		}, true
+	}
+
+	if !identifierRegex.MatchString(lastToken) || nonAliasKeywords[strings.ToUpper(lastToken)] {
+		return Column{Expr: expr}, true
+	}
+
+	previousToken := tokens[len(tokens)-2].text
+	if strings.ToUpper(previousToken) != "AS" && strings.ContainsAny(previousToken[len(previousToken)-1:], "+-*/%<>=!|&^~:") {
+		// The last token is an operand of an expression, not an alias:
+		return Column{Expr: expr}, true
+	}
+
+	return Column{
+		Expr: expr,
+		Name: unquote(lastToken),
+	}, true
+}
+
+func unquote(name string) string {
+	if len(name) >= 2 {
+		first, last := name[0], name[len(name)-1]
+		if (first == '"' && last == '"') || (first == '`' && last == '`') || (first == '[' && last == ']') {
+			return name[1 : len(name)-1]
+		}
+	}
+	return name
+}
+
+type token struct {
+	text string
+	pos  int
+}
+
+func isSpace(r rune) bool {
+	return unicode.IsSpace(r)
+}
+
+func isComma(r rune) bool {
+	return r == ','
+}
+
+// splitTopLevel splits the input on the separators that are not
+// inside parentheses, quoted strings or quoted identifiers.
+func splitTopLevel(s string, isSeparator func(rune) bool) []token {
+	var tokens []token
+	depth := 0
+	var quote rune
+	start := -1
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '[':
+			quote = ']'
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case depth == 0 && isSeparator(r):
+			if start != -1 {
+				tokens = append(tokens, token{text: s[start:i], pos: start})
+				start = -1
+			} else if !isSpace(r) {
+				// Keep empty tokens when splitting by commas
+				tokens = append(tokens, token{text: "", pos: i})
+			}
+			continue
+		}
+
+		if start == -1 {
+			start = i
+		}
+	}
+	if start != -1 {
+		tokens = append(tokens, token{text: s[start:], pos: start})
+	}
+
+	return tokens
+}
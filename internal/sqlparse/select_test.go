@@ -0,0 +1,194 @@
+package sqlparse
+
+import (
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestSelectColumns(t *testing.T) {
+	tests := []struct {
+		desc          string
+		query         string
+		expectedNames []string
+	}{
+		{
+			desc:          "should parse simple columns",
+			query:         "SELECT id, name FROM users WHERE id = $1",
+			expectedNames: []string{"id", "name"},
+		},
+		{
+			desc:          "should parse qualified and quoted columns",
+			query:         "SELECT u.id, \"u\".\"name\", `age`, [address] FROM users u",
+			expectedNames: []string{"id", "name", "age", "address"},
+		},
+		{
+			desc:          "should parse aliases",
+			query:         "SELECT u.id AS user_id, p.title post_title, count(*) AS \"total\" FROM users u JOIN posts p ON ...",
+			expectedNames: []string{"user_id", "post_title", "total"},
+		},
+		{
+			desc:          "should report expressions without alias as unknown",
+			query:         "SELECT count(*), a + b, CASE WHEN a THEN 1 ELSE 2 END, x::int FROM t",
+			expectedNames: []string{"", "", "", ""},
+		},
+		{
+			desc:          "should ignore commas and keywords inside functions and strings",
+			query:         "SELECT coalesce(name, 'FROM, foo') AS name, CAST(age AS int) age FROM users",
+			expectedNames: []string{"name", "age"},
+		},
+		{
+			desc:          "should skip the DISTINCT clause",
+			query:         "SELECT DISTINCT ON (u.id) u.id, u.name FROM users u",
+			expectedNames: []string{"id", "name"},
+		},
+		{
+			desc:          "should skip the TOP clause",
+			query:         "SELECT TOP (10) id, name FROM users",
+			expectedNames: []string{"id", "name"},
+		},
+		{
+			desc:          "should parse the outermost SELECT of queries with CTEs",
+			query:         "WITH adults AS (SELECT id FROM users WHERE age > 18) SELECT id, name FROM adults",
+			expectedNames: []string{"id", "name"},
+		},
+		{
+			desc:          "should parse queries without FROM",
+			query:         "SELECT 1 AS one",
+			expectedNames: []string{"one"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			columns, ok := SelectColumns(test.query)
+			tt.AssertEqual(t, ok, true)
+
+			var names []string
+			for _, column := range columns {
+				names = append(names, column.Name)
+			}
+			tt.AssertEqual(t, names, test.expectedNames)
+		})
+	}
+
+	t.Run("should return false if the columns can't be known statically", func(t *testing.T) {
+		queries := []string{
+			"SELECT * FROM users",
+			"SELECT u.* FROM users u",
+			"FROM users WHERE id = $1",
+			"UPDATE users SET name = $1",
+		}
+
+		for _, query := range queries {
+			_, ok := SelectColumns(query)
+			tt.AssertEqual(t, ok, false)
+		}
+	})
+}
+
+func TestAliasQualifiedColumns(t *testing.T) {
+	escape := func(s string) string {
+		return `"` + s + `"`
+	}
+
+	tests := []struct {
+		desc          string
+		query         string
+		expectedQuery string
+	}{
+		{
+			desc:          "should alias qualified columns",
+			query:         "SELECT u.id, p.id, u.name FROM users u JOIN posts p ON p.user_id = u.id",
+			expectedQuery: `SELECT u.id AS "u.id", p.id AS "p.id", u.name AS "u.name" FROM users u JOIN posts p ON p.user_id = u.id`,
+		},
+		{
+			desc:          "should unquote the qualified names",
+			query:         "SELECT \"u\".\"id\" ,\n  `p`.id\nFROM users u",
+			expectedQuery: "SELECT \"u\".\"id\" AS \"u.id\" ,\n  `p`.id AS \"p.id\"\nFROM users u",
+		},
+		{
+			desc:          "should keep unqualified columns, expressions and existing aliases",
+			query:         "SELECT id, u.name AS name, count(p.id), p.* FROM users u",
+			expectedQuery: "SELECT id, u.name AS name, count(p.id), p.* FROM users u",
+		},
+		{
+			desc:          "should keep queries without SELECT unchanged",
+			query:         "UPDATE users SET name = u.name",
+			expectedQuery: "UPDATE users SET name = u.name",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			query := AliasQualifiedColumns(test.query, escape)
+			tt.AssertEqual(t, query, test.expectedQuery)
+		})
+	}
+}
+
+func TestUnionBranches(t *testing.T) {
+	tests := []struct {
+		desc             string
+		query            string
+		expectedBranches []string
+	}{
+		{
+			desc:             "should return queries without UNION as a single branch",
+			query:            "SELECT id, name FROM users",
+			expectedBranches: []string{"SELECT id, name FROM users"},
+		},
+		{
+			desc:  "should split the query on each UNION",
+			query: "SELECT id, name FROM users UNION ALL SELECT id, title FROM posts\nunion distinct SELECT id, name FROM admins",
+			expectedBranches: []string{
+				"SELECT id, name FROM users",
+				"SELECT id, title FROM posts",
+				"SELECT id, name FROM admins",
+			},
+		},
+		{
+			desc:  "should ignore UNIONs inside parentheses and strings",
+			query: "SELECT id, 'a UNION b' AS name FROM (SELECT id FROM a UNION SELECT id FROM b) t",
+			expectedBranches: []string{
+				"SELECT id, 'a UNION b' AS name FROM (SELECT id FROM a UNION SELECT id FROM b) t",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			tt.AssertEqual(t, UnionBranches(test.query), test.expectedBranches)
+		})
+	}
+}
+
+func TestHasOrderBy(t *testing.T) {
+	tests := []struct {
+		desc     string
+		query    string
+		expected bool
+	}{
+		{
+			desc:     "should detect the ORDER BY of the query",
+			query:    "SELECT id FROM users WHERE age > $1 order\nby name",
+			expected: true,
+		},
+		{
+			desc:     "should return false for queries without ORDER BY",
+			query:    "SELECT id FROM users WHERE age > $1",
+			expected: false,
+		},
+		{
+			desc:     "should ignore ORDER BYs inside parentheses and strings",
+			query:    "SELECT id, row_number() OVER (ORDER BY age) FROM users WHERE name <> 'ORDER BY'",
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			tt.AssertEqual(t, HasOrderBy(test.query), test.expected)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package sqlparse
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Syntax describes the dialect-specific ways of quoting
+// recognized by SkipLen besides the standard ones.
+type Syntax struct {
+	// BracketIdentifiers enables identifiers quoted with brackets, e.g. `[name]`
+	BracketIdentifiers bool
+
+	// DollarQuotes enables the Postgres dollar-quoted strings, e.g. `$body$text$body$`
+	DollarQuotes bool
+
+	// BackslashEscapes enables the MySQL escapes inside
+	// quoted strings, e.g. `'it\'s'`
+	BackslashEscapes bool
+}
+
+// dollarQuoteRegex matches the opening tag of Postgres dollar-quoted strings, e.g. `$$` or `$body$`
+var dollarQuoteRegex = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// SkipLen returns the length of the string literal, quoted identifier
+// or comment at the start of s, or 0 if s doesn't start with one of them,
+// so parsers looking for placeholders can skip their contents.
+//
+// Unterminated ones are skipped until the end of s.
+func SkipLen(s string, syntax Syntax) int {
+	switch {
+	case s == "":
+		return 0
+	case s[0] == '\'' || s[0] == '"':
+		if syntax.BackslashEscapes {
+			return skipEscaped(s, s[0])
+		}
+		return skipUntil(s, 1, s[:1])
+	case s[0] == '`':
+		return skipUntil(s, 1, "`")
+	case s[0] == '[' && syntax.BracketIdentifiers:
+		return skipUntil(s, 1, "]")
+	case strings.HasPrefix(s, "--"):
+		return skipUntil(s, 2, "\n")
+	case strings.HasPrefix(s, "/*"):
+		return skipUntil(s, 2, "*/")
+	case syntax.DollarQuotes && dollarQuoteRegex.MatchString(s):
+		tag := dollarQuoteRegex.FindString(s)
+		return skipUntil(s, len(tag), tag)
+	}
+
+	return 0
+}
+
+// skipUntil returns the length of the prefix of `s` that ends with the
+// first `closing` found after `start`, or the length of `s` if there is none.
+func skipUntil(s string, start int, closing string) int {
+	end := strings.Index(s[start:], closing)
+	if end == -1 {
+		return len(s)
+	}
+	return start + end + len(closing)
+}
+
+// skipEscaped works like skipUntil for quoted strings
+// whose quotes can be escaped with a backslash.
+func skipEscaped(s string, quote byte) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return len(s)
+}
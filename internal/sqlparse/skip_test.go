@@ -0,0 +1,82 @@
+package sqlparse
+
+import (
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestSkipLen(t *testing.T) {
+	tests := []struct {
+		desc        string
+		input       string
+		syntax      Syntax
+		expectedLen int
+	}{
+		{
+			desc:        "should not skip code",
+			input:       "name = $1",
+			expectedLen: 0,
+		},
+		{
+			desc:        "should skip string literals",
+			input:       "'it''s' = $1",
+			expectedLen: len("'it'"),
+		},
+		{
+			desc:        "should skip quoted identifiers",
+			input:       "`$1` = $1",
+			expectedLen: len("`$1`"),
+		},
+		{
+			desc:        "should skip line comments",
+			input:       "-- $1\nname = $1",
+			expectedLen: len("-- $1\n"),
+		},
+		{
+			desc:        "should skip block comments",
+			input:       "/* $1 */ name = $1",
+			expectedLen: len("/* $1 */"),
+		},
+		{
+			desc:        "should skip until the end of unterminated literals",
+			input:       "'name = $1",
+			expectedLen: len("'name = $1"),
+		},
+		{
+			desc:        "should not skip brackets by default",
+			input:       "[$1] = $1",
+			expectedLen: 0,
+		},
+		{
+			desc:        "should skip bracket identifiers",
+			input:       "[@p1] = @p1",
+			syntax:      Syntax{BracketIdentifiers: true},
+			expectedLen: len("[@p1]"),
+		},
+		{
+			desc:        "should skip dollar-quoted strings",
+			input:       "$body$ it's $1 $body$ = $1",
+			syntax:      Syntax{DollarQuotes: true},
+			expectedLen: len("$body$ it's $1 $body$"),
+		},
+		{
+			desc:        "should skip strings with backslash escapes",
+			input:       `'it\'s ?' = ?`,
+			syntax:      Syntax{BackslashEscapes: true},
+			expectedLen: len(`'it\'s ?'`),
+		},
+		{
+			desc:        "should skip strings ending with an escaped backslash",
+			input:       `"C:\\" = ?`,
+			syntax:      Syntax{BackslashEscapes: true},
+			expectedLen: len(`"C:\\"`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			tt.AssertEqual(t, SkipLen(test.input, test.syntax), test.expectedLen)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package sqlparse
+
+import (
+	"regexp"
+	"strings"
+)
+
+var tableNameRegex = regexp.MustCompile(
+	"^(?:(?:\"[^\"]+\"|`[^`]+`|\\[[^\\]]+\\]|[A-Za-z_][A-Za-z0-9_$]*)\\.)*" +
+		"(?:\"[^\"]+\"|`[^`]+`|\\[[^\\]]+\\]|[A-Za-z_][A-Za-z0-9_$]*)",
+)
+
+// TableName returns the unquoted name of the first table following
+// a FROM, INTO or UPDATE keyword outside of parentheses, so keywords
+// used inside function calls, e.g. `EXTRACT(YEAR FROM created_at)`,
+// are ignored.
+//
+// If the FROM is followed by a subquery the name of its first
+// table is returned instead, and if no table is found it returns "".
+func TableName(query string) string {
+	tokens := splitTopLevel(query, isSpace)
+	for i := 0; i+1 < len(tokens); i++ {
+		switch strings.ToUpper(tokens[i].text) {
+		case "FROM", "INTO", "UPDATE":
+		default:
+			continue
+		}
+
+		next := tokens[i+1].text
+		if strings.HasPrefix(next, "(") {
+			end := strings.LastIndex(next, ")")
+			if end == -1 {
+				end = len(next)
+			}
+			if name := TableName(next[1:end]); name != "" {
+				return name
+			}
+			continue
+		}
+
+		name := tableNameRegex.FindString(next)
+		if name == "" {
+			continue
+		}
+
+		parts := splitTopLevel(name, func(r rune) bool { return r == '.' })
+		unquoted := make([]string, 0, len(parts))
+		for _, part := range parts {
+			unquoted = append(unquoted, unquote(part.text))
+		}
+		return strings.Join(unquoted, ".")
+	}
+
+	return ""
+}
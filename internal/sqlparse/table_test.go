@@ -0,0 +1,62 @@
+package sqlparse
+
+import (
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestTableName(t *testing.T) {
+	tests := []struct {
+		desc     string
+		query    string
+		expected string
+	}{
+		{
+			desc:     "should return the table of a SELECT",
+			query:    `SELECT "id", "name" FROM users WHERE id = $1`,
+			expected: "users",
+		},
+		{
+			desc:     "should return the table of INSERT and UPDATE queries",
+			query:    "INSERT INTO `users` (`name`) VALUES (?)",
+			expected: "users",
+		},
+		{
+			desc:     "should unquote each part of qualified names",
+			query:    `UPDATE [dbo].[users] SET [name] = @p1 WHERE [id] = @p2`,
+			expected: "dbo.users",
+		},
+		{
+			desc:     "should return the first table of joins",
+			query:    `SELECT u.id FROM public.users u JOIN posts p ON p.user_id = u.id`,
+			expected: "public.users",
+		},
+		{
+			desc:     "should ignore the FROM keywords inside function calls",
+			query:    `SELECT EXTRACT(YEAR FROM created_at), TRIM(BOTH ' ' FROM name) FROM users`,
+			expected: "users",
+		},
+		{
+			desc:     "should ignore the FROM keywords inside strings",
+			query:    `SELECT 'FROM posts' AS label FROM users`,
+			expected: "users",
+		},
+		{
+			desc:     "should look inside subqueries on the FROM clause",
+			query:    `SELECT count(*) FROM (SELECT SUBSTRING(title FROM 1 FOR 3) FROM posts) AS p`,
+			expected: "posts",
+		},
+		{
+			desc:     "should return an empty string for queries without tables",
+			query:    `SELECT EXTRACT(YEAR FROM now())`,
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			tt.AssertEqual(t, TableName(test.query), test.expected)
+		})
+	}
+}
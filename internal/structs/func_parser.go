@@ -9,7 +9,10 @@ var errType = reflect.TypeOf(new(error)).Elem()
 
 // ParseInputFunc is used exclusively for parsing
 // the ForEachChunk function used on the QueryChunks method.
-func ParseInputFunc(fn interface{}) (reflect.Type, error) {
+//
+// The optionalArgs describe the types of the arguments the function
+// is allowed to receive after the chunk, if it receives any.
+func ParseInputFunc(fn interface{}, optionalArgs ...reflect.Type) (reflect.Type, error) {
 	if fn == nil {
 		return nil, fmt.Errorf("the ForEachChunk attribute is required and cannot be nil")
 	}
@@ -20,7 +23,25 @@ func ParseInputFunc(fn interface{}) (reflect.Type, error) {
 		return nil, fmt.Errorf("the ForEachChunk callback must be a function")
 	}
 	if t.NumIn() != 1 {
-		return nil, fmt.Errorf("the ForEachChunk callback must have 1 argument")
+		if len(optionalArgs) == 0 {
+			return nil, fmt.Errorf("the ForEachChunk callback must have 1 argument")
+		}
+
+		if t.NumIn() != 1+len(optionalArgs) {
+			return nil, fmt.Errorf(
+				"the ForEachChunk callback must have 1 argument or %d arguments",
+				1+len(optionalArgs),
+			)
+		}
+
+		for i, argType := range optionalArgs {
+			if t.In(i+1) != argType {
+				return nil, fmt.Errorf(
+					"the argument %d of the ForEachChunk callback must be of type %v, but got: %v",
+					i+2, argType, t.In(i+1),
+				)
+			}
+		}
 	}
 
 	if t.NumOut() != 1 {
@@ -33,11 +54,7 @@ func ParseInputFunc(fn interface{}) (reflect.Type, error) {
 
 	argsType := t.In(0)
 	if argsType.Kind() != reflect.Slice {
-		return nil, fmt.Errorf("the argument of the ForEachChunk callback must a slice of structs")
-	}
-
-	if argsType.Elem().Kind() != reflect.Struct {
-		return nil, fmt.Errorf("the argument of the ForEachChunk callback must a slice of structs")
+		return nil, fmt.Errorf("the argument of the ForEachChunk callback must a slice of structs or a slice of scalar values")
 	}
 
 	return argsType, nil
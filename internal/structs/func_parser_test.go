@@ -22,6 +22,14 @@ func TestParseInputFunc(t *testing.T) {
 		tt.AssertEqual(t, reflect.TypeOf([]user{}), chunkType)
 	})
 
+	t.Run("should parse a function receiving a slice of scalar values correctly", func(t *testing.T) {
+		chunkType, err := structs.ParseInputFunc(func(ids []int64) error {
+			return nil
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, reflect.TypeOf([]int64{}), chunkType)
+	})
+
 	t.Run("should return errors correctly", func(t *testing.T) {
 		tests := []struct {
 			desc               string
@@ -66,13 +74,6 @@ func TestParseInputFunc(t *testing.T) {
 				},
 				expectErrToContain: []string{"ForEachChunk", "must a slice"},
 			},
-			{
-				desc: "input function argument is not a slice of structs",
-				fn: func(users []string) error {
-					return nil
-				},
-				expectErrToContain: []string{"ForEachChunk", "must a slice of structs"},
-			},
 		}
 
 		for _, test := range tests {
@@ -82,4 +83,30 @@ func TestParseInputFunc(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("should accept the optional arguments", func(t *testing.T) {
+		stringType := reflect.TypeOf("")
+
+		chunkType, err := structs.ParseInputFunc(func(users []user, name string) error {
+			return nil
+		}, stringType)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, chunkType, reflect.TypeOf([]user{}))
+
+		chunkType, err = structs.ParseInputFunc(func(users []user) error {
+			return nil
+		}, stringType)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, chunkType, reflect.TypeOf([]user{}))
+
+		_, err = structs.ParseInputFunc(func(users []user, id int) error {
+			return nil
+		}, stringType)
+		tt.AssertErrContains(t, err, "ForEachChunk", "argument 2", "string", "int")
+
+		_, err = structs.ParseInputFunc(func(users []user, name string, id int) error {
+			return nil
+		}, stringType)
+		tt.AssertErrContains(t, err, "ForEachChunk", "1 argument or 2 arguments")
+	})
 }
@@ -1,10 +1,12 @@
 package structs
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/vingarcia/ksql/internal/modifiers"
 	"github.com/vingarcia/ksql/ksqlmodifiers"
@@ -41,6 +43,10 @@ type FieldInfo struct {
 
 	// Modifier contains the AttrModifier associated with this field.
 	Modifier ksqlmodifiers.AttrModifier
+
+	// Expr is the SQL expression described by the `ksqlexpr` tag,
+	// it is empty for fields that map directly to a column.
+	Expr string
 }
 
 // ByIndex returns either the *FieldInfo of a valid
@@ -63,6 +69,49 @@ func (s StructInfo) ByName(name string) *FieldInfo {
 	return field
 }
 
+// ByColumnName returns the *FieldInfo matching a column name
+// returned by the database.
+//
+// Exact matches are always preferred, if there is no exact match both
+// the input name and the tag names are normalized using the
+// normalize function and compared again.
+//
+// If normalize is nil the names are compared case-insensitively.
+//
+// If no field matches it returns an empty struct with Valid set to false.
+func (s StructInfo) ByColumnName(name string, normalize func(string) string) *FieldInfo {
+	if field, found := s.byName[name]; found {
+		return field
+	}
+
+	if normalize == nil {
+		// The lowercased version of all tag names are
+		// also saved on the byName map so a single lookup is enough:
+		return s.ByName(strings.ToLower(name))
+	}
+
+	normalizedName := normalize(name)
+
+	var match *FieldInfo
+	for _, field := range s.byIndex {
+		if normalize(field.ColumnName) != normalizedName {
+			continue
+		}
+
+		// Prefer the first attribute on the struct
+		// so the result is deterministic:
+		if match == nil || field.Index < match.Index {
+			match = field
+		}
+	}
+
+	if match == nil {
+		return &FieldInfo{}
+	}
+
+	return match
+}
+
 func (s StructInfo) add(field FieldInfo) {
 	field.Valid = true
 	s.byIndex[field.Index] = &field
@@ -96,6 +145,24 @@ func GetTagInfo(key reflect.Type) (StructInfo, error) {
 	return getCachedTagInfo(tagInfoCache, key)
 }
 
+// ColumnTagFn returns the value of the `ksql` tag that should be used
+// for a given struct field, e.g. "name" or "name,json", if it returns
+// an empty string the field is ignored.
+type ColumnTagFn func(field reflect.StructField) string
+
+var columnTagFns = &sync.Map{}
+
+// RegisterColumnTagFn makes KSQL use the input function instead of
+// the `ksql` tags for reading the column names of the input struct type,
+// which allows KSQL to work with structs that can't be tagged, e.g. the
+// ones generated by protoc.
+func RegisterColumnTagFn(structType reflect.Type, fn ColumnTagFn) {
+	columnTagFns.Store(structType, fn)
+
+	// In case the type was already parsed:
+	tagInfoCache.Delete(structType)
+}
+
 func getCachedTagInfo(tagInfoCache *sync.Map, key reflect.Type) (StructInfo, error) {
 	if data, found := tagInfoCache.Load(key); found {
 		info, ok := data.(StructInfo)
@@ -147,6 +214,11 @@ func StructToMap(obj interface{}) (map[string]interface{}, error) {
 			continue
 		}
 
+		// Fields computed from expressions are read-only:
+		if fieldInfo.Expr != "" {
+			continue
+		}
+
 		field := v.Field(i)
 		ft := field.Type()
 		if ft.Kind() == reflect.Ptr {
@@ -256,10 +328,18 @@ func getTagNames(t reflect.Type) (_ StructInfo, err error) {
 		byIndex: map[int]*FieldInfo{},
 		byName:  map[string]*FieldInfo{},
 	}
+
+	getTag := func(field reflect.StructField) string {
+		return field.Tag.Get("ksql")
+	}
+	if fn, found := columnTagFns.Load(t); found {
+		getTag = fn.(ColumnTagFn)
+	}
+
 	for i := 0; i < t.NumField(); i++ {
 
 		attrName := t.Field(i).Name
-		name := t.Field(i).Tag.Get("ksql")
+		name := getTag(t.Field(i))
 		if name == "" {
 			continue
 		}
@@ -269,15 +349,17 @@ func getTagNames(t reflect.Type) (_ StructInfo, err error) {
 			return StructInfo{}, fmt.Errorf("all fields using the ksql tags must be exported, but %v is unexported", t)
 		}
 
-		tags := strings.Split(name, ",")
+		name, modifierName := ParseTag(name)
 		var modifier ksqlmodifiers.AttrModifier
-		if len(tags) > 1 {
-			name = tags[0]
-			modifier, err = modifiers.LoadGlobalModifier(tags[1])
+		if modifierName != "" {
+			modifier, err = modifiers.LoadGlobalModifier(modifierName)
 			if err != nil {
 				return StructInfo{}, fmt.Errorf("attribute contains invalid modifier name: %w", err)
 			}
 		}
+		if modifier.Scan == nil {
+			modifier.Scan, _ = modifiers.LoadScanConverter(t.Field(i).Type)
+		}
 
 		if _, found := info.byName[name]; found {
 			return StructInfo{}, fmt.Errorf(
@@ -291,6 +373,7 @@ func getTagNames(t reflect.Type) (_ StructInfo, err error) {
 			ColumnName: name,
 			Index:      i,
 			Modifier:   modifier,
+			Expr:       t.Field(i).Tag.Get("ksqlexpr"),
 		})
 	}
 
@@ -323,6 +406,16 @@ func getTagNames(t reflect.Type) (_ StructInfo, err error) {
 	return info, nil
 }
 
+// ParseTag splits the value of a `ksql` tag, e.g. `ksql:"name,json"`,
+// into the column name and the name of the modifier, if any.
+func ParseTag(tag string) (columnName string, modifierName string) {
+	tags := strings.Split(tag, ",")
+	if len(tags) > 1 {
+		return tags[0], tags[1]
+	}
+	return tags[0], ""
+}
+
 // DecodeAsSliceOfStructs makes several checks
 // while decoding an input type and returns
 // useful information so that it is easier
@@ -357,3 +450,24 @@ func DecodeAsSliceOfStructs(slice reflect.Type) (
 
 	return elemType, isPtr, nil
 }
+
+var timeType = reflect.TypeOf(time.Time{})
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// IsScalarType returns true for the types that should be scanned
+// directly from a single column instead of being filled using
+// the `ksql` tags, e.g. int64, string, time.Time or any type that
+// implements the sql.Scanner interface.
+//
+// Pointers are dereferenced before checking the type.
+func IsScalarType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+
+	return t == timeType || reflect.PtrTo(t).Implements(scannerType)
+}
@@ -2,6 +2,7 @@ package structs
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	tt "github.com/vingarcia/ksql/internal/testtools"
@@ -109,3 +110,91 @@ func TestGetTagInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestByColumnName(t *testing.T) {
+	info, err := GetTagInfo(reflect.TypeOf(struct {
+		ID       int    `ksql:"id"`
+		UserName string `ksql:"userName"`
+		Age      int    `ksql:"user_age"`
+	}{}))
+	tt.AssertNoErr(t, err)
+
+	tests := []struct {
+		desc              string
+		columnName        string
+		normalize         func(string) string
+		expectedAttrName  string
+		expectedValidFlag bool
+	}{
+		{
+			desc:              "should match exact names",
+			columnName:        "userName",
+			expectedAttrName:  "UserName",
+			expectedValidFlag: true,
+		},
+		{
+			desc:              "should match upper case names by default",
+			columnName:        "ID",
+			expectedAttrName:  "ID",
+			expectedValidFlag: true,
+		},
+		{
+			desc:              "should match names case-insensitively by default",
+			columnName:        "USERNAME",
+			expectedAttrName:  "UserName",
+			expectedValidFlag: true,
+		},
+		{
+			desc:       "should use the custom normalizer when one is provided",
+			columnName: "USER-AGE",
+			normalize: func(s string) string {
+				return strings.ReplaceAll(strings.ToLower(s), "-", "_")
+			},
+			expectedAttrName:  "Age",
+			expectedValidFlag: true,
+		},
+		{
+			desc:              "should return an invalid field if there is no match",
+			columnName:        "not_a_column",
+			expectedAttrName:  "",
+			expectedValidFlag: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			field := info.ByColumnName(test.columnName, test.normalize)
+			tt.AssertEqual(t, field.Valid, test.expectedValidFlag)
+			tt.AssertEqual(t, field.AttrName, test.expectedAttrName)
+		})
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		desc                 string
+		tag                  string
+		expectedColumnName   string
+		expectedModifierName string
+	}{
+		{
+			desc:               "should parse tags without modifiers",
+			tag:                "name",
+			expectedColumnName: "name",
+		},
+		{
+			desc:                 "should parse tags with modifiers",
+			tag:                  "address,json",
+			expectedColumnName:   "address",
+			expectedModifierName: "json",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			columnName, modifierName := ParseTag(test.tag)
+			tt.AssertEqual(t, columnName, test.expectedColumnName)
+			tt.AssertEqual(t, modifierName, test.expectedModifierName)
+		})
+	}
+}
@@ -59,6 +59,9 @@ func (m mockRows) Err() error {
 }
 
 func (m mockRows) Columns() ([]string, error) {
+	if m.ColumnsFn == nil {
+		return nil, nil
+	}
 	return m.ColumnsFn()
 }
 
@@ -0,0 +1,105 @@
+package ksql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// columnRegex matches column names optionally prefixed by the
+// table name, quoted or not, e.g. `users.data` or `"users"."data"`
+var columnRegex = regexp.MustCompile(
+	`^(?:(?:"[^"]+"|` + "`[^`]+`" + `|\[[^\]]+\]|[A-Za-z_][A-Za-z0-9_$]*)\.)*` +
+		`(?:"[^"]+"|` + "`[^`]+`" + `|\[[^\]]+\]|[A-Za-z_][A-Za-z0-9_$]*)$`,
+)
+
+// JSONPath returns the expression that extracts the value at the input path
+// of a JSON column as text, e.g. for filtering on the attributes saved with
+// the `json` modifier:
+//
+//	expr, err := ksql.JSONPath(dialect, "settings", "notifications", "email")
+//	if err != nil {
+//		return err
+//	}
+//
+//	err = db.Query(ctx, &users, "FROM users WHERE "+expr+" = $1", "weekly")
+//
+// The expression depends on the dialect, for the example above it is:
+//
+//   - Postgres:   settings->'notifications'->>'email'
+//   - MySQL:      JSON_UNQUOTE(JSON_EXTRACT(settings, '$."notifications"."email"'))
+//   - SQLite:     json_extract(settings, '$."notifications"."email"')
+//   - SQL Server: JSON_VALUE(settings, '$."notifications"."email"')
+//
+// Path elements containing only digits are used as array indexes.
+//
+// Since the value is extracted as text on most databases, comparing it
+// with numbers or booleans might require a cast on the query, e.g.
+// `CAST(... AS INTEGER)`, which also works across all the dialects.
+func JSONPath(dialect sqldialect.Provider, column string, path ...string) (string, error) {
+	if !columnRegex.MatchString(column) {
+		return "", fmt.Errorf("KSQL: invalid column name `%s` for JSONPath", column)
+	}
+	if len(path) == 0 {
+		return "", fmt.Errorf("KSQL: JSONPath requires at least one path element")
+	}
+	for _, key := range path {
+		if key == "" || strings.ContainsAny(key, `'"\`) {
+			return "", fmt.Errorf("KSQL: invalid JSONPath element `%s`: it must be non empty and can't contain quotes or backslashes", key)
+		}
+	}
+
+	switch dialect.DriverName() {
+	case "postgres":
+		var b strings.Builder
+		b.WriteString(column)
+		for i, key := range path {
+			if i == len(path)-1 {
+				b.WriteString("->>")
+			} else {
+				b.WriteString("->")
+			}
+
+			if isArrayIndex(key) {
+				b.WriteString(key)
+			} else {
+				b.WriteString("'" + key + "'")
+			}
+		}
+		return b.String(), nil
+	case "mysql":
+		return "JSON_UNQUOTE(JSON_EXTRACT(" + column + ", '" + buildJSONPath(path) + "'))", nil
+	case "sqlite3":
+		return "json_extract(" + column + ", '" + buildJSONPath(path) + "')", nil
+	case "sqlserver":
+		return "JSON_VALUE(" + column + ", '" + buildJSONPath(path) + "')", nil
+	}
+
+	return "", fmt.Errorf("KSQL: JSONPath is not supported for the `%s` dialect", dialect.DriverName())
+}
+
+// buildJSONPath builds the SQL/JSON path syntax used by
+// MySQL, SQLite and SQL Server, e.g. `$."items"[0]."name"`
+func buildJSONPath(path []string) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, key := range path {
+		if isArrayIndex(key) {
+			b.WriteString("[" + key + "]")
+		} else {
+			b.WriteString(`."` + key + `"`)
+		}
+	}
+	return b.String()
+}
+
+func isArrayIndex(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if !isDigit(key[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package ksql
+
+import (
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestJSONPath(t *testing.T) {
+	tests := []struct {
+		desc         string
+		dialect      string
+		column       string
+		path         []string
+		expectedExpr string
+	}{
+		{
+			desc:         "postgres",
+			dialect:      "postgres",
+			column:       "settings",
+			path:         []string{"notifications", "email"},
+			expectedExpr: `settings->'notifications'->>'email'`,
+		},
+		{
+			desc:         "postgres with array indexes",
+			dialect:      "postgres",
+			column:       `u."data"`,
+			path:         []string{"items", "0"},
+			expectedExpr: `u."data"->'items'->>0`,
+		},
+		{
+			desc:         "mysql",
+			dialect:      "mysql",
+			column:       "settings",
+			path:         []string{"notifications", "email"},
+			expectedExpr: `JSON_UNQUOTE(JSON_EXTRACT(settings, '$."notifications"."email"'))`,
+		},
+		{
+			desc:         "sqlite3",
+			dialect:      "sqlite3",
+			column:       "settings",
+			path:         []string{"items", "1", "name"},
+			expectedExpr: `json_extract(settings, '$."items"[1]."name"')`,
+		},
+		{
+			desc:         "sqlserver",
+			dialect:      "sqlserver",
+			column:       "[settings]",
+			path:         []string{"theme"},
+			expectedExpr: `JSON_VALUE([settings], '$."theme"')`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("should build the expression for "+test.desc, func(t *testing.T) {
+			expr, err := JSONPath(sqldialect.SupportedDialects[test.dialect], test.column, test.path...)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, expr, test.expectedExpr)
+		})
+	}
+
+	t.Run("should report errors", func(t *testing.T) {
+		dialect := sqldialect.SupportedDialects["postgres"]
+
+		_, err := JSONPath(dialect, "settings; DROP TABLE users", "theme")
+		tt.AssertErrContains(t, err, "KSQL", "invalid column name")
+
+		_, err = JSONPath(dialect, "settings")
+		tt.AssertErrContains(t, err, "KSQL", "at least one path element")
+
+		_, err = JSONPath(dialect, "settings", "it's")
+		tt.AssertErrContains(t, err, "KSQL", "invalid JSONPath element", "it's")
+
+		_, err = JSONPath(dialect, "settings", "")
+		tt.AssertErrContains(t, err, "KSQL", "invalid JSONPath element")
+	})
+}
@@ -0,0 +1,269 @@
+package ksql
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vingarcia/ksql/internal/structs"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// keysetValue is how each value is stored on the keyset tokens,
+// the type is kept so the value has the same type when decoded.
+type keysetValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+type keysetColumn struct {
+	expr     string
+	attrName string
+	desc     bool
+}
+
+// NewKeysetToken returns an opaque token containing the values of the
+// ordering columns of the input record, which is usually the last row
+// of a chunk, so an export can be resumed later from the next row with
+// the condition returned by KeysetCondition, e.g.:
+//
+//	orderBy := []string{"created_at", "id"}
+//
+//	condition, err := ksql.KeysetCondition(dialect, previousToken, orderBy...)
+//	if err != nil {
+//		return err
+//	}
+//
+//	var token string
+//	err = db.QueryChunks(ctx, ksql.ChunkParser{
+//		Query:     "FROM users WHERE " + condition.Query + " ORDER BY created_at, id",
+//		Params:    condition.Params,
+//		ChunkSize: 100,
+//		ForEachChunk: func(users []User) error {
+//			// ... export the users ...
+//
+//			token, err = ksql.NewKeysetToken(users[len(users)-1], orderBy...)
+//			return err
+//		},
+//	})
+//
+// The orderBy arguments have the same format as the ones of the OrderBy
+// option, i.e. column names optionally followed by ASC or DESC, and the
+// names of the columns must match the `ksql` tags of the record.
+//
+// The ordering columns must be unique together and not NULL,
+// otherwise rows might be skipped or repeated when resuming.
+func NewKeysetToken(record interface{}, orderBy ...string) (string, error) {
+	columns, err := parseKeysetColumns(orderBy)
+	if err != nil {
+		return "", err
+	}
+
+	v := reflect.ValueOf(record)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", fmt.Errorf("KSQL: NewKeysetToken expected a struct or a pointer to a struct but got: %T", record)
+	}
+
+	info, err := structs.GetTagInfo(v.Type())
+	if err != nil {
+		return "", err
+	}
+
+	values := make([]keysetValue, len(columns))
+	for i, column := range columns {
+		field := info.ByName(column.attrName)
+		if !field.Valid {
+			return "", fmt.Errorf("KSQL: the ordering column '%s' was not found on the struct %T", column.attrName, record)
+		}
+
+		values[i], err = encodeKeysetValue(v.Field(field.Index).Interface())
+		if err != nil {
+			return "", fmt.Errorf("KSQL: unable to encode the ordering column '%s' on the keyset token: %w", column.attrName, err)
+		}
+	}
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// KeysetCondition returns a query fragment that only matches the rows
+// that come after the row used to create the token with NewKeysetToken,
+// considering the same orderBy arguments, e.g. for `created_at, id`
+// on Postgres it returns:
+//
+//	(created_at > $1 OR (created_at = $2 AND id > $3))
+//
+// The placeholders are numbered as if the fragment was a query of its own,
+// so it can be combined with other fragments using ConcatQueries.
+//
+// If the token is empty it returns a condition that matches all rows,
+// which is useful for starting the export.
+func KeysetCondition(dialect sqldialect.Provider, token string, orderBy ...string) (QueryFragment, error) {
+	columns, err := parseKeysetColumns(orderBy)
+	if err != nil {
+		return QueryFragment{}, err
+	}
+
+	if token == "" {
+		return QueryFragment{Query: "1 = 1"}, nil
+	}
+
+	values, err := decodeKeysetToken(token)
+	if err != nil {
+		return QueryFragment{}, err
+	}
+
+	if len(values) != len(columns) {
+		return QueryFragment{}, fmt.Errorf(
+			"KSQL: the keyset token has %d values but %d ordering columns were informed",
+			len(values), len(columns),
+		)
+	}
+
+	var params []interface{}
+	conditions := make([]string, len(columns))
+	for i, column := range columns {
+		var terms []string
+		for j := 0; j < i; j++ {
+			terms = append(terms, columns[j].expr+" = "+AppendPlaceholder(dialect, &params, values[j]))
+		}
+
+		op := " > "
+		if column.desc {
+			op = " < "
+		}
+		terms = append(terms, column.expr+op+AppendPlaceholder(dialect, &params, values[i]))
+
+		conditions[i] = strings.Join(terms, " AND ")
+		if len(terms) > 1 {
+			conditions[i] = "(" + conditions[i] + ")"
+		}
+	}
+
+	return QueryFragment{
+		Query:  "(" + strings.Join(conditions, " OR ") + ")",
+		Params: params,
+	}, nil
+}
+
+func parseKeysetColumns(orderBy []string) ([]keysetColumn, error) {
+	if len(orderBy) == 0 {
+		return nil, fmt.Errorf("KSQL: at least one ordering column is required for keyset pagination")
+	}
+
+	columns := make([]keysetColumn, len(orderBy))
+	for i, arg := range orderBy {
+		arg = strings.TrimSpace(arg)
+		fields := strings.Fields(arg)
+		if !orderByRegex.MatchString(arg) || len(fields) > 2 {
+			return nil, fmt.Errorf(
+				"KSQL: invalid ordering column `%s`: expected a column name optionally followed by ASC or DESC",
+				arg,
+			)
+		}
+
+		expr := fields[0]
+		attrName := expr[strings.LastIndex(expr, ".")+1:]
+		attrName = strings.Trim(attrName, "\"`[]")
+
+		columns[i] = keysetColumn{
+			expr:     expr,
+			attrName: attrName,
+			desc:     len(fields) == 2 && strings.ToUpper(fields[1]) == "DESC",
+		}
+	}
+
+	return columns, nil
+}
+
+func encodeKeysetValue(value interface{}) (keysetValue, error) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		var err error
+		value, err = valuer.Value()
+		if err != nil {
+			return keysetValue{}, err
+		}
+	}
+
+	if t, ok := value.(time.Time); ok {
+		return keysetValue{Type: "time", Value: t.Format(time.RFC3339Nano)}, nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		return encodeKeysetValue(v.Elem().Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return keysetValue{Type: "int", Value: strconv.FormatInt(v.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return keysetValue{Type: "uint", Value: strconv.FormatUint(v.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return keysetValue{Type: "float", Value: strconv.FormatFloat(v.Float(), 'g', -1, 64)}, nil
+	case reflect.Bool:
+		return keysetValue{Type: "bool", Value: strconv.FormatBool(v.Bool())}, nil
+	case reflect.String:
+		return keysetValue{Type: "string", Value: v.String()}, nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return keysetValue{Type: "bytes", Value: base64.StdEncoding.EncodeToString(v.Bytes())}, nil
+		}
+	case reflect.Invalid, reflect.Ptr:
+		return keysetValue{}, fmt.Errorf("NULL values are not supported on ordering columns")
+	}
+
+	return keysetValue{}, fmt.Errorf("unsupported type %T", value)
+}
+
+func decodeKeysetToken(token string) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("KSQL: invalid keyset token: %w", err)
+	}
+
+	var encodedValues []keysetValue
+	err = json.Unmarshal(b, &encodedValues)
+	if err != nil {
+		return nil, fmt.Errorf("KSQL: invalid keyset token: %w", err)
+	}
+
+	values := make([]interface{}, len(encodedValues))
+	for i, encoded := range encodedValues {
+		switch encoded.Type {
+		case "int":
+			values[i], err = strconv.ParseInt(encoded.Value, 10, 64)
+		case "uint":
+			values[i], err = strconv.ParseUint(encoded.Value, 10, 64)
+		case "float":
+			values[i], err = strconv.ParseFloat(encoded.Value, 64)
+		case "bool":
+			values[i], err = strconv.ParseBool(encoded.Value)
+		case "string":
+			values[i] = encoded.Value
+		case "time":
+			values[i], err = time.Parse(time.RFC3339Nano, encoded.Value)
+		case "bytes":
+			values[i], err = base64.StdEncoding.DecodeString(encoded.Value)
+		default:
+			err = fmt.Errorf("unknown value type '%s'", encoded.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("KSQL: invalid keyset token: %w", err)
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,120 @@
+package ksql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestKeysetPagination(t *testing.T) {
+	type User struct {
+		ID        uint      `ksql:"id"`
+		Name      string    `ksql:"name"`
+		Score     float64   `ksql:"score"`
+		CreatedAt time.Time `ksql:"created_at"`
+		Nickname  *string   `ksql:"nickname"`
+	}
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	u := User{ID: 42, Name: "fakeName", Score: 4.5, CreatedAt: createdAt}
+
+	postgres := sqldialect.SupportedDialects["postgres"]
+
+	t.Run("should build the condition from the token", func(t *testing.T) {
+		token, err := NewKeysetToken(&u, "created_at", "id")
+		tt.AssertNoErr(t, err)
+
+		condition, err := KeysetCondition(postgres, token, "created_at", "id")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, condition, QueryFragment{
+			Query:  "(created_at > $1 OR (created_at = $2 AND id > $3))",
+			Params: []interface{}{createdAt, createdAt, uint64(42)},
+		})
+	})
+
+	t.Run("should keep the types of the values", func(t *testing.T) {
+		token, err := NewKeysetToken(u, "name", "score DESC", "id")
+		tt.AssertNoErr(t, err)
+
+		condition, err := KeysetCondition(sqldialect.SupportedDialects["mysql"], token, "u.name", "u.score DESC", "u.id")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, condition, QueryFragment{
+			Query:  "(u.name > ? OR (u.name = ? AND u.score < ?) OR (u.name = ? AND u.score = ? AND u.id > ?))",
+			Params: []interface{}{"fakeName", "fakeName", 4.5, "fakeName", 4.5, uint64(42)},
+		})
+	})
+
+	t.Run("should support driver.Valuer and pointer attributes", func(t *testing.T) {
+		type Post struct {
+			ID      int             `ksql:"id"`
+			Title   *string         `ksql:"title"`
+			Deleted sql.NullBool    `ksql:"deleted"`
+			Rank    sql.NullFloat64 `ksql:"rank"`
+		}
+
+		title := "fakeTitle"
+		token, err := NewKeysetToken(Post{ID: 1, Title: &title, Deleted: sql.NullBool{Valid: true}}, "deleted", "title", "id")
+		tt.AssertNoErr(t, err)
+
+		condition, err := KeysetCondition(postgres, token, "deleted", "title", "id")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, condition.Params[0], false)
+		tt.AssertEqual(t, condition.Params[2], "fakeTitle")
+		tt.AssertEqual(t, condition.Params[5], int64(1))
+
+		_, err = NewKeysetToken(Post{ID: 1}, "rank", "id")
+		tt.AssertErrContains(t, err, "KSQL", "rank", "NULL")
+	})
+
+	t.Run("should work together with ConcatQueries", func(t *testing.T) {
+		token, err := NewKeysetToken(u, "id")
+		tt.AssertNoErr(t, err)
+
+		condition, err := KeysetCondition(postgres, token, "id")
+		tt.AssertNoErr(t, err)
+
+		query, params, err := ConcatQueries(postgres,
+			QueryFragment{Query: "FROM users WHERE name = $1 AND", Params: []interface{}{"fakeName"}},
+			condition,
+			QueryFragment{Query: "ORDER BY id"},
+		)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, "FROM users WHERE name = $1 AND (id > $2) ORDER BY id")
+		tt.AssertEqual(t, params, []interface{}{"fakeName", uint64(42)})
+	})
+
+	t.Run("should match all rows for empty tokens", func(t *testing.T) {
+		condition, err := KeysetCondition(postgres, "", "id")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, condition, QueryFragment{Query: "1 = 1"})
+	})
+
+	t.Run("should report errors", func(t *testing.T) {
+		token, err := NewKeysetToken(u, "id")
+		tt.AssertNoErr(t, err)
+
+		_, err = KeysetCondition(postgres, token, "created_at", "id")
+		tt.AssertErrContains(t, err, "KSQL", "1 values", "2 ordering columns")
+
+		_, err = KeysetCondition(postgres, "not a token!", "id")
+		tt.AssertErrContains(t, err, "KSQL", "invalid keyset token")
+
+		_, err = KeysetCondition(postgres, token, "id; DROP TABLE users")
+		tt.AssertErrContains(t, err, "KSQL", "invalid ordering column")
+
+		_, err = KeysetCondition(postgres, token)
+		tt.AssertErrContains(t, err, "KSQL", "at least one")
+
+		_, err = NewKeysetToken(u, "not_a_column")
+		tt.AssertErrContains(t, err, "KSQL", "not_a_column")
+
+		_, err = NewKeysetToken(u, "nickname")
+		tt.AssertErrContains(t, err, "KSQL", "nickname", "NULL")
+
+		_, err = NewKeysetToken(42, "id")
+		tt.AssertErrContains(t, err, "KSQL", "struct", "int")
+	})
+}
@@ -0,0 +1,143 @@
+// Package kmoney contains helpers for saving monetary values with KSQL,
+// usually stored as an amount column of type NUMERIC and a currency
+// column with an ISO 4217 code, without ever converting the amounts
+// to floating point numbers.
+//
+// The modifiers on this package are not registered automatically,
+// so it is necessary to register them on startup, e.g.:
+//
+//	func init() {
+//		ksqlmodifiers.RegisterAttrModifier("amount/2", kmoney.NewAmountModifier(2))
+//		ksqlmodifiers.RegisterAttrModifier("currency", kmoney.CurrencyModifier)
+//	}
+//
+//	type Payment struct {
+//		ID       int             `ksql:"id"`
+//		Amount   decimal.Decimal `ksql:"amount,amount/2"`
+//		Currency string          `ksql:"currency,currency"`
+//	}
+package kmoney
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/vingarcia/ksql/internal/modifiers"
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+)
+
+// minorUnits maps the ISO 4217 currency codes to the number
+// of decimal digits used by their minor unit, e.g. cents.
+var minorUnits = map[string]int{
+	"AED": 2, "ARS": 2, "AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2,
+	"CHF": 2, "CLP": 0, "CNY": 2, "COP": 2, "CZK": 2, "DKK": 2,
+	"EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2,
+	"INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3,
+	"MXN": 2, "MYR": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PEN": 2,
+	"PHP": 2, "PLN": 2, "RUB": 2, "SAR": 2, "SEK": 2, "SGD": 2,
+	"THB": 2, "TND": 3, "TRY": 2, "TWD": 2, "UAH": 2, "USD": 2,
+	"UYU": 2, "VND": 0, "ZAR": 2,
+}
+
+// Scale returns the number of decimal digits used by the
+// minor unit of the input currency, e.g. 2 for "USD" and 0 for "JPY".
+//
+// The boolean is false if the currency is unknown.
+func Scale(currency string) (int, bool) {
+	scale, found := minorUnits[currency]
+	return scale, found
+}
+
+// ValidateCurrency returns an error if the input is
+// not one of the ISO 4217 codes known by this package.
+func ValidateCurrency(currency string) error {
+	if _, found := minorUnits[currency]; !found {
+		return fmt.Errorf("kmoney: unknown currency code: '%s'", currency)
+	}
+	return nil
+}
+
+// ValidateAmount returns an error if the input amount is not
+// a valid decimal number, e.g. "-10.25", or if it has more
+// decimal digits than the scale allows.
+//
+// Trailing zeros are ignored, so "10.2500" is valid for scale 2.
+func ValidateAmount(amount string, scale int) error {
+	digits := strings.TrimLeft(amount, "+-")
+	intPart, fracPart := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		intPart, fracPart = digits[:i], digits[i+1:]
+	}
+
+	if intPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return fmt.Errorf("kmoney: invalid amount: '%s'", amount)
+	}
+
+	fracPart = strings.TrimRight(fracPart, "0")
+	if len(fracPart) > scale {
+		return fmt.Errorf(
+			"kmoney: amount '%s' has %d decimal digits but the maximum is %d",
+			amount, len(fracPart), scale,
+		)
+	}
+
+	return nil
+}
+
+// Validate checks both the amount and the currency of a monetary value,
+// using the scale of the currency for validating the amount.
+func Validate(amount string, currency string) error {
+	scale, found := Scale(currency)
+	if !found {
+		return ValidateCurrency(currency)
+	}
+
+	return ValidateAmount(amount, scale)
+}
+
+// NewAmountModifier returns a modifier that works like the builtin
+// `decimal` modifier but returns an error on Insert and Patch operations
+// if the amount has more decimal digits than the input scale, so values
+// are never rounded silently by the database.
+func NewAmountModifier(scale int) ksqlmodifiers.AttrModifier {
+	decimalModifier, err := modifiers.LoadGlobalModifier("decimal")
+	if err != nil {
+		// This should never happen since the decimal modifier is a builtin:
+		panic(fmt.Errorf("kmoney: %w", err))
+	}
+
+	return ksqlmodifiers.AttrModifier{
+		Scan: decimalModifier.Scan,
+		Value: func(ctx context.Context, opInfo ksqlmodifiers.OpInfo, inputValue interface{}) (outputValue interface{}, _ error) {
+			value, err := decimalModifier.Value(ctx, opInfo, inputValue)
+			if err != nil {
+				return nil, err
+			}
+
+			return value, ValidateAmount(value.(string), scale)
+		},
+	}
+}
+
+// CurrencyModifier returns an error on Insert and Patch operations
+// if the attribute is not one of the ISO 4217 codes known by this package.
+var CurrencyModifier = ksqlmodifiers.AttrModifier{
+	Value: func(ctx context.Context, opInfo ksqlmodifiers.OpInfo, inputValue interface{}) (outputValue interface{}, _ error) {
+		currency, ok := inputValue.(string)
+		if !ok {
+			return nil, fmt.Errorf("kmoney: the currency modifier only works with string attributes, but got: %T", inputValue)
+		}
+
+		return currency, ValidateCurrency(currency)
+	},
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+package kmoney
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+)
+
+type fakeDecimal string
+
+func (f fakeDecimal) MarshalText() ([]byte, error) {
+	return []byte(f), nil
+}
+
+func TestValidateAmount(t *testing.T) {
+	tests := []struct {
+		desc               string
+		amount             string
+		scale              int
+		expectErrToContain []string
+	}{
+		{
+			desc:   "should accept amounts within the scale",
+			amount: "-10.25",
+			scale:  2,
+		},
+		{
+			desc:   "should accept integer amounts",
+			amount: "1000",
+			scale:  0,
+		},
+		{
+			desc:   "should ignore trailing zeros",
+			amount: "10.2500",
+			scale:  2,
+		},
+		{
+			desc:               "should reject amounts with too many decimal digits",
+			amount:             "10.255",
+			scale:              2,
+			expectErrToContain: []string{"10.255", "3 decimal digits", "maximum is 2"},
+		},
+		{
+			desc:               "should reject invalid amounts",
+			amount:             "1e10",
+			scale:              2,
+			expectErrToContain: []string{"invalid amount", "1e10"},
+		},
+		{
+			desc:               "should reject empty amounts",
+			amount:             "",
+			scale:              2,
+			expectErrToContain: []string{"invalid amount"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			err := ValidateAmount(test.amount, test.scale)
+			if test.expectErrToContain != nil {
+				tt.AssertErrContains(t, err, test.expectErrToContain...)
+				return
+			}
+			tt.AssertNoErr(t, err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("should use the scale of the currency", func(t *testing.T) {
+		tt.AssertNoErr(t, Validate("10.25", "USD"))
+		tt.AssertNoErr(t, Validate("10.255", "BHD"))
+
+		err := Validate("10.5", "JPY")
+		tt.AssertErrContains(t, err, "10.5", "maximum is 0")
+	})
+
+	t.Run("should reject unknown currencies", func(t *testing.T) {
+		err := Validate("10", "usd")
+		tt.AssertErrContains(t, err, "unknown currency", "usd")
+	})
+}
+
+func TestModifiers(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("amount modifier should validate the scale on Value", func(t *testing.T) {
+		modifier := NewAmountModifier(2)
+
+		value, err := modifier.Value(ctx, ksqlmodifiers.OpInfo{}, fakeDecimal("0.30"))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, "0.30")
+
+		_, err = modifier.Value(ctx, ksqlmodifiers.OpInfo{}, fakeDecimal("0.305"))
+		tt.AssertErrContains(t, err, "0.305", "maximum is 2")
+
+		_, err = modifier.Value(ctx, ksqlmodifiers.OpInfo{}, 0.3)
+		tt.AssertErrContains(t, err, "float64")
+	})
+
+	t.Run("amount modifier should scan without converting to floats", func(t *testing.T) {
+		var amount string
+		err := NewAmountModifier(2).Scan(ctx, ksqlmodifiers.OpInfo{}, &amount, []byte("12345678901234567.89"))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, amount, "12345678901234567.89")
+	})
+
+	t.Run("currency modifier should validate the currency on Value", func(t *testing.T) {
+		value, err := CurrencyModifier.Value(ctx, ksqlmodifiers.OpInfo{}, "EUR")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, "EUR")
+
+		_, err = CurrencyModifier.Value(ctx, ksqlmodifiers.OpInfo{}, "EURO")
+		tt.AssertErrContains(t, err, "unknown currency", "EURO")
+	})
+}
@@ -3,28 +3,34 @@ package ksql
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 
 	"github.com/vingarcia/ksql/internal/modifiers"
+	"github.com/vingarcia/ksql/internal/sqlparse"
 	"github.com/vingarcia/ksql/internal/structs"
 	"github.com/vingarcia/ksql/ksqlmodifiers"
 	"github.com/vingarcia/ksql/sqldialect"
 )
 
-var selectQueryCache = initializeQueryCache()
+// selectQueryCache maps the name of each dialect
+// to the cache of the select queries built for it.
+var selectQueryCache sync.Map
 
-func initializeQueryCache() map[string]*sync.Map {
-	cache := map[string]*sync.Map{}
-	for dname := range sqldialect.SupportedDialects {
-		cache[dname] = &sync.Map{}
-	}
-
-	return cache
+// getSelectQueryCache returns the cache of the select queries of the
+// input dialect, creating it on the first call for dialects that are
+// not natively supported, e.g. the ones configured on the kodbc adapter.
+func getSelectQueryCache(dialect sqldialect.Provider) *sync.Map {
+	cache, _ := selectQueryCache.LoadOrStore(dialect.DriverName(), &sync.Map{})
+	return cache.(*sync.Map)
 }
 
 // DB represents the KSQL client responsible for
@@ -33,6 +39,20 @@ func initializeQueryCache() map[string]*sync.Map {
 type DB struct {
 	dialect sqldialect.Provider
 	db      DBAdapter
+	config  Config
+
+	// outerAdapter is the adapter that started the current
+	// transaction, it is nil when not inside a transaction
+	outerAdapter DBAdapter
+
+	// stats is only set if Config.CollectStats is enabled
+	stats *statsCollector
+
+	// tracker counts the running operations for CloseWithContext
+	tracker *inFlightTracker
+
+	// txSemaphore is only set if Config.MaxConcurrentTransactions is set
+	txSemaphore chan struct{}
 }
 
 // DBAdapter is minimalistic interface to decouple our implementation
@@ -51,6 +71,27 @@ type TxBeginner interface {
 	BeginTx(ctx context.Context) (Tx, error)
 }
 
+// NestedTxBeginner can optionally be implemented by the Tx returned by
+// the TxBeginner in order to make nested calls to `ksql.Transaction()`
+// start nested transactions, e.g. using savepoints, instead of reusing
+// the outer transaction, when the Config.NestedTransactions option is set.
+//
+// Committing a nested transaction only makes its changes part of the
+// outer transaction, and rolling it back only discards its own changes.
+type NestedTxBeginner interface {
+	BeginNestedTx(ctx context.Context) (Tx, error)
+}
+
+// ServerVersioner can optionally be implemented by the DBAdapter
+// in order to customize how the `ksql.ServerVersion()` function
+// retrieves the version of the database server.
+//
+// If it is not implemented KSQL will query the version using the
+// appropriate query for the dialect, e.g. `SELECT version()`.
+type ServerVersioner interface {
+	ServerVersion(ctx context.Context) (string, error)
+}
+
 // Result stores information about the result of an Exec query
 type Result interface {
 	LastInsertId() (int64, error)
@@ -94,6 +135,12 @@ func (s ScanArgError) ErrorWithStructNames(structName string, colName string) er
 	)
 }
 
+// Execer describes a connection that can only execute statements,
+// it is received by the Config.OnNewConnection function.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (Result, error)
+}
+
 // Tx represents a transaction and is expected to be returned by the DBAdapter.BeginTx function
 type Tx interface {
 	DBAdapter
@@ -110,6 +157,207 @@ type Config struct {
 
 	// Used by some adapters (such as kpgx) where nil disables TLS
 	TLSConfig *tls.Config
+
+	// PasswordProvider is optional and, if set, is called every time
+	// the adapter opens a new connection for retrieving the password,
+	// which replaces the password of the connection string.
+	//
+	// This is useful for short-lived credentials that can't be written
+	// on a static connection string, e.g. the IAM authentication tokens
+	// used by AWS RDS and Aurora DSQL, which expire after 15 minutes.
+	//
+	// Currently only the kpgx and kpgx5 adapters use this option.
+	PasswordProvider func(ctx context.Context) (string, error)
+
+	// DSNProvider is optional and, if set, is called every time
+	// the adapter opens a new connection for retrieving the connection
+	// string, allowing credentials managed by a secret manager (such as Vault)
+	// to be rotated without recreating the ksql.DB.
+	//
+	// On the kpgx and kpgx5 adapters the connection string passed to New is still
+	// used for the pool settings, e.g. `pool_max_conn_lifetime`, which can be used
+	// for making sure old connections are replaced after each rotation.
+	//
+	// Currently only the kpgx, kpgx5, kmysql, ksqlserver, ksqlite3 and modernc-ksqlite adapters use this option.
+	DSNProvider func(ctx context.Context) (string, error)
+
+	// OnNewConnection is optional and, if set, is called every time the
+	// adapter opens a new connection, before it is used by any queries,
+	// which is useful for connection-level settings that would otherwise
+	// require driver-specific DSN parameters, e.g.:
+	//
+	//	OnNewConnection: func(ctx context.Context, conn ksql.Execer) error {
+	//		_, err := conn.ExecContext(ctx, "SET search_path TO tenant_42")
+	//		return err
+	//	},
+	//
+	// If it returns an error the connection is discarded and the error
+	// is returned by the operation that required the new connection.
+	//
+	// Currently only the kpgx, kpgx5, kmysql, ksqlserver, ksqlite3 and modernc-ksqlite adapters use this option.
+	OnNewConnection func(ctx context.Context, conn Execer) error
+
+	// NormalizeColumnName is used when matching the column names
+	// returned by the database with the names on the `ksql` tags.
+	//
+	// Exact matches are always preferred, and when there is no exact
+	// match both names are normalized with this function and compared again.
+	//
+	// If unset the names will be compared case-insensitively, which
+	// works for databases that return upper case column names (e.g. Oracle)
+	// as well as for quoted mixed-case names on Postgres.
+	NormalizeColumnName func(columnName string) string
+
+	// TableResolver is optional and, if set, is called by the Insert, Patch,
+	// Delete, PatchDiff and Upsert methods before building their queries,
+	// allowing the user to route each operation to a different table,
+	// e.g. for sharded or time-partitioned tables such as `events_2024_06`.
+	//
+	// The `record` argument is the same value received by the method,
+	// which for the Delete method might be just the ID of the record.
+	//
+	// Queries are not affected since their table names are written by the user.
+	TableResolver func(ctx context.Context, table Table, record interface{}) (Table, error)
+
+	// MaxQueryRows is optional and, if set, limits the number of rows
+	// the Query method can load into memory, protecting the application
+	// from running out of memory due to unbounded SELECTs.
+	//
+	// When a query returns more rows than this limit Query
+	// stops reading them and returns a TooManyRowsError.
+	//
+	// QueryChunks is not affected, which makes it the recommended
+	// way of processing large result sets. The limit can also be
+	// overridden for a single call with the MaxRows option.
+	MaxQueryRows int
+
+	// TimeLocation is optional and, if set, all the time.Time values
+	// scanned from the database are converted to this location.
+	//
+	// This is useful because some databases, e.g. MySQL and SQLite, don't
+	// store the time zone of DATETIME columns, so the drivers return them
+	// in a fixed location (usually UTC) regardless of the location used
+	// when they were saved, which causes subtle bugs when comparing times.
+	//
+	// The conversion keeps the instant of time unchanged, only the location
+	// is changed, and attributes that use modifiers are not affected.
+	TimeLocation *time.Location
+
+	// ForbidUnparameterizedStrings makes the Query, QueryOne, QueryChunks
+	// and Exec methods reject queries containing string literals,
+	// e.g. `WHERE name = 'John'`, which are the most common sign of
+	// values being concatenated into the query instead of passed as params.
+	//
+	// Since this check also rejects legitimate constant strings, projects
+	// that need them should use the QueryValidator option instead.
+	ForbidUnparameterizedStrings bool
+
+	// ForbidStructAndMapParams makes the Query, QueryOne, QueryChunks
+	// and Exec methods reject params that are structs or maps, which
+	// usually means a record was passed where one of its attributes
+	// was expected.
+	//
+	// It is disabled by default because some drivers do accept these
+	// types, e.g. netip.Addr or any type handled by a driver that
+	// implements the driver.NamedValueChecker interface.
+	ForbidStructAndMapParams bool
+
+	// ForbidSliceParams makes the Query, QueryOne, QueryChunks and Exec
+	// methods reject params that are slices or arrays on databases other
+	// than Postgres, which usually means a list was passed where the
+	// driver expected a single value, e.g. on `WHERE id IN (?)`.
+	//
+	// Byte slices and types implementing driver.Valuer are always accepted,
+	// and it is disabled by default because some drivers do accept other
+	// slices, e.g. the ones that implement driver.NamedValueChecker.
+	ForbidSliceParams bool
+
+	// ValidateUnionColumns makes the Query, QueryOne and QueryChunks methods
+	// check the columns of queries with a top-level UNION against the struct,
+	// returning an error listing the columns missing on each side, or
+	// reporting branches that select their columns in different orders.
+	//
+	// It is disabled by default because, like on any other query, the
+	// columns that don't match any attribute are otherwise ignored.
+	ValidateUnionColumns bool
+
+	// QueryValidator is optional and, if set, is called with the final
+	// query of the Query, QueryOne, QueryChunks and Exec methods before
+	// it is sent to the database, allowing the user to enforce custom
+	// rules, e.g. for preventing SQL injection.
+	//
+	// If it returns an error the query is not executed.
+	QueryValidator func(ctx context.Context, query string) error
+
+	// PropagateDeadlineToServer makes KSQL send the time left until the
+	// context deadline to the database server on each query, so the server
+	// aborts queries that run past the deadline even if the client
+	// connection is not promptly torn down.
+	//
+	// On Postgres this is done by setting the `statement_timeout`, inside
+	// transactions the previous timeout is restored after each statement,
+	// and outside of transactions it is done by the adapters that implement
+	// StatementTimeoutQuerier, e.g. kpgx. With other adapters the queries
+	// outside of transactions run inside a short transaction and the
+	// statements sent with Exec are left unchanged, since some of them,
+	// e.g. VACUUM, can't run inside transactions. On MySQL the
+	// `MAX_EXECUTION_TIME` optimizer hint is added to SELECT queries.
+	// It has no effect on other databases or on queries whose context
+	// has no deadline.
+	PropagateDeadlineToServer bool
+
+	// CollectStats enables counting the operations, rows and errors of
+	// each table, which can be retrieved with the DB.StatsByTable method.
+	//
+	// This is useful for finding hot tables and N+1 query patterns
+	// in production without external tooling.
+	CollectStats bool
+
+	// Queries is optional and stores the named queries that
+	// can be executed by name with the QueryNamed and ExecNamed methods,
+	// they are usually loaded with the Queries.FromFS method.
+	Queries Queries
+
+	// RequireDefaultParams makes the QueryNamed and ExecNamed methods return
+	// an error if the query references a `:name` param that was not injected
+	// with InjectDefaultParams, so a forgotten param, e.g. a tenant_id, is
+	// reported before the query is sent.
+	//
+	// It is disabled by default because the references that were not injected
+	// might be valid syntax on the database, e.g. named params on SQLite,
+	// so they are sent unchanged.
+	RequireDefaultParams bool
+
+	// MaxConcurrentTransactions is optional, when it is set starting a
+	// transaction while this number of transactions is already running
+	// fails with ErrTooManyTransactions instead of waiting for a connection.
+	//
+	// This is useful for small pools, e.g. sqlite with MaxOpenConns set to 1,
+	// where starting a transaction while another one is running, e.g. from
+	// inside the callback of the first one, would wait forever. Nested
+	// transactions started with the Provider received by the callback
+	// reuse the outer transaction and are not counted.
+	MaxConcurrentTransactions int
+
+	// NestedTransactions makes calls to `ksql.Transaction()` made inside a
+	// transaction callback start nested transactions, e.g. using savepoints,
+	// if the transaction of the adapter implements the NestedTxBeginner
+	// interface, such as the ones of kpgx and kpgx5.
+	//
+	// It is disabled by default, in which case the outer transaction is
+	// reused and an error returned by the inner callback only aborts the
+	// outer transaction when the outer callback returns it too.
+	NestedTransactions bool
+
+	// MaxQueryBytes and MaxParams are optional and, if set, make every
+	// query larger than MaxQueryBytes or with more params than MaxParams
+	// fail with a QueryTooLargeError before it is sent to the database.
+	//
+	// This protects shared databases from pathological queries, e.g.
+	// gigantic IN lists or bulk inserts built by buggy callers. The
+	// rejected queries are logged as any other failed query.
+	MaxQueryBytes int
+	MaxParams     int
 }
 
 // SetDefaultValues should be called by all adapters
@@ -122,17 +370,43 @@ func (c *Config) SetDefaultValues() {
 
 // NewWithAdapter allows the user to insert a custom implementation
 // of the DBAdapter interface
+//
+// The optional config argument is used for configuring the
+// behavior of the resulting DB, only the first one is used.
 func NewWithAdapter(
 	adapter DBAdapter,
 	dialect sqldialect.Provider,
+	config ...Config,
 ) (DB, error) {
 	if dialect == nil {
 		return DB{}, fmt.Errorf("expected a valid sqldialect.Provider as argument but got `nil`")
 	}
 
+	var c Config
+	if len(config) > 0 {
+		c = config[0]
+	}
+
+	var stats *statsCollector
+	if c.CollectStats {
+		stats = &statsCollector{
+			byTable: map[string]*TableStats{},
+		}
+	}
+
+	var txSemaphore chan struct{}
+	if c.MaxConcurrentTransactions > 0 {
+		txSemaphore = make(chan struct{}, c.MaxConcurrentTransactions)
+	}
+
 	return DB{
 		dialect: dialect,
 		db:      adapter,
+		config:  c,
+		stats:   stats,
+		tracker: &inFlightTracker{drained: make(chan struct{})},
+
+		txSemaphore: txSemaphore,
 	}, nil
 }
 
@@ -140,15 +414,43 @@ func NewWithAdapter(
 // the input should be a slice of structs (or *struct) passed
 // by reference and it will be filled with all the results.
 //
+// If the query starts with `FROM` the SELECT part of the query
+// is generated automatically from the struct tags. For queries that
+// can't start with `FROM`, e.g. queries starting with `WITH`, the
+// `:ksql_columns` marker can be used instead and it will be replaced
+// by the same list of columns:
+//
+//	WITH adults AS (SELECT * FROM users WHERE age >= 18)
+//	SELECT :ksql_columns FROM adults
+//
+// The marker also accepts a table alias, which is useful on joins:
+//
+//	SELECT :ksql_columns(u) FROM users u JOIN posts p ON p.user_id = u.id
+//
+// For polling loops that call Query repeatedly with the same slice
+// the ReuseSlice() option can be used to avoid allocating a new
+// backing array on each call, see `ksql.InjectOptions`.
+//
+// For queries with a top-level UNION all the columns returned must
+// match the attributes of the struct, and the branches that select
+// the same columns in different positions are reported as errors.
+//
 // Note: it is very important to make sure the query will
 // return a small known number of results, otherwise you risk
-// of overloading the available memory.
+// of overloading the available memory, the Config.MaxQueryRows
+// option can be used for enforcing a limit.
 func (c DB) Query(
 	ctx context.Context,
 	records interface{},
 	query string,
 	params ...interface{},
 ) (err error) {
+	if getCallOptions(ctx).replicaRetries > 0 {
+		return c.retryOnConnError(ctx, func(ctx context.Context) error {
+			return c.Query(ctx, records, query, params...)
+		})
+	}
+
 	slicePtr := reflect.ValueOf(records)
 	slicePtrType := slicePtr.Type()
 	if slicePtrType.Kind() != reflect.Ptr {
@@ -161,10 +463,16 @@ func (c DB) Query(
 		return err
 	}
 
-	if isSliceOfPtrs {
+	reuseSlice := getCallOptions(ctx).reuseSlice
+	maxRows := c.config.MaxQueryRows
+	if options := getCallOptions(ctx); options.maxRowsSet {
+		maxRows = options.maxRows
+	}
+	if isSliceOfPtrs || reuseSlice {
 		// Truncate the slice so there is no risk
 		// of overwritting records that were already saved
-		// on the slice:
+		// on the slice, or when reusing the slice,
+		// so its length matches the number of rows:
 		slice = slice.Slice(0, 0)
 	}
 
@@ -174,28 +482,76 @@ func (c DB) Query(
 	}
 
 	firstToken := strings.ToUpper(getFirstToken(query))
-	if info.IsNestedStruct && firstToken == "SELECT" {
+	rawQuery := getCallOptions(ctx).rawQuery
+	buildSelect := firstToken == "FROM" && !rawQuery
+	expandColumns := !rawQuery && hasColumnsMarker(query)
+	if info.IsNestedStruct && firstToken == "SELECT" && !expandColumns {
 		// This error check is necessary, since if we can't build the select part of the query this feature won't work.
 		return fmt.Errorf("can't generate SELECT query for nested struct: when using this feature omit the SELECT part of the query")
 	}
 
-	if firstToken == "FROM" {
-		selectPrefix, err := buildSelectQuery(c.dialect, structType, info, selectQueryCache[c.dialect.DriverName()])
+	if buildSelect {
+		selectPrefix, err := buildSelectPrefix(ctx, c.dialect, structType, info)
 		if err != nil {
 			return err
 		}
 		query = selectPrefix + query
 	}
 
-	defer ctxLog(ctx, query, params, &err)
+	query, err = applyPagination(ctx, c.dialect, query, buildSelect)
+	if err != nil {
+		return err
+	}
+
+	if expandColumns {
+		query, err = expandColumnsMarker(c.dialect, structType, info, query)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !rawQuery && !buildSelect && !info.IsNestedStruct && getCallOptions(ctx).qualifiedColumns {
+		query = sqlparse.AliasQualifiedColumns(query, c.dialect.Escape)
+	}
 
-	rows, err := c.db.QueryContext(ctx, query, params...)
+	if err := c.checkQuery(ctx, query, params); err != nil {
+		return err
+	}
+
+	var numRows int
+	defer ctxLog(ctx, query, params, time.Now(), &numRows, &err)
+	defer c.recordStats(query, &numRows, &err)
+
+	rows, err := c.queryContext(ctx, query, params...)
 	if err != nil {
-		return fmt.Errorf("error running query: %w", err)
+		return OpError{
+			Method: "Query",
+			Query:  query,
+			Err:    fmt.Errorf("error running query: %w", err),
+		}
 	}
 	defer rows.Close()
 
+	err = checkDuplicatedColumns(ctx, c.config, rows, structType)
+	if err != nil {
+		return err
+	}
+
+	err = checkUnionColumns(ctx, c.config, query, rows, structType)
+	if err != nil {
+		return err
+	}
+
 	for idx := 0; rows.Next(); idx++ {
+		if maxRows > 0 && idx >= maxRows {
+			return TooManyRowsError{MaxRows: maxRows}
+		}
+
+		if reuseSlice && idx < slice.Cap() {
+			slice = slice.Slice(0, idx+1)
+			resetSliceElem(slice.Index(idx), structType, isSliceOfPtrs)
+		}
+
 		// Allocate new slice elements
 		// only if they are not already allocated:
 		if slice.Len() <= idx {
@@ -213,10 +569,11 @@ func (c DB) Query(
 			elemPtr = elemPtr.Elem()
 		}
 
-		err = scanRows(ctx, c.dialect, rows, elemPtr.Interface())
+		err = scanRowsWithConfig(ctx, c.dialect, c.config, rows, elemPtr.Interface())
 		if err != nil {
 			return err
 		}
+		numRows++
 	}
 
 	if rows.Err() != nil {
@@ -233,6 +590,21 @@ func (c DB) Query(
 	return nil
 }
 
+// resetSliceElem zeroes a slice element that is about to be reused,
+// allocating a new struct only if the element is a nil pointer.
+func resetSliceElem(elem reflect.Value, structType reflect.Type, isPtr bool) {
+	if !isPtr {
+		elem.Set(reflect.Zero(structType))
+		return
+	}
+
+	if elem.IsNil() {
+		elem.Set(reflect.New(structType))
+		return
+	}
+	elem.Elem().Set(reflect.Zero(structType))
+}
+
 // QueryOne queries one instance from the database,
 // the input struct must be passed by reference
 // and the query should return only one result.
@@ -245,6 +617,12 @@ func (c DB) QueryOne(
 	query string,
 	params ...interface{},
 ) (err error) {
+	if getCallOptions(ctx).replicaRetries > 0 {
+		return c.retryOnConnError(ctx, func(ctx context.Context) error {
+			return c.QueryOne(ctx, record, query, params...)
+		})
+	}
+
 	v := reflect.ValueOf(record)
 	t := v.Type()
 	if t.Kind() != reflect.Ptr {
@@ -266,27 +644,66 @@ func (c DB) QueryOne(
 	}
 
 	firstToken := strings.ToUpper(getFirstToken(query))
-	if info.IsNestedStruct && firstToken == "SELECT" {
+	rawQuery := getCallOptions(ctx).rawQuery
+	buildSelect := firstToken == "FROM" && !rawQuery
+	expandColumns := !rawQuery && hasColumnsMarker(query)
+	if info.IsNestedStruct && firstToken == "SELECT" && !expandColumns {
 		// This error check is necessary, since if we can't build the select part of the query this feature won't work.
 		return fmt.Errorf("can't generate SELECT query for nested struct: when using this feature omit the SELECT part of the query")
 	}
 
-	if firstToken == "FROM" {
-		selectPrefix, err := buildSelectQuery(c.dialect, tStruct, info, selectQueryCache[c.dialect.DriverName()])
+	if buildSelect {
+		selectPrefix, err := buildSelectPrefix(ctx, c.dialect, tStruct, info)
 		if err != nil {
 			return err
 		}
 		query = selectPrefix + query
 	}
 
-	defer ctxLog(ctx, query, params, &err)
+	query, err = applyPagination(ctx, c.dialect, query, buildSelect)
+	if err != nil {
+		return err
+	}
 
-	rows, err := c.db.QueryContext(ctx, query, params...)
+	if expandColumns {
+		query, err = expandColumnsMarker(c.dialect, tStruct, info, query)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !rawQuery && !buildSelect && !info.IsNestedStruct && getCallOptions(ctx).qualifiedColumns {
+		query = sqlparse.AliasQualifiedColumns(query, c.dialect.Escape)
+	}
+
+	if err := c.checkQuery(ctx, query, params); err != nil {
+		return err
+	}
+
+	var numRows int
+	defer ctxLog(ctx, query, params, time.Now(), &numRows, &err)
+	defer c.recordStats(query, &numRows, &err)
+
+	rows, err := c.queryContext(ctx, query, params...)
 	if err != nil {
-		return fmt.Errorf("error running query: %w", err)
+		return OpError{
+			Method: "QueryOne",
+			Query:  query,
+			Err:    fmt.Errorf("error running query: %w", err),
+		}
 	}
 	defer rows.Close()
 
+	err = checkDuplicatedColumns(ctx, c.config, rows, tStruct)
+	if err != nil {
+		return err
+	}
+
+	err = checkUnionColumns(ctx, c.config, query, rows, tStruct)
+	if err != nil {
+		return err
+	}
+
 	if !rows.Next() {
 		if err := rows.Err(); err != nil {
 			return err
@@ -294,10 +711,11 @@ func (c DB) QueryOne(
 		return ErrRecordNotFound
 	}
 
-	err = scanRowsFromType(ctx, c.dialect, rows, record, t, v)
+	err = scanRowsFromType(ctx, c.dialect, c.config, rows, record, t, v)
 	if err != nil {
 		return err
 	}
+	numRows = 1
 
 	return rows.Close()
 }
@@ -318,67 +736,190 @@ func (c DB) QueryOne(
 // pointers to struct as its only argument and that reflection
 // will be used to instantiate this argument and to fill it
 // with the database rows.
+//
+// If the query returns a single column it is also possible
+// to receive a slice of scalar values, e.g. `func(ids []int64) error`,
+// in this case the query must start with the SELECT keyword
+// or the RawQuery() option must be used.
+//
+// The callback can also receive a ksql.Provider as its second argument,
+// e.g. `func(users []User, tx ksql.Provider) error`, in which case
+// each call runs in its own transaction, committed when the callback returns,
+// which is useful for ETL jobs that would otherwise write everything on a
+// single gigantic transaction. This works even when QueryChunks is called
+// inside a Transaction, but the chunk transactions are independent from
+// the wrapping one, and since they need a connection of their own
+// the Config.MaxOpenConns option must be at least 2, if it is not
+// QueryChunks returns an error before running the query.
 func (c DB) QueryChunks(
 	ctx context.Context,
 	parser ChunkParser,
 ) (err error) {
-	fnValue := reflect.ValueOf(parser.ForEachChunk)
-	chunkType, err := structs.ParseInputFunc(parser.ForEachChunk)
+	ctx, done, err := c.trackOperation(ctx)
 	if err != nil {
 		return err
 	}
+	defer done()
 
-	chunk := reflect.MakeSlice(chunkType, 0, parser.ChunkSize)
+	if parser.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, parser.Timeout)
+		defer cancel()
+	}
 
-	structType, isSliceOfPtrs, err := structs.DecodeAsSliceOfStructs(chunkType)
+	fnValue := reflect.ValueOf(parser.ForEachChunk)
+	chunkType, err := structs.ParseInputFunc(parser.ForEachChunk, providerType)
 	if err != nil {
 		return err
 	}
 
-	info, err := structs.GetTagInfo(structType)
-	if err != nil {
+	callForEachChunk := func(chunk reflect.Value) error {
+		err, _ := fnValue.Call([]reflect.Value{chunk})[0].Interface().(error)
 		return err
 	}
+	if fnValue.Type().NumIn() == 2 {
+		callForEachChunk, err = c.newChunkTransactionCaller(ctx, fnValue)
+		if err != nil {
+			return err
+		}
+	}
+
+	chunk := reflect.MakeSlice(chunkType, 0, parser.ChunkSize)
+
+	isScalar := structs.IsScalarType(chunkType.Elem())
 
+	var structType reflect.Type
+	var isSliceOfPtrs bool
 	firstToken := strings.ToUpper(getFirstToken(parser.Query))
-	if info.IsNestedStruct && firstToken == "SELECT" {
-		// This error check is necessary, since if we can't build the select part of the query this feature won't work.
-		return fmt.Errorf("can't generate SELECT query for nested struct: when using this feature omit the SELECT part of the query")
-	}
+	rawQuery := getCallOptions(ctx).rawQuery
+	buildSelect := firstToken == "FROM" && !rawQuery
+	expandColumns := !rawQuery && hasColumnsMarker(parser.Query)
+	if isScalar {
+		if buildSelect {
+			return fmt.Errorf(
+				"KSQL: can't generate the SELECT part of the query for a slice of %v: when using this feature the query must start with SELECT",
+				chunkType.Elem(),
+			)
+		}
+		if expandColumns {
+			return fmt.Errorf(
+				"KSQL: can't expand the %s marker for a slice of %v: this feature is only available for structs",
+				columnsMarker, chunkType.Elem(),
+			)
+		}
+	} else {
+		structType, isSliceOfPtrs, err = structs.DecodeAsSliceOfStructs(chunkType)
+		if err != nil {
+			return err
+		}
 
-	if firstToken == "FROM" {
-		selectPrefix, err := buildSelectQuery(c.dialect, structType, info, selectQueryCache[c.dialect.DriverName()])
+		info, err := structs.GetTagInfo(structType)
 		if err != nil {
 			return err
 		}
-		parser.Query = selectPrefix + parser.Query
+
+		if info.IsNestedStruct && firstToken == "SELECT" && !expandColumns {
+			// This error check is necessary, since if we can't build the select part of the query this feature won't work.
+			return fmt.Errorf("can't generate SELECT query for nested struct: when using this feature omit the SELECT part of the query")
+		}
+
+		if buildSelect {
+			selectPrefix, err := buildSelectPrefix(ctx, c.dialect, structType, info)
+			if err != nil {
+				return err
+			}
+			parser.Query = selectPrefix + parser.Query
+		}
+
+		parser.Query, err = applyPagination(ctx, c.dialect, parser.Query, buildSelect)
+		if err != nil {
+			return err
+		}
+
+		if expandColumns {
+			parser.Query, err = expandColumnsMarker(c.dialect, structType, info, parser.Query)
+			if err != nil {
+				return err
+			}
+		}
+
+		if !rawQuery && !buildSelect && !info.IsNestedStruct && getCallOptions(ctx).qualifiedColumns {
+			parser.Query = sqlparse.AliasQualifiedColumns(parser.Query, c.dialect.Escape)
+		}
 	}
 
-	defer ctxLog(ctx, parser.Query, parser.Params, &err)
+	if err := checkDeadlineHeadroom(ctx, parser.DeadlineHeadroom, 0, 0); err != nil {
+		return err
+	}
 
-	rows, err := c.db.QueryContext(ctx, parser.Query, parser.Params...)
-	if err != nil {
+	if err := c.checkQuery(ctx, parser.Query, parser.Params); err != nil {
 		return err
 	}
+
+	var numRows int
+	defer ctxLog(ctx, parser.Query, parser.Params, time.Now(), &numRows, &err)
+	defer c.recordStats(parser.Query, &numRows, &err)
+
+	rows, err := c.queryWithFetchSize(ctx, parser.FetchSize, parser.Query, parser.Params...)
+	if err != nil {
+		return OpError{
+			Method: "QueryChunks",
+			Query:  parser.Query,
+			Err:    err,
+		}
+	}
 	defer rows.Close()
 
+	if isScalar {
+		err = assertSingleColumn(rows, chunkType.Elem())
+	} else {
+		err = checkDuplicatedColumns(ctx, c.config, rows, structType)
+		if err == nil {
+			err = checkUnionColumns(ctx, c.config, parser.Query, rows, structType)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
 	var idx = 0
+	var processedChunks, processedRows int
 	for rows.Next() {
+		if parser.MaxChunks > 0 && processedChunks >= parser.MaxChunks {
+			return TooManyChunksError{
+				MaxChunks:     parser.MaxChunks,
+				ProcessedRows: processedRows,
+			}
+		}
+
 		// Allocate new slice elements
 		// only if they are not already allocated:
 		if chunk.Len() <= idx {
 			var elemValue reflect.Value
-			elemValue = reflect.New(structType)
-			if !isSliceOfPtrs {
-				elemValue = elemValue.Elem()
+			if isScalar {
+				elemValue = reflect.New(chunkType.Elem()).Elem()
+			} else {
+				elemValue = reflect.New(structType)
+				if !isSliceOfPtrs {
+					elemValue = elemValue.Elem()
+				}
 			}
 			chunk = reflect.Append(chunk, elemValue)
 		}
 
-		err = scanRows(ctx, c.dialect, rows, chunk.Index(idx).Addr().Interface())
+		if isScalar {
+			valuePtr := chunk.Index(idx).Addr().Interface()
+			err = scanScalar(rows, valuePtr)
+			if err == nil {
+				normalizeTimeLocation(c.config.TimeLocation, []interface{}{valuePtr})
+			}
+		} else {
+			err = scanRowsWithConfig(ctx, c.dialect, c.config, rows, chunk.Index(idx).Addr().Interface())
+		}
 		if err != nil {
 			return err
 		}
+		numRows++
 
 		if idx < parser.ChunkSize-1 {
 			idx++
@@ -386,13 +927,25 @@ func (c DB) QueryChunks(
 		}
 
 		idx = 0
-		err, _ = fnValue.Call([]reflect.Value{chunk})[0].Interface().(error)
+		err = callForEachChunk(chunk)
 		if err != nil {
 			if err == ErrAbortIteration {
 				return nil
 			}
 			return err
 		}
+
+		processedChunks++
+		processedRows += chunk.Len()
+		err = checkDeadlineHeadroom(ctx, parser.DeadlineHeadroom, processedChunks, processedRows)
+		if err != nil {
+			return err
+		}
+
+		if parser.Timeout > 0 && ctx.Err() != nil {
+			// Not all drivers check the context while reading buffered rows:
+			return ctx.Err()
+		}
 	}
 
 	if err := rows.Close(); err != nil {
@@ -409,7 +962,7 @@ func (c DB) QueryChunks(
 	if idx > 0 {
 		chunk = chunk.Slice(0, idx)
 
-		err, _ = fnValue.Call([]reflect.Value{chunk})[0].Interface().(error)
+		err = callForEachChunk(chunk)
 		if err != nil {
 			if err == ErrAbortIteration {
 				return nil
@@ -421,6 +974,137 @@ func (c DB) QueryChunks(
 	return nil
 }
 
+// timeNow is a variable so it can be replaced on tests
+var timeNow = time.Now
+
+// checkDeadlineHeadroom returns a DeadlineApproachingError if the
+// time left until the context deadline is smaller than `headroom`.
+func checkDeadlineHeadroom(ctx context.Context, headroom time.Duration, processedChunks int, processedRows int) error {
+	if headroom <= 0 {
+		return nil
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+
+	timeLeft := deadline.Sub(timeNow())
+	if timeLeft >= headroom {
+		return nil
+	}
+
+	return DeadlineApproachingError{
+		ProcessedChunks: processedChunks,
+		ProcessedRows:   processedRows,
+		TimeLeft:        timeLeft,
+	}
+}
+
+var providerType = reflect.TypeOf((*Provider)(nil)).Elem()
+
+// newChunkTransactionCaller returns a function that calls the ForEachChunk
+// callback inside a new transaction, which is started with the adapter that
+// is outside of any transactions, so it is committed independently.
+func (c DB) newChunkTransactionCaller(
+	ctx context.Context,
+	fnValue reflect.Value,
+) (func(chunk reflect.Value) error, error) {
+	txDB := c
+	if c.outerAdapter != nil {
+		txDB.db = c.outerAdapter
+		txDB.outerAdapter = nil
+	}
+
+	if _, ok := txDB.db.(TxBeginner); !ok {
+		return nil, fmt.Errorf(
+			"KSQL: can't start a transaction for each chunk: The DBAdapter doesn't implement the TxBeginner interface",
+		)
+	}
+
+	// The rows being read hold one connection, so each chunk transaction
+	// needs another one, otherwise it would wait forever for the rows.
+	//
+	// A MaxOpenConns of 0 means the pool was configured outside of KSQL,
+	// e.g. when using NewWithAdapter, so we rely on the PoolStats if any.
+	maxOpenConns := c.config.MaxOpenConns
+	if stats, err := txDB.PoolStats(); err == nil {
+		maxOpenConns = stats.MaxOpenConns
+	}
+	if maxOpenConns == 1 {
+		return nil, fmt.Errorf(
+			"KSQL: can't start a transaction for each chunk: the rows being read hold the only connection of the pool," +
+				" set the Config.MaxOpenConns option to at least 2 or use a ForEachChunk callback without the ksql.Provider argument",
+		)
+	}
+
+	if c.outerAdapter != nil && c.config.MaxConcurrentTransactions == 1 {
+		return nil, fmt.Errorf(
+			"KSQL: can't start a transaction for each chunk: the wrapping transaction already reached the limit" +
+				" of 1 set with Config.MaxConcurrentTransactions",
+		)
+	}
+
+	return func(chunk reflect.Value) error {
+		var aborted bool
+		err := txDB.Transaction(ctx, func(tx Provider) error {
+			err, _ := fnValue.Call([]reflect.Value{chunk, reflect.ValueOf(&tx).Elem()})[0].Interface().(error)
+			if err == ErrAbortIteration {
+				// The changes made before aborting should still be committed:
+				aborted = true
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		if aborted {
+			return ErrAbortIteration
+		}
+		return nil
+	}, nil
+}
+
+func assertSingleColumn(rows Rows, scalarType reflect.Type) error {
+	colNames, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("KSQL: unable to read columns from returned rows: %w", err)
+	}
+
+	if len(colNames) != 1 {
+		return fmt.Errorf(
+			"KSQL: expected the query to return a single column when scanning into %v, but it returned %d columns: %v",
+			scalarType, len(colNames), colNames,
+		)
+	}
+
+	return nil
+}
+
+func scanScalar(rows Rows, valuePtr interface{}) error {
+	err := rows.Scan(valuePtr)
+	if err != nil {
+		return fmt.Errorf("KSQL: scan error: %w", err)
+	}
+	return nil
+}
+
+// resolveTable calls the Config.TableResolver if it is set
+func (c DB) resolveTable(ctx context.Context, table Table, record interface{}) (Table, error) {
+	if c.config.TableResolver == nil {
+		return table, nil
+	}
+
+	resolvedTable, err := c.config.TableResolver(ctx, table, record)
+	if err != nil {
+		return Table{}, fmt.Errorf("KSQL: error resolving table `%s`: %w", table.name, err)
+	}
+
+	return resolvedTable, nil
+}
+
 // Insert one or more instances on the database
 //
 // If the original instances have been passed by reference
@@ -443,6 +1127,16 @@ func (c DB) Insert(
 		return fmt.Errorf("KSQL: expected a valid pointer to struct as argument but received a nil pointer: %v", record)
 	}
 
+	err = runBeforeInsert(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	table, err = c.resolveTable(ctx, table, record)
+	if err != nil {
+		return err
+	}
+
 	if err := table.validate(); err != nil {
 		return fmt.Errorf("can't insert in ksql.Table: %w", err)
 	}
@@ -452,27 +1146,85 @@ func (c DB) Insert(
 		return err
 	}
 
-	query, params, scanValues, err := buildInsertQuery(ctx, c.dialect, table, t, v, info, record)
+	if table.sequence != "" && c.dialect.DriverName() == "sqlserver" {
+		err = c.setIDFromSequence(ctx, table, v, info)
+		if err != nil {
+			return err
+		}
+	}
+
+	query, params, scanValues, err := buildInsertQuery(ctx, c.dialect, table, t, v, info, record, "")
 	if err != nil {
 		return err
 	}
 
-	defer ctxLog(ctx, query, params, &err)
+	defer ctxLog(ctx, query, params, time.Now(), nil, &err)
+	defer c.recordStats(query, nil, &err)
 
-	switch table.insertMethodFor(c.dialect) {
-	case sqldialect.InsertWithReturning, sqldialect.InsertWithOutput:
+	insertMethod := insertMethodForRecord(c.dialect, table, info, v)
+	reselectIDs := len(table.reselectColumns) > 0 &&
+		(insertMethod == sqldialect.InsertWithLastInsertID || insertMethod == sqldialect.InsertWithNoIDRetrieval)
+
+	switch {
+	case reselectIDs:
+		err = c.insertAndReselectIDs(ctx, table, v, info, query, params)
+	case insertMethod == sqldialect.InsertWithReturning, insertMethod == sqldialect.InsertWithOutput:
 		err = c.insertReturningIDs(ctx, query, params, scanValues, table.idColumns)
-	case sqldialect.InsertWithLastInsertID:
+	case insertMethod == sqldialect.InsertWithLastInsertID:
 		err = c.insertWithLastInsertID(ctx, t, v, info, record, query, params, table.idColumns[0])
-	case sqldialect.InsertWithNoIDRetrieval:
+	case insertMethod == sqldialect.InsertWithNoIDRetrieval:
 		err = c.insertWithNoIDRetrieval(ctx, query, params)
 	default:
 		// Unsupported drivers should be detected on the New() function,
 		// So we don't expect the code to ever get into this default case.
-		err = fmt.Errorf("code error: unsupported driver `%s`", c.dialect.DriverName())
+		return fmt.Errorf("code error: unsupported driver `%s`", c.dialect.DriverName())
+	}
+	if err != nil {
+		return OpError{
+			Method: "Insert",
+			Table:  table.name,
+			Query:  query,
+			Err:    err,
+		}
 	}
 
-	return err
+	return runAfterInsert(ctx, record)
+}
+
+// insertMethodForRecord works like Table.insertMethodFor except that
+// it skips the retrieval of the IDs if the record already has all of them,
+// which is always the case for join tables whose only columns are the IDs.
+func insertMethodForRecord(
+	dialect sqldialect.Provider,
+	table Table,
+	info structs.StructInfo,
+	v reflect.Value,
+) sqldialect.InsertMethod {
+	if table.keyGeneration == AutoIncrementKeys {
+		// The IDs are never sent, so they must be retrieved
+		return table.insertMethodFor(dialect)
+	}
+
+	for _, id := range table.idColumns {
+		fieldInfo := info.ByName(id)
+		if !fieldInfo.Valid {
+			return table.insertMethodFor(dialect)
+		}
+
+		field := v.Elem().Field(fieldInfo.Index)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				return table.insertMethodFor(dialect)
+			}
+			field = field.Elem()
+		}
+
+		if field.IsZero() {
+			return table.insertMethodFor(dialect)
+		}
+	}
+
+	return sqldialect.InsertWithNoIDRetrieval
 }
 
 func (c DB) insertReturningIDs(
@@ -482,7 +1234,7 @@ func (c DB) insertReturningIDs(
 	scanValues []interface{},
 	idNames []string,
 ) error {
-	rows, err := c.db.QueryContext(ctx, query, params...)
+	rows, err := c.queryContext(ctx, query, params...)
 	if err != nil {
 		return err
 	}
@@ -515,11 +1267,22 @@ func (c DB) insertWithLastInsertID(
 	params []interface{},
 	idName string,
 ) error {
-	result, err := c.db.ExecContext(ctx, query, params...)
+	result, err := c.execContext(ctx, query, params...)
 	if err != nil {
 		return fmt.Errorf("error running insert query: %w", err)
 	}
 
+	return setLastInsertID(v, info, result, idName)
+}
+
+// setLastInsertID sets the ID attribute of the record
+// with the value returned by result.LastInsertId()
+func setLastInsertID(
+	v reflect.Value,
+	info structs.StructInfo,
+	result Result,
+	idName string,
+) error {
 	id, err := result.LastInsertId()
 	if err != nil {
 		return fmt.Errorf("error fetching LastInsertId: %w", err)
@@ -565,12 +1328,158 @@ func (c DB) insertWithLastInsertID(
 	}
 }
 
+// insertAndReselectIDs inserts the record and then selects the IDs generated
+// by the database using the unique columns set with Table.WithReselectBy()
+func (c DB) insertAndReselectIDs(
+	ctx context.Context,
+	table Table,
+	v reflect.Value,
+	info structs.StructInfo,
+	query string,
+	params []interface{},
+) error {
+	selectQuery, selectParams, scanValues, err := c.buildReselectIDsQuery(table, v, info)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.execContext(ctx, query, params...)
+	if err != nil {
+		return fmt.Errorf("error running insert query: %w", err)
+	}
+
+	return c.reselectIDs(ctx, selectQuery, selectParams, scanValues)
+}
+
+// buildReselectIDsQuery builds the query for selecting the IDs generated by
+// the database using the unique columns set with Table.WithReselectBy()
+func (c DB) buildReselectIDsQuery(
+	table Table,
+	v reflect.Value,
+	info structs.StructInfo,
+) (selectQuery string, selectParams []interface{}, scanValues []interface{}, err error) {
+	var conditions []string
+	for i, col := range table.reselectColumns {
+		fieldInfo := info.ByName(col)
+		if !fieldInfo.Valid {
+			return "", nil, nil, fmt.Errorf("the reselect column `%s` is not an attribute of the record", col)
+		}
+
+		fieldValue := v.Elem().Field(fieldInfo.Index)
+		if fieldValue.IsZero() {
+			return "", nil, nil, fmt.Errorf("the reselect column `%s` must be set for retrieving the IDs of the record", col)
+		}
+
+		conditions = append(conditions, c.dialect.Escape(col)+" = "+c.dialect.Placeholder(i))
+		selectParams = append(selectParams, fieldValue.Interface())
+	}
+
+	var escapedIDNames []string
+	for _, id := range table.idColumns {
+		idInfo := info.ByName(id)
+		if !idInfo.Valid {
+			return "", nil, nil, fmt.Errorf("the ID column `%s` is not an attribute of the record", id)
+		}
+
+		escapedIDNames = append(escapedIDNames, c.dialect.Escape(id))
+		scanValues = append(scanValues, v.Elem().Field(idInfo.Index).Addr().Interface())
+	}
+
+	selectQuery = fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s",
+		strings.Join(escapedIDNames, ", "),
+		table.name,
+		strings.Join(conditions, " AND "),
+	)
+
+	return selectQuery, selectParams, scanValues, nil
+}
+
+// reselectIDs runs the query built by buildReselectIDsQuery
+// and scans the IDs into the record
+func (c DB) reselectIDs(
+	ctx context.Context,
+	selectQuery string,
+	selectParams []interface{},
+	scanValues []interface{},
+) error {
+	rows, err := c.queryContext(ctx, selectQuery, selectParams...)
+	if err != nil {
+		return fmt.Errorf("error reselecting the IDs of the inserted record: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		err := fmt.Errorf("the inserted record was not found when reselecting its IDs")
+		if rows.Err() != nil {
+			err = rows.Err()
+		}
+		return err
+	}
+
+	err = rows.Scan(scanValues...)
+	if err != nil {
+		return fmt.Errorf("error scanning the IDs of the inserted record: %w", err)
+	}
+
+	return rows.Close()
+}
+
+// setIDFromSequence sets the ID of the record with the next value of the
+// sequence set with Table.WithSequence(), unless the ID is already set
+func (c DB) setIDFromSequence(
+	ctx context.Context,
+	table Table,
+	v reflect.Value,
+	info structs.StructInfo,
+) error {
+	idInfo := info.ByName(table.idColumns[0])
+	if !idInfo.Valid {
+		return fmt.Errorf("KSQL: the ID column `%s` is not an attribute of the record", table.idColumns[0])
+	}
+
+	field := v.Elem().Field(idInfo.Index)
+	if field.Kind() == reflect.Ptr && !field.IsNil() && !field.Elem().IsZero() {
+		return nil
+	}
+	if field.Kind() != reflect.Ptr && !field.IsZero() {
+		return nil
+	}
+
+	query := "SELECT NEXT VALUE FOR " + table.sequence
+	rows, err := c.queryContext(ctx, query)
+	if err != nil {
+		return OpError{
+			Method: "Insert",
+			Table:  table.name,
+			Query:  query,
+			Err:    fmt.Errorf("error fetching the next value of the sequence: %w", err),
+		}
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		err := fmt.Errorf("KSQL: the sequence `%s` returned no values", table.sequence)
+		if rows.Err() != nil {
+			err = rows.Err()
+		}
+		return err
+	}
+
+	err = rows.Scan(field.Addr().Interface())
+	if err != nil {
+		return fmt.Errorf("error scanning the next value of the sequence `%s`: %w", table.sequence, err)
+	}
+
+	return rows.Close()
+}
+
 func (c DB) insertWithNoIDRetrieval(
 	ctx context.Context,
 	query string,
 	params []interface{},
 ) error {
-	_, err := c.db.ExecContext(ctx, query, params...)
+	_, err := c.execContext(ctx, query, params...)
 	return err
 }
 
@@ -611,6 +1520,11 @@ func (c DB) Delete(
 	table Table,
 	idOrRecord interface{},
 ) (err error) {
+	table, err = c.resolveTable(ctx, table, idOrRecord)
+	if err != nil {
+		return err
+	}
+
 	if err := table.validate(); err != nil {
 		return fmt.Errorf("can't delete from ksql.Table: %w", err)
 	}
@@ -624,17 +1538,25 @@ func (c DB) Delete(
 	var params []interface{}
 	query, params = buildDeleteQuery(c.dialect, table, idMap)
 
-	defer ctxLog(ctx, query, params, &err)
+	var numRows int
+	defer ctxLog(ctx, query, params, time.Now(), &numRows, &err)
+	defer c.recordStats(query, &numRows, &err)
 
-	result, err := c.db.ExecContext(ctx, query, params...)
+	result, err := c.execContext(ctx, query, params...)
 	if err != nil {
-		return err
+		return OpError{
+			Method: "Delete",
+			Table:  table.name,
+			Query:  query,
+			Err:    err,
+		}
 	}
 
 	n, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("unable to check if the record was succesfully deleted: %w", err)
 	}
+	numRows = int(n)
 
 	if n == 0 {
 		return ErrRecordNotFound
@@ -701,23 +1623,83 @@ func (c DB) Patch(
 		return err
 	}
 
+	err = runBeforeUpdate(ctx, record)
+	if err != nil {
+		return err
+	}
+
 	recordMap, err := structs.StructToMap(record)
 	if err != nil {
 		return err
 	}
 
-	query, params, err := buildUpdateQuery(ctx, c.dialect, table.name, info, recordMap, table.idColumns...)
+	table, err = c.resolveTable(ctx, table, record)
 	if err != nil {
 		return err
 	}
 
-	defer ctxLog(ctx, query, params, &err)
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("can't update ksql.Table: %w", err)
+	}
+
+	query, params, err := buildUpdateQuery(ctx, c.dialect, table, info, recordMap, nil)
+	if err != nil {
+		return err
+	}
 
-	result, err := c.db.ExecContext(ctx, query, params...)
+	err = c.execUpdateQuery(ctx, "Patch", table, query, params)
 	if err != nil {
 		return err
 	}
 
+	return runAfterUpdate(ctx, record)
+}
+
+// PatchOrNoop works exactly as the Patch method except that
+// it treats ErrNoValuesToUpdate as a successful no-op,
+// returning `updated` as false and a nil error.
+//
+// This is useful for partial-update endpoints where the
+// request body might only contain attributes that are ignored
+// by Patch, e.g. nil pointers or attributes with the `skipUpdates`
+// modifier, in which case no query is sent to the database.
+func (c DB) PatchOrNoop(
+	ctx context.Context,
+	table Table,
+	record interface{},
+) (updated bool, err error) {
+	err = c.Patch(ctx, table, record)
+	if errors.Is(err, ErrNoValuesToUpdate) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (c DB) execUpdateQuery(
+	ctx context.Context,
+	method string,
+	table Table,
+	query string,
+	params []interface{},
+) (err error) {
+	var numRows int
+	defer ctxLog(ctx, query, params, time.Now(), &numRows, &err)
+	defer c.recordStats(query, &numRows, &err)
+
+	result, err := c.execContext(ctx, query, params...)
+	if err != nil {
+		return OpError{
+			Method: method,
+			Table:  table.name,
+			Query:  query,
+			Err:    err,
+		}
+	}
+
 	n, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf(
@@ -725,6 +1707,8 @@ func (c DB) Patch(
 			err,
 		)
 	}
+	numRows = int(n)
+
 	if n < 1 {
 		return ErrRecordNotFound
 	}
@@ -732,6 +1716,84 @@ func (c DB) Patch(
 	return nil
 }
 
+// BuildInsertQuery returns the query and params that would be used
+// by the Insert method for inserting the input record.
+//
+// The idPtrs are pointers to the ID attributes of the record that should
+// receive the values returned by the RETURNING or OUTPUT clauses, which are
+// only used by the dialects that support these clauses.
+//
+// It is meant to be used by adapters that send the queries to the
+// database by other means, and for testing the mapping of structs.
+func BuildInsertQuery(
+	ctx context.Context,
+	dialect sqldialect.Provider,
+	table Table,
+	record interface{},
+) (query string, params []interface{}, idPtrs []interface{}, err error) {
+	v := reflect.ValueOf(record)
+	t := v.Type()
+	if err = assertStructPtr(t); err != nil {
+		return "", nil, nil, fmt.Errorf(
+			"KSQL: expected record to be a pointer to struct, but got: %T",
+			record,
+		)
+	}
+
+	if v.IsNil() {
+		return "", nil, nil, fmt.Errorf("KSQL: expected a valid pointer to struct as argument but received a nil pointer: %v", record)
+	}
+
+	if err := table.validate(); err != nil {
+		return "", nil, nil, fmt.Errorf("can't insert in ksql.Table: %w", err)
+	}
+
+	info, err := structs.GetTagInfo(t.Elem())
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	return buildInsertQuery(ctx, dialect, table, t, v, info, record, "")
+}
+
+// BuildPatchQuery returns the query and params that would be
+// used by the Patch method for updating the input record.
+//
+// It is meant to be used by adapters that send the queries to the
+// database by other means, and for testing the mapping of structs.
+func BuildPatchQuery(
+	ctx context.Context,
+	dialect sqldialect.Provider,
+	table Table,
+	record interface{},
+) (query string, params []interface{}, err error) {
+	v := reflect.ValueOf(record)
+	t := v.Type()
+	tStruct := t
+	if t.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil, fmt.Errorf("KSQL: expected a valid pointer to struct as argument but received a nil pointer: %v", record)
+		}
+		tStruct = t.Elem()
+	}
+
+	if err := table.validate(); err != nil {
+		return "", nil, fmt.Errorf("can't update ksql.Table: %w", err)
+	}
+
+	info, err := structs.GetTagInfo(tStruct)
+	if err != nil {
+		return "", nil, err
+	}
+
+	recordMap, err := structs.StructToMap(record)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return buildUpdateQuery(ctx, dialect, table, info, recordMap, nil)
+}
+
 func buildInsertQuery(
 	ctx context.Context,
 	dialect sqldialect.Provider,
@@ -740,6 +1802,7 @@ func buildInsertQuery(
 	v reflect.Value,
 	info structs.StructInfo,
 	record interface{},
+	onConflictQuery string,
 ) (query string, params []interface{}, scanValues []interface{}, err error) {
 	recordMap, err := structs.StructToMap(record)
 	if err != nil {
@@ -752,20 +1815,44 @@ func buildInsertQuery(
 			continue
 		}
 
-		// Remove any ID field that was not set:
-		if reflect.ValueOf(field).IsZero() {
+		switch table.keyGeneration {
+		case AutoIncrementKeys:
 			delete(recordMap, fieldName)
+		case DetectKeyGeneration:
+			// Remove any ID field that was not set:
+			if reflect.ValueOf(field).IsZero() {
+				delete(recordMap, fieldName)
+			}
 		}
 	}
 
-	columnNames := []string{}
-	for col := range recordMap {
-		if info.ByName(col).Modifier.SkipOnInsert {
+	columnValues, err := getColumnValues(ctx)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	columnNames := []string{}
+	for col := range recordMap {
+		fieldInfo := info.ByName(col)
+		if _, found := columnValues[col]; found {
+			continue
+		}
+
+		if fieldInfo.Modifier.SkipOnInsert {
+			continue
+		}
+
+		if fieldInfo.Modifier.SkipOnInsertIfZero && v.Elem().Field(fieldInfo.Index).IsZero() {
 			continue
 		}
 
 		columnNames = append(columnNames, col)
 	}
+	for col, value := range columnValues {
+		recordMap[col] = value
+		columnNames = append(columnNames, col)
+	}
+	sortColumnsByFieldIndex(info, columnNames)
 
 	params = make([]interface{}, len(columnNames))
 	valuesQuery := make([]string, len(columnNames))
@@ -773,8 +1860,9 @@ func buildInsertQuery(
 		recordValue := recordMap[col]
 		params[i] = recordValue
 
+		_, isSet := columnValues[col]
 		valueFn := info.ByName(col).Modifier.Value
-		if valueFn != nil {
+		if valueFn != nil && !isSet {
 			params[i] = modifiers.AttrValueWrapper{
 				Ctx:     ctx,
 				Attr:    recordValue,
@@ -795,8 +1883,8 @@ func buildInsertQuery(
 		escapedColumnNames = append(escapedColumnNames, dialect.Escape(col))
 	}
 
-	var returningQuery, outputQuery string
-	switch dialect.InsertMethod() {
+	var returningQuery, outputQuery, scopeIdentityQuery string
+	switch insertMethodForRecord(dialect, table, info, v) {
 	case sqldialect.InsertWithReturning:
 		escapedIDNames := []string{}
 		for _, id := range table.idColumns {
@@ -811,6 +1899,17 @@ func buildInsertQuery(
 			)
 		}
 	case sqldialect.InsertWithOutput:
+		if table.scopeIdentity {
+			// The ID is selected by a second statement on the same batch,
+			// the CAST is necessary because SCOPE_IDENTITY() returns a NUMERIC:
+			scopeIdentityQuery = "; SELECT CAST(SCOPE_IDENTITY() AS BIGINT)"
+			scanValues = append(
+				scanValues,
+				v.Elem().Field(info.ByName(table.idColumns[0]).Index).Addr().Interface(),
+			)
+			break
+		}
+
 		escapedIDNames := []string{}
 		for _, id := range table.idColumns {
 			escapedIDNames = append(escapedIDNames, "INSERTED."+dialect.Escape(id))
@@ -826,24 +1925,35 @@ func buildInsertQuery(
 	}
 
 	if len(columnNames) == 0 && dialect.DriverName() != "mysql" {
+		insertQuery := "INSERT INTO"
+		if onConflictQuery != "" && dialect.DriverName() == "sqlite3" {
+			// SQLite doesn't accept the ON CONFLICT clause after DEFAULT VALUES:
+			insertQuery, onConflictQuery = "INSERT OR IGNORE INTO", ""
+		}
+
 		query = fmt.Sprintf(
-			"INSERT INTO %s%s DEFAULT VALUES%s",
+			"%s %s%s DEFAULT VALUES%s%s%s",
+			insertQuery,
 			table.name,
 			outputQuery,
+			onConflictQuery,
 			returningQuery,
+			scopeIdentityQuery,
 		)
 		return query, params, scanValues, nil
 	}
 
-	// Note that the outputQuery and the returningQuery depend
-	// on the selected driver, thus, they might be empty strings.
+	// Note that the outputQuery, the onConflictQuery, the returningQuery and the
+	// scopeIdentityQuery depend on the selected driver, thus, they might be empty strings.
 	query = fmt.Sprintf(
-		"INSERT INTO %s (%s)%s VALUES (%s)%s",
+		"INSERT INTO %s (%s)%s VALUES (%s)%s%s%s",
 		table.name,
 		strings.Join(escapedColumnNames, ", "),
 		outputQuery,
 		strings.Join(valuesQuery, ", "),
+		onConflictQuery,
 		returningQuery,
+		scopeIdentityQuery,
 	)
 
 	return query, params, scanValues, nil
@@ -852,18 +1962,40 @@ func buildInsertQuery(
 func buildUpdateQuery(
 	ctx context.Context,
 	dialect sqldialect.Provider,
-	tableName string,
+	table Table,
 	info structs.StructInfo,
 	recordMap map[string]interface{},
-	idFieldNames ...string,
+	newKeys map[string]interface{},
 ) (query string, args []interface{}, err error) {
+	idFieldNames := table.idColumns
+
+	columnValues, err := getColumnValues(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var skippedAttrs []string
 	for key := range recordMap {
+		if _, found := columnValues[key]; found {
+			continue
+		}
+
 		if info.ByName(key).Modifier.SkipOnUpdate {
 			delete(recordMap, key)
+			skippedAttrs = append(skippedAttrs, info.ByName(key).AttrName)
+		}
+	}
+
+	for _, idName := range idFieldNames {
+		if _, found := columnValues[idName]; found {
+			return "", nil, fmt.Errorf("KSQL: the ID column `%s` can't be changed with the Set option", idName)
 		}
 	}
+	for col, value := range columnValues {
+		recordMap[col] = value
+	}
 
-	numAttrs := len(recordMap)
+	numAttrs := len(recordMap) + len(newKeys)
 	args = make([]interface{}, numAttrs)
 
 	err = validateIfAllIdsArePresent(idFieldNames, recordMap)
@@ -874,6 +2006,13 @@ func buildUpdateQuery(
 	numNonIDArgs := numAttrs - len(idFieldNames)
 	whereArgs := args[numNonIDArgs:]
 	if numNonIDArgs == 0 {
+		if len(skippedAttrs) > 0 {
+			sort.Strings(skippedAttrs)
+			return "", nil, fmt.Errorf(
+				"%w: the attributes %s were ignored because their modifiers skip updates",
+				ErrNoValuesToUpdate, strings.Join(skippedAttrs, ", "),
+			)
+		}
 		return "", nil, ErrNoValuesToUpdate
 	}
 
@@ -882,24 +2021,32 @@ func buildUpdateQuery(
 		whereArgs[i] = recordMap[fieldName]
 		whereQuery[i] = fmt.Sprintf(
 			"%s = %s",
-			dialect.Escape(fieldName),
+			table.qualifiedColumn(dialect, fieldName),
 			dialect.Placeholder(i+numNonIDArgs),
 		)
 
 		delete(recordMap, fieldName)
 	}
 
+	// The new values of the ID columns are set just like the other
+	// columns, since their old values were already used on the WHERE:
+	for key, value := range newKeys {
+		recordMap[key] = value
+	}
+
 	keys := []string{}
 	for key := range recordMap {
 		keys = append(keys, key)
 	}
+	sortColumnsByFieldIndex(info, keys)
 
 	var setQuery []string
 	for i, k := range keys {
 		recordValue := recordMap[k]
 
+		_, isSet := columnValues[k]
 		valueFn := info.ByName(k).Modifier.Value
-		if valueFn != nil {
+		if valueFn != nil && !isSet {
 			recordValue = modifiers.AttrValueWrapper{
 				Ctx:     ctx,
 				Attr:    recordValue,
@@ -918,9 +2065,21 @@ func buildUpdateQuery(
 		))
 	}
 
+	if table.alias != "" && dialect.DriverName() == "sqlserver" {
+		// SQL Server only accepts aliases declared on a FROM clause:
+		query = fmt.Sprintf(
+			"UPDATE %s SET %s FROM %s WHERE %s",
+			table.alias,
+			strings.Join(setQuery, ", "),
+			table.nameWithAlias(),
+			strings.Join(whereQuery, " AND "),
+		)
+		return query, args, nil
+	}
+
 	query = fmt.Sprintf(
 		"UPDATE %s SET %s WHERE %s",
-		tableName,
+		table.nameWithAlias(),
 		strings.Join(setQuery, ", "),
 		strings.Join(whereQuery, " AND "),
 	)
@@ -928,6 +2087,26 @@ func buildUpdateQuery(
 	return query, args, nil
 }
 
+// sortColumnsByFieldIndex sorts the columns in the same order the
+// attributes are declared on the struct, this way the generated
+// queries are deterministic even though they are built from maps.
+func sortColumnsByFieldIndex(info structs.StructInfo, columns []string) {
+	sort.Slice(columns, func(i, j int) bool {
+		fieldI, fieldJ := info.ByName(columns[i]), info.ByName(columns[j])
+
+		// Columns that are not on the struct, i.e. added with
+		// the Set option, go last ordered by their names:
+		if fieldI.Valid != fieldJ.Valid {
+			return fieldI.Valid
+		}
+		if !fieldI.Valid {
+			return columns[i] < columns[j]
+		}
+
+		return fieldI.Index < fieldJ.Index
+	})
+}
+
 func validateIfAllIdsArePresent(idNames []string, idMap map[string]interface{}) error {
 	for _, idName := range idNames {
 		id, found := idMap[idName]
@@ -945,9 +2124,24 @@ func validateIfAllIdsArePresent(idNames []string, idMap map[string]interface{})
 
 // Exec just runs an SQL command on the database returning no rows.
 func (c DB) Exec(ctx context.Context, query string, params ...interface{}) (_ Result, err error) {
-	defer ctxLog(ctx, query, params, &err)
+	if err := c.checkQuery(ctx, query, params); err != nil {
+		return nil, err
+	}
+
+	numRows := -1
+	defer ctxLog(ctx, query, params, time.Now(), &numRows, &err)
+	defer c.recordStats(query, &numRows, &err)
 
-	return c.db.ExecContext(ctx, query, params...)
+	result, err := c.execContext(ctx, query, params...)
+	if err == nil && (isLogging(ctx) || c.stats != nil) {
+		// Only calling RowsAffected when logging or collecting stats
+		// since it might be expensive or unsupported on some drivers:
+		if n, err := result.RowsAffected(); err == nil {
+			numRows = int(n)
+		}
+	}
+
+	return result, err
 }
 
 // Transaction encapsulates several queries into a single transaction.
@@ -958,49 +2152,146 @@ func (c DB) Exec(ctx context.Context, query string, params ...interface{}) (_ Re
 // otherwise the transaction will me committed.
 //
 // If it happens that a second transaction is started inside a transaction
-// callback the same transaction will be reused with no errors.
+// callback the same transaction will be reused with no errors, unless the
+// Config.NestedTransactions option is set and the transaction of the adapter
+// implements the NestedTxBeginner interface, in which case the second
+// transaction is started as a nested transaction,
+// e.g. using a savepoint, so returning an error from the inner callback
+// only rolls back the changes made inside it.
 func (c DB) Transaction(ctx context.Context, fn func(Provider) error) error {
 	switch txBeginner := c.db.(type) {
 	case Tx:
-		return fn(c)
+		nestedTxBeginner, ok := txBeginner.(NestedTxBeginner)
+		if !ok || !c.config.NestedTransactions {
+			err := applyRLSSettings(ctx, c.dialect.DriverName(), txBeginner)
+			if err != nil {
+				return err
+			}
+			return fn(c)
+		}
+
+		tx, err := nestedTxBeginner.BeginNestedTx(ctx)
+		if err != nil {
+			return fmt.Errorf("KSQL: error starting nested transaction: %w", err)
+		}
+
+		return c.runTransaction(ctx, tx, c.outerAdapter, fn)
 	case TxBeginner:
+		ctx, done, err := c.trackOperation(ctx)
+		if err != nil {
+			return err
+		}
+		defer done()
+
+		if c.txSemaphore != nil {
+			select {
+			case c.txSemaphore <- struct{}{}:
+				defer func() { <-c.txSemaphore }()
+			default:
+				return fmt.Errorf(
+					"%w: the limit set with Config.MaxConcurrentTransactions is %d",
+					ErrTooManyTransactions, c.config.MaxConcurrentTransactions,
+				)
+			}
+		}
+
 		tx, err := txBeginner.BeginTx(ctx)
 		if err != nil {
 			return fmt.Errorf("KSQL: error starting transaction: %w", err)
 		}
-		defer func() {
-			if r := recover(); r != nil {
-				rollbackErr := tx.Rollback(ctx)
-				if rollbackErr != nil {
-					r = fmt.Errorf(
-						"KSQL: unable to rollback after panic with value: %v, rollback error: %w",
-						r, rollbackErr,
-					)
-				}
-				panic(r)
-			}
-		}()
 
-		dbCopy := c
-		dbCopy.db = tx
+		return c.runTransaction(ctx, tx, c.db, fn)
 
-		err = fn(dbCopy)
-		if err != nil {
+	default:
+		return fmt.Errorf("KSQL: can't start transaction: The DBAdapter doesn't implement the TxBeginner interface")
+	}
+}
+
+// runTransaction calls fn with a copy of the DB that uses the input
+// transaction, committing it afterwards or rolling it back on errors.
+//
+// The outerAdapter is the adapter outside of any
+// transactions, used for starting independent ones.
+func (c DB) runTransaction(ctx context.Context, tx Tx, outerAdapter DBAdapter, fn func(Provider) error) (err error) {
+	err = applyRLSSettings(ctx, c.dialect.DriverName(), tx)
+	if err != nil {
+		rollbackErr := tx.Rollback(ctx)
+		if rollbackErr != nil {
+			err = fmt.Errorf("%s, rollback error: %w", err, rollbackErr)
+		}
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
 			rollbackErr := tx.Rollback(ctx)
 			if rollbackErr != nil {
-				err = fmt.Errorf(
-					"KSQL: unable to rollback after error: %s, rollback error: %w",
-					err, rollbackErr,
+				r = fmt.Errorf(
+					"KSQL: unable to rollback after panic with value: %v, rollback error: %w",
+					r, rollbackErr,
 				)
 			}
-			return err
+			panic(r)
 		}
+	}()
 
-		return tx.Commit(ctx)
+	dbCopy := c
+	dbCopy.db = tx
+	dbCopy.outerAdapter = outerAdapter
 
-	default:
-		return fmt.Errorf("KSQL: can't start transaction: The DBAdapter doesn't implement the TxBeginner interface")
+	err = fn(dbCopy)
+	if err != nil {
+		rollbackErr := tx.Rollback(ctx)
+		if rollbackErr != nil {
+			err = fmt.Errorf(
+				"KSQL: unable to rollback after error: %s, rollback error: %w",
+				err, rollbackErr,
+			)
+		}
+		return err
 	}
+
+	return tx.Commit(ctx)
+}
+
+// Dialect returns the dialect used by this DB instance,
+// which is useful for building dialect-specific queries.
+func (c DB) Dialect() sqldialect.Provider {
+	return c.dialect
+}
+
+// DialectOf returns the dialect of the input Provider if it exposes one,
+// which is the case for DB instances, including the ones received inside
+// transactions, and for Mocks with the DialectFn attribute set.
+//
+// It is meant for libraries built on top of ksql.Provider that need to
+// render dialect-specific fragments, e.g. placeholders or escaped names,
+// without requiring the dialect to be passed separately:
+//
+//	dialect, ok := ksql.DialectOf(db)
+//	if !ok {
+//		return fmt.Errorf("the dialect of %T is unknown", db)
+//	}
+//	query := "SELECT id FROM users WHERE " + dialect.Escape("name") + " = " + dialect.Placeholder(0)
+func DialectOf(db Provider) (sqldialect.Provider, bool) {
+	dialecter, ok := db.(interface {
+		Dialect() sqldialect.Provider
+	})
+	if !ok {
+		return nil, false
+	}
+
+	dialect := dialecter.Dialect()
+	return dialect, dialect != nil
+}
+
+// Adapter returns the DBAdapter used by this DB instance,
+// which inside transactions is the adapter of the transaction.
+//
+// This is meant for sending queries directly to the adapter,
+// e.g. the ones whose rows are scanned with DB.ScanRow.
+func (c DB) Adapter() DBAdapter {
+	return c.db
 }
 
 // Close implements the io.Closer interface
@@ -1012,6 +2303,62 @@ func (c DB) Close() error {
 	return nil
 }
 
+var serverVersionQueries = map[string]string{
+	"postgres":  "SELECT version()",
+	"mysql":     "SELECT VERSION()",
+	"sqlserver": "SELECT @@VERSION",
+	"sqlite3":   "SELECT sqlite_version()",
+}
+
+// ServerVersion returns the version of the database server,
+// which is useful for enabling features that are only
+// available on newer versions of the database.
+//
+// The format of the returned string depends on the database,
+// e.g. "PostgreSQL 15.3 on x86_64-pc-linux-gnu, ..." or "3.41.2" for sqlite.
+func (c DB) ServerVersion(ctx context.Context) (version string, err error) {
+	if versioner, ok := c.db.(ServerVersioner); ok {
+		version, err := versioner.ServerVersion(ctx)
+		if err != errNoServerVersioner {
+			return version, err
+		}
+	}
+
+	query, found := serverVersionQueries[c.dialect.DriverName()]
+	if !found {
+		return "", fmt.Errorf(
+			"KSQL: can't get the server version for the `%s` dialect: the DBAdapter doesn't implement the ServerVersioner interface",
+			c.dialect.DriverName(),
+		)
+	}
+
+	defer ctxLog(ctx, query, nil, time.Now(), nil, &err)
+
+	rows, err := c.queryContext(ctx, query)
+	if err != nil {
+		return "", OpError{
+			Method: "ServerVersion",
+			Query:  query,
+			Err:    err,
+		}
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("KSQL: the server version query returned no results")
+	}
+
+	err = rows.Scan(&version)
+	if err != nil {
+		return "", fmt.Errorf("KSQL: unable to scan the server version: %w", err)
+	}
+
+	return version, rows.Close()
+}
+
 type nopScanner struct{}
 
 var nopScannerValue = reflect.ValueOf(&nopScanner{}).Interface()
@@ -1020,15 +2367,60 @@ func (nopScanner) Scan(value interface{}) error {
 	return nil
 }
 
+// ScanRow scans the current row of the input Rows into the record,
+// which must be a pointer to a struct with `ksql` tags, using the same
+// rules used by the Query method for matching columns and attributes,
+// including the modifiers set on the `ksql` tags.
+//
+// It is meant to be used by adapters for scanning the native rows
+// of their drivers, e.g. for interoperating with other libraries,
+// and since it has no access to the Config of a DB the options
+// that affect scanning, e.g. TimeLocation, are not applied.
+//
+// For scanning the rows of handwritten queries use DB.ScanRow instead.
+func ScanRow(ctx context.Context, dialect sqldialect.Provider, rows Rows, record interface{}) error {
+	return scanRows(ctx, dialect, rows, record)
+}
+
+// ScanRow works like the ksql.ScanRow function but it uses the dialect
+// and the Config of the DB, so options such as TimeLocation and
+// NormalizeColumnName are applied just like on the Query method.
+//
+// It is useful for reusing the KSQL struct scanning on queries that
+// can't be run with the Query methods, e.g. queries with a
+// `RETURNING` clause sent directly to the DBAdapter:
+//
+//	rows, err := db.Adapter().QueryContext(ctx, `UPDATE users SET age = age + 1 RETURNING *`)
+//	if err != nil { ... }
+//	defer rows.Close()
+//
+//	for rows.Next() {
+//		var user User
+//		err := db.ScanRow(ctx, rows, &user)
+//		if err != nil { ... }
+//		...
+//	}
+//
+// Note that rows.Next() must be called before ScanRow and that
+// closing the rows and checking rows.Err() is up to the caller.
+func (c DB) ScanRow(ctx context.Context, rows Rows, record interface{}) error {
+	return scanRowsWithConfig(ctx, c.dialect, c.config, rows, record)
+}
+
 func scanRows(ctx context.Context, dialect sqldialect.Provider, rows Rows, record interface{}) error {
+	return scanRowsWithConfig(ctx, dialect, Config{}, rows, record)
+}
+
+func scanRowsWithConfig(ctx context.Context, dialect sqldialect.Provider, config Config, rows Rows, record interface{}) error {
 	v := reflect.ValueOf(record)
 	t := v.Type()
-	return scanRowsFromType(ctx, dialect, rows, record, t, v)
+	return scanRowsFromType(ctx, dialect, config, rows, record, t, v)
 }
 
 func scanRowsFromType(
 	ctx context.Context,
 	dialect sqldialect.Provider,
+	config Config,
 	rows Rows,
 	record interface{},
 	t reflect.Type,
@@ -1067,7 +2459,7 @@ func scanRowsFromType(
 		}
 		// Since this version uses the names of the columns it works
 		// with any order of attributes/columns.
-		attrNames, scanArgs = getScanArgsFromNames(ctx, dialect, colNames, v, info)
+		attrNames, scanArgs = getScanArgsFromNames(ctx, dialect, config, colNames, v, info)
 	}
 
 	err = rows.Scan(scanArgs...)
@@ -1080,6 +2472,8 @@ func scanRowsFromType(
 		}
 		return fmt.Errorf("KSQL: scan error: %w", err)
 	}
+
+	normalizeTimeLocation(config.TimeLocation, scanArgs)
 	return nil
 }
 
@@ -1132,15 +2526,82 @@ func getScanArgsForNestedStructs(
 	return attrNames, scanArgs, nil
 }
 
+// checkDuplicatedColumns reports an error if two of the columns returned
+// by the query would be scanned into the same attribute of the struct.
+//
+// It should be called once per query, right after the rows are opened.
+func checkDuplicatedColumns(
+	ctx context.Context,
+	config Config,
+	rows Rows,
+	structType reflect.Type,
+) error {
+	info, err := structs.GetTagInfo(structType)
+	if err != nil || info.IsNestedStruct {
+		return err
+	}
+
+	colNames, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("KSQL: unable to read columns from returned rows: %w", err)
+	}
+
+	columnByAttr := make(map[string]string, len(colNames))
+	for _, name := range colNames {
+		fieldInfo := fieldForColumn(ctx, config, info, name)
+		if !fieldInfo.Valid {
+			continue
+		}
+
+		if previous, found := columnByAttr[fieldInfo.AttrName]; found {
+			return fmt.Errorf(
+				"KSQL: the columns `%s` and `%s` returned by the query are both mapped to the attribute %s,"+
+					" use different aliases for these columns or the ksql.QualifiedColumns() option",
+				previous, name, fieldInfo.AttrName,
+			)
+		}
+		columnByAttr[fieldInfo.AttrName] = name
+	}
+
+	return nil
+}
+
+// fieldForColumn finds the attribute that should receive the column
+// returned by the query, taking the Alias and QualifiedColumns options
+// into account, the returned FieldInfo is not Valid if there is none.
+func fieldForColumn(
+	ctx context.Context,
+	config Config,
+	info structs.StructInfo,
+	name string,
+) *structs.FieldInfo {
+	options := getCallOptions(ctx)
+
+	tagName := name
+	if alias, found := options.columnAliases[name]; found {
+		tagName = alias
+	}
+
+	fieldInfo := info.ByColumnName(tagName, config.NormalizeColumnName)
+	if !fieldInfo.Valid && strings.Contains(name, ".") && options.qualifiedColumns {
+		// Columns aliased by the QualifiedColumns option fall back
+		// to the attributes tagged with the unqualified name:
+		fieldInfo = info.ByColumnName(name[strings.LastIndex(name, ".")+1:], config.NormalizeColumnName)
+	}
+
+	return fieldInfo
+}
+
 func getScanArgsFromNames(
 	ctx context.Context,
 	dialect sqldialect.Provider,
+	config Config,
 	names []string,
 	v reflect.Value,
 	info structs.StructInfo,
 ) (attrNames []string, scanArgs []interface{}) {
 	for _, name := range names {
-		fieldInfo := info.ByName(name)
+		fieldInfo := fieldForColumn(ctx, config, info, name)
 
 		valueScanner := nopScannerValue
 		if fieldInfo.Valid {
@@ -1172,19 +2633,110 @@ func buildDeleteQuery(
 	table Table,
 	idMap map[string]interface{},
 ) (query string, params []interface{}) {
+	whereClause, params := buildIDsWhereClause(dialect, table, idMap)
+	return buildDeleteQueryWithWhere(dialect, table, whereClause), params
+}
+
+// buildIDsWhereClause returns the conditions matching the
+// record with the input IDs, e.g. `"id" = $1`, without the
+// WHERE keyword so it can be shared by different queries.
+func buildIDsWhereClause(
+	dialect sqldialect.Provider,
+	table Table,
+	idMap map[string]interface{},
+) (whereClause string, params []interface{}) {
 	whereQuery := []string{}
 	for i, idName := range table.idColumns {
 		whereQuery = append(whereQuery, fmt.Sprintf(
-			"%s = %s", dialect.Escape(idName), dialect.Placeholder(i),
+			"%s = %s", table.qualifiedColumn(dialect, idName), dialect.Placeholder(i),
 		))
 		params = append(params, idMap[idName])
 	}
 
+	return strings.Join(whereQuery, " AND "), params
+}
+
+func buildDeleteQueryWithWhere(dialect sqldialect.Provider, table Table, whereClause string) string {
+	deleteTarget := ""
+	if table.alias != "" && (dialect.DriverName() == "sqlserver" || dialect.DriverName() == "mysql") {
+		// These dialects only accept aliases on the multi-table DELETE syntax:
+		deleteTarget = table.alias + " "
+	}
+
 	return fmt.Sprintf(
-		"DELETE FROM %s WHERE %s",
-		table.name,
-		strings.Join(whereQuery, " AND "),
-	), params
+		"DELETE %sFROM %s WHERE %s",
+		deleteTarget,
+		table.nameWithAlias(),
+		whereClause,
+	)
+}
+
+// columnsMarker can be used anywhere on a query to be replaced
+// by the list of columns of the struct the query is being scanned into,
+// e.g. "WITH ... SELECT :ksql_columns FROM ..."
+//
+// For structs that are not nested it is also possible to pass a table alias
+// that will prefix each of the columns, e.g. "SELECT :ksql_columns(u) FROM users u JOIN ..."
+const columnsMarker = ":ksql_columns"
+
+var columnsMarkerRegex = regexp.MustCompile(columnsMarker + `(?:\(\s*([\w.]*)\s*\)|\b)`)
+
+func hasColumnsMarker(query string) bool {
+	return columnsMarkerRegex.MatchString(query)
+}
+
+// expandColumnsMarker replaces all occurrences of the columnsMarker on the query
+// with the same list of escaped columns used when building the SELECT part of the queries.
+func expandColumnsMarker(
+	dialect sqldialect.Provider,
+	structType reflect.Type,
+	info structs.StructInfo,
+	query string,
+) (string, error) {
+	selectPrefix, err := buildSelectQuery(dialect, structType, info, getSelectQueryCache(dialect))
+	if err != nil {
+		return "", err
+	}
+	columns := strings.TrimSpace(strings.TrimPrefix(selectPrefix, "SELECT "))
+
+	var expandErr error
+	query = columnsMarkerRegex.ReplaceAllStringFunc(query, func(marker string) string {
+		alias := columnsMarkerRegex.FindStringSubmatch(marker)[1]
+		if alias == "" {
+			return columns
+		}
+
+		if info.IsNestedStruct {
+			expandErr = fmt.Errorf(
+				"KSQL: can't use the marker `%s` with nested structs: the table names are already defined on the `tablename` tags",
+				marker,
+			)
+			return marker
+		}
+
+		return buildAliasedColumns(dialect, structType, info, alias)
+	})
+
+	return query, expandErr
+}
+
+func buildAliasedColumns(
+	dialect sqldialect.Provider,
+	structType reflect.Type,
+	info structs.StructInfo,
+	alias string,
+) string {
+	var fields []string
+	for i := 0; i < structType.NumField(); i++ {
+		fieldInfo := info.ByIndex(i)
+		if !fieldInfo.Valid {
+			continue
+		}
+
+		fields = append(fields, selectField(dialect, fieldInfo, alias, fieldInfo.ColumnName))
+	}
+
+	return strings.Join(fields, ", ")
 }
 
 // We implemented this function instead of using
@@ -1203,6 +2755,65 @@ func getFirstToken(s string) string {
 	return token.String()
 }
 
+// buildSelectPrefix builds the SELECT part of the queries starting with `FROM`,
+// qualifying the columns if the QualifiedSelect option was used and
+// renaming them if the Alias option was used.
+func buildSelectPrefix(
+	ctx context.Context,
+	dialect sqldialect.Provider,
+	structType reflect.Type,
+	info structs.StructInfo,
+) (string, error) {
+	options := getCallOptions(ctx)
+	if len(options.qualifiedTables) == 0 && len(options.columnAliases) == 0 {
+		return buildSelectQuery(dialect, structType, info, getSelectQueryCache(dialect))
+	}
+
+	if info.IsNestedStruct && len(options.columnAliases) > 0 {
+		return "", fmt.Errorf(
+			"KSQL: can't use the Alias option with nested structs on queries starting with `FROM`",
+		)
+	}
+
+	if info.IsNestedStruct {
+		return buildSelectQueryForNestedStructs(dialect, structType, info, options.qualifiedTables)
+	}
+
+	if len(options.qualifiedTables) > 1 {
+		return "", fmt.Errorf(
+			"KSQL: the QualifiedSelect option only accepts multiple tables for nested structs, but got %d tables",
+			len(options.qualifiedTables),
+		)
+	}
+
+	var qualifier string
+	if len(options.qualifiedTables) == 1 {
+		qualifier = options.qualifiedTables[0].qualifier()
+	}
+
+	queryColumns := make(map[string]string, len(options.columnAliases))
+	for queryColumn, tagColumn := range options.columnAliases {
+		queryColumns[tagColumn] = queryColumn
+	}
+
+	var fields []string
+	for i := 0; i < structType.NumField(); i++ {
+		fieldInfo := info.ByIndex(i)
+		if !fieldInfo.Valid {
+			continue
+		}
+
+		column := fieldInfo.ColumnName
+		if queryColumn, found := queryColumns[column]; found {
+			column = queryColumn
+		}
+
+		fields = append(fields, selectField(dialect, fieldInfo, qualifier, column))
+	}
+
+	return "SELECT " + strings.Join(fields, ", ") + " ", nil
+}
+
 func buildSelectQuery(
 	dialect sqldialect.Provider,
 	structType reflect.Type,
@@ -1218,7 +2829,7 @@ func buildSelectQuery(
 	}
 
 	if info.IsNestedStruct {
-		query, err = buildSelectQueryForNestedStructs(dialect, structType, info)
+		query, err = buildSelectQueryForNestedStructs(dialect, structType, info, nil)
 		if err != nil {
 			return "", err
 		}
@@ -1242,17 +2853,41 @@ func buildSelectQueryForPlainStructs(
 			continue
 		}
 
-		fields = append(fields, dialect.Escape(fieldInfo.ColumnName))
+		fields = append(fields, selectField(dialect, fieldInfo, "", fieldInfo.ColumnName))
 	}
 
 	return "SELECT " + strings.Join(fields, ", ") + " "
 }
 
+// selectField returns the escaped column name, optionally prefixed
+// with a table qualifier, or for fields with a `ksqlexpr` tag the
+// expression of the tag aliased to the column name.
+func selectField(dialect sqldialect.Provider, fieldInfo *structs.FieldInfo, qualifier string, column string) string {
+	if fieldInfo.Expr != "" {
+		return fieldInfo.Expr + " AS " + dialect.Escape(column)
+	}
+
+	if qualifier != "" {
+		return qualifier + "." + dialect.Escape(column)
+	}
+	return dialect.Escape(column)
+}
+
+// buildSelectQueryForNestedStructs builds the SELECT qualifying the columns
+// of each nested struct with its `tablename` tag, or with the alias of the
+// table of the same name, if any, from the input aliasedTables.
 func buildSelectQueryForNestedStructs(
 	dialect sqldialect.Provider,
 	structType reflect.Type,
 	info structs.StructInfo,
+	aliasedTables []Table,
 ) (string, error) {
+	qualifiers := make(map[string]string, len(aliasedTables))
+	for _, table := range aliasedTables {
+		qualifiers[table.name] = table.qualifier()
+	}
+	usedTables := make(map[string]bool, len(aliasedTables))
+
 	var fields []string
 	for i := 0; i < structType.NumField(); i++ {
 		nestedStructInfo := info.ByIndex(i)
@@ -1261,6 +2896,13 @@ func buildSelectQueryForNestedStructs(
 		}
 
 		nestedStructName := nestedStructInfo.ColumnName
+		qualifier, found := qualifiers[nestedStructName]
+		if found {
+			usedTables[nestedStructName] = true
+		} else {
+			qualifier = dialect.Escape(nestedStructName)
+		}
+
 		nestedStructType := structType.Field(i).Type
 		if nestedStructType.Kind() != reflect.Struct {
 			return "", fmt.Errorf(
@@ -1282,7 +2924,16 @@ func buildSelectQueryForNestedStructs(
 
 			fields = append(
 				fields,
-				dialect.Escape(nestedStructName)+"."+dialect.Escape(fieldInfo.ColumnName),
+				selectField(dialect, fieldInfo, qualifier, fieldInfo.ColumnName),
+			)
+		}
+	}
+
+	for _, table := range aliasedTables {
+		if !usedTables[table.name] {
+			return "", fmt.Errorf(
+				"KSQL: the table '%s' passed to the QualifiedSelect option doesn't match the `tablename` tag of any of the nested structs",
+				table.name,
 			)
 		}
 	}
@@ -2,10 +2,14 @@ package ksql
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	tt "github.com/vingarcia/ksql/internal/testtools"
 	"github.com/vingarcia/ksql/sqldialect"
@@ -402,3 +406,2646 @@ func TestInjectLogger(t *testing.T) {
 		})
 	}
 }
+
+func TestColumnNameNormalization(t *testing.T) {
+	ctx := context.Background()
+
+	newMockDB := func(config Config, columns []string, values []interface{}) DB {
+		numRows := 1
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			config:  config,
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					return mockRows{
+						ScanFn: func(args ...interface{}) error {
+							for i, arg := range args {
+								if ptr, ok := arg.(*int); ok {
+									*ptr = values[i].(int)
+								}
+								if ptr, ok := arg.(*string); ok {
+									*ptr = values[i].(string)
+								}
+							}
+							return nil
+						},
+						NextFn: func() bool {
+							numRows--
+							return numRows >= 0
+						},
+						ColumnsFn: func() ([]string, error) { return columns, nil },
+					}, nil
+				},
+			},
+		}
+	}
+
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	t.Run("should match upper case column names by default", func(t *testing.T) {
+		c := newMockDB(Config{}, []string{"ID", "NAME"}, []interface{}{42, "fakeName"})
+
+		var u User
+		err := c.QueryOne(ctx, &u, `SELECT ID, NAME FROM USERS`)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u, User{
+			ID:   42,
+			Name: "fakeName",
+		})
+	})
+
+	t.Run("should use the configured normalizer", func(t *testing.T) {
+		c := newMockDB(Config{
+			NormalizeColumnName: func(name string) string {
+				return strings.TrimPrefix(strings.ToLower(name), "u_")
+			},
+		}, []string{"U_ID", "U_NAME"}, []interface{}{42, "fakeName"})
+
+		var u User
+		err := c.QueryOne(ctx, &u, `SELECT ID AS U_ID, NAME AS U_NAME FROM USERS`)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u, User{
+			ID:   42,
+			Name: "fakeName",
+		})
+	})
+}
+
+func TestOpError(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+	usersTable := NewTable("users")
+
+	fakeErr := errors.New("fakeAdapterErrMsg")
+	c := DB{
+		dialect: sqldialect.SupportedDialects["postgres"],
+		db: mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				return nil, fakeErr
+			},
+			ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+				return nil, fakeErr
+			},
+		},
+	}
+
+	tests := []struct {
+		desc           string
+		methodCall     func(ctx context.Context, db Provider) error
+		expectedMethod string
+		expectedTable  string
+	}{
+		{
+			desc: "should wrap errors from Insert",
+			methodCall: func(ctx context.Context, db Provider) error {
+				return db.Insert(ctx, usersTable, &User{Name: "fakeName"})
+			},
+			expectedMethod: "Insert",
+			expectedTable:  "users",
+		},
+		{
+			desc: "should wrap errors from Patch",
+			methodCall: func(ctx context.Context, db Provider) error {
+				return db.Patch(ctx, usersTable, &User{ID: 42, Name: "fakeName"})
+			},
+			expectedMethod: "Patch",
+			expectedTable:  "users",
+		},
+		{
+			desc: "should wrap errors from Delete",
+			methodCall: func(ctx context.Context, db Provider) error {
+				return db.Delete(ctx, usersTable, 42)
+			},
+			expectedMethod: "Delete",
+			expectedTable:  "users",
+		},
+		{
+			desc: "should wrap errors from Query",
+			methodCall: func(ctx context.Context, db Provider) error {
+				var users []User
+				return db.Query(ctx, &users, "FROM users")
+			},
+			expectedMethod: "Query",
+		},
+		{
+			desc: "should wrap errors from QueryOne",
+			methodCall: func(ctx context.Context, db Provider) error {
+				var user User
+				return db.QueryOne(ctx, &user, "FROM users")
+			},
+			expectedMethod: "QueryOne",
+		},
+		{
+			desc: "should wrap errors from QueryChunks",
+			methodCall: func(ctx context.Context, db Provider) error {
+				return db.QueryChunks(ctx, ChunkParser{
+					Query:     "FROM users",
+					ChunkSize: 10,
+					ForEachChunk: func(users []User) error {
+						return nil
+					},
+				})
+			},
+			expectedMethod: "QueryChunks",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			err := test.methodCall(ctx, c)
+			tt.AssertErrContains(t, err, "KSQL", test.expectedMethod, test.expectedTable, "fakeAdapterErrMsg")
+			tt.AssertEqual(t, errors.Is(err, fakeErr), true)
+
+			var opErr OpError
+			tt.AssertEqual(t, errors.As(err, &opErr), true)
+			tt.AssertEqual(t, opErr.Method, test.expectedMethod)
+			tt.AssertEqual(t, opErr.Table, test.expectedTable)
+			tt.AssertContains(t, opErr.Query, "users")
+		})
+	}
+
+	t.Run("should not wrap ErrRecordNotFound", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					return mockResult{
+						RowsAffectedFn: func() (int64, error) {
+							return 0, nil
+						},
+					}, nil
+				},
+			},
+		}
+
+		err := c.Delete(ctx, usersTable, 42)
+		tt.AssertEqual(t, err, ErrRecordNotFound)
+	})
+}
+
+func TestRawQueryOption(t *testing.T) {
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	tests := []struct {
+		desc       string
+		methodCall func(ctx context.Context, db Provider) error
+	}{
+		{
+			desc: "Query",
+			methodCall: func(ctx context.Context, db Provider) error {
+				var users []User
+				return db.Query(ctx, &users, "FROM users SELECT id, name")
+			},
+		},
+		{
+			desc: "QueryOne",
+			methodCall: func(ctx context.Context, db Provider) error {
+				var user User
+				return db.QueryOne(ctx, &user, "FROM users SELECT id, name")
+			},
+		},
+		{
+			desc: "QueryChunks",
+			methodCall: func(ctx context.Context, db Provider) error {
+				return db.QueryChunks(ctx, ChunkParser{
+					Query:     "FROM users SELECT id, name",
+					ChunkSize: 10,
+					ForEachChunk: func(users []User) error {
+						return nil
+					},
+				})
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var inputQuery string
+			c := DB{
+				dialect: sqldialect.SupportedDialects["postgres"],
+				db: mockDBAdapter{
+					QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+						inputQuery = query
+						return nil, errors.New("fakeErrMsg")
+					},
+				},
+			}
+
+			t.Run("should build the SELECT part by default", func(t *testing.T) {
+				_ = test.methodCall(context.Background(), c)
+				tt.AssertContains(t, inputQuery, `SELECT "id", "name" FROM users`)
+			})
+
+			t.Run("should send the query unchanged when RawQuery is used", func(t *testing.T) {
+				ctx := InjectOptions(context.Background(), RawQuery())
+				_ = test.methodCall(ctx, c)
+				tt.AssertEqual(t, inputQuery, "FROM users SELECT id, name")
+			})
+		})
+	}
+}
+
+func TestColumnsMarker(t *testing.T) {
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	type Post struct {
+		ID    int    `ksql:"id"`
+		Title string `ksql:"title"`
+	}
+
+	tests := []struct {
+		desc               string
+		methodCall         func(ctx context.Context, db Provider) error
+		expectQuery        string
+		expectErrToContain []string
+	}{
+		{
+			desc: "should expand the marker on Query",
+			methodCall: func(ctx context.Context, db Provider) error {
+				var users []User
+				return db.Query(ctx, &users, "WITH u AS (SELECT * FROM users) SELECT :ksql_columns FROM u")
+			},
+			expectQuery: `WITH u AS (SELECT * FROM users) SELECT "id", "name" FROM u`,
+		},
+		{
+			desc: "should expand the marker on QueryOne",
+			methodCall: func(ctx context.Context, db Provider) error {
+				var user User
+				return db.QueryOne(ctx, &user, "WITH u AS (SELECT * FROM users) SELECT :ksql_columns FROM u")
+			},
+			expectQuery: `WITH u AS (SELECT * FROM users) SELECT "id", "name" FROM u`,
+		},
+		{
+			desc: "should expand the marker on QueryChunks",
+			methodCall: func(ctx context.Context, db Provider) error {
+				return db.QueryChunks(ctx, ChunkParser{
+					Query:     "WITH u AS (SELECT * FROM users) SELECT :ksql_columns FROM u",
+					ChunkSize: 10,
+					ForEachChunk: func(users []User) error {
+						return nil
+					},
+				})
+			},
+			expectQuery: `WITH u AS (SELECT * FROM users) SELECT "id", "name" FROM u`,
+		},
+		{
+			desc: "should expand the marker for nested structs",
+			methodCall: func(ctx context.Context, db Provider) error {
+				var rows []struct {
+					User User `tablename:"u"`
+					Post Post `tablename:"p"`
+				}
+				return db.Query(ctx, &rows, "SELECT :ksql_columns FROM users u JOIN posts p ON p.user_id = u.id")
+			},
+			expectQuery: `SELECT "u"."id", "u"."name", "p"."id", "p"."title" FROM users u JOIN posts p ON p.user_id = u.id`,
+		},
+		{
+			desc: "should expand the marker with a table alias",
+			methodCall: func(ctx context.Context, db Provider) error {
+				var users []User
+				return db.Query(ctx, &users, "SELECT :ksql_columns(u) FROM users u JOIN posts p ON p.user_id = u.id")
+			},
+			expectQuery: `SELECT u."id", u."name" FROM users u JOIN posts p ON p.user_id = u.id`,
+		},
+		{
+			desc: "should expand multiple markers on the same query",
+			methodCall: func(ctx context.Context, db Provider) error {
+				var users []User
+				return db.Query(ctx, &users, "SELECT :ksql_columns( a ) FROM (SELECT :ksql_columns FROM users) a")
+			},
+			expectQuery: `SELECT a."id", a."name" FROM (SELECT "id", "name" FROM users) a`,
+		},
+		{
+			desc: "should report error when using a table alias with nested structs",
+			methodCall: func(ctx context.Context, db Provider) error {
+				var rows []struct {
+					User User `tablename:"u"`
+					Post Post `tablename:"p"`
+				}
+				return db.Query(ctx, &rows, "SELECT :ksql_columns(u) FROM users u JOIN posts p ON p.user_id = u.id")
+			},
+			expectErrToContain: []string{":ksql_columns(u)", "nested"},
+		},
+		{
+			desc: "should not expand markers with a different name",
+			methodCall: func(ctx context.Context, db Provider) error {
+				var users []User
+				return db.Query(ctx, &users, "SELECT :ksql_columnsfoo FROM users")
+			},
+			expectQuery: `SELECT :ksql_columnsfoo FROM users`,
+		},
+		{
+			desc: "should not expand the marker when using RawQuery",
+			methodCall: func(ctx context.Context, db Provider) error {
+				var users []User
+				ctx = InjectOptions(ctx, RawQuery())
+				return db.Query(ctx, &users, "SELECT :ksql_columns FROM users")
+			},
+			expectQuery: `SELECT :ksql_columns FROM users`,
+		},
+		{
+			desc: "should report error when using the marker with scalar chunks",
+			methodCall: func(ctx context.Context, db Provider) error {
+				return db.QueryChunks(ctx, ChunkParser{
+					Query:     "SELECT :ksql_columns FROM users",
+					ChunkSize: 10,
+					ForEachChunk: func(names []string) error {
+						return nil
+					},
+				})
+			},
+			expectErrToContain: []string{":ksql_columns", "string"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var inputQuery string
+			c := DB{
+				dialect: sqldialect.SupportedDialects["postgres"],
+				db: mockDBAdapter{
+					QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+						inputQuery = query
+						return mockRows{
+							NextFn:  func() bool { return false },
+							ErrFn:   func() error { return nil },
+							CloseFn: func() error { return nil },
+						}, nil
+					},
+				},
+			}
+
+			err := test.methodCall(context.Background(), c)
+			if test.expectErrToContain != nil {
+				tt.AssertErrContains(t, err, test.expectErrToContain...)
+				return
+			}
+
+			tt.AssertEqual(t, inputQuery, test.expectQuery)
+		})
+	}
+}
+
+func TestQueryChunksDeadlineHeadroom(t *testing.T) {
+	defer func() {
+		timeNow = time.Now
+	}()
+
+	type User struct {
+		ID int `ksql:"id"`
+	}
+
+	newMockDB := func(numRows int) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					return mockRows{
+						ScanFn: func(args ...interface{}) error {
+							*(args[0].(*int)) = numRows
+							return nil
+						},
+						NextFn: func() bool {
+							numRows--
+							return numRows >= 0
+						},
+						ErrFn:     func() error { return nil },
+						CloseFn:   func() error { return nil },
+						ColumnsFn: func() ([]string, error) { return []string{"id"}, nil },
+					}, nil
+				},
+			},
+		}
+	}
+
+	now := time.Now()
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(10*time.Minute))
+	defer cancel()
+
+	t.Run("should process all chunks if there is enough time left", func(t *testing.T) {
+		timeNow = func() time.Time { return now }
+
+		var lengths []int
+		err := newMockDB(5).QueryChunks(ctx, ChunkParser{
+			Query:            "FROM users",
+			ChunkSize:        2,
+			DeadlineHeadroom: 5 * time.Minute,
+			ForEachChunk: func(users []User) error {
+				lengths = append(lengths, len(users))
+				return nil
+			},
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, lengths, []int{2, 2, 1})
+	})
+
+	t.Run("should stop before the next chunk if the deadline is approaching", func(t *testing.T) {
+		currentTime := now
+		timeNow = func() time.Time { return currentTime }
+
+		var lengths []int
+		err := newMockDB(5).QueryChunks(ctx, ChunkParser{
+			Query:            "FROM users",
+			ChunkSize:        2,
+			DeadlineHeadroom: 5 * time.Minute,
+			ForEachChunk: func(users []User) error {
+				lengths = append(lengths, len(users))
+				currentTime = currentTime.Add(3 * time.Minute)
+				return nil
+			},
+		})
+		tt.AssertEqual(t, errors.Is(err, ErrDeadlineApproaching), true)
+		tt.AssertEqual(t, lengths, []int{2, 2})
+
+		var deadlineErr DeadlineApproachingError
+		tt.AssertEqual(t, errors.As(err, &deadlineErr), true)
+		tt.AssertEqual(t, deadlineErr, DeadlineApproachingError{
+			ProcessedChunks: 2,
+			ProcessedRows:   4,
+			TimeLeft:        4 * time.Minute,
+		})
+	})
+
+	t.Run("should not run the query if there is not enough time left", func(t *testing.T) {
+		timeNow = func() time.Time { return now.Add(6 * time.Minute) }
+
+		var called bool
+		err := newMockDB(5).QueryChunks(ctx, ChunkParser{
+			Query:            "FROM users",
+			ChunkSize:        2,
+			DeadlineHeadroom: 5 * time.Minute,
+			ForEachChunk: func(users []User) error {
+				called = true
+				return nil
+			},
+		})
+		tt.AssertErrContains(t, err, "deadline", "0 chunks")
+		tt.AssertEqual(t, called, false)
+	})
+
+	t.Run("should ignore the headroom if the context has no deadline", func(t *testing.T) {
+		timeNow = func() time.Time { return now }
+
+		var lengths []int
+		err := newMockDB(3).QueryChunks(context.Background(), ChunkParser{
+			Query:            "FROM users",
+			ChunkSize:        2,
+			DeadlineHeadroom: 5 * time.Minute,
+			ForEachChunk: func(users []User) error {
+				lengths = append(lengths, len(users))
+				return nil
+			},
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, lengths, []int{2, 1})
+	})
+}
+
+func TestQueryChunksLimits(t *testing.T) {
+	type User struct {
+		ID int `ksql:"id"`
+	}
+
+	newMockDB := func(numRows int) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					return mockRows{
+						ScanFn: func(args ...interface{}) error {
+							*(args[0].(*int)) = numRows
+							return nil
+						},
+						NextFn: func() bool {
+							numRows--
+							return numRows >= 0
+						},
+						ErrFn:     func() error { return nil },
+						CloseFn:   func() error { return nil },
+						ColumnsFn: func() ([]string, error) { return []string{"id"}, nil },
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should process all chunks if they fit in MaxChunks", func(t *testing.T) {
+		var lengths []int
+		err := newMockDB(6).QueryChunks(context.Background(), ChunkParser{
+			Query:     "FROM users",
+			ChunkSize: 2,
+			MaxChunks: 3,
+			ForEachChunk: func(users []User) error {
+				lengths = append(lengths, len(users))
+				return nil
+			},
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, lengths, []int{2, 2, 2})
+	})
+
+	t.Run("should stop with an error if there are more than MaxChunks chunks", func(t *testing.T) {
+		var lengths []int
+		err := newMockDB(7).QueryChunks(context.Background(), ChunkParser{
+			Query:     "FROM users",
+			ChunkSize: 2,
+			MaxChunks: 3,
+			ForEachChunk: func(users []User) error {
+				lengths = append(lengths, len(users))
+				return nil
+			},
+		})
+		tt.AssertEqual(t, errors.Is(err, ErrTooManyChunks), true)
+		tt.AssertEqual(t, lengths, []int{2, 2, 2})
+
+		var tooManyErr TooManyChunksError
+		tt.AssertEqual(t, errors.As(err, &tooManyErr), true)
+		tt.AssertEqual(t, tooManyErr, TooManyChunksError{
+			MaxChunks:     3,
+			ProcessedRows: 6,
+		})
+	})
+
+	t.Run("should pass a context with the Timeout to the adapter", func(t *testing.T) {
+		var hasDeadline bool
+		db := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					_, hasDeadline = ctx.Deadline()
+					return nil, fmt.Errorf("fakeErrMsg")
+				},
+			},
+		}
+
+		err := db.QueryChunks(context.Background(), ChunkParser{
+			Query:        "FROM users",
+			ChunkSize:    2,
+			Timeout:      time.Minute,
+			ForEachChunk: func(users []User) error { return nil },
+		})
+		tt.AssertErrContains(t, err, "fakeErrMsg")
+		tt.AssertEqual(t, hasDeadline, true)
+	})
+
+	t.Run("should stop between chunks once the Timeout expires", func(t *testing.T) {
+		var lengths []int
+		err := newMockDB(5).QueryChunks(context.Background(), ChunkParser{
+			Query:     "FROM users",
+			ChunkSize: 2,
+			Timeout:   10 * time.Millisecond,
+			ForEachChunk: func(users []User) error {
+				lengths = append(lengths, len(users))
+				time.Sleep(20 * time.Millisecond)
+				return nil
+			},
+		})
+		tt.AssertEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+		tt.AssertEqual(t, lengths, []int{2})
+	})
+}
+
+type mockServerVersioner struct {
+	mockDBAdapter
+	ServerVersionFn func(ctx context.Context) (string, error)
+}
+
+func (m mockServerVersioner) ServerVersion(ctx context.Context) (string, error) {
+	return m.ServerVersionFn(ctx)
+}
+
+func TestServerVersion(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		dialect     string
+		expectQuery string
+	}{
+		{dialect: "postgres", expectQuery: "SELECT version()"},
+		{dialect: "mysql", expectQuery: "SELECT VERSION()"},
+		{dialect: "sqlserver", expectQuery: "SELECT @@VERSION"},
+		{dialect: "sqlite3", expectQuery: "SELECT sqlite_version()"},
+	}
+	for _, test := range tests {
+		t.Run("should query the version for the "+test.dialect+" dialect", func(t *testing.T) {
+			var inputQuery string
+			numRows := 1
+			c := DB{
+				dialect: sqldialect.SupportedDialects[test.dialect],
+				db: mockDBAdapter{
+					QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+						inputQuery = query
+						return mockRows{
+							ScanFn: func(args ...interface{}) error {
+								*(args[0].(*string)) = "fakeVersion 1.2.3"
+								return nil
+							},
+							NextFn: func() bool {
+								numRows--
+								return numRows >= 0
+							},
+							CloseFn: func() error { return nil },
+						}, nil
+					},
+				},
+			}
+
+			version, err := c.ServerVersion(ctx)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, version, "fakeVersion 1.2.3")
+			tt.AssertEqual(t, inputQuery, test.expectQuery)
+		})
+	}
+
+	t.Run("should use the adapter implementation if available", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockServerVersioner{
+				ServerVersionFn: func(ctx context.Context) (string, error) {
+					return "15.3", nil
+				},
+			},
+		}
+
+		version, err := c.ServerVersion(ctx)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, version, "15.3")
+	})
+
+	t.Run("should report errors from the adapter", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					return nil, errors.New("fakeErrMsg")
+				},
+			},
+		}
+
+		_, err := c.ServerVersion(ctx)
+		tt.AssertErrContains(t, err, "ServerVersion", "fakeErrMsg")
+	})
+}
+
+func TestTableResolver(t *testing.T) {
+	ctx := context.Background()
+
+	type Event struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+	eventsTable := NewTable("events")
+
+	tests := []struct {
+		desc       string
+		methodCall func(ctx context.Context, db DB) error
+	}{
+		{
+			desc: "Insert",
+			methodCall: func(ctx context.Context, db DB) error {
+				return db.Insert(ctx, eventsTable, &Event{Name: "fakeName"})
+			},
+		},
+		{
+			desc: "Patch",
+			methodCall: func(ctx context.Context, db DB) error {
+				return db.Patch(ctx, eventsTable, &Event{ID: 42, Name: "fakeName"})
+			},
+		},
+		{
+			desc: "Delete",
+			methodCall: func(ctx context.Context, db DB) error {
+				return db.Delete(ctx, eventsTable, 42)
+			},
+		},
+		{
+			desc: "PatchDiff",
+			methodCall: func(ctx context.Context, db DB) error {
+				return db.PatchDiff(ctx, eventsTable, Event{ID: 42}, Event{ID: 42, Name: "fakeName"})
+			},
+		},
+		{
+			desc: "Upsert",
+			methodCall: func(ctx context.Context, db DB) error {
+				return db.Upsert(ctx, eventsTable, &Event{ID: 42, Name: "fakeName"})
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var inputQuery string
+			newMockDB := func(resolver func(ctx context.Context, table Table, record interface{}) (Table, error)) DB {
+				return DB{
+					dialect: sqldialect.SupportedDialects["postgres"],
+					config: Config{
+						TableResolver: resolver,
+					},
+					db: mockDBAdapter{
+						QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+							inputQuery = query
+							return mockRows{
+								ScanFn:  func(args ...interface{}) error { return nil },
+								NextFn:  func() bool { return true },
+								CloseFn: func() error { return nil },
+							}, nil
+						},
+						ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+							inputQuery = query
+							return mockResult{
+								RowsAffectedFn: func() (int64, error) { return 1, nil },
+							}, nil
+						},
+					},
+				}
+			}
+
+			t.Run("should use the resolved table", func(t *testing.T) {
+				var resolvedRecord interface{}
+				c := newMockDB(func(ctx context.Context, table Table, record interface{}) (Table, error) {
+					resolvedRecord = record
+					return table.WithName(table.Name() + "_2024_06"), nil
+				})
+
+				err := test.methodCall(ctx, c)
+				tt.AssertNoErr(t, err)
+				tt.AssertContains(t, inputQuery, "events_2024_06")
+				tt.AssertNotEqual(t, resolvedRecord, nil)
+			})
+
+			t.Run("should forward errors from the resolver", func(t *testing.T) {
+				inputQuery = ""
+				c := newMockDB(func(ctx context.Context, table Table, record interface{}) (Table, error) {
+					return Table{}, errors.New("fakeResolverErrMsg")
+				})
+
+				err := test.methodCall(ctx, c)
+				tt.AssertErrContains(t, err, "KSQL", "events", "fakeResolverErrMsg")
+				tt.AssertEqual(t, inputQuery, "")
+			})
+		})
+	}
+}
+
+func TestReuseSliceOption(t *testing.T) {
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	// newMockDB returns `ids` as the result of the query,
+	// leaving the `name` column unset:
+	newMockDB := func(ids []int) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					idx := -1
+					return mockRows{
+						ScanFn: func(args ...interface{}) error {
+							*(args[0].(*int)) = ids[idx]
+							return nil
+						},
+						NextFn: func() bool {
+							idx++
+							return idx < len(ids)
+						},
+						ErrFn:     func() error { return nil },
+						CloseFn:   func() error { return nil },
+						ColumnsFn: func() ([]string, error) { return []string{"id"}, nil },
+					}, nil
+				},
+			},
+		}
+	}
+
+	ctx := InjectOptions(context.Background(), ReuseSlice())
+
+	t.Run("should reuse the backing array of a slice of structs", func(t *testing.T) {
+		users := make([]User, 3, 10)
+		users[0].Name = "staleName"
+		backingArray := &users[:1][0]
+
+		err := newMockDB([]int{1, 2}).Query(ctx, &users, "FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, users, []User{{ID: 1}, {ID: 2}})
+		tt.AssertEqual(t, &users[0] == backingArray, true)
+
+		err = newMockDB([]int{3}).Query(ctx, &users, "FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, users, []User{{ID: 3}})
+		tt.AssertEqual(t, &users[0] == backingArray, true)
+	})
+
+	t.Run("should reuse the structs of a slice of pointers", func(t *testing.T) {
+		var users []*User
+		err := newMockDB([]int{1, 2}).Query(ctx, &users, "FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(users), 2)
+		first := users[0]
+		first.Name = "staleName"
+
+		err = newMockDB([]int{3, 4, 5}).Query(ctx, &users, "FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, users, []*User{{ID: 3}, {ID: 4}, {ID: 5}})
+		tt.AssertEqual(t, users[0] == first, true)
+	})
+
+	t.Run("should return an empty slice if no rows are returned", func(t *testing.T) {
+		users := []User{{ID: 1}, {ID: 2}}
+
+		err := newMockDB(nil).Query(ctx, &users, "FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(users), 0)
+		tt.AssertEqual(t, cap(users), 2)
+	})
+}
+
+func TestBuildQueries(t *testing.T) {
+	ctx := context.Background()
+	dialect := sqldialect.SupportedDialects["postgres"]
+
+	type User struct {
+		ID   uint   `ksql:"id"`
+		Name string `ksql:"name"`
+		Age  int    `ksql:"age"`
+	}
+	usersTable := NewTable("users")
+
+	t.Run("BuildInsertQuery", func(t *testing.T) {
+		t.Run("should return the query, params and the pointers for the IDs", func(t *testing.T) {
+			user := User{Name: "fakeName", Age: 42}
+			query, params, idPtrs, err := BuildInsertQuery(ctx, dialect, usersTable, &user)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, query, `INSERT INTO users ("name", "age") VALUES ($1, $2) RETURNING "id"`)
+			tt.AssertEqual(t, params, []interface{}{"fakeName", 42})
+			tt.AssertEqual(t, idPtrs, []interface{}{&user.ID})
+		})
+
+		t.Run("should report invalid records", func(t *testing.T) {
+			_, _, _, err := BuildInsertQuery(ctx, dialect, usersTable, User{})
+			tt.AssertErrContains(t, err, "KSQL", "pointer to struct")
+
+			var nilUser *User
+			_, _, _, err = BuildInsertQuery(ctx, dialect, usersTable, nilUser)
+			tt.AssertErrContains(t, err, "KSQL", "nil pointer")
+		})
+	})
+
+	t.Run("BuildPatchQuery", func(t *testing.T) {
+		t.Run("should return the query and params", func(t *testing.T) {
+			query, params, err := BuildPatchQuery(ctx, dialect, usersTable, User{ID: 1, Name: "fakeName", Age: 42})
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, query, `UPDATE users SET "name" = $1, "age" = $2 WHERE "id" = $3`)
+			tt.AssertEqual(t, params, []interface{}{"fakeName", 42, uint(1)})
+		})
+
+		t.Run("should report missing IDs", func(t *testing.T) {
+			_, _, err := BuildPatchQuery(ctx, dialect, usersTable, User{Name: "fakeName"})
+			tt.AssertEqual(t, errors.Is(err, ErrRecordMissingIDs), true)
+		})
+	})
+}
+
+func TestScanRow(t *testing.T) {
+	ctx := context.Background()
+	dialect := sqldialect.SupportedDialects["postgres"]
+
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+		Age  int    `ksql:"age"`
+	}
+
+	t.Run("should scan the columns by name", func(t *testing.T) {
+		rows := mockRows{
+			ColumnsFn: func() ([]string, error) {
+				return []string{"name", "id", "unknown_column"}, nil
+			},
+			ScanFn: func(values ...interface{}) error {
+				tt.AssertEqual(t, len(values), 3)
+				*values[0].(*string) = "fakeName"
+				*values[1].(*int) = 42
+				return nil
+			},
+		}
+
+		var user User
+		err := ScanRow(ctx, dialect, rows, &user)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, user, User{ID: 42, Name: "fakeName"})
+	})
+
+	t.Run("should apply the modifiers of the attributes", func(t *testing.T) {
+		type UserWithAddress struct {
+			ID      int `ksql:"id"`
+			Address struct {
+				City string `json:"city"`
+			} `ksql:"address,json"`
+		}
+
+		rows := mockRows{
+			ColumnsFn: func() ([]string, error) {
+				return []string{"id", "address"}, nil
+			},
+			ScanFn: func(values ...interface{}) error {
+				*values[0].(*int) = 42
+				return values[1].(sql.Scanner).Scan([]byte(`{"city":"fakeCity"}`))
+			},
+		}
+
+		var user UserWithAddress
+		err := ScanRow(ctx, dialect, rows, &user)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, user.ID, 42)
+		tt.AssertEqual(t, user.Address.City, "fakeCity")
+	})
+
+	t.Run("should work with the dialect of a DB instance", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, dialect)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, db.Dialect(), dialect)
+	})
+
+	t.Run("should apply the Config of the DB when called as a method", func(t *testing.T) {
+		type UserWithTime struct {
+			Name      string    `ksql:"name"`
+			CreatedAt time.Time `ksql:"created_at"`
+		}
+
+		location := time.FixedZone("fakeZone", 3*60*60)
+		db, err := NewWithAdapter(mockDBAdapter{}, dialect, Config{
+			TimeLocation:        location,
+			NormalizeColumnName: strings.ToLower,
+		})
+		tt.AssertNoErr(t, err)
+
+		createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		rows := mockRows{
+			ColumnsFn: func() ([]string, error) {
+				return []string{"NAME", "CREATED_AT"}, nil
+			},
+			ScanFn: func(values ...interface{}) error {
+				*values[0].(*string) = "fakeName"
+				*values[1].(*time.Time) = createdAt
+				return nil
+			},
+		}
+
+		var user UserWithTime
+		err = db.ScanRow(ctx, rows, &user)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, user.Name, "fakeName")
+		tt.AssertEqual(t, user.CreatedAt.Equal(createdAt), true)
+		tt.AssertEqual(t, user.CreatedAt.Location(), location)
+	})
+
+	t.Run("should report scan errors with the attribute names", func(t *testing.T) {
+		rows := mockRows{
+			ColumnsFn: func() ([]string, error) {
+				return []string{"id", "age"}, nil
+			},
+			ScanFn: func(values ...interface{}) error {
+				return ScanArgError{
+					ColumnIndex: 1,
+					Err:         errors.New("fakeScanErr"),
+				}
+			},
+		}
+
+		var user User
+		err := ScanRow(ctx, dialect, rows, &user)
+		tt.AssertErrContains(t, err, "KSQL", "User.Age", "fakeScanErr")
+	})
+
+	t.Run("should report error if the record is not a pointer to struct", func(t *testing.T) {
+		var user User
+		err := ScanRow(ctx, dialect, mockRows{}, user)
+		tt.AssertErrContains(t, err, "KSQL", "pointer to struct")
+	})
+}
+
+func TestDialectOf(t *testing.T) {
+	dialect := sqldialect.SupportedDialects["postgres"]
+
+	t.Run("should return the dialect of DB instances", func(t *testing.T) {
+		db, err := NewWithAdapter(mockDBAdapter{}, dialect)
+		tt.AssertNoErr(t, err)
+
+		d, ok := DialectOf(db)
+		tt.AssertEqual(t, ok, true)
+		tt.AssertEqual(t, d, dialect)
+	})
+
+	t.Run("should return the dialect of DB instances inside transactions", func(t *testing.T) {
+		db, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					CommitFn: func(ctx context.Context) error {
+						return nil
+					},
+				}, nil
+			},
+		}, dialect)
+		tt.AssertNoErr(t, err)
+
+		var d sqldialect.Provider
+		var ok bool
+		err = db.Transaction(context.Background(), func(tx Provider) error {
+			d, ok = DialectOf(tx)
+			return nil
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, ok, true)
+		tt.AssertEqual(t, d, dialect)
+	})
+
+	t.Run("should use the DialectFn of mocks", func(t *testing.T) {
+		_, ok := DialectOf(Mock{})
+		tt.AssertEqual(t, ok, false)
+
+		d, ok := DialectOf(Mock{
+			DialectFn: func() sqldialect.Provider {
+				return dialect
+			},
+		})
+		tt.AssertEqual(t, ok, true)
+		tt.AssertEqual(t, d, dialect)
+
+		db, err := NewWithAdapter(mockDBAdapter{}, dialect)
+		tt.AssertNoErr(t, err)
+
+		d, ok = DialectOf(Mock{}.SetFallbackDatabase(db))
+		tt.AssertEqual(t, ok, true)
+		tt.AssertEqual(t, d, dialect)
+	})
+}
+
+func TestQualifiedColumnsOption(t *testing.T) {
+	ctx := context.Background()
+
+	type UserPost struct {
+		UserID int    `ksql:"u.id"`
+		PostID int    `ksql:"p.id"`
+		Title  string `ksql:"title"`
+	}
+
+	type FlatUserPost struct {
+		ID    int    `ksql:"id"`
+		Title string `ksql:"title"`
+	}
+
+	newMockDB := func(query *string, columns []string, values []interface{}) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, q string, params ...interface{}) (Rows, error) {
+					*query = q
+					hasNext := true
+					return mockRows{
+						ColumnsFn: func() ([]string, error) {
+							return columns, nil
+						},
+						NextFn: func() bool {
+							next := hasNext
+							hasNext = false
+							return next
+						},
+						ScanFn: func(args ...interface{}) error {
+							for i, arg := range args {
+								if arg == nopScannerValue {
+									continue
+								}
+								reflect.ValueOf(arg).Elem().Set(reflect.ValueOf(values[i]))
+							}
+							return nil
+						},
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should report error if two columns are mapped to the same attribute", func(t *testing.T) {
+		var query string
+		c := newMockDB(&query, []string{"id", "id", "title"}, []interface{}{1, 2, "fakeTitle"})
+
+		var userPosts []FlatUserPost
+		err := c.Query(ctx, &userPosts, "SELECT u.id, p.id, p.title FROM users u JOIN posts p ON p.user_id = u.id")
+		tt.AssertErrContains(t, err, "KSQL", "`id`", "ID", "QualifiedColumns")
+	})
+
+	t.Run("should alias the qualified columns and map them by their qualified names", func(t *testing.T) {
+		var query string
+		c := newMockDB(&query, []string{"u.id", "p.id", "p.title"}, []interface{}{1, 2, "fakeTitle"})
+
+		var userPosts []UserPost
+		err := c.Query(
+			InjectOptions(ctx, QualifiedColumns()),
+			&userPosts,
+			"SELECT u.id, p.id, p.title FROM users u JOIN posts p ON p.user_id = u.id",
+		)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `SELECT u.id AS "u.id", p.id AS "p.id", p.title AS "p.title" FROM users u JOIN posts p ON p.user_id = u.id`)
+		tt.AssertEqual(t, userPosts, []UserPost{{UserID: 1, PostID: 2, Title: "fakeTitle"}})
+	})
+
+	t.Run("should still report duplicated columns if there are no qualified tags", func(t *testing.T) {
+		var query string
+		c := newMockDB(&query, []string{"u.id", "p.id", "p.title"}, []interface{}{1, 2, "fakeTitle"})
+
+		var userPost FlatUserPost
+		err := c.QueryOne(
+			InjectOptions(ctx, QualifiedColumns()),
+			&userPost,
+			"SELECT u.id, p.id, p.title FROM users u JOIN posts p ON p.user_id = u.id",
+		)
+		tt.AssertErrContains(t, err, "KSQL", "`u.id`", "`p.id`", "ID")
+	})
+
+	t.Run("should not change queries without the option", func(t *testing.T) {
+		var query string
+		c := newMockDB(&query, []string{"id", "title"}, []interface{}{1, "fakeTitle"})
+
+		var userPosts []FlatUserPost
+		err := c.Query(ctx, &userPosts, "SELECT p.id, p.title FROM posts p")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, "SELECT p.id, p.title FROM posts p")
+		tt.AssertEqual(t, userPosts, []FlatUserPost{{ID: 1, Title: "fakeTitle"}})
+	})
+}
+
+func TestQualifiedSelectOption(t *testing.T) {
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	usersTable := NewTable("users")
+
+	tests := []struct {
+		desc       string
+		methodCall func(ctx context.Context, db Provider) error
+	}{
+		{
+			desc: "Query",
+			methodCall: func(ctx context.Context, db Provider) error {
+				var users []User
+				return db.Query(ctx, &users, "FROM users u JOIN posts p ON p.user_id = u.id")
+			},
+		},
+		{
+			desc: "QueryOne",
+			methodCall: func(ctx context.Context, db Provider) error {
+				var user User
+				return db.QueryOne(ctx, &user, "FROM users u JOIN posts p ON p.user_id = u.id")
+			},
+		},
+		{
+			desc: "QueryChunks",
+			methodCall: func(ctx context.Context, db Provider) error {
+				return db.QueryChunks(ctx, ChunkParser{
+					Query:     "FROM users u JOIN posts p ON p.user_id = u.id",
+					ChunkSize: 10,
+					ForEachChunk: func(users []User) error {
+						return nil
+					},
+				})
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var inputQuery string
+			c := DB{
+				dialect: sqldialect.SupportedDialects["postgres"],
+				db: mockDBAdapter{
+					QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+						inputQuery = query
+						return nil, errors.New("fakeErrMsg")
+					},
+				},
+			}
+
+			t.Run("should qualify the columns with the table alias", func(t *testing.T) {
+				ctx := InjectOptions(context.Background(), QualifiedSelect(usersTable.WithAlias("u")))
+				_ = test.methodCall(ctx, c)
+				tt.AssertEqual(t, inputQuery, `SELECT u."id", u."name" FROM users u JOIN posts p ON p.user_id = u.id`)
+			})
+
+			t.Run("should qualify the columns with the table name if there is no alias", func(t *testing.T) {
+				ctx := InjectOptions(context.Background(), QualifiedSelect(usersTable))
+				_ = test.methodCall(ctx, c)
+				tt.AssertEqual(t, inputQuery, `SELECT users."id", users."name" FROM users u JOIN posts p ON p.user_id = u.id`)
+			})
+
+			t.Run("should not qualify the columns without the option", func(t *testing.T) {
+				_ = test.methodCall(context.Background(), c)
+				tt.AssertEqual(t, inputQuery, `SELECT "id", "name" FROM users u JOIN posts p ON p.user_id = u.id`)
+			})
+		})
+	}
+
+	t.Run("should qualify the nested structs with the alias of their tables", func(t *testing.T) {
+		type Post struct {
+			ID    int    `ksql:"id"`
+			Title string `ksql:"title"`
+		}
+
+		var inputQuery string
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					inputQuery = query
+					return nil, errors.New("fakeErrMsg")
+				},
+			},
+		}
+
+		var rows []struct {
+			User     User `tablename:"users"`
+			Post     Post `tablename:"posts"`
+			Reviewer User `tablename:"reviewers"`
+		}
+		ctx := InjectOptions(context.Background(), QualifiedSelect(usersTable.WithAlias("u"), NewTable("posts").WithAlias("p")))
+		_ = c.Query(ctx, &rows, "FROM users u JOIN posts p ON p.user_id = u.id JOIN reviewers ON reviewers.id = p.reviewer_id")
+		tt.AssertEqual(t, inputQuery, `SELECT u."id", u."name", p."id", p."title", "reviewers"."id", "reviewers"."name"`+
+			` FROM users u JOIN posts p ON p.user_id = u.id JOIN reviewers ON reviewers.id = p.reviewer_id`)
+	})
+
+	t.Run("should report tables that don't match any nested struct", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db:      mockDBAdapter{},
+		}
+
+		var rows []struct {
+			User User `tablename:"u"`
+		}
+		ctx := InjectOptions(context.Background(), QualifiedSelect(usersTable))
+		err := c.Query(ctx, &rows, "FROM users u")
+		tt.AssertErrContains(t, err, "KSQL", "QualifiedSelect", "users", "tablename")
+	})
+
+	t.Run("should report multiple tables for structs that are not nested", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db:      mockDBAdapter{},
+		}
+
+		var users []User
+		ctx := InjectOptions(context.Background(), QualifiedSelect(usersTable, NewTable("posts")))
+		err := c.Query(ctx, &users, "FROM users JOIN posts ON posts.user_id = users.id")
+		tt.AssertErrContains(t, err, "KSQL", "QualifiedSelect", "nested structs", "2 tables")
+	})
+}
+
+func TestAliasOption(t *testing.T) {
+	type Address struct {
+		ID   int    `ksql:"id"`
+		Line string `ksql:"address_line"`
+	}
+
+	newMockDB := func(query *string, columns []string) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, q string, params ...interface{}) (Rows, error) {
+					*query = q
+					hasNext := true
+					return mockRows{
+						NextFn: func() bool {
+							next := hasNext
+							hasNext = false
+							return next
+						},
+						ColumnsFn: func() ([]string, error) {
+							return columns, nil
+						},
+						ScanFn: func(args ...interface{}) error {
+							for i, arg := range args {
+								if ptr, ok := arg.(*string); ok {
+									*ptr = "fakeValue" + columns[i]
+								}
+							}
+							return nil
+						},
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should map the aliased columns to the tagged attributes", func(t *testing.T) {
+		var query string
+		c := newMockDB(&query, []string{"id", "full_addr"})
+
+		ctx := InjectOptions(context.Background(), Alias(map[string]string{"full_addr": "address_line"}))
+
+		var address Address
+		err := c.QueryOne(ctx, &address, "SELECT id, street || city AS full_addr FROM addresses")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, address.Line, "fakeValuefull_addr")
+
+		var addresses []Address
+		err = c.Query(ctx, &addresses, "SELECT id, street || city AS full_addr FROM addresses")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(addresses), 1)
+		tt.AssertEqual(t, addresses[0].Line, "fakeValuefull_addr")
+	})
+
+	t.Run("should use the aliased column names on the generated SELECT", func(t *testing.T) {
+		var query string
+		c := newMockDB(&query, []string{"id", "full_addr"})
+
+		ctx := InjectOptions(context.Background(), Alias(map[string]string{"full_addr": "address_line"}))
+
+		var address Address
+		err := c.QueryOne(ctx, &address, "FROM addresses")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `SELECT "id", "full_addr" FROM addresses`)
+		tt.AssertEqual(t, address.Line, "fakeValuefull_addr")
+	})
+
+	t.Run("should still map the columns matching the tags directly", func(t *testing.T) {
+		var query string
+		c := newMockDB(&query, []string{"id", "address_line"})
+
+		ctx := InjectOptions(context.Background(), Alias(map[string]string{"full_addr": "address_line"}))
+
+		var address Address
+		err := c.QueryOne(ctx, &address, "SELECT id, address_line FROM addresses")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, address.Line, "fakeValueaddress_line")
+	})
+
+	t.Run("should merge the maps when the option is used more than once", func(t *testing.T) {
+		ctx := InjectOptions(context.Background(),
+			Alias(map[string]string{"full_addr": "address_line"}),
+			Alias(map[string]string{"addr_id": "id"}),
+		)
+		tt.AssertEqual(t, getCallOptions(ctx).columnAliases, map[string]string{
+			"full_addr": "address_line",
+			"addr_id":   "id",
+		})
+	})
+
+	t.Run("should report error for nested structs on queries starting with FROM", func(t *testing.T) {
+		var query string
+		c := newMockDB(&query, nil)
+
+		var rows []struct {
+			Address Address `tablename:"a"`
+		}
+		ctx := InjectOptions(context.Background(), Alias(map[string]string{"full_addr": "address_line"}))
+		err := c.Query(ctx, &rows, "FROM addresses a")
+		tt.AssertErrContains(t, err, "KSQL", "Alias", "nested structs")
+		tt.AssertEqual(t, query, "")
+	})
+}
+
+func TestExprTag(t *testing.T) {
+	type User struct {
+		ID        int    `ksql:"id"`
+		Name      string `ksql:"name"`
+		LowerName string `ksql:"lower_name" ksqlexpr:"lower(name)"`
+	}
+
+	newMockDB := func(queries *[]string) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, q string, params ...interface{}) (Rows, error) {
+					*queries = append(*queries, q)
+					hasNext := true
+					return mockRows{
+						NextFn: func() bool {
+							next := hasNext
+							hasNext = false
+							return next
+						},
+						ColumnsFn: func() ([]string, error) {
+							return []string{"id", "name", "lower_name"}, nil
+						},
+						ScanFn: func(args ...interface{}) error {
+							*(args[2].(*string)) = "fakeLowerName"
+							return nil
+						},
+					}, nil
+				},
+				ExecContextFn: func(ctx context.Context, q string, params ...interface{}) (Result, error) {
+					*queries = append(*queries, q)
+					return mockResult{
+						RowsAffectedFn: func() (int64, error) { return 1, nil },
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should alias the expression to the column on the generated SELECT", func(t *testing.T) {
+		var queries []string
+		c := newMockDB(&queries)
+
+		var user User
+		err := c.QueryOne(context.Background(), &user, "FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{`SELECT "id", "name", lower(name) AS "lower_name" FROM users`})
+		tt.AssertEqual(t, user.LowerName, "fakeLowerName")
+	})
+
+	t.Run("should not qualify the expression when using QualifiedSelect", func(t *testing.T) {
+		var queries []string
+		c := newMockDB(&queries)
+
+		var users []User
+		ctx := InjectOptions(context.Background(), QualifiedSelect(NewTable("users").WithAlias("u")))
+		err := c.Query(ctx, &users, "FROM users u")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{`SELECT u."id", u."name", lower(name) AS "lower_name" FROM users u`})
+	})
+
+	t.Run("should use the expression for nested structs", func(t *testing.T) {
+		var queries []string
+		c := newMockDB(&queries)
+
+		var rows []struct {
+			User User `tablename:"u"`
+		}
+		err := c.Query(context.Background(), &rows, "FROM users u")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{`SELECT "u"."id", "u"."name", lower(name) AS "lower_name" FROM users u`})
+	})
+
+	t.Run("should ignore the attribute when writing the record", func(t *testing.T) {
+		var queries []string
+		c := newMockDB(&queries)
+
+		err := c.Patch(context.Background(), NewTable("users"), &User{
+			ID:        1,
+			Name:      "fakeName",
+			LowerName: "fakeLowerName",
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(queries), 1)
+		tt.AssertEqual(t, strings.Contains(queries[0], "lower_name"), false)
+	})
+}
+
+func TestInsertWithReselectBy(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID    string `ksql:"id"`
+		Email string `ksql:"email"`
+		Name  string `ksql:"name"`
+	}
+	usersTable := NewTable("users").WithReselectBy("email")
+
+	newMockDB := func(dialect string, queries *[]string, selectParams *[]interface{}) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects[dialect],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					*queries = append(*queries, query)
+					return mockResult{
+						LastInsertIdFn: func() (int64, error) {
+							return 0, nil
+						},
+					}, nil
+				},
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					*queries = append(*queries, query)
+					*selectParams = params
+					hasNext := true
+					return mockRows{
+						NextFn: func() bool {
+							next := hasNext
+							hasNext = false
+							return next
+						},
+						ScanFn: func(args ...interface{}) error {
+							*args[0].(*string) = "fakeUUID"
+							return nil
+						},
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should reselect the generated IDs by the unique columns", func(t *testing.T) {
+		var queries []string
+		var selectParams []interface{}
+		c := newMockDB("mysql", &queries, &selectParams)
+
+		u := User{Email: "fake@email.com", Name: "fakeName"}
+		err := c.Insert(ctx, usersTable, &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u.ID, "fakeUUID")
+		tt.AssertEqual(t, queries, []string{
+			"INSERT INTO users (`email`, `name`) VALUES (?, ?)",
+			"SELECT `id` FROM users WHERE `email` = ?",
+		})
+		tt.AssertEqual(t, selectParams, []interface{}{"fake@email.com"})
+	})
+
+	t.Run("should use RETURNING on dialects that support it", func(t *testing.T) {
+		var queries []string
+		var selectParams []interface{}
+		c := newMockDB("postgres", &queries, &selectParams)
+
+		u := User{Email: "fake@email.com", Name: "fakeName"}
+		err := c.Insert(ctx, usersTable, &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u.ID, "fakeUUID")
+		tt.AssertEqual(t, queries, []string{
+			`INSERT INTO users ("email", "name") VALUES ($1, $2) RETURNING "id"`,
+		})
+	})
+
+	t.Run("should report error if the unique column is not set", func(t *testing.T) {
+		var queries []string
+		var selectParams []interface{}
+		c := newMockDB("mysql", &queries, &selectParams)
+
+		u := User{Name: "fakeName"}
+		err := c.Insert(ctx, usersTable, &u)
+		tt.AssertErrContains(t, err, "KSQL", "email", "must be set")
+		tt.AssertEqual(t, len(queries), 0)
+	})
+
+	t.Run("should report error if the unique column is not an attribute", func(t *testing.T) {
+		var queries []string
+		var selectParams []interface{}
+		c := newMockDB("sqlite3", &queries, &selectParams)
+
+		u := User{Email: "fake@email.com", Name: "fakeName"}
+		err := c.Insert(ctx, usersTable.WithReselectBy("username"), &u)
+		tt.AssertErrContains(t, err, "KSQL", "username", "not an attribute")
+		tt.AssertEqual(t, len(queries), 0)
+	})
+}
+
+func TestInsertWithSQLServerIDRetrieval(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	newMockDB := func(queries *[]string, params *[][]interface{}) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects["sqlserver"],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, p ...interface{}) (Result, error) {
+					*queries = append(*queries, query)
+					*params = append(*params, p)
+					return mockResult{}, nil
+				},
+				QueryContextFn: func(ctx context.Context, query string, p ...interface{}) (Rows, error) {
+					*queries = append(*queries, query)
+					*params = append(*params, p)
+					hasNext := true
+					return mockRows{
+						NextFn: func() bool {
+							next := hasNext
+							hasNext = false
+							return next
+						},
+						ScanFn: func(args ...interface{}) error {
+							*args[0].(*int) = 42
+							return nil
+						},
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should retrieve the ID with SCOPE_IDENTITY() instead of OUTPUT", func(t *testing.T) {
+		var queries []string
+		var params [][]interface{}
+		c := newMockDB(&queries, &params)
+
+		u := User{Name: "fakeName"}
+		err := c.Insert(ctx, NewTable("users").WithScopeIdentity(), &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u.ID, 42)
+		tt.AssertEqual(t, queries, []string{
+			"INSERT INTO users ([name]) VALUES (@p1); SELECT CAST(SCOPE_IDENTITY() AS BIGINT)",
+		})
+	})
+
+	t.Run("should fetch the ID from the sequence before inserting", func(t *testing.T) {
+		var queries []string
+		var params [][]interface{}
+		c := newMockDB(&queries, &params)
+
+		u := User{Name: "fakeName"}
+		err := c.Insert(ctx, NewTable("users").WithSequence("dbo.users_seq"), &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u.ID, 42)
+		tt.AssertEqual(t, queries, []string{
+			"SELECT NEXT VALUE FOR dbo.users_seq",
+			"INSERT INTO users ([id], [name]) VALUES (@p1, @p2)",
+		})
+		tt.AssertEqual(t, params[1], []interface{}{42, "fakeName"})
+	})
+
+	t.Run("should not use the sequence if the ID is already set", func(t *testing.T) {
+		var queries []string
+		var params [][]interface{}
+		c := newMockDB(&queries, &params)
+
+		u := User{ID: 7, Name: "fakeName"}
+		err := c.Insert(ctx, NewTable("users").WithSequence("dbo.users_seq"), &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u.ID, 7)
+		tt.AssertEqual(t, queries, []string{
+			"INSERT INTO users ([id], [name]) VALUES (@p1, @p2)",
+		})
+	})
+
+	t.Run("should report error for invalid configurations", func(t *testing.T) {
+		tests := []struct {
+			desc           string
+			table          Table
+			expectErrToHas []string
+		}{
+			{
+				desc:           "composite keys",
+				table:          NewTable("users", "id", "name").WithScopeIdentity(),
+				expectErrToHas: []string{"single ID column"},
+			},
+			{
+				desc:           "both options together",
+				table:          NewTable("users").WithScopeIdentity().WithSequence("users_seq"),
+				expectErrToHas: []string{"can't be used together"},
+			},
+			{
+				desc:           "client generated keys",
+				table:          NewTable("users").WithKeyGeneration(ClientGeneratedKeys).WithSequence("users_seq"),
+				expectErrToHas: []string{"client generated keys"},
+			},
+			{
+				desc:           "auto increment keys with sequences",
+				table:          NewTable("users").WithKeyGeneration(AutoIncrementKeys).WithSequence("users_seq"),
+				expectErrToHas: []string{"auto increment keys"},
+			},
+			{
+				desc:           "invalid sequence name",
+				table:          NewTable("users").WithSequence("users_seq; DROP TABLE users"),
+				expectErrToHas: []string{"invalid sequence name"},
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				var queries []string
+				var params [][]interface{}
+				c := newMockDB(&queries, &params)
+
+				err := c.Insert(ctx, test.table, &User{Name: "fakeName"})
+				tt.AssertErrContains(t, err, test.expectErrToHas...)
+				tt.AssertEqual(t, len(queries), 0)
+			})
+		}
+	})
+}
+
+func TestInsertWithKeyGeneration(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	tests := []struct {
+		desc           string
+		dialect        string
+		keyGeneration  KeyGeneration
+		user           User
+		expectQuery    string
+		expectParams   []interface{}
+		expectNumIDPtr int
+	}{
+		{
+			desc:           "should omit zero IDs by default",
+			dialect:        "postgres",
+			keyGeneration:  DetectKeyGeneration,
+			user:           User{Name: "fakeName"},
+			expectQuery:    `INSERT INTO users ("name") VALUES ($1) RETURNING "id"`,
+			expectParams:   []interface{}{"fakeName"},
+			expectNumIDPtr: 1,
+		},
+		{
+			desc:           "should insert non-zero IDs by default without retrieving them",
+			dialect:        "postgres",
+			keyGeneration:  DetectKeyGeneration,
+			user:           User{ID: 42, Name: "fakeName"},
+			expectQuery:    `INSERT INTO users ("id", "name") VALUES ($1, $2)`,
+			expectParams:   []interface{}{42, "fakeName"},
+			expectNumIDPtr: 0,
+		},
+		{
+			desc:           "should always omit auto increment IDs",
+			dialect:        "postgres",
+			keyGeneration:  AutoIncrementKeys,
+			user:           User{ID: 42, Name: "fakeName"},
+			expectQuery:    `INSERT INTO users ("name") VALUES ($1) RETURNING "id"`,
+			expectParams:   []interface{}{"fakeName"},
+			expectNumIDPtr: 1,
+		},
+		{
+			desc:           "should insert zero client generated IDs without retrieving them",
+			dialect:        "postgres",
+			keyGeneration:  ClientGeneratedKeys,
+			user:           User{ID: 0, Name: "fakeName"},
+			expectQuery:    `INSERT INTO users ("id", "name") VALUES ($1, $2)`,
+			expectParams:   []interface{}{0, "fakeName"},
+			expectNumIDPtr: 0,
+		},
+		{
+			desc:           "should insert zero client generated IDs on sqlserver",
+			dialect:        "sqlserver",
+			keyGeneration:  ClientGeneratedKeys,
+			user:           User{ID: 0, Name: "fakeName"},
+			expectQuery:    `INSERT INTO users ([id], [name]) VALUES (@p1, @p2)`,
+			expectParams:   []interface{}{0, "fakeName"},
+			expectNumIDPtr: 0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			table := NewTable("users").WithKeyGeneration(test.keyGeneration)
+			query, params, idPtrs, err := BuildInsertQuery(ctx, sqldialect.SupportedDialects[test.dialect], table, &test.user)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, query, test.expectQuery)
+			tt.AssertEqual(t, params, test.expectParams)
+			tt.AssertEqual(t, len(idPtrs), test.expectNumIDPtr)
+		})
+	}
+
+	t.Run("should not retrieve client generated IDs on mysql", func(t *testing.T) {
+		var queries []string
+		c := DB{
+			dialect: sqldialect.SupportedDialects["mysql"],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					queries = append(queries, query)
+					return mockResult{
+						LastInsertIdFn: func() (int64, error) {
+							return 0, fmt.Errorf("LastInsertId should not be called")
+						},
+					}, nil
+				},
+			},
+		}
+
+		u := User{ID: 0, Name: "fakeName"}
+		err := c.Insert(ctx, NewTable("users").WithKeyGeneration(ClientGeneratedKeys), &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{"INSERT INTO users (`id`, `name`) VALUES (?, ?)"})
+	})
+
+	t.Run("should report invalid configurations", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["mysql"],
+		}
+
+		err := c.Insert(ctx, NewTable("users").WithKeyGeneration(KeyGeneration(42)), &User{Name: "fakeName"})
+		tt.AssertErrContains(t, err, "invalid key generation")
+
+		err = c.Insert(ctx, NewTable("users").WithKeyGeneration(ClientGeneratedKeys).WithReselectBy("name"), &User{Name: "fakeName"})
+		tt.AssertErrContains(t, err, "reselect", "client generated keys")
+	})
+}
+
+func TestMaxQueryRows(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID int `ksql:"id"`
+	}
+
+	newMockDB := func(config Config, numRows int) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			config:  config,
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					idx := 0
+					return &mockRows{
+						NextFn: func() bool {
+							idx++
+							return idx <= numRows
+						},
+						ColumnsFn: func() ([]string, error) {
+							return []string{"id"}, nil
+						},
+						ScanFn: func(args ...interface{}) error {
+							*args[0].(*int) = idx
+							return nil
+						},
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should not limit the number of rows by default", func(t *testing.T) {
+		c := newMockDB(Config{}, 3)
+
+		var users []User
+		err := c.Query(ctx, &users, "FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, users, []User{{ID: 1}, {ID: 2}, {ID: 3}})
+	})
+
+	t.Run("should accept results with exactly the max number of rows", func(t *testing.T) {
+		c := newMockDB(Config{MaxQueryRows: 3}, 3)
+
+		var users []User
+		err := c.Query(ctx, &users, "FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(users), 3)
+	})
+
+	t.Run("should return TooManyRowsError when the limit is exceeded", func(t *testing.T) {
+		c := newMockDB(Config{MaxQueryRows: 2}, 3)
+
+		var users []User
+		err := c.Query(ctx, &users, "FROM users")
+		tt.AssertEqual(t, errors.Is(err, ErrTooManyRows), true)
+
+		var tooManyRowsErr TooManyRowsError
+		tt.AssertEqual(t, errors.As(err, &tooManyRowsErr), true)
+		tt.AssertEqual(t, tooManyRowsErr.MaxRows, 2)
+		tt.AssertErrContains(t, err, "QueryChunks", "2 rows")
+
+		// The input slice should not be partially filled:
+		tt.AssertEqual(t, len(users), 0)
+	})
+
+	t.Run("should allow overriding the limit with the MaxRows option", func(t *testing.T) {
+		c := newMockDB(Config{MaxQueryRows: 2}, 3)
+
+		var users []User
+		err := c.Query(InjectOptions(ctx, MaxRows(0)), &users, "FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(users), 3)
+
+		c = newMockDB(Config{}, 3)
+		err = c.Query(InjectOptions(ctx, MaxRows(1)), &users, "FROM users")
+		tt.AssertEqual(t, errors.Is(err, ErrTooManyRows), true)
+	})
+}
+
+func TestInsertWithOmitEmptyModifier(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID     int     `ksql:"id"`
+		Name   string  `ksql:"name"`
+		Status string  `ksql:"status,omitempty"`
+		Quota  *int    `ksql:"quota,omitempty"`
+		Score  float64 `ksql:"score,omitempty"`
+	}
+	usersTable := NewTable("users")
+
+	zero := 0
+	tests := []struct {
+		desc         string
+		user         User
+		expectQuery  string
+		expectParams []interface{}
+	}{
+		{
+			desc:         "should omit zero values",
+			user:         User{Name: "fakeName"},
+			expectQuery:  `INSERT INTO users ("name") VALUES ($1) RETURNING "id"`,
+			expectParams: []interface{}{"fakeName"},
+		},
+		{
+			desc:         "should insert non-zero values",
+			user:         User{Name: "fakeName", Status: "active", Score: 4.2},
+			expectQuery:  `INSERT INTO users ("name", "status", "score") VALUES ($1, $2, $3) RETURNING "id"`,
+			expectParams: []interface{}{"fakeName", "active", 4.2},
+		},
+		{
+			desc:         "should insert non-nil pointers to zero values",
+			user:         User{Name: "fakeName", Quota: &zero},
+			expectQuery:  `INSERT INTO users ("name", "quota") VALUES ($1, $2) RETURNING "id"`,
+			expectParams: []interface{}{"fakeName", 0},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			query, params, _, err := BuildInsertQuery(ctx, sqldialect.PostgresDialect{}, usersTable, &test.user)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, query, test.expectQuery)
+			tt.AssertEqual(t, params, test.expectParams)
+		})
+	}
+
+	t.Run("should have no effect on updates", func(t *testing.T) {
+		query, params, err := BuildPatchQuery(ctx, sqldialect.PostgresDialect{}, usersTable, &User{ID: 42, Name: "fakeName"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `UPDATE users SET "name" = $1, "status" = $2, "score" = $3 WHERE "id" = $4`)
+		tt.AssertEqual(t, params, []interface{}{"fakeName", "", 0.0, 42})
+	})
+}
+
+func TestInsertOnJoinTables(t *testing.T) {
+	ctx := context.Background()
+
+	type TeamMember struct {
+		UserID int `ksql:"user_id"`
+		TeamID int `ksql:"team_id"`
+	}
+	teamMembersTable := NewTable("team_members", "user_id", "team_id")
+
+	tests := []struct {
+		dialect     string
+		expectQuery string
+	}{
+		{
+			dialect:     "postgres",
+			expectQuery: `INSERT INTO team_members ("user_id", "team_id") VALUES ($1, $2)`,
+		},
+		{
+			dialect:     "sqlite3",
+			expectQuery: "INSERT INTO team_members (`user_id`, `team_id`) VALUES (?, ?)",
+		},
+		{
+			dialect:     "mysql",
+			expectQuery: "INSERT INTO team_members (`user_id`, `team_id`) VALUES (?, ?)",
+		},
+		{
+			dialect:     "sqlserver",
+			expectQuery: `INSERT INTO team_members ([user_id], [team_id]) VALUES (@p1, @p2)`,
+		},
+	}
+	for _, test := range tests {
+		t.Run("should not retrieve the IDs on "+test.dialect, func(t *testing.T) {
+			var queries []string
+			c := DB{
+				dialect: sqldialect.SupportedDialects[test.dialect],
+				db: mockDBAdapter{
+					ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+						queries = append(queries, query)
+						return mockResult{
+							LastInsertIdFn: func() (int64, error) {
+								return 0, fmt.Errorf("LastInsertId should not be called")
+							},
+						}, nil
+					},
+				},
+			}
+
+			member := TeamMember{UserID: 1, TeamID: 2}
+			err := c.Insert(ctx, teamMembersTable, &member)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, member, TeamMember{UserID: 1, TeamID: 2})
+			tt.AssertEqual(t, queries, []string{test.expectQuery})
+		})
+	}
+
+	t.Run("should keep preset IDs on tables without auto increment", func(t *testing.T) {
+		type User struct {
+			ID   int    `ksql:"id"`
+			Name string `ksql:"name"`
+		}
+
+		c := DB{
+			dialect: sqldialect.SupportedDialects["mysql"],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					return mockResult{
+						LastInsertIdFn: func() (int64, error) {
+							// MySQL returns 0 for tables without AUTO_INCREMENT columns
+							return 0, nil
+						},
+					}, nil
+				},
+			},
+		}
+
+		u := User{ID: 42, Name: "fakeName"}
+		err := c.Insert(ctx, NewTable("users"), &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u.ID, 42)
+	})
+}
+
+func TestTimeLocation(t *testing.T) {
+	ctx := context.Background()
+
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	tt.AssertNoErr(t, err)
+
+	scannedTime := tt.ParseTime(t, "2024-06-01T12:00:00Z")
+
+	newMockDB := func(config Config, columns []string) DB {
+		numRows := 1
+		return DB{
+			dialect: sqldialect.SupportedDialects["mysql"],
+			config:  config,
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					return mockRows{
+						ScanFn: func(args ...interface{}) error {
+							for _, arg := range args {
+								switch ptr := arg.(type) {
+								case *time.Time:
+									*ptr = scannedTime
+								case **time.Time:
+									t := scannedTime
+									*ptr = &t
+								}
+							}
+							return nil
+						},
+						NextFn: func() bool {
+							numRows--
+							return numRows >= 0
+						},
+						ColumnsFn: func() ([]string, error) { return columns, nil },
+					}, nil
+				},
+			},
+		}
+	}
+
+	type record struct {
+		CreatedAt time.Time  `ksql:"created_at"`
+		UpdatedAt *time.Time `ksql:"updated_at"`
+	}
+
+	t.Run("should keep the location returned by the driver by default", func(t *testing.T) {
+		c := newMockDB(Config{}, []string{"created_at", "updated_at"})
+
+		var r record
+		err := c.QueryOne(ctx, &r, "FROM records")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, r.CreatedAt.Location(), time.UTC)
+		tt.AssertEqual(t, r.UpdatedAt.Location(), time.UTC)
+	})
+
+	t.Run("should convert scanned times to the configured location", func(t *testing.T) {
+		c := newMockDB(Config{TimeLocation: saoPaulo}, []string{"created_at", "updated_at"})
+
+		var r record
+		err := c.QueryOne(ctx, &r, "FROM records")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, r.CreatedAt.Location(), saoPaulo)
+		tt.AssertEqual(t, r.CreatedAt.Equal(scannedTime), true)
+		tt.AssertEqual(t, r.UpdatedAt.Location(), saoPaulo)
+		tt.AssertEqual(t, r.UpdatedAt.Equal(scannedTime), true)
+	})
+
+	t.Run("should not convert zero times", func(t *testing.T) {
+		c := newMockDB(Config{TimeLocation: saoPaulo}, []string{"created_at"})
+
+		var r record
+		err := c.QueryOne(ctx, &r, "FROM records")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, r.UpdatedAt, (*time.Time)(nil))
+
+		var zeroTime time.Time
+		normalizeTimeLocation(saoPaulo, []interface{}{&zeroTime})
+		tt.AssertEqual(t, zeroTime, time.Time{})
+	})
+
+	t.Run("should convert scalar times on QueryChunks", func(t *testing.T) {
+		c := newMockDB(Config{TimeLocation: saoPaulo}, []string{"created_at"})
+
+		var times []time.Time
+		err := c.QueryChunks(ctx, ChunkParser{
+			Query:     "SELECT created_at FROM records",
+			ChunkSize: 10,
+			ForEachChunk: func(chunk []time.Time) error {
+				times = append(times, chunk...)
+				return nil
+			},
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(times), 1)
+		tt.AssertEqual(t, times[0].Location(), saoPaulo)
+	})
+}
+
+func TestTableAlias(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID   uint   `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+	aliasedTable := NewTable("public.users").WithAlias("u")
+
+	tests := []struct {
+		dialect           string
+		expectPatchQuery  string
+		expectDeleteQuery string
+	}{
+		{
+			dialect:           "postgres",
+			expectPatchQuery:  `UPDATE public.users AS u SET "name" = $1 WHERE u."id" = $2`,
+			expectDeleteQuery: `DELETE FROM public.users AS u WHERE u."id" = $1`,
+		},
+		{
+			dialect:           "sqlite3",
+			expectPatchQuery:  "UPDATE public.users AS u SET `name` = ? WHERE u.`id` = ?",
+			expectDeleteQuery: "DELETE FROM public.users AS u WHERE u.`id` = ?",
+		},
+		{
+			dialect:           "mysql",
+			expectPatchQuery:  "UPDATE public.users AS u SET `name` = ? WHERE u.`id` = ?",
+			expectDeleteQuery: "DELETE u FROM public.users AS u WHERE u.`id` = ?",
+		},
+		{
+			dialect:           "sqlserver",
+			expectPatchQuery:  `UPDATE u SET [name] = @p1 FROM public.users AS u WHERE u.[id] = @p2`,
+			expectDeleteQuery: `DELETE u FROM public.users AS u WHERE u.[id] = @p1`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.dialect, func(t *testing.T) {
+			dialect := sqldialect.SupportedDialects[test.dialect]
+
+			t.Run("should qualify the IDs on patch queries", func(t *testing.T) {
+				query, params, err := BuildPatchQuery(ctx, dialect, aliasedTable, User{ID: 42, Name: "fakeName"})
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, query, test.expectPatchQuery)
+				tt.AssertEqual(t, params, []interface{}{"fakeName", uint(42)})
+			})
+
+			t.Run("should qualify the IDs on delete queries", func(t *testing.T) {
+				var deleteQuery string
+				c := DB{
+					dialect: dialect,
+					db: mockDBAdapter{
+						ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+							deleteQuery = query
+							return mockResult{
+								RowsAffectedFn: func() (int64, error) {
+									return 1, nil
+								},
+							}, nil
+						},
+					},
+				}
+
+				err := c.Delete(ctx, aliasedTable, 42)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, deleteQuery, test.expectDeleteQuery)
+			})
+		})
+	}
+
+	t.Run("should not change the queries of tables without alias", func(t *testing.T) {
+		query, _, err := BuildPatchQuery(ctx, sqldialect.SupportedDialects["sqlserver"], NewTable("users"), User{ID: 42, Name: "fakeName"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `UPDATE users SET [name] = @p1 WHERE [id] = @p2`)
+	})
+
+	t.Run("should report error for invalid aliases", func(t *testing.T) {
+		_, _, err := BuildPatchQuery(ctx, sqldialect.SupportedDialects["postgres"], NewTable("users").WithAlias("u; DROP TABLE users"), User{ID: 42, Name: "fakeName"})
+		tt.AssertErrContains(t, err, "invalid table alias", "u; DROP TABLE users")
+	})
+}
+
+func TestQueryChunksWithChunkTransactions(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	newFakeRows := func(numRows int) Rows {
+		id := 0
+		return mockRows{
+			NextFn: func() bool {
+				numRows--
+				return numRows >= 0
+			},
+			ColumnsFn: func() ([]string, error) {
+				return []string{"id", "name"}, nil
+			},
+			ScanFn: func(args ...interface{}) error {
+				id++
+				*args[0].(*int) = id
+				*args[1].(*string) = "fakeName"
+				return nil
+			},
+		}
+	}
+
+	newMockDB := func(events *[]string, numRows int) DB {
+		var numTxs int
+		adapter := mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				return newFakeRows(numRows), nil
+			},
+		}
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockTxBeginner{
+				DBAdapter: adapter,
+				BeginTxFn: func(ctx context.Context) (Tx, error) {
+					numTxs++
+					txName := fmt.Sprint("tx", numTxs)
+					*events = append(*events, "begin "+txName)
+					return mockTx{
+						DBAdapter: mockDBAdapter{
+							QueryContextFn: adapter.QueryContextFn,
+							ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+								*events = append(*events, fmt.Sprint("exec ", query, " on ", txName))
+								return mockResult{}, nil
+							},
+						},
+						CommitFn: func(ctx context.Context) error {
+							*events = append(*events, "commit "+txName)
+							return nil
+						},
+						RollbackFn: func(ctx context.Context) error {
+							*events = append(*events, "rollback "+txName)
+							return nil
+						},
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should run each chunk on its own transaction", func(t *testing.T) {
+		var events []string
+		c := newMockDB(&events, 3)
+
+		err := c.QueryChunks(ctx, ChunkParser{
+			Query:     "FROM users",
+			ChunkSize: 2,
+			ForEachChunk: func(users []User, tx Provider) error {
+				_, err := tx.Exec(ctx, fmt.Sprint("chunk of size ", len(users)))
+				return err
+			},
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, events, []string{
+			"begin tx1",
+			"exec chunk of size 2 on tx1",
+			"commit tx1",
+			"begin tx2",
+			"exec chunk of size 1 on tx2",
+			"commit tx2",
+		})
+	})
+
+	t.Run("should use transactions independent from the wrapping one", func(t *testing.T) {
+		var events []string
+		c := newMockDB(&events, 2)
+
+		err := c.Transaction(ctx, func(db Provider) error {
+			return db.QueryChunks(ctx, ChunkParser{
+				Query:     "FROM users",
+				ChunkSize: 1,
+				ForEachChunk: func(users []User, tx Provider) error {
+					_, err := tx.Exec(ctx, "chunk")
+					return err
+				},
+			})
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, events, []string{
+			"begin tx1",
+			"begin tx2",
+			"exec chunk on tx2",
+			"commit tx2",
+			"begin tx3",
+			"exec chunk on tx3",
+			"commit tx3",
+			"commit tx1",
+		})
+	})
+
+	t.Run("should commit the chunk when the iteration is aborted", func(t *testing.T) {
+		var events []string
+		c := newMockDB(&events, 3)
+
+		err := c.QueryChunks(ctx, ChunkParser{
+			Query:     "FROM users",
+			ChunkSize: 1,
+			ForEachChunk: func(users []User, tx Provider) error {
+				_, err := tx.Exec(ctx, "chunk")
+				if err != nil {
+					return err
+				}
+				return ErrAbortIteration
+			},
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, events, []string{
+			"begin tx1",
+			"exec chunk on tx1",
+			"commit tx1",
+		})
+	})
+
+	t.Run("should rollback the chunk transaction on errors", func(t *testing.T) {
+		var events []string
+		c := newMockDB(&events, 3)
+
+		err := c.QueryChunks(ctx, ChunkParser{
+			Query:     "FROM users",
+			ChunkSize: 1,
+			ForEachChunk: func(users []User, tx Provider) error {
+				return fmt.Errorf("fakeErrMsg")
+			},
+		})
+		tt.AssertErrContains(t, err, "fakeErrMsg")
+		tt.AssertEqual(t, events, []string{
+			"begin tx1",
+			"rollback tx1",
+		})
+	})
+
+	t.Run("should report error if the adapter can't start transactions", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+					return newFakeRows(1), nil
+				},
+			},
+		}
+
+		err := c.QueryChunks(ctx, ChunkParser{
+			Query:     "FROM users",
+			ChunkSize: 1,
+			ForEachChunk: func(users []User, tx Provider) error {
+				return nil
+			},
+		})
+		tt.AssertErrContains(t, err, "KSQL", "TxBeginner")
+	})
+
+	t.Run("should report error if the pool has a single connection", func(t *testing.T) {
+		var events []string
+		c := newMockDB(&events, 3)
+		c.config.MaxOpenConns = 1
+
+		var queried bool
+		c.db = mockTxBeginner{
+			DBAdapter: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+					queried = true
+					return newFakeRows(3), nil
+				},
+			},
+		}
+
+		err := c.QueryChunks(ctx, ChunkParser{
+			Query:     "FROM users",
+			ChunkSize: 2,
+			ForEachChunk: func(users []User, tx Provider) error {
+				return nil
+			},
+		})
+		tt.AssertErrContains(t, err, "KSQL", "MaxOpenConns", "2")
+		tt.AssertEqual(t, queried, false)
+	})
+
+	t.Run("should report error if the wrapping transaction already reached MaxConcurrentTransactions", func(t *testing.T) {
+		var events []string
+		c := newMockDB(&events, 3)
+		c.config.MaxConcurrentTransactions = 1
+		c.txSemaphore = make(chan struct{}, 1)
+
+		err := c.Transaction(ctx, func(db Provider) error {
+			return db.QueryChunks(ctx, ChunkParser{
+				Query:     "FROM users",
+				ChunkSize: 2,
+				ForEachChunk: func(users []User, tx Provider) error {
+					return nil
+				},
+			})
+		})
+		tt.AssertErrContains(t, err, "KSQL", "MaxConcurrentTransactions")
+		tt.AssertEqual(t, events, []string{"begin tx1", "rollback tx1"})
+	})
+}
+
+type mockNestedTx struct {
+	mockTx
+	BeginNestedTxFn func(ctx context.Context) (Tx, error)
+}
+
+func (m mockNestedTx) BeginNestedTx(ctx context.Context) (Tx, error) {
+	return m.BeginNestedTxFn(ctx)
+}
+
+func TestNestedTransactions(t *testing.T) {
+	ctx := context.Background()
+
+	newMockTx := func(name string, calls *[]string, nested func(ctx context.Context) (Tx, error)) Tx {
+		tx := mockTx{
+			DBAdapter: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					*calls = append(*calls, name+": "+query)
+					return mockResult{}, nil
+				},
+			},
+			CommitFn: func(ctx context.Context) error {
+				*calls = append(*calls, name+": commit")
+				return nil
+			},
+			RollbackFn: func(ctx context.Context) error {
+				*calls = append(*calls, name+": rollback")
+				return nil
+			},
+		}
+		if nested == nil {
+			return tx
+		}
+		return mockNestedTx{mockTx: tx, BeginNestedTxFn: nested}
+	}
+
+	t.Run("should use nested transactions if the Tx supports them", func(t *testing.T) {
+		var calls []string
+		c, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return newMockTx("outer", &calls, func(ctx context.Context) (Tx, error) {
+					return newMockTx("inner", &calls, nil), nil
+				}), nil
+			},
+		}, sqldialect.SupportedDialects["postgres"], Config{NestedTransactions: true})
+		tt.AssertNoErr(t, err)
+
+		err = c.Transaction(ctx, func(db Provider) error {
+			_, _ = db.Exec(ctx, "query1")
+
+			err := db.Transaction(ctx, func(db Provider) error {
+				_, _ = db.Exec(ctx, "query2")
+				return errors.New("fakeErrMsg")
+			})
+			tt.AssertErrContains(t, err, "fakeErrMsg")
+
+			err = db.Transaction(ctx, func(db Provider) error {
+				_, _ = db.Exec(ctx, "query3")
+				return nil
+			})
+			tt.AssertNoErr(t, err)
+
+			return nil
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{
+			"outer: query1",
+			"inner: query2",
+			"inner: rollback",
+			"inner: query3",
+			"inner: commit",
+			"outer: commit",
+		})
+	})
+
+	t.Run("should reuse the outer transaction otherwise", func(t *testing.T) {
+		var calls []string
+		c, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return newMockTx("outer", &calls, nil), nil
+			},
+		}, sqldialect.SupportedDialects["postgres"])
+		tt.AssertNoErr(t, err)
+
+		err = c.Transaction(ctx, func(db Provider) error {
+			return db.Transaction(ctx, func(db Provider) error {
+				_, _ = db.Exec(ctx, "query1")
+				return nil
+			})
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{
+			"outer: query1",
+			"outer: commit",
+		})
+	})
+
+	t.Run("should reuse the outer transaction if the NestedTransactions option is not set", func(t *testing.T) {
+		var calls []string
+		c, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return newMockTx("outer", &calls, func(ctx context.Context) (Tx, error) {
+					return newMockTx("inner", &calls, nil), nil
+				}), nil
+			},
+		}, sqldialect.SupportedDialects["postgres"])
+		tt.AssertNoErr(t, err)
+
+		err = c.Transaction(ctx, func(db Provider) error {
+			return db.Transaction(ctx, func(db Provider) error {
+				_, _ = db.Exec(ctx, "query1")
+				return nil
+			})
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{
+			"outer: query1",
+			"outer: commit",
+		})
+	})
+
+	t.Run("should report errors starting the nested transaction", func(t *testing.T) {
+		var calls []string
+		c, err := NewWithAdapter(mockTxBeginner{
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return newMockTx("outer", &calls, func(ctx context.Context) (Tx, error) {
+					return nil, errors.New("fakeErrMsg")
+				}), nil
+			},
+		}, sqldialect.SupportedDialects["postgres"], Config{NestedTransactions: true})
+		tt.AssertNoErr(t, err)
+
+		err = c.Transaction(ctx, func(db Provider) error {
+			return db.Transaction(ctx, func(db Provider) error {
+				return nil
+			})
+		})
+		tt.AssertErrContains(t, err, "KSQL", "nested transaction", "fakeErrMsg")
+		tt.AssertEqual(t, calls, []string{"outer: rollback"})
+	})
+}
+
+func TestMaxConcurrentTransactions(t *testing.T) {
+	ctx := context.Background()
+
+	var numBegins int
+	c, err := NewWithAdapter(mockTxBeginner{
+		BeginTxFn: func(ctx context.Context) (Tx, error) {
+			numBegins++
+			return mockTx{
+				DBAdapter:  mockDBAdapter{},
+				CommitFn:   func(ctx context.Context) error { return nil },
+				RollbackFn: func(ctx context.Context) error { return nil },
+			}, nil
+		},
+	}, sqldialect.SupportedDialects["sqlite3"], Config{
+		MaxConcurrentTransactions: 1,
+	})
+	tt.AssertNoErr(t, err)
+
+	t.Run("should report transactions started while the limit is reached", func(t *testing.T) {
+		numBegins = 0
+		err := c.Transaction(ctx, func(db Provider) error {
+			// Using the outer DB instead of the Provider received
+			// by the callback starts a concurrent transaction:
+			return c.Transaction(ctx, func(db Provider) error {
+				return nil
+			})
+		})
+		tt.AssertEqual(t, errors.Is(err, ErrTooManyTransactions), true)
+		tt.AssertErrContains(t, err, "MaxConcurrentTransactions", "1")
+		tt.AssertEqual(t, numBegins, 1)
+	})
+
+	t.Run("should not count nested transactions", func(t *testing.T) {
+		numBegins = 0
+		err := c.Transaction(ctx, func(db Provider) error {
+			return db.Transaction(ctx, func(db Provider) error {
+				return nil
+			})
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, numBegins, 1)
+	})
+
+	t.Run("should release the slot after the transaction finishes", func(t *testing.T) {
+		numBegins = 0
+		for i := 0; i < 3; i++ {
+			err := c.Transaction(ctx, func(db Provider) error {
+				return errors.New("fakeErrMsg")
+			})
+			tt.AssertErrContains(t, err, "fakeErrMsg")
+		}
+		tt.AssertEqual(t, numBegins, 3)
+	})
+}
@@ -0,0 +1,164 @@
+// Package ksqlexport streams the results of queries into common file
+// formats, which is useful for admin endpoints and offline exports
+// that would otherwise need a struct and a scan loop for each query.
+//
+// The rows are written as soon as they are read from the database,
+// so the memory used doesn't grow with the size of the result.
+package ksqlexport
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/vingarcia/ksql"
+)
+
+// DB is the subset of the ksql.DB methods used by this package.
+type DB interface {
+	QueryRows(ctx context.Context, fn func(rows ksql.Rows) error, query string, params ...interface{}) error
+}
+
+// ToCSV runs the query and writes its results to `w` as CSV,
+// starting with a header containing the names of the columns:
+//
+//	err := ksqlexport.ToCSV(ctx, db, w, "SELECT id, name FROM users WHERE age > $1", 18)
+//
+// NULL values are written as empty strings, time values are written
+// in the RFC3339 format and []byte values are written as strings.
+func ToCSV(ctx context.Context, db DB, w io.Writer, query string, params ...interface{}) error {
+	csvWriter := csv.NewWriter(w)
+
+	err := forEachRow(ctx, db, query, params,
+		func(columns []string) error {
+			return csvWriter.Write(columns)
+		},
+		func(columns []string, values []interface{}) error {
+			record := make([]string, len(values))
+			for i, value := range values {
+				record[i] = formatCSVValue(value)
+			}
+			return csvWriter.Write(record)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// ToJSONLines runs the query and writes each row of the result to `w` as
+// a JSON object on its own line, with the attributes on the same order
+// as the columns of the query:
+//
+//	err := ksqlexport.ToJSONLines(ctx, db, w, "SELECT id, name FROM users WHERE age > $1", 18)
+//
+// NULL values are written as `null` and []byte values are written as strings.
+func ToJSONLines(ctx context.Context, db DB, w io.Writer, query string, params ...interface{}) error {
+	bufWriter := bufio.NewWriter(w)
+
+	var escapedColumns [][]byte
+	err := forEachRow(ctx, db, query, params,
+		func(columns []string) error {
+			for _, column := range columns {
+				escapedColumn, err := json.Marshal(column)
+				if err != nil {
+					return err
+				}
+				escapedColumns = append(escapedColumns, escapedColumn)
+			}
+			return nil
+		},
+		func(columns []string, values []interface{}) error {
+			bufWriter.WriteByte('{')
+			for i, value := range values {
+				if b, ok := value.([]byte); ok {
+					value = string(b)
+				}
+
+				encodedValue, err := json.Marshal(value)
+				if err != nil {
+					return fmt.Errorf("ksqlexport: unable to encode the value of the column `%s`: %w", columns[i], err)
+				}
+
+				if i > 0 {
+					bufWriter.WriteByte(',')
+				}
+				bufWriter.Write(escapedColumns[i])
+				bufWriter.WriteByte(':')
+				bufWriter.Write(encodedValue)
+			}
+			_, err := bufWriter.WriteString("}\n")
+			return err
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return bufWriter.Flush()
+}
+
+// forEachRow runs the query calling `onColumns` once with the names
+// of the columns and then `onRow` for each row of the result.
+func forEachRow(
+	ctx context.Context,
+	db DB,
+	query string,
+	params []interface{},
+	onColumns func(columns []string) error,
+	onRow func(columns []string, values []interface{}) error,
+) error {
+	return db.QueryRows(ctx, func(rows ksql.Rows) error {
+		columns, err := rows.Columns()
+		if err != nil {
+			return fmt.Errorf("ksqlexport: unable to read columns from returned rows: %w", err)
+		}
+
+		err = onColumns(columns)
+		if err != nil {
+			return err
+		}
+
+		values := make([]interface{}, len(columns))
+		scanArgs := make([]interface{}, len(columns))
+		for i := range values {
+			scanArgs[i] = &values[i]
+		}
+
+		for rows.Next() {
+			err = rows.Scan(scanArgs...)
+			if err != nil {
+				return fmt.Errorf("ksqlexport: scan error: %w", err)
+			}
+
+			err = onRow(columns, values)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, query, params...)
+}
+
+func formatCSVValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
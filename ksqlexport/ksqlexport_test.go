@@ -0,0 +1,150 @@
+package ksqlexport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vingarcia/ksql"
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestToCSV(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should write the header and the rows", func(t *testing.T) {
+		db := newMockDB(t, []string{"id", "name", "bio", "created_at"}, [][]interface{}{
+			{int64(1), "Ana", []byte("likes, commas"), time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+			{int64(2), "Bia", nil, time.Date(2024, 6, 7, 8, 9, 10, 0, time.UTC)},
+		})
+
+		var buf bytes.Buffer
+		err := ToCSV(ctx, db, &buf, "SELECT id, name, bio, created_at FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, buf.String(), "id,name,bio,created_at\n"+
+			"1,Ana,\"likes, commas\",2024-01-02T03:04:05Z\n"+
+			"2,Bia,,2024-06-07T08:09:10Z\n",
+		)
+	})
+
+	t.Run("should write only the header if there are no rows", func(t *testing.T) {
+		db := newMockDB(t, []string{"id", "name"}, nil)
+
+		var buf bytes.Buffer
+		err := ToCSV(ctx, db, &buf, "SELECT id, name FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, buf.String(), "id,name\n")
+	})
+
+	t.Run("should report query errors", func(t *testing.T) {
+		db := newMockDBWithErr(t, errors.New("fakeErrMsg"))
+
+		var buf bytes.Buffer
+		err := ToCSV(ctx, db, &buf, "SELECT id, name FROM users")
+		tt.AssertErrContains(t, err, "KSQL", "fakeErrMsg")
+	})
+}
+
+func TestToJSONLines(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should write one object per row keeping the column order", func(t *testing.T) {
+		db := newMockDB(t, []string{"name", "id", "bio"}, [][]interface{}{
+			{"Ana", int64(1), []byte("fake bio")},
+			{"Bia", int64(2), nil},
+		})
+
+		var buf bytes.Buffer
+		err := ToJSONLines(ctx, db, &buf, "SELECT name, id, bio FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, buf.String(), `{"name":"Ana","id":1,"bio":"fake bio"}`+"\n"+
+			`{"name":"Bia","id":2,"bio":null}`+"\n",
+		)
+	})
+
+	t.Run("should write nothing if there are no rows", func(t *testing.T) {
+		db := newMockDB(t, []string{"id"}, nil)
+
+		var buf bytes.Buffer
+		err := ToJSONLines(ctx, db, &buf, "SELECT id FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, buf.String(), "")
+	})
+
+	t.Run("should report query errors", func(t *testing.T) {
+		db := newMockDBWithErr(t, errors.New("fakeErrMsg"))
+
+		var buf bytes.Buffer
+		err := ToJSONLines(ctx, db, &buf, "SELECT id FROM users")
+		tt.AssertErrContains(t, err, "KSQL", "fakeErrMsg")
+	})
+}
+
+func newMockDB(t *testing.T, columns []string, rows [][]interface{}) ksql.DB {
+	db, err := ksql.NewWithAdapter(mockAdapter{
+		rows: &mockRows{
+			columns: columns,
+			rows:    rows,
+			idx:     -1,
+		},
+	}, sqldialect.SupportedDialects["postgres"])
+	tt.AssertNoErr(t, err)
+	return db
+}
+
+func newMockDBWithErr(t *testing.T, err error) ksql.DB {
+	db, dbErr := ksql.NewWithAdapter(mockAdapter{
+		err: err,
+	}, sqldialect.SupportedDialects["postgres"])
+	tt.AssertNoErr(t, dbErr)
+	return db
+}
+
+type mockAdapter struct {
+	rows *mockRows
+	err  error
+}
+
+func (m mockAdapter) QueryContext(ctx context.Context, query string, params ...interface{}) (ksql.Rows, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.rows, nil
+}
+
+func (m mockAdapter) ExecContext(ctx context.Context, query string, params ...interface{}) (ksql.Result, error) {
+	return nil, errors.New("unexpected call to ExecContext")
+}
+
+type mockRows struct {
+	columns []string
+	rows    [][]interface{}
+	idx     int
+}
+
+func (m *mockRows) Scan(args ...interface{}) error {
+	for i, arg := range args {
+		*arg.(*interface{}) = m.rows[m.idx][i]
+	}
+	return nil
+}
+
+func (m *mockRows) Close() error {
+	return nil
+}
+
+func (m *mockRows) Next() bool {
+	m.idx++
+	return m.idx < len(m.rows)
+}
+
+func (m *mockRows) Err() error {
+	return nil
+}
+
+func (m *mockRows) Columns() ([]string, error) {
+	return m.columns, nil
+}
@@ -0,0 +1,290 @@
+// Package ksqlf builds the conditions of WHERE clauses from typed
+// filters, so the repositories built on top of KSQL don't need to
+// concatenate column names and placeholders by hand:
+//
+//	where, err := ksqlf.Where(dialect,
+//		ksqlf.Eq("name", name),
+//		ksqlf.In("id", ids),
+//		ksqlf.Between("age", 18, 65),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//
+//	err = db.Query(ctx, &users, "FROM users "+where.Query, where.Params...)
+//
+// The column names are validated and escaped and the values are
+// always sent as params, so the filters can't be used for SQL injection.
+package ksqlf
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/vingarcia/ksql"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// Filter is a condition that can be combined with other
+// filters and converted to a query fragment with Where.
+type Filter struct {
+	build func(dialect sqldialect.Provider, params *[]interface{}) (string, error)
+}
+
+// Eq matches the rows where the column is equal to the value,
+// if the value is nil it matches the rows where the column is NULL.
+func Eq(column string, value interface{}) Filter {
+	if value == nil {
+		return IsNull(column)
+	}
+	return comparison(column, "=", value)
+}
+
+// NotEq matches the rows where the column is different from the value,
+// if the value is nil it matches the rows where the column is not NULL.
+func NotEq(column string, value interface{}) Filter {
+	if value == nil {
+		return IsNotNull(column)
+	}
+	return comparison(column, "<>", value)
+}
+
+// Gt matches the rows where the column is greater than the value.
+func Gt(column string, value interface{}) Filter {
+	return comparison(column, ">", value)
+}
+
+// Gte matches the rows where the column is greater than or equal to the value.
+func Gte(column string, value interface{}) Filter {
+	return comparison(column, ">=", value)
+}
+
+// Lt matches the rows where the column is less than the value.
+func Lt(column string, value interface{}) Filter {
+	return comparison(column, "<", value)
+}
+
+// Lte matches the rows where the column is less than or equal to the value.
+func Lte(column string, value interface{}) Filter {
+	return comparison(column, "<=", value)
+}
+
+// Like matches the rows where the column matches the pattern,
+// the pattern is sent as a param so it is not escaped, i.e.
+// `%` and `_` characters on it work as wildcards.
+func Like(column string, pattern string) Filter {
+	return comparison(column, "LIKE", pattern)
+}
+
+// Between matches the rows where the column is between
+// min and max, including both of them.
+func Between(column string, min interface{}, max interface{}) Filter {
+	return Filter{
+		build: func(dialect sqldialect.Provider, params *[]interface{}) (string, error) {
+			col, err := escapeColumn(dialect, column)
+			if err != nil {
+				return "", err
+			}
+
+			return col + " BETWEEN " +
+				ksql.AppendPlaceholder(dialect, params, min) + " AND " +
+				ksql.AppendPlaceholder(dialect, params, max), nil
+		},
+	}
+}
+
+// In matches the rows where the column is equal to one of
+// the values of the input slice, e.g. `In("id", []int{1, 2, 3})`.
+//
+// If the slice is empty it matches no rows, instead of
+// generating the invalid SQL `id IN ()`.
+func In(column string, values interface{}) Filter {
+	return in(column, "IN", "1 = 0", values)
+}
+
+// NotIn matches the rows where the column is different from all
+// the values of the input slice, if the slice is empty it matches
+// all rows.
+func NotIn(column string, values interface{}) Filter {
+	return in(column, "NOT IN", "1 = 1", values)
+}
+
+// IsNull matches the rows where the column is NULL.
+func IsNull(column string) Filter {
+	return nullCheck(column, "IS NULL")
+}
+
+// IsNotNull matches the rows where the column is not NULL.
+func IsNotNull(column string) Filter {
+	return nullCheck(column, "IS NOT NULL")
+}
+
+// And matches the rows that match all the input filters,
+// if no filters are informed it matches all rows.
+func And(filters ...Filter) Filter {
+	return join(" AND ", "1 = 1", filters)
+}
+
+// Or matches the rows that match at least one of the input
+// filters, if no filters are informed it matches no rows.
+func Or(filters ...Filter) Filter {
+	return join(" OR ", "1 = 0", filters)
+}
+
+// Not matches the rows that don't match the input filter.
+func Not(filter Filter) Filter {
+	return Filter{
+		build: func(dialect sqldialect.Provider, params *[]interface{}) (string, error) {
+			condition, err := filter.buildCondition(dialect, params)
+			if err != nil {
+				return "", err
+			}
+			return "NOT (" + condition + ")", nil
+		},
+	}
+}
+
+// Condition combines the filters with AND and returns the resulting
+// condition, which is useful for adding the filters to a WHERE clause
+// that already has other conditions.
+//
+// The placeholders are numbered as if the fragment was a query of its
+// own, so it can be combined with other fragments using ksql.ConcatQueries.
+func Condition(dialect sqldialect.Provider, filters ...Filter) (ksql.QueryFragment, error) {
+	if len(filters) == 0 {
+		return ksql.QueryFragment{Query: "1 = 1"}, nil
+	}
+
+	var params []interface{}
+	conditions := make([]string, len(filters))
+	for i, filter := range filters {
+		condition, err := filter.buildCondition(dialect, &params)
+		if err != nil {
+			return ksql.QueryFragment{}, err
+		}
+		conditions[i] = condition
+	}
+
+	return ksql.QueryFragment{
+		Query:  strings.Join(conditions, " AND "),
+		Params: params,
+	}, nil
+}
+
+// Where works like Condition but returns the query starting with
+// `WHERE`, if no filters are informed the query is empty.
+func Where(dialect sqldialect.Provider, filters ...Filter) (ksql.QueryFragment, error) {
+	if len(filters) == 0 {
+		return ksql.QueryFragment{}, nil
+	}
+
+	fragment, err := Condition(dialect, filters...)
+	if err != nil {
+		return ksql.QueryFragment{}, err
+	}
+
+	fragment.Query = "WHERE " + fragment.Query
+	return fragment, nil
+}
+
+func (f Filter) buildCondition(dialect sqldialect.Provider, params *[]interface{}) (string, error) {
+	if f.build == nil {
+		return "", fmt.Errorf("ksqlf: filters must be created with the functions of the ksqlf package")
+	}
+	return f.build(dialect, params)
+}
+
+func comparison(column string, operator string, value interface{}) Filter {
+	return Filter{
+		build: func(dialect sqldialect.Provider, params *[]interface{}) (string, error) {
+			col, err := escapeColumn(dialect, column)
+			if err != nil {
+				return "", err
+			}
+			return col + " " + operator + " " + ksql.AppendPlaceholder(dialect, params, value), nil
+		},
+	}
+}
+
+func in(column string, operator string, emptyCondition string, values interface{}) Filter {
+	return Filter{
+		build: func(dialect sqldialect.Provider, params *[]interface{}) (string, error) {
+			col, err := escapeColumn(dialect, column)
+			if err != nil {
+				return "", err
+			}
+
+			v := reflect.ValueOf(values)
+			if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+				return "", fmt.Errorf("ksqlf: expected a slice of values for the %s filter on `%s`, but got: %T", operator, column, values)
+			}
+
+			if v.Len() == 0 {
+				return emptyCondition, nil
+			}
+
+			placeholders := make([]string, v.Len())
+			for i := range placeholders {
+				placeholders[i] = ksql.AppendPlaceholder(dialect, params, v.Index(i).Interface())
+			}
+
+			return col + " " + operator + " (" + strings.Join(placeholders, ", ") + ")", nil
+		},
+	}
+}
+
+func nullCheck(column string, check string) Filter {
+	return Filter{
+		build: func(dialect sqldialect.Provider, params *[]interface{}) (string, error) {
+			col, err := escapeColumn(dialect, column)
+			if err != nil {
+				return "", err
+			}
+			return col + " " + check, nil
+		},
+	}
+}
+
+func join(separator string, emptyCondition string, filters []Filter) Filter {
+	return Filter{
+		build: func(dialect sqldialect.Provider, params *[]interface{}) (string, error) {
+			if len(filters) == 0 {
+				return emptyCondition, nil
+			}
+
+			conditions := make([]string, len(filters))
+			for i, filter := range filters {
+				condition, err := filter.buildCondition(dialect, params)
+				if err != nil {
+					return "", err
+				}
+				conditions[i] = condition
+			}
+
+			if len(conditions) == 1 {
+				return conditions[0], nil
+			}
+
+			return "(" + strings.Join(conditions, separator) + ")", nil
+		},
+	}
+}
+
+var columnRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+// escapeColumn validates the column name, which might be prefixed with
+// the name of the table, e.g. `users.id`, and escapes each part of it.
+func escapeColumn(dialect sqldialect.Provider, column string) (string, error) {
+	if !columnRegex.MatchString(column) {
+		return "", fmt.Errorf("ksqlf: invalid column name `%s`: expected a column name optionally prefixed by a table name", column)
+	}
+
+	parts := strings.Split(column, ".")
+	for i, part := range parts {
+		parts[i] = dialect.Escape(part)
+	}
+
+	return strings.Join(parts, "."), nil
+}
@@ -0,0 +1,123 @@
+package ksqlf
+
+import (
+	"testing"
+
+	"github.com/vingarcia/ksql"
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestWhere(t *testing.T) {
+	postgres := sqldialect.PostgresDialect{}
+
+	tests := []struct {
+		desc           string
+		dialect        sqldialect.Provider
+		filters        []Filter
+		expectedQuery  string
+		expectedParams []interface{}
+	}{
+		{
+			desc:           "should return an empty fragment when no filters are informed",
+			dialect:        postgres,
+			expectedQuery:  "",
+			expectedParams: nil,
+		},
+		{
+			desc:           "should build comparisons",
+			dialect:        postgres,
+			filters:        []Filter{Eq("name", "Ana"), NotEq("type", "admin"), Gt("age", 18), Lte("score", 10)},
+			expectedQuery:  `WHERE "name" = $1 AND "type" <> $2 AND "age" > $3 AND "score" <= $4`,
+			expectedParams: []interface{}{"Ana", "admin", 18, 10},
+		},
+		{
+			desc:           "should build IN and BETWEEN filters",
+			dialect:        postgres,
+			filters:        []Filter{In("id", []int{1, 2, 3}), Between("age", 18, 65)},
+			expectedQuery:  `WHERE "id" IN ($1, $2, $3) AND "age" BETWEEN $4 AND $5`,
+			expectedParams: []interface{}{1, 2, 3, 18, 65},
+		},
+		{
+			desc:           "should match no rows for empty IN filters",
+			dialect:        postgres,
+			filters:        []Filter{In("id", []int{}), NotIn("type", []string{})},
+			expectedQuery:  `WHERE 1 = 0 AND 1 = 1`,
+			expectedParams: nil,
+		},
+		{
+			desc:           "should compare nil values with IS NULL",
+			dialect:        postgres,
+			filters:        []Filter{Eq("deleted_at", nil), NotEq("name", nil)},
+			expectedQuery:  `WHERE "deleted_at" IS NULL AND "name" IS NOT NULL`,
+			expectedParams: nil,
+		},
+		{
+			desc:    "should combine filters with And, Or and Not",
+			dialect: postgres,
+			filters: []Filter{
+				Or(Like("name", "A%"), And(Gte("age", 18), Lt("age", 65))),
+				Not(In("u.type", []string{"bot"})),
+			},
+			expectedQuery:  `WHERE ("name" LIKE $1 OR ("age" >= $2 AND "age" < $3)) AND NOT ("u"."type" IN ($4))`,
+			expectedParams: []interface{}{"A%", 18, 65, "bot"},
+		},
+		{
+			desc:           "should use the placeholders of the dialect",
+			dialect:        sqldialect.MysqlDialect{},
+			filters:        []Filter{Eq("name", "Ana"), In("id", []int{1, 2})},
+			expectedQuery:  "WHERE `name` = ? AND `id` IN (?, ?)",
+			expectedParams: []interface{}{"Ana", 1, 2},
+		},
+		{
+			desc:           "should use the placeholders of sqlserver",
+			dialect:        sqldialect.SqlserverDialect{},
+			filters:        []Filter{Eq("name", "Ana"), Between("age", 18, 65)},
+			expectedQuery:  "WHERE [name] = @p1 AND [age] BETWEEN @p2 AND @p3",
+			expectedParams: []interface{}{"Ana", 18, 65},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			fragment, err := Where(test.dialect, test.filters...)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, fragment.Query, test.expectedQuery)
+			tt.AssertEqual(t, fragment.Params, test.expectedParams)
+		})
+	}
+
+	t.Run("should report errors", func(t *testing.T) {
+		_, err := Where(postgres, Eq("name; DROP TABLE users", "Ana"))
+		tt.AssertErrContains(t, err, "ksqlf", "invalid column name", "DROP TABLE")
+
+		_, err = Where(postgres, And(Eq("name", "Ana"), In("id", 42)))
+		tt.AssertErrContains(t, err, "ksqlf", "expected a slice", "int")
+
+		_, err = Where(postgres, Filter{})
+		tt.AssertErrContains(t, err, "ksqlf", "must be created")
+	})
+}
+
+func TestCondition(t *testing.T) {
+	dialect := sqldialect.PostgresDialect{}
+
+	t.Run("should match all rows when no filters are informed", func(t *testing.T) {
+		fragment, err := Condition(dialect)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, fragment, ksql.QueryFragment{Query: "1 = 1"})
+	})
+
+	t.Run("should be composable with ksql.ConcatQueries", func(t *testing.T) {
+		condition, err := Condition(dialect, Eq("name", "Ana"), In("id", []int{1, 2}))
+		tt.AssertNoErr(t, err)
+
+		query, params, err := ksql.ConcatQueries(dialect,
+			ksql.QueryFragment{Query: "FROM users WHERE tenant_id = $1 AND", Params: []interface{}{42}},
+			condition,
+		)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `FROM users WHERE tenant_id = $1 AND "name" = $2 AND "id" IN ($3, $4)`)
+		tt.AssertEqual(t, params, []interface{}{42, "Ana", 1, 2})
+	})
+}
@@ -10,6 +10,10 @@ type AttrModifier struct {
 	SkipOnInsert bool
 	SkipOnUpdate bool
 
+	// SkipOnInsertIfZero will leave this attribute out of insertions only when
+	// it holds its zero value, so the default value of the column is used instead.
+	SkipOnInsertIfZero bool
+
 	// Nullable will make sure that on Insert and Patch operations
 	// this field will not be ignored even if it is a NULL pointer.
 	Nullable bool
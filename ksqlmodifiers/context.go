@@ -0,0 +1,86 @@
+package ksqlmodifiers
+
+import "context"
+
+type valueKey struct {
+	key string
+}
+
+// WithValue returns a copy of ctx carrying the input value, which
+// can be read by the Scan and Value functions of the modifiers
+// using the GetValue function or one of the typed accessors.
+//
+// This allows passing per-operation metadata to the modifiers,
+// e.g. the ID of the user making the request for audit columns:
+//
+//	ctx = ksqlmodifiers.WithValue(ctx, "tenant_id", tenantID)
+//	err := db.Insert(ctx, usersTable, &user)
+//
+// Since all ksql methods receive a context, values injected on the
+// context passed to Transaction() are also available to the modifiers
+// as long as the same context is passed to the operations inside it.
+func WithValue(ctx context.Context, key string, value interface{}) context.Context {
+	return context.WithValue(ctx, valueKey{key}, value)
+}
+
+// GetValue returns the value injected with WithValue
+// for the input key and whether it was found.
+func GetValue(ctx context.Context, key string) (value interface{}, found bool) {
+	value = ctx.Value(valueKey{key})
+	return value, value != nil
+}
+
+// GetString returns the value injected with WithValue for the input key,
+// the boolean is false if the value was not found or is not a string.
+func GetString(ctx context.Context, key string) (string, bool) {
+	value, ok := ctx.Value(valueKey{key}).(string)
+	return value, ok
+}
+
+// GetInt64 returns the value injected with WithValue for the input key
+// converted to int64, the boolean is false if the value was not found
+// or is not one of the signed integer types.
+func GetInt64(ctx context.Context, key string) (int64, bool) {
+	switch value := ctx.Value(valueKey{key}).(type) {
+	case int:
+		return int64(value), true
+	case int8:
+		return int64(value), true
+	case int16:
+		return int64(value), true
+	case int32:
+		return int64(value), true
+	case int64:
+		return value, true
+	default:
+		return 0, false
+	}
+}
+
+// operatorKey is unexported so the operator
+// can't be overwritten by the keys of WithValue
+type operatorKey struct{}
+
+// InjectOperator returns a copy of ctx carrying the ID of the user
+// (or service) performing the operations, which is used by the
+// `userIDOnInsert` and `userIDOnUpdate` modifiers for filling
+// audit columns such as `created_by` and `updated_by`:
+//
+//	type User struct {
+//		ID        int    `ksql:"id"`
+//		Name      string `ksql:"name"`
+//		CreatedBy string `ksql:"created_by,userIDOnInsert"`
+//		UpdatedBy string `ksql:"updated_by,userIDOnUpdate"`
+//	}
+//
+//	ctx = ksqlmodifiers.InjectOperator(ctx, currentUserID)
+//	err := db.Insert(ctx, usersTable, &user)
+func InjectOperator(ctx context.Context, id interface{}) context.Context {
+	return context.WithValue(ctx, operatorKey{}, id)
+}
+
+// GetOperator returns the ID injected with InjectOperator and whether it was found.
+func GetOperator(ctx context.Context) (id interface{}, found bool) {
+	id = ctx.Value(operatorKey{})
+	return id, id != nil
+}
@@ -0,0 +1,87 @@
+package ksqlmodifiers
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+func TestContextValues(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return the injected values", func(t *testing.T) {
+		ctx := WithValue(ctx, "user_id", 42)
+		ctx = WithValue(ctx, "user_name", "fakeName")
+
+		value, found := GetValue(ctx, "user_id")
+		tt.AssertEqual(t, found, true)
+		tt.AssertEqual(t, value, 42)
+
+		id, ok := GetInt64(ctx, "user_id")
+		tt.AssertEqual(t, ok, true)
+		tt.AssertEqual(t, id, int64(42))
+
+		name, ok := GetString(ctx, "user_name")
+		tt.AssertEqual(t, ok, true)
+		tt.AssertEqual(t, name, "fakeName")
+	})
+
+	t.Run("should report values that were not found", func(t *testing.T) {
+		_, found := GetValue(ctx, "user_id")
+		tt.AssertEqual(t, found, false)
+
+		_, ok := GetString(ctx, "user_id")
+		tt.AssertEqual(t, ok, false)
+
+		_, ok = GetInt64(ctx, "user_id")
+		tt.AssertEqual(t, ok, false)
+	})
+
+	t.Run("should report values of the wrong type", func(t *testing.T) {
+		ctx := WithValue(ctx, "user_id", "42")
+
+		_, ok := GetInt64(ctx, "user_id")
+		tt.AssertEqual(t, ok, false)
+
+		ctx = WithValue(ctx, "user_name", 42)
+
+		_, ok = GetString(ctx, "user_name")
+		tt.AssertEqual(t, ok, false)
+	})
+
+	t.Run("should not conflict with other context keys", func(t *testing.T) {
+		type otherKey string
+		ctx := context.WithValue(ctx, otherKey("user_id"), 42)
+
+		_, found := GetValue(ctx, "user_id")
+		tt.AssertEqual(t, found, false)
+	})
+}
+
+func TestInjectOperator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return the injected operator", func(t *testing.T) {
+		ctx := InjectOperator(ctx, "fakeOperatorID")
+
+		id, found := GetOperator(ctx)
+		tt.AssertEqual(t, found, true)
+		tt.AssertEqual(t, id, "fakeOperatorID")
+	})
+
+	t.Run("should not be overwritten by values injected with WithValue", func(t *testing.T) {
+		ctx := InjectOperator(ctx, "fakeOperatorID")
+		ctx = WithValue(ctx, "ksql.operator", "otherID")
+		ctx = context.WithValue(ctx, "ksql.operator", "otherID")
+
+		id, found := GetOperator(ctx)
+		tt.AssertEqual(t, found, true)
+		tt.AssertEqual(t, id, "fakeOperatorID")
+	})
+
+	t.Run("should report operators that were not injected", func(t *testing.T) {
+		_, found := GetOperator(ctx)
+		tt.AssertEqual(t, found, false)
+	})
+}
@@ -0,0 +1,33 @@
+package ksqlmodifiers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidEnumValue is returned, wrapped in an InvalidEnumValueError,
+// when an attribute using the `enum` modifier has a value that is
+// not one of the values listed on its tag.
+var ErrInvalidEnumValue error = fmt.Errorf("ksql: invalid enum value")
+
+// InvalidEnumValueError is returned by the `enum` modifier when
+// an attribute has a value that is not on the list of allowed values.
+//
+// It can be checked with `errors.Is(err, ksqlmodifiers.ErrInvalidEnumValue)`.
+type InvalidEnumValueError struct {
+	Value         string
+	AllowedValues []string
+}
+
+// Error implements the error interface
+func (e InvalidEnumValueError) Error() string {
+	return fmt.Sprintf(
+		"%s: '%s' is not one of the allowed values: %s",
+		ErrInvalidEnumValue, e.Value, strings.Join(e.AllowedValues, ", "),
+	)
+}
+
+// Unwrap returns ErrInvalidEnumValue
+func (e InvalidEnumValueError) Unwrap() error {
+	return ErrInvalidEnumValue
+}
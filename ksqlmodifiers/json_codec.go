@@ -0,0 +1,111 @@
+package ksqlmodifiers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+// JSONCodec describes the functions used by the `json` modifiers
+// for serializing and deserializing the attributes.
+//
+// It is implemented by several third-party JSON libraries, e.g.
+// `jsoniter.ConfigCompatibleWithStandardLibrary`, and can also be
+// implemented by small wrappers around packages like protojson.
+type JSONCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type stdJSONCodec struct{}
+
+func (stdJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// codecBox is necessary because atomic.Value
+// only accepts values of a single concrete type.
+type codecBox struct {
+	codec JSONCodec
+}
+
+var globalJSONCodec atomic.Value
+
+func init() {
+	globalJSONCodec.Store(codecBox{stdJSONCodec{}})
+}
+
+// SetJSONCodec replaces the codec used by the builtin `json` and
+// `json/nullable` modifiers, which defaults to the encoding/json package.
+//
+// It is recommended to call it inside an init() function, e.g.:
+//
+//	func init() {
+//		ksqlmodifiers.SetJSONCodec(jsoniter.ConfigCompatibleWithStandardLibrary)
+//	}
+//
+// Passing nil restores the default codec.
+func SetJSONCodec(codec JSONCodec) {
+	if codec == nil {
+		codec = stdJSONCodec{}
+	}
+
+	globalJSONCodec.Store(codecBox{codec})
+}
+
+// NewJSONModifier returns a modifier that works like the builtin `json`
+// modifier but uses the input codec, which is useful for using a different
+// JSON library only on some of the attributes, e.g.:
+//
+//	func init() {
+//		ksqlmodifiers.RegisterAttrModifier("jsoniter", ksqlmodifiers.NewJSONModifier(jsoniter.ConfigFastest))
+//	}
+//
+//	type Event struct {
+//		ID      int     `ksql:"id"`
+//		Payload Payload `ksql:"payload,jsoniter"`
+//	}
+//
+// If the codec is nil the modifier uses the codec set with SetJSONCodec.
+func NewJSONModifier(codec JSONCodec) AttrModifier {
+	getCodec := func() JSONCodec {
+		if codec != nil {
+			return codec
+		}
+		return globalJSONCodec.Load().(codecBox).codec
+	}
+
+	return AttrModifier{
+		Scan: func(ctx context.Context, opInfo OpInfo, attrPtr interface{}, dbValue interface{}) error {
+			if dbValue == nil {
+				return nil
+			}
+
+			// Required since sqlite3 returns strings not bytes
+			if v, ok := dbValue.(string); ok {
+				dbValue = []byte(v)
+			}
+
+			rawJSON, ok := dbValue.([]byte)
+			if !ok {
+				return fmt.Errorf("unexpected type received to Scan: %T", dbValue)
+			}
+			return getCodec().Unmarshal(rawJSON, attrPtr)
+		},
+
+		Value: func(ctx context.Context, opInfo OpInfo, inputValue interface{}) (outputValue interface{}, _ error) {
+			b, err := getCodec().Marshal(inputValue)
+			// SQL server uses the NVARCHAR type to store JSON and
+			// it expects to receive strings not []byte, thus:
+			if opInfo.DriverName == "sqlserver" {
+				return string(b), err
+			}
+			return b, err
+		},
+	}
+}
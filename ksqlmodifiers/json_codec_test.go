@@ -0,0 +1,110 @@
+package ksqlmodifiers
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+// mockJSONCodec mocks the JSONCodec interface
+type mockJSONCodec struct {
+	MarshalFn   func(v interface{}) ([]byte, error)
+	UnmarshalFn func(data []byte, v interface{}) error
+}
+
+func (m mockJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return m.MarshalFn(v)
+}
+
+func (m mockJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return m.UnmarshalFn(data, v)
+}
+
+func TestJSONModifier(t *testing.T) {
+	ctx := context.Background()
+
+	type FakeAttr struct {
+		Foo string `json:"foo"`
+	}
+
+	newFakeCodec := func(calls *[]string) JSONCodec {
+		return mockJSONCodec{
+			MarshalFn: func(v interface{}) ([]byte, error) {
+				*calls = append(*calls, "Marshal")
+				return []byte(`{"foo":"fromCodec"}`), nil
+			},
+			UnmarshalFn: func(data []byte, v interface{}) error {
+				*calls = append(*calls, "Unmarshal:"+string(data))
+				v.(*FakeAttr).Foo = "fromCodec"
+				return nil
+			},
+		}
+	}
+
+	t.Run("should use encoding/json by default", func(t *testing.T) {
+		modifier := NewJSONModifier(nil)
+
+		var attr FakeAttr
+		err := modifier.Scan(ctx, OpInfo{}, &attr, `{"foo":"bar"}`)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, attr, FakeAttr{Foo: "bar"})
+
+		value, err := modifier.Value(ctx, OpInfo{}, FakeAttr{Foo: "bar"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, []byte(`{"foo":"bar"}`))
+
+		value, err = modifier.Value(ctx, OpInfo{DriverName: "sqlserver"}, FakeAttr{Foo: "bar"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, `{"foo":"bar"}`)
+	})
+
+	t.Run("should use the codec passed to NewJSONModifier", func(t *testing.T) {
+		var calls []string
+		modifier := NewJSONModifier(newFakeCodec(&calls))
+
+		var attr FakeAttr
+		err := modifier.Scan(ctx, OpInfo{}, &attr, []byte(`{"foo":"bar"}`))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, attr, FakeAttr{Foo: "fromCodec"})
+
+		value, err := modifier.Value(ctx, OpInfo{}, FakeAttr{Foo: "bar"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, value, []byte(`{"foo":"fromCodec"}`))
+		tt.AssertEqual(t, calls, []string{`Unmarshal:{"foo":"bar"}`, "Marshal"})
+	})
+
+	t.Run("should use the codec set with SetJSONCodec if none is passed", func(t *testing.T) {
+		var calls []string
+		SetJSONCodec(newFakeCodec(&calls))
+		defer SetJSONCodec(nil)
+
+		modifier := NewJSONModifier(nil)
+
+		var attr FakeAttr
+		err := modifier.Scan(ctx, OpInfo{}, &attr, []byte(`{"foo":"bar"}`))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, attr, FakeAttr{Foo: "fromCodec"})
+		tt.AssertEqual(t, calls, []string{`Unmarshal:{"foo":"bar"}`})
+
+		SetJSONCodec(nil)
+		err = modifier.Scan(ctx, OpInfo{}, &attr, []byte(`{"foo":"bar"}`))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, attr, FakeAttr{Foo: "bar"})
+		tt.AssertEqual(t, calls, []string{`Unmarshal:{"foo":"bar"}`})
+	})
+
+	t.Run("should not use the global codec if a codec was passed", func(t *testing.T) {
+		var globalCalls []string
+		SetJSONCodec(newFakeCodec(&globalCalls))
+		defer SetJSONCodec(nil)
+
+		modifier := NewJSONModifier(stdJSONCodec{})
+
+		var attr FakeAttr
+		err := modifier.Scan(ctx, OpInfo{}, &attr, []byte(`{"foo":"bar"}`))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, attr, FakeAttr{Foo: "bar"})
+		tt.AssertEqual(t, len(globalCalls), 0)
+	})
+}
@@ -4,6 +4,27 @@ package ksqlmodifiers
 // it is recommended to do this inside an init() function.
 var RegisterAttrModifier func(key string, modifier AttrModifier)
 
+// RegisterScanConverter allow users to register a function for converting
+// the values returned by the database into a custom type, so shared types
+// don't need a modifier on every attribute that uses them, e.g.:
+//
+//	ksqlmodifiers.RegisterScanConverter(func(dbValue string) (Status, error) {
+//		return ParseStatus(dbValue)
+//	})
+//
+// The converter must have the format `func(dbValue S) (T, error)`, and it
+// is used when scanning attributes of type T or *T that don't have a modifier
+// with a Scan function. NULL values are scanned as the zero value of T or as
+// a nil pointer. The values received from the driver are converted to S
+// if possible, e.g. from []byte to string or from int64 to int, so S can be
+// an interface{} for handling each type separately.
+//
+// It panics if the converter has a different format or if a converter
+// was already registered for the same type, and it must be called
+// before the first query, so it is recommended to do this inside
+// an init() function.
+var RegisterScanConverter func(converter interface{})
+
 // This method is set at startup by the `internal/modifiers` package.
 // It was done that way in order to keep most of the implementation private
 // while also avoiding cyclic dependencies.
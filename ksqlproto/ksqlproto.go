@@ -0,0 +1,83 @@
+// Package ksqlproto allows KSQL to read and write the structs
+// generated by protoc-gen-go without parallel ksql-tagged structs.
+//
+// This package doesn't depend on the protobuf module, it only
+// reads the `protobuf` tags of the generated structs.
+package ksqlproto
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// Register makes KSQL map the attributes of the input protobuf-generated
+// structs to the columns with the same names of their protobuf fields, e.g.:
+//
+//	func init() {
+//		ksqlproto.Register(&pb.User{}, &pb.Address{})
+//	}
+//
+//	// Then these can be used as any other ksql-tagged struct:
+//	var users []*pb.User
+//	err := db.Query(ctx, &users, "FROM users WHERE age > $1", 18)
+//
+// The column names are read from the following tags, in this order:
+//
+// - The `ksql` tag, which also allows the use of modifiers;
+//
+// - The `db` tag, e.g. added with tools such as protoc-go-inject-tag;
+//
+// - The `name` option of the `protobuf` tag, i.e. the field name on the .proto file.
+//
+// The unexported fields generated by protoc and the oneof fields are
+// ignored, and since message fields such as *timestamppb.Timestamp
+// can't be scanned directly they need a modifier on a `ksql` tag.
+//
+// Register should be called before the structs are used, e.g. on an init function,
+// and the input records can be either structs or pointers to structs.
+func Register(records ...interface{}) error {
+	for _, record := range records {
+		t := reflect.TypeOf(record)
+		if t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			return fmt.Errorf("ksqlproto: expected a struct or a pointer to struct, but got: %T", record)
+		}
+
+		structs.RegisterColumnTagFn(t, columnTag)
+	}
+
+	return nil
+}
+
+func columnTag(field reflect.StructField) string {
+	// Fields generated by protoc for internal use, e.g. `state` and `sizeCache`:
+	if field.PkgPath != "" {
+		return ""
+	}
+
+	if tag := field.Tag.Get("ksql"); tag != "" {
+		return tag
+	}
+
+	if tag := field.Tag.Get("db"); tag != "" && tag != "-" {
+		return tag
+	}
+
+	return protobufFieldName(field.Tag.Get("protobuf"))
+}
+
+// protobufFieldName extracts the field name from tags such as:
+// `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3"`
+func protobufFieldName(tag string) string {
+	for _, option := range strings.Split(tag, ",") {
+		if strings.HasPrefix(option, "name=") {
+			return strings.TrimPrefix(option, "name=")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+package ksqlproto_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vingarcia/ksql"
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/ksqlproto"
+	"github.com/vingarcia/ksql/ksqltest"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// User mimics the structs generated by protoc-gen-go
+type User struct {
+	state         struct{}
+	sizeCache     int32
+	unknownFields []byte
+
+	Id       int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserName string `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+	Age      int32  `protobuf:"varint,3,opt,name=age,proto3" json:"age,omitempty" db:"user_age"`
+	Address  []byte `protobuf:"bytes,4,opt,name=address,proto3" json:"address,omitempty" ksql:"address_json"`
+
+	// Types that are assignable to Contact:
+	//	*User_Email
+	Contact isUser_Contact `protobuf_oneof:"contact"`
+}
+
+type isUser_Contact interface{}
+
+func TestRegister(t *testing.T) {
+	err := ksqlproto.Register(&User{})
+	tt.AssertNoErr(t, err)
+
+	t.Run("should map the columns using the protobuf names", func(t *testing.T) {
+		m, err := ksqltest.StructToMap(User{
+			// The internal fields should be ignored:
+			state:         struct{}{},
+			sizeCache:     10,
+			unknownFields: []byte("fakeUnknownFields"),
+
+			Id:       42,
+			UserName: "fakeName",
+			Age:      24,
+			Address:  []byte("fakeAddress"),
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, m, map[string]interface{}{
+			"id":           int64(42),
+			"user_name":    "fakeName",
+			"user_age":     int32(24),
+			"address_json": []byte("fakeAddress"),
+		})
+	})
+
+	t.Run("should fill the struct with the database rows", func(t *testing.T) {
+		var u User
+		err := ksqltest.FillStructWith(&u, map[string]interface{}{
+			"id":        42,
+			"user_name": "fakeName",
+			"user_age":  24,
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u.Id, int64(42))
+		tt.AssertEqual(t, u.UserName, "fakeName")
+		tt.AssertEqual(t, u.Age, int32(24))
+	})
+
+	t.Run("should build queries with the protobuf names", func(t *testing.T) {
+		query, _, err := ksql.BuildPatchQuery(
+			context.Background(),
+			sqldialect.SupportedDialects["postgres"],
+			ksql.NewTable("users"),
+			User{Id: 42, UserName: "fakeName"},
+		)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `UPDATE users SET "user_name" = $1, "user_age" = $2, "address_json" = $3 WHERE "id" = $4`)
+	})
+
+	t.Run("should report invalid records", func(t *testing.T) {
+		err := ksqlproto.Register(42)
+		tt.AssertErrContains(t, err, "ksqlproto", "struct", "int")
+	})
+}
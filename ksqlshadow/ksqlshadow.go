@@ -0,0 +1,356 @@
+// Package ksqlshadow implements a ksql.Provider that mirrors all operations
+// to a shadow database and reports when the results of the two databases
+// diverge, which is useful for testing database migrations with real
+// traffic, e.g. when moving from Postgres to CockroachDB.
+package ksqlshadow
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/vingarcia/ksql"
+)
+
+// DivergenceKind describes how the results of the shadow
+// database diverged from the results of the primary one
+type DivergenceKind string
+
+const (
+	// ErrorDivergence means that only one of the databases returned an
+	// error, or that only one of them returned ksql.ErrRecordNotFound
+	ErrorDivergence DivergenceKind = "error"
+
+	// ResultDivergence means that both databases succeeded but returned
+	// different records or, for Exec, a different number of affected rows
+	ResultDivergence DivergenceKind = "result"
+
+	// LatencyDivergence means that the shadow database was slower than the
+	// primary one by more than the Options.LatencyTolerance
+	LatencyDivergence DivergenceKind = "latency"
+)
+
+// Divergence describes a single operation whose results
+// diverged between the primary and the shadow databases
+type Divergence struct {
+	Kind DivergenceKind
+
+	// Method is the name of the ksql.Provider method, e.g. "Query"
+	Method string
+
+	// Table is the name of the table used by the Insert,
+	// Patch and Delete methods and empty for the others
+	Table string
+
+	// Query is the query used by the Query, QueryOne
+	// and Exec methods and empty for the others
+	Query  string
+	Params []interface{}
+
+	PrimaryErr error
+	ShadowErr  error
+
+	// PrimaryResult and ShadowResult are the records read by the Query
+	// and QueryOne methods, or the number of rows affected for Exec
+	PrimaryResult interface{}
+	ShadowResult  interface{}
+
+	PrimaryLatency time.Duration
+	ShadowLatency  time.Duration
+}
+
+// Reporter is called once for each operation that diverged
+type Reporter func(ctx context.Context, d Divergence)
+
+// Options can be passed to New for customizing
+// how the divergences are detected
+type Options struct {
+	// LatencyTolerance is how much slower than the primary database the
+	// shadow database is allowed to be before a LatencyDivergence is
+	// reported, if it is zero latencies are not compared.
+	LatencyTolerance time.Duration
+}
+
+// Provider implements the ksql.Provider interface
+// by running all operations on both databases.
+//
+// The results of the primary database are always the ones returned
+// to the caller, and the errors of the shadow database are only reported.
+type Provider struct {
+	primary  ksql.Provider
+	shadow   ksql.Provider
+	reporter Reporter
+	options  Options
+}
+
+var _ ksql.Provider = Provider{}
+
+// New builds a Provider that runs all operations on the primary database
+// and then mirrors them on the shadow database, calling the reporter
+// whenever the shadow results diverge from the primary ones.
+//
+// Note that the Query method compares the records in order, so queries
+// without an ORDER BY clause might be reported as divergent, and that
+// QueryChunks is not mirrored since its callbacks usually have side
+// effects that should not run twice.
+func New(primary ksql.Provider, shadow ksql.Provider, reporter Reporter, options ...Options) Provider {
+	var opts Options
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	return Provider{
+		primary:  primary,
+		shadow:   shadow,
+		reporter: reporter,
+		options:  opts,
+	}
+}
+
+// Insert inserts the record on both databases.
+//
+// The shadow database receives a copy of the record after it was
+// inserted on the primary database, so the IDs generated by the
+// primary database are also used by the shadow one.
+func (p Provider) Insert(ctx context.Context, table ksql.Table, record interface{}) error {
+	startedAt := time.Now()
+	err := p.primary.Insert(ctx, table, record)
+	primaryLatency := time.Since(startedAt)
+
+	startedAt = time.Now()
+	shadowErr := p.shadow.Insert(ctx, table, copyStruct(record))
+	shadowLatency := time.Since(startedAt)
+
+	p.compare(ctx, Divergence{
+		Method:         "Insert",
+		Table:          table.Name(),
+		PrimaryErr:     err,
+		ShadowErr:      shadowErr,
+		PrimaryLatency: primaryLatency,
+		ShadowLatency:  shadowLatency,
+	})
+
+	return err
+}
+
+// Patch updates the record on both databases
+func (p Provider) Patch(ctx context.Context, table ksql.Table, record interface{}) error {
+	startedAt := time.Now()
+	err := p.primary.Patch(ctx, table, record)
+	primaryLatency := time.Since(startedAt)
+
+	startedAt = time.Now()
+	shadowErr := p.shadow.Patch(ctx, table, record)
+	shadowLatency := time.Since(startedAt)
+
+	p.compare(ctx, Divergence{
+		Method:         "Patch",
+		Table:          table.Name(),
+		PrimaryErr:     err,
+		ShadowErr:      shadowErr,
+		PrimaryLatency: primaryLatency,
+		ShadowLatency:  shadowLatency,
+	})
+
+	return err
+}
+
+// Delete deletes the record from both databases
+func (p Provider) Delete(ctx context.Context, table ksql.Table, idOrRecord interface{}) error {
+	startedAt := time.Now()
+	err := p.primary.Delete(ctx, table, idOrRecord)
+	primaryLatency := time.Since(startedAt)
+
+	startedAt = time.Now()
+	shadowErr := p.shadow.Delete(ctx, table, idOrRecord)
+	shadowLatency := time.Since(startedAt)
+
+	p.compare(ctx, Divergence{
+		Method:         "Delete",
+		Table:          table.Name(),
+		PrimaryErr:     err,
+		ShadowErr:      shadowErr,
+		PrimaryLatency: primaryLatency,
+		ShadowLatency:  shadowLatency,
+	})
+
+	return err
+}
+
+// Query runs the query on both databases and compares the records
+// read by each of them, only the primary records are returned.
+func (p Provider) Query(ctx context.Context, records interface{}, query string, params ...interface{}) error {
+	startedAt := time.Now()
+	err := p.primary.Query(ctx, records, query, params...)
+	primaryLatency := time.Since(startedAt)
+
+	shadowRecords, ok := newLike(records)
+	if !ok {
+		// The input is invalid so the primary database
+		// has already returned the appropriate error
+		return err
+	}
+
+	startedAt = time.Now()
+	shadowErr := p.shadow.Query(ctx, shadowRecords, query, params...)
+	shadowLatency := time.Since(startedAt)
+
+	p.compare(ctx, Divergence{
+		Method:         "Query",
+		Query:          query,
+		Params:         params,
+		PrimaryErr:     err,
+		ShadowErr:      shadowErr,
+		PrimaryResult:  reflect.ValueOf(records).Elem().Interface(),
+		ShadowResult:   reflect.ValueOf(shadowRecords).Elem().Interface(),
+		PrimaryLatency: primaryLatency,
+		ShadowLatency:  shadowLatency,
+	})
+
+	return err
+}
+
+// QueryOne runs the query on both databases and compares the record
+// read by each of them, only the primary record is returned.
+func (p Provider) QueryOne(ctx context.Context, record interface{}, query string, params ...interface{}) error {
+	startedAt := time.Now()
+	err := p.primary.QueryOne(ctx, record, query, params...)
+	primaryLatency := time.Since(startedAt)
+
+	shadowRecord, ok := newLike(record)
+	if !ok {
+		return err
+	}
+
+	startedAt = time.Now()
+	shadowErr := p.shadow.QueryOne(ctx, shadowRecord, query, params...)
+	shadowLatency := time.Since(startedAt)
+
+	p.compare(ctx, Divergence{
+		Method:         "QueryOne",
+		Query:          query,
+		Params:         params,
+		PrimaryErr:     err,
+		ShadowErr:      shadowErr,
+		PrimaryResult:  reflect.ValueOf(record).Elem().Interface(),
+		ShadowResult:   reflect.ValueOf(shadowRecord).Elem().Interface(),
+		PrimaryLatency: primaryLatency,
+		ShadowLatency:  shadowLatency,
+	})
+
+	return err
+}
+
+// QueryChunks only runs on the primary database, since the
+// ForEachChunk callbacks usually have side effects that
+// should not run twice.
+func (p Provider) QueryChunks(ctx context.Context, parser ksql.ChunkParser) error {
+	return p.primary.QueryChunks(ctx, parser)
+}
+
+// Exec runs the query on both databases and compares
+// the number of rows affected on each of them.
+func (p Provider) Exec(ctx context.Context, query string, params ...interface{}) (ksql.Result, error) {
+	startedAt := time.Now()
+	result, err := p.primary.Exec(ctx, query, params...)
+	primaryLatency := time.Since(startedAt)
+
+	startedAt = time.Now()
+	shadowResult, shadowErr := p.shadow.Exec(ctx, query, params...)
+	shadowLatency := time.Since(startedAt)
+
+	d := Divergence{
+		Method:         "Exec",
+		Query:          query,
+		Params:         params,
+		PrimaryErr:     err,
+		ShadowErr:      shadowErr,
+		PrimaryLatency: primaryLatency,
+		ShadowLatency:  shadowLatency,
+	}
+	if err == nil && shadowErr == nil {
+		d.PrimaryResult, _ = result.RowsAffected()
+		d.ShadowResult, _ = shadowResult.RowsAffected()
+	}
+	p.compare(ctx, d)
+
+	return result, err
+}
+
+// Transaction starts a transaction on each database and calls fn
+// with a Provider that mirrors the operations between them.
+//
+// The shadow transaction is committed right before the primary one,
+// and if only one of the commits fails an ErrorDivergence is reported.
+func (p Provider) Transaction(ctx context.Context, fn func(ksql.Provider) error) error {
+	var fnErr error
+	var shadowErr error
+	err := p.primary.Transaction(ctx, func(primaryTx ksql.Provider) error {
+		shadowErr = p.shadow.Transaction(ctx, func(shadowTx ksql.Provider) error {
+			fnErr = fn(Provider{
+				primary:  primaryTx,
+				shadow:   shadowTx,
+				reporter: p.reporter,
+				options:  p.options,
+			})
+			return fnErr
+		})
+
+		// Errors caused by fn are returned by both transactions,
+		// so we only return errors caused by the shadow database
+		// when the fn itself has failed:
+		return fnErr
+	})
+
+	if fnErr == nil {
+		p.compare(ctx, Divergence{
+			Method:     "Transaction",
+			PrimaryErr: err,
+			ShadowErr:  shadowErr,
+		})
+	}
+
+	return err
+}
+
+// compare calls the reporter if the primary and shadow results diverged
+func (p Provider) compare(ctx context.Context, d Divergence) {
+	switch {
+	case (d.PrimaryErr == nil) != (d.ShadowErr == nil),
+		errors.Is(d.PrimaryErr, ksql.ErrRecordNotFound) != errors.Is(d.ShadowErr, ksql.ErrRecordNotFound):
+		d.Kind = ErrorDivergence
+	case d.PrimaryErr == nil && !reflect.DeepEqual(d.PrimaryResult, d.ShadowResult):
+		d.Kind = ResultDivergence
+	case p.options.LatencyTolerance > 0 && d.ShadowLatency-d.PrimaryLatency > p.options.LatencyTolerance:
+		d.Kind = LatencyDivergence
+	default:
+		return
+	}
+
+	p.reporter(ctx, d)
+}
+
+// newLike returns a pointer to a new zero value of the same type
+// the input points to, or false if it is not a valid pointer.
+func newLike(ptr interface{}) (interface{}, bool) {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil, false
+	}
+
+	return reflect.New(v.Type().Elem()).Interface(), true
+}
+
+// copyStruct returns a pointer to a shallow copy of the input
+// if it is a pointer to struct, otherwise it returns the input.
+func copyStruct(record interface{}) interface{} {
+	v := reflect.ValueOf(record)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return record
+	}
+
+	c := reflect.New(v.Type().Elem())
+	c.Elem().Set(v.Elem())
+	return c.Interface()
+}
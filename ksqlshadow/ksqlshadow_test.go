@@ -0,0 +1,312 @@
+package ksqlshadow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vingarcia/ksql"
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/ksqltest"
+)
+
+type user struct {
+	ID   int    `ksql:"id"`
+	Name string `ksql:"name"`
+}
+
+var usersTable = ksql.NewTable("users")
+
+func newQueryMock(rows []map[string]interface{}, err error) ksql.Mock {
+	return ksql.Mock{
+		QueryFn: func(ctx context.Context, records interface{}, query string, params ...interface{}) error {
+			if err != nil {
+				return err
+			}
+			return ksqltest.FillSliceWith(records, rows)
+		},
+		QueryOneFn: func(ctx context.Context, record interface{}, query string, params ...interface{}) error {
+			if err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				return ksql.ErrRecordNotFound
+			}
+			return ksqltest.FillStructWith(record, rows[0])
+		},
+	}
+}
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should not report when both databases return the same records", func(t *testing.T) {
+		rows := []map[string]interface{}{{"id": 1, "name": "fakeName"}}
+
+		var divergences []Divergence
+		db := New(newQueryMock(rows, nil), newQueryMock(rows, nil), func(ctx context.Context, d Divergence) {
+			divergences = append(divergences, d)
+		})
+
+		var users []user
+		err := db.Query(ctx, &users, "FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, users, []user{{ID: 1, Name: "fakeName"}})
+
+		var u user
+		err = db.QueryOne(ctx, &u, "FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u, user{ID: 1, Name: "fakeName"})
+
+		tt.AssertEqual(t, len(divergences), 0)
+	})
+
+	t.Run("should report different records and return the primary ones", func(t *testing.T) {
+		primary := newQueryMock([]map[string]interface{}{{"id": 1, "name": "primaryName"}}, nil)
+		shadow := newQueryMock([]map[string]interface{}{{"id": 1, "name": "shadowName"}}, nil)
+
+		var divergences []Divergence
+		db := New(primary, shadow, func(ctx context.Context, d Divergence) {
+			divergences = append(divergences, d)
+		})
+
+		var users []user
+		err := db.Query(ctx, &users, "FROM users WHERE id = $1", 1)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, users, []user{{ID: 1, Name: "primaryName"}})
+
+		tt.AssertEqual(t, len(divergences), 1)
+		tt.AssertEqual(t, divergences[0].Kind, ResultDivergence)
+		tt.AssertEqual(t, divergences[0].Method, "Query")
+		tt.AssertEqual(t, divergences[0].Query, "FROM users WHERE id = $1")
+		tt.AssertEqual(t, divergences[0].Params, []interface{}{1})
+		tt.AssertEqual(t, divergences[0].PrimaryResult, []user{{ID: 1, Name: "primaryName"}})
+		tt.AssertEqual(t, divergences[0].ShadowResult, []user{{ID: 1, Name: "shadowName"}})
+	})
+
+	t.Run("should report errors from the shadow database without returning them", func(t *testing.T) {
+		rows := []map[string]interface{}{{"id": 1, "name": "fakeName"}}
+
+		var divergences []Divergence
+		db := New(newQueryMock(rows, nil), newQueryMock(nil, nil), func(ctx context.Context, d Divergence) {
+			divergences = append(divergences, d)
+		})
+
+		var u user
+		err := db.QueryOne(ctx, &u, "FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u, user{ID: 1, Name: "fakeName"})
+
+		tt.AssertEqual(t, len(divergences), 1)
+		tt.AssertEqual(t, divergences[0].Kind, ErrorDivergence)
+		tt.AssertEqual(t, divergences[0].Method, "QueryOne")
+		tt.AssertEqual(t, divergences[0].ShadowErr, ksql.ErrRecordNotFound)
+	})
+
+	t.Run("should not report when both databases fail", func(t *testing.T) {
+		var divergences []Divergence
+		db := New(
+			newQueryMock(nil, errors.New("fakePrimaryErr")),
+			newQueryMock(nil, errors.New("fakeShadowErr")),
+			func(ctx context.Context, d Divergence) {
+				divergences = append(divergences, d)
+			},
+		)
+
+		var users []user
+		err := db.Query(ctx, &users, "FROM users")
+		tt.AssertErrContains(t, err, "fakePrimaryErr")
+		tt.AssertEqual(t, len(divergences), 0)
+	})
+
+	t.Run("should report latency divergences", func(t *testing.T) {
+		shadow := newQueryMock(nil, nil)
+		shadow.QueryFn = func(ctx context.Context, records interface{}, query string, params ...interface{}) error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		}
+
+		var divergences []Divergence
+		db := New(newQueryMock(nil, nil), shadow, func(ctx context.Context, d Divergence) {
+			divergences = append(divergences, d)
+		}, Options{
+			LatencyTolerance: 5 * time.Millisecond,
+		})
+
+		var users []user
+		err := db.Query(ctx, &users, "FROM users")
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, len(divergences), 1)
+		tt.AssertEqual(t, divergences[0].Kind, LatencyDivergence)
+		tt.AssertEqual(t, divergences[0].ShadowLatency > 20*time.Millisecond, true)
+	})
+}
+
+func TestWrites(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should insert a copy of the record with the primary IDs on the shadow database", func(t *testing.T) {
+		var shadowRecord *user
+		primary := ksql.Mock{
+			InsertFn: func(ctx context.Context, table ksql.Table, record interface{}) error {
+				record.(*user).ID = 42
+				return nil
+			},
+		}
+		shadow := ksql.Mock{
+			InsertFn: func(ctx context.Context, table ksql.Table, record interface{}) error {
+				shadowRecord = record.(*user)
+				return nil
+			},
+		}
+
+		var divergences []Divergence
+		db := New(primary, shadow, func(ctx context.Context, d Divergence) {
+			divergences = append(divergences, d)
+		})
+
+		u := user{Name: "fakeName"}
+		err := db.Insert(ctx, usersTable, &u)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, u, user{ID: 42, Name: "fakeName"})
+		tt.AssertEqual(t, *shadowRecord, user{ID: 42, Name: "fakeName"})
+		tt.AssertEqual(t, shadowRecord != &u, true)
+		tt.AssertEqual(t, len(divergences), 0)
+	})
+
+	t.Run("should report write errors on the shadow database", func(t *testing.T) {
+		primary := ksql.Mock{
+			PatchFn: func(ctx context.Context, table ksql.Table, record interface{}) error {
+				return nil
+			},
+			DeleteFn: func(ctx context.Context, table ksql.Table, idOrRecord interface{}) error {
+				return nil
+			},
+		}
+		shadow := ksql.Mock{
+			PatchFn: func(ctx context.Context, table ksql.Table, record interface{}) error {
+				return errors.New("fakePatchErr")
+			},
+			DeleteFn: func(ctx context.Context, table ksql.Table, idOrRecord interface{}) error {
+				return errors.New("fakeDeleteErr")
+			},
+		}
+
+		var divergences []Divergence
+		db := New(primary, shadow, func(ctx context.Context, d Divergence) {
+			divergences = append(divergences, d)
+		})
+
+		err := db.Patch(ctx, usersTable, &user{ID: 1, Name: "fakeName"})
+		tt.AssertNoErr(t, err)
+		err = db.Delete(ctx, usersTable, 1)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, len(divergences), 2)
+		tt.AssertEqual(t, divergences[0].Method, "Patch")
+		tt.AssertEqual(t, divergences[0].Table, "users")
+		tt.AssertErrContains(t, divergences[0].ShadowErr, "fakePatchErr")
+		tt.AssertEqual(t, divergences[1].Method, "Delete")
+		tt.AssertErrContains(t, divergences[1].ShadowErr, "fakeDeleteErr")
+	})
+
+	t.Run("should compare the rows affected by Exec", func(t *testing.T) {
+		newExecMock := func(rowsAffected int64) ksql.Mock {
+			return ksql.Mock{
+				ExecFn: func(ctx context.Context, query string, params ...interface{}) (ksql.Result, error) {
+					return ksql.MockResult{
+						RowsAffectedFn: func() (int64, error) {
+							return rowsAffected, nil
+						},
+					}, nil
+				},
+			}
+		}
+
+		var divergences []Divergence
+		db := New(newExecMock(2), newExecMock(1), func(ctx context.Context, d Divergence) {
+			divergences = append(divergences, d)
+		})
+
+		result, err := db.Exec(ctx, "UPDATE users SET name = $1", "fakeName")
+		tt.AssertNoErr(t, err)
+		n, err := result.RowsAffected()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, n, int64(2))
+
+		tt.AssertEqual(t, len(divergences), 1)
+		tt.AssertEqual(t, divergences[0].Kind, ResultDivergence)
+		tt.AssertEqual(t, divergences[0].PrimaryResult, int64(2))
+		tt.AssertEqual(t, divergences[0].ShadowResult, int64(1))
+	})
+}
+
+func TestTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	newTxMock := func(calls *[]string, name string, commitErr error) ksql.Mock {
+		var m ksql.Mock
+		m = ksql.Mock{
+			InsertFn: func(ctx context.Context, table ksql.Table, record interface{}) error {
+				*calls = append(*calls, name+" insert")
+				return nil
+			},
+			TransactionFn: func(ctx context.Context, fn func(db ksql.Provider) error) error {
+				err := fn(m)
+				if err != nil {
+					return err
+				}
+				*calls = append(*calls, name+" commit")
+				return commitErr
+			},
+		}
+		return m
+	}
+
+	t.Run("should mirror the operations inside transactions", func(t *testing.T) {
+		var calls []string
+		var divergences []Divergence
+		db := New(newTxMock(&calls, "primary", nil), newTxMock(&calls, "shadow", nil), func(ctx context.Context, d Divergence) {
+			divergences = append(divergences, d)
+		})
+
+		err := db.Transaction(ctx, func(db ksql.Provider) error {
+			return db.Insert(ctx, usersTable, &user{Name: "fakeName"})
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{"primary insert", "shadow insert", "shadow commit", "primary commit"})
+		tt.AssertEqual(t, len(divergences), 0)
+	})
+
+	t.Run("should report if only the shadow transaction fails", func(t *testing.T) {
+		var calls []string
+		var divergences []Divergence
+		db := New(newTxMock(&calls, "primary", nil), newTxMock(&calls, "shadow", errors.New("fakeCommitErr")), func(ctx context.Context, d Divergence) {
+			divergences = append(divergences, d)
+		})
+
+		err := db.Transaction(ctx, func(db ksql.Provider) error {
+			return nil
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(divergences), 1)
+		tt.AssertEqual(t, divergences[0].Method, "Transaction")
+		tt.AssertErrContains(t, divergences[0].ShadowErr, "fakeCommitErr")
+	})
+
+	t.Run("should not report errors returned by fn", func(t *testing.T) {
+		var calls []string
+		var divergences []Divergence
+		db := New(newTxMock(&calls, "primary", nil), newTxMock(&calls, "shadow", nil), func(ctx context.Context, d Divergence) {
+			divergences = append(divergences, d)
+		})
+
+		err := db.Transaction(ctx, func(db ksql.Provider) error {
+			return errors.New("fakeFnErr")
+		})
+		tt.AssertErrContains(t, err, "fakeFnErr")
+		tt.AssertEqual(t, len(divergences), 0)
+	})
+}
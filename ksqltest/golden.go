@@ -0,0 +1,241 @@
+package ksqltest
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vingarcia/ksql"
+)
+
+// UpdateGoldenEnvVar is the name of the environment variable that,
+// when set to "true", makes AssertGolden rewrite the golden files
+// instead of comparing them with the recorded queries.
+const UpdateGoldenEnvVar = "KSQL_UPDATE_GOLDEN"
+
+// QueryRecorder is a ksql.DBAdapter that records all
+// the queries generated by KSQL so they can be compared
+// against golden files with the AssertGolden function.
+//
+// If an Adapter is set the queries are also forwarded to it,
+// otherwise the recorder responds without accessing any database:
+// Exec calls report 1 row affected with LastInsertId = 1, INSERT queries
+// return a single empty row (so ID retrieval works) and any other
+// query returns no rows.
+//
+// Example Usage:
+//
+//	recorder := ksqltest.NewQueryRecorder(nil)
+//	db, _ := ksql.NewWithAdapter(recorder, sqldialect.PostgresDialect{})
+//
+//	_ = usersRepo.New(db).UpdateName(ctx, userID, "NewName")
+//
+//	ksqltest.AssertGolden(t, "testdata/update_name.golden.sql", recorder.Queries())
+type QueryRecorder struct {
+	// Adapter is optional and, if set,
+	// will receive all the recorded queries
+	Adapter ksql.DBAdapter
+
+	records *queryRecords
+}
+
+type queryRecords struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+var _ ksql.DBAdapter = QueryRecorder{}
+var _ ksql.TxBeginner = QueryRecorder{}
+
+// NewQueryRecorder instantiates a new QueryRecorder
+// optionally forwarding the queries to the input adapter.
+func NewQueryRecorder(adapter ksql.DBAdapter) QueryRecorder {
+	return QueryRecorder{
+		Adapter: adapter,
+		records: &queryRecords{},
+	}
+}
+
+// Queries returns all the queries recorded so far in the order they were executed
+func (q QueryRecorder) Queries() []string {
+	q.records.mu.Lock()
+	defer q.records.mu.Unlock()
+
+	return append([]string{}, q.records.queries...)
+}
+
+// Reset discards all the queries recorded so far
+func (q QueryRecorder) Reset() {
+	q.records.mu.Lock()
+	defer q.records.mu.Unlock()
+
+	q.records.queries = nil
+}
+
+func (q QueryRecorder) record(query string) {
+	q.records.mu.Lock()
+	defer q.records.mu.Unlock()
+
+	q.records.queries = append(q.records.queries, query)
+}
+
+// ExecContext implements the ksql.DBAdapter interface
+func (q QueryRecorder) ExecContext(ctx context.Context, query string, args ...interface{}) (ksql.Result, error) {
+	q.record(query)
+	if q.Adapter != nil {
+		return q.Adapter.ExecContext(ctx, query, args...)
+	}
+
+	return recordedResult{}, nil
+}
+
+// QueryContext implements the ksql.DBAdapter interface
+func (q QueryRecorder) QueryContext(ctx context.Context, query string, args ...interface{}) (ksql.Rows, error) {
+	q.record(query)
+	if q.Adapter != nil {
+		return q.Adapter.QueryContext(ctx, query, args...)
+	}
+
+	numRows := 0
+	if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "INSERT") {
+		numRows = 1
+	}
+	return &recordedRows{numRows: numRows}, nil
+}
+
+// BeginTx implements the ksql.TxBeginner interface
+//
+// The BEGIN, COMMIT and ROLLBACK statements are
+// also recorded to make the transaction boundaries visible.
+func (q QueryRecorder) BeginTx(ctx context.Context) (ksql.Tx, error) {
+	q.record("BEGIN")
+
+	var tx ksql.Tx
+	if txBeginner, ok := q.Adapter.(ksql.TxBeginner); ok {
+		var err error
+		tx, err = txBeginner.BeginTx(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return recordedTx{
+		QueryRecorder: QueryRecorder{
+			Adapter: tx,
+			records: q.records,
+		},
+		tx: tx,
+	}, nil
+}
+
+type recordedTx struct {
+	QueryRecorder
+	tx ksql.Tx
+}
+
+func (r recordedTx) Commit(ctx context.Context) error {
+	r.record("COMMIT")
+	if r.tx != nil {
+		return r.tx.Commit(ctx)
+	}
+	return nil
+}
+
+func (r recordedTx) Rollback(ctx context.Context) error {
+	r.record("ROLLBACK")
+	if r.tx != nil {
+		return r.tx.Rollback(ctx)
+	}
+	return nil
+}
+
+type recordedResult struct{}
+
+func (recordedResult) LastInsertId() (int64, error) {
+	return 1, nil
+}
+
+func (recordedResult) RowsAffected() (int64, error) {
+	return 1, nil
+}
+
+type recordedRows struct {
+	numRows int
+}
+
+func (r *recordedRows) Scan(args ...interface{}) error {
+	return nil
+}
+
+func (r *recordedRows) Close() error {
+	return nil
+}
+
+func (r *recordedRows) Next() bool {
+	r.numRows--
+	return r.numRows >= 0
+}
+
+func (r *recordedRows) Err() error {
+	return nil
+}
+
+func (r *recordedRows) Columns() ([]string, error) {
+	return nil, nil
+}
+
+// AssertGolden compares the input queries with the contents of the golden file,
+// failing the test if they differ.
+//
+// When the environment variable described by UpdateGoldenEnvVar is set to "true"
+// the golden file is (re)written with the input queries instead,
+// which is the recommended way of creating or updating these files.
+func AssertGolden(t testing.TB, goldenFile string, queries []string) {
+	t.Helper()
+
+	got := formatGolden(queries)
+
+	if os.Getenv(UpdateGoldenEnvVar) == "true" {
+		err := os.MkdirAll(filepath.Dir(goldenFile), 0755)
+		if err != nil {
+			t.Fatalf("AssertGolden: unable to create directory for golden file: %s", err)
+		}
+
+		err = ioutil.WriteFile(goldenFile, []byte(got), 0644)
+		if err != nil {
+			t.Fatalf("AssertGolden: unable to write golden file: %s", err)
+		}
+		return
+	}
+
+	expected, err := ioutil.ReadFile(goldenFile)
+	if err != nil {
+		t.Fatalf(
+			"AssertGolden: unable to read golden file, run the tests with %s=true to create it: %s",
+			UpdateGoldenEnvVar, err,
+		)
+	}
+
+	if got != string(expected) {
+		t.Errorf(
+			"AssertGolden: the queries don't match the golden file `%s`"+
+				" (run the tests with %s=true to update it)\n\nexpected:\n%s\ngot:\n%s",
+			goldenFile, UpdateGoldenEnvVar, string(expected), got,
+		)
+	}
+}
+
+// formatGolden writes one query per paragraph,
+// so diffs on golden files are easy to review.
+func formatGolden(queries []string) string {
+	var b strings.Builder
+	for _, query := range queries {
+		b.WriteString(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+		b.WriteString(";\n\n")
+	}
+	return b.String()
+}
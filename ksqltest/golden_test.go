@@ -0,0 +1,137 @@
+package ksqltest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vingarcia/ksql"
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+type goldenUser struct {
+	ID   int    `ksql:"id"`
+	Name string `ksql:"name"`
+	Age  int    `ksql:"age"`
+}
+
+var goldenUsersTable = ksql.NewTable("users")
+
+func runGoldenCodePath(ctx context.Context, db ksql.Provider) error {
+	return db.Transaction(ctx, func(db ksql.Provider) error {
+		user := goldenUser{Name: "fakeName", Age: 42}
+		err := db.Insert(ctx, goldenUsersTable, &user)
+		if err != nil {
+			return err
+		}
+
+		err = db.Patch(ctx, goldenUsersTable, &goldenUser{ID: 1, Name: "newName", Age: 43})
+		if err != nil {
+			return err
+		}
+
+		var users []goldenUser
+		err = db.Query(ctx, &users, "FROM users WHERE age > $1", 18)
+		if err != nil {
+			return err
+		}
+
+		return db.Delete(ctx, goldenUsersTable, 1)
+	})
+}
+
+type fakeTB struct {
+	testing.TB
+	errMsg string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errMsg = fmt.Sprintf(format, args...)
+}
+
+func (f *fakeTB) Fatalf(format string, args ...interface{}) {
+	f.errMsg = fmt.Sprintf(format, args...)
+}
+
+func TestQueryRecorder(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should record all the queries in order", func(t *testing.T) {
+		recorder := NewQueryRecorder(nil)
+		db, err := ksql.NewWithAdapter(recorder, sqldialect.PostgresDialect{})
+		tt.AssertNoErr(t, err)
+
+		err = runGoldenCodePath(ctx, db)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, recorder.Queries(), []string{
+			"BEGIN",
+			`INSERT INTO users ("name", "age") VALUES ($1, $2) RETURNING "id"`,
+			`UPDATE users SET "name" = $1, "age" = $2 WHERE "id" = $3`,
+			`SELECT "id", "name", "age" FROM users WHERE age > $1`,
+			`DELETE FROM users WHERE "id" = $1`,
+			"COMMIT",
+		})
+
+		recorder.Reset()
+		tt.AssertEqual(t, len(recorder.Queries()), 0)
+	})
+
+	t.Run("should record the rollback of transactions", func(t *testing.T) {
+		recorder := NewQueryRecorder(nil)
+		db, err := ksql.NewWithAdapter(recorder, sqldialect.PostgresDialect{})
+		tt.AssertNoErr(t, err)
+
+		err = db.Transaction(ctx, func(db ksql.Provider) error {
+			_, err := db.Exec(ctx, "fake-query")
+			tt.AssertNoErr(t, err)
+			return fmt.Errorf("fakeErrMsg")
+		})
+		tt.AssertErrContains(t, err, "fakeErrMsg")
+
+		tt.AssertEqual(t, recorder.Queries(), []string{"BEGIN", "fake-query", "ROLLBACK"})
+	})
+}
+
+func TestAssertGolden(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should match the golden file", func(t *testing.T) {
+		recorder := NewQueryRecorder(nil)
+		db, err := ksql.NewWithAdapter(recorder, sqldialect.PostgresDialect{})
+		tt.AssertNoErr(t, err)
+
+		err = runGoldenCodePath(ctx, db)
+		tt.AssertNoErr(t, err)
+
+		AssertGolden(t, "testdata/code_path.golden.sql", recorder.Queries())
+	})
+
+	t.Run("should report differences with the golden file", func(t *testing.T) {
+		fake := &fakeTB{TB: t}
+		AssertGolden(fake, "testdata/code_path.golden.sql", []string{"SELECT 1"})
+		tt.AssertContains(t, fake.errMsg, "AssertGolden", "code_path.golden.sql", "SELECT 1", UpdateGoldenEnvVar)
+	})
+
+	t.Run("should write the golden file when the update env var is set", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "ksqltest")
+		tt.AssertNoErr(t, err)
+		defer os.RemoveAll(dir)
+
+		os.Setenv(UpdateGoldenEnvVar, "true")
+		defer os.Unsetenv(UpdateGoldenEnvVar)
+
+		goldenFile := filepath.Join(dir, "subdir", "queries.golden.sql")
+		AssertGolden(t, goldenFile, []string{"SELECT 1", "SELECT 2;"})
+
+		content, err := ioutil.ReadFile(goldenFile)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, string(content), "SELECT 1;\n\nSELECT 2;\n\n")
+	})
+}
@@ -0,0 +1,79 @@
+package ksqltest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/vingarcia/ksql"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// AssertInsertQuery builds the query ksql.DB.Insert would run for the input
+// record and fails the test if the query or its params differ from the expected ones.
+//
+// It doesn't access any database, so it can be used for unit testing
+// the mapping between your structs and your tables, e.g.:
+//
+//	ksqltest.AssertInsertQuery(t, sqldialect.PostgresDialect{}, usersTable, &User{Name: "Jane"},
+//		`INSERT INTO users ("name") VALUES ($1) RETURNING "id"`,
+//		[]interface{}{"Jane"},
+//	)
+func AssertInsertQuery(
+	t testing.TB,
+	dialect sqldialect.Provider,
+	table ksql.Table,
+	record interface{},
+	wantQuery string,
+	wantParams []interface{},
+) {
+	t.Helper()
+
+	query, params, _, err := ksql.BuildInsertQuery(context.Background(), dialect, table, record)
+	if err != nil {
+		t.Fatalf("AssertInsertQuery: unable to build the insert query: %s", err)
+		return
+	}
+
+	assertQuery(t, "AssertInsertQuery", query, params, wantQuery, wantParams)
+}
+
+// AssertPatchQuery builds the query ksql.DB.Patch would run for the input
+// record and fails the test if the query or its params differ from the expected ones.
+//
+// It doesn't access any database, so it can be used for unit testing
+// the mapping between your structs and your tables.
+func AssertPatchQuery(
+	t testing.TB,
+	dialect sqldialect.Provider,
+	table ksql.Table,
+	record interface{},
+	wantQuery string,
+	wantParams []interface{},
+) {
+	t.Helper()
+
+	query, params, err := ksql.BuildPatchQuery(context.Background(), dialect, table, record)
+	if err != nil {
+		t.Fatalf("AssertPatchQuery: unable to build the patch query: %s", err)
+		return
+	}
+
+	assertQuery(t, "AssertPatchQuery", query, params, wantQuery, wantParams)
+}
+
+func assertQuery(t testing.TB, funcName string, query string, params []interface{}, wantQuery string, wantParams []interface{}) {
+	t.Helper()
+
+	if query != wantQuery {
+		t.Errorf("%s: unexpected query\n\nexpected:\n%s\ngot:\n%s", funcName, wantQuery, query)
+	}
+
+	if len(params) == 0 && len(wantParams) == 0 {
+		return
+	}
+
+	if !reflect.DeepEqual(params, wantParams) {
+		t.Errorf("%s: unexpected params\n\nexpected: %#v\ngot:      %#v", funcName, wantParams, params)
+	}
+}
@@ -0,0 +1,92 @@
+package ksqltest
+
+import (
+	"testing"
+
+	"github.com/vingarcia/ksql"
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestAssertInsertQuery(t *testing.T) {
+	t.Run("should pass when the query and params match", func(t *testing.T) {
+		fake := &fakeTB{TB: t}
+		AssertInsertQuery(fake, sqldialect.PostgresDialect{}, goldenUsersTable, &goldenUser{Name: "fakeName", Age: 42},
+			`INSERT INTO users ("name", "age") VALUES ($1, $2) RETURNING "id"`,
+			[]interface{}{"fakeName", 42},
+		)
+		tt.AssertEqual(t, fake.errMsg, "")
+	})
+
+	t.Run("should report differences on the query", func(t *testing.T) {
+		fake := &fakeTB{TB: t}
+		AssertInsertQuery(fake, sqldialect.MysqlDialect{}, goldenUsersTable, &goldenUser{Name: "fakeName", Age: 42},
+			`INSERT INTO users ("name", "age") VALUES ($1, $2) RETURNING "id"`,
+			[]interface{}{"fakeName", 42},
+		)
+		tt.AssertContains(t, fake.errMsg, "AssertInsertQuery", "unexpected query", "INSERT INTO users (`name`, `age`) VALUES (?, ?)")
+	})
+
+	t.Run("should report differences on the params", func(t *testing.T) {
+		fake := &fakeTB{TB: t}
+		AssertInsertQuery(fake, sqldialect.PostgresDialect{}, goldenUsersTable, &goldenUser{Name: "fakeName", Age: 42},
+			`INSERT INTO users ("name", "age") VALUES ($1, $2) RETURNING "id"`,
+			[]interface{}{"otherName", 42},
+		)
+		tt.AssertContains(t, fake.errMsg, "AssertInsertQuery", "unexpected params", "otherName", "fakeName")
+	})
+
+	t.Run("should report errors building the query", func(t *testing.T) {
+		fake := &fakeTB{TB: t}
+		AssertInsertQuery(fake, sqldialect.PostgresDialect{}, goldenUsersTable, goldenUser{Name: "fakeName"},
+			`INSERT INTO users ("name") VALUES ($1) RETURNING "id"`,
+			[]interface{}{"fakeName"},
+		)
+		tt.AssertContains(t, fake.errMsg, "AssertInsertQuery", "unable to build", "pointer to struct")
+	})
+}
+
+func TestAssertPatchQuery(t *testing.T) {
+	t.Run("should pass when the query and params match", func(t *testing.T) {
+		fake := &fakeTB{TB: t}
+		AssertPatchQuery(fake, sqldialect.PostgresDialect{}, goldenUsersTable, &goldenUser{ID: 1, Name: "fakeName", Age: 42},
+			`UPDATE users SET "name" = $1, "age" = $2 WHERE "id" = $3`,
+			[]interface{}{"fakeName", 42, 1},
+		)
+		tt.AssertEqual(t, fake.errMsg, "")
+	})
+
+	t.Run("should ignore nil pointers", func(t *testing.T) {
+		type partialUser struct {
+			ID   int     `ksql:"id"`
+			Name *string `ksql:"name"`
+			Age  *int    `ksql:"age"`
+		}
+
+		age := 42
+		fake := &fakeTB{TB: t}
+		AssertPatchQuery(fake, sqldialect.SqlserverDialect{}, ksql.NewTable("users"), &partialUser{ID: 1, Age: &age},
+			`UPDATE users SET [age] = @p1 WHERE [id] = @p2`,
+			[]interface{}{42, 1},
+		)
+		tt.AssertEqual(t, fake.errMsg, "")
+	})
+
+	t.Run("should report differences on the query", func(t *testing.T) {
+		fake := &fakeTB{TB: t}
+		AssertPatchQuery(fake, sqldialect.PostgresDialect{}, goldenUsersTable, &goldenUser{ID: 1, Name: "fakeName", Age: 42},
+			`UPDATE users SET "name" = $1 WHERE "id" = $2`,
+			[]interface{}{"fakeName", 42, 1},
+		)
+		tt.AssertContains(t, fake.errMsg, "AssertPatchQuery", "unexpected query", `"age" = $2`)
+	})
+
+	t.Run("should report errors building the query", func(t *testing.T) {
+		fake := &fakeTB{TB: t}
+		AssertPatchQuery(fake, sqldialect.PostgresDialect{}, goldenUsersTable, &goldenUser{Name: "fakeName"},
+			`UPDATE users SET "name" = $1 WHERE "id" = $2`,
+			[]interface{}{"fakeName", 0},
+		)
+		tt.AssertContains(t, fake.errMsg, "AssertPatchQuery", "unable to build")
+	})
+}
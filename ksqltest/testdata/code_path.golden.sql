@@ -0,0 +1,12 @@
+BEGIN;
+
+INSERT INTO users ("name", "age") VALUES ($1, $2) RETURNING "id";
+
+UPDATE users SET "name" = $1, "age" = $2 WHERE "id" = $3;
+
+SELECT "id", "name", "age" FROM users WHERE age > $1;
+
+DELETE FROM users WHERE "id" = $1;
+
+COMMIT;
+
@@ -131,7 +131,11 @@ func CallFunctionWithRows(fn interface{}, rows []map[string]interface{}) error {
 	chunkPtr := reflect.New(chunkType)
 	chunkPtr.Elem().Set(chunk)
 
-	err = FillSliceWith(chunkPtr.Interface(), rows)
+	if structs.IsScalarType(chunkType.Elem()) {
+		err = fillScalarSliceWith(chunkPtr, rows)
+	} else {
+		err = FillSliceWith(chunkPtr.Interface(), rows)
+	}
 	if err != nil {
 		return err
 	}
@@ -140,3 +144,30 @@ func CallFunctionWithRows(fn interface{}, rows []map[string]interface{}) error {
 
 	return err
 }
+
+// fillScalarSliceWith fills a slice of scalar values
+// using the single value available on each of the rows.
+func fillScalarSliceWith(slicePtr reflect.Value, dbRows []map[string]interface{}) error {
+	slice := slicePtr.Elem()
+	elemType := slice.Type().Elem()
+	for _, row := range dbRows {
+		if len(row) != 1 {
+			return fmt.Errorf(
+				"CallFunctionWithRows: expected each row to contain a single column when using a slice of %v, but got: %v",
+				elemType, row,
+			)
+		}
+
+		for _, rawSrc := range row {
+			value, err := structs.NewPtrConverter(rawSrc).Convert(elemType)
+			if err != nil {
+				return fmt.Errorf("CallFunctionWithRows: %w", err)
+			}
+			slice = reflect.Append(slice, value)
+		}
+	}
+
+	slicePtr.Elem().Set(slice)
+
+	return nil
+}
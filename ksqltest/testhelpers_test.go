@@ -362,6 +362,33 @@ func TestCallFunctionWithRows(t *testing.T) {
 		})
 	})
 
+	t.Run("should call functions receiving scalar slices correctly", func(t *testing.T) {
+		var inputNames []string
+		fn := func(names []string) error {
+			inputNames = names
+			return nil
+		}
+
+		err := CallFunctionWithRows(fn, []map[string]interface{}{
+			{"name": "fake-name1"},
+			{"name": "fake-name2"},
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, inputNames, []string{"fake-name1", "fake-name2"})
+	})
+
+	t.Run("should report error if a row has more than one column for scalar slices", func(t *testing.T) {
+		fn := func(names []string) error {
+			return nil
+		}
+
+		err := CallFunctionWithRows(fn, []map[string]interface{}{{
+			"name": "fake-name1",
+			"age":  42,
+		}})
+		tt.AssertErrContains(t, err, "single column")
+	})
+
 	t.Run("should forward errors correctly", func(t *testing.T) {
 		type User struct {
 			Name string `ksql:"name"`
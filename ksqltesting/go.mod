@@ -0,0 +1,12 @@
+module github.com/vingarcia/ksql/ksqltesting
+
+go 1.14
+
+require (
+	github.com/ory/dockertest/v3 v3.10.0
+	github.com/vingarcia/ksql v1.12.3
+	github.com/vingarcia/ksql/adapters/kmysql v1.12.3
+	github.com/vingarcia/ksql/adapters/kpgx v1.12.3
+	github.com/vingarcia/ksql/adapters/ksqlserver v1.12.3
+	github.com/vingarcia/ksql/adapters/modernc-ksqlite v1.12.3
+)
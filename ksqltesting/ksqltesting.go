@@ -0,0 +1,355 @@
+// Package ksqltesting starts a database of each dialect supported
+// by KSQL on docker containers, so applications can run their
+// integration tests against all of them, the same way the adapters
+// of KSQL are tested with ksql.RunTestsForAdapter:
+//
+//	var harness *ksqltesting.Harness
+//
+//	func TestMain(m *testing.M) {
+//		ctx := context.Background()
+//
+//		var err error
+//		harness, err = ksqltesting.New(ctx, ksqltesting.Config{
+//			Migrate: func(ctx context.Context, db ksql.Provider, dialect sqldialect.Provider) error {
+//				_, err := db.Exec(ctx, `CREATE TABLE users (id INT PRIMARY KEY, name VARCHAR(50))`)
+//				return err
+//			},
+//		})
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//
+//		code := m.Run()
+//		harness.Close()
+//		os.Exit(code)
+//	}
+//
+//	func TestUsersRepository(t *testing.T) {
+//		harness.Run(t, func(t *testing.T, db ksqltesting.Database) {
+//			repo := NewUsersRepository(db.DB)
+//			// ...
+//		})
+//	}
+//
+// Docker must be available on the machine running the tests, for
+// lighter tests NewMemoryProvider creates an in-memory SQLite database
+// with the tables described by the structs of the application instead.
+package ksqltesting
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/vingarcia/ksql"
+	"github.com/vingarcia/ksql/adapters/kmysql"
+	"github.com/vingarcia/ksql/adapters/kpgx"
+	"github.com/vingarcia/ksql/adapters/ksqlserver"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// AllDialects lists the names of the dialects started by default
+var AllDialects = []string{"postgres", "mysql", "sqlserver", "sqlite3"}
+
+// Config describes which databases the Harness starts
+// and how they are prepared for the tests.
+type Config struct {
+	// Dialects lists the names of the databases to start,
+	// which must be items of AllDialects, if empty all of
+	// them are started.
+	Dialects []string
+
+	// Migrate is called once for each database after it is ready,
+	// and should create the tables used by the tests.
+	//
+	// Since each database has its own syntax the dialect is
+	// informed so the function can run different migrations for
+	// each one, or run the migration files of the application.
+	Migrate func(ctx context.Context, db ksql.Provider, dialect sqldialect.Provider) error
+
+	// MaxWait is how long to wait for each database to accept
+	// connections after its container is started, defaults to 60s.
+	MaxWait time.Duration
+
+	// ExpireAfter is how long the containers are kept alive before
+	// being killed by docker, which avoids leaking containers when
+	// the tests are interrupted before calling Close, defaults to 10m.
+	ExpireAfter time.Duration
+}
+
+// Database is one of the databases started by the Harness
+type Database struct {
+	// Name is the name of the dialect, e.g. "postgres"
+	Name    string
+	Dialect sqldialect.Provider
+
+	// ConnStr is the connection string used for creating DB,
+	// which is useful for testing code that opens its own connections
+	ConnStr string
+
+	DB ksql.DB
+}
+
+// Harness keeps the databases started by New, it should be
+// created once for the whole test suite, e.g. on TestMain,
+// since starting the containers takes several seconds.
+type Harness struct {
+	databases []Database
+	closers   []func() error
+}
+
+// New starts the databases and runs the migrations on each of them,
+// if any of them fails the ones already started are stopped.
+func New(ctx context.Context, config Config) (_ *Harness, err error) {
+	if len(config.Dialects) == 0 {
+		config.Dialects = AllDialects
+	}
+	if config.MaxWait == 0 {
+		config.MaxWait = 60 * time.Second
+	}
+	if config.ExpireAfter == 0 {
+		config.ExpireAfter = 10 * time.Minute
+	}
+
+	h := &Harness{}
+	defer func() {
+		if err != nil {
+			h.Close()
+		}
+	}()
+
+	for _, name := range config.Dialects {
+		var db Database
+		var closer func() error
+		switch name {
+		case "postgres":
+			db, closer, err = startPostgres(ctx, config)
+		case "mysql":
+			db, closer, err = startMySQL(ctx, config)
+		case "sqlserver":
+			db, closer, err = startSQLServer(ctx, config)
+		case "sqlite3":
+			db, closer, err = startSQLite(ctx)
+		default:
+			return nil, fmt.Errorf("ksqltesting: unsupported dialect '%s', the supported ones are: %v", name, AllDialects)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ksqltesting: error starting %s: %w", name, err)
+		}
+		h.closers = append(h.closers, closer)
+
+		// Appended before the migrations so Close also
+		// closes its connection if they fail:
+		h.databases = append(h.databases, db)
+
+		if config.Migrate != nil {
+			err = config.Migrate(ctx, db.DB, db.Dialect)
+			if err != nil {
+				return nil, fmt.Errorf("ksqltesting: error running the migrations on %s: %w", name, err)
+			}
+		}
+	}
+
+	return h, nil
+}
+
+// Databases returns the databases started by the Harness
+// in the same order they were listed on Config.Dialects.
+func (h *Harness) Databases() []Database {
+	return h.databases
+}
+
+// Run runs fn as a subtest for each of the databases,
+// using the name of the dialect as the name of the subtest.
+//
+// The databases are shared by all tests, so each test should
+// clean up the records it creates or use unique IDs.
+func (h *Harness) Run(t *testing.T, fn func(t *testing.T, db Database)) {
+	for _, db := range h.databases {
+		db := db
+		t.Run(db.Name, func(t *testing.T) {
+			fn(t, db)
+		})
+	}
+}
+
+// Close closes the connections and stops the containers,
+// it returns the first error found but tries to close all of them.
+func (h *Harness) Close() error {
+	var firstErr error
+	for _, db := range h.databases {
+		if err := db.DB.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, closer := range h.closers {
+		if err := closer(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	h.databases = nil
+	h.closers = nil
+	return firstErr
+}
+
+// startContainer runs the image and waits until connect succeeds,
+// returning the address of the container port and a function
+// for stopping it.
+func startContainer(
+	config Config,
+	options *dockertest.RunOptions,
+	port string,
+	connect func(hostAndPort string) error,
+) (hostAndPort string, closer func() error, _ error) {
+	// uses a sensible default on windows (tcp/http) and linux/osx (socket)
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		return "", nil, fmt.Errorf("could not connect to docker: %w", err)
+	}
+
+	resource, err := pool.RunWithOptions(options, func(config *docker.HostConfig) {
+		// set AutoRemove to true so that stopped container goes away by itself
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("could not start container: %w", err)
+	}
+	closer = func() error {
+		return pool.Purge(resource)
+	}
+
+	// Tell docker to hard kill the container if Close is never called:
+	_ = resource.Expire(uint(config.ExpireAfter / time.Second))
+
+	hostAndPort = resource.GetHostPort(port)
+
+	// exponential backoff-retry, because the application in the container might not be ready to accept connections yet
+	pool.MaxWait = config.MaxWait
+	err = pool.Retry(func() error {
+		return connect(hostAndPort)
+	})
+	if err != nil {
+		_ = closer()
+		return "", nil, fmt.Errorf("could not connect to the container: %w", err)
+	}
+
+	return hostAndPort, closer, nil
+}
+
+func startPostgres(ctx context.Context, config Config) (db Database, closer func() error, err error) {
+	db.Name = "postgres"
+	db.Dialect = sqldialect.PostgresDialect{}
+
+	_, closer, err = startContainer(config,
+		&dockertest.RunOptions{
+			Repository: "postgres",
+			Tag:        "14.0",
+			Env: []string{
+				"POSTGRES_PASSWORD=postgres",
+				"POSTGRES_USER=postgres",
+				"POSTGRES_DB=ksql",
+			},
+		},
+		"5432/tcp",
+		func(hostAndPort string) error {
+			db.ConnStr = fmt.Sprintf("postgres://postgres:postgres@%s/ksql?sslmode=disable", hostAndPort)
+			db.DB, err = kpgx.New(ctx, db.ConnStr, ksql.Config{})
+			return err
+		},
+	)
+
+	return db, closer, err
+}
+
+func startMySQL(ctx context.Context, config Config) (db Database, closer func() error, err error) {
+	db.Name = "mysql"
+	db.Dialect = sqldialect.MysqlDialect{}
+
+	_, closer, err = startContainer(config,
+		&dockertest.RunOptions{
+			Repository: "mariadb",
+			Tag:        "10.8",
+			Env: []string{
+				"MARIADB_ROOT_PASSWORD=mysql",
+				"MARIADB_DATABASE=ksql",
+			},
+		},
+		"3306/tcp",
+		func(hostAndPort string) error {
+			db.ConnStr = fmt.Sprintf("root:mysql@(%s)/ksql?timeout=30s&parseTime=true", hostAndPort)
+			db.DB, err = kmysql.New(ctx, db.ConnStr, ksql.Config{})
+			return err
+		},
+	)
+
+	return db, closer, err
+}
+
+func startSQLServer(ctx context.Context, config Config) (db Database, closer func() error, err error) {
+	db.Name = "sqlserver"
+	db.Dialect = sqldialect.SqlserverDialect{}
+
+	_, closer, err = startContainer(config,
+		&dockertest.RunOptions{
+			Repository: "mcr.microsoft.com/mssql/server",
+			Tag:        "2022-latest",
+			Env: []string{
+				"SA_PASSWORD=Sqls3rv3r",
+				"ACCEPT_EULA=Y",
+			},
+		},
+		"1433/tcp",
+		func(hostAndPort string) error {
+			// SQL Server has no option for creating a database on startup:
+			masterDB, err := sql.Open("sqlserver", fmt.Sprintf("sqlserver://sa:Sqls3rv3r@%s", hostAndPort))
+			if err != nil {
+				return err
+			}
+			defer masterDB.Close()
+
+			_, err = masterDB.ExecContext(ctx, "IF DB_ID('ksql') IS NULL CREATE DATABASE ksql")
+			if err != nil {
+				return err
+			}
+
+			db.ConnStr = fmt.Sprintf("sqlserver://sa:Sqls3rv3r@%s?databaseName=ksql", hostAndPort)
+			db.DB, err = ksqlserver.New(ctx, db.ConnStr, ksql.Config{})
+			return err
+		},
+	)
+
+	return db, closer, err
+}
+
+// startSQLite creates the database on a temporary directory, no container
+// is needed, the pure Go driver is used so the tests don't require CGO.
+func startSQLite(ctx context.Context) (db Database, closer func() error, err error) {
+	dir, err := ioutil.TempDir("", "ksqltesting")
+	if err != nil {
+		return Database{}, nil, err
+	}
+	closer = func() error {
+		return os.RemoveAll(dir)
+	}
+
+	db.Name = "sqlite3"
+	db.Dialect = sqldialect.Sqlite3Dialect{}
+	db.ConnStr = filepath.Join(dir, "ksql.db")
+	db.DB, err = ksqlite.New(ctx, db.ConnStr, ksql.Config{})
+	if err != nil {
+		_ = closer()
+		return Database{}, nil, err
+	}
+
+	return db, closer, nil
+}
@@ -0,0 +1,87 @@
+package ksqltesting
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/vingarcia/ksql"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+type user struct {
+	ID   int    `ksql:"id"`
+	Name string `ksql:"name"`
+}
+
+var usersTable = ksql.NewTable("users")
+
+func TestHarness(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should run the migrations and the tests on each database", func(t *testing.T) {
+		var migratedDialects []string
+		harness, err := New(ctx, Config{
+			// SQLite doesn't need docker, so this test can run anywhere:
+			Dialects: []string{"sqlite3"},
+			Migrate: func(ctx context.Context, db ksql.Provider, dialect sqldialect.Provider) error {
+				migratedDialects = append(migratedDialects, dialect.DriverName())
+				_, err := db.Exec(ctx, `CREATE TABLE users (id INTEGER PRIMARY KEY, name VARCHAR(50))`)
+				return err
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer harness.Close()
+
+		if len(migratedDialects) != 1 || migratedDialects[0] != "sqlite3" {
+			t.Fatalf("expected the migrations to run once for sqlite3, but got: %v", migratedDialects)
+		}
+
+		var testedDatabases []string
+		harness.Run(t, func(t *testing.T, db Database) {
+			testedDatabases = append(testedDatabases, db.Name)
+
+			err := db.DB.Insert(ctx, usersTable, &user{Name: "Ana"})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var u user
+			err = db.DB.QueryOne(ctx, &u, "FROM users WHERE name = "+db.Dialect.Placeholder(0), "Ana")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if u.ID == 0 || u.Name != "Ana" {
+				t.Fatalf("unexpected user: %+v", u)
+			}
+		})
+
+		if len(testedDatabases) != 1 || testedDatabases[0] != "sqlite3" {
+			t.Fatalf("expected the test to run once for sqlite3, but got: %v", testedDatabases)
+		}
+	})
+
+	t.Run("should report unsupported dialects", func(t *testing.T) {
+		_, err := New(ctx, Config{
+			Dialects: []string{"oracle"},
+		})
+		if err == nil || !strings.Contains(err.Error(), "oracle") {
+			t.Fatalf("expected error containing 'oracle' but got: %v", err)
+		}
+	})
+
+	t.Run("should report errors from the migrations", func(t *testing.T) {
+		_, err := New(ctx, Config{
+			Dialects: []string{"sqlite3"},
+			Migrate: func(ctx context.Context, db ksql.Provider, dialect sqldialect.Provider) error {
+				_, err := db.Exec(ctx, `NOT VALID SQL`)
+				return err
+			},
+		})
+		if err == nil || !strings.Contains(err.Error(), "migrations") {
+			t.Fatalf("expected error containing 'migrations' but got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,223 @@
+package ksqltesting
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vingarcia/ksql"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// memoryDBCounter makes the name of each in-memory database unique,
+// so the tests using NewMemoryProvider never share their tables
+var memoryDBCounter int64
+
+// NewMemoryProvider returns a ksql.DB backed by an in-memory SQLite
+// database with one table created for each pair of ksql.Table and
+// struct pointer passed as argument, which is useful for unit tests
+// that don't need a real database, e.g.:
+//
+//	db := ksqltesting.NewMemoryProvider(t,
+//		UsersTable, &User{},
+//		PostsTable, &Post{},
+//	)
+//
+// The columns are read from the `ksql` tags of the structs and the
+// ID columns of each ksql.Table are used as its primary key, a single
+// integer ID is generated by the database on insertions.
+//
+// The column types are chosen from the types of the attributes, e.g.
+// INTEGER for ints and DATETIME for time.Time, and any other type is
+// declared without a type so SQLite stores the values as they are sent.
+//
+// The database is closed with t.Cleanup and any error
+// creating it fails the test with t.Fatalf.
+func NewMemoryProvider(t testing.TB, tablesAndRecords ...interface{}) ksql.DB {
+	t.Helper()
+
+	ctx := context.Background()
+
+	createQueries, err := buildCreateTableQueries(tablesAndRecords)
+	if err != nil {
+		t.Fatalf("ksqltesting: %s", err)
+	}
+
+	// The shared cache makes all connections of the pool use the same
+	// database, and it is kept alive as long as one of them is open:
+	connStr := fmt.Sprintf(
+		"file:ksqltesting_memory_%d?mode=memory&cache=shared",
+		atomic.AddInt64(&memoryDBCounter, 1),
+	)
+	sqlDB, err := sql.Open("sqlite", connStr)
+	if err != nil {
+		t.Fatalf("ksqltesting: error opening the in-memory database: %s", err)
+	}
+
+	// So the database is not lost if the pool closes its idle connections:
+	keepAliveConn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		sqlDB.Close()
+		t.Fatalf("ksqltesting: error connecting to the in-memory database: %s", err)
+	}
+	t.Cleanup(func() {
+		keepAliveConn.Close()
+		sqlDB.Close()
+	})
+
+	for _, query := range createQueries {
+		_, err := keepAliveConn.ExecContext(ctx, query)
+		if err != nil {
+			t.Fatalf("ksqltesting: error running `%s` on the in-memory database: %s", query, err)
+		}
+	}
+
+	db, err := ksqlite.NewFromSQLDB(sqlDB)
+	if err != nil {
+		t.Fatalf("ksqltesting: error creating the ksql.DB: %s", err)
+	}
+
+	return db
+}
+
+// buildCreateTableQueries returns one CREATE TABLE
+// query for each pair of ksql.Table and struct pointer
+func buildCreateTableQueries(tablesAndRecords []interface{}) ([]string, error) {
+	if len(tablesAndRecords)%2 != 0 {
+		return nil, fmt.Errorf(
+			"expected pairs of ksql.Table and struct pointer as arguments but got %d arguments",
+			len(tablesAndRecords),
+		)
+	}
+
+	var queries []string
+	for i := 0; i < len(tablesAndRecords); i += 2 {
+		table, ok := tablesAndRecords[i].(ksql.Table)
+		if !ok {
+			return nil, fmt.Errorf("expected argument %d to be a ksql.Table but got: %T", i, tablesAndRecords[i])
+		}
+
+		query, err := buildCreateTableQuery(table, tablesAndRecords[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("error building the table `%s`: %w", table.Name(), err)
+		}
+		queries = append(queries, query)
+	}
+
+	return queries, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func buildCreateTableQuery(table ksql.Table, record interface{}) (string, error) {
+	t := reflect.TypeOf(record)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return "", fmt.Errorf("expected the record to be a pointer to struct but got: %T", record)
+	}
+
+	columnsByAttr, err := ksql.Columns(record)
+	if err != nil {
+		return "", err
+	}
+
+	type column struct {
+		name string
+		t    reflect.Type
+	}
+	var tableColumns []column
+	columnTypes := map[string]reflect.Type{}
+	for i := 0; i < t.Elem().NumField(); i++ {
+		field := t.Elem().Field(i)
+		if field.Tag.Get("tablename") != "" {
+			return "", fmt.Errorf("can't create tables from nested structs, got: %T", record)
+		}
+
+		name, ok := columnsByAttr[field.Name]
+		if !ok || field.Tag.Get("ksqlexpr") != "" {
+			// Attributes without tags and the ones
+			// computed by SQL expressions have no column:
+			continue
+		}
+
+		tableColumns = append(tableColumns, column{name: name, t: field.Type})
+		columnTypes[name] = field.Type
+	}
+
+	if table.Name() == "" {
+		return "", fmt.Errorf("the ksql.Table has no name")
+	}
+
+	dialect := sqldialect.Sqlite3Dialect{}
+
+	idColumns := table.IDColumns()
+	for _, id := range idColumns {
+		if _, found := columnTypes[id]; !found {
+			return "", fmt.Errorf("the ID column `%s` has no matching attribute on %T", id, record)
+		}
+	}
+
+	// A single integer ID is declared as an alias of the rowid,
+	// so it is generated by SQLite on insertions:
+	singleIntegerID := len(idColumns) == 1 && columnType(columnTypes[idColumns[0]]) == "INTEGER"
+
+	var columns []string
+	for _, c := range tableColumns {
+		column := dialect.Escape(c.name)
+		if sqlType := columnType(c.t); sqlType != "" {
+			column += " " + sqlType
+		}
+		if singleIntegerID && c.name == idColumns[0] {
+			column += " PRIMARY KEY"
+		}
+		columns = append(columns, column)
+	}
+
+	if !singleIntegerID {
+		escapedIDs := make([]string, len(idColumns))
+		for i, id := range idColumns {
+			escapedIDs[i] = dialect.Escape(id)
+		}
+		columns = append(columns, "PRIMARY KEY ("+strings.Join(escapedIDs, ", ")+")")
+	}
+
+	return fmt.Sprintf(
+		"CREATE TABLE %s (%s)",
+		dialect.Escape(table.Name()),
+		strings.Join(columns, ", "),
+	), nil
+}
+
+// columnType returns the SQLite type used for the attributes of type t,
+// or an empty string if the column should be declared without a type
+func columnType(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return "DATETIME"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "INTEGER"
+	case reflect.Float32, reflect.Float64:
+		return "REAL"
+	case reflect.String:
+		return "TEXT"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "BLOB"
+		}
+	}
+
+	return ""
+}
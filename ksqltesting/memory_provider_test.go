@@ -0,0 +1,162 @@
+package ksqltesting
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vingarcia/ksql"
+)
+
+type post struct {
+	ID        int       `ksql:"id"`
+	UserID    int       `ksql:"user_id"`
+	Title     string    `ksql:"title"`
+	Score     *float64  `ksql:"score"`
+	CreatedAt time.Time `ksql:"created_at"`
+
+	// Attributes without the ksql tag are ignored:
+	Comments []string
+}
+
+type userTag struct {
+	UserID int    `ksql:"user_id"`
+	Tag    string `ksql:"tag"`
+}
+
+var postsTable = ksql.NewTable("posts")
+var userTagsTable = ksql.NewTable("user_tags", "user_id", "tag")
+
+func TestNewMemoryProvider(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should create the tables from the structs", func(t *testing.T) {
+		db := NewMemoryProvider(t,
+			usersTable, &user{},
+			postsTable, &post{},
+			userTagsTable, &userTag{},
+		)
+
+		u := user{Name: "Ana"}
+		err := db.Insert(ctx, usersTable, &u)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if u.ID == 0 {
+			t.Fatalf("expected the ID to be generated by the database")
+		}
+
+		score := 4.5
+		createdAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		err = db.Insert(ctx, postsTable, &post{UserID: u.ID, Title: "Hello", Score: &score, CreatedAt: createdAt})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		var p post
+		err = db.QueryOne(ctx, &p, "FROM posts WHERE user_id = ?", u.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if p.Title != "Hello" || p.Score == nil || *p.Score != 4.5 || !p.CreatedAt.Equal(createdAt) {
+			t.Fatalf("unexpected post: %+v", p)
+		}
+
+		err = db.Insert(ctx, userTagsTable, &userTag{UserID: u.ID, Tag: "admin"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		err = db.Insert(ctx, userTagsTable, &userTag{UserID: u.ID, Tag: "admin"})
+		if err == nil {
+			t.Fatalf("expected the composite primary key to reject duplicated records")
+		}
+	})
+
+	t.Run("should not share the tables between providers", func(t *testing.T) {
+		db1 := NewMemoryProvider(t, usersTable, &user{})
+		db2 := NewMemoryProvider(t, usersTable, &user{})
+
+		err := db1.Insert(ctx, usersTable, &user{Name: "Ana"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		var users []user
+		err = db2.Query(ctx, &users, "FROM users")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(users) != 0 {
+			t.Fatalf("expected no users on the second database but got: %+v", users)
+		}
+	})
+
+	t.Run("should close the database when the test ends", func(t *testing.T) {
+		var db ksql.DB
+		t.Run("subtest", func(t *testing.T) {
+			db = NewMemoryProvider(t, usersTable, &user{})
+		})
+
+		var users []user
+		err := db.Query(ctx, &users, "FROM users")
+		if err == nil {
+			t.Fatalf("expected an error querying a closed database")
+		}
+	})
+}
+
+func TestBuildCreateTableQueries(t *testing.T) {
+	t.Run("should build the queries from the ksql tags", func(t *testing.T) {
+		queries, err := buildCreateTableQueries([]interface{}{
+			postsTable, &post{},
+			userTagsTable, &userTag{},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		expected := []string{
+			"CREATE TABLE `posts` (`id` INTEGER PRIMARY KEY, `user_id` INTEGER, `title` TEXT, `score` REAL, `created_at` DATETIME)",
+			"CREATE TABLE `user_tags` (`user_id` INTEGER, `tag` TEXT, PRIMARY KEY (`user_id`, `tag`))",
+		}
+		if strings.Join(queries, "\n") != strings.Join(expected, "\n") {
+			t.Fatalf("expected queries:\n%s\nbut got:\n%s", strings.Join(expected, "\n"), strings.Join(queries, "\n"))
+		}
+	})
+
+	tests := []struct {
+		desc               string
+		tablesAndRecords   []interface{}
+		expectErrToContain string
+	}{
+		{
+			desc:               "should report missing records",
+			tablesAndRecords:   []interface{}{usersTable},
+			expectErrToContain: "pairs",
+		},
+		{
+			desc:               "should report arguments that are not tables",
+			tablesAndRecords:   []interface{}{"users", &user{}},
+			expectErrToContain: "ksql.Table",
+		},
+		{
+			desc:               "should report records that are not pointers to structs",
+			tablesAndRecords:   []interface{}{usersTable, user{}},
+			expectErrToContain: "pointer to struct",
+		},
+		{
+			desc:               "should report ID columns missing on the struct",
+			tablesAndRecords:   []interface{}{ksql.NewTable("users", "email"), &user{}},
+			expectErrToContain: "email",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			_, err := buildCreateTableQueries(test.tablesAndRecords)
+			if err == nil || !strings.Contains(err.Error(), test.expectErrToContain) {
+				t.Fatalf("expected error containing '%s' but got: %v", test.expectErrToContain, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+// LeakedRows describes a Rows value that was not closed
+// before the timeout set on DetectRowsLeaks.
+type LeakedRows struct {
+	Query    string
+	OpenedAt time.Time
+
+	// Stack is the stack trace of the goroutine
+	// that called QueryContext when the Rows were opened.
+	Stack []byte
+}
+
+// DetectRowsLeaks wraps the input DBAdapter so that every Rows returned by
+// its QueryContext method, including the ones returned inside transactions,
+// calls `onLeak` if it is not closed within `timeout`, e.g.:
+//
+//	adapter = ksql.DetectRowsLeaks(adapter, 30*time.Second, func(leak ksql.LeakedRows) {
+//		log.Printf("rows for query `%s` not closed, opened at:\n%s", leak.Query, leak.Stack)
+//	})
+//	db, err := ksql.NewWithAdapter(adapter, sqldialect.PostgresDialect{})
+//
+// Leaked Rows hold their connections until they are closed, so without this
+// they usually show up as a connection pool exhaustion with no clue about
+// which code opened them.
+//
+// Since a stack trace is recorded for each query this is
+// meant to be used on tests and development environments.
+//
+// If `onLeak` is nil the leaks are written to os.Stderr.
+//
+// The optional interfaces implemented by the adapter, e.g. Batcher or
+// StatsProvider, are forwarded by the wrapper, but adapter specific
+// features that type assert the concrete adapter won't work with it.
+func DetectRowsLeaks(adapter DBAdapter, timeout time.Duration, onLeak func(LeakedRows)) DBAdapter {
+	if onLeak == nil {
+		onLeak = writeLeakToStderr
+	}
+
+	detector := leakDetectorAdapter{
+		DBAdapter: adapter,
+		timeout:   timeout,
+		onLeak:    onLeak,
+	}
+	if _, ok := adapter.(TxBeginner); ok {
+		return leakDetectorTxBeginner{detector}
+	}
+
+	return detector
+}
+
+func writeLeakToStderr(leak LeakedRows) {
+	fmt.Fprintf(
+		os.Stderr,
+		"KSQL: the rows of the query `%s` opened at %s were not closed, stack trace:\n%s\n",
+		leak.Query, leak.OpenedAt.Format(time.RFC3339), leak.Stack,
+	)
+}
+
+// errNoServerVersioner is returned by wrappers whose wrapped
+// adapter doesn't implement the ServerVersioner interface
+var errNoServerVersioner = errors.New("KSQL: the DBAdapter doesn't implement the ServerVersioner interface")
+
+type leakDetectorAdapter struct {
+	DBAdapter
+	timeout time.Duration
+	onLeak  func(LeakedRows)
+}
+
+func (l leakDetectorAdapter) QueryContext(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+	rows, err := l.DBAdapter.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.watch(rows, query), nil
+}
+
+// watch returns a Rows that reports a leak if
+// it is not closed before the timeout
+func (l leakDetectorAdapter) watch(rows Rows, query string) Rows {
+	leak := LeakedRows{
+		Query:    query,
+		OpenedAt: time.Now(),
+		Stack:    debug.Stack(),
+	}
+
+	return leakDetectorRows{
+		Rows: rows,
+		timer: time.AfterFunc(l.timeout, func() {
+			l.onLeak(leak)
+		}),
+	}
+}
+
+// Close implements the io.Closer interface
+func (l leakDetectorAdapter) Close() error {
+	if closer, ok := l.DBAdapter.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// IsConnError implements the ConnErrorClassifier interface
+func (l leakDetectorAdapter) IsConnError(err error) bool {
+	if classifier, ok := l.DBAdapter.(ConnErrorClassifier); ok {
+		return classifier.IsConnError(err)
+	}
+	return IsConnError(err)
+}
+
+// ServerVersion implements the ServerVersioner interface
+func (l leakDetectorAdapter) ServerVersion(ctx context.Context) (string, error) {
+	if versioner, ok := l.DBAdapter.(ServerVersioner); ok {
+		return versioner.ServerVersion(ctx)
+	}
+	return "", errNoServerVersioner
+}
+
+// PoolStats implements the StatsProvider interface
+func (l leakDetectorAdapter) PoolStats() (PoolStats, error) {
+	if provider, ok := l.DBAdapter.(StatsProvider); ok {
+		return provider.PoolStats()
+	}
+	return PoolStats{}, errNoStatsProvider
+}
+
+// SendBatch implements the Batcher interface
+func (l leakDetectorAdapter) SendBatch(ctx context.Context, queries []BatchQuery) ([]int64, error) {
+	if batcher, ok := l.DBAdapter.(Batcher); ok {
+		return batcher.SendBatch(ctx, queries)
+	}
+	return nil, errNoBatcher
+}
+
+// RouteQuery implements the ShardKeyRouter interface
+func (l leakDetectorAdapter) RouteQuery(ctx context.Context, query string, keys []ShardKeyValue) (string, error) {
+	if router, ok := l.DBAdapter.(ShardKeyRouter); ok {
+		return router.RouteQuery(ctx, query, keys)
+	}
+	return query, nil
+}
+
+type leakDetectorTxBeginner struct {
+	leakDetectorAdapter
+}
+
+func (l leakDetectorTxBeginner) BeginTx(ctx context.Context) (Tx, error) {
+	tx, err := l.DBAdapter.(TxBeginner).BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.leakDetectorAdapter.wrapTx(tx), nil
+}
+
+// wrapTx watches the Rows returned inside the transaction, keeping
+// the support for nested transactions of the wrapped Tx, if any
+func (l leakDetectorAdapter) wrapTx(tx Tx) Tx {
+	wrapped := leakDetectorTx{
+		Tx:       tx,
+		detector: l,
+	}
+	if _, ok := tx.(NestedTxBeginner); ok {
+		return leakDetectorNestedTx{wrapped}
+	}
+
+	return wrapped
+}
+
+type leakDetectorTx struct {
+	Tx
+	detector leakDetectorAdapter
+}
+
+func (l leakDetectorTx) QueryContext(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+	rows, err := l.Tx.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.detector.watch(rows, query), nil
+}
+
+// SendBatch implements the Batcher interface
+func (l leakDetectorTx) SendBatch(ctx context.Context, queries []BatchQuery) ([]int64, error) {
+	if batcher, ok := l.Tx.(Batcher); ok {
+		return batcher.SendBatch(ctx, queries)
+	}
+	return nil, errNoBatcher
+}
+
+type leakDetectorNestedTx struct {
+	leakDetectorTx
+}
+
+// BeginNestedTx implements the NestedTxBeginner interface
+func (l leakDetectorNestedTx) BeginNestedTx(ctx context.Context) (Tx, error) {
+	tx, err := l.Tx.(NestedTxBeginner).BeginNestedTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.detector.wrapTx(tx), nil
+}
+
+type leakDetectorRows struct {
+	Rows
+	timer *time.Timer
+}
+
+func (l leakDetectorRows) Close() error {
+	l.timer.Stop()
+	return l.Rows.Close()
+}
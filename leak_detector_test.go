@@ -0,0 +1,185 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestDetectRowsLeaks(t *testing.T) {
+	ctx := context.Background()
+
+	mockAdapter := mockDBAdapter{
+		QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+			return mockRows{}, nil
+		},
+	}
+
+	t.Run("should report rows that are not closed before the timeout", func(t *testing.T) {
+		leaks := make(chan LeakedRows, 1)
+		adapter := DetectRowsLeaks(mockAdapter, time.Millisecond, func(leak LeakedRows) {
+			leaks <- leak
+		})
+
+		_, err := adapter.QueryContext(ctx, "SELECT * FROM users")
+		tt.AssertNoErr(t, err)
+
+		select {
+		case leak := <-leaks:
+			tt.AssertEqual(t, leak.Query, "SELECT * FROM users")
+			tt.AssertEqual(t, leak.OpenedAt.IsZero(), false)
+			tt.AssertEqual(t, strings.Contains(string(leak.Stack), "TestDetectRowsLeaks"), true)
+		case <-time.After(time.Second):
+			t.Fatal("expected the leak to be reported")
+		}
+	})
+
+	t.Run("should not report rows that were closed", func(t *testing.T) {
+		leaks := make(chan LeakedRows, 1)
+		adapter := DetectRowsLeaks(mockAdapter, 10*time.Millisecond, func(leak LeakedRows) {
+			leaks <- leak
+		})
+
+		rows, err := adapter.QueryContext(ctx, "SELECT * FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertNoErr(t, rows.Close())
+
+		select {
+		case leak := <-leaks:
+			t.Fatalf("unexpected leak reported for query: %s", leak.Query)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("should watch the rows returned inside transactions", func(t *testing.T) {
+		leaks := make(chan LeakedRows, 1)
+		adapter := DetectRowsLeaks(mockTxBeginner{
+			DBAdapter: mockAdapter,
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					DBAdapter: mockAdapter,
+				}, nil
+			},
+		}, time.Millisecond, func(leak LeakedRows) {
+			leaks <- leak
+		})
+
+		txBeginner, ok := adapter.(TxBeginner)
+		tt.AssertEqual(t, ok, true)
+
+		tx, err := txBeginner.BeginTx(ctx)
+		tt.AssertNoErr(t, err)
+
+		_, err = tx.QueryContext(ctx, "SELECT * FROM posts")
+		tt.AssertNoErr(t, err)
+
+		select {
+		case leak := <-leaks:
+			tt.AssertEqual(t, leak.Query, "SELECT * FROM posts")
+		case <-time.After(time.Second):
+			t.Fatal("expected the leak to be reported")
+		}
+	})
+
+	t.Run("should keep the support for nested transactions", func(t *testing.T) {
+		var calls []string
+		newMockTx := func(name string) mockTx {
+			return mockTx{
+				DBAdapter: mockDBAdapter{
+					QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+						calls = append(calls, name+": "+query)
+						return mockRows{}, nil
+					},
+				},
+				CommitFn: func(ctx context.Context) error {
+					calls = append(calls, name+": commit")
+					return nil
+				},
+				RollbackFn: func(ctx context.Context) error {
+					calls = append(calls, name+": rollback")
+					return nil
+				},
+			}
+		}
+
+		leaks := make(chan LeakedRows, 1)
+		adapter := DetectRowsLeaks(mockTxBeginner{
+			DBAdapter: mockAdapter,
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockNestedTx{
+					mockTx: newMockTx("outer"),
+					BeginNestedTxFn: func(ctx context.Context) (Tx, error) {
+						return newMockTx("inner"), nil
+					},
+				}, nil
+			},
+		}, time.Millisecond, func(leak LeakedRows) {
+			leaks <- leak
+		})
+
+		c, err := NewWithAdapter(adapter, sqldialect.SupportedDialects["sqlite3"], Config{
+			NestedTransactions: true,
+		})
+		tt.AssertNoErr(t, err)
+
+		err = c.Transaction(ctx, func(db Provider) error {
+			return db.Transaction(ctx, func(db Provider) error {
+				// The Provider is a DB, so we can open rows
+				// directly with its adapter to leak them:
+				_, err := db.(DB).db.QueryContext(ctx, "SELECT * FROM posts")
+				return err
+			})
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{
+			"inner: SELECT * FROM posts",
+			"inner: commit",
+			"outer: commit",
+		})
+
+		select {
+		case leak := <-leaks:
+			tt.AssertEqual(t, leak.Query, "SELECT * FROM posts")
+		case <-time.After(time.Second):
+			t.Fatal("expected the leak to be reported")
+		}
+	})
+
+	t.Run("should not implement TxBeginner if the wrapped adapter doesn't", func(t *testing.T) {
+		adapter := DetectRowsLeaks(mockAdapter, time.Second, nil)
+
+		_, ok := adapter.(TxBeginner)
+		tt.AssertEqual(t, ok, false)
+	})
+
+	t.Run("should return the errors of the wrapped adapter", func(t *testing.T) {
+		adapter := DetectRowsLeaks(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				return nil, errors.New("fakeErrMsg")
+			},
+		}, time.Second, nil)
+
+		_, err := adapter.QueryContext(ctx, "SELECT * FROM users")
+		tt.AssertErrContains(t, err, "fakeErrMsg")
+	})
+
+	t.Run("should fall back to the version query if the adapter is not a ServerVersioner", func(t *testing.T) {
+		var query string
+		db, err := NewWithAdapter(DetectRowsLeaks(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, q string, params ...interface{}) (Rows, error) {
+				query = q
+				return nil, errors.New("fakeErrMsg")
+			},
+		}, time.Second, nil), sqldialect.SupportedDialects["postgres"])
+		tt.AssertNoErr(t, err)
+
+		_, err = db.ServerVersion(ctx)
+		tt.AssertErrContains(t, err, "fakeErrMsg")
+		tt.AssertEqual(t, query, "SELECT version()")
+	})
+}
@@ -1,9 +1,13 @@
 package ksql
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"time"
 )
 
 // This variable is only used during tests:
@@ -46,36 +50,125 @@ type LogValues struct {
 	Query  string
 	Params []interface{}
 	Err    error
-}
 
-func (l LogValues) MarshalJSON() ([]byte, error) {
-	var out struct {
-		Query  string        `json:"query"`
-		Params []interface{} `json:"params"`
-		Err    string        `json:"error,omitempty"`
-	}
+	// Duration is the time spent running the query
+	// and reading its results from the database
+	Duration time.Duration
 
-	out.Query = l.Query
+	// Rows is the number of rows returned or affected by the
+	// query, or -1 if this information is not available
+	Rows int
 
-	out.Params = l.Params
+	// TraceID is the ID injected with ksql.InjectTraceID(), if any
+	TraceID string
+}
+
+// Record converts the LogValues to the LogRecord format
+// used by the builtin loggers.
+func (l LogValues) Record() LogRecord {
+	record := LogRecord{
+		Query:      l.Query,
+		Params:     l.Params,
+		DurationMS: float64(l.Duration) / float64(time.Millisecond),
+		Rows:       l.Rows,
+		TraceID:    l.TraceID,
+	}
 
 	// Force it to print Params: [], instead of Params: null
-	if out.Params == nil {
-		out.Params = []interface{}{}
+	if record.Params == nil {
+		record.Params = []interface{}{}
 	}
 
 	if l.Err != nil {
-		out.Err = l.Err.Error()
+		record.Err = l.Err.Error()
+	}
+
+	return record
+}
+
+// MarshalJSON implements the json.Marshaler interface
+// using the LogRecord format
+func (l LogValues) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.Record())
+}
+
+// LogRecord is the machine-readable format of the logs emitted by the
+// builtin loggers, each log line is a LogRecord encoded as JSON, e.g.:
+//
+//	{"query":"SELECT ...","params":[42],"duration_ms":1.5,"rows":1,"trace_id":"abc"}
+//
+// The field names are stable, so tools consuming KSQL logs,
+// e.g. query analyzers, can parse them with ksql.ParseLogRecord().
+type LogRecord struct {
+	Query  string        `json:"query"`
+	Params []interface{} `json:"params"`
+
+	// DurationMS is the duration of the query in milliseconds
+	DurationMS float64 `json:"duration_ms"`
+
+	// Rows is the number of rows returned or affected by the
+	// query, or -1 if this information is not available
+	Rows int `json:"rows"`
+
+	Err     string `json:"error,omitempty"`
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+// Duration returns the duration of the query as a time.Duration
+func (r LogRecord) Duration() time.Duration {
+	return time.Duration(r.DurationMS * float64(time.Millisecond))
+}
+
+// ParseLogRecord parses a single log line emitted by the builtin loggers.
+//
+// Numeric params are decoded as json.Number in order to
+// preserve the precision of large integers such as IDs.
+func ParseLogRecord(line []byte) (LogRecord, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+
+	var record LogRecord
+	err := decoder.Decode(&record)
+	if err != nil {
+		return LogRecord{}, fmt.Errorf("KSQL: unable to parse log record: %w", err)
+	}
+
+	if record.Query == "" {
+		return LogRecord{}, fmt.Errorf("KSQL: unable to parse log record: missing query in: %s", string(line))
 	}
-	return json.Marshal(out)
+
+	return record, nil
 }
 
+// ParseLogRecords parses all the log lines emitted by the builtin
+// loggers on the input reader, ignoring empty lines.
+func ParseLogRecords(r io.Reader) ([]LogRecord, error) {
+	var records []LogRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLogLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		record, err := ParseLogRecord(line)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, scanner.Err()
+}
+
+// maxLogLineSize is large enough for queries with big params
+const maxLogLineSize = 16 * 1024 * 1024
+
 // LoggerFn is a the type of function received as
 // argument of the ksql.InjectLogger function.
 type LoggerFn func(ctx context.Context, values LogValues)
 
-type loggerFn func(ctx context.Context, query string, params []interface{}, err error)
-
 // InjectLogger is a debugging tool that allows the user to force
 // KSQL to log the query, query params and error response whenever
 // a query is executed.
@@ -103,20 +196,55 @@ func InjectLogger(
 	ctx context.Context,
 	logFn LoggerFn,
 ) context.Context {
-	return context.WithValue(ctx, loggerKey{}, loggerFn(func(ctx context.Context, query string, params []interface{}, err error) {
-		logFn(ctx, LogValues{
-			Query:  query,
-			Params: params,
-			Err:    err,
-		})
-	}))
+	return context.WithValue(ctx, loggerKey{}, logFn)
 }
 
-func ctxLog(ctx context.Context, query string, params []interface{}, err *error) {
+type traceIDKey struct{}
+
+// InjectTraceID adds a trace ID to the context which
+// is included on the logs of all the queries that use this
+// context, allowing the logs to be correlated with other
+// logs and traces of the same request, e.g.:
+//
+//	ctx = ksql.InjectTraceID(ctx, span.SpanContext().TraceID().String())
+func InjectTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// isLogging reports whether a logger was injected
+// in the context, so expensive log values can be skipped
+func isLogging(ctx context.Context) bool {
+	return ctx.Value(loggerKey{}) != nil
+}
+
+// ctxLog logs the query with the logger injected on the context, if any,
+// numRows is optional and should be nil if the number of rows is not available.
+func ctxLog(
+	ctx context.Context,
+	query string,
+	params []interface{},
+	startedAt time.Time,
+	numRows *int,
+	err *error,
+) {
 	l := ctx.Value(loggerKey{})
 	if l == nil {
 		return
 	}
 
-	l.(loggerFn)(ctx, query, params, *err)
+	rows := -1
+	if numRows != nil {
+		rows = *numRows
+	}
+
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+
+	l.(LoggerFn)(ctx, LogValues{
+		Query:    query,
+		Params:   params,
+		Err:      *err,
+		Duration: time.Since(startedAt),
+		Rows:     rows,
+		TraceID:  traceID,
+	})
 }
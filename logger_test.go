@@ -2,11 +2,15 @@ package ksql
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
 )
 
 func TestCtxLog(t *testing.T) {
@@ -24,12 +28,115 @@ func TestCtxLog(t *testing.T) {
 		}
 
 		panicPayload := tt.PanicHandler(func() {
-			ctxLog(ctx, "fakeQuery", []interface{}{}, nil)
+			ctxLog(ctx, "fakeQuery", []interface{}{}, time.Now(), nil, nil)
 		})
 		tt.AssertEqual(t, panicPayload, nil)
 		tt.AssertEqual(t, printedArgs, []interface{}(nil))
 	})
 
+	t.Run("should log the duration, number of rows and trace ID", func(t *testing.T) {
+		var loggedValues []LogValues
+		ctx := InjectLogger(ctx, func(ctx context.Context, values LogValues) {
+			loggedValues = append(loggedValues, values)
+		})
+		ctx = InjectTraceID(ctx, "fakeTraceID")
+
+		numRows := 2
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					return mockRows{
+						NextFn: func() bool {
+							numRows--
+							return numRows >= 0
+						},
+						ColumnsFn: func() ([]string, error) { return []string{"id"}, nil },
+						ScanFn: func(args ...interface{}) error {
+							time.Sleep(time.Millisecond)
+							return nil
+						},
+					}, nil
+				},
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					return mockResult{
+						RowsAffectedFn: func() (int64, error) {
+							return 3, nil
+						},
+					}, nil
+				},
+			},
+		}
+
+		var users []struct {
+			ID int `ksql:"id"`
+		}
+		err := c.Query(ctx, &users, "FROM users")
+		tt.AssertNoErr(t, err)
+
+		_, err = c.Exec(ctx, "UPDATE users SET age = 42")
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, len(loggedValues), 2)
+		tt.AssertEqual(t, loggedValues[0].Rows, 2)
+		tt.AssertEqual(t, loggedValues[0].TraceID, "fakeTraceID")
+		tt.AssertEqual(t, loggedValues[0].Duration >= 2*time.Millisecond, true)
+		tt.AssertEqual(t, loggedValues[1].Rows, 3)
+		tt.AssertEqual(t, loggedValues[1].TraceID, "fakeTraceID")
+	})
+}
+
+func TestLogRecord(t *testing.T) {
+	t.Run("should encode and parse the log records", func(t *testing.T) {
+		values := LogValues{
+			Query:    "SELECT * FROM users WHERE id = $1",
+			Params:   []interface{}{int64(9007199254740993)},
+			Err:      errors.New("fakeErrMsg"),
+			Duration: 1500 * time.Microsecond,
+			Rows:     -1,
+			TraceID:  "fakeTraceID",
+		}
+
+		b, err := json.Marshal(values)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, string(b), `{"query":"SELECT * FROM users WHERE id = $1","params":[9007199254740993],`+
+			`"duration_ms":1.5,"rows":-1,"error":"fakeErrMsg","trace_id":"fakeTraceID"}`)
+
+		record, err := ParseLogRecord(b)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, record, LogRecord{
+			Query:      "SELECT * FROM users WHERE id = $1",
+			Params:     []interface{}{json.Number("9007199254740993")},
+			DurationMS: 1.5,
+			Rows:       -1,
+			Err:        "fakeErrMsg",
+			TraceID:    "fakeTraceID",
+		})
+		tt.AssertEqual(t, record.Duration(), 1500*time.Microsecond)
+	})
+
+	t.Run("should parse multiple lines", func(t *testing.T) {
+		records, err := ParseLogRecords(strings.NewReader(
+			`{"query":"fakeQuery1","params":[],"duration_ms":1,"rows":1}` + "\n\n" +
+				`{"query":"fakeQuery2","params":["fakeParam"],"duration_ms":2,"rows":0}` + "\n",
+		))
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, records, []LogRecord{
+			{Query: "fakeQuery1", Params: []interface{}{}, DurationMS: 1, Rows: 1},
+			{Query: "fakeQuery2", Params: []interface{}{"fakeParam"}, DurationMS: 2, Rows: 0},
+		})
+	})
+
+	t.Run("should report invalid records", func(t *testing.T) {
+		_, err := ParseLogRecord([]byte("not json"))
+		tt.AssertErrContains(t, err, "KSQL", "log record")
+
+		_, err = ParseLogRecord([]byte(`{"params":[]}`))
+		tt.AssertErrContains(t, err, "KSQL", "missing query")
+
+		_, err = ParseLogRecords(strings.NewReader(`{"query":"fakeQuery"}` + "\nnot json\n"))
+		tt.AssertErrContains(t, err, "KSQL", "log record")
+	})
 }
 
 func TestBuiltinLoggers(t *testing.T) {
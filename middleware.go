@@ -0,0 +1,231 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// Operation describes a call to one of the methods of the Provider
+// interface, as received by the middlewares passed to Chain.
+type Operation struct {
+	// Method is the name of the Provider method, e.g. "Query"
+	Method string
+
+	// Table is the table used by the Insert, Patch and Delete methods
+	Table Table
+
+	// Record is the record received by Insert, Patch and QueryOne,
+	// the slice of records received by Query or the ID or record
+	// received by Delete
+	Record interface{}
+
+	// Query and Params are used by the Query,
+	// QueryOne, QueryChunks and Exec methods
+	Query  string
+	Params []interface{}
+
+	// ChunkParser is the argument of QueryChunks, except for its Query
+	// and Params, which are replaced by the ones on the Operation
+	ChunkParser ChunkParser
+
+	// TxFn is the callback of Transaction, it receives
+	// a Provider with the same middlewares applied
+	TxFn func(Provider) error
+
+	// PoolStats are the stats of the connection pool of the wrapped
+	// provider taken before the middlewares are called, e.g. for
+	// exporting the time spent waiting for connections with the
+	// metrics of each operation. It is nil if the provider doesn't
+	// report them, see DB.PoolStats.
+	PoolStats *PoolStats
+}
+
+// Handler executes an Operation, the returned Result is only set for Exec.
+type Handler func(ctx context.Context, op Operation) (Result, error)
+
+// Middleware wraps the Handler used for all the methods
+// of a Provider, just like an http middleware wraps an http.Handler.
+type Middleware func(next Handler) Handler
+
+// Chain returns a Provider that runs all its calls through the middlewares
+// before calling the input provider, which allows cross-cutting concerns
+// such as authorization checks, caching or metrics to be written once
+// instead of wrapping each method of the Provider interface, e.g.:
+//
+//	db = ksql.Chain(db, func(next ksql.Handler) ksql.Handler {
+//		return func(ctx context.Context, op ksql.Operation) (ksql.Result, error) {
+//			start := time.Now()
+//			result, err := next(ctx, op)
+//			metrics.Observe(op.Method, time.Since(start), err)
+//			return result, err
+//		}
+//	})
+//
+// The first middleware is the outermost one, so it is the first to receive
+// each Operation. The middlewares are also applied to the Provider received
+// by the Transaction callbacks.
+func Chain(provider Provider, middlewares ...Middleware) Provider {
+	handler := providerHandler(provider, middlewares)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return chainedProvider{
+		provider: provider,
+		handler:  withPoolStats(provider, handler),
+	}
+}
+
+// withPoolStats sets the PoolStats of the Operations
+// before they are received by the handler
+func withPoolStats(provider Provider, handler Handler) Handler {
+	statsProvider, ok := provider.(StatsProvider)
+	if !ok {
+		return handler
+	}
+
+	return func(ctx context.Context, op Operation) (Result, error) {
+		if stats, err := statsProvider.PoolStats(); err == nil {
+			op.PoolStats = &stats
+		}
+		return handler(ctx, op)
+	}
+}
+
+// providerHandler returns the innermost Handler of the chain,
+// which calls the method of the provider described by the Operation
+func providerHandler(provider Provider, middlewares []Middleware) Handler {
+	return func(ctx context.Context, op Operation) (Result, error) {
+		switch op.Method {
+		case "Insert":
+			return nil, provider.Insert(ctx, op.Table, op.Record)
+		case "Patch":
+			return nil, provider.Patch(ctx, op.Table, op.Record)
+		case "Delete":
+			return nil, provider.Delete(ctx, op.Table, op.Record)
+		case "Query":
+			return nil, provider.Query(ctx, op.Record, op.Query, op.Params...)
+		case "QueryOne":
+			return nil, provider.QueryOne(ctx, op.Record, op.Query, op.Params...)
+		case "QueryChunks":
+			parser := op.ChunkParser
+			parser.Query = op.Query
+			parser.Params = op.Params
+			return nil, provider.QueryChunks(ctx, parser)
+		case "Exec":
+			return provider.Exec(ctx, op.Query, op.Params...)
+		case "Transaction":
+			return nil, provider.Transaction(ctx, func(tx Provider) error {
+				return op.TxFn(Chain(tx, middlewares...))
+			})
+		}
+
+		return nil, fmt.Errorf("KSQL: unknown Provider method on Operation: '%s'", op.Method)
+	}
+}
+
+type chainedProvider struct {
+	provider Provider
+	handler  Handler
+}
+
+// Insert implements the Provider interface
+func (c chainedProvider) Insert(ctx context.Context, table Table, record interface{}) error {
+	_, err := c.handler(ctx, Operation{
+		Method: "Insert",
+		Table:  table,
+		Record: record,
+	})
+	return err
+}
+
+// Patch implements the Provider interface
+func (c chainedProvider) Patch(ctx context.Context, table Table, record interface{}) error {
+	_, err := c.handler(ctx, Operation{
+		Method: "Patch",
+		Table:  table,
+		Record: record,
+	})
+	return err
+}
+
+// Delete implements the Provider interface
+func (c chainedProvider) Delete(ctx context.Context, table Table, idOrRecord interface{}) error {
+	_, err := c.handler(ctx, Operation{
+		Method: "Delete",
+		Table:  table,
+		Record: idOrRecord,
+	})
+	return err
+}
+
+// Query implements the Provider interface
+func (c chainedProvider) Query(ctx context.Context, records interface{}, query string, params ...interface{}) error {
+	_, err := c.handler(ctx, Operation{
+		Method: "Query",
+		Record: records,
+		Query:  query,
+		Params: params,
+	})
+	return err
+}
+
+// QueryOne implements the Provider interface
+func (c chainedProvider) QueryOne(ctx context.Context, record interface{}, query string, params ...interface{}) error {
+	_, err := c.handler(ctx, Operation{
+		Method: "QueryOne",
+		Record: record,
+		Query:  query,
+		Params: params,
+	})
+	return err
+}
+
+// QueryChunks implements the Provider interface
+func (c chainedProvider) QueryChunks(ctx context.Context, parser ChunkParser) error {
+	_, err := c.handler(ctx, Operation{
+		Method:      "QueryChunks",
+		Query:       parser.Query,
+		Params:      parser.Params,
+		ChunkParser: parser,
+	})
+	return err
+}
+
+// Exec implements the Provider interface
+func (c chainedProvider) Exec(ctx context.Context, query string, params ...interface{}) (Result, error) {
+	return c.handler(ctx, Operation{
+		Method: "Exec",
+		Query:  query,
+		Params: params,
+	})
+}
+
+// Transaction implements the Provider interface
+func (c chainedProvider) Transaction(ctx context.Context, fn func(Provider) error) error {
+	_, err := c.handler(ctx, Operation{
+		Method: "Transaction",
+		TxFn:   fn,
+	})
+	return err
+}
+
+// Dialect returns the dialect of the wrapped provider, if known,
+// so that DialectOf also works with chained providers
+func (c chainedProvider) Dialect() sqldialect.Provider {
+	dialect, _ := DialectOf(c.provider)
+	return dialect
+}
+
+// SendBatch implements the Batcher interface by forwarding the
+// queries to the wrapped provider, if it implements Batcher,
+// so the batches are not received by the middlewares.
+func (c chainedProvider) SendBatch(ctx context.Context, queries []BatchQuery) ([]int64, error) {
+	batcher, ok := c.provider.(Batcher)
+	if !ok {
+		return nil, fmt.Errorf("KSQL: the wrapped Provider doesn't implement the Batcher interface: %T", c.provider)
+	}
+	return batcher.SendBatch(ctx, queries)
+}
@@ -0,0 +1,187 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+
+	recordCalls := func(calls *[]string, name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, op Operation) (Result, error) {
+				*calls = append(*calls, name+":"+op.Method)
+				return next(ctx, op)
+			}
+		}
+	}
+
+	t.Run("should run the middlewares in order before each method", func(t *testing.T) {
+		var calls []string
+		mock := Mock{
+			InsertFn: func(ctx context.Context, table Table, record interface{}) error {
+				calls = append(calls, "Insert:"+table.Name())
+				return nil
+			},
+			QueryFn: func(ctx context.Context, records interface{}, query string, params ...interface{}) error {
+				calls = append(calls, "Query:"+query)
+				return nil
+			},
+			ExecFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+				calls = append(calls, "Exec:"+query)
+				return NewMockResult(1, 2), nil
+			},
+		}
+
+		db := Chain(mock, recordCalls(&calls, "first"), recordCalls(&calls, "second"))
+
+		err := db.Insert(ctx, NewTable("users"), &struct{}{})
+		tt.AssertNoErr(t, err)
+
+		err = db.Query(ctx, &[]struct{}{}, "FROM users")
+		tt.AssertNoErr(t, err)
+
+		result, err := db.Exec(ctx, "DELETE FROM users")
+		tt.AssertNoErr(t, err)
+		rowsAffected, err := result.RowsAffected()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, rowsAffected, int64(2))
+
+		tt.AssertEqual(t, calls, []string{
+			"first:Insert", "second:Insert", "Insert:users",
+			"first:Query", "second:Query", "Query:FROM users",
+			"first:Exec", "second:Exec", "Exec:DELETE FROM users",
+		})
+	})
+
+	t.Run("should allow middlewares to change the operation", func(t *testing.T) {
+		var receivedParser ChunkParser
+		mock := Mock{
+			QueryChunksFn: func(ctx context.Context, parser ChunkParser) error {
+				receivedParser = parser
+				return nil
+			},
+		}
+
+		db := Chain(mock, func(next Handler) Handler {
+			return func(ctx context.Context, op Operation) (Result, error) {
+				op.Query += " WHERE tenant_id = $1"
+				op.Params = append(op.Params, 42)
+				return next(ctx, op)
+			}
+		})
+
+		err := db.QueryChunks(ctx, ChunkParser{
+			Query:     "FROM users",
+			ChunkSize: 10,
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, receivedParser.Query, "FROM users WHERE tenant_id = $1")
+		tt.AssertEqual(t, receivedParser.Params, []interface{}{42})
+		tt.AssertEqual(t, receivedParser.ChunkSize, 10)
+	})
+
+	t.Run("should allow middlewares to stop the operation", func(t *testing.T) {
+		var called bool
+		mock := Mock{
+			DeleteFn: func(ctx context.Context, table Table, idOrRecord interface{}) error {
+				called = true
+				return nil
+			},
+		}
+
+		db := Chain(mock, func(next Handler) Handler {
+			return func(ctx context.Context, op Operation) (Result, error) {
+				if op.Method == "Delete" {
+					return nil, errors.New("fakeErrMsg: forbidden")
+				}
+				return next(ctx, op)
+			}
+		})
+
+		err := db.Delete(ctx, NewTable("users"), 42)
+		tt.AssertErrContains(t, err, "fakeErrMsg", "forbidden")
+		tt.AssertEqual(t, called, false)
+	})
+
+	t.Run("should apply the middlewares inside transactions", func(t *testing.T) {
+		var calls []string
+		mock := Mock{
+			QueryOneFn: func(ctx context.Context, record interface{}, query string, params ...interface{}) error {
+				calls = append(calls, "QueryOne")
+				return nil
+			},
+		}
+
+		db := Chain(mock, recordCalls(&calls, "mw"))
+
+		err := db.Transaction(ctx, func(tx Provider) error {
+			return tx.QueryOne(ctx, &struct{}{}, "FROM users")
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, calls, []string{"mw:Transaction", "mw:QueryOne", "QueryOne"})
+	})
+
+	t.Run("should send the pool stats of the wrapped provider to the middlewares", func(t *testing.T) {
+		fakeStats := PoolStats{MaxOpenConns: 10, InUse: 10, WaitCount: 3}
+		db, err := NewWithAdapter(mockStatsProvider{
+			mockTxBeginner: mockTxBeginner{
+				DBAdapter: mockDBAdapter{
+					ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+						return mockResult{}, nil
+					},
+				},
+			},
+			PoolStatsFn: func() (PoolStats, error) {
+				return fakeStats, nil
+			},
+		}, sqldialect.SupportedDialects["postgres"])
+		tt.AssertNoErr(t, err)
+
+		var stats []*PoolStats
+		chained := Chain(db, func(next Handler) Handler {
+			return func(ctx context.Context, op Operation) (Result, error) {
+				stats = append(stats, op.PoolStats)
+				return next(ctx, op)
+			}
+		})
+
+		_, err = chained.Exec(ctx, "DELETE FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, stats, []*PoolStats{&fakeStats})
+
+		stats = nil
+		_, err = Chain(Mock{
+			ExecFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+				return NewMockResult(0, 0), nil
+			},
+		}, func(next Handler) Handler {
+			return func(ctx context.Context, op Operation) (Result, error) {
+				stats = append(stats, op.PoolStats)
+				return next(ctx, op)
+			}
+		}).Exec(ctx, "DELETE FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, stats, []*PoolStats{nil})
+	})
+
+	t.Run("should report the dialect of the wrapped provider", func(t *testing.T) {
+		db := Chain(Mock{
+			DialectFn: func() sqldialect.Provider {
+				return sqldialect.SupportedDialects["postgres"]
+			},
+		})
+
+		dialect, ok := DialectOf(db)
+		tt.AssertEqual(t, ok, true)
+		tt.AssertEqual(t, dialect.DriverName(), "postgres")
+
+		_, ok = DialectOf(Chain(Mock{}))
+		tt.AssertEqual(t, ok, false)
+	})
+}
@@ -3,6 +3,8 @@ package ksql
 import (
 	"context"
 	"fmt"
+
+	"github.com/vingarcia/ksql/sqldialect"
 )
 
 var _ Provider = Mock{}
@@ -59,6 +61,9 @@ type Mock struct {
 
 	ExecFn        func(ctx context.Context, query string, params ...interface{}) (Result, error)
 	TransactionFn func(ctx context.Context, fn func(db Provider) error) error
+
+	// DialectFn is optional and is only used by the Dialect method
+	DialectFn func() sqldialect.Provider
 }
 
 // MockResult implements the Result interface returned by the Exec function
@@ -127,6 +132,12 @@ func (m Mock) SetFallbackDatabase(db Provider) Mock {
 		m.TransactionFn = db.Transaction
 	}
 
+	if dialect, ok := DialectOf(db); ok && m.DialectFn == nil {
+		m.DialectFn = func() sqldialect.Provider {
+			return dialect
+		}
+	}
+
 	return m
 }
 
@@ -211,6 +222,17 @@ func (m Mock) Transaction(ctx context.Context, fn func(db Provider) error) error
 	return m.TransactionFn(ctx, fn)
 }
 
+// Dialect mocks the behavior of the DB.Dialect method.
+// If DialectFn is set it will just call it returning the same return value.
+// If DialectFn is unset it will return nil, which makes
+// the ksql.DialectOf function report that the dialect is unknown.
+func (m Mock) Dialect() sqldialect.Provider {
+	if m.DialectFn == nil {
+		return nil
+	}
+	return m.DialectFn()
+}
+
 // NewMockResult returns a simple implementation of the Result interface.
 func NewMockResult(lastInsertID int64, rowsAffected int64) Result {
 	return MockResult{
@@ -0,0 +1,188 @@
+package ksql
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Queries stores named queries, usually loaded from .sql files with the
+// Queries.FromFS method, which can be executed by name with the
+// DB.QueryNamed method when set on the Config.Queries attribute.
+//
+// The zero value is an empty set of queries ready to use.
+type Queries struct {
+	byName map[string]string
+}
+
+// Add returns a copy of the Queries with the input query
+// registered with the input name, replacing any previous
+// query with the same name.
+//
+// Since each call copies all the queries, prefer the FromFS
+// method for loading a large number of queries.
+func (q Queries) Add(name string, query string) Queries {
+	byName := make(map[string]string, len(q.byName)+1)
+	for k, v := range q.byName {
+		byName[k] = v
+	}
+	byName[name] = query
+
+	return Queries{byName: byName}
+}
+
+// Get returns the query registered with the input name
+func (q Queries) Get(name string) (query string, found bool) {
+	query, found = q.byName[name]
+	return query, found
+}
+
+// Names returns the names of all the registered queries
+func (q Queries) Names() []string {
+	names := make([]string, 0, len(q.byName))
+	for name := range q.byName {
+		names = append(names, name)
+	}
+	return names
+}
+
+// queryNameRegex matches the headers used by sqlc for naming queries, e.g.:
+//
+//	-- name: GetUser :one
+var queryNameRegex = regexp.MustCompile(`^--\s*name:\s*(\w+)(?:\s+:\w+)?\s*$`)
+
+// addQueriesFromFile parses the content of a .sql file and adds all its
+// named queries to the byName map, the fileName is only used for the
+// error messages.
+func addQueriesFromFile(byName map[string]string, fileName string, content []byte) error {
+	var currentName string
+	var currentQuery []string
+	addCurrentQuery := func() error {
+		if currentName == "" {
+			return nil
+		}
+
+		query := strings.TrimSpace(strings.Join(currentQuery, "\n"))
+		if query == "" {
+			return fmt.Errorf("KSQL: the query '%s' on file '%s' is empty", currentName, fileName)
+		}
+
+		if _, found := byName[currentName]; found {
+			return fmt.Errorf("KSQL: the query name '%s' on file '%s' is duplicated", currentName, fileName)
+		}
+
+		byName[currentName] = query
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, len(content)+1)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		trimmedLine := strings.TrimSpace(line)
+
+		if match := queryNameRegex.FindStringSubmatch(trimmedLine); match != nil {
+			if err := addCurrentQuery(); err != nil {
+				return err
+			}
+
+			currentName = match[1]
+			currentQuery = nil
+			continue
+		}
+
+		if currentName == "" {
+			if trimmedLine != "" && !strings.HasPrefix(trimmedLine, "--") {
+				return fmt.Errorf(
+					"KSQL: found a query without a `-- name: <QueryName>` header on line %d of file '%s'",
+					lineNumber, fileName,
+				)
+			}
+			continue
+		}
+
+		currentQuery = append(currentQuery, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("KSQL: error reading file '%s': %w", fileName, err)
+	}
+
+	return addCurrentQuery()
+}
+
+// QueryNamed runs the query registered with the input name on Config.Queries.
+//
+// If records is a pointer to a slice it works exactly as the Query method,
+// otherwise it works as the QueryOne method, e.g.:
+//
+//	var user User
+//	err := db.QueryNamed(ctx, &user, "GetUser", userID)
+//
+//	var users []User
+//	err = db.QueryNamed(ctx, &users, "ListUsers")
+//
+// References with the `:name` syntax are bound to the params
+// injected on the context with InjectDefaultParams.
+func (c DB) QueryNamed(
+	ctx context.Context,
+	records interface{},
+	name string,
+	params ...interface{},
+) error {
+	query, params, err := c.getNamedQuery(ctx, name, params)
+	if err != nil {
+		return err
+	}
+
+	t := reflect.TypeOf(records)
+	if t != nil && t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Slice {
+		return c.Query(ctx, records, query, params...)
+	}
+
+	return c.QueryOne(ctx, records, query, params...)
+}
+
+// ExecNamed runs the statement registered with the input name on
+// Config.Queries, which is usually annotated with `:exec`, and works
+// exactly as the Exec method, e.g.:
+//
+//	result, err := db.ExecNamed(ctx, "DeleteInactiveUsers", cutoffDate)
+//
+// References with the `:name` syntax are bound to the params
+// injected on the context with InjectDefaultParams.
+func (c DB) ExecNamed(
+	ctx context.Context,
+	name string,
+	params ...interface{},
+) (Result, error) {
+	query, params, err := c.getNamedQuery(ctx, name, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Exec(ctx, query, params...)
+}
+
+// getNamedQuery returns the query registered with the input
+// name with the default params of the context bound to it
+func (c DB) getNamedQuery(
+	ctx context.Context,
+	name string,
+	params []interface{},
+) (string, []interface{}, error) {
+	query, found := c.config.Queries.Get(name)
+	if !found {
+		return "", nil, fmt.Errorf("KSQL: no query named '%s' was found on Config.Queries", name)
+	}
+
+	query, params, err := bindDefaultParams(ctx, c.dialect, query, params, c.config.RequireDefaultParams)
+	if err != nil {
+		return "", nil, fmt.Errorf("KSQL: error binding the params of the query named '%s': %w", name, err)
+	}
+
+	return query, params, nil
+}
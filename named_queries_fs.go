@@ -0,0 +1,78 @@
+//go:build go1.16
+// +build go1.16
+
+package ksql
+
+import (
+	"io/fs"
+	"sort"
+)
+
+// FromFS returns a copy of the Queries with all the named queries
+// found on the .sql files of the input file system, which is usually
+// an embed.FS, using the same format used by sqlc, e.g.:
+//
+//	-- name: GetUser :one
+//	SELECT * FROM users WHERE id = $1;
+//
+//	-- name: ListUsers :many
+//	FROM users ORDER BY name;
+//
+//	-- name: DeleteUser :exec
+//	DELETE FROM users WHERE id = $1;
+//
+// The `:one`, `:many` and `:exec` annotations are optional and ignored,
+// since the method used for running the query decides how it runs:
+// statements are run with DB.ExecNamed and DB.QueryNamed decides how to
+// scan the results by the type of its records argument. Files on
+// subdirectories are also loaded, and query names must be unique among
+// all files.
+//
+// Example Usage:
+//
+//	//go:embed queries/*.sql
+//	var queryFiles embed.FS
+//
+//	queries, err := ksql.Queries{}.FromFS(queryFiles)
+//	...
+//	db, err := kpgx.New(ctx, connStr, ksql.Config{
+//		Queries: queries,
+//	})
+func (q Queries) FromFS(fsys fs.FS) (Queries, error) {
+	var fileNames []string
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() && len(path) > 4 && path[len(path)-4:] == ".sql" {
+			fileNames = append(fileNames, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return Queries{}, err
+	}
+
+	// Making the errors deterministic:
+	sort.Strings(fileNames)
+
+	byName := make(map[string]string, len(q.byName))
+	for name, query := range q.byName {
+		byName[name] = query
+	}
+
+	for _, fileName := range fileNames {
+		content, err := fs.ReadFile(fsys, fileName)
+		if err != nil {
+			return Queries{}, err
+		}
+
+		err = addQueriesFromFile(byName, fileName, content)
+		if err != nil {
+			return Queries{}, err
+		}
+	}
+
+	return Queries{byName: byName}, nil
+}
@@ -0,0 +1,259 @@
+//go:build go1.16
+// +build go1.16
+
+package ksql
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestQueriesFromFS(t *testing.T) {
+	t.Run("should load all named queries from the .sql files", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"users.sql": &fstest.MapFile{Data: []byte(`
+-- Queries for the users table
+
+-- name: GetUser :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: ListUsers :many
+FROM users ORDER BY name;
+`)},
+			"posts/posts.sql": &fstest.MapFile{Data: []byte(`-- name: GetPost
+FROM posts WHERE id = $1`)},
+			"README.md": &fstest.MapFile{Data: []byte("ignored")},
+		}
+
+		queries, err := Queries{}.FromFS(fsys)
+		tt.AssertNoErr(t, err)
+
+		names := queries.Names()
+		sort.Strings(names)
+		tt.AssertEqual(t, names, []string{"GetPost", "GetUser", "ListUsers"})
+
+		query, found := queries.Get("GetUser")
+		tt.AssertEqual(t, found, true)
+		tt.AssertEqual(t, query, "SELECT * FROM users\nWHERE id = $1;")
+
+		query, found = queries.Get("ListUsers")
+		tt.AssertEqual(t, found, true)
+		tt.AssertEqual(t, query, "FROM users ORDER BY name;")
+
+		query, found = queries.Get("GetPost")
+		tt.AssertEqual(t, found, true)
+		tt.AssertEqual(t, query, "FROM posts WHERE id = $1")
+	})
+
+	t.Run("should not change the original Queries", func(t *testing.T) {
+		original := Queries{}.Add("Foo", "SELECT 1")
+
+		queries, err := original.FromFS(fstest.MapFS{
+			"bar.sql": &fstest.MapFile{Data: []byte("-- name: Bar\nSELECT 2")},
+		})
+		tt.AssertNoErr(t, err)
+
+		_, found := original.Get("Bar")
+		tt.AssertEqual(t, found, false)
+		_, found = queries.Get("Foo")
+		tt.AssertEqual(t, found, true)
+	})
+
+	t.Run("should report invalid files", func(t *testing.T) {
+		tests := []struct {
+			desc               string
+			files              fstest.MapFS
+			expectErrToContain []string
+		}{
+			{
+				desc: "query without a name",
+				files: fstest.MapFS{
+					"users.sql": &fstest.MapFile{Data: []byte("-- comment\nSELECT 1")},
+				},
+				expectErrToContain: []string{"KSQL", "users.sql", "line 2", "name:"},
+			},
+			{
+				desc: "empty query",
+				files: fstest.MapFS{
+					"users.sql": &fstest.MapFile{Data: []byte("-- name: GetUser\n\n-- name: ListUsers\nFROM users")},
+				},
+				expectErrToContain: []string{"KSQL", "users.sql", "GetUser", "empty"},
+			},
+			{
+				desc: "duplicated names",
+				files: fstest.MapFS{
+					"a.sql": &fstest.MapFile{Data: []byte("-- name: GetUser\nSELECT 1")},
+					"b.sql": &fstest.MapFile{Data: []byte("-- name: GetUser\nSELECT 2")},
+				},
+				expectErrToContain: []string{"KSQL", "b.sql", "GetUser", "duplicated"},
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				_, err := Queries{}.FromFS(test.files)
+				tt.AssertErrContains(t, err, test.expectErrToContain...)
+			})
+		}
+	})
+}
+
+func TestQueryNamed(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	queries := Queries{}.
+		Add("GetUser", "SELECT id, name FROM users WHERE id = $1").
+		Add("ListUsers", "SELECT id, name FROM users").
+		Add("ListTenantUsers", "SELECT id, name FROM users WHERE tenant_id = :tenant_id AND age > $1")
+
+	var paramsRan [][]interface{}
+	newDB := func(queriesRan *[]string, numRows int) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			config:  Config{Queries: queries},
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+					*queriesRan = append(*queriesRan, query)
+					paramsRan = append(paramsRan, args)
+					return &mockRows{
+						NextFn: func() bool {
+							numRows--
+							return numRows >= 0
+						},
+						ColumnsFn: func() ([]string, error) {
+							return []string{"id", "name"}, nil
+						},
+						ScanFn: func(args ...interface{}) error {
+							*args[0].(*int) = 42
+							*args[1].(*string) = "fakeName"
+							return nil
+						},
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should work as QueryOne for pointers to structs", func(t *testing.T) {
+		var queriesRan []string
+		c := newDB(&queriesRan, 1)
+
+		var user User
+		err := c.QueryNamed(ctx, &user, "GetUser", 42)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, user, User{ID: 42, Name: "fakeName"})
+		tt.AssertEqual(t, queriesRan, []string{"SELECT id, name FROM users WHERE id = $1"})
+	})
+
+	t.Run("should work as Query for pointers to slices", func(t *testing.T) {
+		var queriesRan []string
+		c := newDB(&queriesRan, 2)
+
+		var users []User
+		err := c.QueryNamed(ctx, &users, "ListUsers")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, users, []User{{ID: 42, Name: "fakeName"}, {ID: 42, Name: "fakeName"}})
+		tt.AssertEqual(t, queriesRan, []string{"SELECT id, name FROM users"})
+	})
+
+	t.Run("should bind the params injected with InjectDefaultParams", func(t *testing.T) {
+		var queriesRan []string
+		paramsRan = nil
+		c := newDB(&queriesRan, 1)
+
+		ctx := InjectDefaultParams(ctx, map[string]interface{}{"tenant_id": 7})
+
+		var users []User
+		err := c.QueryNamed(ctx, &users, "ListTenantUsers", 18)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queriesRan, []string{"SELECT id, name FROM users WHERE tenant_id = $2 AND age > $1"})
+		tt.AssertEqual(t, paramsRan, [][]interface{}{{18, 7}})
+	})
+
+	t.Run("should report missing default params if they are required", func(t *testing.T) {
+		var queriesRan []string
+		c := newDB(&queriesRan, 1)
+		c.config.RequireDefaultParams = true
+
+		var users []User
+		err := c.QueryNamed(ctx, &users, "ListTenantUsers", 18)
+		tt.AssertErrContains(t, err, "KSQL", "ListTenantUsers", ":tenant_id")
+		tt.AssertEqual(t, len(queriesRan), 0)
+	})
+
+	t.Run("should report unknown query names", func(t *testing.T) {
+		var queriesRan []string
+		c := newDB(&queriesRan, 1)
+
+		var user User
+		err := c.QueryNamed(ctx, &user, "NotAQuery")
+		tt.AssertErrContains(t, err, "KSQL", "NotAQuery")
+		tt.AssertEqual(t, len(queriesRan), 0)
+	})
+}
+
+func TestExecNamed(t *testing.T) {
+	ctx := context.Background()
+
+	queries, err := Queries{}.FromFS(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`
+-- name: DeleteTenantUser :exec
+DELETE FROM users WHERE tenant_id = :tenant_id AND id = $1;
+`)},
+	})
+	tt.AssertNoErr(t, err)
+
+	newDB := func(queriesRan *[]string, paramsRan *[][]interface{}) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			config:  Config{Queries: queries},
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+					*queriesRan = append(*queriesRan, query)
+					*paramsRan = append(*paramsRan, args)
+					return mockResult{
+						RowsAffectedFn: func() (int64, error) {
+							return 1, nil
+						},
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should run the statement with the default params bound", func(t *testing.T) {
+		var queriesRan []string
+		var paramsRan [][]interface{}
+		c := newDB(&queriesRan, &paramsRan)
+
+		ctx := InjectDefaultParams(ctx, map[string]interface{}{"tenant_id": 7})
+
+		result, err := c.ExecNamed(ctx, "DeleteTenantUser", 42)
+		tt.AssertNoErr(t, err)
+		n, err := result.RowsAffected()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, n, int64(1))
+		tt.AssertEqual(t, queriesRan, []string{"DELETE FROM users WHERE tenant_id = $2 AND id = $1;"})
+		tt.AssertEqual(t, paramsRan, [][]interface{}{{42, 7}})
+	})
+
+	t.Run("should report unknown query names", func(t *testing.T) {
+		var queriesRan []string
+		var paramsRan [][]interface{}
+		c := newDB(&queriesRan, &paramsRan)
+
+		_, err := c.ExecNamed(ctx, "NotAQuery")
+		tt.AssertErrContains(t, err, "KSQL", "NotAQuery")
+		tt.AssertEqual(t, len(queriesRan), 0)
+	})
+}
@@ -0,0 +1,293 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Option configures the behavior of the KSQL calls
+// that receive a context created with `InjectOptions`.
+type Option func(*callOptions)
+
+// callOptions holds the per-call configurations
+// that can be changed using Options.
+type callOptions struct {
+	rawQuery         bool
+	reuseSlice       bool
+	qualifiedColumns bool
+
+	// qualifiedTables are the tables whose aliases, or names,
+	// prefix the columns of the SELECT generated for queries
+	// starting with `FROM`
+	qualifiedTables []Table
+
+	// columnAliases maps the column names returned by
+	// the queries to the column names on the struct tags
+	columnAliases map[string]string
+
+	// maxRows is only used if maxRowsSet is true,
+	// so the option can also disable the limit
+	maxRows    int
+	maxRowsSet bool
+
+	orderBy []string
+	limit   int
+	offset  int
+
+	replicaRetries int
+
+	// columnValues are the column values set with the Set
+	// option, which override the values on the structs
+	columnValues map[string]interface{}
+
+	shardKeys []ShardKeyValue
+}
+
+type optionsKey struct{}
+
+// InjectOptions returns a copy of `ctx` carrying the given options,
+// all KSQL calls made with the returned context will use them.
+//
+// Options injected on a context that already has options
+// are merged with the previous ones, e.g.:
+//
+//	ctx = ksql.InjectOptions(ctx, ksql.RawQuery())
+//
+//	// This query will be sent to the database unchanged:
+//	err := db.Query(ctx, &users, "FROM users u SELECT ...")
+func InjectOptions(ctx context.Context, opts ...Option) context.Context {
+	options := getCallOptions(ctx)
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return context.WithValue(ctx, optionsKey{}, options)
+}
+
+func getCallOptions(ctx context.Context) callOptions {
+	options, _ := ctx.Value(optionsKey{}).(callOptions)
+	return options
+}
+
+// withoutCallOptions returns a copy of `ctx` without the injected options,
+// so they don't change the queries KSQL builds and runs internally.
+//
+// Only the ShardKey option is kept, since the internal
+// queries must be routed with the rest of the operation.
+func withoutCallOptions(ctx context.Context) context.Context {
+	options := getCallOptions(ctx)
+	return context.WithValue(ctx, optionsKey{}, callOptions{
+		shardKeys: options.shardKeys,
+	})
+}
+
+// RawQuery disables the automatic generation of the SELECT part
+// of the query for queries starting with the `FROM` token.
+//
+// This is useful for queries that legitimately start with `FROM`
+// and should be sent to the database exactly as they were written.
+func RawQuery() Option {
+	return func(o *callOptions) {
+		o.rawQuery = true
+	}
+}
+
+// ReuseSlice makes Query reuse the backing array of the input slice,
+// as well as the structs referenced by a slice of pointers, instead
+// of allocating new ones. This is useful for high-frequency polling
+// loops where the same slice is passed to Query on every iteration.
+//
+// When this option is used the slice length is always reset to
+// the number of rows returned, and each reused element is zeroed
+// before scanning, so no data from previous calls is kept.
+//
+// Note that when reusing a slice of pointers the structs previously
+// returned by Query are overwritten, so they should not be retained
+// between calls.
+func ReuseSlice() Option {
+	return func(o *callOptions) {
+		o.reuseSlice = true
+	}
+}
+
+// QualifiedColumns allows queries with joins to return columns with the
+// same name, e.g. `users.id` and `posts.id`, on flat structs.
+//
+// When this option is used the table-qualified columns written on the
+// SELECT clause without an alias receive their qualified names as aliases,
+// so they can be mapped to attributes tagged with the qualified names:
+//
+//	type UserPost struct {
+//		UserID int    `ksql:"u.id"`
+//		PostID int    `ksql:"p.id"`
+//		Title  string `ksql:"title"`
+//	}
+//
+//	ctx = ksql.InjectOptions(ctx, ksql.QualifiedColumns())
+//	err := db.Query(ctx, &userPosts, "SELECT u.id, p.id, p.title FROM users u JOIN posts p ON p.user_id = u.id")
+//
+// Qualified columns that don't match any qualified tag are mapped to
+// the attribute tagged with the column name, e.g. `p.title` to `title`.
+//
+// Without this option queries returning two columns mapped to
+// the same attribute return an error.
+func QualifiedColumns() Option {
+	return func(o *callOptions) {
+		o.qualifiedColumns = true
+	}
+}
+
+// QualifiedSelect makes queries starting with `FROM` prefix the columns of the
+// generated SELECT with the alias of the input table, or with its name if
+// it has no alias, e.g.:
+//
+//	ctx = ksql.InjectOptions(ctx, ksql.QualifiedSelect(UsersTable.WithAlias("u")))
+//
+//	// SELECT u."id", u."name" FROM users u JOIN posts p ...
+//	err := db.Query(ctx, &users, "FROM users u JOIN posts p ON p.user_id = u.id WHERE p.title = $1", title)
+//
+// This prevents ambiguous column errors when joins are added to
+// queries that started as single table queries.
+//
+// For nested structs it receives one table for each `tablename` tag that
+// should be aliased, matching the tag against the name of the table, so
+// the columns of the nested struct are prefixed with the alias instead:
+//
+//	type UserPost struct {
+//		User User `tablename:"users"`
+//		Post Post `tablename:"posts"`
+//	}
+//
+//	ctx = ksql.InjectOptions(ctx, ksql.QualifiedSelect(UsersTable.WithAlias("u"), PostsTable.WithAlias("p")))
+//
+//	// SELECT u."id", u."name", p."id", p."title" FROM users u JOIN posts p ...
+//	err := db.Query(ctx, &userPosts, "FROM users u JOIN posts p ON p.user_id = u.id")
+//
+// Note that the FROM clause is still written by the user, so the
+// table must be referenced there with the same alias or name.
+func QualifiedSelect(tables ...Table) Option {
+	return func(o *callOptions) {
+		o.qualifiedTables = tables
+	}
+}
+
+// Alias maps the columns returned by the query to the columns on the
+// `ksql` tags of the struct, so the same struct can be used with queries
+// whose column names or aliases are different from its tags, e.g.:
+//
+//	type Address struct {
+//		ID   int    `ksql:"id"`
+//		Line string `ksql:"address_line"`
+//	}
+//
+//	ctx = ksql.InjectOptions(ctx, ksql.Alias(map[string]string{"full_addr": "address_line"}))
+//	err := db.Query(ctx, &addresses, "SELECT id, street || ', ' || city AS full_addr FROM addresses")
+//
+// The keys of the map are the column names returned by the query and
+// the values are the column names on the tags. For queries starting
+// with `FROM` the generated SELECT uses the query column names.
+//
+// Using this option more than once merges the maps.
+func Alias(aliases map[string]string) Option {
+	return func(o *callOptions) {
+		merged := make(map[string]string, len(o.columnAliases)+len(aliases))
+		for queryColumn, tagColumn := range o.columnAliases {
+			merged[queryColumn] = tagColumn
+		}
+		for queryColumn, tagColumn := range aliases {
+			merged[queryColumn] = tagColumn
+		}
+		o.columnAliases = merged
+	}
+}
+
+// MaxRows limits the number of rows Query can load into memory,
+// overriding the Config.MaxQueryRows limit for this call.
+//
+// If the query returns more than `n` rows Query stops reading them
+// and returns a TooManyRowsError, if `n` is 0 the limit is disabled.
+func MaxRows(n int) Option {
+	return func(o *callOptions) {
+		o.maxRows = n
+		o.maxRowsSet = true
+	}
+}
+
+// OrderBy adds an ORDER BY clause to queries starting with `FROM`,
+// each argument should be a column name optionally followed
+// by the ASC or DESC keywords, e.g.:
+//
+//	ctx = ksql.InjectOptions(ctx, ksql.OrderBy("name ASC", "id"), ksql.Limit(10))
+//	err := db.Query(ctx, &users, "FROM users WHERE age > $1", 18)
+//
+// Arguments that are not column names are rejected
+// to prevent SQL injection.
+//
+// Queries that don't start with `FROM`, or that already have
+// an ORDER BY clause, return an error when used with this option.
+// The queries KSQL builds internally ignore it.
+func OrderBy(columns ...string) Option {
+	return func(o *callOptions) {
+		o.orderBy = columns
+	}
+}
+
+// Limit limits the number of rows returned by queries starting with `FROM`,
+// rendering the syntax supported by each dialect, e.g. `LIMIT` on Postgres
+// or `OFFSET ... FETCH NEXT ...` on SQL Server.
+//
+// Like OrderBy it returns an error on queries that don't start with `FROM`.
+func Limit(n int) Option {
+	return func(o *callOptions) {
+		o.limit = n
+	}
+}
+
+// Offset skips the first `n` rows returned by queries starting with `FROM`,
+// rendering the syntax supported by each dialect.
+//
+// Like OrderBy it returns an error on queries that don't start with `FROM`.
+func Offset(n int) Option {
+	return func(o *callOptions) {
+		o.offset = n
+	}
+}
+
+// Set adds a column to the queries built by Insert, InsertIgnore and the
+// Patch methods, or overrides the value of a struct attribute, without
+// modifying the struct, which is useful for system-level columns that
+// are not part of the domain model, e.g.:
+//
+//	ctx = ksql.InjectOptions(ctx, ksql.Set("updated_by", userID))
+//
+//	// UPDATE users SET "name" = $1, "updated_by" = $2 WHERE "id" = $3
+//	err := db.Patch(ctx, UsersTable, &user)
+//
+// The values are sent to the database as they are, i.e. the modifiers of
+// the overridden attributes are not applied, and the attributes skipped
+// by their modifiers are written anyway. ID columns can't be set on Patch.
+//
+// Using this option more than once merges the values.
+func Set(column string, value interface{}) Option {
+	return func(o *callOptions) {
+		merged := make(map[string]interface{}, len(o.columnValues)+1)
+		for col, v := range o.columnValues {
+			merged[col] = v
+		}
+		merged[column] = value
+		o.columnValues = merged
+	}
+}
+
+// getColumnValues returns the column values set with the Set option
+// after checking the column names can be safely escaped.
+func getColumnValues(ctx context.Context) (map[string]interface{}, error) {
+	columnValues := getCallOptions(ctx).columnValues
+	for col := range columnValues {
+		if col == "" || strings.ContainsAny(col, "\"`[]") {
+			return nil, fmt.Errorf("KSQL: invalid column name `%s` received by the Set option", col)
+		}
+	}
+	return columnValues, nil
+}
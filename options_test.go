@@ -0,0 +1,77 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestInjectOptions(t *testing.T) {
+	t.Run("should return the zero options if nothing was injected", func(t *testing.T) {
+		tt.AssertEqual(t, getCallOptions(context.Background()), callOptions{})
+	})
+
+	t.Run("should apply the injected options", func(t *testing.T) {
+		ctx := InjectOptions(context.Background(), RawQuery())
+		tt.AssertEqual(t, getCallOptions(ctx).rawQuery, true)
+	})
+
+	t.Run("should keep the options injected previously", func(t *testing.T) {
+		ctx := InjectOptions(context.Background(), RawQuery())
+		ctx = InjectOptions(ctx)
+		tt.AssertEqual(t, getCallOptions(ctx).rawQuery, true)
+	})
+}
+
+func TestSetOption(t *testing.T) {
+	dialect := sqldialect.SupportedDialects["postgres"]
+
+	type User struct {
+		ID        uint   `ksql:"id"`
+		Name      string `ksql:"name"`
+		CreatedBy string `ksql:"created_by,skipUpdates"`
+		Tags      []int  `ksql:"tags,json"`
+	}
+	usersTable := NewTable("users")
+
+	t.Run("should add the column values to inserts", func(t *testing.T) {
+		ctx := InjectOptions(context.Background(), Set("updated_by", 42), Set("origin", "api"))
+
+		user := User{Name: "fakeName", CreatedBy: "fakeCreator"}
+		query, params, _, err := BuildInsertQuery(ctx, dialect, usersTable, &user)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `INSERT INTO users ("name", "created_by", "tags", "origin", "updated_by") VALUES ($1, $2, $3, $4, $5) RETURNING "id"`)
+		tt.AssertEqual(t, params[3:], []interface{}{"api", 42})
+	})
+
+	t.Run("should override the struct values without applying their modifiers", func(t *testing.T) {
+		ctx := InjectOptions(context.Background(), Set("tags", "[1,2]"), Set("created_by", "system"))
+
+		query, params, err := BuildPatchQuery(ctx, dialect, usersTable, User{ID: 1, Name: "fakeName"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `UPDATE users SET "name" = $1, "created_by" = $2, "tags" = $3 WHERE "id" = $4`)
+		tt.AssertEqual(t, params, []interface{}{"fakeName", "system", "[1,2]", uint(1)})
+	})
+
+	t.Run("should not modify the input struct", func(t *testing.T) {
+		ctx := InjectOptions(context.Background(), Set("name", "overriddenName"))
+
+		user := User{Name: "fakeName"}
+		_, params, _, err := BuildInsertQuery(ctx, dialect, usersTable, &user)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, params[0], "overriddenName")
+		tt.AssertEqual(t, user.Name, "fakeName")
+	})
+
+	t.Run("should report errors", func(t *testing.T) {
+		ctx := InjectOptions(context.Background(), Set("id", 2))
+		_, _, err := BuildPatchQuery(ctx, dialect, usersTable, User{ID: 1, Name: "fakeName"})
+		tt.AssertErrContains(t, err, "KSQL", "ID column", "id")
+
+		ctx = InjectOptions(context.Background(), Set(`name" = 1; --`, 2))
+		_, _, _, err = BuildInsertQuery(ctx, dialect, usersTable, &User{Name: "fakeName"})
+		tt.AssertErrContains(t, err, "KSQL", "invalid column name")
+	})
+}
@@ -0,0 +1,130 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vingarcia/ksql/internal/sqlparse"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+var orderByRegex = regexp.MustCompile(
+	`(?i)^(?:(?:"[^"]+"|` + "`[^`]+`" + `|\[[^\]]+\]|[A-Za-z_][A-Za-z0-9_$]*)\.)*` +
+		`(?:"[^"]+"|` + "`[^`]+`" + `|\[[^\]]+\]|[A-Za-z_][A-Za-z0-9_$]*)` +
+		`(?:\s+(?:ASC|DESC))?(?:\s+NULLS\s+(?:FIRST|LAST))?$`,
+)
+
+func (o callOptions) hasPagination() bool {
+	return len(o.orderBy) > 0 || o.limit != 0 || o.offset != 0
+}
+
+// buildPaginationSuffix renders the OrderBy, Limit and Offset
+// options using the syntax supported by the input dialect.
+//
+// The queryHasOrderBy argument tells if the query the suffix is
+// appended to has its own ORDER BY clause.
+func buildPaginationSuffix(dialect sqldialect.Provider, options callOptions, queryHasOrderBy bool) (string, error) {
+	if options.limit < 0 || options.offset < 0 {
+		return "", fmt.Errorf(
+			"KSQL: Limit and Offset must not be negative, but got Limit(%d) and Offset(%d)",
+			options.limit, options.offset,
+		)
+	}
+
+	var orderBy []string
+	for _, column := range options.orderBy {
+		column = strings.TrimSpace(column)
+		if !orderByRegex.MatchString(column) {
+			return "", fmt.Errorf(
+				"KSQL: invalid OrderBy argument `%s`: expected a column name optionally followed by ASC or DESC",
+				column,
+			)
+		}
+		orderBy = append(orderBy, column)
+	}
+
+	var suffix string
+	if len(orderBy) > 0 {
+		suffix = " ORDER BY " + strings.Join(orderBy, ", ")
+	}
+
+	if options.limit == 0 && options.offset == 0 {
+		return suffix, nil
+	}
+
+	limit := strconv.Itoa(options.limit)
+	offset := strconv.Itoa(options.offset)
+	switch dialect.DriverName() {
+	case "sqlserver":
+		if len(orderBy) == 0 && !queryHasOrderBy {
+			// SQL Server only accepts OFFSET and FETCH after an ORDER BY clause:
+			suffix = " ORDER BY (SELECT NULL)"
+		}
+		suffix += " OFFSET " + offset + " ROWS"
+		if options.limit > 0 {
+			suffix += " FETCH NEXT " + limit + " ROWS ONLY"
+		}
+		return suffix, nil
+
+	case "mysql", "sqlite3":
+		if options.limit == 0 {
+			// MySQL and SQLite don't accept OFFSET without a LIMIT,
+			// so we use the largest limit each of them accepts:
+			limit = "18446744073709551615"
+			if dialect.DriverName() == "sqlite3" {
+				limit = "-1"
+			}
+		}
+		suffix += " LIMIT " + limit
+		if options.offset > 0 {
+			suffix += " OFFSET " + offset
+		}
+		return suffix, nil
+
+	default:
+		if options.limit > 0 {
+			suffix += " LIMIT " + limit
+		}
+		if options.offset > 0 {
+			suffix += " OFFSET " + offset
+		}
+		return suffix, nil
+	}
+}
+
+// applyPagination appends the OrderBy, Limit and Offset options
+// injected on the context to queries starting with `FROM`.
+//
+// Since the other queries can't be changed safely by KSQL
+// an error is returned if they are used with these options.
+func applyPagination(ctx context.Context, dialect sqldialect.Provider, query string, buildSelect bool) (string, error) {
+	options := getCallOptions(ctx)
+	if !options.hasPagination() {
+		return query, nil
+	}
+
+	if !buildSelect {
+		return "", fmt.Errorf(
+			"KSQL: the OrderBy, Limit and Offset options can only be used on queries starting with FROM and without the RawQuery option",
+		)
+	}
+
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	queryHasOrderBy := sqlparse.HasOrderBy(query)
+	if queryHasOrderBy && len(options.orderBy) > 0 {
+		return "", fmt.Errorf(
+			"KSQL: the OrderBy option can't be used on queries that already have an ORDER BY clause: %s",
+			query,
+		)
+	}
+
+	suffix, err := buildPaginationSuffix(dialect, options, queryHasOrderBy)
+	if err != nil {
+		return "", err
+	}
+
+	return query + suffix, nil
+}
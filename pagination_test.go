@@ -0,0 +1,226 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestBuildPaginationSuffix(t *testing.T) {
+	tests := []struct {
+		desc         string
+		dialect      string
+		options      []Option
+		expectSuffix string
+	}{
+		{
+			desc:         "should render nothing without options",
+			dialect:      "postgres",
+			expectSuffix: "",
+		},
+		{
+			desc:         "should render ORDER BY",
+			dialect:      "postgres",
+			options:      []Option{OrderBy("name ASC", "u.id", `"age" DESC NULLS LAST`)},
+			expectSuffix: ` ORDER BY name ASC, u.id, "age" DESC NULLS LAST`,
+		},
+		{
+			desc:         "should render LIMIT and OFFSET on postgres",
+			dialect:      "postgres",
+			options:      []Option{OrderBy("name"), Limit(10), Offset(20)},
+			expectSuffix: " ORDER BY name LIMIT 10 OFFSET 20",
+		},
+		{
+			desc:         "should render OFFSET without LIMIT on postgres",
+			dialect:      "postgres",
+			options:      []Option{Offset(20)},
+			expectSuffix: " OFFSET 20",
+		},
+		{
+			desc:         "should render LIMIT on mysql",
+			dialect:      "mysql",
+			options:      []Option{Limit(10)},
+			expectSuffix: " LIMIT 10",
+		},
+		{
+			desc:         "should render OFFSET without LIMIT on mysql",
+			dialect:      "mysql",
+			options:      []Option{Offset(20)},
+			expectSuffix: " LIMIT 18446744073709551615 OFFSET 20",
+		},
+		{
+			desc:         "should render OFFSET without LIMIT on sqlite3",
+			dialect:      "sqlite3",
+			options:      []Option{Offset(20)},
+			expectSuffix: " LIMIT -1 OFFSET 20",
+		},
+		{
+			desc:         "should render OFFSET and FETCH on sqlserver",
+			dialect:      "sqlserver",
+			options:      []Option{OrderBy("[name] DESC"), Limit(10), Offset(20)},
+			expectSuffix: " ORDER BY [name] DESC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY",
+		},
+		{
+			desc:         "should add a placeholder ORDER BY on sqlserver",
+			dialect:      "sqlserver",
+			options:      []Option{Limit(10)},
+			expectSuffix: " ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := InjectOptions(context.Background(), test.options...)
+			suffix, err := buildPaginationSuffix(sqldialect.SupportedDialects[test.dialect], getCallOptions(ctx), false)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, suffix, test.expectSuffix)
+		})
+	}
+
+	t.Run("should reject invalid arguments", func(t *testing.T) {
+		tests := []struct {
+			desc               string
+			options            []Option
+			expectErrToContain []string
+		}{
+			{
+				desc:               "expressions on OrderBy",
+				options:            []Option{OrderBy("name; DROP TABLE users")},
+				expectErrToContain: []string{"KSQL", "OrderBy", "DROP TABLE"},
+			},
+			{
+				desc:               "invalid directions on OrderBy",
+				options:            []Option{OrderBy("name UP")},
+				expectErrToContain: []string{"KSQL", "OrderBy", "name UP"},
+			},
+			{
+				desc:               "negative limits",
+				options:            []Option{Limit(-1)},
+				expectErrToContain: []string{"KSQL", "Limit(-1)"},
+			},
+			{
+				desc:               "negative offsets",
+				options:            []Option{Offset(-1)},
+				expectErrToContain: []string{"KSQL", "Offset(-1)"},
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				ctx := InjectOptions(context.Background(), test.options...)
+				_, err := buildPaginationSuffix(sqldialect.PostgresDialect{}, getCallOptions(ctx), false)
+				tt.AssertErrContains(t, err, test.expectErrToContain...)
+			})
+		}
+	})
+}
+
+func TestPaginationOptions(t *testing.T) {
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	var queries []string
+	c := DB{
+		dialect: sqldialect.SupportedDialects["postgres"],
+		db: mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				queries = append(queries, query)
+				return &mockRows{
+					NextFn: func() bool { return false },
+				}, nil
+			},
+		},
+	}
+
+	ctx := InjectOptions(context.Background(), OrderBy("name"), Limit(10))
+
+	t.Run("should append the options to queries starting with FROM", func(t *testing.T) {
+		queries = nil
+
+		var users []User
+		err := c.Query(ctx, &users, "FROM users WHERE age > $1;", 18)
+		tt.AssertNoErr(t, err)
+
+		var user User
+		err = c.QueryOne(ctx, &user, "FROM users WHERE age > $1", 18)
+		tt.AssertEqual(t, err, ErrRecordNotFound)
+
+		err = c.QueryChunks(ctx, ChunkParser{
+			Query:     "FROM users WHERE age > $1",
+			Params:    []interface{}{18},
+			ChunkSize: 10,
+			ForEachChunk: func(users []User) error {
+				return nil
+			},
+		})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, queries, []string{
+			`SELECT "id", "name" FROM users WHERE age > $1 ORDER BY name LIMIT 10`,
+			`SELECT "id", "name" FROM users WHERE age > $1 ORDER BY name LIMIT 10`,
+			`SELECT "id", "name" FROM users WHERE age > $1 ORDER BY name LIMIT 10`,
+		})
+	})
+
+	t.Run("should return an error on queries starting with SELECT", func(t *testing.T) {
+		queries = nil
+
+		var users []User
+		err := c.Query(ctx, &users, "SELECT id, name FROM users")
+		tt.AssertErrContains(t, err, "KSQL", "OrderBy", "FROM")
+
+		err = c.QueryChunks(ctx, ChunkParser{
+			Query:     "SELECT id, name FROM users WHERE age > $1",
+			Params:    []interface{}{18},
+			ChunkSize: 10,
+			ForEachChunk: func(users []User) error {
+				return nil
+			},
+		})
+		tt.AssertErrContains(t, err, "KSQL", "OrderBy", "FROM")
+
+		tt.AssertEqual(t, len(queries), 0)
+	})
+
+	t.Run("should return an error when using the RawQuery option", func(t *testing.T) {
+		queries = nil
+
+		var user User
+		err := c.QueryOne(InjectOptions(ctx, RawQuery()), &user, "FROM users WHERE age > $1", 18)
+		tt.AssertErrContains(t, err, "KSQL", "OrderBy", "RawQuery")
+
+		tt.AssertEqual(t, len(queries), 0)
+	})
+
+	t.Run("should return an error if the query already has an ORDER BY clause", func(t *testing.T) {
+		queries = nil
+
+		var users []User
+		err := c.Query(ctx, &users, "FROM users ORDER BY age")
+		tt.AssertErrContains(t, err, "KSQL", "OrderBy", "ORDER BY age")
+
+		tt.AssertEqual(t, len(queries), 0)
+	})
+
+	t.Run("should append Limit and Offset after the ORDER BY clause of the query", func(t *testing.T) {
+		queries = nil
+
+		ctx := InjectOptions(context.Background(), Limit(10), Offset(20))
+
+		var users []User
+		err := c.Query(ctx, &users, "FROM users WHERE id IN (SELECT user_id FROM posts ORDER BY id LIMIT 5) ORDER BY age")
+		tt.AssertNoErr(t, err)
+
+		c := c
+		c.dialect = sqldialect.SupportedDialects["sqlserver"]
+		err = c.Query(ctx, &users, "FROM users ORDER BY age")
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, queries, []string{
+			`SELECT "id", "name" FROM users WHERE id IN (SELECT user_id FROM posts ORDER BY id LIMIT 5) ORDER BY age LIMIT 10 OFFSET 20`,
+			`SELECT [id], [name] FROM users ORDER BY age OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY`,
+		})
+	})
+}
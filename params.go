@@ -0,0 +1,174 @@
+package ksql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/vingarcia/ksql/internal/sqlparse"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// checkQuery runs the validations configured for the queries written
+// by the user as well as the checkParams validations on their params.
+func (c DB) checkQuery(ctx context.Context, query string, params []interface{}) error {
+	if c.config.ForbidUnparameterizedStrings {
+		if literals := sqlparse.StringLiterals(query); len(literals) > 0 {
+			return fmt.Errorf(
+				"KSQL: the query contains the string literal %s, but unparameterized strings are forbidden"+
+					" by the ForbidUnparameterizedStrings option, pass it as a param instead",
+				literals[0],
+			)
+		}
+	}
+
+	if c.config.QueryValidator != nil {
+		if err := c.config.QueryValidator(ctx, query); err != nil {
+			return fmt.Errorf("KSQL: query rejected by the QueryValidator: %w", err)
+		}
+	}
+
+	return checkParams(c.dialect, c.config, params)
+}
+
+// checkQuerySize returns a QueryTooLargeError if the query exceeds
+// the limits set with Config.MaxQueryBytes or Config.MaxParams.
+func (c DB) checkQuerySize(query string, params []interface{}) error {
+	maxBytes, maxParams := c.config.MaxQueryBytes, c.config.MaxParams
+	if (maxBytes > 0 && len(query) > maxBytes) || (maxParams > 0 && len(params) > maxParams) {
+		return QueryTooLargeError{
+			NumBytes:  len(query),
+			NumParams: len(params),
+			MaxBytes:  maxBytes,
+			MaxParams: maxParams,
+		}
+	}
+	return nil
+}
+
+// checkParams detects common mistakes on the params passed to Query,
+// QueryOne, QueryChunks and Exec, so we can return a friendly error
+// instead of the opaque error that would be returned by the driver.
+//
+// Types implementing the driver.Valuer interface are always accepted
+// since they know how to convert themselves to a supported type, and
+// so are sql.NamedArg and sql.Out which are handled by database/sql.
+//
+// Structs and maps are only rejected if the ForbidStructAndMapParams
+// option is set, and slices only if the ForbidSliceParams option is set,
+// since some drivers know how to handle them.
+func checkParams(dialect sqldialect.Provider, config Config, params []interface{}) error {
+	for i, param := range params {
+		switch param.(type) {
+		case nil, sql.NamedArg, sql.Out, *sql.Out:
+			continue
+		}
+
+		v := reflect.ValueOf(param)
+		t := v.Type()
+		if implementsValuer(t) {
+			continue
+		}
+		if t.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				// Nil pointers are sent as NULL by the drivers
+				continue
+			}
+			t = t.Elem()
+			if implementsValuer(t) {
+				continue
+			}
+		}
+
+		switch t.Kind() {
+		case reflect.Struct:
+			if t == timeType {
+				err := checkTimeParam(dialect, i, reflect.Indirect(v).Interface().(time.Time))
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if !config.ForbidStructAndMapParams {
+				continue
+			}
+
+			return fmt.Errorf(
+				"KSQL: param %d has type %T, but structs can't be used as query params,"+
+					" pass its attributes instead or implement the driver.Valuer interface",
+				i+1, param,
+			)
+
+		case reflect.Map:
+			if !config.ForbidStructAndMapParams || dialect.DriverName() == "postgres" {
+				// The pgx driver can encode maps, e.g. as hstore or JSON
+				continue
+			}
+
+			return fmt.Errorf(
+				"KSQL: param %d has type %T, but the %s dialect doesn't support maps as query params,"+
+					" encode it first, e.g. as JSON, or implement the driver.Valuer interface",
+				i+1, param, dialect.DriverName(),
+			)
+
+		case reflect.Slice, reflect.Array:
+			if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+				// Byte slices are supported by all drivers
+				continue
+			}
+
+			if len(params) == 1 && t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Interface {
+				return fmt.Errorf(
+					"KSQL: the only param has type %T, did you forget the `...` when passing the params?",
+					param,
+				)
+			}
+
+			if !config.ForbidSliceParams || dialect.DriverName() == "postgres" {
+				// Postgres has native support for arrays
+				continue
+			}
+
+			return fmt.Errorf(
+				"KSQL: param %d has type %T, but the %s dialect doesn't support slices or arrays as query params",
+				i+1, param, dialect.DriverName(),
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkTimeParam rejects the times that the database can't store, which
+// MySQL either rejects with an opaque error or silently replaces with the
+// zero date, depending on its sql_mode.
+//
+// The zero time.Time is accepted since it is sent as the `0000-00-00`
+// date, which is valid on MySQL if the NO_ZERO_DATE mode is disabled.
+func checkTimeParam(dialect sqldialect.Provider, idx int, t time.Time) error {
+	if dialect.DriverName() != "mysql" || t.IsZero() {
+		return nil
+	}
+
+	if year := t.Year(); year < 1000 || year > 9999 {
+		return fmt.Errorf(
+			"KSQL: param %d is the time %s, but the mysql dialect only supports dates between the years 1000 and 9999,"+
+				" if the value is unknown use NULL instead, e.g. with a nil *time.Time",
+			idx+1, t.Format(time.RFC3339),
+		)
+	}
+
+	return nil
+}
+
+func implementsValuer(t reflect.Type) bool {
+	return t.Implements(valuerType) || reflect.PtrTo(t).Implements(valuerType)
+}
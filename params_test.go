@@ -0,0 +1,292 @@
+package ksql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestCheckParams(t *testing.T) {
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	now := time.Now()
+	var nilUser *User
+
+	t.Run("should accept valid params", func(t *testing.T) {
+		tests := []struct {
+			desc    string
+			dialect string
+			config  Config
+			params  []interface{}
+		}{
+			{
+				desc:    "scalars and nil values",
+				dialect: "sqlite3",
+				params:  []interface{}{42, "fakeName", 4.2, true, nil, nilUser},
+			},
+			{
+				desc:    "time values",
+				dialect: "mysql",
+				params:  []interface{}{now, &now, time.Time{}},
+			},
+			{
+				desc:    "times outside the range of mysql on other dialects",
+				dialect: "postgres",
+				params:  []interface{}{time.Date(1, 1, 1, 0, 0, 1, 0, time.UTC)},
+			},
+			{
+				desc:    "slices without the ForbidSliceParams option",
+				dialect: "mysql",
+				params:  []interface{}{[]int{1, 2}, [2]string{"foo", "bar"}},
+			},
+			{
+				desc:    "byte slices",
+				dialect: "sqlserver",
+				params:  []interface{}{[]byte("fakeData")},
+			},
+			{
+				desc:    "types implementing driver.Valuer",
+				dialect: "sqlite3",
+				params:  []interface{}{sql.NullString{String: "fakeName", Valid: true}, &sql.NullInt64{}},
+			},
+			{
+				desc:    "slices and maps on postgres",
+				dialect: "postgres",
+				config:  Config{ForbidStructAndMapParams: true},
+				params:  []interface{}{[]int{1, 2, 3}, map[string]string{"foo": "bar"}},
+			},
+			{
+				desc:    "named and output args",
+				dialect: "sqlserver",
+				config:  Config{ForbidStructAndMapParams: true},
+				params:  []interface{}{sql.Named("id", 42), sql.Named("ids", []int{1, 2}), sql.Out{Dest: new(int)}, &sql.Out{Dest: new(int)}},
+			},
+			{
+				desc:    "structs and maps without the ForbidStructAndMapParams option",
+				dialect: "sqlite3",
+				params:  []interface{}{netip.MustParseAddr("127.0.0.1"), User{}, &User{}, map[string]string{}},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				err := checkParams(sqldialect.SupportedDialects[test.dialect], test.config, test.params)
+				tt.AssertNoErr(t, err)
+			})
+		}
+	})
+
+	t.Run("should report invalid params", func(t *testing.T) {
+		tests := []struct {
+			desc               string
+			dialect            string
+			config             Config
+			params             []interface{}
+			expectErrToContain []string
+		}{
+			{
+				desc:               "structs",
+				dialect:            "postgres",
+				config:             Config{ForbidStructAndMapParams: true},
+				params:             []interface{}{42, User{}},
+				expectErrToContain: []string{"KSQL", "param 2", "User", "struct"},
+			},
+			{
+				desc:               "pointers to structs",
+				dialect:            "postgres",
+				config:             Config{ForbidStructAndMapParams: true},
+				params:             []interface{}{&User{}},
+				expectErrToContain: []string{"KSQL", "param 1", "*ksql.User", "struct"},
+			},
+			{
+				desc:               "slices on dialects without array support",
+				dialect:            "mysql",
+				config:             Config{ForbidSliceParams: true},
+				params:             []interface{}{"fakeName", []int{1, 2}},
+				expectErrToContain: []string{"KSQL", "param 2", "[]int", "mysql"},
+			},
+			{
+				desc:               "times outside the range supported by mysql",
+				dialect:            "mysql",
+				params:             []interface{}{now, time.Date(999, 12, 31, 0, 0, 0, 0, time.UTC)},
+				expectErrToContain: []string{"KSQL", "param 2", "0999-12-31", "mysql", "1000 and 9999"},
+			},
+			{
+				desc:               "pointers to times outside the range supported by mysql",
+				dialect:            "mysql",
+				params:             []interface{}{&time.Time{}, func() *time.Time { t := time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC); return &t }()},
+				expectErrToContain: []string{"KSQL", "param 2", "mysql", "1000 and 9999"},
+			},
+			{
+				desc:               "maps on dialects without map support",
+				dialect:            "sqlite3",
+				config:             Config{ForbidStructAndMapParams: true},
+				params:             []interface{}{map[string]string{}},
+				expectErrToContain: []string{"KSQL", "param 1", "map[string]string", "sqlite3"},
+			},
+			{
+				desc:               "params passed without the ellipsis",
+				dialect:            "postgres",
+				params:             []interface{}{[]interface{}{42, "fakeName"}},
+				expectErrToContain: []string{"KSQL", "[]interface {}", "..."},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				err := checkParams(sqldialect.SupportedDialects[test.dialect], test.config, test.params)
+				tt.AssertErrContains(t, err, test.expectErrToContain...)
+			})
+		}
+	})
+
+	t.Run("should check the params before sending the query", func(t *testing.T) {
+		ctx := context.Background()
+
+		var queried bool
+		c := DB{
+			dialect: sqldialect.SupportedDialects["mysql"],
+			config:  Config{ForbidStructAndMapParams: true, ForbidSliceParams: true},
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					queried = true
+					return &mockRows{}, nil
+				},
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					queried = true
+					return mockResult{}, nil
+				},
+			},
+		}
+
+		var users []User
+		err := c.Query(ctx, &users, "SELECT id, name FROM users WHERE id IN (?)", []int{1, 2})
+		tt.AssertErrContains(t, err, "KSQL", "[]int")
+
+		var user User
+		err = c.QueryOne(ctx, &user, "SELECT id, name FROM users WHERE id = ?", User{})
+		tt.AssertErrContains(t, err, "KSQL", "User")
+
+		err = c.QueryChunks(ctx, ChunkParser{
+			Query:     "SELECT id, name FROM users WHERE id = ?",
+			Params:    []interface{}{User{}},
+			ChunkSize: 10,
+			ForEachChunk: func(users []User) error {
+				return nil
+			},
+		})
+		tt.AssertErrContains(t, err, "KSQL", "User")
+
+		_, err = c.Exec(ctx, "DELETE FROM users WHERE id IN (?)", []int{1, 2})
+		tt.AssertErrContains(t, err, "KSQL", "[]int")
+
+		tt.AssertEqual(t, queried, false)
+	})
+}
+
+func TestCheckQuery(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	newMockDB := func(config Config, queried *bool) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			config:  config,
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					*queried = true
+					return &mockRows{
+						NextFn: func() bool { return false },
+					}, nil
+				},
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					*queried = true
+					return mockResult{}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should accept string literals by default", func(t *testing.T) {
+		var queried bool
+		c := newMockDB(Config{}, &queried)
+
+		_, err := c.Exec(ctx, "DELETE FROM users WHERE name = 'John'")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queried, true)
+	})
+
+	t.Run("should reject string literals when ForbidUnparameterizedStrings is set", func(t *testing.T) {
+		var queried bool
+		c := newMockDB(Config{ForbidUnparameterizedStrings: true}, &queried)
+
+		var users []User
+		err := c.Query(ctx, &users, "FROM users WHERE name = 'John'")
+		tt.AssertErrContains(t, err, "KSQL", "'John'", "ForbidUnparameterizedStrings")
+
+		var user User
+		err = c.QueryOne(ctx, &user, "FROM users WHERE name = 'John'")
+		tt.AssertErrContains(t, err, "KSQL", "'John'", "ForbidUnparameterizedStrings")
+
+		err = c.QueryChunks(ctx, ChunkParser{
+			Query:     "FROM users WHERE name = 'John'",
+			ChunkSize: 10,
+			ForEachChunk: func(users []User) error {
+				return nil
+			},
+		})
+		tt.AssertErrContains(t, err, "KSQL", "'John'", "ForbidUnparameterizedStrings")
+
+		_, err = c.Exec(ctx, "DELETE FROM users WHERE name = 'John'")
+		tt.AssertErrContains(t, err, "KSQL", "'John'", "ForbidUnparameterizedStrings")
+
+		tt.AssertEqual(t, queried, false)
+
+		_, err = c.Exec(ctx, `DELETE FROM "users's" WHERE name = $1`, "John")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queried, true)
+	})
+
+	t.Run("should call the QueryValidator with the final query", func(t *testing.T) {
+		var queried bool
+		var validatedQueries []string
+		c := newMockDB(Config{
+			QueryValidator: func(ctx context.Context, query string) error {
+				validatedQueries = append(validatedQueries, query)
+				if strings.Contains(query, "DROP") {
+					return fmt.Errorf("fakeValidationErr")
+				}
+				return nil
+			},
+		}, &queried)
+
+		var users []User
+		err := c.Query(ctx, &users, "FROM users WHERE id = $1", 42)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queried, true)
+
+		queried = false
+		_, err = c.Exec(ctx, "DROP TABLE users")
+		tt.AssertErrContains(t, err, "KSQL", "QueryValidator", "fakeValidationErr")
+		tt.AssertEqual(t, queried, false)
+
+		tt.AssertEqual(t, validatedQueries, []string{
+			`SELECT "id", "name" FROM users WHERE id = $1`,
+			"DROP TABLE users",
+		})
+	})
+}
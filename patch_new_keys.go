@@ -0,0 +1,96 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// NewKeys maps the names of the ID columns of a table
+// to the new values they should receive on PatchWithNewKeys.
+type NewKeys map[string]interface{}
+
+// PatchWithNewKeys works like Patch except that it also changes the values
+// of the ID columns informed on newKeys, which is useful for natural keys
+// that might change, e.g. when the username is the primary key:
+//
+//	err := db.PatchWithNewKeys(ctx, UsersTable, &user, ksql.NewKeys{"username": "newname"})
+//
+// The IDs set on the record are used on the WHERE clause for finding
+// the record, i.e. they should contain the old keys, and the new keys
+// are set together with the other attributes of the record.
+//
+// The record itself is not changed, so after the update its ID
+// attributes still contain the old keys.
+func (c DB) PatchWithNewKeys(
+	ctx context.Context,
+	table Table,
+	record interface{},
+	newKeys NewKeys,
+) (err error) {
+	v := reflect.ValueOf(record)
+	t := v.Type()
+	tStruct := t
+	if t.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return fmt.Errorf("KSQL: expected a valid pointer to struct as argument but received a nil pointer: %v", record)
+		}
+		tStruct = t.Elem()
+	}
+	info, err := structs.GetTagInfo(tStruct)
+	if err != nil {
+		return err
+	}
+
+	err = runBeforeUpdate(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	recordMap, err := structs.StructToMap(record)
+	if err != nil {
+		return err
+	}
+
+	table, err = c.resolveTable(ctx, table, record)
+	if err != nil {
+		return err
+	}
+
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("can't update ksql.Table: %w", err)
+	}
+
+	for key := range newKeys {
+		if !isIDColumn(table, key) {
+			return fmt.Errorf(
+				"KSQL: PatchWithNewKeys received the new key `%s` but it is not one of the ID columns of the table: %v",
+				key, table.idColumns,
+			)
+		}
+	}
+
+	query, params, err := buildUpdateQuery(ctx, c.dialect, table, info, recordMap, newKeys)
+	if err != nil {
+		return err
+	}
+
+	err = c.execUpdateQuery(ctx, "PatchWithNewKeys", table, query, params)
+	if err != nil {
+		return err
+	}
+
+	return runAfterUpdate(ctx, record)
+}
+
+func isIDColumn(table Table, column string) bool {
+	for _, idName := range table.idColumns {
+		if idName == column {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,126 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestPatchWithNewKeys(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		Username  string `ksql:"username"`
+		Name      string `ksql:"name"`
+		CreatedAt string `ksql:"created_at,skipUpdates"`
+	}
+	usersTable := NewTable("users", "username")
+
+	type UserPermission struct {
+		UserID int    `ksql:"user_id"`
+		PermID int    `ksql:"perm_id"`
+		Type   string `ksql:"type"`
+	}
+	userPermissionsTable := NewTable("user_permissions", "user_id", "perm_id")
+
+	newMockDB := func(dialect string, query *string, params *[]interface{}, rowsAffected int64) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects[dialect],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, q string, p ...interface{}) (Result, error) {
+					*query = q
+					*params = p
+					return mockResult{
+						RowsAffectedFn: func() (int64, error) {
+							return rowsAffected, nil
+						},
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should set the new keys and use the old ones on the WHERE clause", func(t *testing.T) {
+		var query string
+		var params []interface{}
+		c := newMockDB("postgres", &query, &params, 1)
+
+		err := c.PatchWithNewKeys(ctx, usersTable, &User{
+			Username:  "oldname",
+			Name:      "fakeName",
+			CreatedAt: "fakeDate",
+		}, NewKeys{"username": "newname"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `UPDATE users SET "username" = $1, "name" = $2 WHERE "username" = $3`)
+		tt.AssertEqual(t, params, []interface{}{"newname", "fakeName", "oldname"})
+	})
+
+	t.Run("should work with only some of the columns of a composite key", func(t *testing.T) {
+		var query string
+		var params []interface{}
+		c := newMockDB("mysql", &query, &params, 1)
+
+		err := c.PatchWithNewKeys(ctx, userPermissionsTable, &UserPermission{
+			UserID: 1,
+			PermID: 2,
+			Type:   "read",
+		}, NewKeys{"perm_id": 3})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, "UPDATE user_permissions SET `perm_id` = ?, `type` = ? WHERE `user_id` = ? AND `perm_id` = ?")
+		tt.AssertEqual(t, params, []interface{}{3, "read", 1, 2})
+	})
+
+	t.Run("should update only the keys if there are no other values", func(t *testing.T) {
+		var query string
+		var params []interface{}
+		c := newMockDB("postgres", &query, &params, 1)
+
+		err := c.PatchWithNewKeys(ctx, usersTable, &struct {
+			Username string `ksql:"username"`
+		}{
+			Username: "oldname",
+		}, NewKeys{"username": "newname"})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `UPDATE users SET "username" = $1 WHERE "username" = $2`)
+		tt.AssertEqual(t, params, []interface{}{"newname", "oldname"})
+	})
+
+	t.Run("should report error for invalid new keys", func(t *testing.T) {
+		var query string
+		var params []interface{}
+		c := newMockDB("postgres", &query, &params, 1)
+
+		err := c.PatchWithNewKeys(ctx, usersTable, &User{
+			Username: "oldname",
+			Name:     "fakeName",
+		}, NewKeys{"name": "newname"})
+		tt.AssertErrContains(t, err, "KSQL", "PatchWithNewKeys", "name", "ID columns")
+		tt.AssertEqual(t, query, "")
+	})
+
+	t.Run("should report error if the old keys are missing", func(t *testing.T) {
+		var query string
+		var params []interface{}
+		c := newMockDB("postgres", &query, &params, 1)
+
+		err := c.PatchWithNewKeys(ctx, usersTable, &User{
+			Name: "fakeName",
+		}, NewKeys{"username": "newname"})
+		tt.AssertEqual(t, errors.Is(err, ErrRecordMissingIDs), true)
+	})
+
+	t.Run("should return ErrRecordNotFound if no rows were updated", func(t *testing.T) {
+		var query string
+		var params []interface{}
+		c := newMockDB("postgres", &query, &params, 0)
+
+		err := c.PatchWithNewKeys(ctx, usersTable, &User{
+			Username: "oldname",
+			Name:     "fakeName",
+		}, NewKeys{"username": "newname"})
+		tt.AssertEqual(t, errors.Is(err, ErrRecordNotFound), true)
+	})
+}
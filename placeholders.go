@@ -0,0 +1,192 @@
+package ksql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vingarcia/ksql/internal/sqlparse"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// Placeholders returns n placeholders for the input dialect
+// separated by commas, starting from the placeholder of index startIdx,
+// which is the number of params that come before them on the query.
+//
+// It is useful for writing bulk statements manually, e.g.
+// on Postgres `Placeholders(dialect, 3, 3)` returns "$4, $5, $6",
+// while on MySQL it returns "?, ?, ?".
+func Placeholders(dialect sqldialect.Provider, n int, startIdx int) string {
+	placeholders := make([]string, n)
+	for i := range placeholders {
+		placeholders[i] = dialect.Placeholder(startIdx + i)
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+// AppendPlaceholder appends the value to the params slice
+// and returns the placeholder that references it on the query, e.g.:
+//
+//	var params []interface{}
+//	query := "SELECT id, name FROM users WHERE age > " + ksql.AppendPlaceholder(dialect, &params, minAge)
+//	if name != "" {
+//		query += " AND name = " + ksql.AppendPlaceholder(dialect, &params, name)
+//	}
+//	err := db.Query(ctx, &users, query, params...)
+func AppendPlaceholder(dialect sqldialect.Provider, params *[]interface{}, value interface{}) string {
+	*params = append(*params, value)
+	return dialect.Placeholder(len(*params) - 1)
+}
+
+// QueryFragment is a piece of a query together with the params it uses,
+// its placeholders are numbered as if the fragment was a query of its own,
+// i.e. on Postgres the first param of each fragment is referenced as `$1`.
+type QueryFragment struct {
+	Query  string
+	Params []interface{}
+}
+
+// PlaceholderRenumberer can optionally be implemented by the sqldialect.Provider
+// in order to customize how ConcatQueries renumbers the placeholders of each
+// fragment, e.g. for dialects whose placeholders are not recognized by KSQL.
+//
+// RenumberPlaceholders receives the query of a fragment, the number of params
+// that come before it on the resulting query and the number of params of the
+// fragment, and should return the query referencing the right params.
+type PlaceholderRenumberer interface {
+	RenumberPlaceholders(query string, offset int, numParams int) (string, error)
+}
+
+// ConcatQueries joins the query fragments separating them with spaces,
+// renumbering their placeholders so they reference the right params on
+// the resulting query, and returns this query together with its params:
+//
+//	query, params, err := ksql.ConcatQueries(dialect,
+//		ksql.QueryFragment{Query: "SELECT id, name FROM users WHERE age > $1", Params: []interface{}{18}},
+//		ksql.QueryFragment{Query: "AND name = $1", Params: []interface{}{"Ana"}},
+//	)
+//	// query: "SELECT id, name FROM users WHERE age > $1 AND name = $2"
+//
+// Placeholders inside string literals, quoted identifiers and comments
+// are left unchanged. For dialects with positional placeholders, e.g.
+// MySQL, the fragments are only validated, since no renumbering is needed.
+//
+// An error is returned if a fragment references a param it doesn't have.
+//
+// Dialects with other placeholder formats can implement
+// the PlaceholderRenumberer interface.
+func ConcatQueries(dialect sqldialect.Provider, fragments ...QueryFragment) (query string, params []interface{}, err error) {
+	renumber := func(query string, offset int, numParams int) (string, error) {
+		prefix, numbered := placeholderPrefix(dialect)
+		return renumberPlaceholders(query, prefix, numbered, querySyntax(dialect), offset, numParams)
+	}
+	if renumberer, ok := dialect.(PlaceholderRenumberer); ok {
+		renumber = renumberer.RenumberPlaceholders
+	}
+
+	queries := make([]string, 0, len(fragments))
+	for i, fragment := range fragments {
+		fragmentQuery, err := renumber(fragment.Query, len(params), len(fragment.Params))
+		if err != nil {
+			return "", nil, fmt.Errorf("KSQL: invalid query fragment number %d: %w", i+1, err)
+		}
+
+		queries = append(queries, fragmentQuery)
+		params = append(params, fragment.Params...)
+	}
+
+	return strings.Join(queries, " "), params, nil
+}
+
+// placeholderPrefix returns the prefix of the numbered placeholders of the
+// dialect, e.g. `$` or `@p`, or the placeholder itself for positional ones.
+func placeholderPrefix(dialect sqldialect.Provider) (prefix string, numbered bool) {
+	first := dialect.Placeholder(0)
+	if first == dialect.Placeholder(1) {
+		return first, false
+	}
+
+	return strings.TrimSuffix(first, "1"), true
+}
+
+// querySyntax returns the quoting rules of the dialect
+// used for skipping the parts of the queries that are not code.
+func querySyntax(dialect sqldialect.Provider) sqlparse.Syntax {
+	prefix, numbered := placeholderPrefix(dialect)
+	return sqlparse.Syntax{
+		BracketIdentifiers: prefix == "@p",
+		DollarQuotes:       numbered && prefix == "$",
+		BackslashEscapes:   dialect.DriverName() == "mysql",
+	}
+}
+
+// renumberPlaceholders adds `offset` to the numbered placeholders of the
+// query, skipping string literals, quoted identifiers and comments.
+func renumberPlaceholders(
+	query string,
+	prefix string,
+	numbered bool,
+	syntax sqlparse.Syntax,
+	offset int,
+	numParams int,
+) (string, error) {
+	var b strings.Builder
+	var numPositional int
+	for i := 0; i < len(query); {
+		rest := query[i:]
+
+		if skipLen := sqlparse.SkipLen(rest, syntax); skipLen > 0 {
+			b.WriteString(rest[:skipLen])
+			i += skipLen
+			continue
+		}
+
+		if !strings.HasPrefix(rest, prefix) || (numbered && i > 0 && isIdentifierByte(query[i-1])) {
+			b.WriteByte(rest[0])
+			i++
+			continue
+		}
+
+		if !numbered {
+			numPositional++
+			b.WriteString(prefix)
+			i += len(prefix)
+			continue
+		}
+
+		digits := len(prefix)
+		for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+			digits++
+		}
+		if digits == len(prefix) {
+			b.WriteByte(rest[0])
+			i++
+			continue
+		}
+
+		n, _ := strconv.Atoi(rest[len(prefix):digits])
+		if n < 1 || n > numParams {
+			return "", fmt.Errorf(
+				"the placeholder %s references a param that doesn't exist, the fragment has %d params",
+				rest[:digits], numParams,
+			)
+		}
+
+		b.WriteString(prefix + strconv.Itoa(n+offset))
+		i += digits
+	}
+
+	if !numbered && numPositional != numParams {
+		return "", fmt.Errorf(
+			"the fragment has %d placeholders but %d params",
+			numPositional, numParams,
+		)
+	}
+
+	return b.String(), nil
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '@' || c == '$' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
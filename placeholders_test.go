@@ -0,0 +1,179 @@
+package ksql
+
+import (
+	"strconv"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestPlaceholders(t *testing.T) {
+	tests := []struct {
+		desc                 string
+		dialect              string
+		n                    int
+		startIdx             int
+		expectedPlaceholders string
+	}{
+		{
+			desc:                 "should generate numbered placeholders for postgres",
+			dialect:              "postgres",
+			n:                    3,
+			startIdx:             3,
+			expectedPlaceholders: "$4, $5, $6",
+		},
+		{
+			desc:                 "should generate numbered placeholders for sqlserver",
+			dialect:              "sqlserver",
+			n:                    2,
+			startIdx:             0,
+			expectedPlaceholders: "@p1, @p2",
+		},
+		{
+			desc:                 "should generate positional placeholders for mysql",
+			dialect:              "mysql",
+			n:                    3,
+			startIdx:             5,
+			expectedPlaceholders: "?, ?, ?",
+		},
+		{
+			desc:                 "should return an empty string if n is 0",
+			dialect:              "postgres",
+			n:                    0,
+			startIdx:             0,
+			expectedPlaceholders: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			placeholders := Placeholders(sqldialect.SupportedDialects[test.dialect], test.n, test.startIdx)
+			tt.AssertEqual(t, placeholders, test.expectedPlaceholders)
+		})
+	}
+}
+
+func TestAppendPlaceholder(t *testing.T) {
+	dialect := sqldialect.SupportedDialects["postgres"]
+
+	params := []interface{}{"fakeName"}
+	query := "SELECT id FROM users WHERE name = $1 AND age > " + AppendPlaceholder(dialect, &params, 18)
+	query += " AND age < " + AppendPlaceholder(dialect, &params, 60)
+
+	tt.AssertEqual(t, query, "SELECT id FROM users WHERE name = $1 AND age > $2 AND age < $3")
+	tt.AssertEqual(t, params, []interface{}{"fakeName", 18, 60})
+}
+
+func TestConcatQueries(t *testing.T) {
+	tests := []struct {
+		desc           string
+		dialect        string
+		fragments      []QueryFragment
+		expectedQuery  string
+		expectedParams []interface{}
+	}{
+		{
+			desc:    "should renumber the placeholders for postgres",
+			dialect: "postgres",
+			fragments: []QueryFragment{
+				{Query: "SELECT id FROM users WHERE age > $1 AND age < $2", Params: []interface{}{18, 60}},
+				{Query: "AND (name = $1 OR nickname = $1)", Params: []interface{}{"Ana"}},
+				{Query: "ORDER BY id"},
+				{Query: "LIMIT $1", Params: []interface{}{10}},
+			},
+			expectedQuery:  "SELECT id FROM users WHERE age > $1 AND age < $2 AND (name = $3 OR nickname = $3) ORDER BY id LIMIT $4",
+			expectedParams: []interface{}{18, 60, "Ana", 10},
+		},
+		{
+			desc:    "should renumber the placeholders for sqlserver",
+			dialect: "sqlserver",
+			fragments: []QueryFragment{
+				{Query: "SELECT [id] FROM users WHERE age > @p1", Params: []interface{}{18}},
+				{Query: "AND name = @p1", Params: []interface{}{"Ana"}},
+			},
+			expectedQuery:  "SELECT [id] FROM users WHERE age > @p1 AND name = @p2",
+			expectedParams: []interface{}{18, "Ana"},
+		},
+		{
+			desc:    "should keep positional placeholders unchanged",
+			dialect: "mysql",
+			fragments: []QueryFragment{
+				{Query: "SELECT id FROM users WHERE age > ?", Params: []interface{}{18}},
+				{Query: "AND name IN (?, ?)", Params: []interface{}{"Ana", "Bia"}},
+			},
+			expectedQuery:  "SELECT id FROM users WHERE age > ? AND name IN (?, ?)",
+			expectedParams: []interface{}{18, "Ana", "Bia"},
+		},
+		{
+			desc:    "should not change placeholders inside literals, identifiers and comments",
+			dialect: "postgres",
+			fragments: []QueryFragment{
+				{Query: "SELECT id FROM users WHERE age > $1", Params: []interface{}{18}},
+				{Query: "AND name <> '$1' AND \"$1\" = $1 -- $1\n/* $1 */ AND bio <> $$ $1 $$ AND $tag$ $1 $tag$ = $1", Params: []interface{}{"Ana"}},
+			},
+			expectedQuery:  "SELECT id FROM users WHERE age > $1 AND name <> '$1' AND \"$1\" = $2 -- $1\n/* $1 */ AND bio <> $$ $1 $$ AND $tag$ $1 $tag$ = $2",
+			expectedParams: []interface{}{18, "Ana"},
+		},
+		{
+			desc:    "should not count placeholders inside literals with backslash escapes on mysql",
+			dialect: "mysql",
+			fragments: []QueryFragment{
+				{Query: `SELECT id FROM users WHERE bio <> 'it\'s ?' AND age > ?`, Params: []interface{}{18}},
+				{Query: `AND name = ?`, Params: []interface{}{"Ana"}},
+			},
+			expectedQuery:  `SELECT id FROM users WHERE bio <> 'it\'s ?' AND age > ? AND name = ?`,
+			expectedParams: []interface{}{18, "Ana"},
+		},
+		{
+			desc:           "should return an empty query if there are no fragments",
+			dialect:        "postgres",
+			fragments:      nil,
+			expectedQuery:  "",
+			expectedParams: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			query, params, err := ConcatQueries(sqldialect.SupportedDialects[test.dialect], test.fragments...)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, query, test.expectedQuery)
+			tt.AssertEqual(t, params, test.expectedParams)
+		})
+	}
+
+	t.Run("should use the PlaceholderRenumberer if the dialect implements it", func(t *testing.T) {
+		query, params, err := ConcatQueries(renumbererDialect{sqldialect.SupportedDialects["postgres"]},
+			QueryFragment{Query: "SELECT id FROM users WHERE age > :1", Params: []interface{}{18}},
+			QueryFragment{Query: "AND name = :1", Params: []interface{}{"Ana"}},
+		)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, "SELECT id FROM users WHERE age > :1 AND name = :1+1")
+		tt.AssertEqual(t, params, []interface{}{18, "Ana"})
+	})
+
+	t.Run("should report error for placeholders without params", func(t *testing.T) {
+		_, _, err := ConcatQueries(sqldialect.SupportedDialects["postgres"],
+			QueryFragment{Query: "SELECT id FROM users WHERE age > $1", Params: []interface{}{18}},
+			QueryFragment{Query: "AND name = $2", Params: []interface{}{"Ana"}},
+		)
+		tt.AssertErrContains(t, err, "KSQL", "fragment number 2", "$2", "1 params")
+
+		_, _, err = ConcatQueries(sqldialect.SupportedDialects["sqlite3"],
+			QueryFragment{Query: "SELECT id FROM users WHERE age > ? AND name = ?", Params: []interface{}{18}},
+		)
+		tt.AssertErrContains(t, err, "KSQL", "fragment number 1", "2 placeholders", "1 params")
+	})
+}
+
+type renumbererDialect struct {
+	sqldialect.Provider
+}
+
+func (renumbererDialect) RenumberPlaceholders(query string, offset int, numParams int) (string, error) {
+	if offset == 0 {
+		return query, nil
+	}
+	return query + "+" + strconv.Itoa(offset), nil
+}
@@ -0,0 +1,84 @@
+package ksql
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// PoolStats describes the state of the connection pool of a DBAdapter,
+// using the same meaning for all adapters so the saturation of the pool
+// can be monitored the same way on database/sql and pgxpool based adapters.
+type PoolStats struct {
+	// MaxOpenConns is the maximum number of connections, 0 means unlimited
+	MaxOpenConns int
+
+	// OpenConns is the number of established connections,
+	// both in use and idle
+	OpenConns int
+	InUse     int
+	Idle      int
+
+	// WaitCount is the total number of operations that had
+	// to wait for a connection because the pool was exhausted
+	WaitCount int64
+
+	// WaitDuration is the total time spent waiting for connections
+	WaitDuration time.Duration
+}
+
+// StatsProvider can optionally be implemented by the DBAdapter in order
+// to report the stats of its connection pool on `DB.PoolStats()`.
+type StatsProvider interface {
+	PoolStats() (PoolStats, error)
+}
+
+// errNoStatsProvider is returned when the adapter, or the
+// adapter wrapped by KSQL, doesn't implement StatsProvider
+var errNoStatsProvider = errors.New("KSQL: the DBAdapter doesn't implement the StatsProvider interface")
+
+// PoolStats returns the current stats of the connection pool of the
+// DBAdapter, if it implements the StatsProvider interface, e.g. for
+// exporting the wait metrics periodically.
+//
+// The middlewares passed to Chain also receive these stats
+// on the Operation.PoolStats attribute, e.g.:
+//
+//	db = ksql.Chain(db, func(next ksql.Handler) ksql.Handler {
+//		return func(ctx context.Context, op ksql.Operation) (ksql.Result, error) {
+//			if op.PoolStats != nil {
+//				metrics.Gauge("db_pool_wait_count", op.PoolStats.WaitCount)
+//				metrics.Gauge("db_pool_wait_seconds", op.PoolStats.WaitDuration.Seconds())
+//			}
+//			return next(ctx, op)
+//		}
+//	})
+//
+// When called inside a transaction it reports the stats
+// of the pool used for starting the transaction.
+func (c DB) PoolStats() (PoolStats, error) {
+	adapter := c.db
+	if c.outerAdapter != nil {
+		adapter = c.outerAdapter
+	}
+
+	provider, ok := adapter.(StatsProvider)
+	if !ok {
+		return PoolStats{}, errNoStatsProvider
+	}
+
+	return provider.PoolStats()
+}
+
+// NewPoolStatsFromSQL converts the stats of a `*sql.DB` to PoolStats,
+// it is meant to be used by adapters based on database/sql.
+func NewPoolStatsFromSQL(stats sql.DBStats) PoolStats {
+	return PoolStats{
+		MaxOpenConns: stats.MaxOpenConnections,
+		OpenConns:    stats.OpenConnections,
+		InUse:        stats.InUse,
+		Idle:         stats.Idle,
+		WaitCount:    stats.WaitCount,
+		WaitDuration: stats.WaitDuration,
+	}
+}
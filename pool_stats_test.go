@@ -0,0 +1,124 @@
+package ksql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+type mockStatsProvider struct {
+	mockTxBeginner
+	PoolStatsFn func() (PoolStats, error)
+}
+
+func (m mockStatsProvider) PoolStats() (PoolStats, error) {
+	return m.PoolStatsFn()
+}
+
+func TestPoolStats(t *testing.T) {
+	ctx := context.Background()
+	dialect := sqldialect.SupportedDialects["postgres"]
+
+	fakeStats := PoolStats{
+		MaxOpenConns: 10,
+		OpenConns:    10,
+		InUse:        10,
+		WaitCount:    3,
+		WaitDuration: 2 * time.Second,
+	}
+
+	newAdapter := func() mockStatsProvider {
+		adapter := mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				return mockResult{}, nil
+			},
+		}
+		return mockStatsProvider{
+			mockTxBeginner: mockTxBeginner{
+				DBAdapter: adapter,
+				BeginTxFn: func(ctx context.Context) (Tx, error) {
+					return mockTx{
+						DBAdapter: adapter,
+						CommitFn: func(ctx context.Context) error {
+							return nil
+						},
+						RollbackFn: func(ctx context.Context) error {
+							return nil
+						},
+					}, nil
+				},
+			},
+			PoolStatsFn: func() (PoolStats, error) {
+				return fakeStats, nil
+			},
+		}
+	}
+
+	t.Run("should return the stats reported by the adapter", func(t *testing.T) {
+		db, err := NewWithAdapter(newAdapter(), dialect)
+		tt.AssertNoErr(t, err)
+
+		stats, err := db.PoolStats()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, stats, fakeStats)
+	})
+
+	t.Run("should return the stats of the outer adapter inside transactions", func(t *testing.T) {
+		db, err := NewWithAdapter(newAdapter(), dialect)
+		tt.AssertNoErr(t, err)
+
+		var stats PoolStats
+		err = db.Transaction(ctx, func(db Provider) error {
+			stats, err = db.(DB).PoolStats()
+			return err
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, stats, fakeStats)
+	})
+
+	t.Run("should work with adapters wrapped by DetectRowsLeaks", func(t *testing.T) {
+		db, err := NewWithAdapter(DetectRowsLeaks(newAdapter(), time.Minute, nil), dialect)
+		tt.AssertNoErr(t, err)
+
+		stats, err := db.PoolStats()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, stats, fakeStats)
+	})
+
+	t.Run("should report adapters that don't implement StatsProvider", func(t *testing.T) {
+		db, err := NewWithAdapter(newAdapter().mockTxBeginner, dialect)
+		tt.AssertNoErr(t, err)
+
+		_, err = db.PoolStats()
+		tt.AssertErrContains(t, err, "KSQL", "StatsProvider")
+
+		db, err = NewWithAdapter(DetectRowsLeaks(newAdapter().mockTxBeginner, time.Minute, nil), dialect)
+		tt.AssertNoErr(t, err)
+
+		_, err = db.PoolStats()
+		tt.AssertErrContains(t, err, "KSQL", "StatsProvider")
+	})
+
+	t.Run("should convert the stats of database/sql", func(t *testing.T) {
+		stats := NewPoolStatsFromSQL(sql.DBStats{
+			MaxOpenConnections: 10,
+			OpenConnections:    4,
+			InUse:              3,
+			Idle:               1,
+			WaitCount:          5,
+			WaitDuration:       time.Second,
+		})
+		tt.AssertEqual(t, stats, PoolStats{
+			MaxOpenConns: 10,
+			OpenConns:    4,
+			InUse:        3,
+			Idle:         1,
+			WaitCount:    5,
+			WaitDuration: time.Second,
+		})
+	})
+}
@@ -0,0 +1,56 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+)
+
+// StatementPreparer can optionally be implemented by the DBAdapter in
+// order to support the DB.Prepare method, preparing the statement on the
+// database so its first execution doesn't pay for the preparation.
+type StatementPreparer interface {
+	PrepareContext(ctx context.Context, query string) error
+}
+
+// Prepare validates the input queries with the same checks used
+// before running queries, e.g. Config.QueryValidator, and asks
+// the adapter to prepare them if it implements the StatementPreparer
+// interface, otherwise the queries are only validated.
+//
+// It is meant to be called during startup with the queries used by
+// the most latency sensitive requests, e.g.:
+//
+//	err := db.Prepare(ctx,
+//		"SELECT id, name FROM users WHERE id = $1",
+//		"UPDATE users SET last_seen = $1 WHERE id = $2",
+//	)
+//
+// Queries starting with `FROM` must be passed with their SELECT part,
+// since it is only generated when the struct is known.
+//
+// If keys were set with the ShardKey option the queries are prepared
+// after being rewritten by the ShardKeyRouter, so they match the ones
+// sent when the same keys are used on the calls.
+func (c DB) Prepare(ctx context.Context, queries ...string) error {
+	preparer, canPrepare := c.db.(StatementPreparer)
+	for _, query := range queries {
+		if err := c.checkQuery(ctx, query, nil); err != nil {
+			return err
+		}
+
+		if !canPrepare {
+			continue
+		}
+
+		query, err := c.routeQuery(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		if err := preparer.PrepareContext(ctx, query); err != nil {
+			return fmt.Errorf("KSQL: error preparing query `%s`: %w", query, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+type mockStatementPreparer struct {
+	mockDBAdapter
+	PrepareContextFn func(ctx context.Context, query string) error
+}
+
+func (m mockStatementPreparer) PrepareContext(ctx context.Context, query string) error {
+	return m.PrepareContextFn(ctx, query)
+}
+
+func TestPrepare(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should prepare the queries when supported by the adapter", func(t *testing.T) {
+		var prepared []string
+		db := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockStatementPreparer{
+				PrepareContextFn: func(ctx context.Context, query string) error {
+					prepared = append(prepared, query)
+					return nil
+				},
+			},
+		}
+
+		err := db.Prepare(ctx, "SELECT 1", "SELECT id FROM users WHERE id = $1")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, prepared, []string{"SELECT 1", "SELECT id FROM users WHERE id = $1"})
+	})
+
+	t.Run("should only validate the queries for other adapters", func(t *testing.T) {
+		db := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db:      mockDBAdapter{},
+		}
+
+		err := db.Prepare(ctx, "SELECT 1")
+		tt.AssertNoErr(t, err)
+	})
+
+	t.Run("should report invalid queries", func(t *testing.T) {
+		var prepared []string
+		db := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			config: Config{
+				ForbidUnparameterizedStrings: true,
+			},
+			db: mockStatementPreparer{
+				PrepareContextFn: func(ctx context.Context, query string) error {
+					prepared = append(prepared, query)
+					return nil
+				},
+			},
+		}
+
+		err := db.Prepare(ctx, "SELECT 1", "SELECT id FROM users WHERE name = 'foo'")
+		tt.AssertErrContains(t, err, "KSQL", "'foo'")
+		tt.AssertEqual(t, prepared, []string{"SELECT 1"})
+	})
+
+	t.Run("should report errors from the adapter", func(t *testing.T) {
+		db := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockStatementPreparer{
+				PrepareContextFn: func(ctx context.Context, query string) error {
+					return fmt.Errorf("fakeErrMsg")
+				},
+			},
+		}
+
+		err := db.Prepare(ctx, "SELECT 1")
+		tt.AssertErrContains(t, err, "KSQL", "SELECT 1", "fakeErrMsg")
+	})
+}
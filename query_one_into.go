@@ -0,0 +1,213 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/vingarcia/ksql/internal/modifiers"
+	"github.com/vingarcia/ksql/internal/structs"
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// QueryOneInto works like QueryOne except that the row is scanned
+// into several flat structs at once, which is a lighter alternative
+// to declaring a nested struct for each combination of joined tables:
+//
+//	var user User
+//	var address Address
+//	err := db.QueryOneInto(ctx, []interface{}{&user, &address},
+//		"SELECT u.*, a.* FROM users u JOIN addresses a ON a.user_id = u.id WHERE u.id = $1", userID,
+//	)
+//
+// Each column returned by the query is scanned into the first struct with a
+// matching `ksql` tag that didn't receive a column with the same name yet,
+// so columns present on more than one struct, e.g. `id`, must be selected
+// in the same order as the structs are passed. Columns that don't match
+// any of the structs are ignored.
+//
+// If the query starts with `FROM` the SELECT part of the query is generated
+// from the struct tags, which only works if the structs have no column names
+// in common, otherwise the columns would be ambiguous.
+//
+// QueryOneInto returns a ErrRecordNotFound if
+// the query returns no results.
+func (c DB) QueryOneInto(
+	ctx context.Context,
+	records []interface{},
+	query string,
+	params ...interface{},
+) (err error) {
+	if len(records) == 0 {
+		return fmt.Errorf("KSQL: QueryOneInto expected to receive at least one record, but got none")
+	}
+
+	values := make([]reflect.Value, len(records))
+	infos := make([]structs.StructInfo, len(records))
+	for i, record := range records {
+		v := reflect.ValueOf(record)
+		if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return fmt.Errorf("KSQL: expected all records to be non-nil pointers to struct, but got: %T", record)
+		}
+
+		info, err := structs.GetTagInfo(v.Elem().Type())
+		if err != nil {
+			return err
+		}
+
+		if info.IsNestedStruct {
+			return fmt.Errorf("KSQL: QueryOneInto only accepts flat structs, for nested structs use QueryOne instead, but got: %T", record)
+		}
+
+		values[i] = v.Elem()
+		infos[i] = info
+	}
+
+	if strings.ToUpper(getFirstToken(query)) == "FROM" && !getCallOptions(ctx).rawQuery {
+		selectPrefix, err := buildSelectQueryForStructs(c.dialect, values, infos)
+		if err != nil {
+			return err
+		}
+		query = selectPrefix + query
+	}
+
+	if err := c.checkQuery(ctx, query, params); err != nil {
+		return err
+	}
+
+	var numRows int
+	defer ctxLog(ctx, query, params, time.Now(), &numRows, &err)
+	defer c.recordStats(query, &numRows, &err)
+
+	rows, err := c.queryContext(ctx, query, params...)
+	if err != nil {
+		return OpError{
+			Method: "QueryOneInto",
+			Query:  query,
+			Err:    fmt.Errorf("error running query: %w", err),
+		}
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return ErrRecordNotFound
+	}
+
+	colNames, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("KSQL: unable to read columns from returned rows: %w", err)
+	}
+
+	attrNames, scanArgs := getScanArgsForStructs(ctx, c.dialect, c.config, colNames, values, infos)
+
+	err = rows.Scan(scanArgs...)
+	if err != nil {
+		if scanErr, ok := err.(ScanArgError); ok {
+			return fmt.Errorf(
+				"KSQL: scan error: %w",
+				scanErr.ErrorWithStructNames(attrNames[scanErr.ColumnIndex][0], attrNames[scanErr.ColumnIndex][1]),
+			)
+		}
+		return fmt.Errorf("KSQL: scan error: %w", err)
+	}
+
+	normalizeTimeLocation(c.config.TimeLocation, scanArgs)
+	numRows = 1
+
+	return rows.Close()
+}
+
+// buildSelectQueryForStructs builds the SELECT part of the query with the
+// columns of all the structs, refusing column names that would be ambiguous
+func buildSelectQueryForStructs(
+	dialect sqldialect.Provider,
+	values []reflect.Value,
+	infos []structs.StructInfo,
+) (string, error) {
+	ownerByColumn := map[string]reflect.Type{}
+	var fields []string
+	for i, v := range values {
+		for j := 0; j < v.NumField(); j++ {
+			fieldInfo := infos[i].ByIndex(j)
+			if !fieldInfo.Valid {
+				continue
+			}
+
+			if owner, found := ownerByColumn[fieldInfo.ColumnName]; found {
+				return "", fmt.Errorf(
+					"KSQL: can't generate the SELECT part of the query: the column `%s` is present on both %v and %v,"+
+						" write the SELECT part of the query explicitly instead",
+					fieldInfo.ColumnName, owner, v.Type(),
+				)
+			}
+			ownerByColumn[fieldInfo.ColumnName] = v.Type()
+
+			fields = append(fields, selectField(dialect, fieldInfo, "", fieldInfo.ColumnName))
+		}
+	}
+
+	return "SELECT " + strings.Join(fields, ", ") + " ", nil
+}
+
+// getScanArgsForStructs maps each column to the first struct
+// that has a matching attribute not already mapped to another column.
+//
+// The returned attrNames contain the struct and attribute
+// names of each column for building better error messages.
+func getScanArgsForStructs(
+	ctx context.Context,
+	dialect sqldialect.Provider,
+	config Config,
+	names []string,
+	values []reflect.Value,
+	infos []structs.StructInfo,
+) (attrNames [][2]string, scanArgs []interface{}) {
+	usedAttrs := make([]map[int]bool, len(values))
+	for i := range usedAttrs {
+		usedAttrs[i] = map[int]bool{}
+	}
+
+	columnAliases := getCallOptions(ctx).columnAliases
+	for _, name := range names {
+		tagName := name
+		if alias, found := columnAliases[name]; found {
+			tagName = alias
+		}
+
+		valueScanner := nopScannerValue
+		attrName := [2]string{"", name}
+		for i, v := range values {
+			fieldInfo := infos[i].ByColumnName(tagName, config.NormalizeColumnName)
+			if !fieldInfo.Valid || usedAttrs[i][fieldInfo.Index] {
+				continue
+			}
+			usedAttrs[i][fieldInfo.Index] = true
+
+			valueScanner = v.Field(fieldInfo.Index).Addr().Interface()
+			if fieldInfo.Modifier.Scan != nil {
+				valueScanner = &modifiers.AttrScanWrapper{
+					Ctx:     ctx,
+					AttrPtr: valueScanner,
+					ScanFn:  fieldInfo.Modifier.Scan,
+					OpInfo: ksqlmodifiers.OpInfo{
+						DriverName: dialect.DriverName(),
+						Method:     "Query",
+					},
+				}
+			}
+			attrName = [2]string{v.Type().Name(), fieldInfo.AttrName}
+			break
+		}
+
+		scanArgs = append(scanArgs, valueScanner)
+		attrNames = append(attrNames, attrName)
+	}
+
+	return attrNames, scanArgs
+}
@@ -0,0 +1,172 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestQueryOneInto(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	type Address struct {
+		ID     int    `ksql:"id"`
+		UserID int    `ksql:"user_id"`
+		Street string `ksql:"street"`
+	}
+
+	newMockDB := func(query *string, columns []string, values []interface{}) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, q string, params ...interface{}) (Rows, error) {
+					*query = q
+					hasNext := true
+					return mockRows{
+						NextFn: func() bool {
+							next := hasNext
+							hasNext = false
+							return next
+						},
+						ColumnsFn: func() ([]string, error) {
+							return columns, nil
+						},
+						ScanFn: func(args ...interface{}) error {
+							for i, arg := range args {
+								switch ptr := arg.(type) {
+								case *int:
+									*ptr = values[i].(int)
+								case *string:
+									*ptr = values[i].(string)
+								}
+							}
+							return nil
+						},
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should map repeated columns to the structs in order", func(t *testing.T) {
+		var query string
+		c := newMockDB(&query,
+			[]string{"id", "name", "id", "user_id", "street", "unknown"},
+			[]interface{}{1, "fakeName", 2, 1, "fakeStreet", "ignored"},
+		)
+
+		var user User
+		var address Address
+		err := c.QueryOneInto(ctx, []interface{}{&user, &address},
+			"SELECT u.*, a.*, 'ignored' AS unknown FROM users u JOIN addresses a ON a.user_id = u.id WHERE u.id = $1", 1,
+		)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, user, User{ID: 1, Name: "fakeName"})
+		tt.AssertEqual(t, address, Address{ID: 2, UserID: 1, Street: "fakeStreet"})
+	})
+
+	t.Run("should build the SELECT part of the query for queries starting with FROM", func(t *testing.T) {
+		type UserAddress struct {
+			UserID int    `ksql:"user_id"`
+			Street string `ksql:"street"`
+		}
+
+		var query string
+		c := newMockDB(&query,
+			[]string{"id", "name", "user_id", "street"},
+			[]interface{}{1, "fakeName", 1, "fakeStreet"},
+		)
+
+		var user User
+		var address UserAddress
+		err := c.QueryOneInto(ctx, []interface{}{&user, &address},
+			"FROM users u JOIN addresses a ON a.user_id = u.id WHERE u.id = $1", 1,
+		)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, query, `SELECT "id", "name", "user_id", "street" FROM users u JOIN addresses a ON a.user_id = u.id WHERE u.id = $1`)
+		tt.AssertEqual(t, user, User{ID: 1, Name: "fakeName"})
+		tt.AssertEqual(t, address, UserAddress{UserID: 1, Street: "fakeStreet"})
+	})
+
+	t.Run("should return ErrRecordNotFound if there are no results", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, q string, params ...interface{}) (Rows, error) {
+					return mockRows{
+						NextFn: func() bool { return false },
+					}, nil
+				},
+			},
+		}
+
+		var user User
+		var address Address
+		err := c.QueryOneInto(ctx, []interface{}{&user, &address}, "SELECT u.*, a.* FROM users u JOIN addresses a ON true")
+		tt.AssertEqual(t, errors.Is(err, ErrRecordNotFound), true)
+	})
+
+	t.Run("should report error for invalid inputs", func(t *testing.T) {
+		type Nested struct {
+			User User `tablename:"u"`
+		}
+
+		var user User
+		var address Address
+		tests := []struct {
+			desc           string
+			records        []interface{}
+			query          string
+			expectErrToHas []string
+		}{
+			{
+				desc:           "no records",
+				records:        nil,
+				query:          "SELECT * FROM users",
+				expectErrToHas: []string{"KSQL", "at least one record"},
+			},
+			{
+				desc:           "record is not a pointer",
+				records:        []interface{}{&user, address},
+				query:          "SELECT * FROM users",
+				expectErrToHas: []string{"KSQL", "pointers to struct", "Address"},
+			},
+			{
+				desc:           "record is a nil pointer",
+				records:        []interface{}{&user, (*Address)(nil)},
+				query:          "SELECT * FROM users",
+				expectErrToHas: []string{"KSQL", "non-nil pointers to struct"},
+			},
+			{
+				desc:           "nested structs",
+				records:        []interface{}{&user, &Nested{}},
+				query:          "SELECT * FROM users",
+				expectErrToHas: []string{"KSQL", "flat structs", "QueryOne"},
+			},
+			{
+				desc:           "ambiguous columns with queries starting with FROM",
+				records:        []interface{}{&user, &address},
+				query:          "FROM users u JOIN addresses a ON a.user_id = u.id",
+				expectErrToHas: []string{"KSQL", "`id`", "User", "Address", "SELECT"},
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				var query string
+				c := newMockDB(&query, nil, nil)
+
+				err := c.QueryOneInto(ctx, test.records, test.query)
+				tt.AssertErrContains(t, err, test.expectErrToHas...)
+				tt.AssertEqual(t, query, "")
+			})
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// QueryOneOrInsert works like QueryOne except that if no record is found
+// the input record is inserted with the same rules used by Insert,
+// collapsing the common get-or-create pattern into a single call.
+//
+// The returned created value informs if the record was inserted,
+// if it is false the record is filled with the result of the query.
+//
+// Concurrent calls are safe as long as the table has a unique
+// constraint matching the query, e.g.:
+//
+//	user := User{Email: email, Name: name}
+//	created, err := db.QueryOneOrInsert(ctx, UsersTable, &user, "FROM users WHERE email = $1", email)
+//
+// On Postgres, MySQL and SQLite the insertion uses InsertIgnore so
+// if another call inserts the same record first the query is just
+// repeated. On SQL Server a failed insertion is followed by the
+// query, and the insertion error is returned if nothing is found.
+func (c DB) QueryOneOrInsert(
+	ctx context.Context,
+	table Table,
+	record interface{},
+	query string,
+	params ...interface{},
+) (created bool, err error) {
+	err = c.QueryOne(ctx, record, query, params...)
+	if !errors.Is(err, ErrRecordNotFound) {
+		return false, err
+	}
+
+	var insertErr error
+	if c.dialect.DriverName() == "sqlserver" {
+		insertErr = c.Insert(ctx, table, record)
+		created = insertErr == nil
+	} else {
+		created, err = c.InsertIgnore(ctx, table, record)
+		if err != nil {
+			return false, err
+		}
+	}
+	if created {
+		return true, nil
+	}
+
+	// Another record was inserted after our query, most likely
+	// by a concurrent call, so we query it again:
+	err = c.QueryOne(ctx, record, query, params...)
+	if errors.Is(err, ErrRecordNotFound) {
+		if insertErr != nil {
+			return false, insertErr
+		}
+		return false, fmt.Errorf(
+			"KSQL: the record conflicted with an existing one on table `%s` which doesn't match the input query",
+			table.name,
+		)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return false, nil
+}
@@ -0,0 +1,171 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestQueryOneOrInsert(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID    int    `ksql:"id"`
+		Email string `ksql:"email"`
+	}
+	usersTable := NewTable("users")
+
+	// newFakeRows returns one row with id 42 or no rows if found is false
+	newFakeRows := func(found bool) Rows {
+		return &mockRows{
+			NextFn: func() bool {
+				next := found
+				found = false
+				return next
+			},
+			ColumnsFn: func() ([]string, error) {
+				return []string{"id", "email"}, nil
+			},
+			ScanFn: func(args ...interface{}) error {
+				*args[0].(*int) = 42
+				if len(args) > 1 {
+					*args[1].(*string) = "existing@example.com"
+				}
+				return nil
+			},
+		}
+	}
+
+	t.Run("on postgres", func(t *testing.T) {
+		tests := []struct {
+			desc string
+
+			// The results for each query, in order:
+			results []bool
+
+			expectCreated bool
+			expectUser    User
+			expectQueries []string
+			expectErr     []string
+		}{
+			{
+				desc:          "should return the existing record",
+				results:       []bool{true},
+				expectCreated: false,
+				expectUser:    User{ID: 42, Email: "existing@example.com"},
+				expectQueries: []string{"SELECT"},
+			},
+			{
+				desc:          "should insert the record if it doesn't exist",
+				results:       []bool{false, true},
+				expectCreated: true,
+				expectUser:    User{ID: 42, Email: "new@example.com"},
+				expectQueries: []string{"SELECT", "INSERT"},
+			},
+			{
+				desc:          "should query again if the insertion conflicts",
+				results:       []bool{false, false, true},
+				expectCreated: false,
+				expectUser:    User{ID: 42, Email: "existing@example.com"},
+				expectQueries: []string{"SELECT", "INSERT", "SELECT"},
+			},
+			{
+				desc:          "should report conflicts with records not matching the query",
+				results:       []bool{false, false, false},
+				expectUser:    User{Email: "new@example.com"},
+				expectQueries: []string{"SELECT", "INSERT", "SELECT"},
+				expectErr:     []string{"KSQL", "conflicted", "users"},
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				var queries []string
+				c := DB{
+					dialect: sqldialect.SupportedDialects["postgres"],
+					db: mockDBAdapter{
+						QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+							queries = append(queries, strings.Fields(query)[0])
+							found := test.results[0]
+							test.results = test.results[1:]
+							return newFakeRows(found), nil
+						},
+					},
+				}
+
+				user := User{Email: "new@example.com"}
+				created, err := c.QueryOneOrInsert(ctx, usersTable, &user, "FROM users WHERE email = $1", user.Email)
+				if test.expectErr != nil {
+					tt.AssertErrContains(t, err, test.expectErr...)
+				} else {
+					tt.AssertNoErr(t, err)
+				}
+				tt.AssertEqual(t, created, test.expectCreated)
+				tt.AssertEqual(t, user, test.expectUser)
+				tt.AssertEqual(t, queries, test.expectQueries)
+			})
+		}
+	})
+
+	t.Run("on sqlserver", func(t *testing.T) {
+		t.Run("should query again if the insertion fails", func(t *testing.T) {
+			results := []bool{false, true}
+			c := DB{
+				dialect: sqldialect.SupportedDialects["sqlserver"],
+				db: mockDBAdapter{
+					QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+						if strings.HasPrefix(query, "INSERT") {
+							return nil, errors.New("fakeDuplicateKeyErr")
+						}
+						found := results[0]
+						results = results[1:]
+						return newFakeRows(found), nil
+					},
+				},
+			}
+
+			user := User{Email: "new@example.com"}
+			created, err := c.QueryOneOrInsert(ctx, usersTable, &user, "FROM users WHERE email = @p1", user.Email)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, created, false)
+			tt.AssertEqual(t, user, User{ID: 42, Email: "existing@example.com"})
+		})
+
+		t.Run("should return the insertion error if the record is still not found", func(t *testing.T) {
+			c := DB{
+				dialect: sqldialect.SupportedDialects["sqlserver"],
+				db: mockDBAdapter{
+					QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+						if strings.HasPrefix(query, "INSERT") {
+							return nil, errors.New("fakeInsertErr")
+						}
+						return newFakeRows(false), nil
+					},
+				},
+			}
+
+			user := User{Email: "new@example.com"}
+			created, err := c.QueryOneOrInsert(ctx, usersTable, &user, "FROM users WHERE email = @p1", user.Email)
+			tt.AssertErrContains(t, err, "fakeInsertErr")
+			tt.AssertEqual(t, created, false)
+		})
+	})
+
+	t.Run("should report query errors", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+					return nil, errors.New("fakeQueryErr")
+				},
+			},
+		}
+
+		var user User
+		_, err := c.QueryOneOrInsert(ctx, usersTable, &user, "FROM users WHERE email = $1", "new@example.com")
+		tt.AssertErrContains(t, err, "fakeQueryErr")
+	})
+}
@@ -0,0 +1,75 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// QueryRows runs the query and calls `fn` with the resulting Rows, which
+// is useful for reading results whose columns are only known at runtime,
+// e.g. for exporting the results of arbitrary queries:
+//
+//	err := db.QueryRows(ctx, func(rows ksql.Rows) error {
+//		columns, err := rows.Columns()
+//		...
+//		for rows.Next() {
+//			...
+//		}
+//		return nil
+//	}, "SELECT * FROM users WHERE age > $1", 18)
+//
+// Unlike calling the DBAdapter directly the query goes through the
+// same validations, logging, stats and options as the Query method.
+//
+// The rows are closed and rows.Err() is checked after `fn` returns,
+// and the errors returned by `fn` are returned unchanged.
+func (c DB) QueryRows(
+	ctx context.Context,
+	fn func(rows Rows) error,
+	query string,
+	params ...interface{},
+) (err error) {
+	if err := c.checkQuery(ctx, query, params); err != nil {
+		return err
+	}
+
+	var numRows int
+	defer ctxLog(ctx, query, params, time.Now(), &numRows, &err)
+	defer c.recordStats(query, &numRows, &err)
+
+	rows, err := c.queryContext(ctx, query, params...)
+	if err != nil {
+		return OpError{
+			Method: "QueryRows",
+			Query:  query,
+			Err:    fmt.Errorf("error running query: %w", err),
+		}
+	}
+	defer rows.Close()
+
+	err = fn(countedRows{Rows: rows, numRows: &numRows})
+	if err != nil {
+		return err
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("KSQL: unexpected error when parsing query result: %w", err)
+	}
+
+	return rows.Close()
+}
+
+// countedRows counts the rows read so they can be logged
+type countedRows struct {
+	Rows
+	numRows *int
+}
+
+func (c countedRows) Next() bool {
+	if !c.Rows.Next() {
+		return false
+	}
+	*c.numRows++
+	return true
+}
@@ -0,0 +1,78 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestQueryRows(t *testing.T) {
+	ctx := context.Background()
+
+	newMockDB := func(config Config, queried *bool) DB {
+		c, err := NewWithAdapter(mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				*queried = true
+				remaining := 2
+				return mockRows{
+					NextFn: func() bool {
+						remaining--
+						return remaining >= 0
+					},
+					ColumnsFn: func() ([]string, error) {
+						return []string{"id", "name"}, nil
+					},
+					ScanFn: func(args ...interface{}) error {
+						return nil
+					},
+				}, nil
+			},
+		}, sqldialect.SupportedDialects["postgres"], config)
+		tt.AssertNoErr(t, err)
+		return c
+	}
+
+	t.Run("should call fn with the rows and record the rows read", func(t *testing.T) {
+		var queried bool
+		c := newMockDB(Config{CollectStats: true}, &queried)
+
+		var numRows int
+		var columns []string
+		err := c.QueryRows(ctx, func(rows Rows) (err error) {
+			columns, err = rows.Columns()
+			for rows.Next() {
+				numRows++
+			}
+			return err
+		}, "SELECT id, name FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, columns, []string{"id", "name"})
+		tt.AssertEqual(t, numRows, 2)
+		tt.AssertEqual(t, c.StatsByTable()["users"], TableStats{Queries: 1, Rows: 2})
+	})
+
+	t.Run("should validate the query before running it", func(t *testing.T) {
+		var queried bool
+		c := newMockDB(Config{ForbidUnparameterizedStrings: true}, &queried)
+
+		err := c.QueryRows(ctx, func(rows Rows) error {
+			return nil
+		}, "SELECT id, name FROM users WHERE name = 'John'")
+		tt.AssertErrContains(t, err, "KSQL", "ForbidUnparameterizedStrings")
+		tt.AssertEqual(t, queried, false)
+	})
+
+	t.Run("should return the errors of fn unchanged", func(t *testing.T) {
+		var queried bool
+		c := newMockDB(Config{}, &queried)
+
+		fakeErr := errors.New("fakeErrMsg")
+		err := c.QueryRows(ctx, func(rows Rows) error {
+			return fakeErr
+		}, "SELECT id, name FROM users")
+		tt.AssertEqual(t, err, fakeErr)
+	})
+}
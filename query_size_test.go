@@ -0,0 +1,94 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestQuerySizeLimits(t *testing.T) {
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+	usersTable := NewTable("users")
+
+	newFakeDB := func(config Config) (DB, *int) {
+		var numCalls int
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+					numCalls++
+					return mockRows{
+						NextFn: func() bool { return false },
+						ColumnsFn: func() ([]string, error) {
+							return []string{"id", "name"}, nil
+						},
+					}, nil
+				},
+				ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+					numCalls++
+					return mockResult{}, nil
+				},
+			},
+			config: config,
+		}, &numCalls
+	}
+
+	t.Run("should reject queries with too many params", func(t *testing.T) {
+		ctx := context.Background()
+		db, numCalls := newFakeDB(Config{MaxParams: 2})
+
+		var users []User
+		err := db.Query(ctx, &users, "FROM users WHERE id IN ($1, $2, $3)", 1, 2, 3)
+		tt.AssertEqual(t, errors.Is(err, ErrQueryTooLarge), true)
+		tt.AssertErrContains(t, err, "3 params", "limit is 2")
+
+		var sizeErr QueryTooLargeError
+		tt.AssertEqual(t, errors.As(err, &sizeErr), true)
+		tt.AssertEqual(t, sizeErr.NumParams, 3)
+		tt.AssertEqual(t, sizeErr.MaxParams, 2)
+
+		_, err = db.Exec(ctx, "DELETE FROM users WHERE id IN ($1, $2, $3)", 1, 2, 3)
+		tt.AssertEqual(t, errors.Is(err, ErrQueryTooLarge), true)
+
+		tt.AssertEqual(t, *numCalls, 0)
+	})
+
+	t.Run("should reject queries with too many bytes", func(t *testing.T) {
+		ctx := context.Background()
+		db, numCalls := newFakeDB(Config{MaxQueryBytes: 20})
+
+		_, err := db.Exec(ctx, "DELETE FROM users WHERE name = $1", "fakeName")
+		tt.AssertEqual(t, errors.Is(err, ErrQueryTooLarge), true)
+		tt.AssertErrContains(t, err, "bytes", "limit is 20")
+
+		var sizeErr QueryTooLargeError
+		tt.AssertEqual(t, errors.As(err, &sizeErr), true)
+		tt.AssertEqual(t, sizeErr.NumBytes, len("DELETE FROM users WHERE name = $1"))
+		tt.AssertEqual(t, sizeErr.MaxBytes, 20)
+
+		err = db.Insert(ctx, usersTable, &User{Name: "fakeName"})
+		tt.AssertEqual(t, errors.Is(err, ErrQueryTooLarge), true)
+
+		tt.AssertEqual(t, *numCalls, 0)
+	})
+
+	t.Run("should run queries within the limits", func(t *testing.T) {
+		ctx := context.Background()
+		db, numCalls := newFakeDB(Config{MaxQueryBytes: 100, MaxParams: 3})
+
+		var users []User
+		err := db.Query(ctx, &users, "FROM users WHERE id IN ($1, $2, $3)", 1, 2, 3)
+		tt.AssertNoErr(t, err)
+
+		_, err = db.Exec(ctx, "DELETE FROM users WHERE id = $1", 1)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, *numCalls, 2)
+	})
+}
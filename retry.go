@@ -0,0 +1,97 @@
+package ksql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// ConnErrorClassifier can optionally be implemented by the DBAdapter
+// in order to inform which errors were caused by connection-level
+// failures, e.g. a broken pipe or a replica failover, which is used
+// for deciding if the queries using the RetryOnReplicaError option
+// should be retried.
+//
+// If it is not implemented KSQL uses the IsConnError function,
+// which only recognizes the errors from the standard library,
+// so adapters are expected to call it for the errors that are
+// not specific to their drivers.
+type ConnErrorClassifier interface {
+	IsConnError(err error) bool
+}
+
+// RetryOnReplicaError makes Query and QueryOne retry the query up to `n`
+// times when it fails because of a connection-level error, e.g.
+// a broken pipe or a replica failover, since retrying reads is safe:
+//
+//	ctx = ksql.InjectOptions(ctx, ksql.RetryOnReplicaError(3))
+//	err := db.Query(ctx, &users, "FROM users WHERE age > $1", 18)
+//
+// The errors are classified by the DBAdapter if it implements the
+// ConnErrorClassifier interface. Serialization failures, i.e. the
+// SQLSTATE 40001, are also retried since they are sent by hot standby
+// replicas when a read conflicts with the replication. Queries are never retried inside
+// transactions, since the transaction is lost with the connection,
+// and the retries are made immediately, since the broken connection
+// is discarded by the connection pool.
+func RetryOnReplicaError(n int) Option {
+	return func(o *callOptions) {
+		o.replicaRetries = n
+	}
+}
+
+// retryOnConnError calls fn again while it returns connection-level
+// errors, up to the number of retries set with RetryOnReplicaError.
+func (c DB) retryOnConnError(ctx context.Context, fn func(ctx context.Context) error) error {
+	retries := getCallOptions(ctx).replicaRetries
+
+	// Disabling the option so nested calls don't retry as well:
+	ctx = InjectOptions(ctx, RetryOnReplicaError(0))
+
+	err := fn(ctx)
+	if _, isTx := c.db.(Tx); isTx {
+		return err
+	}
+
+	for i := 0; i < retries && err != nil && ctx.Err() == nil && c.isRetriableReadError(err); i++ {
+		err = fn(ctx)
+	}
+
+	return err
+}
+
+func (c DB) isRetriableReadError(err error) bool {
+	if state, _ := SQLState(err); state == SQLStateSerializationFailure {
+		return true
+	}
+
+	if classifier, ok := c.db.(ConnErrorClassifier); ok {
+		return classifier.IsConnError(err)
+	}
+
+	return IsConnError(err)
+}
+
+// IsConnError recognizes the connection-level errors returned
+// by the standard library packages, e.g. io.ErrUnexpectedEOF
+// or a broken pipe, it is used when the DBAdapter doesn't
+// implement the ConnErrorClassifier interface.
+func IsConnError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		// Timeouts are not retried since the query
+		// might have been executed by the server:
+		(errors.As(err, &netErr) && !netErr.Timeout())
+}
@@ -0,0 +1,203 @@
+package ksql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// mockConnErrorClassifier mocks the ksql.ConnErrorClassifier interface
+type mockConnErrorClassifier struct {
+	DBAdapter
+	IsConnErrorFn func(err error) bool
+}
+
+func (m mockConnErrorClassifier) IsConnError(err error) bool {
+	return m.IsConnErrorFn(err)
+}
+
+func TestRetryOnReplicaError(t *testing.T) {
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	// newFlakyAdapter returns an adapter that fails
+	// with the input errors before succeeding
+	newFlakyAdapter := func(numCalls *int, errs ...error) DBAdapter {
+		return mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+				*numCalls++
+				if len(errs) > 0 {
+					err := errs[0]
+					errs = errs[1:]
+					return nil, err
+				}
+
+				numRows := 1
+				return mockRows{
+					NextFn: func() bool {
+						numRows--
+						return numRows >= 0
+					},
+					ColumnsFn: func() ([]string, error) {
+						return []string{"id", "name"}, nil
+					},
+					ScanFn: func(args ...interface{}) error {
+						*args[0].(*int) = 42
+						*args[1].(*string) = "fakeName"
+						return nil
+					},
+				}, nil
+			},
+		}
+	}
+
+	dialect := sqldialect.SupportedDialects["postgres"]
+
+	t.Run("should retry Query and QueryOne on connection errors", func(t *testing.T) {
+		ctx := InjectOptions(context.Background(), RetryOnReplicaError(2))
+
+		var numCalls int
+		c, err := NewWithAdapter(newFlakyAdapter(&numCalls, driver.ErrBadConn, io.ErrUnexpectedEOF), dialect)
+		tt.AssertNoErr(t, err)
+
+		var users []User
+		err = c.Query(ctx, &users, "FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, users, []User{{ID: 42, Name: "fakeName"}})
+		tt.AssertEqual(t, numCalls, 3)
+
+		numCalls = 0
+		c, err = NewWithAdapter(newFlakyAdapter(&numCalls, driver.ErrBadConn), dialect)
+		tt.AssertNoErr(t, err)
+
+		var user User
+		err = c.QueryOne(ctx, &user, "FROM users WHERE id = $1", 42)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, user, User{ID: 42, Name: "fakeName"})
+		tt.AssertEqual(t, numCalls, 2)
+	})
+
+	t.Run("should retry serialization failures", func(t *testing.T) {
+		ctx := InjectOptions(context.Background(), RetryOnReplicaError(2))
+
+		var numCalls int
+		conflictErr := fmt.Errorf("wrapped: %w", fakeStaterError{state: SQLStateSerializationFailure})
+		c, err := NewWithAdapter(mockConnErrorClassifier{
+			DBAdapter: newFlakyAdapter(&numCalls, conflictErr),
+			IsConnErrorFn: func(err error) bool {
+				return false
+			},
+		}, dialect)
+		tt.AssertNoErr(t, err)
+
+		var users []User
+		err = c.Query(ctx, &users, "FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, numCalls, 2)
+	})
+
+	t.Run("should stop after the maximum number of retries", func(t *testing.T) {
+		ctx := InjectOptions(context.Background(), RetryOnReplicaError(1))
+
+		var numCalls int
+		c, err := NewWithAdapter(newFlakyAdapter(&numCalls, driver.ErrBadConn, driver.ErrBadConn), dialect)
+		tt.AssertNoErr(t, err)
+
+		var users []User
+		err = c.Query(ctx, &users, "FROM users")
+		tt.AssertEqual(t, errors.Is(err, driver.ErrBadConn), true)
+		tt.AssertEqual(t, numCalls, 2)
+	})
+
+	t.Run("should not retry other errors", func(t *testing.T) {
+		ctx := InjectOptions(context.Background(), RetryOnReplicaError(3))
+
+		var numCalls int
+		c, err := NewWithAdapter(newFlakyAdapter(&numCalls, fmt.Errorf("fakeSyntaxErr")), dialect)
+		tt.AssertNoErr(t, err)
+
+		var users []User
+		err = c.Query(ctx, &users, "FROM users")
+		tt.AssertErrContains(t, err, "fakeSyntaxErr")
+		tt.AssertEqual(t, numCalls, 1)
+	})
+
+	t.Run("should not retry network timeouts", func(t *testing.T) {
+		ctx := InjectOptions(context.Background(), RetryOnReplicaError(3))
+
+		var numCalls int
+		timeoutErr := &net.DNSError{Err: "fakeTimeoutErr", IsTimeout: true}
+		c, err := NewWithAdapter(newFlakyAdapter(&numCalls, timeoutErr), dialect)
+		tt.AssertNoErr(t, err)
+
+		var users []User
+		err = c.Query(ctx, &users, "FROM users")
+		tt.AssertErrContains(t, err, "fakeTimeoutErr")
+		tt.AssertEqual(t, numCalls, 1)
+	})
+
+	t.Run("should not retry without the option", func(t *testing.T) {
+		var numCalls int
+		c, err := NewWithAdapter(newFlakyAdapter(&numCalls, driver.ErrBadConn), dialect)
+		tt.AssertNoErr(t, err)
+
+		var users []User
+		err = c.Query(context.Background(), &users, "FROM users")
+		tt.AssertEqual(t, errors.Is(err, driver.ErrBadConn), true)
+		tt.AssertEqual(t, numCalls, 1)
+	})
+
+	t.Run("should not retry inside transactions", func(t *testing.T) {
+		ctx := InjectOptions(context.Background(), RetryOnReplicaError(3))
+
+		var numCalls int
+		adapter := newFlakyAdapter(&numCalls, driver.ErrBadConn)
+		c, err := NewWithAdapter(mockTxBeginner{
+			DBAdapter: adapter,
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					DBAdapter: adapter,
+					RollbackFn: func(ctx context.Context) error {
+						return nil
+					},
+				}, nil
+			},
+		}, dialect)
+		tt.AssertNoErr(t, err)
+
+		err = c.Transaction(ctx, func(db Provider) error {
+			var users []User
+			return db.Query(ctx, &users, "FROM users")
+		})
+		tt.AssertEqual(t, errors.Is(err, driver.ErrBadConn), true)
+		tt.AssertEqual(t, numCalls, 1)
+	})
+
+	t.Run("should use the adapter classification if available", func(t *testing.T) {
+		ctx := InjectOptions(context.Background(), RetryOnReplicaError(3))
+
+		var numCalls int
+		c, err := NewWithAdapter(mockConnErrorClassifier{
+			DBAdapter: newFlakyAdapter(&numCalls, fmt.Errorf("fakeFailoverErr")),
+			IsConnErrorFn: func(err error) bool {
+				return strings.Contains(err.Error(), "fakeFailoverErr")
+			},
+		}, dialect)
+		tt.AssertNoErr(t, err)
+
+		var users []User
+		err = c.Query(ctx, &users, "FROM users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, numCalls, 2)
+	})
+}
@@ -0,0 +1,87 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+type rlsSettingsKey struct{}
+
+// InjectRLSSettings adds Postgres settings to the context that are set
+// at the start of every transaction started with this context,
+// which makes it practical to use row-level security for multitenancy:
+//
+//	ctx = ksql.InjectRLSSettings(ctx, map[string]string{
+//		"app.tenant_id": tenantID,
+//	})
+//
+//	err := db.Transaction(ctx, func(db ksql.Provider) error {
+//		// The RLS policies can now read the tenant with:
+//		//   current_setting('app.tenant_id')
+//		return db.Query(ctx, &orders, "FROM orders")
+//	})
+//
+// The settings are set with `set_config(name, value, true)`, which works
+// like `SET LOCAL` but with the values sent as query params, so they only
+// last until the end of the transaction and can't leak to other requests
+// using the same connection.
+//
+// For the same reason queries using this context must run inside a
+// transaction, otherwise KSQL returns an error instead of silently running
+// them without the settings.
+//
+// This option only affects Postgres and is ignored for other databases.
+func InjectRLSSettings(ctx context.Context, settings map[string]string) context.Context {
+	// Copying so changes to the input map don't affect the context:
+	settingsCopy := make(map[string]string, len(settings))
+	for k, v := range settings {
+		settingsCopy[k] = v
+	}
+
+	return context.WithValue(ctx, rlsSettingsKey{}, settingsCopy)
+}
+
+func getRLSSettings(ctx context.Context, dialectName string) map[string]string {
+	if dialectName != "postgres" {
+		return nil
+	}
+
+	settings, _ := ctx.Value(rlsSettingsKey{}).(map[string]string)
+	return settings
+}
+
+// checkRLSSettings returns an error if the context has RLS settings and the
+// query is not running inside a transaction, since the settings wouldn't apply.
+func (c DB) checkRLSSettings(ctx context.Context) error {
+	if len(getRLSSettings(ctx, c.dialect.DriverName())) == 0 {
+		return nil
+	}
+
+	if _, isTx := c.db.(Tx); !isTx {
+		return fmt.Errorf("KSQL: queries using a context with RLS settings must run inside a transaction")
+	}
+
+	return nil
+}
+
+// applyRLSSettings sets the RLS settings injected in the context on the transaction
+func applyRLSSettings(ctx context.Context, dialectName string, tx DBAdapter) error {
+	settings := getRLSSettings(ctx, dialectName)
+
+	// Sorting so the order of the queries is deterministic:
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		_, err := tx.ExecContext(ctx, "SELECT set_config($1, $2, true)", name, settings[name])
+		if err != nil {
+			return fmt.Errorf("KSQL: error applying RLS setting `%s`: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestInjectRLSSettings(t *testing.T) {
+	type execCall struct {
+		query  string
+		params []interface{}
+	}
+
+	newFakeDB := func(dialect string) (DB, *[]execCall) {
+		var calls []execCall
+		adapter := mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+				calls = append(calls, execCall{query: query, params: params})
+				return mockResult{}, nil
+			},
+		}
+
+		db, err := NewWithAdapter(mockTxBeginner{
+			DBAdapter: adapter,
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					DBAdapter: adapter,
+					CommitFn: func(ctx context.Context) error {
+						return nil
+					},
+					RollbackFn: func(ctx context.Context) error {
+						return nil
+					},
+				}, nil
+			},
+		}, sqldialect.SupportedDialects[dialect])
+		tt.AssertNoErr(t, err)
+
+		return db, &calls
+	}
+
+	t.Run("should apply the settings at the start of transactions", func(t *testing.T) {
+		settings := map[string]string{
+			"app.user_id":   "7",
+			"app.tenant_id": "42",
+		}
+		ctx := InjectRLSSettings(context.Background(), settings)
+
+		// Changes to the input map should not affect the context:
+		settings["app.tenant_id"] = "43"
+
+		db, calls := newFakeDB("postgres")
+		err := db.Transaction(ctx, func(db Provider) error {
+			_, err := db.Exec(ctx, "DELETE FROM orders")
+			return err
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, *calls, []execCall{
+			{query: "SELECT set_config($1, $2, true)", params: []interface{}{"app.tenant_id", "42"}},
+			{query: "SELECT set_config($1, $2, true)", params: []interface{}{"app.user_id", "7"}},
+			{query: "DELETE FROM orders"},
+		})
+	})
+
+	t.Run("should apply the settings of nested transactions", func(t *testing.T) {
+		db, calls := newFakeDB("postgres")
+		err := db.Transaction(context.Background(), func(db Provider) error {
+			ctx := InjectRLSSettings(context.Background(), map[string]string{"app.tenant_id": "42"})
+			return db.Transaction(ctx, func(db Provider) error {
+				return nil
+			})
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, *calls, []execCall{
+			{query: "SELECT set_config($1, $2, true)", params: []interface{}{"app.tenant_id", "42"}},
+		})
+	})
+
+	t.Run("should reject queries outside of transactions", func(t *testing.T) {
+		ctx := InjectRLSSettings(context.Background(), map[string]string{"app.tenant_id": "42"})
+
+		db, calls := newFakeDB("postgres")
+		_, err := db.Exec(ctx, "DELETE FROM orders")
+		tt.AssertErrContains(t, err, "KSQL", "RLS", "transaction")
+
+		var users []struct {
+			ID int `ksql:"id"`
+		}
+		err = db.Query(ctx, &users, "FROM users")
+		tt.AssertErrContains(t, err, "KSQL", "RLS", "transaction")
+
+		tt.AssertEqual(t, len(*calls), 0)
+	})
+
+	t.Run("should be ignored for other databases", func(t *testing.T) {
+		ctx := InjectRLSSettings(context.Background(), map[string]string{"app.tenant_id": "42"})
+
+		db, calls := newFakeDB("mysql")
+		_, err := db.Exec(ctx, "DELETE FROM orders")
+		tt.AssertNoErr(t, err)
+
+		err = db.Transaction(ctx, func(db Provider) error {
+			return nil
+		})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, *calls, []execCall{{query: "DELETE FROM orders"}})
+	})
+}
@@ -0,0 +1,66 @@
+package ksql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+type scanConverterStatus struct {
+	code string
+}
+
+func init() {
+	ksqlmodifiers.RegisterScanConverter(func(dbValue string) (scanConverterStatus, error) {
+		return scanConverterStatus{code: dbValue}, nil
+	})
+}
+
+func TestScanConverters(t *testing.T) {
+	ctx := context.Background()
+
+	type Order struct {
+		ID             int                  `ksql:"id"`
+		Status         scanConverterStatus  `ksql:"status"`
+		PreviousStatus *scanConverterStatus `ksql:"previous_status"`
+	}
+
+	c := DB{
+		dialect: sqldialect.SupportedDialects["postgres"],
+		db: mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				hasNext := true
+				return mockRows{
+					NextFn: func() bool {
+						next := hasNext
+						hasNext = false
+						return next
+					},
+					ColumnsFn: func() ([]string, error) {
+						return []string{"id", "status", "previous_status"}, nil
+					},
+					ScanFn: func(args ...interface{}) error {
+						*args[0].(*int) = 42
+						// The driver returns []byte which is converted to the input type of the converter:
+						err := args[1].(sql.Scanner).Scan([]byte("shipped"))
+						if err != nil {
+							return err
+						}
+						return args[2].(sql.Scanner).Scan("paid")
+					},
+				}, nil
+			},
+		},
+	}
+
+	var order Order
+	err := c.QueryOne(ctx, &order, "FROM orders WHERE id = $1", 42)
+	tt.AssertNoErr(t, err)
+	tt.AssertEqual(t, order.ID, 42)
+	tt.AssertEqual(t, order.Status, scanConverterStatus{code: "shipped"})
+	tt.AssertEqual(t, *order.PreviousStatus, scanConverterStatus{code: "paid"})
+}
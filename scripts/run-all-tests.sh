@@ -25,5 +25,13 @@ export PATH=$PATH:$(pwd)/scripts
 ( cd adapters/kpostgres ; run-with-replace.sh go test -coverprofile=coverage.txt -covermode=atomic -coverpkg=github.com/vingarcia/ksql ./... )
 ( cd adapters/ksqlserver ; run-with-replace.sh go test -coverprofile=coverage.txt -covermode=atomic -coverpkg=github.com/vingarcia/ksql ./... )
 ( cd adapters/kmysql ; run-with-replace.sh go test -coverprofile=coverage.txt -covermode=atomic -coverpkg=github.com/vingarcia/ksql ./... )
+( cd adapters/kodbc ; run-with-replace.sh go test -coverprofile=coverage.txt -covermode=atomic -coverpkg=github.com/vingarcia/ksql ./... )
+
+# And for the ksqltesting module, which also uses the local adapters:
+( cd ksqltesting ; run-with-replace.sh go test -coverprofile=coverage.txt -covermode=atomic -coverpkg=github.com/vingarcia/ksql ./... )
+
+# And for the tools:
+( cd tools/ksqlcheck ; run-with-replace.sh go test ./... )
+( cd tools/ksqlgen ; run-with-replace.sh go test ./... )
 
 # codecov will find all `coverate.txt` files, so it will work fine.
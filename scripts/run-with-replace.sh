@@ -3,8 +3,19 @@
 cmd=$@
 
 # Update go.mod with replace so testing will
-# run against the local version of ksql:
-echo "replace github.com/vingarcia/ksql => ../../" >> go.mod
+# run against the local version of ksql, the path is
+# absolute since the modules are not all on the same depth:
+root=$(git rev-parse --show-toplevel)
+echo "replace github.com/vingarcia/ksql => $root" >> go.mod
+
+# Modules that depend on the adapters, e.g. ksqltesting,
+# should also run against their local versions:
+for adapter in $(ls $root/adapters); do
+  if grep -q "github.com/vingarcia/ksql/adapters/$adapter " go.mod; then
+    echo "replace github.com/vingarcia/ksql/adapters/$adapter => $root/adapters/$adapter" >> go.mod
+  fi
+done
+
 go mod tidy
 
 # Run the input command:
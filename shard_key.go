@@ -0,0 +1,79 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+)
+
+// ShardKeyValue is a partition key set with the ShardKey option
+type ShardKeyValue struct {
+	Column string
+	Value  interface{}
+}
+
+// ShardKey informs the value of the partition key used by the queries
+// of the call, so adapters for sharded deployments, e.g. Citus or Vitess,
+// can route them without vendor-specific SQL on the application code:
+//
+//	ctx = ksql.InjectOptions(ctx, ksql.ShardKey("customer_id", customerID))
+//	err := db.Query(ctx, &orders, "FROM orders WHERE customer_id = $1", customerID)
+//
+// The keys are translated into query comments or session settings by
+// adapters implementing the ShardKeyRouter interface, e.g. the
+// kmysql.ShardKeyCommentAdapter, other adapters can read them from
+// the context with the ShardKeys function. Adapters that
+// don't use the keys just ignore them.
+//
+// Using this option again for the same column replaces its value.
+func ShardKey(column string, value interface{}) Option {
+	return func(o *callOptions) {
+		keys := make([]ShardKeyValue, 0, len(o.shardKeys)+1)
+		for _, key := range o.shardKeys {
+			if key.Column != column {
+				keys = append(keys, key)
+			}
+		}
+		o.shardKeys = append(keys, ShardKeyValue{Column: column, Value: value})
+	}
+}
+
+// ShardKeys returns the partition keys set on the context with the
+// ShardKey option, in the order they were set, or nil if there are none.
+func ShardKeys(ctx context.Context) []ShardKeyValue {
+	return getCallOptions(ctx).shardKeys
+}
+
+// ShardKeyRouter can optionally be implemented by the DBAdapter, or by the
+// Tx it returns, in order to translate the keys set with the ShardKey option
+// into whatever the sharding layer expects, e.g. a comment on the query.
+//
+// RouteQuery is only called if at least one key was set, and the
+// query it returns is the one sent to the database.
+type ShardKeyRouter interface {
+	RouteQuery(ctx context.Context, query string, keys []ShardKeyValue) (string, error)
+}
+
+// routeQuery returns the query rewritten by the ShardKeyRouter,
+// inside transactions the router of the outer adapter is used
+// if the Tx doesn't implement the interface.
+func (c DB) routeQuery(ctx context.Context, query string) (string, error) {
+	keys := ShardKeys(ctx)
+	if len(keys) == 0 {
+		return query, nil
+	}
+
+	router, ok := c.db.(ShardKeyRouter)
+	if !ok {
+		router, ok = c.outerAdapter.(ShardKeyRouter)
+	}
+	if !ok {
+		return query, nil
+	}
+
+	routedQuery, err := router.RouteQuery(ctx, query, keys)
+	if err != nil {
+		return "", fmt.Errorf("KSQL: error routing the query using the shard keys: %w", err)
+	}
+
+	return routedQuery, nil
+}
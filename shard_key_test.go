@@ -0,0 +1,208 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+type mockShardKeyRouter struct {
+	mockTxBeginner
+	RouteQueryFn func(ctx context.Context, query string, keys []ShardKeyValue) (string, error)
+}
+
+func (m mockShardKeyRouter) RouteQuery(ctx context.Context, query string, keys []ShardKeyValue) (string, error) {
+	return m.RouteQueryFn(ctx, query, keys)
+}
+
+type mockShardKeyRouterWithBatcher struct {
+	mockShardKeyRouter
+	PrepareContextFn func(ctx context.Context, query string) error
+	SendBatchFn      func(ctx context.Context, queries []BatchQuery) ([]int64, error)
+}
+
+func (m mockShardKeyRouterWithBatcher) PrepareContext(ctx context.Context, query string) error {
+	return m.PrepareContextFn(ctx, query)
+}
+
+func (m mockShardKeyRouterWithBatcher) SendBatch(ctx context.Context, queries []BatchQuery) ([]int64, error) {
+	return m.SendBatchFn(ctx, queries)
+}
+
+func TestShardKey(t *testing.T) {
+	dialect := sqldialect.SupportedDialects["postgres"]
+
+	t.Run("should store the keys on the context", func(t *testing.T) {
+		ctx := context.Background()
+		tt.AssertEqual(t, len(ShardKeys(ctx)), 0)
+
+		ctx = InjectOptions(ctx, ShardKey("customer_id", 42), ShardKey("region", "eu"))
+		ctx2 := InjectOptions(ctx, ShardKey("customer_id", 43))
+
+		tt.AssertEqual(t, ShardKeys(ctx), []ShardKeyValue{
+			{Column: "customer_id", Value: 42},
+			{Column: "region", Value: "eu"},
+		})
+		tt.AssertEqual(t, ShardKeys(ctx2), []ShardKeyValue{
+			{Column: "region", Value: "eu"},
+			{Column: "customer_id", Value: 43},
+		})
+	})
+
+	// newFakeDB returns a DB whose adapter prefixes the
+	// queries with a comment containing the shard keys:
+	newFakeDB := func(t *testing.T, wrap func(DBAdapter) DBAdapter) (DB, *[]string) {
+		var queries []string
+		adapter := mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+				queries = append(queries, query)
+				return mockResult{}, nil
+			},
+		}
+
+		var router DBAdapter = mockShardKeyRouter{
+			mockTxBeginner: mockTxBeginner{
+				DBAdapter: adapter,
+				BeginTxFn: func(ctx context.Context) (Tx, error) {
+					return mockTx{
+						DBAdapter: adapter,
+						CommitFn: func(ctx context.Context) error {
+							return nil
+						},
+						RollbackFn: func(ctx context.Context) error {
+							return nil
+						},
+					}, nil
+				},
+			},
+			RouteQueryFn: func(ctx context.Context, query string, keys []ShardKeyValue) (string, error) {
+				if keys[0].Value == nil {
+					return "", errors.New("fakeRoutingErrMsg")
+				}
+				return fmt.Sprintf("/* %s=%v */ %s", keys[0].Column, keys[0].Value, query), nil
+			},
+		}
+		if wrap != nil {
+			router = wrap(router)
+		}
+
+		db, err := NewWithAdapter(router, dialect)
+		tt.AssertNoErr(t, err)
+
+		return db, &queries
+	}
+
+	t.Run("should route the queries with the adapter", func(t *testing.T) {
+		db, queries := newFakeDB(t, nil)
+
+		ctx := InjectOptions(context.Background(), ShardKey("customer_id", 42))
+		_, err := db.Exec(ctx, "DELETE FROM orders WHERE customer_id = $1", 42)
+		tt.AssertNoErr(t, err)
+
+		_, err = db.Exec(context.Background(), "DELETE FROM orders")
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, *queries, []string{
+			"/* customer_id=42 */ DELETE FROM orders WHERE customer_id = $1",
+			"DELETE FROM orders",
+		})
+	})
+
+	t.Run("should route the queries inside transactions", func(t *testing.T) {
+		db, queries := newFakeDB(t, nil)
+
+		ctx := InjectOptions(context.Background(), ShardKey("customer_id", 42))
+		err := db.Transaction(ctx, func(db Provider) error {
+			_, err := db.Exec(ctx, "DELETE FROM orders")
+			return err
+		})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, *queries, []string{"/* customer_id=42 */ DELETE FROM orders"})
+	})
+
+	t.Run("should route the queries of adapters wrapped by DetectRowsLeaks", func(t *testing.T) {
+		db, queries := newFakeDB(t, func(adapter DBAdapter) DBAdapter {
+			return DetectRowsLeaks(adapter, time.Minute, nil)
+		})
+
+		ctx := InjectOptions(context.Background(), ShardKey("customer_id", 42))
+		_, err := db.Exec(ctx, "DELETE FROM orders")
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, *queries, []string{"/* customer_id=42 */ DELETE FROM orders"})
+	})
+
+	t.Run("should report routing errors", func(t *testing.T) {
+		db, queries := newFakeDB(t, nil)
+
+		ctx := InjectOptions(context.Background(), ShardKey("customer_id", nil))
+		_, err := db.Exec(ctx, "DELETE FROM orders")
+		tt.AssertErrContains(t, err, "KSQL", "shard keys", "fakeRoutingErrMsg")
+		tt.AssertEqual(t, len(*queries), 0)
+	})
+
+	t.Run("should route the prepared and batched queries", func(t *testing.T) {
+		var queries []string
+		db, err := NewWithAdapter(mockShardKeyRouterWithBatcher{
+			mockShardKeyRouter: mockShardKeyRouter{
+				RouteQueryFn: func(ctx context.Context, query string, keys []ShardKeyValue) (string, error) {
+					return fmt.Sprintf("/* %s=%v */ %s", keys[0].Column, keys[0].Value, query), nil
+				},
+			},
+			PrepareContextFn: func(ctx context.Context, query string) error {
+				queries = append(queries, query)
+				return nil
+			},
+			SendBatchFn: func(ctx context.Context, batch []BatchQuery) ([]int64, error) {
+				for _, query := range batch {
+					queries = append(queries, query.Query)
+				}
+				return []int64{1, 1}, nil
+			},
+		}, dialect)
+		tt.AssertNoErr(t, err)
+
+		ctx := InjectOptions(context.Background(), ShardKey("customer_id", 42))
+		err = db.Prepare(ctx, "SELECT id FROM orders WHERE id = $1")
+		tt.AssertNoErr(t, err)
+
+		batch := []BatchQuery{
+			{Query: "DELETE FROM orders WHERE id = $1", Params: []interface{}{1}},
+			{Query: "DELETE FROM orders WHERE id = $1", Params: []interface{}{2}},
+		}
+		_, err = db.SendBatch(ctx, batch)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, queries, []string{
+			"/* customer_id=42 */ SELECT id FROM orders WHERE id = $1",
+			"/* customer_id=42 */ DELETE FROM orders WHERE id = $1",
+			"/* customer_id=42 */ DELETE FROM orders WHERE id = $1",
+		})
+		// The input queries should not be modified:
+		tt.AssertEqual(t, batch[0].Query, "DELETE FROM orders WHERE id = $1")
+	})
+
+	t.Run("should ignore the keys on adapters that are not routers", func(t *testing.T) {
+		var queries []string
+		db := DB{
+			dialect: dialect,
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, args ...interface{}) (Result, error) {
+					queries = append(queries, query)
+					return mockResult{}, nil
+				},
+			},
+		}
+
+		ctx := InjectOptions(context.Background(), ShardKey("customer_id", 42))
+		_, err := db.Exec(ctx, "DELETE FROM orders")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, queries, []string{"DELETE FROM orders"})
+	})
+}
@@ -0,0 +1,152 @@
+package ksql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+// OpenSQLDB works like sql.Open, except that it also applies the options
+// of the input config that must run every time a new connection is opened:
+//
+// - If config.DSNProvider is set it is called for retrieving the
+// connection string, and the input connectionString is ignored.
+//
+// - If config.OnNewConnection is set it is called with the new connection.
+//
+// It is meant to be used by the adapters built on top of the
+// `database/sql` package, so they all support these options.
+func OpenSQLDB(
+	driverName string,
+	connectionString string,
+	config Config,
+) (*sql.DB, error) {
+	if config.DSNProvider == nil && config.OnNewConnection == nil {
+		return sql.Open(driverName, connectionString)
+	}
+
+	// sql.Open doesn't connect to the database, so this is a
+	// cheap way of getting the driver registered with this name:
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	db.Close()
+
+	return sql.OpenDB(NewConnector(d, connectionString, config)), nil
+}
+
+// NewConnector returns a driver.Connector that opens connections
+// with the input driver applying the same options applied by OpenSQLDB.
+//
+// It is useful for adapters that need to instantiate the driver
+// themselves instead of using the one registered on the sql package.
+func NewConnector(
+	d driver.Driver,
+	connectionString string,
+	config Config,
+) driver.Connector {
+	return sqlConnector{
+		driver:          d,
+		dsn:             connectionString,
+		dsnProvider:     config.DSNProvider,
+		onNewConnection: config.OnNewConnection,
+	}
+}
+
+// sqlConnector is a driver.Connector that asks for a new DSN and
+// runs the OnNewConnection function every time it opens a connection.
+type sqlConnector struct {
+	driver          driver.Driver
+	dsn             string
+	dsnProvider     func(ctx context.Context) (string, error)
+	onNewConnection func(ctx context.Context, conn Execer) error
+}
+
+// Connect implements the driver.Connector interface
+func (c sqlConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	dsn := c.dsn
+	if c.dsnProvider != nil {
+		var err error
+		dsn, err = c.dsnProvider(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("KSQL: unable to get connection string from the DSNProvider: %w", err)
+		}
+	}
+
+	conn, err := c.open(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.onNewConnection != nil {
+		err = c.onNewConnection(ctx, connExecer{conn})
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("KSQL: error initializing new connection on OnNewConnection: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+func (c sqlConnector) open(ctx context.Context, dsn string) (driver.Conn, error) {
+	if driverCtx, ok := c.driver.(driver.DriverContext); ok {
+		connector, err := driverCtx.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return connector.Connect(ctx)
+	}
+
+	return c.driver.Open(dsn)
+}
+
+// Driver implements the driver.Connector interface
+func (c sqlConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// connExecer adapts a driver.Conn to the Execer interface
+type connExecer struct {
+	conn driver.Conn
+}
+
+// ExecContext implements the Execer interface
+func (c connExecer) ExecContext(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	namedArgs := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		value, err := driver.DefaultParameterConverter.ConvertValue(arg)
+		if err != nil {
+			return nil, fmt.Errorf("KSQL: unsupported argument %d: %w", i+1, err)
+		}
+		namedArgs[i] = driver.NamedValue{Ordinal: i + 1, Value: value}
+	}
+
+	if execer, ok := c.conn.(driver.ExecerContext); ok {
+		result, err := execer.ExecContext(ctx, query, namedArgs)
+		if err != driver.ErrSkip {
+			return result, err
+		}
+	}
+
+	// The driver doesn't support executing queries
+	// directly so we fall back to a prepared statement:
+	stmt, err := c.conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	if stmtCtx, ok := stmt.(driver.StmtExecContext); ok {
+		return stmtCtx.ExecContext(ctx, namedArgs)
+	}
+
+	values := make([]driver.Value, len(namedArgs))
+	for i, arg := range namedArgs {
+		values[i] = arg.Value
+	}
+	return stmt.Exec(values)
+}
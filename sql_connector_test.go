@@ -0,0 +1,144 @@
+package ksql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type fakeSQLDriver struct {
+	fakeOpenedDSNs *[]string
+}
+
+func (f fakeSQLDriver) Open(dsn string) (driver.Conn, error) {
+	*f.fakeOpenedDSNs = append(*f.fakeOpenedDSNs, dsn)
+	return fakeSQLConn{dsn: dsn}, nil
+}
+
+type fakeSQLConn struct {
+	driver.Conn
+	dsn string
+}
+
+func (fakeSQLConn) Close() error {
+	return nil
+}
+
+func (f fakeSQLConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	fakeExecQueries = append(fakeExecQueries, fmt.Sprint(f.dsn, ": ", query, " ", args[0].Value))
+	return driver.RowsAffected(0), nil
+}
+
+var fakeOpenedDSNs []string
+var fakeExecQueries []string
+
+func init() {
+	sql.Register("ksql-fake-driver", fakeSQLDriver{fakeOpenedDSNs: &fakeOpenedDSNs})
+}
+
+func TestOpenSQLDB(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should use the connection string if there is no DSNProvider", func(t *testing.T) {
+		fakeOpenedDSNs = nil
+
+		db, err := OpenSQLDB("ksql-fake-driver", "fakeDSN", Config{})
+		tt.AssertNoErr(t, err)
+		defer db.Close()
+
+		conn, err := db.Conn(ctx)
+		tt.AssertNoErr(t, err)
+		conn.Close()
+
+		tt.AssertEqual(t, fakeOpenedDSNs, []string{"fakeDSN"})
+	})
+
+	t.Run("should ask the DSNProvider for a DSN on each new connection", func(t *testing.T) {
+		fakeOpenedDSNs = nil
+
+		var numCalls int
+		db, err := OpenSQLDB("ksql-fake-driver", "ignoredDSN", Config{
+			DSNProvider: func(ctx context.Context) (string, error) {
+				numCalls++
+				return fmt.Sprintf("fakeDSN%d", numCalls), nil
+			},
+		})
+		tt.AssertNoErr(t, err)
+		defer db.Close()
+
+		conn1, err := db.Conn(ctx)
+		tt.AssertNoErr(t, err)
+		conn2, err := db.Conn(ctx)
+		tt.AssertNoErr(t, err)
+		conn1.Close()
+		conn2.Close()
+
+		tt.AssertEqual(t, fakeOpenedDSNs, []string{"fakeDSN1", "fakeDSN2"})
+	})
+
+	t.Run("should report errors from the DSNProvider", func(t *testing.T) {
+		db, err := OpenSQLDB("ksql-fake-driver", "", Config{
+			DSNProvider: func(ctx context.Context) (string, error) {
+				return "", fmt.Errorf("fakeProviderErr")
+			},
+		})
+		tt.AssertNoErr(t, err)
+		defer db.Close()
+
+		err = db.PingContext(ctx)
+		tt.AssertErrContains(t, err, "KSQL", "DSNProvider", "fakeProviderErr")
+	})
+
+	t.Run("should run OnNewConnection on each new connection", func(t *testing.T) {
+		fakeOpenedDSNs = nil
+		fakeExecQueries = nil
+
+		db, err := OpenSQLDB("ksql-fake-driver", "fakeDSN", Config{
+			OnNewConnection: func(ctx context.Context, conn Execer) error {
+				_, err := conn.ExecContext(ctx, "SET search_path TO", "fakeSchema")
+				return err
+			},
+		})
+		tt.AssertNoErr(t, err)
+		defer db.Close()
+
+		conn1, err := db.Conn(ctx)
+		tt.AssertNoErr(t, err)
+		conn2, err := db.Conn(ctx)
+		tt.AssertNoErr(t, err)
+		conn1.Close()
+		conn2.Close()
+
+		tt.AssertEqual(t, fakeOpenedDSNs, []string{"fakeDSN", "fakeDSN"})
+		tt.AssertEqual(t, fakeExecQueries, []string{
+			"fakeDSN: SET search_path TO fakeSchema",
+			"fakeDSN: SET search_path TO fakeSchema",
+		})
+	})
+
+	t.Run("should report errors from OnNewConnection", func(t *testing.T) {
+		db, err := OpenSQLDB("ksql-fake-driver", "fakeDSN", Config{
+			OnNewConnection: func(ctx context.Context, conn Execer) error {
+				return fmt.Errorf("fakeInitErr")
+			},
+		})
+		tt.AssertNoErr(t, err)
+		defer db.Close()
+
+		err = db.PingContext(ctx)
+		tt.AssertErrContains(t, err, "KSQL", "OnNewConnection", "fakeInitErr")
+	})
+
+	t.Run("should report unknown drivers", func(t *testing.T) {
+		_, err := OpenSQLDB("ksql-unknown-driver", "", Config{
+			DSNProvider: func(ctx context.Context) (string, error) {
+				return "", nil
+			},
+		})
+		tt.AssertErrContains(t, err, "ksql-unknown-driver")
+	})
+}
@@ -0,0 +1,81 @@
+package ksql
+
+import (
+	"errors"
+	"sync"
+)
+
+// The SQLSTATE codes returned by SQLState for the most common errors,
+// the adapters of databases that have their own error codes translate
+// them to these values so they can be checked in a portable way.
+//
+// The other codes follow the SQL standard or the Postgres extensions to it,
+// see: https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	SQLStateNotNullViolation     = "23502"
+	SQLStateForeignKeyViolation  = "23503"
+	SQLStateUniqueViolation      = "23505"
+	SQLStateCheckViolation       = "23514"
+	SQLStateSerializationFailure = "40001"
+	SQLStateDeadlockDetected     = "40P01"
+	SQLStateSyntaxError          = "42601"
+	SQLStateUndefinedTable       = "42P01"
+)
+
+// SQLStateFunc extracts the SQLSTATE code from the errors of a
+// specific database driver, returning false if the error was not
+// created by that driver or if it has no equivalent SQLSTATE.
+type SQLStateFunc func(err error) (state string, ok bool)
+
+var sqlStateFuncs struct {
+	sync.RWMutex
+	fns []SQLStateFunc
+}
+
+// RegisterSQLStateFunc registers a function for extracting the
+// SQLSTATE code from the errors of a database driver.
+//
+// It is meant to be called by the adapters on their init
+// functions, so users usually don't need to call it.
+func RegisterSQLStateFunc(fn SQLStateFunc) {
+	sqlStateFuncs.Lock()
+	defer sqlStateFuncs.Unlock()
+	sqlStateFuncs.fns = append(sqlStateFuncs.fns, fn)
+}
+
+// SQLState returns the SQLSTATE code of the database error wrapped
+// by err, e.g. "23505" for unique violations, or false if err was
+// not caused by the database or if the code is unknown:
+//
+//	err := db.Insert(ctx, UsersTable, &user)
+//	if state, _ := ksql.SQLState(err); state == ksql.SQLStateUniqueViolation {
+//		return ErrEmailAlreadyInUse
+//	}
+//
+// Errors implementing a `SQLState() string` method, like the ones of the
+// Postgres drivers, are supported directly, for the other databases the
+// adapters register functions translating the driver-specific codes.
+func SQLState(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+
+	var stater interface {
+		SQLState() string
+	}
+	if errors.As(err, &stater) {
+		if state := stater.SQLState(); state != "" {
+			return state, true
+		}
+	}
+
+	sqlStateFuncs.RLock()
+	defer sqlStateFuncs.RUnlock()
+	for _, fn := range sqlStateFuncs.fns {
+		if state, ok := fn(err); ok {
+			return state, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,63 @@
+package ksql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+)
+
+type fakeStaterError struct {
+	state string
+}
+
+func (f fakeStaterError) Error() string    { return "fakeStaterError" }
+func (f fakeStaterError) SQLState() string { return f.state }
+
+type fakeDriverError struct {
+	code int
+}
+
+func (f fakeDriverError) Error() string { return "fakeDriverError" }
+
+func TestSQLState(t *testing.T) {
+	t.Run("should return false for nil and non database errors", func(t *testing.T) {
+		_, ok := SQLState(nil)
+		tt.AssertEqual(t, ok, false)
+
+		_, ok = SQLState(errors.New("fakeErrMsg"))
+		tt.AssertEqual(t, ok, false)
+	})
+
+	t.Run("should use the SQLState method of wrapped errors", func(t *testing.T) {
+		err := OpError{
+			Method: "Insert",
+			Err:    fmt.Errorf("fakeWrapper: %w", fakeStaterError{state: "23505"}),
+		}
+
+		state, ok := SQLState(err)
+		tt.AssertEqual(t, ok, true)
+		tt.AssertEqual(t, state, SQLStateUniqueViolation)
+
+		_, ok = SQLState(fakeStaterError{state: ""})
+		tt.AssertEqual(t, ok, false)
+	})
+
+	t.Run("should use the registered functions", func(t *testing.T) {
+		RegisterSQLStateFunc(func(err error) (string, bool) {
+			var driverErr fakeDriverError
+			if !errors.As(err, &driverErr) || driverErr.code != 1062 {
+				return "", false
+			}
+			return SQLStateUniqueViolation, true
+		})
+
+		state, ok := SQLState(fmt.Errorf("fakeWrapper: %w", fakeDriverError{code: 1062}))
+		tt.AssertEqual(t, ok, true)
+		tt.AssertEqual(t, state, SQLStateUniqueViolation)
+
+		_, ok = SQLState(fakeDriverError{code: 42})
+		tt.AssertEqual(t, ok, false)
+	})
+}
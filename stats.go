@@ -0,0 +1,82 @@
+package ksql
+
+import (
+	"sync"
+
+	"github.com/vingarcia/ksql/internal/sqlparse"
+)
+
+// TableStats contains the counters collected for a single
+// table when the Config.CollectStats option is enabled.
+type TableStats struct {
+	// Queries is the number of operations sent to the database
+	// that referenced the table, including the failed ones.
+	Queries int64
+
+	// Rows is the number of rows read by the Query, QueryOne, QueryChunks
+	// and QueryRows methods plus the number of rows affected by the
+	// operations that report it, e.g. Patch, Delete and Exec.
+	Rows int64
+
+	// Errors is the number of operations that returned errors.
+	Errors int64
+}
+
+// statsCollector is shared by all the copies of a DB,
+// including the ones used inside transactions.
+type statsCollector struct {
+	mu      sync.Mutex
+	byTable map[string]*TableStats
+}
+
+// StatsByTable returns a snapshot of the counters collected for each table
+// since the DB was created, or nil if the Config.CollectStats option is disabled.
+//
+// The table of each operation is the first table referenced by its query,
+// e.g. on `SELECT ... FROM users u JOIN posts p ...` only the `users` table
+// is counted, and operations whose table can't be detected are not counted.
+func (c DB) StatsByTable() map[string]TableStats {
+	if c.stats == nil {
+		return nil
+	}
+
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+
+	stats := make(map[string]TableStats, len(c.stats.byTable))
+	for table, tableStats := range c.stats.byTable {
+		stats[table] = *tableStats
+	}
+
+	return stats
+}
+
+// recordStats is meant to be deferred together with ctxLog,
+// so it receives pointers to the values set by the caller.
+func (c DB) recordStats(query string, numRows *int, err *error) {
+	if c.stats == nil {
+		return
+	}
+
+	table := sqlparse.TableName(query)
+	if table == "" {
+		return
+	}
+
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+
+	tableStats, found := c.stats.byTable[table]
+	if !found {
+		tableStats = &TableStats{}
+		c.stats.byTable[table] = tableStats
+	}
+
+	tableStats.Queries++
+	if numRows != nil && *numRows > 0 {
+		tableStats.Rows += int64(*numRows)
+	}
+	if err != nil && *err != nil {
+		tableStats.Errors++
+	}
+}
@@ -0,0 +1,131 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestStatsByTable(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+	usersTable := NewTable("users")
+
+	newAdapter := func(numRows int, queryErr error) DBAdapter {
+		return mockDBAdapter{
+			QueryContextFn: func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				if queryErr != nil {
+					return nil, queryErr
+				}
+
+				remaining := numRows
+				return mockRows{
+					NextFn: func() bool {
+						remaining--
+						return remaining >= 0
+					},
+					ColumnsFn: func() ([]string, error) {
+						return []string{"id", "name"}, nil
+					},
+					ScanFn: func(args ...interface{}) error {
+						return nil
+					},
+				}, nil
+			},
+			ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+				return mockResult{
+					RowsAffectedFn: func() (int64, error) {
+						return 2, nil
+					},
+				}, nil
+			},
+		}
+	}
+
+	t.Run("should return nil if CollectStats is disabled", func(t *testing.T) {
+		c, err := NewWithAdapter(newAdapter(3, nil), sqldialect.SupportedDialects["postgres"])
+		tt.AssertNoErr(t, err)
+
+		var users []User
+		err = c.Query(ctx, &users, "FROM users")
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, c.StatsByTable() == nil, true)
+	})
+
+	t.Run("should count the operations of each table", func(t *testing.T) {
+		c, err := NewWithAdapter(newAdapter(3, nil), sqldialect.SupportedDialects["postgres"], Config{
+			CollectStats: true,
+		})
+		tt.AssertNoErr(t, err)
+
+		var users []User
+		err = c.Query(ctx, &users, "FROM users")
+		tt.AssertNoErr(t, err)
+
+		var user User
+		err = c.QueryOne(ctx, &user, "FROM users WHERE id = $1", 42)
+		tt.AssertNoErr(t, err)
+
+		err = c.Patch(ctx, usersTable, &User{ID: 42, Name: "fakeName"})
+		tt.AssertNoErr(t, err)
+
+		_, err = c.Exec(ctx, "DELETE FROM posts WHERE user_id = $1", 42)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, c.StatsByTable(), map[string]TableStats{
+			"users": {Queries: 3, Rows: 6},
+			"posts": {Queries: 1, Rows: 2},
+		})
+	})
+
+	t.Run("should count the errors", func(t *testing.T) {
+		c, err := NewWithAdapter(newAdapter(0, errors.New("fakeErrMsg")), sqldialect.SupportedDialects["postgres"], Config{
+			CollectStats: true,
+		})
+		tt.AssertNoErr(t, err)
+
+		var users []User
+		err = c.Query(ctx, &users, "FROM users")
+		tt.AssertErrContains(t, err, "fakeErrMsg")
+
+		tt.AssertEqual(t, c.StatsByTable(), map[string]TableStats{
+			"users": {Queries: 1, Errors: 1},
+		})
+	})
+
+	t.Run("should share the stats with the transactions", func(t *testing.T) {
+		adapter := newAdapter(1, nil)
+		c, err := NewWithAdapter(mockTxBeginner{
+			DBAdapter: adapter,
+			BeginTxFn: func(ctx context.Context) (Tx, error) {
+				return mockTx{
+					DBAdapter: adapter,
+					CommitFn: func(ctx context.Context) error {
+						return nil
+					},
+				}, nil
+			},
+		}, sqldialect.SupportedDialects["postgres"], Config{
+			CollectStats: true,
+		})
+		tt.AssertNoErr(t, err)
+
+		err = c.Transaction(ctx, func(db Provider) error {
+			var users []User
+			return db.Query(ctx, &users, "FROM users")
+		})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, c.StatsByTable(), map[string]TableStats{
+			"users": {Queries: 1, Rows: 1},
+		})
+	})
+}
@@ -7,8 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -78,11 +82,22 @@ func RunTestsForAdapter(
 			QueryOneTest(t, dialect, connStr, newDBAdapter)
 			InsertTest(t, dialect, connStr, newDBAdapter)
 			DeleteTest(t, dialect, connStr, newDBAdapter)
+			DeleteReturningTest(t, dialect, connStr, newDBAdapter)
 			PatchTest(t, dialect, connStr, newDBAdapter)
 			QueryChunksTest(t, dialect, connStr, newDBAdapter)
 			TransactionTest(t, dialect, connStr, newDBAdapter)
 			ModifiersTest(t, dialect, connStr, newDBAdapter)
 			ScanRowsTest(t, dialect, connStr, newDBAdapter)
+			ServerVersionTest(t, dialect, connStr, newDBAdapter)
+			PoolStatsTest(t, dialect, connStr, newDBAdapter)
+			UpsertTest(t, dialect, connStr, newDBAdapter)
+			InsertIgnoreTest(t, dialect, connStr, newDBAdapter)
+			QueryOneOrInsertTest(t, dialect, connStr, newDBAdapter)
+			ConcurrencyTest(t, dialect, connStr, newDBAdapter)
+			BinaryDataTest(t, dialect, connStr, newDBAdapter)
+			DecimalTest(t, dialect, connStr, newDBAdapter)
+			SQLStateTest(t, dialect, connStr, newDBAdapter)
+			DeleteCascadeCheckTest(t, dialect, connStr, newDBAdapter)
 		})
 	})
 }
@@ -345,6 +360,253 @@ func QueryTest(
 			})
 		}
 
+		t.Run("using the :ksql_columns marker", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+			defer closer.Close()
+
+			t.Run("should query queries starting with WITH correctly", func(t *testing.T) {
+				_, err := db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Carla Souza', 20, '{"country":"BR"}')`)
+				tt.AssertNoErr(t, err)
+				_, err = db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Davi Souza', 10, '{"country":"US"}')`)
+				tt.AssertNoErr(t, err)
+
+				c := newTestDB(db, dialect)
+				var users []user
+				err = c.Query(ctx, &users, fmt.Sprint(
+					`WITH adults AS (SELECT * FROM users WHERE age >= 18)`,
+					` SELECT :ksql_columns FROM adults WHERE name like `, c.dialect.Placeholder(0),
+				), "% Souza")
+
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, len(users), 1)
+				tt.AssertNotEqual(t, users[0].ID, uint(0))
+				tt.AssertEqual(t, users[0].Name, "Carla Souza")
+				tt.AssertEqual(t, users[0].Age, 20)
+				tt.AssertEqual(t, users[0].Address.Country, "BR")
+			})
+
+			t.Run("should expand the marker with a table alias correctly", func(t *testing.T) {
+				_, err := db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Eva Lima', 30, '{"country":"PT"}')`)
+				tt.AssertNoErr(t, err)
+				var eva user
+				getUserByName(db, dialect, &eva, "Eva Lima")
+
+				_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO posts (user_id, title) VALUES (`, eva.ID, `, 'Eva Post1')`))
+				tt.AssertNoErr(t, err)
+
+				c := newTestDB(db, dialect)
+				var users []user
+				err = c.Query(ctx, &users, fmt.Sprint(
+					`SELECT :ksql_columns(u) FROM users u JOIN posts p ON p.user_id = u.id`,
+					` WHERE p.title = `, c.dialect.Placeholder(0),
+				), "Eva Post1")
+
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, len(users), 1)
+				tt.AssertEqual(t, users[0].ID, eva.ID)
+				tt.AssertEqual(t, users[0].Name, "Eva Lima")
+				tt.AssertEqual(t, users[0].Address.Country, "PT")
+			})
+		})
+
+		t.Run("using columns with the same name", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+			defer closer.Close()
+
+			_, err = db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Fabio Reis', 40, '{"country":"BR"}')`)
+			tt.AssertNoErr(t, err)
+			var fabio user
+			getUserByName(db, dialect, &fabio, "Fabio Reis")
+
+			_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO posts (user_id, title) VALUES (`, fabio.ID, `, 'Fabio Post1')`))
+			tt.AssertNoErr(t, err)
+
+			t.Run("should report error if two columns are mapped to the same attribute", func(t *testing.T) {
+				c := newTestDB(db, dialect)
+				var posts []post
+				err = c.Query(ctx, &posts, fmt.Sprint(
+					`SELECT u.id, p.id, p.title FROM users u JOIN posts p ON p.user_id = u.id`,
+					` WHERE p.title = `, c.dialect.Placeholder(0),
+				), "Fabio Post1")
+
+				tt.AssertErrContains(t, err, "KSQL", "id", "ID", "QualifiedColumns")
+			})
+
+			t.Run("should map the columns by their qualified names with the QualifiedColumns option", func(t *testing.T) {
+				type userPost struct {
+					UserID uint   `ksql:"u.id"`
+					PostID uint   `ksql:"p.id"`
+					Title  string `ksql:"title"`
+				}
+
+				c := newTestDB(db, dialect)
+				var userPosts []userPost
+				err = c.Query(InjectOptions(ctx, QualifiedColumns()), &userPosts, fmt.Sprint(
+					`SELECT u.id, p.id, p.title FROM users u JOIN posts p ON p.user_id = u.id`,
+					` WHERE p.title = `, c.dialect.Placeholder(0),
+				), "Fabio Post1")
+
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, len(userPosts), 1)
+				tt.AssertEqual(t, userPosts[0].UserID, fabio.ID)
+				tt.AssertNotEqual(t, userPosts[0].PostID, uint(0))
+				tt.AssertEqual(t, userPosts[0].Title, "Fabio Post1")
+			})
+		})
+
+		t.Run("using the QualifiedSelect option", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+			defer closer.Close()
+
+			_, err = db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Gabi Souza', 25, '{"country":"BR"}')`)
+			tt.AssertNoErr(t, err)
+			var gabi user
+			getUserByName(db, dialect, &gabi, "Gabi Souza")
+
+			_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO posts (user_id, title) VALUES (`, gabi.ID, `, 'Gabi Post1')`))
+			tt.AssertNoErr(t, err)
+
+			t.Run("should qualify the generated columns so joins are not ambiguous", func(t *testing.T) {
+				c := newTestDB(db, dialect)
+				var users []user
+				err = c.Query(InjectOptions(ctx, QualifiedSelect(usersTable.WithAlias("u"))), &users, fmt.Sprint(
+					`FROM users u JOIN posts p ON p.user_id = u.id`,
+					` WHERE p.title = `, c.dialect.Placeholder(0),
+				), "Gabi Post1")
+
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, len(users), 1)
+				tt.AssertEqual(t, users[0].ID, gabi.ID)
+				tt.AssertEqual(t, users[0].Name, "Gabi Souza")
+				tt.AssertEqual(t, users[0].Address.Country, "BR")
+			})
+
+			t.Run("should qualify the nested structs with the table aliases", func(t *testing.T) {
+				c := newTestDB(db, dialect)
+				var rows []struct {
+					User user `tablename:"users"`
+					Post post `tablename:"posts"`
+				}
+				err = c.Query(InjectOptions(ctx, QualifiedSelect(usersTable.WithAlias("u"), postsTable.WithAlias("p"))), &rows, fmt.Sprint(
+					`FROM users u JOIN posts p ON p.user_id = u.id`,
+					` WHERE p.title = `, c.dialect.Placeholder(0),
+				), "Gabi Post1")
+
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, len(rows), 1)
+				tt.AssertEqual(t, rows[0].User.ID, gabi.ID)
+				tt.AssertEqual(t, rows[0].User.Name, "Gabi Souza")
+				tt.AssertEqual(t, rows[0].Post.UserID, gabi.ID)
+				tt.AssertEqual(t, rows[0].Post.Title, "Gabi Post1")
+			})
+		})
+
+		t.Run("using the OrderBy, Limit and Offset options", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+			defer closer.Close()
+
+			for _, name := range []string{"Ana", "Bia", "Caio", "Davi"} {
+				_, err = db.ExecContext(ctx, `INSERT INTO users (name, age) VALUES (`+dialect.Placeholder(0)+`, 20)`, name)
+				tt.AssertNoErr(t, err)
+			}
+
+			tests := []struct {
+				desc          string
+				options       []Option
+				expectedNames []string
+			}{
+				{
+					desc:          "should order the results",
+					options:       []Option{OrderBy("name DESC")},
+					expectedNames: []string{"Davi", "Caio", "Bia", "Ana"},
+				},
+				{
+					desc:          "should limit the results",
+					options:       []Option{OrderBy("name"), Limit(2)},
+					expectedNames: []string{"Ana", "Bia"},
+				},
+				{
+					desc:          "should skip the first results",
+					options:       []Option{OrderBy("name"), Offset(3)},
+					expectedNames: []string{"Davi"},
+				},
+				{
+					desc:          "should paginate the results",
+					options:       []Option{OrderBy("name"), Limit(2), Offset(1)},
+					expectedNames: []string{"Bia", "Caio"},
+				},
+			}
+			for _, test := range tests {
+				t.Run(test.desc, func(t *testing.T) {
+					c := newTestDB(db, dialect)
+
+					var users []user
+					err := c.Query(InjectOptions(ctx, test.options...), &users, "FROM users WHERE age = "+c.dialect.Placeholder(0), 20)
+					tt.AssertNoErr(t, err)
+
+					var names []string
+					for _, u := range users {
+						names = append(names, u.Name)
+					}
+					tt.AssertEqual(t, names, test.expectedNames)
+				})
+			}
+		})
+
+		t.Run("using the Config.TimeLocation option", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+			defer closer.Close()
+
+			saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+			tt.AssertNoErr(t, err)
+
+			type tsUser struct {
+				ID        uint       `ksql:"id"`
+				Name      string     `ksql:"name"`
+				CreatedAt *time.Time `ksql:"created_at"`
+				UpdatedAt time.Time  `ksql:"updated_at"`
+			}
+
+			updatedAt := tt.ParseTime(t, "2024-06-01T12:00:00Z")
+			u := tsUser{
+				Name:      "Time Zone User",
+				UpdatedAt: updatedAt,
+			}
+			err = newTestDB(db, dialect).Insert(ctx, usersTable, &u)
+			tt.AssertNoErr(t, err)
+
+			c := newTestDB(db, dialect)
+			c.config.TimeLocation = saoPaulo
+
+			var users []tsUser
+			err = c.Query(ctx, &users, "FROM users WHERE id = "+c.dialect.Placeholder(0), u.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(users), 1)
+			tt.AssertEqual(t, users[0].CreatedAt, (*time.Time)(nil))
+			tt.AssertEqual(t, users[0].UpdatedAt.Location(), saoPaulo)
+			tt.AssertEqual(t, users[0].UpdatedAt.Equal(updatedAt), true)
+		})
+
 		t.Run("testing error cases", func(t *testing.T) {
 			db, closer := newDBAdapter(t)
 			defer closer.Close()
@@ -741,6 +1003,55 @@ func QueryOneTest(
 			tt.AssertEqual(t, errors.Is(err, context.Canceled), true)
 		})
 	})
+
+	t.Run("QueryOneInto", func(t *testing.T) {
+		t.Run("should scan the joined tables into separate structs", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+			defer closer.Close()
+
+			_, err = db.ExecContext(ctx, `INSERT INTO users (name, age, address) VALUES ('Bia', 0, '{"country":"BR"}')`)
+			tt.AssertNoErr(t, err)
+			var bia user
+			getUserByName(db, dialect, &bia, "Bia")
+
+			_, err = db.ExecContext(ctx, fmt.Sprint(`INSERT INTO posts (user_id, title) VALUES (`, bia.ID, `, 'Bia Post1')`))
+			tt.AssertNoErr(t, err)
+
+			c := newTestDB(db, dialect)
+			var u user
+			var p post
+			err = c.QueryOneInto(ctx, []interface{}{&u, &p}, fmt.Sprint(
+				`SELECT u.*, p.* FROM users u JOIN posts p ON p.user_id = u.id`,
+				` WHERE u.id = `, c.dialect.Placeholder(0),
+			), bia.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, u.ID, bia.ID)
+			tt.AssertEqual(t, u.Name, "Bia")
+			tt.AssertEqual(t, u.Address.Country, "BR")
+			tt.AssertNotEqual(t, p.ID, 0)
+			tt.AssertEqual(t, p.UserID, bia.ID)
+			tt.AssertEqual(t, p.Title, "Bia Post1")
+		})
+
+		t.Run("should return ErrRecordNotFound when there are no results", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+			defer closer.Close()
+
+			c := newTestDB(db, dialect)
+			var u user
+			var p post
+			err = c.QueryOneInto(ctx, []interface{}{&u, &p}, `SELECT u.*, p.* FROM users u JOIN posts p ON p.user_id = u.id`)
+			tt.AssertEqual(t, errors.Is(err, ErrRecordNotFound), true)
+		})
+	})
 }
 
 // InsertTest runs all tests for making sure the Insert function is
@@ -1045,6 +1356,38 @@ func InsertTest(
 					}
 				})
 
+				t.Run("should insert in tables whose only columns are the composite key", func(t *testing.T) {
+					c := newTestDB(db, dialect)
+
+					type teamMember struct {
+						UserID int `ksql:"user_id"`
+						TeamID int `ksql:"team_id"`
+					}
+					teamMembersTable := NewTable("team_members", "user_id", "team_id")
+
+					err := c.Insert(ctx, teamMembersTable, &teamMember{UserID: 1, TeamID: 2})
+					tt.AssertNoErr(t, err)
+					err = c.Insert(ctx, teamMembersTable, &teamMember{UserID: 1, TeamID: 3})
+					tt.AssertNoErr(t, err)
+
+					member := teamMember{UserID: 2, TeamID: 2}
+					err = c.Insert(ctx, teamMembersTable, &member)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, member, teamMember{UserID: 2, TeamID: 2})
+
+					var members []teamMember
+					err = c.Query(ctx, &members, `FROM team_members WHERE team_id = `+c.dialect.Placeholder(0)+` ORDER BY user_id`, 2)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, members, []teamMember{
+						{UserID: 1, TeamID: 2},
+						{UserID: 2, TeamID: 2},
+					})
+
+					// Inserting the same key twice should fail:
+					err = c.Insert(ctx, teamMembersTable, &teamMember{UserID: 1, TeamID: 2})
+					tt.AssertNotEqual(t, err, nil)
+				})
+
 				t.Run("when inserting a struct with no values but composite keys should still retrieve the IDs", func(t *testing.T) {
 					c := newTestDB(db, dialect)
 
@@ -1412,6 +1755,24 @@ func DeleteTest(
 			})
 		})
 
+		t.Run("should work with tables with aliases", func(t *testing.T) {
+			c := newTestDB(db, dialect)
+
+			u := user{
+				Name: "Fernanda",
+			}
+			err := c.Insert(ctx, usersTable, &u)
+			tt.AssertNoErr(t, err)
+			tt.AssertNotEqual(t, u.ID, uint(0))
+
+			err = c.Delete(ctx, usersTable.WithAlias("u"), u.ID)
+			tt.AssertNoErr(t, err)
+
+			result := user{}
+			err = getUserByID(c.db, c.dialect, &result, u.ID)
+			tt.AssertEqual(t, err, sql.ErrNoRows)
+		})
+
 		t.Run("should work even when ksql.NewTable receives a qualified table name", func(t *testing.T) {
 			c := newTestDB(db, dialect)
 
@@ -1628,6 +1989,74 @@ func DeleteTest(
 	})
 }
 
+// DeleteReturningTest runs all tests for making sure the DeleteReturning function is
+// working for a given adapter and dialect.
+func DeleteReturningTest(
+	t *testing.T,
+	dialect sqldialect.Provider,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+) {
+	ctx := context.Background()
+
+	t.Run("DeleteReturning", func(t *testing.T) {
+		t.Run("should delete the record and return its previous values", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			c := newTestDB(db, dialect)
+
+			u1 := user{
+				Name:    "Fernanda",
+				Age:     22,
+				Address: address{City: "Belo Horizonte"},
+			}
+			err = c.Insert(ctx, usersTable, &u1)
+			tt.AssertNoErr(t, err)
+
+			u2 := user{
+				Name: "Won't be deleted",
+			}
+			err = c.Insert(ctx, usersTable, &u2)
+			tt.AssertNoErr(t, err)
+
+			var deletedUser user
+			err = c.DeleteReturning(ctx, usersTable, u1.ID, &deletedUser)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, deletedUser, u1)
+
+			var result user
+			err = getUserByID(c.db, c.dialect, &result, u1.ID)
+			tt.AssertEqual(t, err, sql.ErrNoRows)
+
+			err = getUserByID(c.db, c.dialect, &result, u2.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Name, "Won't be deleted")
+		})
+
+		t.Run("should return ErrRecordNotFound if no record matches the ID", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			c := newTestDB(db, dialect)
+
+			var deletedUser user
+			err = c.DeleteReturning(ctx, usersTable, 4200, &deletedUser)
+			tt.AssertEqual(t, err, ErrRecordNotFound)
+		})
+	})
+}
+
 // PatchTest runs all tests for making sure the Patch function is
 // working for a given adapter and dialect.
 func PatchTest(
@@ -1830,18 +2259,37 @@ func PatchTest(
 			tt.AssertEqual(t, result.Name, "Thayane")
 		})
 
-		t.Run("should return ErrRecordNotFound when asked to update an inexistent user", func(t *testing.T) {
+		t.Run("should work with tables with aliases", func(t *testing.T) {
 			c := newTestDB(db, dialect)
 
-			err = c.Patch(ctx, usersTable, user{
-				ID:   4200,
-				Name: "Thayane",
-			})
-			tt.AssertEqual(t, err, ErrRecordNotFound)
-		})
+			u := user{
+				Name: "Letícia",
+			}
+			err := c.Insert(ctx, usersTable, &u)
+			tt.AssertNoErr(t, err)
+			tt.AssertNotEqual(t, u.ID, uint(0))
 
-		t.Run("should report database errors correctly", func(t *testing.T) {
-			c := newTestDB(db, dialect)
+			err = c.Patch(ctx, usersTable.WithAlias("u"), &user{ID: u.ID, Name: "Thayane"})
+			tt.AssertNoErr(t, err)
+
+			var result user
+			err = getUserByID(c.db, c.dialect, &result, u.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Name, "Thayane")
+		})
+
+		t.Run("should return ErrRecordNotFound when asked to update an inexistent user", func(t *testing.T) {
+			c := newTestDB(db, dialect)
+
+			err = c.Patch(ctx, usersTable, user{
+				ID:   4200,
+				Name: "Thayane",
+			})
+			tt.AssertEqual(t, err, ErrRecordNotFound)
+		})
+
+		t.Run("should report database errors correctly", func(t *testing.T) {
+			c := newTestDB(db, dialect)
 
 			err = c.Patch(ctx, NewTable("non_existing_table"), user{
 				ID:   1,
@@ -1869,7 +2317,8 @@ func PatchTest(
 				ID:   1,
 				Name: "some name",
 			})
-			tt.AssertErrContains(t, err, "struct", "no values to update")
+			tt.AssertErrContains(t, err, "struct", "no values to update", "Name")
+			tt.AssertEqual(t, errors.Is(err, ErrNoValuesToUpdate), true)
 		})
 
 		t.Run("should report error if the id is missing", func(t *testing.T) {
@@ -1936,6 +2385,141 @@ func PatchTest(
 			tt.AssertEqual(t, errors.Is(err, context.Canceled), true)
 		})
 	})
+
+	t.Run("PatchOrNoop", func(t *testing.T) {
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		err := createTables(ctx, db, dialect)
+		if err != nil {
+			t.Fatal("could not create test table!, reason:", err.Error())
+		}
+
+		t.Run("should update the record and report it was updated", func(t *testing.T) {
+			c := newTestDB(db, dialect)
+
+			u := user{
+				Name: "Letícia",
+			}
+			err := c.Insert(ctx, usersTable, &u)
+			tt.AssertNoErr(t, err)
+
+			updated, err := c.PatchOrNoop(ctx, usersTable, user{
+				ID:   u.ID,
+				Name: "Thayane",
+			})
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, updated, true)
+
+			var result user
+			err = getUserByID(c.db, c.dialect, &result, u.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Name, "Thayane")
+		})
+
+		t.Run("should do nothing if the struct has no fields to update", func(t *testing.T) {
+			c := newTestDB(db, dialect)
+
+			u := user{
+				Name: "Letícia",
+			}
+			err := c.Insert(ctx, usersTable, &u)
+			tt.AssertNoErr(t, err)
+
+			updated, err := c.PatchOrNoop(ctx, usersTable, struct {
+				ID   uint   `ksql:"id"`
+				Name string `ksql:"name,skipUpdates"`
+				Age  *int   `ksql:"age"`
+			}{
+				ID:   u.ID,
+				Name: "Thayane",
+			})
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, updated, false)
+
+			var result user
+			err = getUserByID(c.db, c.dialect, &result, u.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Name, "Letícia")
+		})
+
+		t.Run("should return ErrRecordNotFound when asked to update an inexistent user", func(t *testing.T) {
+			c := newTestDB(db, dialect)
+
+			updated, err := c.PatchOrNoop(ctx, usersTable, user{
+				ID:   4200,
+				Name: "Thayane",
+			})
+			tt.AssertEqual(t, err, ErrRecordNotFound)
+			tt.AssertEqual(t, updated, false)
+		})
+	})
+
+	t.Run("PatchWithNewKeys", func(t *testing.T) {
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		err := createTables(ctx, db, dialect)
+		if err != nil {
+			t.Fatal("could not create test table!, reason:", err.Error())
+		}
+
+		permsTable := NewTable("user_permissions", "user_id", "perm_id")
+
+		t.Run("should update the keys of the record", func(t *testing.T) {
+			c := newTestDB(db, dialect)
+
+			err = createUserPermission(db, c.dialect, userPermission{
+				UserID: 42,
+				PermID: 43,
+				Type:   "existingFakeType",
+			})
+			tt.AssertNoErr(t, err)
+
+			existingPerm, err := getUserPermissionBySecondaryKeys(db, c.dialect, 42, 43)
+			tt.AssertNoErr(t, err)
+
+			// The ID is omitted because IDENTITY columns
+			// can't be updated on SQL Server:
+			err = c.PatchWithNewKeys(ctx, permsTable, &struct {
+				UserID int    `ksql:"user_id"`
+				PermID int    `ksql:"perm_id"`
+				Type   string `ksql:"type"`
+			}{
+				UserID: 42,
+				PermID: 43,
+				Type:   "newFakeType",
+			}, NewKeys{"perm_id": 44})
+			tt.AssertNoErr(t, err)
+
+			_, err = getUserPermissionBySecondaryKeys(db, c.dialect, 42, 43)
+			tt.AssertEqual(t, err, sql.ErrNoRows)
+
+			newPerm, err := getUserPermissionBySecondaryKeys(db, c.dialect, 42, 44)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, newPerm, userPermission{
+				ID:     existingPerm.ID,
+				UserID: 42,
+				PermID: 44,
+				Type:   "newFakeType",
+			})
+		})
+
+		t.Run("should return ErrRecordNotFound when the old keys don't exist", func(t *testing.T) {
+			c := newTestDB(db, dialect)
+
+			err := c.PatchWithNewKeys(ctx, permsTable, &struct {
+				UserID int    `ksql:"user_id"`
+				PermID int    `ksql:"perm_id"`
+				Type   string `ksql:"type"`
+			}{
+				UserID: 4200,
+				PermID: 4300,
+				Type:   "fakeType",
+			}, NewKeys{"perm_id": 4400})
+			tt.AssertEqual(t, err, ErrRecordNotFound)
+		})
+	})
 }
 
 // QueryChunksTest runs all tests for making sure the QueryChunks function is
@@ -2458,6 +3042,215 @@ func QueryChunksTest(
 				tt.AssertEqual(t, errors.Is(err, context.Canceled), true)
 			})
 		})
+
+		t.Run("scalar chunks", func(t *testing.T) {
+			t.Run("should query chunks of scalar values correctly", func(t *testing.T) {
+				db, closer := newDBAdapter(t)
+				defer closer.Close()
+
+				err := createTables(ctx, db, dialect)
+				if err != nil {
+					t.Fatal("could not create test table!, reason:", err.Error())
+				}
+
+				c := newTestDB(db, dialect)
+
+				_ = c.Insert(ctx, usersTable, &user{Name: "User1"})
+				_ = c.Insert(ctx, usersTable, &user{Name: "User2"})
+				_ = c.Insert(ctx, usersTable, &user{Name: "User3"})
+
+				var lengths []int
+				var names []string
+				err = c.QueryChunks(ctx, ChunkParser{
+					Query:  `SELECT name FROM users WHERE name like ` + c.dialect.Placeholder(0) + ` ORDER BY name ASC`,
+					Params: []interface{}{"User%"},
+
+					ChunkSize: 2,
+					ForEachChunk: func(buffer []string) error {
+						names = append(names, buffer...)
+						lengths = append(lengths, len(buffer))
+						return nil
+					},
+				})
+
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, lengths, []int{2, 1})
+				tt.AssertEqual(t, names, []string{"User1", "User2", "User3"})
+			})
+
+			t.Run("should query chunks of pointers to scalar values correctly", func(t *testing.T) {
+				db, closer := newDBAdapter(t)
+				defer closer.Close()
+
+				err := createTables(ctx, db, dialect)
+				if err != nil {
+					t.Fatal("could not create test table!, reason:", err.Error())
+				}
+
+				c := newTestDB(db, dialect)
+
+				_ = c.Insert(ctx, usersTable, &user{Name: "User1", Age: 22})
+
+				var ages []*int64
+				err = c.QueryChunks(ctx, ChunkParser{
+					Query: `SELECT age FROM users`,
+
+					ChunkSize: 10,
+					ForEachChunk: func(buffer []*int64) error {
+						ages = append(ages, buffer...)
+						return nil
+					},
+				})
+
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, len(ages), 1)
+				tt.AssertEqual(t, *ages[0], int64(22))
+			})
+
+			t.Run("should report error if the query returns more than one column", func(t *testing.T) {
+				db, closer := newDBAdapter(t)
+				defer closer.Close()
+
+				err := createTables(ctx, db, dialect)
+				if err != nil {
+					t.Fatal("could not create test table!, reason:", err.Error())
+				}
+
+				c := newTestDB(db, dialect)
+
+				err = c.QueryChunks(ctx, ChunkParser{
+					Query: `SELECT id, name FROM users`,
+
+					ChunkSize: 10,
+					ForEachChunk: func(buffer []string) error {
+						return nil
+					},
+				})
+				tt.AssertErrContains(t, err, "single column", "2 columns")
+			})
+
+			t.Run("should report error if the query starts with FROM", func(t *testing.T) {
+				db, closer := newDBAdapter(t)
+				defer closer.Close()
+
+				c := newTestDB(db, dialect)
+
+				err := c.QueryChunks(ctx, ChunkParser{
+					Query: `FROM users`,
+
+					ChunkSize: 10,
+					ForEachChunk: func(buffer []string) error {
+						return nil
+					},
+				})
+				tt.AssertErrContains(t, err, "SELECT", "string")
+			})
+		})
+		t.Run("with FetchSize", func(t *testing.T) {
+			if dialect.DriverName() != "postgres" {
+				t.Skip("the FetchSize option is only implemented with cursors on postgres")
+			}
+
+			queryNames := func(ctx context.Context, c DB) ([]string, error) {
+				var names []string
+				err := c.QueryChunks(ctx, ChunkParser{
+					Query:  `FROM users WHERE name like ` + c.dialect.Placeholder(0) + ` ORDER BY name`,
+					Params: []interface{}{"User%"},
+
+					ChunkSize: 3,
+					FetchSize: 2,
+					ForEachChunk: func(buffer []user) error {
+						for _, u := range buffer {
+							names = append(names, u.Name)
+						}
+						return nil
+					},
+				})
+				return names, err
+			}
+
+			t.Run("should load all the rows using a cursor", func(t *testing.T) {
+				db, closer := newDBAdapter(t)
+				defer closer.Close()
+
+				err := createTables(ctx, db, dialect)
+				if err != nil {
+					t.Fatal("could not create test table!, reason:", err.Error())
+				}
+
+				c := newTestDB(db, dialect)
+
+				for i := 1; i <= 5; i++ {
+					err = c.Insert(ctx, usersTable, &user{Name: fmt.Sprintf("User%d", i)})
+					tt.AssertNoErr(t, err)
+				}
+
+				names, err := queryNames(ctx, c)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, names, []string{"User1", "User2", "User3", "User4", "User5"})
+
+				// The transaction of the cursor should be closed:
+				var row struct {
+					Count int `ksql:"count"`
+				}
+				err = c.QueryOne(ctx, &row, `SELECT count(*) AS count FROM pg_cursors`)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, row.Count, 0)
+			})
+
+			t.Run("should load all the rows using a cursor inside a transaction", func(t *testing.T) {
+				db, closer := newDBAdapter(t)
+				defer closer.Close()
+
+				err := createTables(ctx, db, dialect)
+				if err != nil {
+					t.Fatal("could not create test table!, reason:", err.Error())
+				}
+
+				c := newTestDB(db, dialect)
+
+				var names []string
+				err = c.Transaction(ctx, func(db Provider) error {
+					tx := db.(DB)
+					for i := 1; i <= 5; i++ {
+						err := tx.Insert(ctx, usersTable, &user{Name: fmt.Sprintf("User%d", i)})
+						if err != nil {
+							return err
+						}
+					}
+
+					names, err = queryNames(ctx, tx)
+					return err
+				})
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, names, []string{"User1", "User2", "User3", "User4", "User5"})
+			})
+
+			t.Run("should propagate the deadline to the cursor statements", func(t *testing.T) {
+				db, closer := newDBAdapter(t)
+				defer closer.Close()
+
+				err := createTables(ctx, db, dialect)
+				if err != nil {
+					t.Fatal("could not create test table!, reason:", err.Error())
+				}
+
+				c := newTestDB(db, dialect)
+				c.config.PropagateDeadlineToServer = true
+
+				for i := 1; i <= 5; i++ {
+					err = c.Insert(ctx, usersTable, &user{Name: fmt.Sprintf("User%d", i)})
+					tt.AssertNoErr(t, err)
+				}
+
+				ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+				defer cancel()
+
+				names, err := queryNames(ctx, c)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, names, []string{"User1", "User2", "User3", "User4", "User5"})
+			})
+		})
 	})
 }
 
@@ -2724,13 +3517,40 @@ func TransactionTest(
 			})
 			tt.AssertEqual(t, errors.Is(err, context.Canceled), true)
 		})
-	})
-}
 
-func ModifiersTest(
-	t *testing.T,
-	dialect sqldialect.Provider,
-	connStr string,
+		t.Run("should apply the RLS settings injected on the context", func(t *testing.T) {
+			if dialect.DriverName() != "postgres" {
+				t.Skip("RLS settings are only supported on postgres")
+			}
+
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, dialect)
+
+			ctx := InjectRLSSettings(ctx, map[string]string{"app.tenant_id": "42"})
+
+			var setting struct {
+				Value string `ksql:"value"`
+			}
+			err := c.Transaction(ctx, func(db Provider) error {
+				return db.QueryOne(ctx, &setting, "SELECT current_setting('app.tenant_id') AS value")
+			})
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, setting.Value, "42")
+
+			// The setting should not leak outside of the transaction:
+			err = c.QueryOne(context.Background(), &setting, "SELECT COALESCE(current_setting('app.tenant_id', true), '') AS value")
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, setting.Value, "")
+		})
+	})
+}
+
+func ModifiersTest(
+	t *testing.T,
+	dialect sqldialect.Provider,
+	connStr string,
 	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
 ) {
 	ctx := context.Background()
@@ -3033,6 +3853,120 @@ func ModifiersTest(
 			})
 		})
 
+		t.Run("omitempty modifier", func(t *testing.T) {
+			type omitEmptyUser struct {
+				ID            uint   `ksql:"id"`
+				Name          string `ksql:"name"`
+				NullableField string `ksql:"nullable_field,omitempty"`
+			}
+
+			t.Run("should use the database default for zero values", func(t *testing.T) {
+				c := newTestDB(db, dialect)
+
+				u := omitEmptyUser{
+					Name: "Letícia",
+				}
+				err := c.Insert(ctx, usersTable, &u)
+				tt.AssertNoErr(t, err)
+				tt.AssertNotEqual(t, u.ID, 0)
+
+				var result omitEmptyUser
+				err = c.QueryOne(ctx, &result, `FROM users WHERE id = `+c.dialect.Placeholder(0), u.ID)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, result.NullableField, "not_null")
+			})
+
+			t.Run("should insert non-zero values", func(t *testing.T) {
+				c := newTestDB(db, dialect)
+
+				u := omitEmptyUser{
+					Name:          "Letícia",
+					NullableField: "fakeValue",
+				}
+				err := c.Insert(ctx, usersTable, &u)
+				tt.AssertNoErr(t, err)
+				tt.AssertNotEqual(t, u.ID, 0)
+
+				var result omitEmptyUser
+				err = c.QueryOne(ctx, &result, `FROM users WHERE id = `+c.dialect.Placeholder(0), u.ID)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, result.NullableField, "fakeValue")
+			})
+		})
+
+		t.Run("userIDOnInsert and userIDOnUpdate modifiers", func(t *testing.T) {
+			// The nullable_field column is used here as an audit column:
+			type createdByUser struct {
+				ID        uint   `ksql:"id"`
+				Name      string `ksql:"name"`
+				CreatedBy string `ksql:"nullable_field,userIDOnInsert"`
+			}
+			type updatedByUser struct {
+				ID        uint   `ksql:"id"`
+				Name      string `ksql:"name"`
+				UpdatedBy string `ksql:"nullable_field,userIDOnUpdate"`
+			}
+			type untaggedUser struct {
+				ID            uint   `ksql:"id"`
+				Name          string `ksql:"name"`
+				NullableField string `ksql:"nullable_field"`
+			}
+
+			t.Run("userIDOnInsert should be set on insertion but not on updates", func(t *testing.T) {
+				c := newTestDB(db, dialect)
+
+				u := createdByUser{
+					Name: "Nina Audit",
+				}
+				err := c.Insert(ksqlmodifiers.InjectOperator(ctx, "operator1"), usersTable, &u)
+				tt.AssertNoErr(t, err)
+				tt.AssertNotEqual(t, u.ID, 0)
+
+				u.Name = "Nina Audited"
+				err = c.Patch(ksqlmodifiers.InjectOperator(ctx, "operator2"), usersTable, &u)
+				tt.AssertNoErr(t, err)
+
+				var result untaggedUser
+				err = c.QueryOne(ctx, &result, `FROM users WHERE id = `+c.dialect.Placeholder(0), u.ID)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, result.Name, "Nina Audited")
+				tt.AssertEqual(t, result.NullableField, "operator1")
+			})
+
+			t.Run("userIDOnUpdate should be set on insertion and on updates", func(t *testing.T) {
+				c := newTestDB(db, dialect)
+
+				u := updatedByUser{
+					Name: "Otto Audit",
+				}
+				err := c.Insert(ksqlmodifiers.InjectOperator(ctx, "operator1"), usersTable, &u)
+				tt.AssertNoErr(t, err)
+				tt.AssertNotEqual(t, u.ID, 0)
+
+				var result untaggedUser
+				err = c.QueryOne(ctx, &result, `FROM users WHERE id = `+c.dialect.Placeholder(0), u.ID)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, result.NullableField, "operator1")
+
+				err = c.Patch(ksqlmodifiers.InjectOperator(ctx, "operator2"), usersTable, &u)
+				tt.AssertNoErr(t, err)
+
+				err = c.QueryOne(ctx, &result, `FROM users WHERE id = `+c.dialect.Placeholder(0), u.ID)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, result.NullableField, "operator2")
+			})
+
+			t.Run("should report error if no operator was injected", func(t *testing.T) {
+				c := newTestDB(db, dialect)
+
+				u := updatedByUser{
+					Name: "Pia Audit",
+				}
+				err := c.Insert(ctx, usersTable, &u)
+				tt.AssertErrContains(t, err, "userIDOnUpdate", "InjectOperator")
+			})
+		})
+
 		t.Run("skipUpdates modifier", func(t *testing.T) {
 			t.Run("should set the field on insertion", func(t *testing.T) {
 				c := newTestDB(db, dialect)
@@ -3259,6 +4193,58 @@ func ModifiersTest(
 				tt.AssertEqual(t, u2.Age, 42)
 			})
 		})
+
+		t.Run("enum modifier", func(t *testing.T) {
+			type enumUser struct {
+				ID   uint   `ksql:"id"`
+				Name string `ksql:"name,enum(Maria|Maria Eduarda)"`
+			}
+
+			t.Run("should save the allowed values", func(t *testing.T) {
+				c := newTestDB(db, dialect)
+
+				u := enumUser{Name: "Maria"}
+				err := c.Insert(ctx, usersTable, &u)
+				tt.AssertNoErr(t, err)
+				tt.AssertNotEqual(t, u.ID, 0)
+
+				u.Name = "Maria Eduarda"
+				err = c.Patch(ctx, usersTable, u)
+				tt.AssertNoErr(t, err)
+
+				var u2 enumUser
+				err = c.QueryOne(ctx, &u2, "FROM users WHERE id = "+c.dialect.Placeholder(0), u.ID)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, u2.Name, "Maria Eduarda")
+			})
+
+			t.Run("should report invalid values on Insert and Patch", func(t *testing.T) {
+				c := newTestDB(db, dialect)
+
+				err := c.Insert(ctx, usersTable, &enumUser{Name: "Maria Clara"})
+				tt.AssertEqual(t, errors.Is(err, ksqlmodifiers.ErrInvalidEnumValue), true)
+
+				var count struct {
+					Count int `ksql:"count"`
+				}
+				err = c.QueryOne(ctx, &count, "SELECT count(*) AS count FROM users WHERE name = "+c.dialect.Placeholder(0), "Maria Clara")
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, count.Count, 0)
+
+				u := enumUser{Name: "Maria"}
+				err = c.Insert(ctx, usersTable, &u)
+				tt.AssertNoErr(t, err)
+
+				u.Name = "Maria Clara"
+				err = c.Patch(ctx, usersTable, u)
+				tt.AssertEqual(t, errors.Is(err, ksqlmodifiers.ErrInvalidEnumValue), true)
+
+				var u2 enumUser
+				err = c.QueryOne(ctx, &u2, "FROM users WHERE id = "+c.dialect.Placeholder(0), u.ID)
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, u2.Name, "Maria")
+			})
+		})
 	})
 }
 
@@ -3301,6 +4287,33 @@ func ScanRowsTest(
 			tt.AssertEqual(t, u.Age, 14)
 		})
 
+		t.Run("should scan rows from handwritten queries with DB.ScanRow", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			err := createTables(ctx, db, dialect)
+			tt.AssertNoErr(t, err)
+
+			c := newTestDB(db, dialect)
+			_ = c.Insert(ctx, usersTable, &user{Name: "User1", Age: 22, Address: address{City: "SP"}})
+
+			rows, err := c.Adapter().QueryContext(ctx, "SELECT * FROM users WHERE name='User1'")
+			tt.AssertNoErr(t, err)
+			defer rows.Close()
+
+			tt.AssertEqual(t, rows.Next(), true)
+
+			var u user
+			err = c.ScanRow(ctx, rows, &u)
+			tt.AssertNoErr(t, err)
+
+			tt.AssertEqual(t, u.Name, "User1")
+			tt.AssertEqual(t, u.Age, 22)
+			tt.AssertEqual(t, u.Address.City, "SP")
+			tt.AssertEqual(t, rows.Next(), false)
+			tt.AssertNoErr(t, rows.Err())
+		})
+
 		t.Run("should ignore extra columns from query", func(t *testing.T) {
 			db, closer := newDBAdapter(t)
 			defer closer.Close()
@@ -3482,116 +4495,1014 @@ func ScanRowsTest(
 	})
 }
 
-func createTables(ctx context.Context, db DBAdapter, dialect sqldialect.Provider) (err error) {
-	db.ExecContext(ctx, `DROP TABLE users`)
+// UpsertTest runs all tests for making sure the Upsert function is
+// working for a given adapter and dialect.
+func UpsertTest(
+	t *testing.T,
+	dialect sqldialect.Provider,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+) {
+	ctx := context.Background()
 
-	switch dialect.DriverName() {
-	case "sqlite3":
-		_, err = db.ExecContext(ctx, `CREATE TABLE users (
-		  id INTEGER PRIMARY KEY,
-			age INTEGER,
-			name TEXT,
-			address BLOB,
-			created_at DATETIME,
-			updated_at DATETIME,
-			nullable_field TEXT DEFAULT "not_null"
-		)`)
-	case "postgres":
-		_, err = db.ExecContext(ctx, `CREATE TABLE users (
-		  id serial PRIMARY KEY,
-			age INT,
-			name VARCHAR(50),
-			address jsonb,
-			created_at TIMESTAMP,
-			updated_at TIMESTAMP,
-			nullable_field VARCHAR(50) DEFAULT 'not_null'
-		)`)
-	case "mysql":
-		_, err = db.ExecContext(ctx, `CREATE TABLE users (
-			id INT AUTO_INCREMENT PRIMARY KEY,
-			age INT,
-			name VARCHAR(50),
-			address JSON,
-			created_at DATETIME,
-			updated_at DATETIME,
-			nullable_field VARCHAR(50) DEFAULT "not_null"
-		)`)
-	case "sqlserver":
-		_, err = db.ExecContext(ctx, `CREATE TABLE users (
-			id INT IDENTITY(1,1) PRIMARY KEY,
-			age INT,
-			name VARCHAR(50),
-			address NVARCHAR(4000),
-			created_at DATETIME,
-			updated_at DATETIME,
-			nullable_field VARCHAR(50) DEFAULT 'not_null'
-		)`)
-	}
-	if err != nil {
-		return fmt.Errorf("failed to create new users table: %s", err.Error())
+	// This struct has no `id` field so it can
+	// also be inserted on IDENTITY tables on SQL Server:
+	type permission struct {
+		UserID int    `ksql:"user_id"`
+		PermID int    `ksql:"perm_id"`
+		Type   string `ksql:"type"`
 	}
 
-	db.ExecContext(ctx, `DROP TABLE posts`)
+	t.Run("Upsert", func(t *testing.T) {
+		variations := []struct {
+			desc   string
+			config []UpsertConfig
+		}{
+			{
+				desc: "using the default strategy",
+			},
+			{
+				desc:   "using MERGE on postgres",
+				config: []UpsertConfig{{UseMerge: true}},
+			},
+		}
+		for _, variation := range variations {
+			t.Run(variation.desc, func(t *testing.T) {
+				if len(variation.config) > 0 && variation.config[0].UseMerge {
+					if dialect.DriverName() != "postgres" {
+						t.Skip("the UseMerge option only affects postgres")
+					}
 
-	switch dialect.DriverName() {
-	case "sqlite3":
-		_, err = db.ExecContext(ctx, `CREATE TABLE posts (
-		  id INTEGER PRIMARY KEY,
-		  user_id INTEGER,
-			title TEXT
-		)`)
-	case "postgres":
-		_, err = db.ExecContext(ctx, `CREATE TABLE posts (
-		  id serial PRIMARY KEY,
-			user_id INT,
-			title VARCHAR(50)
-		)`)
-	case "mysql":
-		_, err = db.ExecContext(ctx, `CREATE TABLE posts (
-			id INT AUTO_INCREMENT PRIMARY KEY,
-			user_id INT,
-			title VARCHAR(50)
-		)`)
-	case "sqlserver":
-		_, err = db.ExecContext(ctx, `CREATE TABLE posts (
-			id INT IDENTITY(1,1) PRIMARY KEY,
-			user_id INT,
-			title VARCHAR(50)
-		)`)
-	}
-	if err != nil {
-		return fmt.Errorf("failed to create new posts table: %s", err.Error())
-	}
+					db, closer := newDBAdapter(t)
+					defer closer.Close()
+					if getMajorVersion(t, newTestDB(db, dialect)) < 15 {
+						t.Skip("MERGE is only supported on Postgres 15 or newer")
+					}
+				}
 
-	db.ExecContext(ctx, `DROP TABLE user_permissions`)
+				t.Run("should insert records that don't exist yet", func(t *testing.T) {
+					db, closer := newDBAdapter(t)
+					defer closer.Close()
 
-	switch dialect.DriverName() {
-	case "sqlite3":
-		_, err = db.ExecContext(ctx, `CREATE TABLE user_permissions (
-			id INTEGER PRIMARY KEY,
-			user_id INTEGER,
-			perm_id INTEGER,
-			type TEXT,
-			UNIQUE (user_id, perm_id)
-		)`)
-	case "postgres":
-		_, err = db.ExecContext(ctx, `CREATE TABLE user_permissions (
-			id serial PRIMARY KEY,
-			user_id INT,
-			perm_id INT,
-			type VARCHAR(50),
-			UNIQUE (user_id, perm_id)
-		)`)
-	case "mysql":
-		_, err = db.ExecContext(ctx, `CREATE TABLE user_permissions (
-			id INT AUTO_INCREMENT PRIMARY KEY,
-			user_id INT,
-			perm_id INT,
-			type VARCHAR(50),
-			UNIQUE KEY (user_id, perm_id)
-		)`)
-	case "sqlserver":
-		_, err = db.ExecContext(ctx, `CREATE TABLE user_permissions (
+					err := createTables(ctx, db, dialect)
+					if err != nil {
+						t.Fatal("could not create test table!, reason:", err.Error())
+					}
+
+					c := newTestDB(db, dialect)
+					err = c.Upsert(ctx, userPermissionsTable, &permission{UserID: 1, PermID: 2, Type: "read"}, variation.config...)
+					tt.AssertNoErr(t, err)
+
+					result, err := getUserPermissionBySecondaryKeys(db, dialect, 1, 2)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, result.Type, "read")
+				})
+
+				t.Run("should update records that already exist", func(t *testing.T) {
+					db, closer := newDBAdapter(t)
+					defer closer.Close()
+
+					err := createTables(ctx, db, dialect)
+					if err != nil {
+						t.Fatal("could not create test table!, reason:", err.Error())
+					}
+
+					c := newTestDB(db, dialect)
+					err = c.Insert(ctx, userPermissionsTable, &permission{UserID: 1, PermID: 2, Type: "read"})
+					tt.AssertNoErr(t, err)
+
+					err = c.Upsert(ctx, userPermissionsTable, &permission{UserID: 1, PermID: 2, Type: "write"}, variation.config...)
+					tt.AssertNoErr(t, err)
+
+					userPerms, err := getUserPermissionsByUser(db, dialect, 1)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, len(userPerms), 1)
+
+					result, err := getUserPermissionBySecondaryKeys(db, dialect, 1, 2)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, result.Type, "write")
+				})
+
+				t.Run("should leave existing records unchanged when using DoNothingOnMatch", func(t *testing.T) {
+					db, closer := newDBAdapter(t)
+					defer closer.Close()
+
+					err := createTables(ctx, db, dialect)
+					if err != nil {
+						t.Fatal("could not create test table!, reason:", err.Error())
+					}
+
+					config := UpsertConfig{DoNothingOnMatch: true}
+					if len(variation.config) > 0 {
+						config.UseMerge = variation.config[0].UseMerge
+					}
+
+					c := newTestDB(db, dialect)
+					err = c.Insert(ctx, userPermissionsTable, &permission{UserID: 1, PermID: 2, Type: "read"})
+					tt.AssertNoErr(t, err)
+
+					err = c.Upsert(ctx, userPermissionsTable, &permission{UserID: 1, PermID: 2, Type: "write"}, config)
+					tt.AssertNoErr(t, err)
+
+					result, err := getUserPermissionBySecondaryKeys(db, dialect, 1, 2)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, result.Type, "read")
+				})
+
+				t.Run("should report the number of rows written", func(t *testing.T) {
+					db, closer := newDBAdapter(t)
+					defer closer.Close()
+
+					err := createTables(ctx, db, dialect)
+					if err != nil {
+						t.Fatal("could not create test table!, reason:", err.Error())
+					}
+
+					config := UpsertConfig{DoNothingOnMatch: true}
+					if len(variation.config) > 0 {
+						config.UseMerge = variation.config[0].UseMerge
+					}
+
+					c := newTestDB(db, dialect)
+					n, err := c.UpsertWithRowsAffected(ctx, userPermissionsTable, &permission{UserID: 1, PermID: 2, Type: "read"}, variation.config...)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, n, int64(1))
+
+					n, err = c.UpsertWithRowsAffected(ctx, userPermissionsTable, &permission{UserID: 1, PermID: 2, Type: "write"}, variation.config...)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, n, int64(1))
+
+					n, err = c.UpsertWithRowsAffected(ctx, userPermissionsTable, &permission{UserID: 1, PermID: 2, Type: "admin"}, config)
+					tt.AssertNoErr(t, err)
+					tt.AssertEqual(t, n, int64(0))
+				})
+			})
+		}
+	})
+
+	t.Run("UpsertMany", func(t *testing.T) {
+		t.Run("should insert new records and update existing ones", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			c := newTestDB(db, dialect)
+			err = c.Insert(ctx, userPermissionsTable, &permission{UserID: 1, PermID: 1, Type: "read"})
+			tt.AssertNoErr(t, err)
+
+			err = c.UpsertMany(ctx, userPermissionsTable, []permission{
+				{UserID: 1, PermID: 1, Type: "write"},
+				{UserID: 1, PermID: 2, Type: "read"},
+			})
+			tt.AssertNoErr(t, err)
+
+			userPerms, err := getUserPermissionsByUser(db, dialect, 1)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(userPerms), 2)
+
+			result, err := getUserPermissionBySecondaryKeys(db, dialect, 1, 1)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Type, "write")
+
+			result, err = getUserPermissionBySecondaryKeys(db, dialect, 1, 2)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Type, "read")
+		})
+
+		t.Run("should not overwrite the columns of nil attributes", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			type nullablePermission struct {
+				UserID int     `ksql:"user_id"`
+				PermID int     `ksql:"perm_id"`
+				Type   *string `ksql:"type"`
+			}
+
+			c := newTestDB(db, dialect)
+			err = c.Insert(ctx, userPermissionsTable, &permission{UserID: 1, PermID: 1, Type: "read"})
+			tt.AssertNoErr(t, err)
+
+			write := "write"
+			err = c.UpsertMany(ctx, userPermissionsTable, []nullablePermission{
+				{UserID: 1, PermID: 1},
+				{UserID: 1, PermID: 2, Type: &write},
+			})
+			tt.AssertNoErr(t, err)
+
+			result, err := getUserPermissionBySecondaryKeys(db, dialect, 1, 1)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Type, "read")
+
+			result, err = getUserPermissionBySecondaryKeys(db, dialect, 1, 2)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Type, "write")
+		})
+
+		t.Run("should write all batches", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			var perms []permission
+			for i := 1; i <= 25; i++ {
+				perms = append(perms, permission{UserID: 1, PermID: i, Type: "read"})
+			}
+
+			c := newTestDB(db, dialect)
+			err = c.UpsertMany(ctx, userPermissionsTable, &perms, UpsertConfig{BatchSize: 10})
+			tt.AssertNoErr(t, err)
+
+			userPerms, err := getUserPermissionsByUser(db, dialect, 1)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(userPerms), 25)
+		})
+	})
+}
+
+// InsertIgnoreTest runs all tests for making sure the InsertIgnore function is
+// working for a given adapter and dialect.
+func InsertIgnoreTest(
+	t *testing.T,
+	dialect sqldialect.Provider,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+) {
+	ctx := context.Background()
+
+	type permission struct {
+		ID     int    `ksql:"id"`
+		UserID int    `ksql:"user_id"`
+		PermID int    `ksql:"perm_id"`
+		Type   string `ksql:"type"`
+	}
+	permissionsTable := NewTable("user_permissions", "id")
+
+	t.Run("InsertIgnore", func(t *testing.T) {
+		if dialect.DriverName() == "sqlserver" {
+			t.Skip("InsertIgnore is not supported on SQL Server")
+		}
+
+		t.Run("should insert records and retrieve their IDs", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			c := newTestDB(db, dialect)
+
+			perm := permission{UserID: 1, PermID: 2, Type: "read"}
+			created, err := c.InsertIgnore(ctx, permissionsTable, &perm)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, created, true)
+			tt.AssertNotEqual(t, perm.ID, 0)
+
+			result, err := getUserPermissionBySecondaryKeys(db, dialect, 1, 2)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.ID, perm.ID)
+			tt.AssertEqual(t, result.Type, "read")
+		})
+
+		t.Run("should skip records conflicting with existing ones", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			c := newTestDB(db, dialect)
+
+			err = c.Insert(ctx, permissionsTable, &permission{UserID: 1, PermID: 2, Type: "read"})
+			tt.AssertNoErr(t, err)
+
+			perm := permission{UserID: 1, PermID: 2, Type: "write"}
+			created, err := c.InsertIgnore(ctx, permissionsTable, &perm)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, created, false)
+			tt.AssertEqual(t, perm.ID, 0)
+
+			userPerms, err := getUserPermissionsByUser(db, dialect, 1)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(userPerms), 1)
+
+			result, err := getUserPermissionBySecondaryKeys(db, dialect, 1, 2)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Type, "read")
+		})
+
+		t.Run("should work inside transactions after a conflict", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			c := newTestDB(db, dialect)
+
+			err = c.Transaction(ctx, func(db Provider) error {
+				err := db.Insert(ctx, permissionsTable, &permission{UserID: 1, PermID: 2, Type: "read"})
+				tt.AssertNoErr(t, err)
+
+				created, err := db.(DB).InsertIgnore(ctx, permissionsTable, &permission{UserID: 1, PermID: 2, Type: "write"})
+				tt.AssertNoErr(t, err)
+				tt.AssertEqual(t, created, false)
+
+				return db.Insert(ctx, permissionsTable, &permission{UserID: 1, PermID: 3, Type: "read"})
+			})
+			tt.AssertNoErr(t, err)
+
+			userPerms, err := getUserPermissionsByUser(db, dialect, 1)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(userPerms), 2)
+		})
+	})
+}
+
+// QueryOneOrInsertTest runs all tests for making sure the QueryOneOrInsert
+// function is working for a given adapter and dialect.
+func QueryOneOrInsertTest(
+	t *testing.T,
+	dialect sqldialect.Provider,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+) {
+	ctx := context.Background()
+
+	type permission struct {
+		ID     int    `ksql:"id"`
+		UserID int    `ksql:"user_id"`
+		PermID int    `ksql:"perm_id"`
+		Type   string `ksql:"type"`
+	}
+	permissionsTable := NewTable("user_permissions", "id")
+	query := "FROM user_permissions WHERE user_id = " + dialect.Placeholder(0) + " AND perm_id = " + dialect.Placeholder(1)
+
+	t.Run("QueryOneOrInsert", func(t *testing.T) {
+		t.Run("should insert the record if it doesn't exist", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			c := newTestDB(db, dialect)
+
+			perm := permission{UserID: 1, PermID: 2, Type: "read"}
+			created, err := c.QueryOneOrInsert(ctx, permissionsTable, &perm, query, 1, 2)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, created, true)
+			tt.AssertNotEqual(t, perm.ID, 0)
+
+			result, err := getUserPermissionBySecondaryKeys(db, dialect, 1, 2)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.ID, perm.ID)
+		})
+
+		t.Run("should return the existing record without inserting", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			c := newTestDB(db, dialect)
+
+			existing := permission{UserID: 1, PermID: 2, Type: "read"}
+			err = c.Insert(ctx, permissionsTable, &existing)
+			tt.AssertNoErr(t, err)
+
+			perm := permission{UserID: 1, PermID: 2, Type: "write"}
+			created, err := c.QueryOneOrInsert(ctx, permissionsTable, &perm, query, 1, 2)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, created, false)
+			tt.AssertEqual(t, perm, existing)
+
+			userPerms, err := getUserPermissionsByUser(db, dialect, 1)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(userPerms), 1)
+		})
+	})
+}
+
+// ConcurrencyTest runs tests for making sure the adapter can be used
+// by several goroutines at the same time without races or deadlocks.
+//
+// If the io.Closer returned by newDBAdapter has a SetMaxOpenConns method,
+// e.g. a *sql.DB, the pool is limited to a single connection, so the
+// goroutines have to wait for each other, which is when deadlocks happen.
+func ConcurrencyTest(
+	t *testing.T,
+	dialect sqldialect.Provider,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+) {
+	const numWorkers = 10
+	const numIterations = 5
+
+	t.Run("Concurrency", func(t *testing.T) {
+		t.Run("should run Query, Insert and Transaction from several goroutines", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			if pool, ok := closer.(interface{ SetMaxOpenConns(n int) }); ok {
+				pool.SetMaxOpenConns(1)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			err := createTables(ctx, db, dialect)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+
+			c := newTestDB(db, dialect)
+
+			errs := make(chan error, numWorkers)
+			var wg sync.WaitGroup
+			for i := 0; i < numWorkers; i++ {
+				wg.Add(1)
+				go func(worker int) {
+					defer wg.Done()
+					for j := 0; j < numIterations; j++ {
+						err := runConcurrencyIteration(ctx, c, fmt.Sprintf("Worker%d-%d", worker, j))
+						if err != nil {
+							errs <- fmt.Errorf("worker %d failed on iteration %d: %w", worker, j, err)
+							return
+						}
+					}
+				}(i)
+			}
+
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(time.Minute):
+				t.Fatal("the goroutines did not finish in time, there might be a deadlock")
+			}
+
+			close(errs)
+			for err := range errs {
+				t.Error(err.Error())
+			}
+			if t.Failed() {
+				return
+			}
+
+			var users []user
+			err = c.Query(ctx, &users, "FROM users")
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(users), numWorkers*numIterations)
+
+			var posts []post
+			err = c.Query(ctx, &posts, "FROM posts")
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(posts), numWorkers*numIterations)
+		})
+	})
+}
+
+// runConcurrencyIteration runs the operations
+// each goroutine of the ConcurrencyTest repeats.
+func runConcurrencyIteration(ctx context.Context, c DB, name string) error {
+	u := user{Name: name}
+	err := c.Insert(ctx, usersTable, &u)
+	if err != nil {
+		return fmt.Errorf("error inserting user: %w", err)
+	}
+
+	var users []user
+	err = c.Query(ctx, &users, "FROM users WHERE name = "+c.dialect.Placeholder(0), name)
+	if err != nil {
+		return fmt.Errorf("error querying users: %w", err)
+	}
+	if len(users) != 1 || users[0].ID != u.ID {
+		return fmt.Errorf("expected to find only the user with id %d but got: %+v", u.ID, users)
+	}
+
+	return c.Transaction(ctx, func(db Provider) error {
+		err := db.Insert(ctx, postsTable, &post{UserID: u.ID, Title: name})
+		if err != nil {
+			return fmt.Errorf("error inserting post inside transaction: %w", err)
+		}
+
+		var p post
+		err = db.QueryOne(ctx, &p, "FROM posts WHERE user_id = "+c.dialect.Placeholder(0), u.ID)
+		if err != nil {
+			return fmt.Errorf("error querying post inside transaction: %w", err)
+		}
+		if p.Title != name {
+			return fmt.Errorf("expected post title to be %q but got %q", name, p.Title)
+		}
+
+		return nil
+	})
+}
+
+// BinaryDataTest runs tests for making sure attributes of type []byte
+// are written to and read from binary columns without any conversions,
+// e.g. BLOB on SQLite and MySQL, bytea on Postgres and VARBINARY on SQL Server.
+func BinaryDataTest(
+	t *testing.T,
+	dialect sqldialect.Provider,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+) {
+	ctx := context.Background()
+
+	type file struct {
+		ID      int    `ksql:"id"`
+		Name    string `ksql:"name"`
+		Content []byte `ksql:"content"`
+	}
+	filesTable := NewTable("files")
+
+	// Invalid UTF-8 sequences and zero bytes make sure
+	// the content is not being converted to strings:
+	content := []byte{0x00, 0xff, 0xfe, 'k', 's', 'q', 'l', 0x00, 0x80}
+
+	t.Run("BinaryData", func(t *testing.T) {
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		err := createTables(ctx, db, dialect)
+		if err != nil {
+			t.Fatal("could not create test table!, reason:", err.Error())
+		}
+
+		t.Run("should insert and query []byte attributes", func(t *testing.T) {
+			c := newTestDB(db, dialect)
+
+			f := file{Name: "file1", Content: content}
+			err := c.Insert(ctx, filesTable, &f)
+			tt.AssertNoErr(t, err)
+			tt.AssertNotEqual(t, f.ID, 0)
+
+			var result file
+			err = c.QueryOne(ctx, &result, "FROM files WHERE id = "+c.dialect.Placeholder(0), f.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result, file{ID: f.ID, Name: "file1", Content: content})
+
+			var results []file
+			err = c.Query(ctx, &results, "FROM files WHERE id = "+c.dialect.Placeholder(0), f.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, results, []file{{ID: f.ID, Name: "file1", Content: content}})
+		})
+
+		t.Run("should patch []byte attributes", func(t *testing.T) {
+			c := newTestDB(db, dialect)
+
+			f := file{Name: "file2", Content: []byte("oldContent")}
+			err := c.Insert(ctx, filesTable, &f)
+			tt.AssertNoErr(t, err)
+
+			err = c.Patch(ctx, filesTable, &file{ID: f.ID, Name: "file2", Content: content})
+			tt.AssertNoErr(t, err)
+
+			var result file
+			err = c.QueryOne(ctx, &result, "FROM files WHERE id = "+c.dialect.Placeholder(0), f.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Content, content)
+		})
+
+		t.Run("should save nil []byte attributes as NULL", func(t *testing.T) {
+			c := newTestDB(db, dialect)
+
+			f := file{Name: "file3", Content: nil}
+			err := c.Insert(ctx, filesTable, &f)
+			tt.AssertNoErr(t, err)
+
+			result := file{Content: []byte("notNil")}
+			err = c.QueryOne(ctx, &result, "FROM files WHERE content IS NULL AND id = "+c.dialect.Placeholder(0), f.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Content == nil, true)
+		})
+	})
+}
+
+// SQLStateTest runs tests for making sure the errors of
+// the adapter are translated to the normalized SQLSTATE codes.
+func SQLStateTest(
+	t *testing.T,
+	dialect sqldialect.Provider,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+) {
+	ctx := context.Background()
+
+	t.Run("SQLState", func(t *testing.T) {
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		err := createTables(ctx, db, dialect)
+		if err != nil {
+			t.Fatal("could not create test table!, reason:", err.Error())
+		}
+
+		t.Run("should report unique violations", func(t *testing.T) {
+			c := newTestDB(db, dialect)
+
+			table := NewTable("user_permissions")
+			err := c.Insert(ctx, table, &userPermission{UserID: 1, PermID: 42, Type: "read"})
+			tt.AssertNoErr(t, err)
+
+			err = c.Insert(ctx, table, &userPermission{UserID: 1, PermID: 42, Type: "write"})
+			tt.AssertNotEqual(t, err, nil)
+
+			state, ok := SQLState(err)
+			tt.AssertEqual(t, ok, true)
+			tt.AssertEqual(t, state, SQLStateUniqueViolation)
+		})
+
+		t.Run("should return false for errors not caused by the database", func(t *testing.T) {
+			_, ok := SQLState(ErrRecordNotFound)
+			tt.AssertEqual(t, ok, false)
+		})
+	})
+}
+
+// DeleteCascadeCheckTest runs tests for making sure the foreign
+// keys referencing a table are read correctly from the database.
+func DeleteCascadeCheckTest(
+	t *testing.T,
+	dialect sqldialect.Provider,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+) {
+	ctx := context.Background()
+
+	t.Run("DeleteCascadeCheck", func(t *testing.T) {
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		// The tables are dropped in the order the foreign keys allow:
+		for _, table := range []string{"fk_books", "fk_reviews", "fk_authors"} {
+			db.ExecContext(ctx, `DROP TABLE `+table)
+		}
+
+		for _, query := range []string{
+			`CREATE TABLE fk_authors (id INT PRIMARY KEY)`,
+			`CREATE TABLE fk_books (
+				id INT PRIMARY KEY,
+				author_id INT,
+				FOREIGN KEY (author_id) REFERENCES fk_authors (id)
+			)`,
+			`CREATE TABLE fk_reviews (
+				id INT PRIMARY KEY,
+				author_id INT,
+				FOREIGN KEY (author_id) REFERENCES fk_authors (id) ON DELETE CASCADE
+			)`,
+		} {
+			_, err := db.ExecContext(ctx, query)
+			if err != nil {
+				t.Fatal("could not create test table!, reason:", err.Error())
+			}
+		}
+
+		c := newTestDB(db, dialect)
+		for _, query := range []string{
+			`INSERT INTO fk_authors (id) VALUES (1), (2)`,
+			`INSERT INTO fk_books (id, author_id) VALUES (1, 1), (2, 1)`,
+			`INSERT INTO fk_reviews (id, author_id) VALUES (1, 1), (2, 2)`,
+		} {
+			_, err := c.Exec(ctx, query)
+			tt.AssertNoErr(t, err)
+		}
+
+		authorsTable := NewTable("fk_authors")
+
+		t.Run("should report the tables blocking the delete", func(t *testing.T) {
+			blockers, err := c.DeleteCascadeCheck(ctx, authorsTable, 1)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(blockers), 1)
+			tt.AssertEqual(t, blockers[0].Table, "fk_books")
+			tt.AssertEqual(t, blockers[0].Columns, []string{"author_id"})
+			tt.AssertEqual(t, blockers[0].NumRows, 2)
+		})
+
+		t.Run("should ignore foreign keys with ON DELETE CASCADE", func(t *testing.T) {
+			blockers, err := c.DeleteCascadeCheck(ctx, authorsTable, 2)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, len(blockers), 0)
+		})
+	})
+}
+
+// DecimalTest runs tests for making sure the `decimal` modifier reads
+// and writes NUMERIC columns without losing precision, which is
+// important for columns storing monetary values.
+func DecimalTest(
+	t *testing.T,
+	dialect sqldialect.Provider,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+) {
+	ctx := context.Background()
+
+	type payment struct {
+		ID       int    `ksql:"id"`
+		Amount   string `ksql:"amount,decimal"`
+		Currency string `ksql:"currency"`
+	}
+	paymentsTable := NewTable("payments")
+
+	// SQLite has no exact numeric type, so NUMERIC
+	// columns are stored as floating point numbers:
+	isExact := dialect.DriverName() != "sqlite3"
+
+	assertSameDecimal := func(t *testing.T, got string, expected string) {
+		gotRat, ok := new(big.Rat).SetString(got)
+		if !ok {
+			t.Fatalf("expected a decimal number but got: '%s'", got)
+		}
+		expectedRat, _ := new(big.Rat).SetString(expected)
+		if gotRat.Cmp(expectedRat) != 0 {
+			t.Fatalf("expected decimal %s but got: %s", expected, got)
+		}
+	}
+
+	t.Run("Decimal", func(t *testing.T) {
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		err := createTables(ctx, db, dialect)
+		if err != nil {
+			t.Fatal("could not create test table!, reason:", err.Error())
+		}
+
+		t.Run("should insert and query decimal attributes", func(t *testing.T) {
+			c := newTestDB(db, dialect)
+
+			p := payment{Amount: "1234.56", Currency: "USD"}
+			err := c.Insert(ctx, paymentsTable, &p)
+			tt.AssertNoErr(t, err)
+			tt.AssertNotEqual(t, p.ID, 0)
+
+			var result payment
+			err = c.QueryOne(ctx, &result, "FROM payments WHERE id = "+c.dialect.Placeholder(0), p.ID)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, result.Currency, "USD")
+			assertSameDecimal(t, result.Amount, "1234.56")
+		})
+
+		t.Run("should not lose precision on large amounts", func(t *testing.T) {
+			if !isExact {
+				t.Skip("the database has no exact numeric type")
+			}
+
+			c := newTestDB(db, dialect)
+
+			p := payment{Amount: "12345678901234.5678", Currency: "BRL"}
+			err := c.Insert(ctx, paymentsTable, &p)
+			tt.AssertNoErr(t, err)
+
+			err = c.Patch(ctx, paymentsTable, &payment{ID: p.ID, Amount: "98765432109876.5432", Currency: "BRL"})
+			tt.AssertNoErr(t, err)
+
+			var result payment
+			err = c.QueryOne(ctx, &result, "FROM payments WHERE id = "+c.dialect.Placeholder(0), p.ID)
+			tt.AssertNoErr(t, err)
+			assertSameDecimal(t, result.Amount, "98765432109876.5432")
+		})
+
+		t.Run("should not add rounding errors on aggregations", func(t *testing.T) {
+			if !isExact {
+				t.Skip("the database has no exact numeric type")
+			}
+
+			c := newTestDB(db, dialect)
+
+			for _, amount := range []string{"0.10", "0.20"} {
+				err := c.Insert(ctx, paymentsTable, &payment{Amount: amount, Currency: "EUR"})
+				tt.AssertNoErr(t, err)
+			}
+
+			var total struct {
+				Total string `ksql:"total,decimal"`
+			}
+			err := c.QueryOne(ctx, &total, "SELECT SUM(amount) AS total FROM payments WHERE currency = "+c.dialect.Placeholder(0), "EUR")
+			tt.AssertNoErr(t, err)
+			assertSameDecimal(t, total.Total, "0.3")
+		})
+	})
+}
+
+// ServerVersionTest runs all tests for making sure the ServerVersion function is
+// working for a given adapter and dialect.
+func ServerVersionTest(
+	t *testing.T,
+	dialect sqldialect.Provider,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+) {
+	ctx := context.Background()
+
+	t.Run("ServerVersion", func(t *testing.T) {
+		t.Run("should return the server version", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, dialect)
+			version, err := c.ServerVersion(ctx)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, strings.TrimSpace(version) != "", true)
+			tt.AssertEqual(t, strings.ContainsAny(version, "0123456789"), true)
+		})
+
+		t.Run("should return the server version inside transactions", func(t *testing.T) {
+			db, closer := newDBAdapter(t)
+			defer closer.Close()
+
+			c := newTestDB(db, dialect)
+			var version string
+			err := c.Transaction(ctx, func(db Provider) (err error) {
+				version, err = db.(DB).ServerVersion(ctx)
+				return err
+			})
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, strings.TrimSpace(version) != "", true)
+		})
+	})
+}
+
+// PoolStatsTest runs tests for making sure the adapters implementing
+// the StatsProvider interface report the stats of their connection pool.
+func PoolStatsTest(
+	t *testing.T,
+	dialect sqldialect.Provider,
+	connStr string,
+	newDBAdapter func(t *testing.T) (DBAdapter, io.Closer),
+) {
+	ctx := context.Background()
+
+	t.Run("PoolStats", func(t *testing.T) {
+		db, closer := newDBAdapter(t)
+		defer closer.Close()
+
+		if _, ok := db.(StatsProvider); !ok {
+			t.Skip("the adapter doesn't implement the StatsProvider interface")
+		}
+
+		t.Run("should report the open connections", func(t *testing.T) {
+			c := newTestDB(db, dialect)
+
+			var openConns int
+			err := c.Transaction(ctx, func(db Provider) error {
+				stats, err := db.(DB).PoolStats()
+				openConns = stats.OpenConns
+				tt.AssertEqual(t, stats.InUse >= 1, true)
+				return err
+			})
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, openConns >= 1, true)
+
+			stats, err := c.PoolStats()
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, stats.WaitCount >= 0, true)
+			tt.AssertEqual(t, stats.OpenConns >= stats.InUse+stats.Idle, true)
+		})
+	})
+}
+
+func createTables(ctx context.Context, db DBAdapter, dialect sqldialect.Provider) (err error) {
+	db.ExecContext(ctx, `DROP TABLE users`)
+
+	switch dialect.DriverName() {
+	case "sqlite3":
+		_, err = db.ExecContext(ctx, `CREATE TABLE users (
+		  id INTEGER PRIMARY KEY,
+			age INTEGER,
+			name TEXT,
+			address BLOB,
+			created_at DATETIME,
+			updated_at DATETIME,
+			nullable_field TEXT DEFAULT "not_null"
+		)`)
+	case "postgres":
+		_, err = db.ExecContext(ctx, `CREATE TABLE users (
+		  id serial PRIMARY KEY,
+			age INT,
+			name VARCHAR(50),
+			address jsonb,
+			created_at TIMESTAMP,
+			updated_at TIMESTAMP,
+			nullable_field VARCHAR(50) DEFAULT 'not_null'
+		)`)
+	case "mysql":
+		_, err = db.ExecContext(ctx, `CREATE TABLE users (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			age INT,
+			name VARCHAR(50),
+			address JSON,
+			created_at DATETIME,
+			updated_at DATETIME,
+			nullable_field VARCHAR(50) DEFAULT "not_null"
+		)`)
+	case "sqlserver":
+		_, err = db.ExecContext(ctx, `CREATE TABLE users (
+			id INT IDENTITY(1,1) PRIMARY KEY,
+			age INT,
+			name VARCHAR(50),
+			address NVARCHAR(4000),
+			created_at DATETIME,
+			updated_at DATETIME,
+			nullable_field VARCHAR(50) DEFAULT 'not_null'
+		)`)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create new users table: %s", err.Error())
+	}
+
+	db.ExecContext(ctx, `DROP TABLE posts`)
+
+	switch dialect.DriverName() {
+	case "sqlite3":
+		_, err = db.ExecContext(ctx, `CREATE TABLE posts (
+		  id INTEGER PRIMARY KEY,
+		  user_id INTEGER,
+			title TEXT
+		)`)
+	case "postgres":
+		_, err = db.ExecContext(ctx, `CREATE TABLE posts (
+		  id serial PRIMARY KEY,
+			user_id INT,
+			title VARCHAR(50)
+		)`)
+	case "mysql":
+		_, err = db.ExecContext(ctx, `CREATE TABLE posts (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			user_id INT,
+			title VARCHAR(50)
+		)`)
+	case "sqlserver":
+		_, err = db.ExecContext(ctx, `CREATE TABLE posts (
+			id INT IDENTITY(1,1) PRIMARY KEY,
+			user_id INT,
+			title VARCHAR(50)
+		)`)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create new posts table: %s", err.Error())
+	}
+
+	db.ExecContext(ctx, `DROP TABLE user_permissions`)
+
+	switch dialect.DriverName() {
+	case "sqlite3":
+		_, err = db.ExecContext(ctx, `CREATE TABLE user_permissions (
+			id INTEGER PRIMARY KEY,
+			user_id INTEGER,
+			perm_id INTEGER,
+			type TEXT,
+			UNIQUE (user_id, perm_id)
+		)`)
+	case "postgres":
+		_, err = db.ExecContext(ctx, `CREATE TABLE user_permissions (
+			id serial PRIMARY KEY,
+			user_id INT,
+			perm_id INT,
+			type VARCHAR(50),
+			UNIQUE (user_id, perm_id)
+		)`)
+	case "mysql":
+		_, err = db.ExecContext(ctx, `CREATE TABLE user_permissions (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			user_id INT,
+			perm_id INT,
+			type VARCHAR(50),
+			UNIQUE KEY (user_id, perm_id)
+		)`)
+	case "sqlserver":
+		_, err = db.ExecContext(ctx, `CREATE TABLE user_permissions (
 			id INT IDENTITY(1,1) PRIMARY KEY,
 			user_id INT,
 			perm_id INT,
@@ -3603,6 +5514,83 @@ func createTables(ctx context.Context, db DBAdapter, dialect sqldialect.Provider
 		return fmt.Errorf("failed to create new user_permissions table: %s", err.Error())
 	}
 
+	db.ExecContext(ctx, `DROP TABLE team_members`)
+
+	// This join table has no columns besides its composite key,
+	// and the same definition works for all the dialects:
+	_, err = db.ExecContext(ctx, `CREATE TABLE team_members (
+		user_id INT NOT NULL,
+		team_id INT NOT NULL,
+		PRIMARY KEY (user_id, team_id)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create new team_members table: %s", err.Error())
+	}
+
+	db.ExecContext(ctx, `DROP TABLE files`)
+
+	switch dialect.DriverName() {
+	case "sqlite3":
+		_, err = db.ExecContext(ctx, `CREATE TABLE files (
+			id INTEGER PRIMARY KEY,
+			name TEXT,
+			content BLOB
+		)`)
+	case "postgres":
+		_, err = db.ExecContext(ctx, `CREATE TABLE files (
+			id serial PRIMARY KEY,
+			name VARCHAR(50),
+			content bytea
+		)`)
+	case "mysql":
+		_, err = db.ExecContext(ctx, `CREATE TABLE files (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(50),
+			content LONGBLOB
+		)`)
+	case "sqlserver":
+		_, err = db.ExecContext(ctx, `CREATE TABLE files (
+			id INT IDENTITY(1,1) PRIMARY KEY,
+			name VARCHAR(50),
+			content VARBINARY(MAX)
+		)`)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create new files table: %s", err.Error())
+	}
+
+	db.ExecContext(ctx, `DROP TABLE payments`)
+
+	switch dialect.DriverName() {
+	case "sqlite3":
+		_, err = db.ExecContext(ctx, `CREATE TABLE payments (
+			id INTEGER PRIMARY KEY,
+			amount NUMERIC(18,4),
+			currency TEXT
+		)`)
+	case "postgres":
+		_, err = db.ExecContext(ctx, `CREATE TABLE payments (
+			id serial PRIMARY KEY,
+			amount NUMERIC(18,4),
+			currency CHAR(3)
+		)`)
+	case "mysql":
+		_, err = db.ExecContext(ctx, `CREATE TABLE payments (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			amount DECIMAL(18,4),
+			currency CHAR(3)
+		)`)
+	case "sqlserver":
+		_, err = db.ExecContext(ctx, `CREATE TABLE payments (
+			id INT IDENTITY(1,1) PRIMARY KEY,
+			amount DECIMAL(18,4),
+			currency CHAR(3)
+		)`)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create new payments table: %s", err.Error())
+	}
+
 	return nil
 }
 
@@ -3743,6 +5731,20 @@ func getUserPermissionsByUser(db DBAdapter, dialect sqldialect.Provider, userID
 	return results, nil
 }
 
+func getMajorVersion(t *testing.T, c DB) int {
+	version, err := c.ServerVersion(context.Background())
+	tt.AssertNoErr(t, err)
+
+	match := regexp.MustCompile(`(\d+)\.`).FindStringSubmatch(version)
+	if match == nil {
+		t.Fatalf("could not parse server version: '%s'", version)
+	}
+
+	major, err := strconv.Atoi(match[1])
+	tt.AssertNoErr(t, err)
+	return major
+}
+
 func mustBuildSelectQuery(t *testing.T,
 	dialect sqldialect.Provider,
 	record interface{},
@@ -3756,7 +5758,7 @@ func mustBuildSelectQuery(t *testing.T,
 	structInfo, err := structs.GetTagInfo(structType)
 	tt.AssertNoErr(t, err)
 
-	selectPrefix, err := buildSelectQuery(dialect, structType, structInfo, selectQueryCache[dialect.DriverName()])
+	selectPrefix, err := buildSelectQuery(dialect, structType, structInfo, getSelectQueryCache(dialect))
 	tt.AssertNoErr(t, err)
 
 	return selectPrefix + query
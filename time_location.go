@@ -0,0 +1,30 @@
+package ksql
+
+import (
+	"time"
+)
+
+// normalizeTimeLocation converts all the time.Time values
+// scanned into the input scanArgs to the input location.
+//
+// Values scanned with modifiers are ignored since
+// it is the modifier's job to decide how to parse them.
+func normalizeTimeLocation(loc *time.Location, scanArgs []interface{}) {
+	if loc == nil {
+		return
+	}
+
+	for _, arg := range scanArgs {
+		switch t := arg.(type) {
+		case *time.Time:
+			if !t.IsZero() {
+				*t = t.In(loc)
+			}
+		case **time.Time:
+			if *t != nil && !(*t).IsZero() {
+				converted := (*t).In(loc)
+				*t = &converted
+			}
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"github.com/vingarcia/ksql/tools/ksqlcheck"
+	"golang.org/x/tools/go/analysis/multichecker"
+)
+
+func main() {
+	multichecker.Main(
+		ksqlcheck.Analyzer,
+		ksqlcheck.UnparameterizedAnalyzer,
+	)
+}
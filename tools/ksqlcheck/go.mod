@@ -0,0 +1,8 @@
+module github.com/vingarcia/ksql/tools/ksqlcheck
+
+go 1.14
+
+require (
+	github.com/vingarcia/ksql v1.12.3
+	golang.org/x/tools v0.1.12
+)
@@ -0,0 +1,165 @@
+// Package ksqlcheck implements a static analyzer that checks if the
+// columns returned by the queries passed to the Query and QueryOne
+// methods of KSQL match the `ksql` tags of the destination structs.
+//
+// It can be used with `go vet`:
+//
+//	go install github.com/vingarcia/ksql/tools/ksqlcheck/cmd/ksqlcheck@latest
+//	go vet -vettool=$(which ksqlcheck) ./...
+//
+// Or as part of any other tool that supports the go/analysis framework.
+package ksqlcheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"github.com/vingarcia/ksql/internal/sqlparse"
+	"github.com/vingarcia/ksql/internal/structs"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const ksqlPkgPath = "github.com/vingarcia/ksql"
+
+// Analyzer reports queries returning columns that don't match
+// any of the `ksql` tags of the destination struct, which would
+// otherwise be silently ignored by KSQL.
+//
+// Only queries that can be known statically are checked, i.e. string
+// literals or constants, and queries starting with `FROM` or using the
+// `:ksql_columns` marker are skipped, since KSQL builds their columns.
+//
+// Note that the column names are compared case-insensitively,
+// so if you are using the Config.NormalizeColumnName option
+// this analyzer might report false positives.
+var Analyzer = &analysis.Analyzer{
+	Name:     "ksqlcheck",
+	Doc:      "check that the columns of ksql queries match the ksql tags of the destination structs",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{
+		(*ast.CallExpr)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		if !isKSQLQueryCall(pass, call) || len(call.Args) < 3 {
+			return
+		}
+
+		queryArg := call.Args[2]
+		tv := pass.TypesInfo.Types[queryArg]
+		if tv.Value == nil || tv.Value.Kind() != constant.String {
+			return
+		}
+		query := constant.StringVal(tv.Value)
+
+		tokens := strings.Fields(query)
+		if len(tokens) == 0 || strings.ToUpper(tokens[0]) == "FROM" || strings.Contains(query, ":ksql_columns") {
+			return
+		}
+
+		structName, tags, ok := getDestinationTags(pass.TypesInfo.TypeOf(call.Args[1]))
+		if !ok {
+			return
+		}
+
+		columns, ok := sqlparse.SelectColumns(query)
+		if !ok {
+			return
+		}
+
+		for _, column := range columns {
+			if column.Name == "" || tags[strings.ToLower(column.Name)] {
+				continue
+			}
+
+			pass.Reportf(
+				queryArg.Pos(),
+				"the column `%s` returned by the query doesn't match any ksql tag of %s",
+				column.Name, structName,
+			)
+		}
+	})
+
+	return nil, nil
+}
+
+// isKSQLQueryCall checks if the call is to the Query or QueryOne
+// methods of the ksql.DB struct or of the ksql.Provider interface.
+func isKSQLQueryCall(pass *analysis.Pass, call *ast.CallExpr) bool {
+	name := getKSQLMethodName(pass, call)
+	return name == "Query" || name == "QueryOne"
+}
+
+// getKSQLMethodName returns the name of the method called if it belongs
+// to one of the types of the ksql package, or an empty string otherwise.
+func getKSQLMethodName(pass *analysis.Pass, call *ast.CallExpr) string {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+
+	selection, ok := pass.TypesInfo.Selections[sel]
+	if !ok || selection.Kind() != types.MethodVal {
+		return ""
+	}
+
+	method := selection.Obj()
+	if method.Pkg() == nil || method.Pkg().Path() != ksqlPkgPath {
+		return ""
+	}
+
+	return method.Name()
+}
+
+// getDestinationTags returns the lowercased column names of the `ksql` tags
+// of the struct referenced by the records argument of Query or QueryOne.
+//
+// Nested structs, i.e. the ones using the `tablename` tag, are not checked
+// since KSQL doesn't allow writing the SELECT part of their queries.
+func getDestinationTags(t types.Type) (structName string, tags map[string]bool, ok bool) {
+	for t != nil {
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		} else if slice, ok := t.(*types.Slice); ok {
+			t = slice.Elem()
+		} else {
+			break
+		}
+	}
+	if t == nil {
+		return "", nil, false
+	}
+
+	structType, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return "", nil, false
+	}
+
+	tags = map[string]bool{}
+	for i := 0; i < structType.NumFields(); i++ {
+		tag := reflect.StructTag(structType.Tag(i)).Get("ksql")
+		if tag == "" {
+			continue
+		}
+
+		columnName, _ := structs.ParseTag(tag)
+		tags[strings.ToLower(columnName)] = true
+	}
+
+	if len(tags) == 0 {
+		return "", nil, false
+	}
+
+	return types.TypeString(t, func(p *types.Package) string { return p.Name() }), tags, true
+}
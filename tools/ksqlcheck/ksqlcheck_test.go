@@ -0,0 +1,15 @@
+package ksqlcheck
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "example")
+}
+
+func TestUnparameterizedAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), UnparameterizedAnalyzer, "unparameterized")
+}
@@ -0,0 +1,42 @@
+package example
+
+import (
+	"context"
+
+	"github.com/vingarcia/ksql"
+)
+
+type User struct {
+	ID   int    `ksql:"id"`
+	Name string `ksql:"name"`
+	Age  int    `ksql:"age,skipUpdates"`
+}
+
+type UserWithPosts struct {
+	User User `tablename:"u"`
+}
+
+const selectUsers = "SELECT id, nmae FROM users"
+
+func queries(ctx context.Context, db ksql.DB, provider ksql.Provider, dynamicQuery string) {
+	var users []User
+	var userPtrs []*User
+	var user User
+
+	db.Query(ctx, &users, "SELECT id, name, age FROM users")
+	db.Query(ctx, &users, "SELECT u.id, u.name AS Name, count(*) FROM users u")
+	db.Query(ctx, &users, "SELECT id, user_name FROM users")        // want "the column `user_name` returned by the query doesn't match any ksql tag of example.User"
+	db.Query(ctx, &userPtrs, "SELECT id, p.age AS agee FROM users") // want "the column `agee` returned by the query doesn't match any ksql tag of example.User"
+	db.Query(ctx, &users, selectUsers)                              // want "the column `nmae` returned by the query doesn't match any ksql tag of example.User"
+	db.QueryOne(ctx, &user, "SELECT id, nam FROM users")            // want "the column `nam` returned by the query doesn't match any ksql tag of example.User"
+	provider.QueryOne(ctx, &user, "SELECT ide FROM users")          // want "the column `ide` returned by the query doesn't match any ksql tag of example.User"
+
+	// These can't be checked statically:
+	db.Query(ctx, &users, dynamicQuery)
+	db.Query(ctx, &users, "SELECT * FROM users")
+	db.Query(ctx, &users, "FROM users WHERE name = $1")
+	db.Query(ctx, &users, "SELECT :ksql_columns, 1 AS other FROM users")
+
+	var nested []UserWithPosts
+	db.Query(ctx, &nested, "SELECT u.foo FROM users u")
+}
@@ -0,0 +1,33 @@
+// Package ksql is a stub of the real ksql package
+// containing only what is necessary for testing the analyzer.
+package ksql
+
+import "context"
+
+type DB struct{}
+
+func (DB) Query(ctx context.Context, records interface{}, query string, params ...interface{}) error {
+	return nil
+}
+
+func (DB) QueryOne(ctx context.Context, record interface{}, query string, params ...interface{}) error {
+	return nil
+}
+
+type Provider interface {
+	Query(ctx context.Context, records interface{}, query string, params ...interface{}) error
+	QueryOne(ctx context.Context, record interface{}, query string, params ...interface{}) error
+}
+
+func (DB) Exec(ctx context.Context, query string, params ...interface{}) (Result, error) {
+	return nil, nil
+}
+
+type Result interface{}
+
+type ChunkParser struct {
+	Query        string
+	Params       []interface{}
+	ChunkSize    int
+	ForEachChunk interface{}
+}
@@ -0,0 +1,36 @@
+package unparameterized
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vingarcia/ksql"
+)
+
+type User struct {
+	ID   int    `ksql:"id"`
+	Name string `ksql:"name"`
+}
+
+const usersTable = "users"
+
+func queries(ctx context.Context, db ksql.DB, name string, query string) {
+	var users []User
+	var user User
+
+	db.Query(ctx, &users, "FROM users WHERE name = '"+name+"'")                // want "query built with string concatenation, pass the values as params instead"
+	db.QueryOne(ctx, &user, fmt.Sprintf("FROM users WHERE name = '%s'", name)) // want "query built with fmt.Sprintf, pass the values as params instead"
+	db.Exec(ctx, ("DELETE FROM users WHERE name = " + name))                   // want "query built with string concatenation, pass the values as params instead"
+	_ = ksql.ChunkParser{
+		Query: fmt.Sprint("FROM users WHERE name = ", name), // want "query built with fmt.Sprint, pass the values as params instead"
+	}
+
+	// These are safe or can't be checked:
+	db.Query(ctx, &users, "FROM "+usersTable+" WHERE name = $1", name)
+	db.QueryOne(ctx, &user, query)
+	db.Exec(ctx, "DELETE FROM users WHERE name = $1", name)
+	_ = ksql.ChunkParser{
+		Query:  "FROM users WHERE name = $1",
+		Params: []interface{}{name},
+	}
+}
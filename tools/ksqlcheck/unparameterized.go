@@ -0,0 +1,130 @@
+package ksqlcheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// UnparameterizedAnalyzer reports queries built with string concatenation
+// or with the fmt.Sprintf family of functions, which are the most common
+// cause of SQL injection, so values should be passed as params instead.
+//
+// It checks the query argument of the Query, QueryOne and Exec methods
+// as well as the Query attribute of the ksql.ChunkParser struct, and it
+// is the static companion of the Config.ForbidUnparameterizedStrings option.
+//
+// Only the expression passed as argument is checked, so queries
+// built on a separate variable are not reported.
+var UnparameterizedAnalyzer = &analysis.Analyzer{
+	Name:     "ksqlunparameterized",
+	Doc:      "check that ksql queries are not built with string concatenation or fmt.Sprintf",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runUnparameterized,
+}
+
+var queryArgIndexes = map[string]int{
+	"Query":    2,
+	"QueryOne": 2,
+	"Exec":     1,
+}
+
+func runUnparameterized(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{
+		(*ast.CallExpr)(nil),
+		(*ast.CompositeLit)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			idx, ok := queryArgIndexes[getKSQLMethodName(pass, n)]
+			if !ok || len(n.Args) <= idx {
+				return
+			}
+			checkQueryExpr(pass, n.Args[idx])
+
+		case *ast.CompositeLit:
+			if !isChunkParser(pass.TypesInfo.TypeOf(n)) {
+				return
+			}
+			for _, elt := range n.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+				if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Query" {
+					checkQueryExpr(pass, kv.Value)
+				}
+			}
+		}
+	})
+
+	return nil, nil
+}
+
+func checkQueryExpr(pass *analysis.Pass, expr ast.Expr) {
+	if pass.TypesInfo.Types[expr].Value != nil {
+		// Constant expressions can't contain user input
+		return
+	}
+
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		expr = paren.X
+	}
+
+	switch expr := expr.(type) {
+	case *ast.BinaryExpr:
+		if expr.Op == token.ADD {
+			pass.Reportf(expr.Pos(), "query built with string concatenation, pass the values as params instead")
+		}
+
+	case *ast.CallExpr:
+		if name := getFmtSprintName(pass, expr); name != "" {
+			pass.Reportf(expr.Pos(), "query built with fmt.%s, pass the values as params instead", name)
+		}
+	}
+}
+
+// getFmtSprintName returns the name of the function if the call is
+// to fmt.Sprintf, fmt.Sprint or fmt.Sprintln, or an empty string otherwise.
+func getFmtSprintName(pass *analysis.Pass, call *ast.CallExpr) string {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+
+	fn, ok := pass.TypesInfo.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "fmt" {
+		return ""
+	}
+
+	switch fn.Name() {
+	case "Sprintf", "Sprint", "Sprintln":
+		return fn.Name()
+	}
+	return ""
+}
+
+func isChunkParser(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == ksqlPkgPath && obj.Name() == "ChunkParser"
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/vingarcia/ksql"
+	"github.com/vingarcia/ksql/adapters/kmysql"
+	"github.com/vingarcia/ksql/adapters/kpgx"
+	"github.com/vingarcia/ksql/adapters/ksqlserver"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+	"github.com/vingarcia/ksql/sqldialect"
+	"github.com/vingarcia/ksql/tools/ksqlgen"
+)
+
+func main() {
+	dialectName := flag.String("dialect", "", "the database dialect: postgres, mysql, sqlserver or sqlite3")
+	connStr := flag.String("conn", "", "the connection string for the database")
+	pkgName := flag.String("pkg", "models", "the package name of the generated file")
+	tablesFlag := flag.String("tables", "", "comma separated list of tables to generate, defaults to all tables")
+	outPath := flag.String("out", "", "the output file, defaults to stdout")
+	flag.Parse()
+
+	if *dialectName == "" || *connStr == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+
+	db, err := connect(ctx, *dialectName, *connStr)
+	if err != nil {
+		log.Fatalf("unable to connect to the database: %s", err)
+	}
+	defer db.Close()
+
+	var tableNames []string
+	if *tablesFlag != "" {
+		for _, name := range strings.Split(*tablesFlag, ",") {
+			tableNames = append(tableNames, strings.TrimSpace(name))
+		}
+	}
+
+	tables, err := ksqlgen.Introspect(ctx, db, sqldialect.SupportedDialects[*dialectName], tableNames...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = ksqlgen.Generate(&out, *pkgName, tables)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *outPath == "" {
+		os.Stdout.Write(out.Bytes())
+		return
+	}
+
+	err = ioutil.WriteFile(*outPath, out.Bytes(), 0644)
+	if err != nil {
+		log.Fatalf("unable to write output file '%s': %s", *outPath, err)
+	}
+}
+
+func connect(ctx context.Context, dialectName string, connStr string) (ksql.DB, error) {
+	switch dialectName {
+	case "postgres":
+		return kpgx.New(ctx, connStr, ksql.Config{})
+	case "mysql":
+		return kmysql.New(ctx, connStr, ksql.Config{})
+	case "sqlserver":
+		return ksqlserver.New(ctx, connStr, ksql.Config{})
+	case "sqlite3":
+		return ksqlite.New(ctx, connStr, ksql.Config{})
+	}
+
+	return ksql.DB{}, fmt.Errorf("unsupported dialect: '%s'", dialectName)
+}
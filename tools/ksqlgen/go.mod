@@ -0,0 +1,11 @@
+module github.com/vingarcia/ksql/tools/ksqlgen
+
+go 1.14
+
+require (
+	github.com/vingarcia/ksql v1.12.3
+	github.com/vingarcia/ksql/adapters/kmysql v1.12.3
+	github.com/vingarcia/ksql/adapters/kpgx v1.12.3
+	github.com/vingarcia/ksql/adapters/ksqlserver v1.12.3
+	github.com/vingarcia/ksql/adapters/modernc-ksqlite v1.12.3
+)
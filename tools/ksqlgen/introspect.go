@@ -0,0 +1,213 @@
+package ksqlgen
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/vingarcia/ksql"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// TableSchema describes a database table as read by Introspect
+type TableSchema struct {
+	Name    string
+	Columns []Column
+
+	// IDColumns lists the columns of the primary key in the order they
+	// appear on the key, so composite keys have more than one item.
+	IDColumns []string
+}
+
+// Column describes a single column of a TableSchema
+type Column struct {
+	Name string
+
+	// Type is the type reported by the database, e.g. "varchar" or "bigint"
+	Type     string
+	Nullable bool
+}
+
+// Introspect reads the schema of the tables from the database.
+//
+// If no table names are informed all the tables of the current
+// schema are returned, otherwise only the informed ones are, and
+// an error is returned if any of them is missing.
+//
+// The results are sorted by table name and the columns
+// are kept in the order they were declared on the database.
+func Introspect(
+	ctx context.Context,
+	db ksql.Provider,
+	dialect sqldialect.Provider,
+	tableNames ...string,
+) ([]TableSchema, error) {
+	var tables []TableSchema
+	var err error
+	switch dialect.DriverName() {
+	case "postgres":
+		tables, err = introspectInformationSchema(ctx, db, "current_schema()")
+	case "mysql":
+		tables, err = introspectInformationSchema(ctx, db, "DATABASE()")
+	case "sqlserver":
+		tables, err = introspectInformationSchema(ctx, db, "SCHEMA_NAME()")
+	case "sqlite3":
+		tables, err = introspectSqlite(ctx, db)
+	default:
+		return nil, fmt.Errorf("ksqlgen: unsupported dialect: '%s'", dialect.DriverName())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tableNames) == 0 {
+		return tables, nil
+	}
+
+	tablesByName := map[string]TableSchema{}
+	for _, table := range tables {
+		tablesByName[table.Name] = table
+	}
+
+	filtered := make([]TableSchema, 0, len(tableNames))
+	for _, name := range tableNames {
+		table, found := tablesByName[name]
+		if !found {
+			return nil, fmt.Errorf("ksqlgen: table '%s' was not found on the database", name)
+		}
+		filtered = append(filtered, table)
+	}
+
+	return filtered, nil
+}
+
+func introspectInformationSchema(ctx context.Context, db ksql.Provider, schemaExpr string) ([]TableSchema, error) {
+	// All columns are aliased because MySQL 8 returns
+	// the information_schema columns in upper case:
+	var columns []struct {
+		TableName  string `ksql:"table_name"`
+		ColumnName string `ksql:"column_name"`
+		DataType   string `ksql:"data_type"`
+		IsNullable string `ksql:"is_nullable"`
+	}
+	err := db.Query(ctx, &columns, `
+		SELECT
+			c.table_name AS table_name,
+			c.column_name AS column_name,
+			c.data_type AS data_type,
+			c.is_nullable AS is_nullable
+		FROM information_schema.columns c
+		JOIN information_schema.tables t
+			ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = `+schemaExpr+` AND t.table_type = 'BASE TABLE'
+		ORDER BY c.table_name, c.ordinal_position
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("ksqlgen: error reading columns: %w", err)
+	}
+
+	var keys []struct {
+		TableName  string `ksql:"table_name"`
+		ColumnName string `ksql:"column_name"`
+	}
+	err = db.Query(ctx, &keys, `
+		SELECT
+			kcu.table_name AS table_name,
+			kcu.column_name AS column_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON kcu.constraint_name = tc.constraint_name
+			AND kcu.table_schema = tc.table_schema
+			AND kcu.table_name = tc.table_name
+		WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = `+schemaExpr+`
+		ORDER BY kcu.table_name, kcu.ordinal_position
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("ksqlgen: error reading primary keys: %w", err)
+	}
+
+	var tables []TableSchema
+	tableIdx := map[string]int{}
+	for _, c := range columns {
+		idx, found := tableIdx[c.TableName]
+		if !found {
+			idx = len(tables)
+			tableIdx[c.TableName] = idx
+			tables = append(tables, TableSchema{Name: c.TableName})
+		}
+
+		tables[idx].Columns = append(tables[idx].Columns, Column{
+			Name:     c.ColumnName,
+			Type:     strings.ToLower(c.DataType),
+			Nullable: strings.EqualFold(c.IsNullable, "YES"),
+		})
+	}
+
+	for _, k := range keys {
+		idx, found := tableIdx[k.TableName]
+		if !found {
+			continue
+		}
+		tables[idx].IDColumns = append(tables[idx].IDColumns, k.ColumnName)
+	}
+
+	return tables, nil
+}
+
+func introspectSqlite(ctx context.Context, db ksql.Provider) ([]TableSchema, error) {
+	var tableNames []struct {
+		Name string `ksql:"name"`
+	}
+	err := db.Query(ctx, &tableNames, `
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("ksqlgen: error reading tables: %w", err)
+	}
+
+	tables := make([]TableSchema, 0, len(tableNames))
+	for _, t := range tableNames {
+		var columns []struct {
+			Name    string `ksql:"name"`
+			Type    string `ksql:"type"`
+			NotNull bool   `ksql:"notnull"`
+
+			// PK is the position of the column on the primary key
+			// starting from 1, or 0 if it is not part of the key.
+			PK int `ksql:"pk"`
+		}
+		err := db.Query(ctx, &columns, `SELECT name, type, "notnull", pk FROM pragma_table_info(?) ORDER BY cid`, t.Name)
+		if err != nil {
+			return nil, fmt.Errorf("ksqlgen: error reading columns of table '%s': %w", t.Name, err)
+		}
+
+		table := TableSchema{Name: t.Name}
+		idColumns := make([]string, len(columns))
+		for _, c := range columns {
+			table.Columns = append(table.Columns, Column{
+				Name: c.Name,
+				Type: strings.ToLower(c.Type),
+				// On SQLite primary keys can be NULL unless
+				// explicitly declared as NOT NULL, but that is
+				// almost always a legacy bug, so we ignore it:
+				Nullable: !c.NotNull && c.PK == 0,
+			})
+
+			if c.PK > 0 && c.PK <= len(idColumns) {
+				idColumns[c.PK-1] = c.Name
+			}
+		}
+
+		for _, name := range idColumns {
+			if name != "" {
+				table.IDColumns = append(table.IDColumns, name)
+			}
+		}
+
+		tables = append(tables, table)
+	}
+
+	return tables, nil
+}
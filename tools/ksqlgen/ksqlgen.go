@@ -0,0 +1,199 @@
+// Package ksqlgen generates Go structs with `ksql` tags and the matching
+// ksql.Table declarations from the schema of a live database, which
+// is useful for bootstrapping projects with large existing schemas.
+//
+// It can be used as a command line tool:
+//
+//	go install github.com/vingarcia/ksql/tools/ksqlgen/cmd/ksqlgen@latest
+//	ksqlgen -dialect postgres -conn "$DATABASE_URL" -pkg models -out models/models.go
+//
+// Or as a library by calling Introspect and then Generate
+// with any ksql.Provider, e.g. from inside a `go generate` script.
+package ksqlgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Generate writes to w a formatted Go source file declaring
+// one struct and one ksql.Table for each of the input tables.
+//
+// Nullable columns are mapped to pointer types and tables
+// with composite primary keys have all their ID columns
+// informed to ksql.NewTable. Tables with no primary key
+// have no ksql.Table declared, since KSQL wouldn't be
+// able to Patch or Delete their records.
+func Generate(w io.Writer, pkgName string, tables []TableSchema) error {
+	var body bytes.Buffer
+	imports := map[string]bool{}
+	for _, table := range tables {
+		if len(table.Columns) == 0 {
+			return fmt.Errorf("ksqlgen: table '%s' has no columns", table.Name)
+		}
+
+		structName := goName(table.Name)
+
+		if len(table.IDColumns) == 0 {
+			fmt.Fprintf(&body, "// (The table `%s` has no primary key so no ksql.Table was generated for it)\n\n", table.Name)
+		} else {
+			imports["github.com/vingarcia/ksql"] = true
+
+			quotedIDs := make([]string, 0, len(table.IDColumns))
+			for _, id := range table.IDColumns {
+				quotedIDs = append(quotedIDs, fmt.Sprintf("%q", id))
+			}
+
+			fmt.Fprintf(&body, "// %sTable is the ksql.Table for the `%s` table\n", structName, table.Name)
+			fmt.Fprintf(&body, "var %sTable = ksql.NewTable(%q, %s)\n\n", structName, table.Name, strings.Join(quotedIDs, ", "))
+		}
+
+		fmt.Fprintf(&body, "// %s represents a row of the `%s` table\n", structName, table.Name)
+		fmt.Fprintf(&body, "type %s struct {\n", structName)
+		fieldNames := map[string]bool{}
+		for _, column := range table.Columns {
+			fieldName := goName(column.Name)
+			for fieldNames[fieldName] {
+				fieldName += "_"
+			}
+			fieldNames[fieldName] = true
+
+			goType, importPath := goType(column)
+			if importPath != "" {
+				imports[importPath] = true
+			}
+
+			fmt.Fprintf(&body, "\t%s %s `ksql:\"%s\"`\n", fieldName, goType, column.Name)
+		}
+		fmt.Fprintf(&body, "}\n\n")
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by ksqlgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", pkgName)
+	if len(imports) > 0 {
+		fmt.Fprintf(&src, "import (\n")
+		for _, path := range []string{"encoding/json", "time"} {
+			if imports[path] {
+				fmt.Fprintf(&src, "\t%q\n", path)
+			}
+		}
+		if imports["github.com/vingarcia/ksql"] {
+			fmt.Fprintf(&src, "\n\t%q\n", "github.com/vingarcia/ksql")
+		}
+		fmt.Fprintf(&src, ")\n\n")
+	}
+	src.Write(body.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("ksqlgen: error formatting generated code: %w", err)
+	}
+
+	_, err = w.Write(formatted)
+	return err
+}
+
+var typeParamsRegex = regexp.MustCompile(`\(.*\)`)
+
+// goType returns the Go type for the column as
+// well as the import path it needs if any.
+func goType(column Column) (goType string, importPath string) {
+	dbType := strings.TrimSpace(typeParamsRegex.ReplaceAllString(column.Type, ""))
+
+	switch dbType {
+	case "smallint", "integer", "int", "int2", "int4", "mediumint", "tinyint", "serial", "smallserial":
+		goType = "int"
+	case "bigint", "int8", "bigserial":
+		goType = "int64"
+	case "boolean", "bool", "bit":
+		goType = "bool"
+	case "real", "float4":
+		goType = "float32"
+	case "double precision", "double", "float", "float8", "numeric", "decimal", "money", "smallmoney":
+		goType = "float64"
+	case "date", "datetime", "datetime2", "smalldatetime", "datetimeoffset",
+		"timestamp", "timestamptz", "timestamp with time zone", "timestamp without time zone":
+		goType = "time.Time"
+		importPath = "time"
+	case "bytea", "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary", "image":
+		return "[]byte", ""
+	case "json", "jsonb":
+		return "json.RawMessage", "encoding/json"
+	case "char", "varchar", "character", "character varying", "text", "tinytext", "mediumtext", "longtext",
+		"nchar", "nvarchar", "ntext", "uuid", "enum", "citext", "xml":
+		goType = "string"
+	default:
+		goType = sqliteAffinityType(dbType)
+		if goType == "" {
+			return "interface{}", ""
+		}
+	}
+
+	if column.Nullable {
+		goType = "*" + goType
+	}
+
+	return goType, importPath
+}
+
+// sqliteAffinityType implements the rules SQLite uses for
+// deciding the type affinity of columns with arbitrary types,
+// see: https://www.sqlite.org/datatype3.html#determination_of_column_affinity
+func sqliteAffinityType(dbType string) string {
+	switch {
+	case strings.Contains(dbType, "int"):
+		return "int64"
+	case strings.Contains(dbType, "char"),
+		strings.Contains(dbType, "clob"),
+		strings.Contains(dbType, "text"):
+		return "string"
+	case strings.Contains(dbType, "real"),
+		strings.Contains(dbType, "floa"),
+		strings.Contains(dbType, "doub"):
+		return "float64"
+	}
+
+	return ""
+}
+
+// commonInitialisms are written in upper case on Go names,
+// following the style used by golint
+var commonInitialisms = map[string]bool{
+	"api": true, "cpu": true, "css": true, "dns": true, "html": true,
+	"http": true, "https": true, "id": true, "ip": true, "json": true,
+	"sql": true, "ssh": true, "tcp": true, "tls": true, "ttl": true,
+	"uid": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// goName converts names like `user_posts` into exported Go names like `UserPosts`
+func goName(dbName string) string {
+	words := strings.FieldsFunc(dbName, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var name strings.Builder
+	for _, word := range words {
+		lower := strings.ToLower(word)
+		if commonInitialisms[lower] {
+			name.WriteString(strings.ToUpper(lower))
+			continue
+		}
+
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		name.WriteString(string(runes))
+	}
+
+	result := name.String()
+	if result == "" || unicode.IsDigit([]rune(result)[0]) {
+		result = "X" + result
+	}
+
+	return result
+}
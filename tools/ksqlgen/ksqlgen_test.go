@@ -0,0 +1,253 @@
+package ksqlgen
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/vingarcia/ksql"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/ksqltest"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestIntrospect(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should read tables from the information_schema", func(t *testing.T) {
+		var queries []string
+		db := ksql.Mock{
+			QueryFn: func(ctx context.Context, records interface{}, query string, params ...interface{}) error {
+				queries = append(queries, query)
+				if strings.Contains(query, "information_schema.columns") {
+					return ksqltest.FillSliceWith(records, []map[string]interface{}{
+						{"table_name": "team_members", "column_name": "team_id", "data_type": "integer", "is_nullable": "NO"},
+						{"table_name": "team_members", "column_name": "user_id", "data_type": "integer", "is_nullable": "NO"},
+						{"table_name": "users", "column_name": "id", "data_type": "BIGINT", "is_nullable": "NO"},
+						{"table_name": "users", "column_name": "name", "data_type": "varchar", "is_nullable": "YES"},
+					})
+				}
+				return ksqltest.FillSliceWith(records, []map[string]interface{}{
+					{"table_name": "team_members", "column_name": "user_id"},
+					{"table_name": "team_members", "column_name": "team_id"},
+					{"table_name": "users", "column_name": "id"},
+				})
+			},
+		}
+
+		tables, err := Introspect(ctx, db, sqldialect.PostgresDialect{})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, tables, []TableSchema{
+			{
+				Name: "team_members",
+				Columns: []Column{
+					{Name: "team_id", Type: "integer"},
+					{Name: "user_id", Type: "integer"},
+				},
+				IDColumns: []string{"user_id", "team_id"},
+			},
+			{
+				Name: "users",
+				Columns: []Column{
+					{Name: "id", Type: "bigint"},
+					{Name: "name", Type: "varchar", Nullable: true},
+				},
+				IDColumns: []string{"id"},
+			},
+		})
+		tt.AssertEqual(t, len(queries), 2)
+		tt.AssertContains(t, queries[0], "current_schema()")
+	})
+
+	t.Run("should read tables from sqlite", func(t *testing.T) {
+		db := ksql.Mock{
+			QueryFn: func(ctx context.Context, records interface{}, query string, params ...interface{}) error {
+				if strings.Contains(query, "sqlite_master") {
+					return ksqltest.FillSliceWith(records, []map[string]interface{}{
+						{"name": "team_members"},
+					})
+				}
+
+				tt.AssertEqual(t, params, []interface{}{"team_members"})
+				return ksqltest.FillSliceWith(records, []map[string]interface{}{
+					{"name": "team_id", "type": "INTEGER", "notnull": false, "pk": 2},
+					{"name": "user_id", "type": "INTEGER", "notnull": false, "pk": 1},
+					{"name": "role", "type": "TEXT", "notnull": false, "pk": 0},
+				})
+			},
+		}
+
+		tables, err := Introspect(ctx, db, sqldialect.Sqlite3Dialect{})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, tables, []TableSchema{
+			{
+				Name: "team_members",
+				Columns: []Column{
+					{Name: "team_id", Type: "integer"},
+					{Name: "user_id", Type: "integer"},
+					{Name: "role", Type: "text", Nullable: true},
+				},
+				IDColumns: []string{"user_id", "team_id"},
+			},
+		})
+	})
+
+	t.Run("should read the tables of a sqlite database", func(t *testing.T) {
+		// A single connection is used so all the
+		// queries see the same in memory database:
+		db, err := ksqlite.New(ctx, ":memory:", ksql.Config{MaxOpenConns: 1})
+		tt.AssertNoErr(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(ctx, `CREATE TABLE users (
+			id INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			age INTEGER
+		)`)
+		tt.AssertNoErr(t, err)
+
+		_, err = db.Exec(ctx, `CREATE TABLE team_members (
+			team_id INTEGER,
+			user_id INTEGER,
+			role TEXT,
+			PRIMARY KEY (user_id, team_id)
+		)`)
+		tt.AssertNoErr(t, err)
+
+		tables, err := Introspect(ctx, db, sqldialect.Sqlite3Dialect{})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, tables, []TableSchema{
+			{
+				Name: "team_members",
+				Columns: []Column{
+					{Name: "team_id", Type: "integer"},
+					{Name: "user_id", Type: "integer"},
+					{Name: "role", Type: "text", Nullable: true},
+				},
+				IDColumns: []string{"user_id", "team_id"},
+			},
+			{
+				Name: "users",
+				Columns: []Column{
+					{Name: "id", Type: "integer"},
+					{Name: "name", Type: "text"},
+					{Name: "age", Type: "integer", Nullable: true},
+				},
+				IDColumns: []string{"id"},
+			},
+		})
+	})
+
+	t.Run("should filter the tables by name", func(t *testing.T) {
+		db := ksql.Mock{
+			QueryFn: func(ctx context.Context, records interface{}, query string, params ...interface{}) error {
+				return ksqltest.FillSliceWith(records, []map[string]interface{}{
+					{"name": "posts"},
+					{"name": "users"},
+				})
+			},
+		}
+
+		tables, err := Introspect(ctx, db, sqldialect.Sqlite3Dialect{}, "users")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(tables), 1)
+		tt.AssertEqual(t, tables[0].Name, "users")
+
+		_, err = Introspect(ctx, db, sqldialect.Sqlite3Dialect{}, "notATable")
+		tt.AssertErrContains(t, err, "ksqlgen", "notATable")
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("should generate structs and tables", func(t *testing.T) {
+		var out bytes.Buffer
+		err := Generate(&out, "models", []TableSchema{
+			{
+				Name: "user_posts",
+				Columns: []Column{
+					{Name: "id", Type: "bigint"},
+					{Name: "user_id", Type: "integer"},
+					{Name: "title", Type: "character varying(255)"},
+					{Name: "metadata", Type: "jsonb", Nullable: true},
+					{Name: "created_at", Type: "timestamp with time zone", Nullable: true},
+				},
+				IDColumns: []string{"id"},
+			},
+			{
+				Name: "team_members",
+				Columns: []Column{
+					{Name: "team_id", Type: "integer"},
+					{Name: "user_id", Type: "integer"},
+				},
+				IDColumns: []string{"team_id", "user_id"},
+			},
+			{
+				Name: "audit_logs",
+				Columns: []Column{
+					{Name: "message", Type: "text"},
+				},
+			},
+		})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, out.String(), `// Code generated by ksqlgen. DO NOT EDIT.
+
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/vingarcia/ksql"
+)
+
+// UserPostsTable is the ksql.Table for the `+"`user_posts`"+` table
+var UserPostsTable = ksql.NewTable("user_posts", "id")
+
+// UserPosts represents a row of the `+"`user_posts`"+` table
+type UserPosts struct {
+	ID        int64           `+"`ksql:\"id\"`"+`
+	UserID    int             `+"`ksql:\"user_id\"`"+`
+	Title     string          `+"`ksql:\"title\"`"+`
+	Metadata  json.RawMessage `+"`ksql:\"metadata\"`"+`
+	CreatedAt *time.Time      `+"`ksql:\"created_at\"`"+`
+}
+
+// TeamMembersTable is the ksql.Table for the `+"`team_members`"+` table
+var TeamMembersTable = ksql.NewTable("team_members", "team_id", "user_id")
+
+// TeamMembers represents a row of the `+"`team_members`"+` table
+type TeamMembers struct {
+	TeamID int `+"`ksql:\"team_id\"`"+`
+	UserID int `+"`ksql:\"user_id\"`"+`
+}
+
+// (The table `+"`audit_logs`"+` has no primary key so no ksql.Table was generated for it)
+
+// AuditLogs represents a row of the `+"`audit_logs`"+` table
+type AuditLogs struct {
+	Message string `+"`ksql:\"message\"`"+`
+}
+`)
+	})
+
+	t.Run("should report tables without columns", func(t *testing.T) {
+		err := Generate(&bytes.Buffer{}, "models", []TableSchema{{Name: "empty"}})
+		tt.AssertErrContains(t, err, "ksqlgen", "empty")
+	})
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"users":       "Users",
+		"user_id":     "UserID",
+		"api-keys":    "APIKeys",
+		"createdAt":   "CreatedAt",
+		"2fa_secrets": "X2faSecrets",
+		"url":         "URL",
+	}
+	for dbName, expected := range tests {
+		tt.AssertEqual(t, goName(dbName), expected)
+	}
+}
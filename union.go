@@ -0,0 +1,137 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vingarcia/ksql/internal/sqlparse"
+	"github.com/vingarcia/ksql/internal/structs"
+)
+
+// checkUnionColumns validates the columns of queries with a top-level UNION
+// against the attributes of the struct, since the database only reports
+// the column names of the first branch of the UNION, so branches selecting
+// different columns usually surface as confusing scan errors.
+//
+// It only runs if Config.ValidateUnionColumns is set, and queries
+// without a top-level UNION and nested structs are not checked.
+func checkUnionColumns(
+	ctx context.Context,
+	config Config,
+	query string,
+	rows Rows,
+	structType reflect.Type,
+) error {
+	if !config.ValidateUnionColumns {
+		return nil
+	}
+
+	// Most queries have no UNION, so we avoid parsing them:
+	if !strings.Contains(strings.ToUpper(query), "UNION") {
+		return nil
+	}
+
+	branches := sqlparse.UnionBranches(query)
+	if len(branches) < 2 {
+		return nil
+	}
+
+	info, err := structs.GetTagInfo(structType)
+	if err != nil || info.IsNestedStruct {
+		return err
+	}
+
+	err = checkUnionBranches(ctx, config, info, branches)
+	if err != nil {
+		return err
+	}
+
+	colNames, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("KSQL: unable to read columns from returned rows: %w", err)
+	}
+
+	var unknownColumns []string
+	foundAttrs := map[int]bool{}
+	for _, name := range colNames {
+		fieldInfo := fieldForColumn(ctx, config, info, name)
+		if !fieldInfo.Valid {
+			unknownColumns = append(unknownColumns, name)
+			continue
+		}
+		foundAttrs[fieldInfo.Index] = true
+	}
+	if len(unknownColumns) == 0 {
+		return nil
+	}
+
+	var missingColumns []string
+	for i := 0; i < structType.NumField(); i++ {
+		fieldInfo := info.ByIndex(i)
+		if fieldInfo.Valid && !foundAttrs[i] {
+			missingColumns = append(missingColumns, fieldInfo.ColumnName)
+		}
+	}
+
+	return fmt.Errorf(
+		"KSQL: the columns returned by the UNION query don't match the attributes of %v:"+
+			" columns missing on the struct: [%s], columns missing on the result: [%s]"+
+			" (note that the column names of a UNION are the ones selected by its first branch)",
+		structType, strings.Join(unknownColumns, ", "), strings.Join(missingColumns, ", "),
+	)
+}
+
+// checkUnionBranches compares the columns selected by each branch with the
+// ones selected by the first branch, skipping the branches whose columns
+// can't be known statically, e.g. when they use `*`.
+func checkUnionBranches(
+	ctx context.Context,
+	config Config,
+	info structs.StructInfo,
+	branches []string,
+) error {
+	firstColumns, ok := sqlparse.SelectColumns(branches[0])
+	if !ok {
+		return nil
+	}
+
+	for i, branch := range branches[1:] {
+		branchNumber := i + 2
+
+		columns, ok := sqlparse.SelectColumns(branch)
+		if !ok {
+			continue
+		}
+
+		if len(columns) != len(firstColumns) {
+			return fmt.Errorf(
+				"KSQL: the branches of the UNION query select different numbers of columns:"+
+					" the first branch selects %d columns and the branch number %d selects %d",
+				len(firstColumns), branchNumber, len(columns),
+			)
+		}
+
+		for j, column := range columns {
+			if column.Name == "" || column.Name == firstColumns[j].Name {
+				continue
+			}
+
+			for k, firstColumn := range firstColumns {
+				if k == j || firstColumn.Name != column.Name || !fieldForColumn(ctx, config, info, column.Name).Valid {
+					continue
+				}
+
+				return fmt.Errorf(
+					"KSQL: the column `%s` is selected at position %d by the first branch of the UNION query"+
+						" but at position %d by the branch number %d, since the branches are combined by position"+
+						" its values would be scanned into the wrong attributes",
+					column.Name, k+1, j+1, branchNumber,
+				)
+			}
+		}
+	}
+
+	return nil
+}
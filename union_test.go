@@ -0,0 +1,111 @@
+package ksql
+
+import (
+	"context"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestUnionColumnsValidation(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+
+	newMockDB := func(query *string, columns []string) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			config: Config{
+				ValidateUnionColumns: true,
+			},
+			db: mockDBAdapter{
+				QueryContextFn: func(ctx context.Context, q string, params ...interface{}) (Rows, error) {
+					*query = q
+					hasNext := true
+					return mockRows{
+						NextFn: func() bool {
+							next := hasNext
+							hasNext = false
+							return next
+						},
+						ColumnsFn: func() ([]string, error) {
+							return columns, nil
+						},
+						ScanFn: func(args ...interface{}) error {
+							return nil
+						},
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("should accept UNION queries whose columns match the struct", func(t *testing.T) {
+		var query string
+		c := newMockDB(&query, []string{"id", "name"})
+
+		var users []User
+		err := c.Query(ctx, &users, "SELECT id, name FROM users UNION ALL SELECT id, title FROM posts")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(users), 1)
+	})
+
+	t.Run("should report the columns missing on the struct and on the result", func(t *testing.T) {
+		var query string
+		c := newMockDB(&query, []string{"id", "full_name"})
+
+		var users []User
+		err := c.Query(ctx, &users, "SELECT * FROM users UNION SELECT * FROM admins")
+		tt.AssertErrContains(t, err, "KSQL", "UNION",
+			"missing on the struct: [full_name]",
+			"missing on the result: [name]",
+		)
+	})
+
+	t.Run("should report branches selecting a different number of columns", func(t *testing.T) {
+		var query string
+		c := newMockDB(&query, []string{"id", "name"})
+
+		var user User
+		err := c.QueryOne(ctx, &user, "SELECT id, name FROM users UNION SELECT id FROM admins")
+		tt.AssertErrContains(t, err, "KSQL", "UNION", "first branch selects 2", "branch number 2 selects 1")
+	})
+
+	t.Run("should report columns selected at different positions", func(t *testing.T) {
+		var query string
+		c := newMockDB(&query, []string{"id", "name"})
+
+		err := c.QueryChunks(ctx, ChunkParser{
+			Query:     "SELECT id, name FROM users UNION SELECT id, 'x' AS name FROM posts UNION SELECT name, id FROM admins",
+			ChunkSize: 10,
+			ForEachChunk: func(users []User) error {
+				return nil
+			},
+		})
+		tt.AssertErrContains(t, err, "KSQL", "`name`", "position 2", "position 1", "branch number 3")
+	})
+
+	t.Run("should not check queries without UNION", func(t *testing.T) {
+		var query string
+		c := newMockDB(&query, []string{"id", "full_name"})
+
+		var users []User
+		err := c.Query(ctx, &users, "SELECT id, name AS full_name FROM users")
+		tt.AssertNoErr(t, err)
+	})
+
+	t.Run("should not check UNION queries if the option is disabled", func(t *testing.T) {
+		var query string
+		c := newMockDB(&query, []string{"id", "full_name"})
+		c.config.ValidateUnionColumns = false
+
+		var users []User
+		err := c.Query(ctx, &users, "SELECT * FROM users UNION SELECT * FROM admins")
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(users), 1)
+	})
+}
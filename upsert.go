@@ -0,0 +1,440 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/vingarcia/ksql/internal/modifiers"
+	"github.com/vingarcia/ksql/internal/structs"
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// UpsertConfig allows the user to customize what Upsert
+// does when a record with the same IDs already exists.
+//
+// Records that don't exist yet are always inserted.
+type UpsertConfig struct {
+	// UpdateColumns restricts which columns are updated when a record
+	// with the same IDs already exists, by default all non-ID columns
+	// are updated.
+	UpdateColumns []string
+
+	// DoNothingOnMatch makes Upsert leave existing records unchanged,
+	// so only the records that don't exist yet are inserted.
+	DoNothingOnMatch bool
+
+	// UseMerge makes Upsert use the MERGE statement instead
+	// of `INSERT ... ON CONFLICT` on Postgres, which requires Postgres 15+.
+	//
+	// SQL Server always uses MERGE since it has no ON CONFLICT clause.
+	UseMerge bool
+
+	// BatchSize is only used by UpsertMany and limits the number of
+	// records written by each statement, by default the batches are as
+	// large as the limit of params of the database allows.
+	BatchSize int
+}
+
+// Upsert inserts the record on the database or, if a record with the
+// same IDs already exists, updates the existing record instead.
+//
+// All the ID columns of the table must be set on the input record,
+// since they are used for checking if the record already exists.
+//
+// The SQL used depends on the dialect: Postgres and SQLite use
+// `INSERT ... ON CONFLICT`, MySQL uses `INSERT ... ON DUPLICATE KEY UPDATE`
+// and SQL Server uses the MERGE statement.
+//
+// Note that on SQL Server inserting explicit values on IDENTITY columns
+// is only possible if IDENTITY_INSERT is enabled for the table.
+//
+// The insert and update hooks of the record are not called,
+// since it is not known in advance which of them would apply.
+func (c DB) Upsert(
+	ctx context.Context,
+	table Table,
+	record interface{},
+	config ...UpsertConfig,
+) error {
+	_, err := c.upsert(ctx, "Upsert", table, record, config...)
+	return err
+}
+
+// UpsertWithRowsAffected works like Upsert but also returns the number of
+// rows written, which is 1 if the record was inserted or updated and 0 if
+// it was left unchanged, e.g. when using the UpsertConfig.DoNothingOnMatch
+// option, which is useful for reporting accurate metrics on ingestion
+// pipelines without running extra queries.
+//
+// Note that MySQL only counts updated rows if some of the values changed,
+// while the other databases count them even if the new values are equal
+// to the existing ones.
+func (c DB) UpsertWithRowsAffected(
+	ctx context.Context,
+	table Table,
+	record interface{},
+	config ...UpsertConfig,
+) (rowsAffected int64, err error) {
+	result, err := c.upsert(ctx, "UpsertWithRowsAffected", table, record, config...)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err = result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("KSQL: unable to get the number of rows affected by the upsert: %w", err)
+	}
+
+	// MySQL reports 2 affected rows when an existing record is updated:
+	if rowsAffected > 1 {
+		rowsAffected = 1
+	}
+
+	return rowsAffected, nil
+}
+
+func (c DB) upsert(
+	ctx context.Context,
+	method string,
+	table Table,
+	record interface{},
+	config ...UpsertConfig,
+) (result Result, err error) {
+	var cfg UpsertConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	v := reflect.ValueOf(record)
+	t := v.Type()
+	tStruct := t
+	if t.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, fmt.Errorf("KSQL: expected a valid pointer to struct as argument but received a nil pointer: %v", record)
+		}
+		tStruct = t.Elem()
+	}
+	if tStruct.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("KSQL: expected record to be a struct or a pointer to struct, but got: %T", record)
+	}
+
+	table, err = c.resolveTable(ctx, table, record)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := table.validate(); err != nil {
+		return nil, fmt.Errorf("can't upsert in ksql.Table: %w", err)
+	}
+
+	info, err := structs.GetTagInfo(tStruct)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsNestedStruct {
+		return nil, fmt.Errorf("KSQL: can't upsert nested structs, got: %T", record)
+	}
+
+	recordMap, err := structs.StructToMap(record)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateIfAllIdsArePresent(table.idColumns, recordMap)
+	if err != nil {
+		return nil, err
+	}
+
+	query, params, err := buildUpsertQuery(ctx, c.dialect, table, tStruct, info, recordMap, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctxLog(ctx, query, params, time.Now(), nil, &err)
+	defer c.recordStats(query, nil, &err)
+
+	result, err = c.execContext(ctx, query, params...)
+	if err != nil {
+		return nil, OpError{
+			Method: method,
+			Table:  table.name,
+			Query:  query,
+			Err:    err,
+		}
+	}
+
+	return result, nil
+}
+
+func buildUpsertQuery(
+	ctx context.Context,
+	dialect sqldialect.Provider,
+	table Table,
+	structType reflect.Type,
+	info structs.StructInfo,
+	recordMap map[string]interface{},
+	cfg UpsertConfig,
+) (query string, params []interface{}, err error) {
+	insertColumns, updateColumns, err := upsertColumns(table, structType, info, []map[string]interface{}{recordMap}, cfg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	placeholders := map[string]string{}
+	for i, col := range insertColumns {
+		params = append(params, upsertParam(ctx, dialect, info, "Upsert", col, recordMap[col]))
+		placeholders[col] = dialect.Placeholder(i)
+	}
+	valuesQuery := buildUpsertValuesQuery(insertColumns, placeholders)
+
+	switch dialect.DriverName() {
+	case "postgres":
+		if cfg.UseMerge {
+			query = buildMergeQuery(dialect, table, "", insertColumns, updateColumns, placeholders)
+		} else {
+			query = buildOnConflictQuery(dialect, table, insertColumns, updateColumns, valuesQuery, "EXCLUDED")
+		}
+	case "sqlite3":
+		query = buildOnConflictQuery(dialect, table, insertColumns, updateColumns, valuesQuery, "excluded")
+	case "mysql":
+		query = buildOnDuplicateKeyQuery(dialect, table, insertColumns, updateColumns, valuesQuery)
+	case "sqlserver":
+		query = buildMergeQuery(dialect, table, " WITH (HOLDLOCK)", insertColumns, updateColumns, placeholders) + ";"
+	default:
+		return "", nil, fmt.Errorf("KSQL: Upsert is not supported for the `%s` dialect", dialect.DriverName())
+	}
+
+	return query, params, nil
+}
+
+// upsertColumns returns the columns inserted and updated by the upsert
+// of the input records, ordered by the struct fields so the generated
+// queries are deterministic.
+//
+// A column is inserted if it is set on any of the records.
+func upsertColumns(
+	table Table,
+	structType reflect.Type,
+	info structs.StructInfo,
+	recordMaps []map[string]interface{},
+	cfg UpsertConfig,
+) (insertColumns []string, updateColumns []string, err error) {
+	isID := map[string]bool{}
+	for _, id := range table.idColumns {
+		isID[id] = true
+	}
+
+	isInserted := map[string]bool{}
+	var defaultUpdateColumns []string
+	for i := 0; i < structType.NumField(); i++ {
+		fieldInfo := info.ByIndex(i)
+		if !fieldInfo.Valid || fieldInfo.Modifier.SkipOnInsert {
+			continue
+		}
+
+		col := fieldInfo.ColumnName
+		if !anyRecordHasColumn(recordMaps, col) {
+			continue
+		}
+		insertColumns = append(insertColumns, col)
+		isInserted[col] = true
+
+		if !isID[col] && !fieldInfo.Modifier.SkipOnUpdate {
+			defaultUpdateColumns = append(defaultUpdateColumns, col)
+		}
+	}
+
+	updateColumns = defaultUpdateColumns
+	if cfg.UpdateColumns != nil {
+		updateColumns = cfg.UpdateColumns
+		for _, col := range updateColumns {
+			if isID[col] {
+				return nil, nil, fmt.Errorf("KSQL: can't use the ID column `%s` as one of the UpsertConfig.UpdateColumns", col)
+			}
+			if !isInserted[col] {
+				return nil, nil, fmt.Errorf(
+					"KSQL: the UpsertConfig.UpdateColumns contains the column `%s` which is not being inserted by the input record",
+					col,
+				)
+			}
+		}
+	}
+	if cfg.DoNothingOnMatch {
+		updateColumns = nil
+	}
+
+	return insertColumns, updateColumns, nil
+}
+
+func anyRecordHasColumn(recordMaps []map[string]interface{}, col string) bool {
+	for _, recordMap := range recordMaps {
+		if _, found := recordMap[col]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// upsertParam wraps the value with the Value function
+// of the attribute modifier, if there is one
+func upsertParam(
+	ctx context.Context,
+	dialect sqldialect.Provider,
+	info structs.StructInfo,
+	method string,
+	col string,
+	value interface{},
+) interface{} {
+	valueFn := info.ByName(col).Modifier.Value
+	if valueFn == nil {
+		return value
+	}
+
+	return modifiers.AttrValueWrapper{
+		Ctx:     ctx,
+		Attr:    value,
+		ValueFn: valueFn,
+		OpInfo: ksqlmodifiers.OpInfo{
+			DriverName: dialect.DriverName(),
+			Method:     method,
+		},
+	}
+}
+
+// buildUpsertValuesQuery returns a row of the VALUES
+// clause, e.g. `($1, $2, $3)`
+func buildUpsertValuesQuery(columns []string, placeholders map[string]string) string {
+	values := make([]string, len(columns))
+	for i, col := range columns {
+		values[i] = placeholders[col]
+	}
+	return "(" + strings.Join(values, ", ") + ")"
+}
+
+func buildOnConflictQuery(
+	dialect sqldialect.Provider,
+	table Table,
+	insertColumns []string,
+	updateColumns []string,
+	valuesQuery string,
+	excludedTableName string,
+) string {
+	var escapedIDs []string
+	for _, id := range table.idColumns {
+		escapedIDs = append(escapedIDs, dialect.Escape(id))
+	}
+
+	onConflictAction := "DO NOTHING"
+	if len(updateColumns) > 0 {
+		var setQuery []string
+		for _, col := range updateColumns {
+			setQuery = append(setQuery, dialect.Escape(col)+" = "+excludedTableName+"."+dialect.Escape(col))
+		}
+		onConflictAction = "DO UPDATE SET " + strings.Join(setQuery, ", ")
+	}
+
+	return fmt.Sprintf(
+		"%s ON CONFLICT (%s) %s",
+		buildUpsertInsertQuery(dialect, table, insertColumns, valuesQuery),
+		strings.Join(escapedIDs, ", "),
+		onConflictAction,
+	)
+}
+
+func buildOnDuplicateKeyQuery(
+	dialect sqldialect.Provider,
+	table Table,
+	insertColumns []string,
+	updateColumns []string,
+	valuesQuery string,
+) string {
+	var setQuery []string
+	for _, col := range updateColumns {
+		setQuery = append(setQuery, dialect.Escape(col)+" = VALUES("+dialect.Escape(col)+")")
+	}
+
+	if len(setQuery) == 0 {
+		// Assigning a column to itself makes MySQL leave the existing record unchanged
+		// without ignoring other errors as `INSERT IGNORE` would:
+		idCol := dialect.Escape(table.idColumns[0])
+		setQuery = append(setQuery, idCol+" = "+idCol)
+	}
+
+	return fmt.Sprintf(
+		"%s ON DUPLICATE KEY UPDATE %s",
+		buildUpsertInsertQuery(dialect, table, insertColumns, valuesQuery),
+		strings.Join(setQuery, ", "),
+	)
+}
+
+// buildUpsertInsertQuery returns the INSERT part of the upsert
+// queries, `valuesQuery` contains one or more rows of values.
+func buildUpsertInsertQuery(
+	dialect sqldialect.Provider,
+	table Table,
+	insertColumns []string,
+	valuesQuery string,
+) string {
+	var escapedColumns []string
+	for _, col := range insertColumns {
+		escapedColumns = append(escapedColumns, dialect.Escape(col))
+	}
+
+	return fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES %s",
+		table.name,
+		strings.Join(escapedColumns, ", "),
+		valuesQuery,
+	)
+}
+
+// buildMergeQuery builds a MERGE statement that references the query params
+// directly instead of using a VALUES list as source, this way the database
+// can infer the type of each param from the column it is compared to or
+// assigned to.
+func buildMergeQuery(
+	dialect sqldialect.Provider,
+	table Table,
+	tableHints string,
+	insertColumns []string,
+	updateColumns []string,
+	placeholders map[string]string,
+) string {
+	var matchConditions []string
+	for _, id := range table.idColumns {
+		matchConditions = append(matchConditions, "target."+dialect.Escape(id)+" = "+placeholders[id])
+	}
+
+	var query strings.Builder
+	fmt.Fprintf(&query,
+		"MERGE INTO %s%s AS target USING (SELECT 1 AS one) AS source ON %s",
+		table.name,
+		tableHints,
+		strings.Join(matchConditions, " AND "),
+	)
+
+	if len(updateColumns) > 0 {
+		var setQuery []string
+		for _, col := range updateColumns {
+			setQuery = append(setQuery, dialect.Escape(col)+" = "+placeholders[col])
+		}
+		query.WriteString(" WHEN MATCHED THEN UPDATE SET " + strings.Join(setQuery, ", "))
+	}
+
+	var escapedColumns, values []string
+	for _, col := range insertColumns {
+		escapedColumns = append(escapedColumns, dialect.Escape(col))
+		values = append(values, placeholders[col])
+	}
+	fmt.Fprintf(&query,
+		" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)",
+		strings.Join(escapedColumns, ", "),
+		strings.Join(values, ", "),
+	)
+
+	return query.String()
+}
@@ -0,0 +1,325 @@
+package ksql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vingarcia/ksql/internal/structs"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+// maxParamsByDriver is the maximum number of params
+// each database accepts on a single statement
+var maxParamsByDriver = map[string]int{
+	"postgres":  65535,
+	"mysql":     65535,
+	"sqlite3":   32766,
+	"sqlserver": 2100,
+}
+
+// UpsertMany works like Upsert for a slice of records, e.g.:
+//
+//	err := db.UpsertMany(ctx, UsersTable, &users)
+//
+// The records are written using multi-row statements, i.e. `INSERT ... VALUES
+// (...), (...) ON CONFLICT ...` on Postgres and SQLite, `... ON DUPLICATE KEY
+// UPDATE` on MySQL and a MERGE with a VALUES source on SQL Server, split in
+// batches so each statement respects the limit of params of the database,
+// the Config.MaxParams option and the UpsertConfig.BatchSize option.
+//
+// If more than one statement is necessary they are written inside a
+// transaction, so either all records are written or none of them.
+//
+// Just like on Upsert the attributes that are nil are not written, so
+// the records are grouped by the attributes they set, and each group is
+// written with its own statements.
+//
+// The IDs of the records must be unique within the input slice, since Postgres
+// and SQL Server don't allow the same row to be updated twice by one statement.
+// The UpsertConfig.UseMerge option is ignored.
+//
+// Like on Upsert, the insert and update hooks of the records are not called.
+func (c DB) UpsertMany(
+	ctx context.Context,
+	table Table,
+	records interface{},
+	config ...UpsertConfig,
+) error {
+	var cfg UpsertConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	v := reflect.ValueOf(records)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return fmt.Errorf("KSQL: expected a valid pointer to slice as argument but received a nil pointer: %v", records)
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("KSQL: expected records to be a slice of structs or a pointer to one, but got: %T", records)
+	}
+	if v.Len() == 0 {
+		return nil
+	}
+
+	tStruct := v.Type().Elem()
+	if tStruct.Kind() == reflect.Ptr {
+		tStruct = tStruct.Elem()
+	}
+	if tStruct.Kind() != reflect.Struct {
+		return fmt.Errorf("KSQL: expected records to be a slice of structs or a pointer to one, but got: %T", records)
+	}
+
+	table, err := c.resolveTable(ctx, table, v.Index(0).Interface())
+	if err != nil {
+		return err
+	}
+
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("can't upsert in ksql.Table: %w", err)
+	}
+
+	info, err := structs.GetTagInfo(tStruct)
+	if err != nil {
+		return err
+	}
+	if info.IsNestedStruct {
+		return fmt.Errorf("KSQL: can't upsert nested structs, got: %T", records)
+	}
+
+	recordMaps := make([]map[string]interface{}, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		record := v.Index(i)
+		if record.Kind() == reflect.Ptr && record.IsNil() {
+			return fmt.Errorf("KSQL: the record at index %d of the UpsertMany input is a nil pointer", i)
+		}
+
+		recordMaps[i], err = structs.StructToMap(record.Interface())
+		if err != nil {
+			return err
+		}
+
+		err = validateIfAllIdsArePresent(table.idColumns, recordMaps[i])
+		if err != nil {
+			return fmt.Errorf("KSQL: invalid record at index %d of the UpsertMany input: %w", i, err)
+		}
+	}
+
+	var batches []upsertManyBatch
+	for _, group := range groupByColumns(recordMaps) {
+		insertColumns, updateColumns, err := upsertColumns(table, tStruct, info, group, cfg)
+		if err != nil {
+			return err
+		}
+
+		batchSize, err := c.upsertBatchSize(len(insertColumns), cfg)
+		if err != nil {
+			return err
+		}
+
+		for start := 0; start < len(group); start += batchSize {
+			end := start + batchSize
+			if end > len(group) {
+				end = len(group)
+			}
+
+			batches = append(batches, upsertManyBatch{
+				insertColumns: insertColumns,
+				updateColumns: updateColumns,
+				recordMaps:    group[start:end],
+			})
+		}
+	}
+
+	if len(batches) == 1 {
+		return c.upsertBatch(ctx, table, info, batches[0])
+	}
+
+	return c.Transaction(ctx, func(db Provider) error {
+		for _, batch := range batches {
+			err := db.(DB).upsertBatch(ctx, table, info, batch)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// upsertManyBatch contains the records written by a single
+// statement of UpsertMany, which all set the same columns
+type upsertManyBatch struct {
+	insertColumns []string
+	updateColumns []string
+	recordMaps    []map[string]interface{}
+}
+
+// groupByColumns groups the records by the set of columns present on
+// each of them, keeping the order in which each group first appears
+func groupByColumns(recordMaps []map[string]interface{}) [][]map[string]interface{} {
+	var groups [][]map[string]interface{}
+	groupIdx := map[string]int{}
+	for _, recordMap := range recordMaps {
+		columns := make([]string, 0, len(recordMap))
+		for col := range recordMap {
+			columns = append(columns, col)
+		}
+		sort.Strings(columns)
+		key := strings.Join(columns, ",")
+
+		idx, found := groupIdx[key]
+		if !found {
+			idx = len(groups)
+			groupIdx[key] = idx
+			groups = append(groups, nil)
+		}
+		groups[idx] = append(groups[idx], recordMap)
+	}
+	return groups
+}
+
+// upsertBatchSize returns the maximum number of records that can be
+// written on a single statement without exceeding the limits of params
+func (c DB) upsertBatchSize(numColumns int, cfg UpsertConfig) (int, error) {
+	maxParams := maxParamsByDriver[c.dialect.DriverName()]
+	if c.config.MaxParams > 0 && (maxParams == 0 || c.config.MaxParams < maxParams) {
+		maxParams = c.config.MaxParams
+	}
+
+	if maxParams == 0 {
+		if cfg.BatchSize > 0 {
+			return cfg.BatchSize, nil
+		}
+		return 1000, nil
+	}
+
+	batchSize := maxParams / numColumns
+	if batchSize == 0 {
+		return 0, fmt.Errorf(
+			"KSQL: can't upsert records with %d columns since the limit of params per statement is %d",
+			numColumns, maxParams,
+		)
+	}
+
+	if cfg.BatchSize > 0 && cfg.BatchSize < batchSize {
+		batchSize = cfg.BatchSize
+	}
+
+	return batchSize, nil
+}
+
+func (c DB) upsertBatch(
+	ctx context.Context,
+	table Table,
+	info structs.StructInfo,
+	batch upsertManyBatch,
+) (err error) {
+	query, params, err := buildUpsertManyQuery(ctx, c.dialect, table, info, batch.insertColumns, batch.updateColumns, batch.recordMaps)
+	if err != nil {
+		return err
+	}
+
+	defer ctxLog(ctx, query, params, time.Now(), nil, &err)
+	defer c.recordStats(query, nil, &err)
+
+	_, err = c.execContext(ctx, query, params...)
+	if err != nil {
+		return OpError{
+			Method: "UpsertMany",
+			Table:  table.name,
+			Query:  query,
+			Err:    err,
+		}
+	}
+
+	return nil
+}
+
+func buildUpsertManyQuery(
+	ctx context.Context,
+	dialect sqldialect.Provider,
+	table Table,
+	info structs.StructInfo,
+	insertColumns []string,
+	updateColumns []string,
+	recordMaps []map[string]interface{},
+) (query string, params []interface{}, err error) {
+	rows := make([]string, len(recordMaps))
+	for i, recordMap := range recordMaps {
+		placeholders := map[string]string{}
+		for _, col := range insertColumns {
+			placeholders[col] = dialect.Placeholder(len(params))
+			params = append(params, upsertParam(ctx, dialect, info, "UpsertMany", col, recordMap[col]))
+		}
+		rows[i] = buildUpsertValuesQuery(insertColumns, placeholders)
+	}
+	valuesQuery := strings.Join(rows, ", ")
+
+	switch dialect.DriverName() {
+	case "postgres":
+		query = buildOnConflictQuery(dialect, table, insertColumns, updateColumns, valuesQuery, "EXCLUDED")
+	case "sqlite3":
+		query = buildOnConflictQuery(dialect, table, insertColumns, updateColumns, valuesQuery, "excluded")
+	case "mysql":
+		query = buildOnDuplicateKeyQuery(dialect, table, insertColumns, updateColumns, valuesQuery)
+	case "sqlserver":
+		query = buildMergeManyQuery(dialect, table, insertColumns, updateColumns, valuesQuery)
+	default:
+		return "", nil, fmt.Errorf("KSQL: UpsertMany is not supported for the `%s` dialect", dialect.DriverName())
+	}
+
+	return query, params, nil
+}
+
+// buildMergeManyQuery builds a SQL Server MERGE statement
+// that uses the rows of `valuesQuery` as its source.
+func buildMergeManyQuery(
+	dialect sqldialect.Provider,
+	table Table,
+	insertColumns []string,
+	updateColumns []string,
+	valuesQuery string,
+) string {
+	escapedColumns := make([]string, len(insertColumns))
+	sourceColumns := make([]string, len(insertColumns))
+	for i, col := range insertColumns {
+		escapedColumns[i] = dialect.Escape(col)
+		sourceColumns[i] = "source." + dialect.Escape(col)
+	}
+
+	var matchConditions []string
+	for _, id := range table.idColumns {
+		matchConditions = append(matchConditions, "target."+dialect.Escape(id)+" = source."+dialect.Escape(id))
+	}
+
+	var query strings.Builder
+	fmt.Fprintf(&query,
+		"MERGE INTO %s WITH (HOLDLOCK) AS target USING (VALUES %s) AS source (%s) ON %s",
+		table.name,
+		valuesQuery,
+		strings.Join(escapedColumns, ", "),
+		strings.Join(matchConditions, " AND "),
+	)
+
+	if len(updateColumns) > 0 {
+		var setQuery []string
+		for _, col := range updateColumns {
+			setQuery = append(setQuery, dialect.Escape(col)+" = source."+dialect.Escape(col))
+		}
+		query.WriteString(" WHEN MATCHED THEN UPDATE SET " + strings.Join(setQuery, ", "))
+	}
+
+	fmt.Fprintf(&query,
+		" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s);",
+		strings.Join(escapedColumns, ", "),
+		strings.Join(sourceColumns, ", "),
+	)
+
+	return query.String()
+}
@@ -0,0 +1,332 @@
+package ksql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/vingarcia/ksql/internal/modifiers"
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/ksqlmodifiers"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestUpsertMany(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID        int    `ksql:"id"`
+		Name      string `ksql:"name"`
+		CreatedAt string `ksql:"created_at,skipUpdates"`
+	}
+	usersTable := NewTable("users")
+
+	users := []User{
+		{ID: 1, Name: "fakeName1", CreatedAt: "fakeDate1"},
+		{ID: 2, Name: "fakeName2", CreatedAt: "fakeDate2"},
+	}
+
+	tests := []struct {
+		desc        string
+		dialect     string
+		config      []UpsertConfig
+		expectQuery string
+	}{
+		{
+			desc:        "postgres",
+			dialect:     "postgres",
+			expectQuery: `INSERT INTO users ("id", "name", "created_at") VALUES ($1, $2, $3), ($4, $5, $6) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`,
+		},
+		{
+			desc:        "sqlite3",
+			dialect:     "sqlite3",
+			expectQuery: "INSERT INTO users (`id`, `name`, `created_at`) VALUES (?, ?, ?), (?, ?, ?) ON CONFLICT (`id`) DO UPDATE SET `name` = excluded.`name`",
+		},
+		{
+			desc:        "mysql",
+			dialect:     "mysql",
+			expectQuery: "INSERT INTO users (`id`, `name`, `created_at`) VALUES (?, ?, ?), (?, ?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+		},
+		{
+			desc:        "sqlserver",
+			dialect:     "sqlserver",
+			expectQuery: `MERGE INTO users WITH (HOLDLOCK) AS target USING (VALUES (@p1, @p2, @p3), (@p4, @p5, @p6)) AS source ([id], [name], [created_at]) ON target.[id] = source.[id] WHEN MATCHED THEN UPDATE SET [name] = source.[name] WHEN NOT MATCHED THEN INSERT ([id], [name], [created_at]) VALUES (source.[id], source.[name], source.[created_at]);`,
+		},
+		{
+			desc:        "postgres with DoNothingOnMatch",
+			dialect:     "postgres",
+			config:      []UpsertConfig{{DoNothingOnMatch: true}},
+			expectQuery: `INSERT INTO users ("id", "name", "created_at") VALUES ($1, $2, $3), ($4, $5, $6) ON CONFLICT ("id") DO NOTHING`,
+		},
+		{
+			desc:        "sqlserver with DoNothingOnMatch",
+			dialect:     "sqlserver",
+			config:      []UpsertConfig{{DoNothingOnMatch: true}},
+			expectQuery: `MERGE INTO users WITH (HOLDLOCK) AS target USING (VALUES (@p1, @p2, @p3), (@p4, @p5, @p6)) AS source ([id], [name], [created_at]) ON target.[id] = source.[id] WHEN NOT MATCHED THEN INSERT ([id], [name], [created_at]) VALUES (source.[id], source.[name], source.[created_at]);`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("should build the query correctly for "+test.desc, func(t *testing.T) {
+			var inputQuery string
+			var inputParams []interface{}
+			c := DB{
+				dialect: sqldialect.SupportedDialects[test.dialect],
+				db: mockDBAdapter{
+					ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+						inputQuery = query
+						inputParams = params
+						return mockResult{}, nil
+					},
+				},
+			}
+
+			err := c.UpsertMany(ctx, usersTable, &users, test.config...)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, inputQuery, test.expectQuery)
+			tt.AssertEqual(t, inputParams, []interface{}{1, "fakeName1", "fakeDate1", 2, "fakeName2", "fakeDate2"})
+		})
+	}
+
+	t.Run("should write records setting different attributes on separate statements", func(t *testing.T) {
+		type Post struct {
+			ID    int     `ksql:"id"`
+			Title *string `ksql:"title"`
+			Body  *string `ksql:"body"`
+		}
+		title := "fakeTitle"
+
+		var events []string
+		var inputParams [][]interface{}
+		adapter := mockDBAdapter{
+			ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+				events = append(events, query)
+				inputParams = append(inputParams, params)
+				return mockResult{}, nil
+			},
+		}
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockTxBeginner{
+				DBAdapter: adapter,
+				BeginTxFn: func(ctx context.Context) (Tx, error) {
+					events = append(events, "BEGIN")
+					return mockTx{
+						DBAdapter: adapter,
+						CommitFn: func(ctx context.Context) error {
+							events = append(events, "COMMIT")
+							return nil
+						},
+					}, nil
+				},
+			},
+		}
+
+		// The nil attributes must not overwrite the values
+		// already stored on the database, just like on Upsert:
+		err := c.UpsertMany(ctx, NewTable("posts"), []*Post{{ID: 1, Title: &title}, {ID: 2}, {ID: 3, Title: &title}})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, events, []string{
+			"BEGIN",
+			`INSERT INTO posts ("id", "title") VALUES ($1, $2), ($3, $4) ON CONFLICT ("id") DO UPDATE SET "title" = EXCLUDED."title"`,
+			`INSERT INTO posts ("id") VALUES ($1) ON CONFLICT ("id") DO NOTHING`,
+			"COMMIT",
+		})
+		tt.AssertEqual(t, inputParams, [][]interface{}{
+			{1, "fakeTitle", 3, "fakeTitle"},
+			{2},
+		})
+	})
+
+	t.Run("should report UpsertMany as the method to the attribute modifiers", func(t *testing.T) {
+		modifiers.RegisterAttrModifier("fakeUpsertManyMethod", ksqlmodifiers.AttrModifier{
+			Value: func(ctx context.Context, opInfo ksqlmodifiers.OpInfo, inputValue interface{}) (interface{}, error) {
+				return opInfo.Method, nil
+			},
+		})
+
+		type Post struct {
+			ID    int    `ksql:"id"`
+			Title string `ksql:"title,fakeUpsertManyMethod"`
+		}
+
+		var inputParams []interface{}
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					inputParams = params
+					return mockResult{}, nil
+				},
+			},
+		}
+
+		err := c.UpsertMany(ctx, NewTable("posts"), []Post{{ID: 1, Title: "fakeTitle"}})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, len(inputParams), 2)
+
+		method, err := inputParams[1].(driver.Valuer).Value()
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, method, "UpsertMany")
+	})
+
+	t.Run("should split the records in batches inside a transaction", func(t *testing.T) {
+		tests := []struct {
+			desc             string
+			config           Config
+			upsertConfig     UpsertConfig
+			expectBatchSizes []int
+		}{
+			{
+				desc:             "using Config.MaxParams",
+				config:           Config{MaxParams: 10},
+				expectBatchSizes: []int{9, 9, 9, 6},
+			},
+			{
+				desc:             "using UpsertConfig.BatchSize",
+				upsertConfig:     UpsertConfig{BatchSize: 2},
+				expectBatchSizes: []int{6, 6, 6, 6, 6, 3},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				var events []string
+				var batchSizes []int
+				adapter := mockDBAdapter{
+					ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+						events = append(events, "EXEC")
+						batchSizes = append(batchSizes, len(params))
+						return mockResult{}, nil
+					},
+				}
+
+				c := DB{
+					dialect: sqldialect.SupportedDialects["postgres"],
+					db: mockTxBeginner{
+						DBAdapter: adapter,
+						BeginTxFn: func(ctx context.Context) (Tx, error) {
+							events = append(events, "BEGIN")
+							return mockTx{
+								DBAdapter: adapter,
+								CommitFn: func(ctx context.Context) error {
+									events = append(events, "COMMIT")
+									return nil
+								},
+								RollbackFn: func(ctx context.Context) error {
+									events = append(events, "ROLLBACK")
+									return nil
+								},
+							}, nil
+						},
+					},
+					config: test.config,
+				}
+
+				records := make([]User, 11)
+				for i := range records {
+					records[i] = User{ID: i + 1, Name: "fakeName", CreatedAt: "fakeDate"}
+				}
+
+				err := c.UpsertMany(ctx, usersTable, records, test.upsertConfig)
+				tt.AssertNoErr(t, err)
+
+				// Each record has 3 params:
+				tt.AssertEqual(t, batchSizes, test.expectBatchSizes)
+				tt.AssertEqual(t, events[0], "BEGIN")
+				tt.AssertEqual(t, events[len(events)-1], "COMMIT")
+			})
+		}
+	})
+
+	t.Run("should do nothing for empty slices", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					t.Fatal("no query should be sent")
+					return nil, nil
+				},
+			},
+		}
+
+		err := c.UpsertMany(ctx, usersTable, []User{})
+		tt.AssertNoErr(t, err)
+	})
+
+	t.Run("should report errors correctly", func(t *testing.T) {
+		tests := []struct {
+			desc               string
+			records            interface{}
+			config             Config
+			expectErrToContain []string
+			expectErrIs        error
+		}{
+			{
+				desc:               "missing IDs",
+				records:            []User{{ID: 1}, {Name: "fakeName"}},
+				expectErrIs:        ErrRecordMissingIDs,
+				expectErrToContain: []string{"KSQL", "index 1"},
+			},
+			{
+				desc:               "not a slice",
+				records:            &User{ID: 1},
+				expectErrToContain: []string{"KSQL", "slice"},
+			},
+			{
+				desc:               "not a slice of structs",
+				records:            []int{1, 2},
+				expectErrToContain: []string{"KSQL", "slice of structs"},
+			},
+			{
+				desc:               "nil records",
+				records:            []*User{{ID: 1}, nil},
+				expectErrToContain: []string{"KSQL", "index 1", "nil pointer"},
+			},
+			{
+				desc:               "records with more columns than the params limit",
+				records:            []User{{ID: 1, Name: "fakeName"}},
+				config:             Config{MaxParams: 2},
+				expectErrToContain: []string{"KSQL", "3 columns", "limit", "2"},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				c := DB{
+					dialect: sqldialect.SupportedDialects["postgres"],
+					db: mockDBAdapter{
+						ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+							return mockResult{}, nil
+						},
+					},
+					config: test.config,
+				}
+
+				err := c.UpsertMany(ctx, usersTable, test.records)
+				if test.expectErrIs != nil {
+					tt.AssertEqual(t, errors.Is(err, test.expectErrIs), true)
+				}
+				tt.AssertErrContains(t, err, test.expectErrToContain...)
+			})
+		}
+	})
+
+	t.Run("should wrap adapter errors with OpError", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					return nil, errors.New("fakeErrMsg")
+				},
+			},
+		}
+
+		err := c.UpsertMany(ctx, usersTable, users)
+		var opErr OpError
+		tt.AssertEqual(t, errors.As(err, &opErr), true)
+		tt.AssertEqual(t, opErr.Method, "UpsertMany")
+		tt.AssertEqual(t, opErr.Table, "users")
+	})
+}
@@ -0,0 +1,257 @@
+package ksql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tt "github.com/vingarcia/ksql/internal/testtools"
+	"github.com/vingarcia/ksql/sqldialect"
+)
+
+func TestUpsert(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID        int    `ksql:"id"`
+		Name      string `ksql:"name"`
+		Age       int    `ksql:"age"`
+		CreatedAt string `ksql:"created_at,skipUpdates"`
+	}
+	usersTable := NewTable("users")
+
+	user := User{ID: 42, Name: "fakeName", Age: 20, CreatedAt: "fakeDate"}
+
+	tests := []struct {
+		desc        string
+		dialect     string
+		config      []UpsertConfig
+		expectQuery string
+	}{
+		{
+			desc:        "postgres",
+			dialect:     "postgres",
+			expectQuery: `INSERT INTO users ("id", "name", "age", "created_at") VALUES ($1, $2, $3, $4) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name", "age" = EXCLUDED."age"`,
+		},
+		{
+			desc:        "postgres with UseMerge",
+			dialect:     "postgres",
+			config:      []UpsertConfig{{UseMerge: true}},
+			expectQuery: `MERGE INTO users AS target USING (SELECT 1 AS one) AS source ON target."id" = $1 WHEN MATCHED THEN UPDATE SET "name" = $2, "age" = $3 WHEN NOT MATCHED THEN INSERT ("id", "name", "age", "created_at") VALUES ($1, $2, $3, $4)`,
+		},
+		{
+			desc:        "sqlite3",
+			dialect:     "sqlite3",
+			expectQuery: "INSERT INTO users (`id`, `name`, `age`, `created_at`) VALUES (?, ?, ?, ?) ON CONFLICT (`id`) DO UPDATE SET `name` = excluded.`name`, `age` = excluded.`age`",
+		},
+		{
+			desc:        "mysql",
+			dialect:     "mysql",
+			expectQuery: "INSERT INTO users (`id`, `name`, `age`, `created_at`) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `age` = VALUES(`age`)",
+		},
+		{
+			desc:        "sqlserver",
+			dialect:     "sqlserver",
+			expectQuery: `MERGE INTO users WITH (HOLDLOCK) AS target USING (SELECT 1 AS one) AS source ON target.[id] = @p1 WHEN MATCHED THEN UPDATE SET [name] = @p2, [age] = @p3 WHEN NOT MATCHED THEN INSERT ([id], [name], [age], [created_at]) VALUES (@p1, @p2, @p3, @p4);`,
+		},
+		{
+			desc:        "postgres with UpdateColumns",
+			dialect:     "postgres",
+			config:      []UpsertConfig{{UpdateColumns: []string{"age"}}},
+			expectQuery: `INSERT INTO users ("id", "name", "age", "created_at") VALUES ($1, $2, $3, $4) ON CONFLICT ("id") DO UPDATE SET "age" = EXCLUDED."age"`,
+		},
+		{
+			desc:        "postgres with DoNothingOnMatch",
+			dialect:     "postgres",
+			config:      []UpsertConfig{{DoNothingOnMatch: true}},
+			expectQuery: `INSERT INTO users ("id", "name", "age", "created_at") VALUES ($1, $2, $3, $4) ON CONFLICT ("id") DO NOTHING`,
+		},
+		{
+			desc:        "mysql with DoNothingOnMatch",
+			dialect:     "mysql",
+			config:      []UpsertConfig{{DoNothingOnMatch: true}},
+			expectQuery: "INSERT INTO users (`id`, `name`, `age`, `created_at`) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE `id` = `id`",
+		},
+		{
+			desc:        "sqlserver with DoNothingOnMatch",
+			dialect:     "sqlserver",
+			config:      []UpsertConfig{{DoNothingOnMatch: true}},
+			expectQuery: `MERGE INTO users WITH (HOLDLOCK) AS target USING (SELECT 1 AS one) AS source ON target.[id] = @p1 WHEN NOT MATCHED THEN INSERT ([id], [name], [age], [created_at]) VALUES (@p1, @p2, @p3, @p4);`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("should build the query correctly for "+test.desc, func(t *testing.T) {
+			var inputQuery string
+			var inputParams []interface{}
+			c := DB{
+				dialect: sqldialect.SupportedDialects[test.dialect],
+				db: mockDBAdapter{
+					ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+						inputQuery = query
+						inputParams = params
+						return mockResult{}, nil
+					},
+				},
+			}
+
+			err := c.Upsert(ctx, usersTable, &user, test.config...)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, inputQuery, test.expectQuery)
+			tt.AssertEqual(t, inputParams, []interface{}{42, "fakeName", 20, "fakeDate"})
+		})
+	}
+
+	t.Run("should report errors correctly", func(t *testing.T) {
+		tests := []struct {
+			desc               string
+			record             interface{}
+			config             []UpsertConfig
+			expectErrToContain []string
+			expectErrIs        error
+		}{
+			{
+				desc:        "missing IDs",
+				record:      &User{Name: "fakeName"},
+				expectErrIs: ErrRecordMissingIDs,
+			},
+			{
+				desc:               "not a struct",
+				record:             42,
+				expectErrToContain: []string{"KSQL", "struct", "int"},
+			},
+			{
+				desc:               "ID column on UpdateColumns",
+				record:             &user,
+				config:             []UpsertConfig{{UpdateColumns: []string{"id"}}},
+				expectErrToContain: []string{"KSQL", "ID column", "id"},
+			},
+			{
+				desc:               "unknown column on UpdateColumns",
+				record:             &user,
+				config:             []UpsertConfig{{UpdateColumns: []string{"not_a_column"}}},
+				expectErrToContain: []string{"KSQL", "not_a_column"},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				c := DB{
+					dialect: sqldialect.SupportedDialects["postgres"],
+					db: mockDBAdapter{
+						ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+							return mockResult{}, nil
+						},
+					},
+				}
+
+				err := c.Upsert(ctx, usersTable, test.record, test.config...)
+				if test.expectErrIs != nil {
+					tt.AssertEqual(t, errors.Is(err, test.expectErrIs), true)
+				}
+				tt.AssertErrContains(t, err, test.expectErrToContain...)
+			})
+		}
+	})
+
+	t.Run("should wrap adapter errors with OpError", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					return nil, errors.New("fakeErrMsg")
+				},
+			},
+		}
+
+		err := c.Upsert(ctx, usersTable, &user)
+		var opErr OpError
+		tt.AssertEqual(t, errors.As(err, &opErr), true)
+		tt.AssertEqual(t, opErr.Method, "Upsert")
+		tt.AssertEqual(t, opErr.Table, "users")
+	})
+}
+
+func TestUpsertWithRowsAffected(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		ID   int    `ksql:"id"`
+		Name string `ksql:"name"`
+	}
+	usersTable := NewTable("users")
+
+	newDB := func(driver string, rowsAffected int64, err error) DB {
+		return DB{
+			dialect: sqldialect.SupportedDialects[driver],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					return mockResult{
+						RowsAffectedFn: func() (int64, error) {
+							return rowsAffected, err
+						},
+					}, nil
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		desc               string
+		dialect            string
+		rowsAffected       int64
+		expectRowsAffected int64
+	}{
+		{
+			desc:               "inserted or updated records",
+			dialect:            "postgres",
+			rowsAffected:       1,
+			expectRowsAffected: 1,
+		},
+		{
+			desc:               "unchanged records",
+			dialect:            "postgres",
+			rowsAffected:       0,
+			expectRowsAffected: 0,
+		},
+		{
+			desc:               "records updated on mysql",
+			dialect:            "mysql",
+			rowsAffected:       2,
+			expectRowsAffected: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("should report "+test.desc, func(t *testing.T) {
+			c := newDB(test.dialect, test.rowsAffected, nil)
+
+			n, err := c.UpsertWithRowsAffected(ctx, usersTable, &User{ID: 42, Name: "fakeName"})
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, n, test.expectRowsAffected)
+		})
+	}
+
+	t.Run("should report errors from RowsAffected", func(t *testing.T) {
+		c := newDB("postgres", 0, errors.New("fakeErrMsg"))
+
+		_, err := c.UpsertWithRowsAffected(ctx, usersTable, &User{ID: 42, Name: "fakeName"})
+		tt.AssertErrContains(t, err, "rows affected", "fakeErrMsg")
+	})
+
+	t.Run("should wrap adapter errors with OpError", func(t *testing.T) {
+		c := DB{
+			dialect: sqldialect.SupportedDialects["postgres"],
+			db: mockDBAdapter{
+				ExecContextFn: func(ctx context.Context, query string, params ...interface{}) (Result, error) {
+					return nil, errors.New("fakeErrMsg")
+				},
+			},
+		}
+
+		_, err := c.UpsertWithRowsAffected(ctx, usersTable, &User{ID: 42, Name: "fakeName"})
+		var opErr OpError
+		tt.AssertEqual(t, errors.As(err, &opErr), true)
+		tt.AssertEqual(t, opErr.Method, "UpsertWithRowsAffected")
+	})
+}